@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"time"
@@ -37,13 +38,15 @@ var ARCHIVE_PROCESS_STARTED = "Archive process started"
 type ApiHandler struct {
 	limitDefault   int32
 	eventRepo      events.EventRepo
+	eventBus       events.EventBus
 	illRepo        ill_db.IllRepo
 	tenantResolver *tenant.TenantResolver
 }
 
-func NewApiHandler(eventRepo events.EventRepo, illRepo ill_db.IllRepo, tenantResolver *tenant.TenantResolver, limitDefault int32) ApiHandler {
+func NewApiHandler(eventRepo events.EventRepo, eventBus events.EventBus, illRepo ill_db.IllRepo, tenantResolver *tenant.TenantResolver, limitDefault int32) ApiHandler {
 	return ApiHandler{
 		eventRepo:      eventRepo,
+		eventBus:       eventBus,
 		illRepo:        illRepo,
 		tenantResolver: tenantResolver,
 		limitDefault:   limitDefault,
@@ -156,6 +159,31 @@ func (a *ApiHandler) GetIllTransactionsIdEvents(w http.ResponseWriter, r *http.R
 	WriteJsonResponse(w, resp)
 }
 
+func (a *ApiHandler) GetIllTransactionsIdTimeline(w http.ResponseWriter, r *http.Request, id string, params oapi.GetIllTransactionsIdTimelineParams) {
+	ctx := common.CreateExtCtxWithArgs(r.Context(), &common.LoggerArgs{
+		Other: map[string]string{"method": "GetIllTransactionsIdTimeline", "id": id},
+	})
+	if events.IsSyntheticID(id) {
+		AddBadRequestError(ctx, w, errors.New("synthetic IDs are not allowed for timeline lookup"))
+		return
+	}
+
+	tran, err := a.getIllTranFromParams(ctx, w, r, params.RequesterSymbol, nil, &id)
+	if err != nil {
+		return
+	}
+	if tran == nil {
+		AddNotFoundError(w)
+		return
+	}
+	eventList, _, err := a.eventRepo.GetIllTransactionEvents(ctx, tran.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		AddInternalError(ctx, w, err)
+		return
+	}
+	WriteJsonResponse(w, ToApiTimeline(eventList))
+}
+
 func (a *ApiHandler) illTransactionEventsResponse(ctx common.ExtendedContext, id string) (oapi.Events, error) {
 	resp := oapi.Events{Items: make([]oapi.Event, 0)}
 	eventList, fullCount, err := a.eventRepo.GetIllTransactionEvents(ctx, id)
@@ -626,6 +654,248 @@ func (a *ApiHandler) PostArchiveIllTransactions(w http.ResponseWriter, r *http.R
 	})
 }
 
+func (a *ApiHandler) GetStuckEvents(w http.ResponseWriter, r *http.Request, params oapi.GetStuckEventsParams) {
+	ctx := common.CreateExtCtxWithArgs(r.Context(), &common.LoggerArgs{
+		Other: map[string]string{"method": "GetStuckEvents"},
+	})
+	dbparams := events.ListStuckEventsParams{
+		Limit:  a.limitDefault,
+		Offset: 0,
+		ExpiredBefore: pgtype.Timestamp{
+			Time:  time.Now(),
+			Valid: true,
+		},
+	}
+	if params.Limit != nil {
+		dbparams.Limit = *params.Limit
+	}
+	if params.Offset != nil {
+		dbparams.Offset = *params.Offset
+	}
+	rows, count, err := a.eventRepo.ListStuckEvents(ctx, dbparams)
+	if err != nil {
+		AddInternalError(ctx, w, err)
+		return
+	}
+	var resp oapi.StuckEvents
+	resp.Items = make([]oapi.StuckEvent, 0)
+	for _, row := range rows {
+		resp.Items = append(resp.Items, toApiStuckEvent(row))
+	}
+	resp.About = CollectAboutData(count, dbparams.Offset, dbparams.Limit, r)
+	WriteJsonResponse(w, resp)
+}
+
+func (a *ApiHandler) PostStuckEventsIdRedrive(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := common.CreateExtCtxWithArgs(r.Context(), &common.LoggerArgs{
+		Other: map[string]string{"method": "PostStuckEventsIdRedrive", "id": id},
+	})
+	event, err := a.eventBus.RequeueTask(id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			AddNotFoundError(w)
+		} else if errors.Is(err, events.ErrTaskNotStuck) {
+			AddBadRequestError(ctx, w, err)
+		} else {
+			AddInternalError(ctx, w, err)
+		}
+		return
+	}
+	ctx.Logger().Info("re-driving stuck TASK event", "eventName", event.EventName)
+	WriteJsonResponse(w, ToApiEvent(event, event.IllTransactionID, toPatronRequestId(event)))
+}
+
+func toApiStuckEvent(row events.ListStuckEventsRow) oapi.StuckEvent {
+	stuck := oapi.StuckEvent{
+		Event:      ToApiEvent(row.Event, row.Event.IllTransactionID, toPatronRequestId(row.Event)),
+		Attempts:   row.LeaseAttempts,
+		LeaseOwner: toString(row.LeaseOwner),
+	}
+	if row.LeaseExpiresAt.Valid {
+		stuck.LeaseExpiresAt = &row.LeaseExpiresAt.Time
+	}
+	return stuck
+}
+
+func (a *ApiHandler) GetFailedEvents(w http.ResponseWriter, r *http.Request, params oapi.GetFailedEventsParams) {
+	ctx := common.CreateExtCtxWithArgs(r.Context(), &common.LoggerArgs{
+		Other: map[string]string{"method": "GetFailedEvents"},
+	})
+	dbparams, err := getFailedEventsFilter(params.EventName, (*string)(params.Domain), params.Owner, params.MinAge, params.MaxAge)
+	if err != nil {
+		AddBadRequestError(ctx, w, err)
+		return
+	}
+	dbparams.Limit = a.limitDefault
+	if params.Limit != nil {
+		dbparams.Limit = *params.Limit
+	}
+	if params.Offset != nil {
+		dbparams.Offset = *params.Offset
+	}
+	rows, count, err := a.eventRepo.ListFailedEvents(ctx, dbparams)
+	if err != nil {
+		AddInternalError(ctx, w, err)
+		return
+	}
+	var resp oapi.FailedEvents
+	resp.Items = make([]oapi.FailedEvent, 0)
+	for _, row := range rows {
+		resp.Items = append(resp.Items, oapi.FailedEvent{
+			Event:  ToApiEvent(row.Event, row.Event.IllTransactionID, toPatronRequestId(row.Event)),
+			Domain: row.Domain,
+			Owner:  row.Owner,
+		})
+	}
+	resp.About = CollectAboutData(count, dbparams.Offset, dbparams.Limit, r)
+	WriteJsonResponse(w, resp)
+}
+
+func (a *ApiHandler) PostFailedEventsRerun(w http.ResponseWriter, r *http.Request, params oapi.PostFailedEventsRerunParams) {
+	ctx := common.CreateExtCtxWithArgs(r.Context(), &common.LoggerArgs{
+		Other: map[string]string{"method": "PostFailedEventsRerun"},
+	})
+	dbparams, err := getFailedEventsFilter(params.EventName, (*string)(params.Domain), params.Owner, params.MinAge, params.MaxAge)
+	if err != nil {
+		AddBadRequestError(ctx, w, err)
+		return
+	}
+	if params.EventName == nil && params.Domain == nil && params.Owner == nil && params.MinAge == nil && params.MaxAge == nil &&
+		(params.All == nil || !*params.All) {
+		AddBadRequestError(ctx, w, errors.New("at least one filter or all=true is required"))
+		return
+	}
+	note, err := readFailedEventNote(r)
+	if err != nil {
+		AddBadRequestError(ctx, w, err)
+		return
+	}
+	dbparams.Limit = a.limitDefault
+	if params.Limit != nil {
+		dbparams.Limit = *params.Limit
+	}
+	rows, _, err := a.eventRepo.ListFailedEvents(ctx, dbparams)
+	if err != nil {
+		AddInternalError(ctx, w, err)
+		return
+	}
+	var resp oapi.Events
+	resp.Items = make([]oapi.Event, 0)
+	for _, row := range rows {
+		rerun, err := a.eventBus.RerunTask(row.Event.ID, note)
+		if err != nil {
+			// the task may have been re-run or resolved since it was listed
+			if !errors.Is(err, events.ErrTaskNotFailed) {
+				ctx.Logger().Error("failed to re-run TASK event", "error", err, "eventId", row.Event.ID, "eventName", row.Event.EventName)
+			}
+			continue
+		}
+		resp.Items = append(resp.Items, ToApiEvent(rerun, rerun.IllTransactionID, toPatronRequestId(rerun)))
+	}
+	ctx.Logger().Info("re-running failed TASK events", "count", len(resp.Items))
+	resp.About = CollectAboutData(int64(len(resp.Items)), 0, int32(len(resp.Items)), r)
+	WriteJsonResponse(w, resp)
+}
+
+func (a *ApiHandler) PostFailedEventsIdRerun(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := common.CreateExtCtxWithArgs(r.Context(), &common.LoggerArgs{
+		Other: map[string]string{"method": "PostFailedEventsIdRerun", "id": id},
+	})
+	note, err := readFailedEventNote(r)
+	if err != nil {
+		AddBadRequestError(ctx, w, err)
+		return
+	}
+	event, err := a.eventBus.RerunTask(id, note)
+	if err != nil {
+		addFailedEventError(ctx, w, err)
+		return
+	}
+	ctx.Logger().Info("re-running failed TASK event", "eventName", event.EventName, "rerunId", event.ID)
+	WriteJsonResponse(w, ToApiEvent(event, event.IllTransactionID, toPatronRequestId(event)))
+}
+
+func (a *ApiHandler) PostFailedEventsIdResolve(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := common.CreateExtCtxWithArgs(r.Context(), &common.LoggerArgs{
+		Other: map[string]string{"method": "PostFailedEventsIdResolve", "id": id},
+	})
+	note, err := readFailedEventNote(r)
+	if err != nil {
+		AddBadRequestError(ctx, w, err)
+		return
+	}
+	event, err := a.eventBus.ResolveTask(id, note)
+	if err != nil {
+		addFailedEventError(ctx, w, err)
+		return
+	}
+	ctx.Logger().Info("resolved failed TASK event")
+	WriteJsonResponse(w, ToApiEvent(event, event.IllTransactionID, toPatronRequestId(event)))
+}
+
+func addFailedEventError(ctx common.ExtendedContext, w http.ResponseWriter, err error) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		AddNotFoundError(w)
+	} else if errors.Is(err, events.ErrTaskNotFailed) {
+		AddBadRequestError(ctx, w, err)
+	} else {
+		AddInternalError(ctx, w, err)
+	}
+}
+
+// readFailedEventNote returns the note of the optional request body
+func readFailedEventNote(r *http.Request) (string, error) {
+	var action oapi.FailedEventAction
+	err := json.NewDecoder(r.Body).Decode(&action)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return "", nil
+		}
+		return "", err
+	}
+	if action.Note == nil {
+		return "", nil
+	}
+	return *action.Note, nil
+}
+
+// getFailedEventsFilter converts the filters of the failed events endpoints, ages are durations such as "48h"
+func getFailedEventsFilter(eventName *string, domain *string, owner *string, minAge *string, maxAge *string) (events.ListFailedEventsParams, error) {
+	var dbparams events.ListFailedEventsParams
+	if eventName != nil {
+		dbparams.EventName = pgtype.Text{String: *eventName, Valid: true}
+	}
+	if domain != nil {
+		dbparams.Domain = pgtype.Text{String: *domain, Valid: true}
+	}
+	if owner != nil {
+		dbparams.Owner = pgtype.Text{String: *owner, Valid: true}
+	}
+	now := time.Now()
+	if minAge != nil {
+		age, err := time.ParseDuration(*minAge)
+		if err != nil {
+			return dbparams, fmt.Errorf("invalid min_age: %w", err)
+		}
+		dbparams.CreatedBefore = pgtype.Timestamp{Time: now.Add(-age), Valid: true}
+	}
+	if maxAge != nil {
+		age, err := time.ParseDuration(*maxAge)
+		if err != nil {
+			return dbparams, fmt.Errorf("invalid max_age: %w", err)
+		}
+		dbparams.CreatedAfter = pgtype.Timestamp{Time: now.Add(-age), Valid: true}
+	}
+	return dbparams, nil
+}
+
+func toPatronRequestId(event events.Event) *string {
+	if event.PatronRequestID == "" || events.IsSyntheticID(event.PatronRequestID) {
+		return nil
+	}
+	return &event.PatronRequestID
+}
+
 func ToApiEvent(event events.Event, illId string, prId *string) oapi.Event {
 	api := oapi.Event{
 		Id:               event.ID,
@@ -3,7 +3,9 @@ package api
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/indexdata/crosslink/broker/events"
 	"github.com/indexdata/crosslink/broker/oapi"
@@ -34,6 +36,19 @@ func TestGetIllTransactionsIdEventsRejectsSyntheticIDs(t *testing.T) {
 	}
 }
 
+func TestGetIllTransactionsIdTimelineRejectsSyntheticIDs(t *testing.T) {
+	for _, id := range []string{events.DEFAULT_ILL_TRANSACTION_ID, events.DEFAULT_PATRON_REQUEST_ID} {
+		t.Run(id, func(t *testing.T) {
+			h := ApiHandler{}
+			req := httptest.NewRequest(http.MethodGet, "/ill_transactions/"+id+"/timeline", nil)
+			rr := httptest.NewRecorder()
+			h.GetIllTransactionsIdTimeline(rr, req, id, oapi.GetIllTransactionsIdTimelineParams{})
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Contains(t, rr.Body.String(), "synthetic IDs are not allowed")
+		})
+	}
+}
+
 func TestDeleteIllTransactionsIdRejectsSyntheticID(t *testing.T) {
 	h := ApiHandler{}
 	req := httptest.NewRequest(http.MethodDelete, "/ill_transactions/"+events.DEFAULT_ILL_TRANSACTION_ID, nil)
@@ -44,3 +59,27 @@ func TestDeleteIllTransactionsIdRejectsSyntheticID(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
 	assert.Contains(t, rr.Body.String(), "synthetic IDs cannot be deleted")
 }
+
+func TestGetFailedEventsFilter(t *testing.T) {
+	name := "message-supplier"
+	minAge := "1h"
+	maxAge := "48h"
+	params, err := getFailedEventsFilter(&name, nil, nil, &minAge, &maxAge)
+	assert.NoError(t, err)
+	assert.Equal(t, name, params.EventName.String)
+	assert.False(t, params.Domain.Valid)
+	assert.False(t, params.Owner.Valid)
+	assert.Equal(t, 47*time.Hour, params.CreatedBefore.Time.Sub(params.CreatedAfter.Time))
+
+	badAge := "2x"
+	_, err = getFailedEventsFilter(nil, nil, nil, nil, &badAge)
+	assert.ErrorContains(t, err, "invalid max_age")
+}
+
+func TestPostFailedEventsIdRerunBadBody(t *testing.T) {
+	h := ApiHandler{}
+	req := httptest.NewRequest(http.MethodPost, "/failed_events/e1/rerun", strings.NewReader("{"))
+	rr := httptest.NewRecorder()
+	h.PostFailedEventsIdRerun(rr, req, "e1")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
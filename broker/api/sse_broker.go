@@ -3,8 +3,11 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/indexdata/crosslink/broker/common"
 	"github.com/indexdata/crosslink/broker/events"
@@ -12,22 +15,40 @@ import (
 	prservice "github.com/indexdata/crosslink/broker/patron_request/service"
 	"github.com/indexdata/crosslink/broker/tenant"
 	"github.com/indexdata/crosslink/iso18626"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	DEFAULT_SSE_HEARTBEAT_INTERVAL = 15 * time.Second
+	DEFAULT_SSE_REPLAY_WINDOW      = time.Hour
+	SSE_REPLAY_LIMIT               = 1000
+	SSE_CLIENT_BUFFER              = 64
+	SSE_CLEANUP_INTERVAL           = time.Minute
+	LastEventIdHeader              = "Last-Event-ID"
 )
 
 type SseBroker struct {
 	input          chan SseMessage
-	clients        map[string]map[chan string]bool
+	clients        map[string]map[chan SseMessage]bool
 	mu             sync.Mutex
 	ctx            common.ExtendedContext
 	tenantResolver *tenant.TenantResolver
+	eventRepo      events.EventRepo
+	// HeartbeatInterval is how often a comment is sent to keep idle connections open, zero disables heartbeats
+	HeartbeatInterval time.Duration
+	// ReplayWindow is how long messages are kept for Last-Event-ID replay, zero disables replay
+	ReplayWindow time.Duration
 }
 
-func NewSseBroker(ctx common.ExtendedContext, tenantResolver *tenant.TenantResolver) (broker *SseBroker) {
+func NewSseBroker(ctx common.ExtendedContext, tenantResolver *tenant.TenantResolver, eventRepo events.EventRepo) (broker *SseBroker) {
 	broker = &SseBroker{
-		input:          make(chan SseMessage),
-		clients:        make(map[string]map[chan string]bool),
-		ctx:            ctx,
-		tenantResolver: tenantResolver,
+		input:             make(chan SseMessage),
+		clients:           make(map[string]map[chan SseMessage]bool),
+		ctx:               ctx,
+		tenantResolver:    tenantResolver,
+		eventRepo:         eventRepo,
+		HeartbeatInterval: DEFAULT_SSE_HEARTBEAT_INTERVAL,
+		ReplayWindow:      DEFAULT_SSE_REPLAY_WINDOW,
 	}
 
 	// Start the single broadcaster goroutine
@@ -43,10 +64,11 @@ func (b *SseBroker) run() {
 		b.mu.Lock()
 		for clientChannel := range b.clients[event.receiver] {
 			select {
-			case clientChannel <- event.message:
+			case clientChannel <- event:
 				// Successfully sent
 			default:
-				// Client is slow or disconnected, remove them to prevent memory leak
+				// Client is slow or disconnected, remove them to prevent memory leak.
+				// The client can catch up with Last-Event-ID when it reconnects.
 				b.removeClient(event.receiver, clientChannel)
 			}
 		}
@@ -54,13 +76,15 @@ func (b *SseBroker) run() {
 	}
 }
 
-func (b *SseBroker) removeClient(receiver string, clientChannel chan string) {
+func (b *SseBroker) removeClient(receiver string, clientChannel chan SseMessage) {
 	clients := b.clients[receiver]
-	if clients != nil {
-		delete(clients, clientChannel)
-		if len(clients) == 0 {
-			delete(b.clients, receiver)
-		}
+	if _, ok := clients[clientChannel]; !ok {
+		// already removed
+		return
+	}
+	delete(clients, clientChannel)
+	if len(clients) == 0 {
+		delete(b.clients, receiver)
 	}
 	close(clientChannel)
 	b.ctx.Logger().Debug("Client channel closed and removed.")
@@ -95,14 +119,23 @@ func (b *SseBroker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("query parameter 'side' must be %s or %s", prservice.SideBorrowing, prservice.SideLending), http.StatusBadRequest)
 		return
 	}
-	clientChannel := make(chan string, 10)
+	var lastEventId int64
+	resume := r.Header.Get(LastEventIdHeader)
+	if resume != "" {
+		lastEventId, err = strconv.ParseInt(resume, 10, 64)
+		if err != nil || lastEventId < 0 {
+			http.Error(w, fmt.Sprintf("header %s must be a non-negative integer", LastEventIdHeader), http.StatusBadRequest)
+			return
+		}
+	}
+	clientChannel := make(chan SseMessage, SSE_CLIENT_BUFFER)
 	b.mu.Lock()
 	receiver := side + symbol
 	clients := b.clients[receiver]
 	if clients != nil {
 		clients[clientChannel] = true
 	} else {
-		b.clients[receiver] = map[chan string]bool{clientChannel: true}
+		b.clients[receiver] = map[chan SseMessage]bool{clientChannel: true}
 	}
 	b.mu.Unlock()
 	b.ctx.Logger().Debug(fmt.Sprintf("new client registered: %s", receiver))
@@ -125,6 +158,30 @@ func (b *SseBroker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The client is registered before replaying, so nothing is lost in between.
+	// Live messages already sent by the replay are skipped.
+	replayedId := lastEventId
+	if resume != "" {
+		messages, err := b.getReplayMessages(ectx, receiver, lastEventId)
+		if err != nil {
+			ectx.Logger().Error("failed to read SSE replay messages", "error", err, "receiver", receiver)
+		}
+		for _, message := range messages {
+			if err := writeSseMessage(w, message); err != nil {
+				return
+			}
+			replayedId = message.id
+		}
+	}
+	flusher.Flush()
+
+	var heartbeat <-chan time.Time
+	if b.HeartbeatInterval > 0 {
+		ticker := time.NewTicker(b.HeartbeatInterval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
 	// Context for connection status check
 	ctx := r.Context()
 	for {
@@ -133,8 +190,21 @@ func (b *SseBroker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			// Client connection closed
 			return
 
-		case event := <-clientChannel:
-			if _, err := fmt.Fprintf(w, "data: %s\n\n", event); err != nil {
+		case <-heartbeat:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case event, ok := <-clientChannel:
+			if !ok {
+				// Client was too slow and has been removed
+				return
+			}
+			if event.id > 0 && event.id <= replayedId {
+				continue
+			}
+			if err := writeSseMessage(w, event); err != nil {
 				return
 			}
 			flusher.Flush()
@@ -142,11 +212,86 @@ func (b *SseBroker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (b *SseBroker) getReplayMessages(ctx common.ExtendedContext, receiver string, lastEventId int64) ([]SseMessage, error) {
+	if b.eventRepo == nil || b.ReplayWindow <= 0 {
+		return nil, nil
+	}
+	rows, err := b.eventRepo.ListSseMessagesAfter(ctx, events.ListSseMessagesAfterParams{
+		Receiver:     receiver,
+		ID:           lastEventId,
+		Limit:        SSE_REPLAY_LIMIT,
+		CreatedAfter: pgtype.Timestamp{Time: time.Now().Add(-b.ReplayWindow), Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]SseMessage, 0, len(rows))
+	for _, row := range rows {
+		messages = append(messages, SseMessage{id: row.ID, receiver: row.Receiver, message: row.Message})
+	}
+	return messages, nil
+}
+
+func writeSseMessage(w io.Writer, message SseMessage) error {
+	var err error
+	if message.id > 0 {
+		_, err = fmt.Fprintf(w, "id: %d\ndata: %s\n\n", message.id, message.message)
+	} else {
+		_, err = fmt.Fprintf(w, "data: %s\n\n", message.message)
+	}
+	return err
+}
+
 func (b *SseBroker) SubmitMessageToChannels(message SseMessage) {
 	b.input <- message
 }
 
+// publish stores the message in the replay log and submits it to the clients of this instance.
+// Every instance observes the event and stores it for the same receiver, so the message
+// gets the same id on all instances and clients can resume on any of them.
+func (b *SseBroker) publish(ctx common.ExtendedContext, eventId string, receiver string, message string) {
+	sseMessage := SseMessage{receiver: receiver, message: message}
+	if b.eventRepo != nil && b.ReplayWindow > 0 {
+		saved, err := b.eventRepo.SaveSseMessage(ctx, events.SaveSseMessageParams{
+			EventID:   pgtype.Text{String: eventId, Valid: eventId != ""},
+			Receiver:  receiver,
+			Message:   message,
+			CreatedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+		})
+		if err != nil {
+			ctx.Logger().Error("failed to save SSE message", "error", err, "eventId", eventId)
+		} else {
+			sseMessage.id = saved.ID
+		}
+	}
+	b.SubmitMessageToChannels(sseMessage)
+}
+
+// RunCleanup deletes messages older than ReplayWindow from the replay log until the context is done.
+func (b *SseBroker) RunCleanup(ctx common.ExtendedContext) {
+	if b.eventRepo == nil || b.ReplayWindow <= 0 {
+		return
+	}
+	ticker := time.NewTicker(SSE_CLEANUP_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := b.eventRepo.DeleteSseMessagesBefore(ctx, pgtype.Timestamp{
+				Time:  time.Now().Add(-b.ReplayWindow),
+				Valid: true,
+			})
+			if err != nil {
+				ctx.Logger().Error("failed to delete expired SSE messages", "error", err)
+			}
+		}
+	}
+}
+
 type SseMessage struct {
+	id       int64
 	receiver string
 	message  string
 }
@@ -178,7 +323,7 @@ func (b *SseBroker) IncomingIsoMessage(ctx common.ExtendedContext, event events.
 			ctx.Logger().Error("failed to parse event data", "error", err)
 			return
 		}
-		b.SubmitMessageToChannels(SseMessage{receiver: string(side) + symbol, message: string(updateMessageBytes)})
+		b.publish(ctx, event.ID, string(side)+symbol, string(updateMessageBytes))
 	}
 }
 
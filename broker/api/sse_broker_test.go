@@ -0,0 +1,206 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/events"
+	"github.com/indexdata/crosslink/broker/tenant"
+	"github.com/indexdata/crosslink/iso18626"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+type sseEventRepo struct {
+	events.EventRepo
+	mu      sync.Mutex
+	saved   []events.SaveSseMessageParams
+	replay  []events.SseMessage
+	listed  events.ListSseMessagesAfterParams
+	deleted pgtype.Timestamp
+}
+
+func (r *sseEventRepo) SaveSseMessage(ctx common.ExtendedContext, params events.SaveSseMessageParams) (events.SseMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.saved = append(r.saved, params)
+	return events.SseMessage{ID: 100 + int64(len(r.saved)), EventID: params.EventID, Receiver: params.Receiver, Message: params.Message}, nil
+}
+
+func (r *sseEventRepo) ListSseMessagesAfter(ctx common.ExtendedContext, params events.ListSseMessagesAfterParams) ([]events.SseMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listed = params
+	return r.replay, nil
+}
+
+func (r *sseEventRepo) DeleteSseMessagesBefore(ctx common.ExtendedContext, createdAt pgtype.Timestamp) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleted = createdAt
+	return nil
+}
+
+func newTestSseBroker(repo events.EventRepo) *SseBroker {
+	ctx := common.CreateExtCtxWithArgs(context.Background(), nil)
+	return NewSseBroker(ctx, tenant.NewResolver(), repo)
+}
+
+// openSseStream connects to the broker and returns a channel of the non-empty lines received
+func openSseStream(t *testing.T, broker *SseBroker, lastEventId string) <-chan string {
+	t.Helper()
+	server := httptest.NewServer(broker)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		server.Close()
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/sse/events?side=borrowing&symbol=ISIL:REQ", nil)
+	assert.NoError(t, err)
+	if lastEventId != "" {
+		req.Header.Set(LastEventIdHeader, lastEventId)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	lines := make(chan string, 100)
+	go func() {
+		defer resp.Body.Close()
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if line := scanner.Text(); line != "" {
+				lines <- line
+			}
+		}
+	}()
+	return lines
+}
+
+func nextLine(t *testing.T, lines <-chan string) string {
+	t.Helper()
+	select {
+	case line := <-lines:
+		return line
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for SSE line")
+		return ""
+	}
+}
+
+func isoMessageEvent(id string) events.Event {
+	message := iso18626.NewISO18626Message()
+	message.SupplyingAgencyMessage = &iso18626.SupplyingAgencyMessage{
+		Header: iso18626.Header{
+			RequestingAgencyId: iso18626.TypeAgencyId{
+				AgencyIdType:  iso18626.TypeSchemeValuePair{Text: "ISIL"},
+				AgencyIdValue: "REQ",
+			},
+		},
+	}
+	return events.Event{
+		ID:        id,
+		EventName: events.EventNameMessageRequester,
+		ResultData: events.EventResult{
+			CommonEventData: events.CommonEventData{OutgoingMessage: message},
+		},
+	}
+}
+
+func TestSseReplayThenLive(t *testing.T) {
+	repo := &sseEventRepo{replay: []events.SseMessage{
+		{ID: 5, Receiver: "borrowingISIL:REQ", Message: `{"n":5}`},
+		{ID: 6, Receiver: "borrowingISIL:REQ", Message: `{"n":6}`},
+	}}
+	broker := newTestSseBroker(repo)
+	lines := openSseStream(t, broker, "4")
+
+	assert.Equal(t, "id: 5", nextLine(t, lines))
+	assert.Equal(t, `data: {"n":5}`, nextLine(t, lines))
+	assert.Equal(t, "id: 6", nextLine(t, lines))
+	assert.Equal(t, `data: {"n":6}`, nextLine(t, lines))
+
+	repo.mu.Lock()
+	assert.Equal(t, "borrowingISIL:REQ", repo.listed.Receiver)
+	assert.Equal(t, int64(4), repo.listed.ID)
+	assert.Equal(t, int32(SSE_REPLAY_LIMIT), repo.listed.Limit)
+	assert.WithinDuration(t, time.Now().Add(-DEFAULT_SSE_REPLAY_WINDOW), repo.listed.CreatedAfter.Time, 5*time.Second)
+	repo.mu.Unlock()
+
+	// already replayed, so skipped
+	broker.SubmitMessageToChannels(SseMessage{id: 6, receiver: "borrowingISIL:REQ", message: `{"n":6}`})
+	// other receiver
+	broker.SubmitMessageToChannels(SseMessage{id: 7, receiver: "lendingISIL:REQ", message: `{"n":7}`})
+	broker.SubmitMessageToChannels(SseMessage{id: 8, receiver: "borrowingISIL:REQ", message: `{"n":8}`})
+	assert.Equal(t, "id: 8", nextLine(t, lines))
+	assert.Equal(t, `data: {"n":8}`, nextLine(t, lines))
+}
+
+func TestSseIncomingIsoMessageIsStored(t *testing.T) {
+	repo := &sseEventRepo{}
+	broker := newTestSseBroker(repo)
+	lines := openSseStream(t, broker, "")
+
+	broker.IncomingIsoMessage(common.CreateExtCtxWithArgs(context.Background(), nil), isoMessageEvent("e-1"))
+
+	assert.Equal(t, "id: 101", nextLine(t, lines))
+	data := nextLine(t, lines)
+	assert.True(t, strings.HasPrefix(data, `data: {"event":"message-requester","data":`), data)
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	assert.Len(t, repo.saved, 1)
+	assert.Equal(t, pgtype.Text{String: "e-1", Valid: true}, repo.saved[0].EventID)
+	assert.Equal(t, "borrowingISIL:REQ", repo.saved[0].Receiver)
+	assert.Equal(t, strings.TrimPrefix(data, "data: "), repo.saved[0].Message)
+}
+
+func TestSseWithoutReplay(t *testing.T) {
+	repo := &sseEventRepo{}
+	broker := newTestSseBroker(repo)
+	broker.ReplayWindow = 0
+	lines := openSseStream(t, broker, "")
+
+	broker.IncomingIsoMessage(common.CreateExtCtxWithArgs(context.Background(), nil), isoMessageEvent("e-1"))
+
+	assert.True(t, strings.HasPrefix(nextLine(t, lines), "data: "))
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	assert.Empty(t, repo.saved)
+}
+
+func TestSseHeartbeat(t *testing.T) {
+	broker := newTestSseBroker(&sseEventRepo{})
+	broker.HeartbeatInterval = 10 * time.Millisecond
+	lines := openSseStream(t, broker, "")
+
+	assert.Equal(t, ": heartbeat", nextLine(t, lines))
+}
+
+func TestSseInvalidLastEventId(t *testing.T) {
+	broker := newTestSseBroker(&sseEventRepo{})
+	for _, value := range []string{"abc", "-1"} {
+		req := httptest.NewRequest(http.MethodGet, "/sse/events?side=borrowing&symbol=ISIL:REQ", nil)
+		req.Header.Set(LastEventIdHeader, value)
+		rr := httptest.NewRecorder()
+		broker.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, value)
+		assert.Equal(t, "header Last-Event-ID must be a non-negative integer\n", rr.Body.String())
+	}
+}
+
+func TestSseRemoveClientTwice(t *testing.T) {
+	broker := newTestSseBroker(nil)
+	clientChannel := make(chan SseMessage)
+	broker.clients["borrowingISIL:REQ"] = map[chan SseMessage]bool{clientChannel: true}
+	broker.mu.Lock()
+	broker.removeClient("borrowingISIL:REQ", clientChannel)
+	assert.NotPanics(t, func() { broker.removeClient("borrowingISIL:REQ", clientChannel) })
+	broker.mu.Unlock()
+	assert.Empty(t, broker.clients)
+}
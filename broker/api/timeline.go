@@ -0,0 +1,221 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/indexdata/crosslink/broker/events"
+	"github.com/indexdata/crosslink/broker/handler"
+	"github.com/indexdata/crosslink/broker/oapi"
+	"github.com/indexdata/crosslink/iso18626"
+)
+
+// ToApiTimeline merges the events and the messages and state changes recorded with them into one
+// chronologically ordered list. Entries derived from an event follow the event entry and have it as parent.
+func ToApiTimeline(eventList []events.Event) oapi.Timeline {
+	items := make([]oapi.TimelineEntry, 0, len(eventList))
+	var lastState *string
+	for _, event := range eventList {
+		items = append(items, newTimelineEntry(event, event.ID, oapi.TimelineEntryTypeEvent, eventSummary(event), eventErrorData(event)))
+		items[len(items)-1].ParentId = toString(event.ParentID)
+		items = append(items, messageEntries(event)...)
+		items = append(items, lmsEntries(event)...)
+		if entry, ok := stateTransitionEntry(event, lastState); ok {
+			lastState = event.ResultData.ActionResult.ToState
+			items = append(items, entry)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Timestamp.Before(items[j].Timestamp)
+	})
+	linkChildren(items)
+	resp := oapi.Timeline{Items: items}
+	resp.About.Count = int64(len(items))
+	return resp
+}
+
+func newTimelineEntry(event events.Event, id string, entryType oapi.TimelineEntryType, summary string, data any) oapi.TimelineEntry {
+	entry := oapi.TimelineEntry{
+		Id:          id,
+		Type:        entryType,
+		Timestamp:   event.Timestamp.Time,
+		EventId:     event.ID,
+		EventName:   string(event.EventName),
+		EventStatus: string(event.EventStatus),
+		ChildIds:    make([]string, 0),
+		Summary:     summary,
+		Data:        data,
+	}
+	if id != event.ID {
+		entry.ParentId = &event.ID
+	}
+	return entry
+}
+
+func eventSummary(event events.Event) string {
+	summary := string(event.EventType) + " " + string(event.EventName) + " " + string(event.EventStatus)
+	if event.EventData.Action != nil {
+		summary += ": " + string(*event.EventData.Action)
+	}
+	return summary
+}
+
+// eventErrorData returns the failure details of the event, nil if there are none
+func eventErrorData(event events.Event) any {
+	data := map[string]any{}
+	if event.ResultData.EventError != nil {
+		data["eventError"] = event.ResultData.EventError
+	}
+	if event.ResultData.Problem != nil {
+		data["problem"] = event.ResultData.Problem
+	}
+	if event.ResultData.HttpFailure != nil {
+		data["httpFailure"] = event.ResultData.HttpFailure
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return data
+}
+
+// messageEntries returns the ISO18626 messages exchanged by the event. Notices record the messages
+// in the event data while tasks record them in the result data, the event data of a task is its input.
+func messageEntries(event events.Event) []oapi.TimelineEntry {
+	messages := event.ResultData.CommonEventData
+	if event.EventType == events.EventTypeNotice {
+		messages = event.EventData.CommonEventData
+	}
+	var entries []oapi.TimelineEntry
+	if messages.IncomingMessage != nil {
+		entry := newTimelineEntry(event, event.ID+"/incoming", oapi.TimelineEntryTypeIso18626Incoming,
+			messageSummary(messages.IncomingMessage), messages.IncomingMessage)
+		if original, ok := event.EventData.CustomData[handler.ORIGINAL_INCOMING_MESSAGE]; ok && !sameJson(original, messages.IncomingMessage) {
+			entry.OriginalData = original
+		}
+		entries = append(entries, entry)
+	}
+	if messages.OutgoingMessage != nil {
+		entry := newTimelineEntry(event, event.ID+"/outgoing", oapi.TimelineEntryTypeIso18626Outgoing,
+			messageSummary(messages.OutgoingMessage), messages.OutgoingMessage)
+		// a confirmation is received after the message it confirms was sent
+		if messages.IncomingMessage != nil && isConfirmation(messages.IncomingMessage) {
+			entries = append([]oapi.TimelineEntry{entry}, entries...)
+		} else {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func lmsEntries(event events.Event) []oapi.TimelineEntry {
+	var entries []oapi.TimelineEntry
+	if outgoing, ok := event.EventData.CustomData[events.LMS_OUTGOING_MESSAGE]; ok && outgoing != nil {
+		entries = append(entries, newTimelineEntry(event, event.ID+"/lms-request", oapi.TimelineEntryTypeLmsRequest,
+			lmsSummary(outgoing), outgoing))
+	}
+	if incoming, ok := event.EventData.CustomData[events.LMS_INCOMING_MESSAGE]; ok && incoming != nil {
+		entries = append(entries, newTimelineEntry(event, event.ID+"/lms-response", oapi.TimelineEntryTypeLmsResponse,
+			lmsSummary(incoming), incoming))
+	}
+	return entries
+}
+
+func stateTransitionEntry(event events.Event, fromState *string) (oapi.TimelineEntry, bool) {
+	result := event.ResultData.ActionResult
+	if result == nil || result.ToState == nil {
+		return oapi.TimelineEntry{}, false
+	}
+	if fromState != nil && *fromState == *result.ToState {
+		return oapi.TimelineEntry{}, false
+	}
+	data := map[string]any{
+		"toState": *result.ToState,
+		"outcome": result.Outcome,
+	}
+	summary := "-> " + *result.ToState
+	if fromState != nil {
+		data["fromState"] = *fromState
+		summary = *fromState + " " + summary
+	}
+	if event.EventData.Action != nil {
+		data["action"] = *event.EventData.Action
+	}
+	return newTimelineEntry(event, event.ID+"/state", oapi.TimelineEntryTypeStateTransition, summary, data), true
+}
+
+func linkChildren(items []oapi.TimelineEntry) {
+	index := make(map[string]int, len(items))
+	for i, item := range items {
+		index[item.Id] = i
+	}
+	for _, item := range items {
+		if item.ParentId == nil {
+			continue
+		}
+		if i, ok := index[*item.ParentId]; ok {
+			items[i].ChildIds = append(items[i].ChildIds, item.Id)
+		}
+	}
+}
+
+func messageSummary(msg *iso18626.ISO18626Message) string {
+	switch {
+	case msg.Request != nil:
+		return "request"
+	case msg.RequestConfirmation != nil:
+		return "requestConfirmation " + string(msg.RequestConfirmation.ConfirmationHeader.MessageStatus)
+	case msg.SupplyingAgencyMessage != nil:
+		summary := "supplyingAgencyMessage " + string(msg.SupplyingAgencyMessage.StatusInfo.Status)
+		if msg.SupplyingAgencyMessage.MessageInfo.ReasonForMessage != "" {
+			summary += " (" + string(msg.SupplyingAgencyMessage.MessageInfo.ReasonForMessage) + ")"
+		}
+		return summary
+	case msg.SupplyingAgencyMessageConfirmation != nil:
+		return "supplyingAgencyMessageConfirmation " + string(msg.SupplyingAgencyMessageConfirmation.ConfirmationHeader.MessageStatus)
+	case msg.RequestingAgencyMessage != nil:
+		return "requestingAgencyMessage " + string(msg.RequestingAgencyMessage.Action)
+	case msg.RequestingAgencyMessageConfirmation != nil:
+		return "requestingAgencyMessageConfirmation " + string(msg.RequestingAgencyMessageConfirmation.ConfirmationHeader.MessageStatus)
+	}
+	return "unknown message"
+}
+
+func isConfirmation(msg *iso18626.ISO18626Message) bool {
+	return msg.RequestConfirmation != nil || msg.SupplyingAgencyMessageConfirmation != nil ||
+		msg.RequestingAgencyMessageConfirmation != nil
+}
+
+// lmsSummary returns the NCIP operation of the logged message map or the command identifier of a SIP2 message
+func lmsSummary(msg any) string {
+	m, _ := msg.(map[string]any)
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, ok := m[key].(map[string]any); ok {
+			return key
+		}
+	}
+	if line, ok := m["message"].(string); ok && len(line) >= 2 {
+		return "SIP2 " + line[:2]
+	}
+	return "unknown message"
+}
+
+// sameJson tells whether both values have the same JSON representation, values read back
+// from the database are generic maps while freshly created ones are structs
+func sameJson(a any, b any) bool {
+	aBytes, aErr := json.Marshal(a)
+	bBytes, bErr := json.Marshal(b)
+	if aErr != nil || bErr != nil {
+		return false
+	}
+	var aValue, bValue any
+	if json.Unmarshal(aBytes, &aValue) != nil || json.Unmarshal(bBytes, &bValue) != nil {
+		return false
+	}
+	return reflect.DeepEqual(aValue, bValue)
+}
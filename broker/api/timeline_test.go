@@ -0,0 +1,166 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/events"
+	"github.com/indexdata/crosslink/broker/handler"
+	"github.com/indexdata/crosslink/broker/oapi"
+	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
+	"github.com/indexdata/crosslink/iso18626"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+func timelineEvent(id string, offset time.Duration, eventType events.EventType, name events.EventName) events.Event {
+	return events.Event{
+		ID:          id,
+		Timestamp:   pgtype.Timestamp{Time: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC).Add(offset), Valid: true},
+		EventType:   eventType,
+		EventName:   name,
+		EventStatus: events.EventStatusSuccess,
+	}
+}
+
+func entryTypes(items []oapi.TimelineEntry) []oapi.TimelineEntryType {
+	types := make([]oapi.TimelineEntryType, 0, len(items))
+	for _, item := range items {
+		types = append(types, item.Type)
+	}
+	return types
+}
+
+func TestToApiTimelineEmpty(t *testing.T) {
+	timeline := ToApiTimeline(nil)
+	assert.NotNil(t, timeline.Items)
+	assert.Empty(t, timeline.Items)
+	assert.Equal(t, int64(0), timeline.About.Count)
+}
+
+func TestToApiTimelineIllTransaction(t *testing.T) {
+	original := &iso18626.ISO18626Message{Request: &iso18626.Request{Header: iso18626.Header{RequestingAgencyRequestId: "req-1"}}}
+	afterShim := &iso18626.ISO18626Message{Request: &iso18626.Request{Header: iso18626.Header{RequestingAgencyRequestId: "req-1"}}}
+	afterShim.Request.BibliographicInfo.Title = "Shimmed"
+	received := timelineEvent("e1", 0, events.EventTypeNotice, events.EventNameRequestReceived)
+	received.EventData.IncomingMessage = afterShim
+	received.EventData.OutgoingMessage = &iso18626.ISO18626Message{RequestConfirmation: &iso18626.RequestConfirmation{
+		ConfirmationHeader: iso18626.ConfirmationHeader{MessageStatus: iso18626.TypeMessageStatusOK}}}
+	received.EventData.CustomData = map[string]any{handler.ORIGINAL_INCOMING_MESSAGE: original}
+
+	message := timelineEvent("e2", time.Second, events.EventTypeTask, events.EventNameMessageSupplier)
+	message.ParentID = pgtype.Text{String: "e1", Valid: true}
+	message.EventStatus = events.EventStatusError
+	message.EventData.IncomingMessage = afterShim
+	message.ResultData.OutgoingMessage = afterShim
+	message.ResultData.IncomingMessage = &iso18626.ISO18626Message{RequestConfirmation: &iso18626.RequestConfirmation{
+		ConfirmationHeader: iso18626.ConfirmationHeader{MessageStatus: iso18626.TypeMessageStatusERROR}}}
+	message.ResultData.EventError = &events.EventError{Message: "failed to send message"}
+
+	timeline := ToApiTimeline([]events.Event{message, received})
+
+	assert.Equal(t, int64(6), timeline.About.Count)
+	assert.Equal(t, []oapi.TimelineEntryType{
+		oapi.TimelineEntryTypeEvent, oapi.TimelineEntryTypeIso18626Incoming, oapi.TimelineEntryTypeIso18626Outgoing,
+		oapi.TimelineEntryTypeEvent, oapi.TimelineEntryTypeIso18626Outgoing, oapi.TimelineEntryTypeIso18626Incoming,
+	}, entryTypes(timeline.Items))
+
+	receivedEntry := timeline.Items[0]
+	assert.Equal(t, "e1", receivedEntry.Id)
+	assert.Nil(t, receivedEntry.ParentId)
+	assert.Equal(t, []string{"e1/incoming", "e1/outgoing", "e2"}, receivedEntry.ChildIds)
+	assert.Equal(t, "NOTICE request-received SUCCESS", receivedEntry.Summary)
+	assert.Nil(t, receivedEntry.Data)
+
+	incoming := timeline.Items[1]
+	assert.Equal(t, "e1", *incoming.ParentId)
+	assert.Equal(t, "request", incoming.Summary)
+	assert.Equal(t, afterShim, incoming.Data)
+	assert.Equal(t, original, incoming.OriginalData)
+	assert.Equal(t, "requestConfirmation OK", timeline.Items[2].Summary)
+
+	messageEntry := timeline.Items[3]
+	assert.Equal(t, "e1", *messageEntry.ParentId)
+	assert.Equal(t, string(events.EventStatusError), messageEntry.EventStatus)
+	assert.Equal(t, map[string]any{"eventError": message.ResultData.EventError}, messageEntry.Data)
+	assert.Equal(t, "e2/outgoing", timeline.Items[4].Id)
+	assert.Equal(t, "requestConfirmation ERROR", timeline.Items[5].Summary)
+	assert.Nil(t, timeline.Items[5].OriginalData)
+}
+
+func TestToApiTimelineUnmodifiedByShim(t *testing.T) {
+	msg := &iso18626.ISO18626Message{RequestingAgencyMessage: &iso18626.RequestingAgencyMessage{Action: iso18626.TypeActionReceived}}
+	// as read back from the database
+	original, err := common.StructToMap(msg)
+	assert.NoError(t, err)
+	event := timelineEvent("e1", 0, events.EventTypeNotice, events.EventNameRequesterMsgReceived)
+	event.EventData.IncomingMessage = msg
+	event.EventData.CustomData = map[string]any{handler.ORIGINAL_INCOMING_MESSAGE: original}
+
+	timeline := ToApiTimeline([]events.Event{event})
+
+	assert.Len(t, timeline.Items, 2)
+	assert.Equal(t, "requestingAgencyMessage Received", timeline.Items[1].Summary)
+	assert.Nil(t, timeline.Items[1].OriginalData)
+}
+
+func TestToApiTimelinePatronRequest(t *testing.T) {
+	validate := pr_db.PatronRequestAction("validate")
+	send := pr_db.PatronRequestAction("send-request")
+	validated, sent := "VALIDATED", "SENT"
+
+	action1 := timelineEvent("a1", 0, events.EventTypeTask, events.EventNameInvokeAction)
+	action1.EventData.Action = &validate
+	action1.ResultData.ActionResult = &events.ActionResult{Outcome: "success", ToState: &validated}
+
+	lms := timelineEvent("l1", 0, events.EventTypeNotice, events.EventNameLmsRequesterMessage)
+	lms.ParentID = pgtype.Text{String: "a1", Valid: true}
+	lms.EventData.CustomData = map[string]any{
+		events.LMS_OUTGOING_MESSAGE: map[string]any{"version": "2.02", "LookupUser": map[string]any{}},
+		events.LMS_INCOMING_MESSAGE: map[string]any{"message": "64Y"},
+	}
+
+	action2 := timelineEvent("a2", time.Minute, events.EventTypeTask, events.EventNameInvokeAction)
+	action2.EventData.Action = &send
+	action2.ResultData.ActionResult = &events.ActionResult{Outcome: "success", ToState: &sent}
+
+	action3 := timelineEvent("a3", 2*time.Minute, events.EventTypeTask, events.EventNameInvokeAction)
+	action3.EventData.Action = &send
+	action3.ResultData.ActionResult = &events.ActionResult{Outcome: "failure", ToState: &sent}
+
+	timeline := ToApiTimeline([]events.Event{action1, lms, action2, action3})
+
+	assert.Equal(t, []oapi.TimelineEntryType{
+		oapi.TimelineEntryTypeEvent, oapi.TimelineEntryTypeStateTransition,
+		oapi.TimelineEntryTypeEvent, oapi.TimelineEntryTypeLmsRequest, oapi.TimelineEntryTypeLmsResponse,
+		oapi.TimelineEntryTypeEvent, oapi.TimelineEntryTypeStateTransition,
+		oapi.TimelineEntryTypeEvent,
+	}, entryTypes(timeline.Items))
+	assert.Equal(t, "TASK invoke-action SUCCESS: validate", timeline.Items[0].Summary)
+	assert.Equal(t, []string{"a1/state", "l1"}, timeline.Items[0].ChildIds)
+	assert.Equal(t, "-> VALIDATED", timeline.Items[1].Summary)
+	assert.Equal(t, map[string]any{"toState": validated, "outcome": "success", "action": validate}, timeline.Items[1].Data)
+	assert.Equal(t, []string{"l1/lms-request", "l1/lms-response"}, timeline.Items[2].ChildIds)
+	assert.Equal(t, "LookupUser", timeline.Items[3].Summary)
+	assert.Equal(t, "SIP2 64", timeline.Items[4].Summary)
+	assert.Equal(t, "VALIDATED -> SENT", timeline.Items[6].Summary)
+	assert.Equal(t, validated, timeline.Items[6].Data.(map[string]any)["fromState"])
+}
+
+func TestMessageSummary(t *testing.T) {
+	reason := iso18626.TypeReasonForMessageStatusChange
+	assert.Equal(t, "supplyingAgencyMessage Loaned (StatusChange)", messageSummary(&iso18626.ISO18626Message{
+		SupplyingAgencyMessage: &iso18626.SupplyingAgencyMessage{
+			StatusInfo:  iso18626.StatusInfo{Status: iso18626.TypeStatusLoaned},
+			MessageInfo: iso18626.MessageInfo{ReasonForMessage: reason},
+		}}))
+	assert.Equal(t, "supplyingAgencyMessageConfirmation OK", messageSummary(&iso18626.ISO18626Message{
+		SupplyingAgencyMessageConfirmation: &iso18626.SupplyingAgencyMessageConfirmation{
+			ConfirmationHeader: iso18626.ConfirmationHeader{MessageStatus: iso18626.TypeMessageStatusOK}}}))
+	assert.Equal(t, "requestingAgencyMessageConfirmation ERROR", messageSummary(&iso18626.ISO18626Message{
+		RequestingAgencyMessageConfirmation: &iso18626.RequestingAgencyMessageConfirmation{
+			ConfirmationHeader: iso18626.ConfirmationHeader{MessageStatus: iso18626.TypeMessageStatusERROR}}}))
+	assert.Equal(t, "unknown message", messageSummary(&iso18626.ISO18626Message{}))
+	assert.Equal(t, "unknown message", lmsSummary(nil))
+}
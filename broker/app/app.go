@@ -15,7 +15,9 @@ import (
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/indexdata/crosslink/broker/catalog"
+	"github.com/indexdata/crosslink/broker/channel"
 	"github.com/indexdata/crosslink/broker/email"
+	"github.com/indexdata/crosslink/broker/metrics"
 	prapi "github.com/indexdata/crosslink/broker/patron_request/api"
 	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
 	"github.com/indexdata/crosslink/broker/patron_request/proapi"
@@ -23,11 +25,17 @@ import (
 	psapi "github.com/indexdata/crosslink/broker/pullslip/api"
 	ps_db "github.com/indexdata/crosslink/broker/pullslip/db"
 	psoapi "github.com/indexdata/crosslink/broker/pullslip/oapi"
+	"github.com/indexdata/crosslink/broker/retention"
 	schedapi "github.com/indexdata/crosslink/broker/scheduler/api"
 	sched_db "github.com/indexdata/crosslink/broker/scheduler/db"
 	schedoapi "github.com/indexdata/crosslink/broker/scheduler/oapi"
 	sched_service "github.com/indexdata/crosslink/broker/scheduler/service"
 	"github.com/indexdata/crosslink/broker/tenant"
+	"github.com/indexdata/crosslink/broker/tracing"
+	whapi "github.com/indexdata/crosslink/broker/webhook/api"
+	wh_db "github.com/indexdata/crosslink/broker/webhook/db"
+	whoapi "github.com/indexdata/crosslink/broker/webhook/oapi"
+	wh_service "github.com/indexdata/crosslink/broker/webhook/service"
 
 	"github.com/dustin/go-humanize"
 	"github.com/indexdata/crosslink/broker/adapter"
@@ -47,6 +55,7 @@ import (
 	"github.com/indexdata/go-utils/utils"
 	"github.com/jackc/pgx/v5/pgxpool"
 	nethttpmiddleware "github.com/oapi-codegen/nethttp-middleware"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 
 	"github.com/indexdata/crosslink/broker/lms"
 )
@@ -55,6 +64,7 @@ var HTTP_PORT = utils.Must(utils.GetEnvInt("HTTP_PORT", 8081))
 var DB_TYPE = utils.GetEnv("DB_TYPE", "postgres")
 var DB_USER = utils.GetEnv("DB_USER", "crosslink")
 var METAPROXY_URL = utils.GetEnv("METAPROXY_URL", "")
+var METRICS_TOKEN = utils.GetEnv("METRICS_TOKEN", "")
 var DB_PASSWORD = utils.GetEnv("DB_PASSWORD", "crosslink")
 var DB_HOST = utils.GetEnv("DB_HOST", "localhost")
 var DB_PORT = utils.GetEnv("DB_PORT", "25432")
@@ -94,6 +104,105 @@ var SHUTDOWN_DELAY, _ = utils.GetEnvAny("SHUTDOWN_DELAY", time.Duration(15*float
 	}
 	return d, nil
 })
+var TASK_LEASE_DURATION, _ = utils.GetEnvAny("TASK_LEASE_DURATION", events.DEFAULT_TASK_LEASE, func(val string) (time.Duration, error) {
+	d, err := time.ParseDuration(val)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid TASK_LEASE_DURATION value: %s", val)
+	}
+	return d, nil
+})
+var TASK_SWEEP_INTERVAL, _ = utils.GetEnvAny("TASK_SWEEP_INTERVAL", events.DEFAULT_TASK_SWEEP_INTERVAL, func(val string) (time.Duration, error) {
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid TASK_SWEEP_INTERVAL value: %s", val)
+	}
+	return d, nil
+})
+var TASK_MAX_ATTEMPTS = utils.Must(utils.GetEnvInt("TASK_MAX_ATTEMPTS", events.DEFAULT_TASK_MAX_ATTEMPTS))
+var EVENT_SIGNAL_POLL_INTERVAL, _ = utils.GetEnvAny("EVENT_SIGNAL_POLL_INTERVAL", events.DEFAULT_SIGNAL_POLL_INTERVAL, func(val string) (time.Duration, error) {
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid EVENT_SIGNAL_POLL_INTERVAL value: %s", val)
+	}
+	return d, nil
+})
+var EVENT_SIGNAL_RETENTION, _ = utils.GetEnvAny("EVENT_SIGNAL_RETENTION", events.DEFAULT_SIGNAL_RETENTION, func(val string) (time.Duration, error) {
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid EVENT_SIGNAL_RETENTION value: %s", val)
+	}
+	return d, nil
+})
+var SSE_HEARTBEAT_INTERVAL, _ = utils.GetEnvAny("SSE_HEARTBEAT_INTERVAL", api.DEFAULT_SSE_HEARTBEAT_INTERVAL, func(val string) (time.Duration, error) {
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid SSE_HEARTBEAT_INTERVAL value: %s", val)
+	}
+	return d, nil
+})
+var SSE_REPLAY_WINDOW, _ = utils.GetEnvAny("SSE_REPLAY_WINDOW", api.DEFAULT_SSE_REPLAY_WINDOW, func(val string) (time.Duration, error) {
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid SSE_REPLAY_WINDOW value: %s", val)
+	}
+	return d, nil
+})
+var WEBHOOK_TIMEOUT, _ = utils.GetEnvAny("WEBHOOK_TIMEOUT", wh_service.DEFAULT_TIMEOUT, func(val string) (time.Duration, error) {
+	d, err := time.ParseDuration(val)
+	if err != nil || d <= 0 || d >= wh_service.DELIVERY_CLAIM {
+		return 0, fmt.Errorf("invalid WEBHOOK_TIMEOUT value: %s", val)
+	}
+	return d, nil
+})
+var WEBHOOK_RETRY_BACKOFF, _ = utils.GetEnvAny("WEBHOOK_RETRY_BACKOFF", wh_service.DEFAULT_RETRY_BACKOFF, func(val string) (time.Duration, error) {
+	d, err := time.ParseDuration(val)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid WEBHOOK_RETRY_BACKOFF value: %s", val)
+	}
+	return d, nil
+})
+var WEBHOOK_POLL_INTERVAL, _ = utils.GetEnvAny("WEBHOOK_POLL_INTERVAL", wh_service.DEFAULT_POLL_INTERVAL, func(val string) (time.Duration, error) {
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid WEBHOOK_POLL_INTERVAL value: %s", val)
+	}
+	return d, nil
+})
+var WEBHOOK_MAX_ATTEMPTS = utils.Must(utils.GetEnvInt("WEBHOOK_MAX_ATTEMPTS", wh_service.DEFAULT_MAX_ATTEMPTS))
+var OUTBOX_RETRY_BACKOFF, _ = utils.GetEnvAny("OUTBOX_RETRY_BACKOFF", prservice.DEFAULT_OUTBOX_RETRY_BACKOFF, func(val string) (time.Duration, error) {
+	d, err := time.ParseDuration(val)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid OUTBOX_RETRY_BACKOFF value: %s", val)
+	}
+	return d, nil
+})
+var OUTBOX_POLL_INTERVAL, _ = utils.GetEnvAny("OUTBOX_POLL_INTERVAL", prservice.DEFAULT_OUTBOX_POLL_INTERVAL, func(val string) (time.Duration, error) {
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid OUTBOX_POLL_INTERVAL value: %s", val)
+	}
+	return d, nil
+})
+var OUTBOX_MAX_ATTEMPTS = utils.Must(utils.GetEnvInt("OUTBOX_MAX_ATTEMPTS", prservice.DEFAULT_OUTBOX_MAX_ATTEMPTS))
+var EVENT_RETENTION, _ = utils.GetEnvAny("EVENT_RETENTION", time.Duration(0), func(val string) (time.Duration, error) {
+	d, err := common.ParseDurationWithDays(val)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid EVENT_RETENTION value: %s", val)
+	}
+	return d, nil
+})
+var EVENT_RETENTION_RULES = utils.GetEnv("EVENT_RETENTION_RULES", "")
+var EVENT_EXPORT_DIR = utils.GetEnv("EVENT_EXPORT_DIR", "")
+var EVENT_PARTITION_MONTHS_AHEAD = utils.Must(utils.GetEnvInt("EVENT_PARTITION_MONTHS_AHEAD", retention.DEFAULT_MONTHS_AHEAD))
+var EVENT_MAINTENANCE_INTERVAL, _ = utils.GetEnvAny("EVENT_MAINTENANCE_INTERVAL", retention.DEFAULT_INTERVAL, func(val string) (time.Duration, error) {
+	d, err := time.ParseDuration(val)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid EVENT_MAINTENANCE_INTERVAL value: %s", val)
+	}
+	return d, nil
+})
+var OTEL_EXPORTER_OTLP_ENDPOINT = utils.GetEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+var OTEL_SERVICE_NAME = utils.GetEnv("OTEL_SERVICE_NAME", "crosslink-broker")
 
 var ServeMux *http.ServeMux
 var appCtx = common.CreateExtCtxWithLogArgsAndHandler(context.Background(), nil, configLog())
@@ -110,6 +219,9 @@ type Context struct {
 	SseBroker       *api.SseBroker
 	PsApiHandler    psapi.PullSlipApiHandler
 	SchedApiHandler schedapi.SchedulerApiHandler
+	WhApiHandler    whapi.WebhookApiHandler
+	// ShutdownTracing flushes the spans not yet exported
+	ShutdownTracing func(context.Context) error
 }
 
 func configLog() slog.Handler {
@@ -142,6 +254,10 @@ func configLog() slog.Handler {
 
 func Init(ctx context.Context) (Context, error) {
 	appCtx.Logger().Info("starting " + vcs.GetSignature())
+	shutdownTracing, err := tracing.Init(ctx, OTEL_EXPORTER_OTLP_ENDPOINT, OTEL_SERVICE_NAME)
+	if err != nil {
+		return Context{}, err
+	}
 	lookupAdapterEnv, err := catalog.CreateLookupAdapterFromEnv(map[string]any{
 		catalog.HoldingsAdapter:    HOLDINGS_ADAPTER,
 		catalog.HoldingsSruURL:     HOLDINGS_SRU_URL,
@@ -175,13 +291,22 @@ func Init(ctx context.Context) (Context, error) {
 	if err != nil {
 		return Context{}, err
 	}
+	err = metrics.RegisterDbPool(pool)
+	if err != nil {
+		return Context{}, err
+	}
 
 	eventRepo := CreateEventRepo(pool)
+	eventRetention, err := CreateEventRetention(eventRepo)
+	if err != nil {
+		return Context{}, err
+	}
 	eventBus := CreateEventBus(eventRepo)
 	illRepo := ill_db.CreateIllRepo(pool)
 	prRepo := pr_db.CreatePrRepo(pool, DB_EXPLAIN_ANALYZE)
 	psRepo := ps_db.CreatePsRepo(pool)
 	schedRepo := sched_db.CreateSchedRepo(pool)
+	whRepo := wh_db.CreateWhRepo(pool)
 
 	var emailSenderService *sched_service.EmailSenderService
 	emailSenderService, err = sched_service.NewEmailSenderService(prRepo, illRepo)
@@ -192,25 +317,40 @@ func Init(ctx context.Context) (Context, error) {
 	lookupAdapterCreator := catalog.NewLookupAdapterCreator(AVAILABILITY_ADAPTER, METAPROXY_URL)
 	lookupAdapterFactory := service.NewLookupAdapterFactory(illRepo, dirAdapter, CONSORTIUM_SYMBOL, lookupAdapterEnv, lookupAdapterCreator)
 	prActionService := prservice.CreatePatronRequestActionService(prRepo, illRepo, eventBus, &iso18626Handler, lmsCreator, email.NewEmailService(), lookupAdapterFactory, dirAdapter)
+	outbox := prActionService.Outbox()
+	outbox.MaxAttempts = int32(OUTBOX_MAX_ATTEMPTS)
+	outbox.RetryBackoff = OUTBOX_RETRY_BACKOFF
+	outbox.PollInterval = OUTBOX_POLL_INTERVAL
+	outbox.SetChannel(proapi.NotificationChannelSms, channel.NewSmsChannel())
+	outbox.SetChannel(proapi.NotificationChannelPush, channel.NewPushChannel())
+	emailSenderService.SetEmailQueue(outbox)
 	prMessageHandler.SetAutoActionRunner(prActionService)
+	prMessageHandler.SetLmsCreator(lmsCreator)
 	iso18626Client := client.CreateIso18626Client(eventBus, illRepo, prMessageHandler, MAX_MESSAGE_SIZE, delay)
-	supplierLocator := service.CreateSupplierLocator(eventBus, illRepo, dirAdapter, lookupAdapterFactory)
+	supplierLocator := service.CreateSupplierLocator(eventBus, illRepo, dirAdapter, lookupAdapterFactory, lmsCreator)
 	workflowManager := service.CreateWorkflowManager(eventBus, illRepo, service.WorkflowConfig{})
 	tenantResolver := tenant.NewResolver().WithIllRepo(illRepo).WithLookupAdapter(dirAdapter).WithTenantToSymbol(TENANT_TO_SYMBOL)
-	apiHandler := api.NewApiHandler(eventRepo, illRepo, tenantResolver, API_PAGE_SIZE)
+	apiHandler := api.NewApiHandler(eventRepo, eventBus, illRepo, tenantResolver, API_PAGE_SIZE)
 	prApiHandler := prapi.NewPrApiHandler(prRepo, eventBus, eventRepo, tenantResolver, &iso18626Handler, API_PAGE_SIZE)
 	prApiHandler.SetAutoActionRunner(prActionService)
 	prApiHandler.SetActionTaskProcessor(prActionService)
-	sseBroker := api.NewSseBroker(appCtx, tenantResolver)
-	psApiHandler := psapi.NewPsApiHandler(psRepo, prRepo, tenantResolver)
+	prApiHandler.SetTemplatePreviewService(prActionService)
+	sseBroker := api.NewSseBroker(appCtx, tenantResolver, eventRepo)
+	sseBroker.HeartbeatInterval = SSE_HEARTBEAT_INTERVAL
+	sseBroker.ReplayWindow = SSE_REPLAY_WINDOW
+	psApiHandler := psapi.NewPsApiHandler(psRepo, prRepo, illRepo, tenantResolver)
 
 	batchActionService := sched_service.NewBatchActionService(eventBus, prRepo, schedRepo, emailSenderService)
+	webhookService := wh_service.NewWebhookService(whRepo, prRepo, illRepo, &http.Client{Timeout: WEBHOOK_TIMEOUT})
+	webhookService.MaxAttempts = int32(WEBHOOK_MAX_ATTEMPTS)
+	webhookService.RetryBackoff = WEBHOOK_RETRY_BACKOFF
+	webhookService.PollInterval = WEBHOOK_POLL_INTERVAL
 
 	if err != nil {
 		appCtx.Logger().Warn("email service not available, email sending events will fail", "error", err)
 	}
 
-	AddDefaultHandlers(eventBus, iso18626Client, supplierLocator, workflowManager, iso18626Handler, sseBroker, batchActionService, *prActionService)
+	AddDefaultHandlers(eventBus, iso18626Client, supplierLocator, workflowManager, iso18626Handler, sseBroker, batchActionService, *prActionService, webhookService)
 	err = StartEventBus(ctx, eventBus)
 	if err != nil {
 		return Context{}, err
@@ -220,6 +360,11 @@ func Init(ctx context.Context) (Context, error) {
 	if err = StartScheduler(ctx, schedRepo, eventBus); err != nil {
 		return Context{}, err
 	}
+	whApiHandler := whapi.NewWebhookApiHandler(API_PAGE_SIZE, whRepo, tenantResolver)
+	go webhookService.Run(common.CreateExtCtxWithArgs(ctx, nil))
+	go outbox.Run(common.CreateExtCtxWithArgs(ctx, nil))
+	go sseBroker.RunCleanup(common.CreateExtCtxWithArgs(ctx, nil))
+	go eventRetention.Run(common.CreateExtCtxWithArgs(ctx, nil))
 
 	return Context{
 		EventBus:        eventBus,
@@ -233,6 +378,8 @@ func Init(ctx context.Context) (Context, error) {
 		SseBroker:       sseBroker,
 		PsApiHandler:    psApiHandler,
 		SchedApiHandler: schedApiHandler,
+		WhApiHandler:    whApiHandler,
+		ShutdownTracing: shutdownTracing,
 	}, nil
 }
 
@@ -251,6 +398,7 @@ func StartServer(ctx Context) error {
 		return err
 	}
 	ServeMux.HandleFunc("GET /healthz", HandleHealthz)
+	ServeMux.Handle("GET /metrics", metrics.Handler(METRICS_TOKEN))
 	//all methods must be mapped explicitly to avoid conflicts with the index handler
 	ServeMux.HandleFunc("GET /iso18626", handler.Iso18626PostHandler(ctx.IllRepo, ctx.EventBus, ctx.DirAdapter, MAX_MESSAGE_SIZE))
 	ServeMux.HandleFunc("POST /iso18626", handler.Iso18626PostHandler(ctx.IllRepo, ctx.EventBus, ctx.DirAdapter, MAX_MESSAGE_SIZE))
@@ -267,6 +415,7 @@ func StartServer(ctx Context) error {
 	})
 	psoapi.HandlerFromMux(&ctx.PsApiHandler, ServeMux)
 	schedoapi.HandlerFromMux(&ctx.SchedApiHandler, ServeMux)
+	whoapi.HandlerFromMux(&ctx.WhApiHandler, ServeMux)
 	ServeMux.HandleFunc("GET /sse/events", ctx.SseBroker.ServeHTTP)
 	if ctx.TenantResolver.HasTenantMapping() {
 		basePath := tenant.OKAPI_PATH_PREFIX
@@ -278,15 +427,21 @@ func StartServer(ctx Context) error {
 		})
 		psoapi.HandlerFromMuxWithBaseURL(&ctx.PsApiHandler, ServeMux, basePath)
 		schedoapi.HandlerFromMuxWithBaseURL(&ctx.SchedApiHandler, ServeMux, basePath)
+		whoapi.HandlerFromMuxWithBaseURL(&ctx.WhApiHandler, ServeMux, basePath)
 		ServeMux.HandleFunc("GET "+basePath+"/sse/events", ctx.SseBroker.ServeHTTP)
 	}
 	signatureHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Server", vcs.GetSignature())
 		ServeMux.ServeHTTP(w, r)
 	})
+	tracingHandler := otelhttp.NewHandler(signatureHandler, "crosslink-broker",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return "HTTP " + r.Method
+		}),
+		otelhttp.WithFilter(isTracedRequest))
 	server := &http.Server{
 		Addr:              ":" + strconv.Itoa(HTTP_PORT),
-		Handler:           signatureHandler,
+		Handler:           tracingHandler,
 		ReadHeaderTimeout: 10 * time.Second,
 		ReadTimeout:       30 * time.Second,
 		WriteTimeout:      60 * time.Second,
@@ -308,17 +463,31 @@ func StartServer(ctx Context) error {
 	case sig := <-shutdown:
 		appCtx.Logger().Info("HTTP server shutdown initiated", "signal", sig)
 		// give outstanding requests a timeout to complete
-		ctx, cancel := context.WithTimeout(appCtx, SHUTDOWN_DELAY)
+		shutdownCtx, cancel := context.WithTimeout(appCtx, SHUTDOWN_DELAY)
 		defer cancel()
-		if err := server.Shutdown(ctx); err != nil {
+		if err := server.Shutdown(shutdownCtx); err != nil {
 			server.Close()
 			return fmt.Errorf("HTTP server could not shutdown gracefully: %w", err)
 		}
 		appCtx.Logger().Info("HTTP server shutdown complete")
+		if ctx.ShutdownTracing != nil {
+			if err := ctx.ShutdownTracing(shutdownCtx); err != nil {
+				appCtx.Logger().Warn("failed to flush trace spans", "error", err)
+			}
+		}
 		return nil
 	}
 }
 
+// isTracedRequest skips probes, metrics scraping and long-lived event streams
+func isTracedRequest(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/healthz", "/metrics":
+		return false
+	}
+	return !strings.HasSuffix(r.URL.Path, "/sse/events")
+}
+
 func newOpenAPIRequestValidator() (func(http.Handler) http.Handler, error) {
 	spec, err := openapi3.NewLoader().LoadFromData(oapi.OpenAPISpecYAML)
 	if err != nil {
@@ -380,14 +549,38 @@ func CreateEventRepo(dbPool *pgxpool.Pool) events.EventRepo {
 	return eventRepo
 }
 
+func CreateEventRetention(eventRepo events.EventRepo) (*retention.EventRetention, error) {
+	rules, err := retention.ParseRules(EVENT_RETENTION_RULES, EVENT_RETENTION)
+	if err != nil {
+		return nil, err
+	}
+	// there is no default partition, events of a month without a partition cannot be saved
+	if EVENT_PARTITION_MONTHS_AHEAD < 1 {
+		return nil, fmt.Errorf("invalid EVENT_PARTITION_MONTHS_AHEAD value: %d, must be at least 1", EVENT_PARTITION_MONTHS_AHEAD)
+	}
+	eventRetention := retention.NewEventRetention(eventRepo)
+	eventRetention.MaxAge = EVENT_RETENTION
+	eventRetention.Rules = rules
+	eventRetention.ExportDir = EVENT_EXPORT_DIR
+	eventRetention.MonthsAhead = EVENT_PARTITION_MONTHS_AHEAD
+	eventRetention.Interval = EVENT_MAINTENANCE_INTERVAL
+	return eventRetention, nil
+}
+
 func CreateEventBus(eventRepo events.EventRepo) events.EventBus {
 	eventBus := events.NewPostgresEventBus(eventRepo, ConnectionString)
+	eventBus.LeaseDuration = TASK_LEASE_DURATION
+	eventBus.SweepInterval = TASK_SWEEP_INTERVAL
+	eventBus.MaxTaskAttempts = int32(TASK_MAX_ATTEMPTS)
+	eventBus.SignalPollInterval = EVENT_SIGNAL_POLL_INTERVAL
+	eventBus.SignalRetention = EVENT_SIGNAL_RETENTION
 	return eventBus
 }
 
 func AddDefaultHandlers(eventBus events.EventBus, iso18626Client client.Iso18626Client,
 	supplierLocator service.SupplierLocator, workflowManager service.WorkflowManager, iso18626Handler handler.Iso18626Handler,
-	sseBroker *api.SseBroker, batchActionService *sched_service.BatchActionService, prActionService prservice.PatronRequestActionService) {
+	sseBroker *api.SseBroker, batchActionService *sched_service.BatchActionService, prActionService prservice.PatronRequestActionService,
+	webhookService *wh_service.WebhookService) {
 	eventBus.HandleEventCreated(events.EventNameMessageSupplier, events.HandlerRoleConsumer, iso18626Client.MessageSupplier)
 	eventBus.HandleEventCreated(events.EventNameMessageRequester, events.HandlerRoleConsumer, iso18626Client.MessageRequester)
 	eventBus.HandleEventCreated(events.EventNameConfirmRequesterMsg, events.HandlerRoleObserver, iso18626Handler.ConfirmRequesterMsg)
@@ -412,6 +605,11 @@ func AddDefaultHandlers(eventBus events.EventBus, iso18626Client client.Iso18626
 
 	eventBus.HandleEventCreated(events.EventNameInvokeBackgroundAction, events.HandlerRoleConsumer, prActionService.InvokeAction)
 
+	for _, eventName := range wh_service.ObservedEventNames {
+		eventBus.HandleEventCreated(eventName, events.HandlerRoleConsumer, webhookService.EventCreated)
+		eventBus.HandleTaskCompleted(eventName, events.HandlerRoleConsumer, webhookService.TaskCompleted)
+	}
+
 	// Invoke-action is intentionally not registered on event-created/task-completed handlers.
 	// It is processed inline by patron-request services and API handlers.
 }
@@ -0,0 +1,179 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DefaultCatalogSourceName names the catalog configured directly on catalogConfig
+// when it is queried together with additional sources.
+const DefaultCatalogSourceName = "default"
+
+type FederatedSource struct {
+	Name     string
+	Priority int32
+	Adapter  LookupAdapter
+}
+
+// FederatedLookupAdapter queries several catalogs concurrently and merges their holdings.
+type FederatedLookupAdapter struct {
+	sources []FederatedSource
+}
+
+// SourceLog describes the outcome of a lookup in a single catalog of a federated lookup.
+type SourceLog struct {
+	Name     string   `json:"name"`
+	Priority int32    `json:"priority"`
+	Query    string   `json:"query,omitempty"`
+	Holdings int      `json:"holdings"`
+	Symbols  []string `json:"symbols,omitempty"`
+	Skipped  []string `json:"skippedSymbols,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// SourcesResult is implemented by lookup results that combine several catalogs.
+type SourcesResult interface {
+	GetSources() []SourceLog
+}
+
+type federatedSourceResult struct {
+	source FederatedSource
+	result LookupResult
+	err    error
+}
+
+type FederatedLookupResult struct {
+	results     []federatedSourceResult
+	logs        []SourceLog
+	holdings    []Holding
+	holdingsErr error
+	merged      bool
+}
+
+// NewFederatedLookupAdapter returns an adapter for the given sources. Sources are
+// consulted in descending priority; sources with equal priority keep their order.
+func NewFederatedLookupAdapter(sources []FederatedSource) LookupAdapter {
+	sorted := slices.Clone(sources)
+	slices.SortStableFunc(sorted, func(a, b FederatedSource) int {
+		return int(b.Priority) - int(a.Priority)
+	})
+	return &FederatedLookupAdapter{sources: sorted}
+}
+
+func (a *FederatedLookupAdapter) Lookup(params LookupParams) (LookupResult, error) {
+	return a.lookup(context.Background(), params)
+}
+
+func (a *FederatedLookupAdapter) lookup(ctx context.Context, params LookupParams) (LookupResult, error) {
+	results := make([]federatedSourceResult, len(a.sources))
+	var wg sync.WaitGroup
+	for i, source := range a.sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := Lookup(ctx, source.Adapter, params)
+			results[i] = federatedSourceResult{source: source, result: result, err: err}
+		}()
+	}
+	wg.Wait()
+
+	fed := &FederatedLookupResult{results: results, logs: make([]SourceLog, len(results))}
+	var errs []error
+	for i, r := range results {
+		fed.logs[i] = SourceLog{Name: r.source.Name, Priority: r.source.Priority}
+		if r.result != nil {
+			fed.logs[i].Query = r.result.GetQuery()
+		}
+		if r.err != nil {
+			fed.logs[i].Error = r.err.Error()
+			errs = append(errs, fmt.Errorf("catalog %s: %w", r.source.Name, r.err))
+		}
+	}
+	// a lookup only fails when no catalog could be searched
+	if len(errs) == len(results) {
+		return fed, errors.Join(errs...)
+	}
+	return fed, nil
+}
+
+func (r *FederatedLookupResult) GetQuery() string {
+	var queries []string
+	for _, log := range r.logs {
+		if log.Query != "" {
+			queries = append(queries, log.Name+": "+log.Query)
+		}
+	}
+	return strings.Join(queries, "; ")
+}
+
+// GetHoldings merges holdings from all catalogs. When a symbol is held in several
+// catalogs only the holdings from the catalog with the highest priority are kept.
+func (r *FederatedLookupResult) GetHoldings() ([]Holding, error) {
+	if r.merged {
+		return r.holdings, r.holdingsErr
+	}
+	r.merged = true
+	holdings := []Holding{}
+	symbolSource := map[string]int{}
+	var errs []error
+	searched := 0
+	for i, res := range r.results {
+		if res.err != nil || res.result == nil {
+			continue
+		}
+		sourceHoldings, err := res.result.GetHoldings()
+		if err != nil {
+			r.logs[i].Error = err.Error()
+			errs = append(errs, fmt.Errorf("catalog %s: %w", res.source.Name, err))
+			continue
+		}
+		searched++
+		for _, holding := range sourceHoldings {
+			if owner, ok := symbolSource[holding.Symbol]; ok && owner != i {
+				if !slices.Contains(r.logs[i].Skipped, holding.Symbol) {
+					r.logs[i].Skipped = append(r.logs[i].Skipped, holding.Symbol)
+				}
+				continue
+			}
+			if _, ok := symbolSource[holding.Symbol]; !ok {
+				symbolSource[holding.Symbol] = i
+				r.logs[i].Symbols = append(r.logs[i].Symbols, holding.Symbol)
+			}
+			r.logs[i].Holdings++
+			holdings = append(holdings, holding)
+		}
+	}
+	if searched == 0 && len(errs) > 0 {
+		r.holdingsErr = errors.Join(errs...)
+		return nil, r.holdingsErr
+	}
+	r.holdings = holdings
+	return r.holdings, nil
+}
+
+// GetMetadata returns metadata from the catalog with the highest priority that provides it.
+func (r *FederatedLookupResult) GetMetadata() (Metadata, error) {
+	var errs []error
+	for _, res := range r.results {
+		if res.err != nil || res.result == nil {
+			continue
+		}
+		metadata, err := res.result.GetMetadata()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("catalog %s: %w", res.source.Name, err))
+			continue
+		}
+		if metadata != (Metadata{}) {
+			return metadata, nil
+		}
+	}
+	return Metadata{}, errors.Join(errs...)
+}
+
+func (r *FederatedLookupResult) GetSources() []SourceLog {
+	return r.logs
+}
@@ -0,0 +1,123 @@
+package catalog
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/indexdata/crosslink/broker/ill_db"
+	dirapi "github.com/indexdata/crosslink/directory/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFederatedLookupMergesByPriority(t *testing.T) {
+	reservoir := &MockLookupAdapter{
+		Holdings: []Holding{
+			{Symbol: "ISIL:SUP1", LocalIdentifier: "r1"},
+			{Symbol: "ISIL:SUP2", LocalIdentifier: "r2"},
+		},
+	}
+	partner := &MockLookupAdapter{
+		Holdings: []Holding{
+			{Symbol: "ISIL:SUP2", LocalIdentifier: "p2"},
+			{Symbol: "ISIL:SUP2", LocalIdentifier: "p2b"},
+			{Symbol: "ISIL:SUP3", LocalIdentifier: "p3"},
+		},
+		Metadata: Metadata{Title: "partner title"},
+	}
+	adapter := NewFederatedLookupAdapter([]FederatedSource{
+		{Name: "reservoir", Adapter: reservoir},
+		{Name: "partner", Priority: 10, Adapter: partner},
+	})
+	result, err := adapter.Lookup(LookupParams{Identifier: "id"})
+	assert.NoError(t, err)
+	holdings, err := result.GetHoldings()
+	assert.NoError(t, err)
+	assert.Equal(t, []Holding{
+		{Symbol: "ISIL:SUP2", LocalIdentifier: "p2"},
+		{Symbol: "ISIL:SUP2", LocalIdentifier: "p2b"},
+		{Symbol: "ISIL:SUP3", LocalIdentifier: "p3"},
+		{Symbol: "ISIL:SUP1", LocalIdentifier: "r1"},
+	}, holdings)
+
+	metadata, err := result.GetMetadata()
+	assert.NoError(t, err)
+	assert.Equal(t, "partner title", metadata.Title)
+
+	sources := result.(SourcesResult).GetSources()
+	assert.Len(t, sources, 2)
+	assert.Equal(t, "partner", sources[0].Name)
+	assert.Equal(t, 3, sources[0].Holdings)
+	assert.Equal(t, []string{"ISIL:SUP2", "ISIL:SUP3"}, sources[0].Symbols)
+	assert.Equal(t, "reservoir", sources[1].Name)
+	assert.Equal(t, 1, sources[1].Holdings)
+	assert.Equal(t, []string{"ISIL:SUP1"}, sources[1].Symbols)
+	assert.Equal(t, []string{"ISIL:SUP2"}, sources[1].Skipped)
+}
+
+func TestFederatedLookupPartialFailure(t *testing.T) {
+	adapter := NewFederatedLookupAdapter([]FederatedSource{
+		{Name: "down", Priority: 1, Adapter: &MockLookupAdapter{Err: errors.New("connection refused")}},
+		{Name: "up", Adapter: &MockLookupAdapter{Holdings: []Holding{{Symbol: "ISIL:SUP1"}}}},
+	})
+	result, err := adapter.Lookup(LookupParams{Identifier: "id"})
+	assert.NoError(t, err)
+	holdings, err := result.GetHoldings()
+	assert.NoError(t, err)
+	assert.Equal(t, []Holding{{Symbol: "ISIL:SUP1"}}, holdings)
+	sources := result.(SourcesResult).GetSources()
+	assert.Equal(t, "connection refused", sources[0].Error)
+	assert.Empty(t, sources[1].Error)
+}
+
+func TestFederatedLookupAllFail(t *testing.T) {
+	adapter := NewFederatedLookupAdapter([]FederatedSource{
+		{Name: "a", Adapter: &MockLookupAdapter{Err: errors.New("error a")}},
+		{Name: "b", Adapter: &MockLookupAdapter{Err: errors.New("error b")}},
+	})
+	result, err := adapter.Lookup(LookupParams{Identifier: "id"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "catalog a: error a")
+	assert.Contains(t, err.Error(), "catalog b: error b")
+	assert.Empty(t, result.GetQuery())
+}
+
+func TestGetAdapterWithSources(t *testing.T) {
+	creator := NewLookupAdapterCreator(LookupAdapterMock, "")
+	priority := int32(5)
+	peer := ill_db.Peer{
+		CustomData: dirapi.Entry{
+			CatalogConfig: &dirapi.CatalogConfig{
+				Zoom: &dirapi.ZoomConfig{Address: "a", Options: &map[string]string{"location": "default"}},
+				Sources: &[]dirapi.CatalogSource{
+					{Name: "partner", Priority: &priority, Zoom: &dirapi.ZoomConfig{Address: "b", Options: &map[string]string{"location": "partner"}}},
+				},
+			},
+		},
+	}
+	aa, err := creator.GetAdapter(peer)
+	assert.NoError(t, err)
+	assert.IsType(t, &FederatedLookupAdapter{}, aa)
+	result, err := aa.Lookup(LookupParams{})
+	assert.NoError(t, err)
+	holdings, err := result.GetHoldings()
+	assert.NoError(t, err)
+	// both mock holdings share the empty symbol, so only the partner holding is kept
+	assert.Equal(t, []Holding{{Location: "partner"}}, holdings)
+	sources := result.(SourcesResult).GetSources()
+	assert.Equal(t, "partner", sources[0].Name)
+	assert.Equal(t, DefaultCatalogSourceName, sources[1].Name)
+}
+
+func TestGetAdapterWithBadSource(t *testing.T) {
+	creator := NewLookupAdapterCreator(LookupAdapterZoom, "")
+	peer := ill_db.Peer{
+		CustomData: dirapi.Entry{
+			CatalogConfig: &dirapi.CatalogConfig{
+				Sources: &[]dirapi.CatalogSource{{Name: "partner"}},
+			},
+		},
+	}
+	_, err := creator.GetAdapter(peer)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "catalogConfig.sources partner: must specify either sru or zoom")
+}
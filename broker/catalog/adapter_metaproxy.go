@@ -1,6 +1,7 @@
 package catalog
 
 import (
+	"context"
 	"net/http"
 
 	dirapi "github.com/indexdata/crosslink/directory/api"
@@ -18,5 +19,9 @@ func NewMetaproxyLookupAdapter(config dirapi.ZoomConfig, metaproxyUrl string, qu
 }
 
 func (a *MetaproxyLookupAdapter) Lookup(params LookupParams) (LookupResult, error) {
-	return a.holdingsLookupAdapter.Lookup(params)
+	return a.lookup(context.Background(), params)
+}
+
+func (a *MetaproxyLookupAdapter) lookup(ctx context.Context, params LookupParams) (LookupResult, error) {
+	return lookupWithContext(ctx, a.holdingsLookupAdapter, params)
 }
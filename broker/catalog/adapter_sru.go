@@ -1,6 +1,7 @@
 package catalog
 
 import (
+	"context"
 	"encoding/xml"
 	"errors"
 	"fmt"
@@ -75,14 +76,14 @@ func encodeCqlSearchClause(field string, value string) (string, error) {
 	return cqlQuery.String(), nil
 }
 
-func (s *SruLookupAdapter) search(sruUrl string, params LookupParams, query string, processRecord func([]byte) (bool, error)) (bool, error) {
+func (s *SruLookupAdapter) search(ctx context.Context, sruUrl string, params LookupParams, query string, processRecord func([]byte) (bool, error)) (bool, error) {
 	var sruResponse sru.SearchRetrieveResponse
 	query = "?maximumRecords=1000&recordSchema=" + url.QueryEscape(s.recordSchema) + "&" + query
 	if s.xTarget != "" {
 		query += "&x-target=" + url.QueryEscape(s.xTarget)
 	}
 	found := false
-	err := httpclient.NewClient().GetXml(s.client, sruUrl+query, &sruResponse)
+	err := httpclient.NewClient().WithContext(ctx).GetXml(s.client, sruUrl+query, &sruResponse)
 	// notice: returning query even in case of error, to allow logging the query that caused the error
 	if err != nil {
 		return false, err
@@ -108,7 +109,7 @@ func (s *SruLookupAdapter) search(sruUrl string, params LookupParams, query stri
 	return found, nil
 }
 
-func (s *SruLookupAdapter) lookupServer(sruUrl string, params LookupParams, processRecord func([]byte) (bool, error)) (bool, string, error) {
+func (s *SruLookupAdapter) lookupServer(ctx context.Context, sruUrl string, params LookupParams, processRecord func([]byte) (bool, error)) (bool, string, error) {
 	cqlList, pqfList, err := s.queryBuilder.Build(params)
 	if err != nil {
 		return false, "", err
@@ -118,7 +119,7 @@ func (s *SruLookupAdapter) lookupServer(sruUrl string, params LookupParams, proc
 	for _, cql := range cqlList {
 		query = cql
 		sruQuery := "query=" + url.QueryEscape(cql)
-		found, err = s.search(sruUrl, params, sruQuery, processRecord)
+		found, err = s.search(ctx, sruUrl, params, sruQuery, processRecord)
 		if err != nil || found {
 			return found, query, err
 		}
@@ -126,7 +127,7 @@ func (s *SruLookupAdapter) lookupServer(sruUrl string, params LookupParams, proc
 	for _, pqf := range pqfList {
 		query = pqf
 		sruQuery := "x-pquery=" + url.QueryEscape(pqf)
-		found, err = s.search(sruUrl, params, sruQuery, processRecord)
+		found, err = s.search(ctx, sruUrl, params, sruQuery, processRecord)
 		if err != nil || found {
 			return found, query, err
 		}
@@ -135,11 +136,15 @@ func (s *SruLookupAdapter) lookupServer(sruUrl string, params LookupParams, proc
 }
 
 func (s *SruLookupAdapter) Lookup(params LookupParams) (LookupResult, error) {
+	return s.lookup(context.Background(), params)
+}
+
+func (s *SruLookupAdapter) lookup(ctx context.Context, params LookupParams) (LookupResult, error) {
 	var result SruLookupResult
 
 	for _, sruUrl := range s.sruUrl {
 		var err error
-		found, query, err := s.lookupServer(sruUrl, params, func(xmlBuffer []byte) (bool, error) {
+		found, query, err := s.lookupServer(ctx, sruUrl, params, func(xmlBuffer []byte) (bool, error) {
 			h, err := s.holdingsParser.Parse(xmlBuffer, params)
 			if err != nil {
 				return false, err
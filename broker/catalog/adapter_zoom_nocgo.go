@@ -15,3 +15,7 @@ type ZoomLookupAdapter struct{}
 func NewZoomLookupAdapter(config dirapi.ZoomConfig, queryBuilder LookupQueryBuilder, holdingsParser HoldingsParser, metadataParser MetadataParser) (LookupAdapter, error) {
 	return nil, fmt.Errorf("ZOOM lookup adapter requires cgo, but cgo is not enabled")
 }
+
+func (a *ZoomLookupAdapter) Lookup(params LookupParams) (LookupResult, error) {
+	return nil, fmt.Errorf("ZOOM lookup adapter requires cgo, but cgo is not enabled")
+}
@@ -60,8 +60,50 @@ func (c *LookupAdapterCreatorImpl) GetAdapter(peer ill_db.Peer) (LookupAdapter,
 	if config == nil {
 		return nil, nil // No lookup adapter for this peer
 	}
+	if config.Sources == nil || len(*config.Sources) == 0 {
+		return c.createAdapter(*config)
+	}
+	return c.createFederatedAdapter(*config)
+}
+
+// sourceCatalogConfig returns the catalog configuration of a single source of a federated lookup
+func sourceCatalogConfig(source dirapi.CatalogSource) dirapi.CatalogConfig {
+	return dirapi.CatalogConfig{
+		Sru:            source.Sru,
+		Zoom:           source.Zoom,
+		QueryConfig:    source.QueryConfig,
+		HoldingsFormat: source.HoldingsFormat,
+		MetadataFormat: source.MetadataFormat,
+	}
+}
+
+func (c *LookupAdapterCreatorImpl) createFederatedAdapter(config dirapi.CatalogConfig) (LookupAdapter, error) {
+	var sources []FederatedSource
+	// the catalog configured directly takes part in the lookup when present
+	if config.Sru != nil || config.Zoom != nil {
+		adapter, err := c.createAdapter(config)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, FederatedSource{Name: DefaultCatalogSourceName, Adapter: adapter})
+	}
+	for _, source := range *config.Sources {
+		adapter, err := c.createAdapter(sourceCatalogConfig(source))
+		if err != nil {
+			return nil, fmt.Errorf("catalogConfig.sources %s: %w", source.Name, err)
+		}
+		var priority int32
+		if source.Priority != nil {
+			priority = *source.Priority
+		}
+		sources = append(sources, FederatedSource{Name: source.Name, Priority: priority, Adapter: adapter})
+	}
+	return NewFederatedLookupAdapter(sources), nil
+}
+
+func (c *LookupAdapterCreatorImpl) createAdapter(config dirapi.CatalogConfig) (LookupAdapter, error) {
 	if c.mode == LookupAdapterMock {
-		return NewMockLookupAdapter(*config)
+		return NewMockLookupAdapter(config)
 	}
 	holdingsParser, err := getHoldingsParser(config.HoldingsFormat)
 	if err != nil {
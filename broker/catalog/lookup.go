@@ -0,0 +1,38 @@
+package catalog
+
+import (
+	"context"
+	"time"
+
+	"github.com/indexdata/crosslink/broker/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// contextLookupAdapter is implemented by adapters passing the context on to their requests
+type contextLookupAdapter interface {
+	lookup(ctx context.Context, params LookupParams) (LookupResult, error)
+}
+
+// Lookup runs the lookup in a span and records its latency per adapter type
+func Lookup(ctx context.Context, adapter LookupAdapter, params LookupParams) (LookupResult, error) {
+	name := AdapterName(adapter)
+	ctx, span := tracing.Tracer().Start(ctx, "catalog lookup", trace.WithAttributes(
+		attribute.String("crosslink.catalog.adapter", name),
+		attribute.String("crosslink.catalog.identifier", params.Identifier)))
+	start := time.Now()
+	result, err := lookupWithContext(ctx, adapter, params)
+	observeLookup(name, start, err)
+	if result != nil {
+		span.SetAttributes(attribute.String("crosslink.catalog.query", result.GetQuery()))
+	}
+	tracing.End(span, err)
+	return result, err
+}
+
+func lookupWithContext(ctx context.Context, adapter LookupAdapter, params LookupParams) (LookupResult, error) {
+	if a, ok := adapter.(contextLookupAdapter); ok {
+		return a.lookup(ctx, params)
+	}
+	return adapter.Lookup(params)
+}
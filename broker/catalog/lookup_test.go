@@ -0,0 +1,50 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/indexdata/crosslink/broker/tracing"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestLookupSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(tracing.NewTracerProvider(sdktrace.WithSyncer(exporter), "test"))
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	adapter := NewFederatedLookupAdapter([]FederatedSource{
+		{Name: "ok", Adapter: &MockLookupShared{}},
+		{Name: "down", Adapter: &MockLookupAdapter{Err: errors.New("connection refused")}},
+	})
+	_, err := Lookup(context.Background(), adapter, LookupParams{Identifier: "id"})
+	assert.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 3)
+	parent := spans[2]
+	assert.Equal(t, "catalog lookup", parent.Name)
+	assert.Contains(t, parent.Attributes, attribute.String("crosslink.catalog.adapter", "federated"))
+	assert.Contains(t, parent.Attributes, attribute.String("crosslink.catalog.identifier", "id"))
+	assert.Equal(t, codes.Unset, parent.Status.Code)
+	adapters := map[attribute.Value]codes.Code{}
+	for _, span := range spans[:2] {
+		assert.Equal(t, parent.SpanContext.SpanID(), span.Parent.SpanID())
+		for _, attr := range span.Attributes {
+			if attr.Key == "crosslink.catalog.adapter" {
+				adapters[attr.Value] = span.Status.Code
+			}
+		}
+	}
+	assert.Equal(t, map[attribute.Value]codes.Code{
+		attribute.StringValue("mock-shared"): codes.Unset,
+		attribute.StringValue("mock"):        codes.Error,
+	}, adapters)
+}
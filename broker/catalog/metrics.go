@@ -0,0 +1,32 @@
+package catalog
+
+import (
+	"time"
+
+	"github.com/indexdata/crosslink/broker/metrics"
+)
+
+// observeLookup records the latency of a lookup started at start per adapter type
+func observeLookup(adapterName string, start time.Time, err error) {
+	metrics.CatalogLookupDuration.WithLabelValues(adapterName, metrics.Outcome(err)).Observe(metrics.Since(start))
+}
+
+// AdapterName returns the metrics label of the adapter
+func AdapterName(adapter LookupAdapter) string {
+	switch adapter.(type) {
+	case *SruLookupAdapter:
+		return "sru"
+	case *ZoomLookupAdapter:
+		return "zoom"
+	case *MetaproxyLookupAdapter:
+		return "metaproxy"
+	case *FederatedLookupAdapter:
+		return "federated"
+	case *MockLookupAdapter:
+		return "mock"
+	case *MockLookupShared:
+		return "mock-shared"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,55 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/indexdata/crosslink/broker/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func lookupCount(t *testing.T, adapter string, outcome string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	err := metrics.CatalogLookupDuration.WithLabelValues(adapter, outcome).(prometheus.Metric).Write(&m)
+	assert.NoError(t, err)
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestLookup(t *testing.T) {
+	ok := lookupCount(t, "mock", metrics.OutcomeOk)
+	failed := lookupCount(t, "mock", metrics.OutcomeError)
+
+	_, err := Lookup(context.Background(), &MockLookupAdapter{}, LookupParams{Identifier: "id"})
+	assert.NoError(t, err)
+	_, err = Lookup(context.Background(), &MockLookupAdapter{Err: errors.New("connection refused")}, LookupParams{Identifier: "id"})
+	assert.EqualError(t, err, "connection refused")
+
+	assert.Equal(t, ok+1, lookupCount(t, "mock", metrics.OutcomeOk))
+	assert.Equal(t, failed+1, lookupCount(t, "mock", metrics.OutcomeError))
+}
+
+func TestLookupFederatedSources(t *testing.T) {
+	federated := lookupCount(t, "federated", metrics.OutcomeOk)
+	sources := lookupCount(t, "mock-shared", metrics.OutcomeOk)
+
+	adapter := NewFederatedLookupAdapter([]FederatedSource{
+		{Name: "a", Adapter: &MockLookupShared{}},
+		{Name: "b", Adapter: &MockLookupShared{}},
+	})
+	_, err := Lookup(context.Background(), adapter, LookupParams{Identifier: "id"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, federated+1, lookupCount(t, "federated", metrics.OutcomeOk))
+	assert.Equal(t, sources+2, lookupCount(t, "mock-shared", metrics.OutcomeOk))
+}
+
+func TestAdapterName(t *testing.T) {
+	assert.Equal(t, "sru", AdapterName(&SruLookupAdapter{}))
+	assert.Equal(t, "metaproxy", AdapterName(&MetaproxyLookupAdapter{}))
+	assert.Equal(t, "zoom", AdapterName(&ZoomLookupAdapter{}))
+	assert.Equal(t, "other", AdapterName(nil))
+}
@@ -0,0 +1,112 @@
+// Package channel sends short text notifications to patrons over channels other than email, e.g. SMS or push.
+package channel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/indexdata/go-utils/utils"
+)
+
+// Environment variables for the SMS and push providers.
+var (
+	SMS_PROVIDER_URL    = utils.GetEnv("SMS_PROVIDER_URL", "")
+	SMS_PROVIDER_TOKEN  = utils.GetEnv("SMS_PROVIDER_TOKEN", "")
+	SMS_SENDER          = utils.GetEnv("SMS_SENDER", "")
+	PUSH_PROVIDER_URL   = utils.GetEnv("PUSH_PROVIDER_URL", "")
+	PUSH_PROVIDER_TOKEN = utils.GetEnv("PUSH_PROVIDER_TOKEN", "")
+)
+
+const DEFAULT_TIMEOUT = 10 * time.Second
+
+// Channel delivers a text message to a recipient, a phone number for SMS or a device token for push.
+type Channel interface {
+	Send(ctx context.Context, to string, text string) error
+	IsReadyToSend() bool
+}
+
+// Message is the JSON body posted to an HTTP provider.
+type Message struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to"`
+	Text string `json:"text"`
+}
+
+// ProviderError is returned when a provider does not accept a message.
+type ProviderError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("provider returned HTTP status %d: %s", e.StatusCode, e.Body)
+}
+
+// IsPermanentFailure reports whether err is a provider rejecting the message itself (4xx status),
+// for which retrying is pointless. Timeouts and rate limits are retried.
+func IsPermanentFailure(err error) bool {
+	var providerErr *ProviderError
+	return errors.As(err, &providerErr) && providerErr.StatusCode >= 400 && providerErr.StatusCode < 500 &&
+		providerErr.StatusCode != http.StatusRequestTimeout && providerErr.StatusCode != http.StatusTooManyRequests
+}
+
+// HttpChannel posts each message as JSON to a provider URL, authenticated with an optional bearer token.
+// Any 2xx status is success.
+type HttpChannel struct {
+	client *http.Client
+	url    string
+	token  string
+	from   string
+}
+
+func NewHttpChannel(client *http.Client, url string, token string, from string) *HttpChannel {
+	return &HttpChannel{client: client, url: url, token: token, from: from}
+}
+
+// NewSmsChannel returns the SMS channel configured by the SMS_PROVIDER_* environment variables.
+func NewSmsChannel() *HttpChannel {
+	return NewHttpChannel(&http.Client{Timeout: DEFAULT_TIMEOUT}, SMS_PROVIDER_URL, SMS_PROVIDER_TOKEN, SMS_SENDER)
+}
+
+// NewPushChannel returns the push channel configured by the PUSH_PROVIDER_* environment variables.
+func NewPushChannel() *HttpChannel {
+	return NewHttpChannel(&http.Client{Timeout: DEFAULT_TIMEOUT}, PUSH_PROVIDER_URL, PUSH_PROVIDER_TOKEN, "")
+}
+
+func (c *HttpChannel) IsReadyToSend() bool {
+	return c.url != ""
+}
+
+func (c *HttpChannel) Send(ctx context.Context, to string, text string) error {
+	if !c.IsReadyToSend() {
+		return errors.New("channel provider not configured")
+	}
+	body, err := json.Marshal(Message{From: c.from, To: to, Text: text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &ProviderError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package channel_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/indexdata/crosslink/broker/channel"
+	"github.com/indexdata/crosslink/broker/channel/channeltest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpChannel_Send(t *testing.T) {
+	provider := channeltest.NewProvider()
+	defer provider.Close()
+	sms := channel.NewHttpChannel(http.DefaultClient, provider.URL, "secret", "Library")
+
+	assert.True(t, sms.IsReadyToSend())
+	assert.NoError(t, sms.Send(context.Background(), "+4512345678", "Your book is ready"))
+
+	assert.Equal(t, []channel.Message{{From: "Library", To: "+4512345678", Text: "Your book is ready"}}, provider.Messages())
+	assert.Equal(t, "Bearer secret", provider.Headers()[0].Get("Authorization"))
+	assert.Equal(t, "application/json", provider.Headers()[0].Get("Content-Type"))
+}
+
+func TestHttpChannel_SendWithoutToken(t *testing.T) {
+	provider := channeltest.NewProvider()
+	defer provider.Close()
+	push := channel.NewHttpChannel(http.DefaultClient, provider.URL, "", "")
+
+	assert.NoError(t, push.Send(context.Background(), "device-1", "Your book is ready"))
+
+	assert.Equal(t, []channel.Message{{To: "device-1", Text: "Your book is ready"}}, provider.Messages())
+	assert.Empty(t, provider.Headers()[0].Get("Authorization"))
+}
+
+func TestHttpChannel_SendRejected(t *testing.T) {
+	provider := channeltest.NewProvider()
+	defer provider.Close()
+	provider.Fail(http.StatusUnprocessableEntity)
+	sms := channel.NewHttpChannel(http.DefaultClient, provider.URL, "", "")
+
+	err := sms.Send(context.Background(), "invalid", "text")
+
+	var providerErr *channel.ProviderError
+	if assert.True(t, errors.As(err, &providerErr)) {
+		assert.Equal(t, http.StatusUnprocessableEntity, providerErr.StatusCode)
+	}
+	assert.True(t, channel.IsPermanentFailure(err))
+	assert.Empty(t, provider.Messages())
+}
+
+func TestIsPermanentFailure(t *testing.T) {
+	assert.True(t, channel.IsPermanentFailure(&channel.ProviderError{StatusCode: http.StatusBadRequest}))
+	assert.False(t, channel.IsPermanentFailure(&channel.ProviderError{StatusCode: http.StatusTooManyRequests}))
+	assert.False(t, channel.IsPermanentFailure(&channel.ProviderError{StatusCode: http.StatusRequestTimeout}))
+	assert.False(t, channel.IsPermanentFailure(&channel.ProviderError{StatusCode: http.StatusBadGateway}))
+	assert.False(t, channel.IsPermanentFailure(errors.New("connection refused")))
+}
+
+func TestHttpChannel_NotConfigured(t *testing.T) {
+	sms := channel.NewHttpChannel(http.DefaultClient, "", "", "")
+
+	assert.False(t, sms.IsReadyToSend())
+	assert.EqualError(t, sms.Send(context.Background(), "+4512345678", "text"), "channel provider not configured")
+}
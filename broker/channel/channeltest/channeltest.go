@@ -0,0 +1,72 @@
+// Package channeltest provides a stand-in HTTP provider for tests that records the messages it receives.
+package channeltest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/indexdata/crosslink/broker/channel"
+)
+
+// Provider accepts messages posted by channel.HttpChannel.
+type Provider struct {
+	URL      string
+	server   *httptest.Server
+	mu       sync.Mutex
+	messages []channel.Message
+	headers  []http.Header
+	status   int
+}
+
+// NewProvider starts a provider on a free local port.
+func NewProvider() *Provider {
+	p := &Provider{}
+	p.server = httptest.NewServer(http.HandlerFunc(p.handle))
+	p.URL = p.server.URL
+	return p
+}
+
+// Messages returns the messages accepted so far.
+func (p *Provider) Messages() []channel.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]channel.Message(nil), p.messages...)
+}
+
+// Headers returns the request headers of the accepted messages.
+func (p *Provider) Headers() []http.Header {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]http.Header(nil), p.headers...)
+}
+
+// Fail makes the provider reject messages with status, until it is called with 0.
+func (p *Provider) Fail(status int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status = status
+}
+
+// Close stops the provider.
+func (p *Provider) Close() {
+	p.server.Close()
+}
+
+func (p *Provider) handle(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.status != 0 {
+		http.Error(w, http.StatusText(p.status), p.status)
+		return
+	}
+	var message channel.Message
+	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p.messages = append(p.messages, message)
+	p.headers = append(p.headers, r.Header.Clone())
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -16,10 +16,14 @@ import (
 	"github.com/indexdata/crosslink/broker/common"
 	"github.com/indexdata/crosslink/broker/events"
 	"github.com/indexdata/crosslink/broker/ill_db"
+	"github.com/indexdata/crosslink/broker/metrics"
+	"github.com/indexdata/crosslink/broker/tracing"
 	"github.com/indexdata/crosslink/httpclient"
 	"github.com/indexdata/crosslink/iso18626"
 	"github.com/indexdata/go-utils/utils"
 	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const CLIENT_COMP = "iso18626_client"
@@ -465,11 +469,13 @@ func (c *Iso18626Client) HandleIllMessage(ctx common.ExtendedContext, peer *ill_
 	if strings.EqualFold(peer.Vendor, string(dirapi.CrossLink)) {
 		return c.prMessageHandler.HandleMessage(ctx, msg, peer)
 	}
-	return c.SendHttpPost(peer, msg)
+	return c.SendHttpPost(ctx, peer, msg)
 }
 
-func (c *Iso18626Client) SendHttpPost(peer *ill_db.Peer, msg *iso18626.ISO18626Message) (*iso18626.ISO18626Message, error) {
+func (c *Iso18626Client) SendHttpPost(ctx common.ExtendedContext, peer *ill_db.Peer, msg *iso18626.ISO18626Message) (*iso18626.ISO18626Message, error) {
+	ctx, span := tracing.Start(ctx, "iso18626 send", trace.WithAttributes(attribute.String("crosslink.peer", peer.Name)))
 	httpClient := httpclient.NewClient().
+		WithContext(ctx).
 		WithMaxSize(int64(c.maxMsgSize)).
 		WithHeaders("User-Agent", vcs.GetSignature())
 	for k, v := range peer.HttpHeaders {
@@ -478,6 +484,7 @@ func (c *Iso18626Client) SendHttpPost(peer *ill_db.Peer, msg *iso18626.ISO18626M
 	time.Sleep(c.sendDelay)
 	iso18626Shim := getPeerShim(peer)
 	var resmsg iso18626.ISO18626Message
+	start := time.Now()
 	err := httpClient.RequestResponse(c.client, http.MethodPost,
 		[]string{httpclient.ContentTypeApplicationXml, httpclient.ContentTypeTextXml},
 		peer.Url, msg, &resmsg, func(v any) ([]byte, error) {
@@ -493,7 +500,10 @@ func (c *Iso18626Client) SendHttpPost(peer *ill_db.Peer, msg *iso18626.ISO18626M
 				return fmt.Errorf("v is not a *iso18626.ISO18626Message: %v", v)
 			}
 		})
+	metrics.IsoSendDuration.WithLabelValues(peer.Name).Observe(metrics.Since(start))
+	tracing.End(span, err)
 	if err != nil {
+		metrics.IsoSendFailures.WithLabelValues(peer.Name).Inc()
 		return nil, err
 	}
 	return &resmsg, nil
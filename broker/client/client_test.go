@@ -94,7 +94,7 @@ func TestSendHttpPost(t *testing.T) {
 		Url:         server.URL,
 		HttpHeaders: headers,
 	}
-	_, err := client.SendHttpPost(&peer, msg)
+	_, err := client.SendHttpPost(common.CreateExtCtxWithArgs(context.Background(), nil), &peer, msg)
 	assert.NoError(t, err)
 }
 
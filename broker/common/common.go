@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const MULTIPLE_ITEMS = "#MultipleItems#"
@@ -144,3 +146,15 @@ func ToInt32(i int) int32 {
 		return int32(i)
 	}
 }
+
+// ParseDurationWithDays parses a time.Duration that may also be given in days, for example "30d"
+func ParseDurationWithDays(value string) (time.Duration, error) {
+	if !strings.HasSuffix(value, "d") {
+		return time.ParseDuration(value)
+	}
+	days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(days) * 24 * time.Hour, nil
+}
@@ -8,6 +8,7 @@ import (
 	"strconv"
 
 	"github.com/indexdata/go-utils/utils"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var pid = utils.Must(os.Hostname()) + "/" + strconv.Itoa(os.Getpid())
@@ -20,6 +21,8 @@ type ExtendedContext interface {
 	WithArgs(args *LoggerArgs) ExtendedContext
 	// return logger args associated with this context
 	LoggerArgs() LoggerArgs
+	// create new instance backed by the given context, e.g. carrying a trace span, with the same log handler and args
+	WithContext(ctx context.Context) ExtendedContext
 }
 
 func Must[T any](ctx ExtendedContext, handler func() (ret T, err error), errMsg string) T {
@@ -76,6 +79,10 @@ func (ctx *_ExtCtxImpl) WithArgs(args *LoggerArgs) ExtendedContext {
 	return CreateExtCtxWithLogArgsAndHandler(ctx.Context, args, ctx.logHandler)
 }
 
+func (ctx *_ExtCtxImpl) WithContext(goCtx context.Context) ExtendedContext {
+	return CreateExtCtxWithLogArgsAndHandler(goCtx, ctx.loggerArgs, ctx.logHandler)
+}
+
 func (ctx *_ExtCtxImpl) LoggerArgs() LoggerArgs {
 	if ctx.loggerArgs == nil {
 		return LoggerArgs{}
@@ -93,6 +100,9 @@ func CreateExtCtxWithLogArgsAndHandler(ctx context.Context, args *LoggerArgs, lo
 	extctx.logHandler = logHandler
 	extctx.loggerArgs = args
 	extctx.logger = createChildLoggerWithArgs(slog.New(logHandler), args)
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+		extctx.logger = extctx.logger.With("traceId", spanCtx.TraceID().String())
+	}
 	return &extctx
 }
 
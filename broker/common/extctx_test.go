@@ -113,3 +113,13 @@ func TestMustHttpWithoutErrorMessage(t *testing.T) {
 		return nil, errorToThrow
 	}, "")
 }
+
+func TestWithContext(t *testing.T) {
+	type key struct{}
+	args := &LoggerArgs{TransactionId: "test-transaction-id"}
+	extCtx := CreateExtCtxWithArgs(context.Background(), args)
+	extCtx2 := extCtx.WithContext(context.WithValue(context.Background(), key{}, "value"))
+	assert.Equal(t, "value", extCtx2.Value(key{}))
+	assert.Nil(t, extCtx.Value(key{}))
+	assert.Equal(t, *args, extCtx2.LoggerArgs())
+}
@@ -43,10 +43,11 @@ type EmailData struct {
 	IncludePdf bool     `json:"includePdf,omitempty"`
 }
 
-// PdfAttach holds a PDF file to attach to the email.
-type PdfAttach struct {
-	Filename string
-	Data     []byte
+// Attachment holds a file to attach to the email, ContentType defaults to application/pdf.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
 }
 
 type EmailService interface {
@@ -90,9 +91,15 @@ func (s *EmailServiceImpl) IsReadyToSend() bool {
 	return s.readyToSend
 }
 
+// IsPermanentFailure reports whether err is a permanent SMTP rejection (5xx reply), for which retrying is pointless.
+func IsPermanentFailure(err error) bool {
+	var smtpErr *textproto.Error
+	return errors.As(err, &smtpErr) && smtpErr.Code >= 500 && smtpErr.Code < 600
+}
+
 // BuildRawMessage constructs a MIME multipart/mixed raw message.
-// If attachment is non-nil its bytes are included as a PDF attachment.
-func BuildRawMessage(fromAddr string, data EmailData, attachment *PdfAttach) ([]byte, error) {
+// Each attachment is included as a base64 encoded part after the body.
+func BuildRawMessage(fromAddr string, data EmailData, attachments ...Attachment) ([]byte, error) {
 	if strings.ContainsAny(fromAddr, "\r\n") {
 		return nil, errors.New("header injection detected in fromAddr")
 	}
@@ -104,6 +111,11 @@ func BuildRawMessage(fromAddr string, data EmailData, attachment *PdfAttach) ([]
 			return nil, errors.New("header injection detected in to address")
 		}
 	}
+	for _, attachment := range attachments {
+		if strings.ContainsAny(attachment.Filename, "\r\n\"") || strings.ContainsAny(attachment.ContentType, "\r\n") {
+			return nil, errors.New("header injection detected in attachment")
+		}
+	}
 
 	var buf bytes.Buffer
 
@@ -139,10 +151,14 @@ func BuildRawMessage(fromAddr string, data EmailData, attachment *PdfAttach) ([]
 		return nil, fmt.Errorf("close qp writer: %w", err)
 	}
 
-	// PDF attachment part.
-	if attachment != nil {
+	// Attachment parts.
+	for _, attachment := range attachments {
+		contentType := attachment.ContentType
+		if contentType == "" {
+			contentType = "application/pdf"
+		}
 		attHeaders := make(textproto.MIMEHeader)
-		attHeaders.Set("Content-Type", `application/pdf; name="`+attachment.Filename+`"`)
+		attHeaders.Set("Content-Type", contentType+`; name="`+attachment.Filename+`"`)
 		attHeaders.Set("Content-Transfer-Encoding", "base64")
 		attHeaders.Set("Content-Disposition", `attachment; filename="`+attachment.Filename+`"`)
 
@@ -1,8 +1,10 @@
 package email
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/indexdata/crosslink/broker/email/smtptest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -34,7 +36,7 @@ func TestBuildRawMessage_PlainTextHeaders(t *testing.T) {
 		Subject: "Hello",
 		Body:    "Plain text body",
 	}
-	raw, err := BuildRawMessage("from@example.com", data, nil)
+	raw, err := BuildRawMessage("from@example.com", data)
 	assert.NoError(t, err)
 	msg := string(raw)
 	assert.Contains(t, msg, "From: from@example.com")
@@ -51,7 +53,7 @@ func TestBuildRawMessage_HTMLBody(t *testing.T) {
 		Body:   "<p>HTML body</p>",
 		IsHTML: true,
 	}
-	raw, err := BuildRawMessage("from@example.com", data, nil)
+	raw, err := BuildRawMessage("from@example.com", data)
 	assert.NoError(t, err)
 	assert.Contains(t, string(raw), "text/html")
 }
@@ -61,7 +63,7 @@ func TestBuildRawMessage_MultipleRecipients(t *testing.T) {
 		To:   []string{"a@b.com", "c@d.com"},
 		Body: "body",
 	}
-	raw, err := BuildRawMessage("from@example.com", data, nil)
+	raw, err := BuildRawMessage("from@example.com", data)
 	assert.NoError(t, err)
 	assert.Contains(t, string(raw), "a@b.com, c@d.com")
 }
@@ -71,7 +73,7 @@ func TestBuildRawMessage_WithPDFAttachment(t *testing.T) {
 		To:   []string{"to@example.com"},
 		Body: "body with attachment",
 	}
-	att := &PdfAttach{Filename: "pull-slips.pdf", Data: []byte("%PDF-1.4 fake")}
+	att := Attachment{Filename: "pull-slips.pdf", Data: []byte("%PDF-1.4 fake")}
 	raw, err := BuildRawMessage("from@example.com", data, att)
 	assert.NoError(t, err)
 	msg := string(raw)
@@ -80,9 +82,77 @@ func TestBuildRawMessage_WithPDFAttachment(t *testing.T) {
 	assert.Contains(t, msg, "Content-Transfer-Encoding: base64")
 }
 
+func TestBuildRawMessage_WithMultipleAttachments(t *testing.T) {
+	data := EmailData{To: []string{"to@example.com"}, Body: "report"}
+	raw, err := BuildRawMessage("from@example.com", data,
+		Attachment{Filename: "report.csv", ContentType: "text/csv; charset=UTF-8", Data: []byte("id\n1\n")},
+		Attachment{Filename: "report.xlsx", ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", Data: []byte("PK")})
+	assert.NoError(t, err)
+	msg := string(raw)
+	assert.Contains(t, msg, `Content-Type: text/csv; charset=UTF-8; name="report.csv"`)
+	assert.Contains(t, msg, `attachment; filename="report.csv"`)
+	assert.Contains(t, msg, `application/vnd.openxmlformats-officedocument.spreadsheetml.sheet; name="report.xlsx"`)
+	assert.Contains(t, msg, `attachment; filename="report.xlsx"`)
+	assert.NotContains(t, msg, "application/pdf")
+}
+
+func TestBuildRawMessage_AttachmentHeaderInjection(t *testing.T) {
+	data := EmailData{To: []string{"to@example.com"}, Body: "body"}
+	_, err := BuildRawMessage("from@example.com", data, Attachment{Filename: "a.csv\r\nBcc: x@y.com"})
+	assert.EqualError(t, err, "header injection detected in attachment")
+}
+
 func TestBuildRawMessage_WithoutAttachment(t *testing.T) {
 	data := EmailData{To: []string{"to@example.com"}, Body: "body"}
-	raw, err := BuildRawMessage("from@example.com", data, nil)
+	raw, err := BuildRawMessage("from@example.com", data)
 	assert.NoError(t, err)
 	assert.NotContains(t, string(raw), "application/pdf")
 }
+
+// ---------------------------------------------------------------------------
+// EmailServiceImpl with a local SMTP server
+// ---------------------------------------------------------------------------
+
+func newSmtpEmailService(t *testing.T) (*EmailServiceImpl, *smtptest.Server) {
+	server, err := smtptest.NewServer()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = server.Close() })
+	host, port := SMTP_HOST, SMTP_PORT
+	SMTP_HOST, SMTP_PORT = server.Host, server.Port
+	t.Cleanup(func() { SMTP_HOST, SMTP_PORT = host, port })
+	return NewEmailService(), server
+}
+
+func TestSendEmail_DeliversToSmtpServer(t *testing.T) {
+	service, server := newSmtpEmailService(t)
+	raw, err := BuildRawMessage("from@example.com", EmailData{To: []string{"to@example.com"}, Subject: "Hello", Body: "Body"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, service.SendEmail("from@example.com", []string{"to@example.com"}, raw))
+
+	messages := server.Messages()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "from@example.com", messages[0].From)
+	assert.Equal(t, []string{"to@example.com"}, messages[0].To)
+	assert.Contains(t, string(messages[0].Data), "Subject: Hello")
+}
+
+func TestSendEmail_Rejected(t *testing.T) {
+	service, server := newSmtpEmailService(t)
+
+	server.Reject("451 try again later")
+	err := service.SendEmail("from@example.com", []string{"to@example.com"}, []byte("Subject: x\r\n\r\nx"))
+	assert.ErrorContains(t, err, "try again later")
+	assert.False(t, IsPermanentFailure(err))
+
+	server.Reject("550 no such user")
+	err = service.SendEmail("from@example.com", []string{"to@example.com"}, []byte("Subject: x\r\n\r\nx"))
+	assert.ErrorContains(t, err, "no such user")
+	assert.True(t, IsPermanentFailure(err))
+	assert.Empty(t, server.Messages())
+}
+
+func TestIsPermanentFailure_OtherErrors(t *testing.T) {
+	assert.False(t, IsPermanentFailure(nil))
+	assert.False(t, IsPermanentFailure(errors.New("connection refused")))
+}
@@ -0,0 +1,169 @@
+// Package smtptest provides a local SMTP server for tests that records the messages it receives.
+package smtptest
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"strings"
+	"sync"
+)
+
+// Message is a message accepted by the server.
+type Message struct {
+	From string
+	To   []string
+	Data []byte
+}
+
+// Server is a minimal SMTP server listening on a local port, it supports no TLS and no authentication.
+type Server struct {
+	Addr     string
+	Host     string
+	Port     string
+	listener net.Listener
+	mu       sync.Mutex
+	messages []Message
+	reply    string
+	wg       sync.WaitGroup
+}
+
+// NewServer starts a server on a free local port.
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	s := &Server{Addr: listener.Addr().String(), Host: host, Port: port, listener: listener}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Messages returns the messages accepted so far.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Reject makes the server answer recipients with reply, e.g. "451 try again later" or
+// "550 no such user", until it is called with an empty reply.
+func (s *Server) Reject(reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reply = reply
+}
+
+// Close stops the server and waits for open connections to finish.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *Server) rejectReply() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reply
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	write := func(line string) {
+		_, _ = w.WriteString(line + "\r\n")
+		_ = w.Flush()
+	}
+	write("220 smtptest ready")
+	var msg Message
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			write("250 smtptest")
+		case "MAIL":
+			msg = Message{From: address(arg)}
+			write("250 OK")
+		case "RCPT":
+			if reply := s.rejectReply(); reply != "" {
+				write(reply)
+				continue
+			}
+			msg.To = append(msg.To, address(arg))
+			write("250 OK")
+		case "DATA":
+			write("354 end data with <CR><LF>.<CR><LF>")
+			data, err := readData(r)
+			if err != nil {
+				return
+			}
+			msg.Data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			msg = Message{}
+			write("250 OK")
+		case "RSET":
+			msg = Message{}
+			write("250 OK")
+		case "NOOP":
+			write("250 OK")
+		case "QUIT":
+			write("221 bye")
+			return
+		default:
+			write("502 command not implemented")
+		}
+	}
+}
+
+// address extracts the address of a MAIL FROM:<a> or RCPT TO:<a> argument.
+func address(arg string) string {
+	_, value, _ := strings.Cut(arg, ":")
+	value = strings.TrimSpace(value)
+	if end := strings.Index(value, ">"); end >= 0 {
+		value = value[:end]
+	}
+	return strings.TrimPrefix(value, "<")
+}
+
+func readData(r *bufio.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if line == ".\r\n" || line == ".\n" {
+			return buf.Bytes(), nil
+		}
+		buf.WriteString(strings.TrimPrefix(line, "."))
+	}
+}
@@ -5,28 +5,56 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"os"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/metrics"
+	"github.com/indexdata/crosslink/broker/tracing"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const EVENT_BUS_CHANNEL = "crosslink_channel"
 const EB_COMP = "event_bus"
 const DEFAULT_ILL_TRANSACTION_ID = "00000000-0000-0000-0000-000000000001"
 const DEFAULT_PATRON_REQUEST_ID = "00000000-0000-0000-0000-000000000002"
+const DEFAULT_TASK_LEASE = 2 * time.Minute
+const DEFAULT_TASK_SWEEP_INTERVAL = 1 * time.Minute
+const DEFAULT_TASK_MAX_ATTEMPTS = 3
+const SWEEP_BATCH_SIZE = 100
+const DEFAULT_SIGNAL_POLL_INTERVAL = 30 * time.Second
+const DEFAULT_SIGNAL_RETENTION = 24 * time.Hour
+const SIGNAL_BATCH_SIZE = 500
+
+// signals committed out of sequence order may be behind the cursor, so catch-up
+// re-reads this many signals before it and skips those already handled
+const SIGNAL_CATCH_UP_OVERLAP = 100
+const SEEN_SIGNALS_MAX = 1000
+
+// ErrTaskNotStuck is returned when re-driving a task that is not PROCESSING or whose lease is still held
+var ErrTaskNotStuck = errors.New("task is not stuck")
+
+// ErrLeaseLost is returned when completing a task whose lease has been taken over by another owner
+var ErrLeaseLost = errors.New("task lease lost to another owner")
+
+// ErrTaskNotFailed is returned when re-running or resolving a task that did not fail or was already handled
+var ErrTaskNotFailed = errors.New("task is not failed")
 
 type EventBus interface {
 	Start(ctx common.ExtendedContext) error
-	CreateTask(id string, eventName EventName, data EventData, eventDomain EventDomain, parentId *string, target SignalTarget) (string, error)
-	CreateNotice(id string, eventName EventName, data EventData, status EventStatus, eventDomain EventDomain, target SignalTarget) (string, error)
+	// CreateTask creates a task, the trace context of ctx is stored with it so that the handlers continue the trace.
+	CreateTask(ctx common.ExtendedContext, id string, eventName EventName, data EventData, eventDomain EventDomain, parentId *string, target SignalTarget) (string, error)
+	CreateNotice(ctx common.ExtendedContext, id string, eventName EventName, data EventData, status EventStatus, eventDomain EventDomain, target SignalTarget) (string, error)
 	// CreateNoticeWithParent creates a notice linked to a parent event.
-	CreateNoticeWithParent(id string, eventName EventName, data EventData, status EventStatus, eventDomain EventDomain, parentId *string, target SignalTarget) (string, error)
+	CreateNoticeWithParent(ctx common.ExtendedContext, id string, eventName EventName, data EventData, status EventStatus, eventDomain EventDomain, parentId *string, target SignalTarget) (string, error)
 	// BeginTask marks a task as processing and emits SignalTaskBegin to the selected target.
 	BeginTask(eventId string, target SignalTarget) (Event, error)
 	// CompleteTask marks a task as finished and emits SignalTaskComplete to the selected target.
@@ -43,6 +71,12 @@ type EventBus interface {
 	ProcessExclusiveTask(ctx common.ExtendedContext, event Event, target SignalTarget, h func(common.ExtendedContext, Event) (EventStatus, *EventResult)) (Event, error)
 	FindAncestor(descendant *Event, eventName EventName) *Event
 	GetLatestRequestEventByAction(ctx common.ExtendedContext, illTransId string, action string) (Event, error)
+	// RequeueTask moves a PROCESSING task with an expired or missing lease back to NEW and signals consumers.
+	RequeueTask(eventId string) (Event, error)
+	// RerunTask creates a copy of a failed task as its child and signals consumers, the failed task is marked as re-run.
+	RerunTask(eventId string, note string) (Event, error)
+	// ResolveTask marks a failed task as resolved and records the note in a task-resolved notice linked to it.
+	ResolveTask(eventId string, note string) (Event, error)
 }
 
 type PostgresEventBus struct {
@@ -51,14 +85,37 @@ type PostgresEventBus struct {
 	ConnectionString string
 	handlers         map[Signal]map[HandlerRole]map[EventName][]func(ctx common.ExtendedContext, event Event)
 	randGen          *rand.Rand // local random generator to avoid same seed for all instance, only needed in Go < 1.20
+	instanceId       string
+	// LeaseDuration is how long a task may run without a heartbeat before it is considered orphaned
+	LeaseDuration time.Duration
+	// SweepInterval is how often orphaned tasks are looked for, zero disables the sweeper
+	SweepInterval time.Duration
+	// MaxTaskAttempts is how many times a task is started before an orphaned task is failed instead of re-queued
+	MaxTaskAttempts int32
+	// SignalPollInterval is how often signals not claimed by any consumer are re-sent, zero disables polling
+	SignalPollInterval time.Duration
+	// SignalRetention is how long signals are kept in the event_signal log
+	SignalRetention time.Duration
+	signalMu        sync.Mutex
+	signalFloor     int64 // last signal logged before this instance started listening
+	signalCursor    int64 // highest signal handled by this instance
+	seenSignals     map[int64]struct{}
 }
 
 func NewPostgresEventBus(repo EventRepo, connString string) *PostgresEventBus {
+	hostname, _ := os.Hostname()
 	return &PostgresEventBus{
 		repo:             repo,
 		ConnectionString: connString,
 		// #nosec G404 - math/rand is sufficient for connection jitter
-		randGen: rand.New(rand.NewSource(time.Now().UnixNano())),
+		randGen:         rand.New(rand.NewSource(time.Now().UnixNano())),
+		instanceId:      hostname + "-" + uuid.New().String()[:8],
+		LeaseDuration:   DEFAULT_TASK_LEASE,
+		SweepInterval:   DEFAULT_TASK_SWEEP_INTERVAL,
+		MaxTaskAttempts: DEFAULT_TASK_MAX_ATTEMPTS,
+
+		SignalPollInterval: DEFAULT_SIGNAL_POLL_INTERVAL,
+		SignalRetention:    DEFAULT_SIGNAL_RETENTION,
 	}
 }
 
@@ -87,6 +144,14 @@ func (p *PostgresEventBus) Start(ctx common.ExtendedContext) error {
 	if err = connectAndListen(); err != nil {
 		return err
 	}
+	// read after LISTEN so that later signals are either notified or caught up
+	latest, err := p.repo.GetLatestEventSignalId(p.ctx)
+	if err != nil {
+		ctx.Logger().Error("unable to read event signal log", "error", err)
+		return err
+	}
+	p.signalFloor = latest
+	p.signalCursor = latest
 
 	go func() {
 		for {
@@ -115,6 +180,7 @@ func (p *PostgresEventBus) Start(ctx common.ExtendedContext) error {
 
 						if err = connectAndListen(); err == nil {
 							ctx.Logger().Info("successfully reconnected")
+							go p.CatchUpSignals()
 							break // Exit the retry loop on success
 						}
 						ctx.Logger().Error("reconnection attempt failed", "error", err, "next_try_in", delay)
@@ -138,12 +204,130 @@ func (p *PostgresEventBus) Start(ctx common.ExtendedContext) error {
 			if err != nil {
 				ctx.Logger().Error("failed to unmarshal notification", "error", err, "payload", notification.Payload)
 			}
+			if notifyData.Seq != 0 && !p.markSignalSeen(notifyData.Seq) {
+				continue
+			}
 			go p.handleNotify(notifyData)
 		}
 	}()
+	if p.SweepInterval > 0 {
+		go p.runSweeper(ctx)
+	}
+	if p.SignalPollInterval > 0 {
+		go p.runSignalPoller(ctx)
+	}
 	return nil
 }
 
+// markSignalSeen returns false if the signal has been handled by this instance already
+func (p *PostgresEventBus) markSignalSeen(seq int64) bool {
+	p.signalMu.Lock()
+	defer p.signalMu.Unlock()
+	if _, ok := p.seenSignals[seq]; ok {
+		return false
+	}
+	if p.seenSignals == nil {
+		p.seenSignals = make(map[int64]struct{})
+	}
+	p.seenSignals[seq] = struct{}{}
+	if seq > p.signalCursor {
+		p.signalCursor = seq
+	}
+	if len(p.seenSignals) > SEEN_SIGNALS_MAX {
+		for s := range p.seenSignals {
+			if s <= p.signalCursor-SIGNAL_CATCH_UP_OVERLAP {
+				delete(p.seenSignals, s)
+			}
+		}
+	}
+	return true
+}
+
+// CatchUpSignals handles the signals logged after the cursor of this instance,
+// e.g. those sent while the LISTEN connection was down
+func (p *PostgresEventBus) CatchUpSignals() {
+	p.signalMu.Lock()
+	from := max(p.signalFloor, p.signalCursor-SIGNAL_CATCH_UP_OVERLAP)
+	p.signalMu.Unlock()
+	count := 0
+	for {
+		signals, err := p.repo.ListEventSignalsAfter(p.ctx, ListEventSignalsAfterParams{
+			ID:    from,
+			Limit: SIGNAL_BATCH_SIZE,
+		})
+		if err != nil {
+			p.ctx.Logger().Error("failed to list event signals", "error", err, "after", from)
+			return
+		}
+		for _, signal := range signals {
+			from = signal.ID
+			if p.markSignalSeen(signal.ID) {
+				count++
+				go p.handleNotify(NotifyData{
+					Event:  signal.EventID,
+					Signal: Signal(signal.Signal),
+					Target: SignalTarget(signal.Target),
+					Seq:    signal.ID,
+				})
+			}
+		}
+		if len(signals) < SIGNAL_BATCH_SIZE {
+			break
+		}
+	}
+	if count > 0 {
+		p.ctx.Logger().Info("caught up with missed event signals", "count", count)
+	}
+}
+
+func (p *PostgresEventBus) runSignalPoller(ctx common.ExtendedContext) {
+	ticker := time.NewTicker(p.SignalPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.PollUnclaimedSignals()
+		}
+	}
+}
+
+// PollUnclaimedSignals re-sends to consumers the signals that no instance has claimed
+// within a poll interval, e.g. because all instances were down or disconnected when
+// they were sent. Claiming makes sure each is still handled only once. Signals older
+// than the retention are removed from the log.
+func (p *PostgresEventBus) PollUnclaimedSignals() {
+	signals, err := p.repo.ListUnclaimedEventSignals(p.ctx, ListUnclaimedEventSignalsParams{
+		Limit: SIGNAL_BATCH_SIZE,
+		CreatedBefore: pgtype.Timestamp{
+			Time:  time.Now().Add(-p.SignalPollInterval),
+			Valid: true,
+		},
+	})
+	if err != nil {
+		p.ctx.Logger().Error("failed to list unclaimed event signals", "error", err)
+	}
+	for _, signal := range signals {
+		p.ctx.Logger().Warn("re-sending unclaimed event signal", "eventId", signal.EventID, "signal", signal.Signal)
+		go p.handleNotify(NotifyData{
+			Event:  signal.EventID,
+			Signal: Signal(signal.Signal),
+			Target: SignalConsumers,
+			Seq:    signal.ID,
+		})
+	}
+	if p.SignalRetention > 0 {
+		err = p.repo.DeleteEventSignalsBefore(p.ctx, pgtype.Timestamp{
+			Time:  time.Now().Add(-p.SignalRetention),
+			Valid: true,
+		})
+		if err != nil {
+			p.ctx.Logger().Error("failed to delete old event signals", "error", err)
+		}
+	}
+}
+
 func (p *PostgresEventBus) handleNotify(data NotifyData) {
 	event, err := p.repo.GetEvent(p.ctx, data.Event)
 	if err != nil {
@@ -198,7 +382,7 @@ func triggerHandlers(eventCtx common.ExtendedContext, event Event, handlers []fu
 	eventCtx.Logger().Debug("all handlers finished", "eventName", event.EventName, "signal", signal)
 }
 
-func (p *PostgresEventBus) CreateTask(classId string, eventName EventName, data EventData, eventDomain EventDomain, parentId *string, target SignalTarget) (string, error) {
+func (p *PostgresEventBus) CreateTask(ctx common.ExtendedContext, classId string, eventName EventName, data EventData, eventDomain EventDomain, parentId *string, target SignalTarget) (string, error) {
 	id := uuid.New().String()
 	illTransactionID, patronRequestID := getIllTransactionAndPatronRequestId(classId, eventDomain)
 	return id, p.repo.WithTxFunc(p.ctx, func(eventRepo EventRepo) error {
@@ -213,6 +397,7 @@ func (p *PostgresEventBus) CreateTask(classId string, eventName EventName, data
 			ParentID:         getPgText(parentId),
 			LastSignal:       string(SignalTaskCreated),
 			PatronRequestID:  patronRequestID,
+			TraceParent:      tracing.TraceParent(ctx),
 		})
 		if err != nil && event.ParentID.Valid {
 			return err
@@ -223,15 +408,15 @@ func (p *PostgresEventBus) CreateTask(classId string, eventName EventName, data
 	})
 }
 
-func (p *PostgresEventBus) CreateNotice(classId string, eventName EventName, data EventData, status EventStatus, eventDomain EventDomain, target SignalTarget) (string, error) {
-	return p.createNotice(classId, eventName, data, status, eventDomain, nil, target)
+func (p *PostgresEventBus) CreateNotice(ctx common.ExtendedContext, classId string, eventName EventName, data EventData, status EventStatus, eventDomain EventDomain, target SignalTarget) (string, error) {
+	return p.createNotice(ctx, classId, eventName, data, status, eventDomain, nil, target)
 }
 
-func (p *PostgresEventBus) CreateNoticeWithParent(classId string, eventName EventName, data EventData, status EventStatus, eventDomain EventDomain, parentId *string, target SignalTarget) (string, error) {
-	return p.createNotice(classId, eventName, data, status, eventDomain, parentId, target)
+func (p *PostgresEventBus) CreateNoticeWithParent(ctx common.ExtendedContext, classId string, eventName EventName, data EventData, status EventStatus, eventDomain EventDomain, parentId *string, target SignalTarget) (string, error) {
+	return p.createNotice(ctx, classId, eventName, data, status, eventDomain, parentId, target)
 }
 
-func (p *PostgresEventBus) createNotice(classId string, eventName EventName, data EventData, status EventStatus, eventDomain EventDomain, parentId *string, target SignalTarget) (string, error) {
+func (p *PostgresEventBus) createNotice(ctx common.ExtendedContext, classId string, eventName EventName, data EventData, status EventStatus, eventDomain EventDomain, parentId *string, target SignalTarget) (string, error) {
 	id := uuid.New().String()
 	illTransactionID, patronRequestID := getIllTransactionAndPatronRequestId(classId, eventDomain)
 	return id, p.repo.WithTxFunc(p.ctx, func(eventRepo EventRepo) error {
@@ -246,6 +431,7 @@ func (p *PostgresEventBus) createNotice(classId string, eventName EventName, dat
 			LastSignal:       string(SignalNoticeCreated),
 			PatronRequestID:  patronRequestID,
 			ParentID:         getPgText(parentId),
+			TraceParent:      tracing.TraceParent(ctx),
 		})
 		if err != nil {
 			return err
@@ -278,6 +464,14 @@ func (p *PostgresEventBus) BeginTask(eventId string, target SignalTarget) (Event
 		if err != nil {
 			return err
 		}
+		_, err = eventRepo.AcquireEventLease(p.ctx, AcquireEventLeaseParams{
+			EventID:   eventId,
+			Owner:     p.instanceId,
+			ExpiresAt: p.getLeaseExpiry(),
+		})
+		if err != nil {
+			return err
+		}
 		err = eventRepo.Notify(p.ctx, eventId, SignalTaskBegin, target)
 		return err
 	})
@@ -298,6 +492,16 @@ func (p *PostgresEventBus) CompleteTask(eventId string, result *EventResult, sta
 		if event.EventStatus != EventStatusProcessing {
 			return fmt.Errorf("cannot complete task processing, event is not in state PROCESSING but %s", event.EventStatus)
 		}
+		released, err := eventRepo.ReleaseEventLease(p.ctx, ReleaseEventLeaseParams{
+			EventID: eventId,
+			Owner:   p.instanceId,
+		})
+		if err != nil {
+			return err
+		}
+		if released == 0 {
+			return ErrLeaseLost
+		}
 		event.EventStatus = status
 		if result != nil {
 			event.ResultData = *result
@@ -334,7 +538,18 @@ func (p *PostgresEventBus) ProcessTask(ctx common.ExtendedContext, event Event,
 		return event, err
 	}
 
-	status, result := h(ctx, event)
+	ctx, span := tracing.Start(ctx, "task "+string(event.EventName), trace.WithAttributes(
+		attribute.String("crosslink.event.id", event.ID),
+		attribute.String("crosslink.event.name", string(event.EventName)),
+		attribute.String("crosslink.ill_transaction.id", event.IllTransactionID)))
+	start := time.Now()
+	status, result := p.runWithLease(ctx, event, h)
+	metrics.EventTaskDuration.WithLabelValues(string(event.EventName), string(status)).Observe(metrics.Since(start))
+	span.SetAttributes(attribute.String("crosslink.event.status", string(status)))
+	if status == EventStatusError {
+		span.SetStatus(codes.Error, getErrorMessage(result))
+	}
+	span.End()
 
 	event, err = p.CompleteTask(event.ID, result, status, target)
 	if err != nil {
@@ -369,6 +584,259 @@ func (p *PostgresEventBus) ProcessExclusiveTask(ctx common.ExtendedContext, even
 	return event, exclusivityCheckErr
 }
 
+// runWithLease renews the lease of the task while h runs, so that the sweeper of
+// any instance can tell a long-running task from one orphaned by a crash
+func (p *PostgresEventBus) runWithLease(ctx common.ExtendedContext, event Event, h func(common.ExtendedContext, Event) (EventStatus, *EventResult)) (EventStatus, *EventResult) {
+	if p.LeaseDuration <= 0 {
+		return h(ctx, event)
+	}
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(p.LeaseDuration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_, err := p.repo.RenewEventLease(p.ctx, RenewEventLeaseParams{
+					EventID:   event.ID,
+					Owner:     p.instanceId,
+					ExpiresAt: p.getLeaseExpiry(),
+				})
+				if err != nil {
+					p.getEventContext(&event).Logger().Warn("failed to renew TASK lease", "error", err, "eventName", event.EventName)
+				}
+			}
+		}
+	}()
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+	return h(ctx, event)
+}
+
+func (p *PostgresEventBus) RequeueTask(eventId string) (Event, error) {
+	var event Event
+	err := p.repo.WithTxFunc(p.ctx, func(eventRepo EventRepo) error {
+		var err error
+		event, err = getStuckTaskForUpdate(p.ctx, eventRepo, eventId)
+		if err != nil {
+			return err
+		}
+		event, err = eventRepo.UpdateEventLifecycle(p.ctx, UpdateEventLifecycleParams{
+			ID:          eventId,
+			EventStatus: EventStatusNew,
+			LastSignal:  string(SignalTaskCreated),
+		})
+		if err != nil {
+			return err
+		}
+		return eventRepo.Notify(p.ctx, eventId, SignalTaskCreated, SignalConsumers)
+	})
+	return event, err
+}
+
+// failTask completes an orphaned task with an error once it has used up its attempts
+func (p *PostgresEventBus) failTask(eventId string, attempts int32) (Event, error) {
+	var event Event
+	err := p.repo.WithTxFunc(p.ctx, func(eventRepo EventRepo) error {
+		var err error
+		event, err = getStuckTaskForUpdate(p.ctx, eventRepo, eventId)
+		if err != nil {
+			return err
+		}
+		_, result := NewErrorResult("task lease expired", fmt.Sprintf("task not completed after %d attempts", attempts))
+		event.EventStatus = EventStatusError
+		event.ResultData = *result
+		event.LastSignal = string(SignalTaskComplete)
+		event, err = eventRepo.SaveEvent(p.ctx, SaveEventParams(event))
+		if err != nil {
+			return err
+		}
+		err = eventRepo.DeleteEventLease(p.ctx, eventId)
+		if err != nil {
+			return err
+		}
+		return eventRepo.Notify(p.ctx, eventId, SignalTaskComplete, SignalAll)
+	})
+	return event, err
+}
+
+func getStuckTaskForUpdate(ctx common.ExtendedContext, eventRepo EventRepo, eventId string) (Event, error) {
+	event, err := eventRepo.GetEventForUpdate(ctx, eventId)
+	if err != nil {
+		return event, err
+	}
+	if event.EventType != EventTypeTask || event.EventStatus != EventStatusProcessing {
+		return event, fmt.Errorf("%w, event is %s %s", ErrTaskNotStuck, event.EventType, event.EventStatus)
+	}
+	lease, err := eventRepo.GetEventLease(ctx, eventId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return event, nil
+		}
+		return event, err
+	}
+	if lease.ExpiresAt.Time.After(time.Now()) {
+		return event, fmt.Errorf("%w, lease held by %s until %s", ErrTaskNotStuck, lease.Owner, lease.ExpiresAt.Time.Format(time.RFC3339))
+	}
+	return event, nil
+}
+
+func (p *PostgresEventBus) RerunTask(eventId string, note string) (Event, error) {
+	var rerun Event
+	err := p.repo.WithTxFunc(p.ctx, func(eventRepo EventRepo) error {
+		event, err := getFailedTaskForUpdate(p.ctx, eventRepo, eventId)
+		if err != nil {
+			return err
+		}
+		rerun, err = eventRepo.SaveEvent(p.ctx, SaveEventParams{
+			ID:               uuid.New().String(),
+			IllTransactionID: event.IllTransactionID,
+			Timestamp:        getPgNow(),
+			EventType:        EventTypeTask,
+			EventName:        event.EventName,
+			EventStatus:      EventStatusNew,
+			EventData:        event.EventData,
+			ParentID:         getPgText(&event.ID),
+			LastSignal:       string(SignalTaskCreated),
+			PatronRequestID:  event.PatronRequestID,
+			TraceParent:      event.TraceParent,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = eventRepo.SaveEventResolution(p.ctx, SaveEventResolutionParams{
+			EventID:      eventId,
+			Resolution:   string(ResolutionRerun),
+			Note:         getPgNote(note),
+			RerunEventID: getPgText(&rerun.ID),
+			ResolvedAt:   getPgNow(),
+		})
+		if err != nil {
+			return err
+		}
+		return eventRepo.Notify(p.ctx, rerun.ID, SignalTaskCreated, SignalConsumers)
+	})
+	return rerun, err
+}
+
+func (p *PostgresEventBus) ResolveTask(eventId string, note string) (Event, error) {
+	var notice Event
+	err := p.repo.WithTxFunc(p.ctx, func(eventRepo EventRepo) error {
+		event, err := getFailedTaskForUpdate(p.ctx, eventRepo, eventId)
+		if err != nil {
+			return err
+		}
+		notice, err = eventRepo.SaveEvent(p.ctx, SaveEventParams{
+			ID:               uuid.New().String(),
+			IllTransactionID: event.IllTransactionID,
+			Timestamp:        getPgNow(),
+			EventType:        EventTypeNotice,
+			EventName:        EventNameTaskResolved,
+			EventStatus:      EventStatusSuccess,
+			EventData:        EventData{CommonEventData: CommonEventData{Note: note}},
+			ParentID:         getPgText(&event.ID),
+			LastSignal:       string(SignalNoticeCreated),
+			PatronRequestID:  event.PatronRequestID,
+			TraceParent:      event.TraceParent,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = eventRepo.SaveEventResolution(p.ctx, SaveEventResolutionParams{
+			EventID:    eventId,
+			Resolution: string(ResolutionResolved),
+			Note:       getPgNote(note),
+			ResolvedAt: getPgNow(),
+		})
+		if err != nil {
+			return err
+		}
+		return eventRepo.Notify(p.ctx, notice.ID, SignalNoticeCreated, SignalAll)
+	})
+	return notice, err
+}
+
+func getFailedTaskForUpdate(ctx common.ExtendedContext, eventRepo EventRepo, eventId string) (Event, error) {
+	event, err := eventRepo.GetEventForUpdate(ctx, eventId)
+	if err != nil {
+		return event, err
+	}
+	if event.EventType != EventTypeTask || (event.EventStatus != EventStatusError && event.EventStatus != EventStatusProblem) {
+		return event, fmt.Errorf("%w, event is %s %s", ErrTaskNotFailed, event.EventType, event.EventStatus)
+	}
+	resolution, err := eventRepo.GetEventResolution(ctx, eventId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return event, nil
+		}
+		return event, err
+	}
+	return event, fmt.Errorf("%w, task was marked %s at %s", ErrTaskNotFailed, resolution.Resolution, resolution.ResolvedAt.Time.Format(time.RFC3339))
+}
+
+func getPgNote(note string) pgtype.Text {
+	return pgtype.Text{
+		Valid:  note != "",
+		String: note,
+	}
+}
+
+func (p *PostgresEventBus) runSweeper(ctx common.ExtendedContext) {
+	ticker := time.NewTicker(p.SweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.SweepExpiredTasks()
+		}
+	}
+}
+
+// SweepExpiredTasks re-queues tasks whose lease has expired, or fails them when
+// they have been started MaxTaskAttempts times already
+func (p *PostgresEventBus) SweepExpiredTasks() {
+	rows, _, err := p.repo.ListStuckEvents(p.ctx, ListStuckEventsParams{
+		Limit:         SWEEP_BATCH_SIZE,
+		ExpiredBefore: getPgNow(),
+	})
+	if err != nil {
+		p.ctx.Logger().Error("failed to list stuck TASK events", "error", err)
+		return
+	}
+	for _, row := range rows {
+		event := row.Event
+		if row.LeaseAttempts >= p.MaxTaskAttempts {
+			_, err = p.failTask(event.ID, row.LeaseAttempts)
+		} else {
+			_, err = p.RequeueTask(event.ID)
+		}
+		if err != nil {
+			if !errors.Is(err, ErrTaskNotStuck) {
+				p.getEventContext(&event).Logger().Error("failed to recover stuck TASK event", "error", err, "eventName", event.EventName)
+			}
+			continue
+		}
+		p.getEventContext(&event).Logger().Warn("recovered stuck TASK event", "eventName", event.EventName,
+			"leaseOwner", row.LeaseOwner.String, "attempts", row.LeaseAttempts)
+	}
+}
+
+func (p *PostgresEventBus) getLeaseExpiry() pgtype.Timestamp {
+	return pgtype.Timestamp{
+		Time:  time.Now().Add(p.LeaseDuration),
+		Valid: true,
+	}
+}
+
 func (p *PostgresEventBus) registerHandler(signal Signal, role HandlerRole, eventName EventName, f func(ctx common.ExtendedContext, event Event)) {
 	if p.handlers == nil {
 		p.handlers = make(map[Signal]map[HandlerRole]map[EventName][]func(ctx common.ExtendedContext, event Event))
@@ -420,13 +888,21 @@ func (p *PostgresEventBus) GetLatestRequestEventByAction(ctx common.ExtendedCont
 
 func (p *PostgresEventBus) getEventContext(event *Event) common.ExtendedContext {
 	//TODO extend context with event name and status
-	return p.ctx.WithArgs(&common.LoggerArgs{
+	ctx := tracing.WithTraceParent(p.ctx, event.TraceParent)
+	return ctx.WithArgs(&common.LoggerArgs{
 		TransactionId: event.IllTransactionID,
 		EventId:       event.ID,
 		Component:     EB_COMP,
 	})
 }
 
+func getErrorMessage(result *EventResult) string {
+	if result != nil && result.EventError != nil {
+		return result.EventError.Message
+	}
+	return ""
+}
+
 func getParentId(event *Event) *string {
 	if event != nil && event.ParentID.Valid {
 		return &event.ParentID.String
@@ -3,11 +3,22 @@ package events
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/tracing"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 func TestProcessExclusiveTaskCompletesTaskWhenExclusivityCheckFails(t *testing.T) {
@@ -97,3 +108,504 @@ func (r *exclusiveCheckErrorRepo) GetLatestRequestEventByAction(ctx common.Exten
 func (r *exclusiveCheckErrorRepo) GetPatronRequestEvents(ctx common.ExtendedContext, id string) ([]Event, error) {
 	return nil, nil
 }
+
+func (r *exclusiveCheckErrorRepo) AcquireEventLease(ctx common.ExtendedContext, params AcquireEventLeaseParams) (EventLease, error) {
+	return EventLease{EventID: params.EventID, Owner: params.Owner, ExpiresAt: params.ExpiresAt, Attempts: 1}, nil
+}
+
+func (r *exclusiveCheckErrorRepo) RenewEventLease(ctx common.ExtendedContext, params RenewEventLeaseParams) (EventLease, error) {
+	return EventLease{EventID: params.EventID, Owner: params.Owner, ExpiresAt: params.ExpiresAt, Attempts: 1}, nil
+}
+
+func (r *exclusiveCheckErrorRepo) GetEventLease(ctx common.ExtendedContext, eventId string) (EventLease, error) {
+	return EventLease{}, pgx.ErrNoRows
+}
+
+func (r *exclusiveCheckErrorRepo) ReleaseEventLease(ctx common.ExtendedContext, params ReleaseEventLeaseParams) (int64, error) {
+	return 1, nil
+}
+
+func (r *exclusiveCheckErrorRepo) DeleteEventLease(ctx common.ExtendedContext, eventId string) error {
+	return nil
+}
+
+func (r *exclusiveCheckErrorRepo) ListStuckEvents(ctx common.ExtendedContext, params ListStuckEventsParams) ([]ListStuckEventsRow, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *exclusiveCheckErrorRepo) ListFailedEvents(ctx common.ExtendedContext, params ListFailedEventsParams) ([]ListFailedEventsRow, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *exclusiveCheckErrorRepo) GetEventResolution(ctx common.ExtendedContext, eventId string) (EventResolution, error) {
+	return EventResolution{}, pgx.ErrNoRows
+}
+
+func (r *exclusiveCheckErrorRepo) SaveEventResolution(ctx common.ExtendedContext, params SaveEventResolutionParams) (EventResolution, error) {
+	return EventResolution(params), nil
+}
+
+func (r *exclusiveCheckErrorRepo) GetLatestEventSignalId(ctx common.ExtendedContext) (int64, error) {
+	return 0, nil
+}
+
+func (r *exclusiveCheckErrorRepo) ListEventSignalsAfter(ctx common.ExtendedContext, params ListEventSignalsAfterParams) ([]EventSignal, error) {
+	return nil, nil
+}
+
+func (r *exclusiveCheckErrorRepo) ListUnclaimedEventSignals(ctx common.ExtendedContext, params ListUnclaimedEventSignalsParams) ([]EventSignal, error) {
+	return nil, nil
+}
+
+func (r *exclusiveCheckErrorRepo) DeleteEventSignalsBefore(ctx common.ExtendedContext, createdAt pgtype.Timestamp) error {
+	return nil
+}
+
+func (r *exclusiveCheckErrorRepo) SaveSseMessage(ctx common.ExtendedContext, params SaveSseMessageParams) (SseMessage, error) {
+	return SseMessage{}, nil
+}
+
+func (r *exclusiveCheckErrorRepo) ListSseMessagesAfter(ctx common.ExtendedContext, params ListSseMessagesAfterParams) ([]SseMessage, error) {
+	return nil, nil
+}
+
+func (r *exclusiveCheckErrorRepo) DeleteSseMessagesBefore(ctx common.ExtendedContext, createdAt pgtype.Timestamp) error {
+	return nil
+}
+
+func (r *exclusiveCheckErrorRepo) TryEventMaintenanceLock(ctx common.ExtendedContext) (bool, error) {
+	return false, nil
+}
+
+func (r *exclusiveCheckErrorRepo) CreateEventPartition(ctx common.ExtendedContext, month pgtype.Timestamp) (string, error) {
+	return "", nil
+}
+
+func (r *exclusiveCheckErrorRepo) ListEventPartitions(ctx common.ExtendedContext) ([]ListEventPartitionsRow, error) {
+	return nil, nil
+}
+
+func (r *exclusiveCheckErrorRepo) DropEventPartition(ctx common.ExtendedContext, name string) error {
+	return nil
+}
+
+func (r *exclusiveCheckErrorRepo) ListEventsBetween(ctx common.ExtendedContext, params ListEventsBetweenParams) ([]Event, error) {
+	return nil, nil
+}
+
+func (r *exclusiveCheckErrorRepo) DeleteExpiredEvents(ctx common.ExtendedContext, params DeleteExpiredEventsParams) ([]Event, error) {
+	return nil, nil
+}
+
+type leaseRepo struct {
+	exclusiveCheckErrorRepo
+	mu        sync.Mutex
+	lease     *EventLease
+	renewals  int
+	notified  []Signal
+	stuckRows []ListStuckEventsRow
+}
+
+func (r *leaseRepo) WithTxFunc(ctx common.ExtendedContext, fn func(EventRepo) error) error {
+	return fn(r)
+}
+
+func (r *leaseRepo) AcquireEventLease(ctx common.ExtendedContext, params AcquireEventLeaseParams) (EventLease, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lease = &EventLease{EventID: params.EventID, Owner: params.Owner, ExpiresAt: params.ExpiresAt, Attempts: 1}
+	return *r.lease, nil
+}
+
+func (r *leaseRepo) RenewEventLease(ctx common.ExtendedContext, params RenewEventLeaseParams) (EventLease, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.lease == nil || r.lease.Owner != params.Owner {
+		return EventLease{}, pgx.ErrNoRows
+	}
+	r.renewals++
+	r.lease.ExpiresAt = params.ExpiresAt
+	return *r.lease, nil
+}
+
+func (r *leaseRepo) GetEventLease(ctx common.ExtendedContext, eventId string) (EventLease, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.lease == nil {
+		return EventLease{}, pgx.ErrNoRows
+	}
+	return *r.lease, nil
+}
+
+func (r *leaseRepo) DeleteEventLease(ctx common.ExtendedContext, eventId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lease = nil
+	return nil
+}
+
+func (r *leaseRepo) ReleaseEventLease(ctx common.ExtendedContext, params ReleaseEventLeaseParams) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.lease == nil || r.lease.Owner != params.Owner {
+		return 0, nil
+	}
+	r.lease = nil
+	return 1, nil
+}
+
+func (r *leaseRepo) ListStuckEvents(ctx common.ExtendedContext, params ListStuckEventsParams) ([]ListStuckEventsRow, int64, error) {
+	return r.stuckRows, int64(len(r.stuckRows)), nil
+}
+
+func (r *leaseRepo) Notify(ctx common.ExtendedContext, eventId string, signal Signal, target SignalTarget) error {
+	r.notified = append(r.notified, signal)
+	return nil
+}
+
+func newLeaseTestBus(status EventStatus) (*PostgresEventBus, *leaseRepo) {
+	repo := &leaseRepo{}
+	repo.event = Event{
+		ID:               "event-1",
+		IllTransactionID: DEFAULT_ILL_TRANSACTION_ID,
+		EventType:        EventTypeTask,
+		EventName:        EventNameInvokeAction,
+		EventStatus:      status,
+	}
+	eventBus := NewPostgresEventBus(repo, "")
+	eventBus.ctx = common.CreateExtCtxWithArgs(context.Background(), nil)
+	return eventBus, repo
+}
+
+func TestProcessTaskRenewsLease(t *testing.T) {
+	eventBus, repo := newLeaseTestBus(EventStatusNew)
+	eventBus.LeaseDuration = 30 * time.Millisecond
+
+	event, err := eventBus.ProcessTask(eventBus.ctx, Event{ID: repo.event.ID}, SignalConsumers, func(common.ExtendedContext, Event) (EventStatus, *EventResult) {
+		lease, leaseErr := repo.GetEventLease(eventBus.ctx, repo.event.ID)
+		assert.NoError(t, leaseErr)
+		assert.Equal(t, eventBus.instanceId, lease.Owner)
+		time.Sleep(100 * time.Millisecond)
+		return EventStatusSuccess, &EventResult{}
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, EventStatusSuccess, event.EventStatus)
+	assert.GreaterOrEqual(t, repo.renewals, 1)
+	assert.Nil(t, repo.lease)
+}
+
+func TestProcessTaskLostLease(t *testing.T) {
+	eventBus, repo := newLeaseTestBus(EventStatusNew)
+
+	_, err := eventBus.ProcessTask(eventBus.ctx, Event{ID: repo.event.ID}, SignalConsumers, func(common.ExtendedContext, Event) (EventStatus, *EventResult) {
+		// the task is taken over by another instance while running
+		_, leaseErr := repo.AcquireEventLease(eventBus.ctx, AcquireEventLeaseParams{EventID: repo.event.ID, Owner: "other"})
+		assert.NoError(t, leaseErr)
+		return EventStatusSuccess, &EventResult{}
+	})
+
+	assert.ErrorIs(t, err, ErrLeaseLost)
+	assert.Equal(t, EventStatusProcessing, repo.event.EventStatus)
+	assert.Equal(t, "other", repo.lease.Owner)
+	assert.Equal(t, []Signal{SignalTaskBegin}, repo.notified)
+}
+
+func TestRequeueTask(t *testing.T) {
+	eventBus, repo := newLeaseTestBus(EventStatusProcessing)
+	repo.lease = &EventLease{EventID: repo.event.ID, Owner: "other", ExpiresAt: pgtype.Timestamp{Time: time.Now().Add(time.Minute), Valid: true}}
+
+	_, err := eventBus.RequeueTask(repo.event.ID)
+	assert.ErrorIs(t, err, ErrTaskNotStuck)
+	assert.ErrorContains(t, err, "lease held by other")
+
+	repo.lease.ExpiresAt.Time = time.Now().Add(-time.Minute)
+	event, err := eventBus.RequeueTask(repo.event.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, EventStatusNew, event.EventStatus)
+	assert.Equal(t, string(SignalTaskCreated), event.LastSignal)
+	assert.Equal(t, []Signal{SignalTaskCreated}, repo.notified)
+
+	_, err = eventBus.RequeueTask(repo.event.ID)
+	assert.ErrorIs(t, err, ErrTaskNotStuck)
+	assert.ErrorContains(t, err, "event is TASK NEW")
+}
+
+type failedTaskRepo struct {
+	exclusiveCheckErrorRepo
+	saved      []SaveEventParams
+	resolution *EventResolution
+	notified   []string
+}
+
+func (r *failedTaskRepo) WithTxFunc(ctx common.ExtendedContext, fn func(EventRepo) error) error {
+	return fn(r)
+}
+
+func (r *failedTaskRepo) SaveEvent(ctx common.ExtendedContext, params SaveEventParams) (Event, error) {
+	r.saved = append(r.saved, params)
+	return Event(params), nil
+}
+
+func (r *failedTaskRepo) GetEventResolution(ctx common.ExtendedContext, eventId string) (EventResolution, error) {
+	if r.resolution == nil {
+		return EventResolution{}, pgx.ErrNoRows
+	}
+	return *r.resolution, nil
+}
+
+func (r *failedTaskRepo) SaveEventResolution(ctx common.ExtendedContext, params SaveEventResolutionParams) (EventResolution, error) {
+	resolution := EventResolution(params)
+	r.resolution = &resolution
+	return resolution, nil
+}
+
+func (r *failedTaskRepo) Notify(ctx common.ExtendedContext, eventId string, signal Signal, target SignalTarget) error {
+	r.notified = append(r.notified, eventId+" "+string(signal)+" "+string(target))
+	return nil
+}
+
+func newFailedTaskTestBus(status EventStatus) (*PostgresEventBus, *failedTaskRepo) {
+	repo := &failedTaskRepo{}
+	repo.event = Event{
+		ID:               "event-1",
+		IllTransactionID: DEFAULT_ILL_TRANSACTION_ID,
+		PatronRequestID:  "pr-1",
+		EventType:        EventTypeTask,
+		EventName:        EventNameInvokeAction,
+		EventStatus:      status,
+		EventData:        EventData{CommonEventData: CommonEventData{Note: "action note"}},
+		TraceParent:      "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+	}
+	eventBus := NewPostgresEventBus(repo, "")
+	eventBus.ctx = common.CreateExtCtxWithArgs(context.Background(), nil)
+	return eventBus, repo
+}
+
+func TestRerunTask(t *testing.T) {
+	eventBus, repo := newFailedTaskTestBus(EventStatusSuccess)
+	_, err := eventBus.RerunTask(repo.event.ID, "")
+	assert.ErrorIs(t, err, ErrTaskNotFailed)
+	assert.ErrorContains(t, err, "event is TASK SUCCESS")
+
+	eventBus, repo = newFailedTaskTestBus(EventStatusError)
+	rerun, err := eventBus.RerunTask(repo.event.ID, "fixed peer address")
+	assert.NoError(t, err)
+	assert.NotEqual(t, repo.event.ID, rerun.ID)
+	assert.Equal(t, EventTypeTask, rerun.EventType)
+	assert.Equal(t, EventNameInvokeAction, rerun.EventName)
+	assert.Equal(t, EventStatusNew, rerun.EventStatus)
+	assert.Equal(t, repo.event.ID, rerun.ParentID.String)
+	assert.Equal(t, "pr-1", rerun.PatronRequestID)
+	assert.Equal(t, "action note", rerun.EventData.Note)
+	assert.Equal(t, repo.event.TraceParent, rerun.TraceParent)
+	if assert.NotNil(t, repo.resolution) {
+		assert.Equal(t, string(ResolutionRerun), repo.resolution.Resolution)
+		assert.Equal(t, "fixed peer address", repo.resolution.Note.String)
+		assert.Equal(t, rerun.ID, repo.resolution.RerunEventID.String)
+	}
+	assert.Equal(t, []string{rerun.ID + " task_created consumers"}, repo.notified)
+
+	_, err = eventBus.RerunTask(repo.event.ID, "")
+	assert.ErrorIs(t, err, ErrTaskNotFailed)
+	assert.ErrorContains(t, err, "task was marked RERUN")
+	assert.Len(t, repo.saved, 1)
+}
+
+func TestResolveTask(t *testing.T) {
+	eventBus, repo := newFailedTaskTestBus(EventStatusProcessing)
+	_, err := eventBus.ResolveTask(repo.event.ID, "")
+	assert.ErrorIs(t, err, ErrTaskNotFailed)
+
+	eventBus, repo = newFailedTaskTestBus(EventStatusProblem)
+	notice, err := eventBus.ResolveTask(repo.event.ID, "handled by phone")
+	assert.NoError(t, err)
+	assert.Equal(t, EventTypeNotice, notice.EventType)
+	assert.Equal(t, EventNameTaskResolved, notice.EventName)
+	assert.Equal(t, EventStatusSuccess, notice.EventStatus)
+	assert.Equal(t, repo.event.ID, notice.ParentID.String)
+	assert.Equal(t, "handled by phone", notice.EventData.Note)
+	if assert.NotNil(t, repo.resolution) {
+		assert.Equal(t, string(ResolutionResolved), repo.resolution.Resolution)
+		assert.Equal(t, "handled by phone", repo.resolution.Note.String)
+		assert.False(t, repo.resolution.RerunEventID.Valid)
+	}
+	assert.Equal(t, []string{notice.ID + " notice_created all"}, repo.notified)
+
+	_, err = eventBus.RerunTask(repo.event.ID, "")
+	assert.ErrorIs(t, err, ErrTaskNotFailed)
+	assert.ErrorContains(t, err, "task was marked RESOLVED")
+}
+
+func TestSweepExpiredTasks(t *testing.T) {
+	eventBus, repo := newLeaseTestBus(EventStatusProcessing)
+	repo.stuckRows = []ListStuckEventsRow{{Event: repo.event, LeaseAttempts: 1}}
+	eventBus.SweepExpiredTasks()
+	assert.Equal(t, EventStatusNew, repo.event.EventStatus)
+	assert.Equal(t, []Signal{SignalTaskCreated}, repo.notified)
+
+	eventBus, repo = newLeaseTestBus(EventStatusProcessing)
+	repo.lease = &EventLease{EventID: repo.event.ID, Owner: "other", ExpiresAt: pgtype.Timestamp{Time: time.Now().Add(-time.Minute), Valid: true}, Attempts: 3}
+	repo.stuckRows = []ListStuckEventsRow{{Event: repo.event, LeaseAttempts: 3}}
+	eventBus.SweepExpiredTasks()
+	assert.Equal(t, EventStatusError, repo.event.EventStatus)
+	assert.Equal(t, string(SignalTaskComplete), repo.event.LastSignal)
+	if assert.NotNil(t, repo.event.ResultData.EventError) {
+		assert.Equal(t, "task lease expired", repo.event.ResultData.EventError.Message)
+		assert.Equal(t, "task not completed after 3 attempts", repo.event.ResultData.EventError.Cause)
+	}
+	assert.Nil(t, repo.lease)
+	assert.Equal(t, []Signal{SignalTaskComplete}, repo.notified)
+}
+
+type signalRepo struct {
+	exclusiveCheckErrorRepo
+	mu            sync.Mutex
+	signals       []EventSignal
+	claimed       []string
+	deletedBefore pgtype.Timestamp
+}
+
+func (r *signalRepo) ListEventSignalsAfter(ctx common.ExtendedContext, params ListEventSignalsAfterParams) ([]EventSignal, error) {
+	var signals []EventSignal
+	for _, s := range r.signals {
+		if s.ID > params.ID {
+			signals = append(signals, s)
+		}
+	}
+	return signals, nil
+}
+
+func (r *signalRepo) ListUnclaimedEventSignals(ctx common.ExtendedContext, params ListUnclaimedEventSignalsParams) ([]EventSignal, error) {
+	return r.signals, nil
+}
+
+func (r *signalRepo) DeleteEventSignalsBefore(ctx common.ExtendedContext, createdAt pgtype.Timestamp) error {
+	r.deletedBefore = createdAt
+	return nil
+}
+
+func (r *signalRepo) GetEvent(ctx common.ExtendedContext, id string) (Event, error) {
+	return Event{ID: id, EventName: EventNameInvokeAction}, nil
+}
+
+func (r *signalRepo) ClaimEventForSignal(ctx common.ExtendedContext, id string, signal Signal) (Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.claimed = append(r.claimed, id)
+	return Event{ID: id, EventName: EventNameInvokeAction}, nil
+}
+
+func TestMarkSignalSeen(t *testing.T) {
+	eventBus := NewPostgresEventBus(nil, "")
+	assert.True(t, eventBus.markSignalSeen(2))
+	assert.True(t, eventBus.markSignalSeen(1))
+	assert.False(t, eventBus.markSignalSeen(2))
+	assert.Equal(t, int64(2), eventBus.signalCursor)
+
+	for i := int64(3); i <= SEEN_SIGNALS_MAX+10; i++ {
+		assert.True(t, eventBus.markSignalSeen(i))
+	}
+	assert.LessOrEqual(t, len(eventBus.seenSignals), SEEN_SIGNALS_MAX)
+	assert.False(t, eventBus.markSignalSeen(SEEN_SIGNALS_MAX+10-SIGNAL_CATCH_UP_OVERLAP+1))
+}
+
+func TestCatchUpSignals(t *testing.T) {
+	repo := &signalRepo{signals: []EventSignal{
+		{ID: 1, EventID: "e1", Signal: string(SignalTaskCreated), Target: string(SignalObservers)},
+		{ID: 2, EventID: "e2", Signal: string(SignalTaskCreated), Target: string(SignalObservers)},
+		{ID: 3, EventID: "e3", Signal: string(SignalTaskCreated), Target: string(SignalConsumers)},
+		{ID: 4, EventID: "e4", Signal: string(SignalTaskCreated), Target: string(SignalAll)},
+	}}
+	eventBus := NewPostgresEventBus(repo, "")
+	eventBus.ctx = common.CreateExtCtxWithArgs(context.Background(), nil)
+	eventBus.signalFloor = 1
+	eventBus.signalCursor = 1
+	assert.True(t, eventBus.markSignalSeen(3))
+
+	var mu sync.Mutex
+	var observed, consumed []string
+	eventBus.HandleEventCreated(EventNameInvokeAction, HandlerRoleObserver, func(ctx common.ExtendedContext, event Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		observed = append(observed, event.ID)
+	})
+	eventBus.HandleEventCreated(EventNameInvokeAction, HandlerRoleConsumer, func(ctx common.ExtendedContext, event Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		consumed = append(consumed, event.ID)
+	})
+
+	eventBus.CatchUpSignals()
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(observed) == 2 && len(consumed) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, []string{"e2", "e4"}, observed)
+	assert.Equal(t, []string{"e4"}, consumed)
+	assert.Equal(t, int64(4), eventBus.signalCursor)
+
+	// nothing is handled twice
+	eventBus.CatchUpSignals()
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, observed, 2)
+}
+
+func TestPollUnclaimedSignals(t *testing.T) {
+	repo := &signalRepo{signals: []EventSignal{
+		{ID: 7, EventID: "e7", Signal: string(SignalTaskCreated), Target: string(SignalAll)},
+	}}
+	eventBus := NewPostgresEventBus(repo, "")
+	eventBus.ctx = common.CreateExtCtxWithArgs(context.Background(), nil)
+	observed := false
+	eventBus.HandleEventCreated(EventNameInvokeAction, HandlerRoleObserver, func(ctx common.ExtendedContext, event Event) {
+		observed = true
+	})
+
+	eventBus.PollUnclaimedSignals()
+	assert.Eventually(t, func() bool {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		return len(repo.claimed) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"e7"}, repo.claimed)
+	assert.False(t, observed)
+	assert.True(t, repo.deletedBefore.Valid)
+	assert.WithinDuration(t, time.Now().Add(-DEFAULT_SIGNAL_RETENTION), repo.deletedBefore.Time, time.Minute)
+}
+
+func TestProcessTaskContinuesStoredTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(tracing.NewTracerProvider(sdktrace.WithSyncer(exporter), "test"))
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	eventBus, repo := newLeaseTestBus(EventStatusNew)
+	requestCtx, requestSpan := tracing.Start(eventBus.ctx, "request")
+	id, err := eventBus.CreateTask(requestCtx, DEFAULT_ILL_TRANSACTION_ID, EventNameInvokeAction, EventData{}, EventDomainIllTransaction, nil, SignalConsumers)
+	requestSpan.End()
+	assert.NoError(t, err)
+	assert.Equal(t, id, repo.event.ID)
+	assert.Equal(t, tracing.TraceParent(requestCtx), repo.event.TraceParent)
+
+	// as if the task was picked up by another instance
+	taskCtx := eventBus.getEventContext(&repo.event)
+	_, err = eventBus.ProcessTask(taskCtx, repo.event, SignalConsumers, func(ctx common.ExtendedContext, event Event) (EventStatus, *EventResult) {
+		assert.True(t, trace.SpanContextFromContext(ctx).IsValid())
+		return EventStatusError, &EventResult{CommonEventData: CommonEventData{EventError: &EventError{Message: "no suppliers"}}}
+	})
+	assert.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	task := spans[1]
+	assert.Equal(t, "task "+string(EventNameInvokeAction), task.Name)
+	assert.Equal(t, spans[0].SpanContext.TraceID(), task.SpanContext.TraceID())
+	assert.Equal(t, spans[0].SpanContext.SpanID(), task.Parent.SpanID())
+	assert.Contains(t, task.Attributes, attribute.String("crosslink.event.id", id))
+	assert.Contains(t, task.Attributes, attribute.String("crosslink.event.status", string(EventStatusError)))
+	assert.Equal(t, codes.Error, task.Status.Code)
+	assert.Equal(t, "no suppliers", task.Status.Description)
+}
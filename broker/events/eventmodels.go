@@ -57,6 +57,15 @@ const (
 	EventNameCheckAvailability      EventName = "check-availability"
 	EventNameInvokeBatchAction      EventName = "invoke-batch-action"
 	EventNameInvokeBackgroundAction EventName = "invoke-background-action"
+	EventNameTaskResolved           EventName = "task-resolved"
+)
+
+// Resolution records how an operator handled a failed task
+type Resolution string
+
+const (
+	ResolutionRerun    Resolution = "RERUN"
+	ResolutionResolved Resolution = "RESOLVED"
 )
 
 type Signal string
@@ -129,6 +138,8 @@ type NotifyData struct {
 	Event  string       `json:"event"`
 	Signal Signal       `json:"signal"`
 	Target SignalTarget `json:"target"`
+	// Seq is the id of the signal in the event_signal log
+	Seq int64 `json:"seq,omitempty"`
 }
 
 type BatchActionData struct {
@@ -162,6 +173,12 @@ func NewProblemResult(kind string, details string) (EventStatus, *EventResult) {
 
 const MUST_LOCATE = "mustLocate"
 
+// Custom data keys of the LMS log events holding the messages exchanged with the LMS
+const (
+	LMS_OUTGOING_MESSAGE = "lmsOutgoingMessage"
+	LMS_INCOMING_MESSAGE = "lmsIncomingMessage"
+)
+
 type DuplicateCheck struct {
 	Enabled              bool                  `json:"enabled"`
 	LookupParams         *catalog.LookupParams `json:"lookupParams"`
@@ -3,9 +3,11 @@ package events
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/indexdata/crosslink/broker/common"
 	"github.com/indexdata/crosslink/broker/repo"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type EventRepo interface {
@@ -21,6 +23,28 @@ type EventRepo interface {
 	GetBatchActionEvents(ctx common.ExtendedContext, taskID string) ([]Event, error)
 	GetLatestRequestEventByAction(ctx common.ExtendedContext, illTransId string, action string) (Event, error)
 	GetPatronRequestEvents(ctx common.ExtendedContext, id string) ([]Event, error)
+	AcquireEventLease(ctx common.ExtendedContext, params AcquireEventLeaseParams) (EventLease, error)
+	RenewEventLease(ctx common.ExtendedContext, params RenewEventLeaseParams) (EventLease, error)
+	GetEventLease(ctx common.ExtendedContext, eventId string) (EventLease, error)
+	DeleteEventLease(ctx common.ExtendedContext, eventId string) error
+	ReleaseEventLease(ctx common.ExtendedContext, params ReleaseEventLeaseParams) (int64, error)
+	ListStuckEvents(ctx common.ExtendedContext, params ListStuckEventsParams) ([]ListStuckEventsRow, int64, error)
+	ListFailedEvents(ctx common.ExtendedContext, params ListFailedEventsParams) ([]ListFailedEventsRow, int64, error)
+	GetEventResolution(ctx common.ExtendedContext, eventId string) (EventResolution, error)
+	SaveEventResolution(ctx common.ExtendedContext, params SaveEventResolutionParams) (EventResolution, error)
+	GetLatestEventSignalId(ctx common.ExtendedContext) (int64, error)
+	ListEventSignalsAfter(ctx common.ExtendedContext, params ListEventSignalsAfterParams) ([]EventSignal, error)
+	ListUnclaimedEventSignals(ctx common.ExtendedContext, params ListUnclaimedEventSignalsParams) ([]EventSignal, error)
+	DeleteEventSignalsBefore(ctx common.ExtendedContext, createdAt pgtype.Timestamp) error
+	SaveSseMessage(ctx common.ExtendedContext, params SaveSseMessageParams) (SseMessage, error)
+	ListSseMessagesAfter(ctx common.ExtendedContext, params ListSseMessagesAfterParams) ([]SseMessage, error)
+	DeleteSseMessagesBefore(ctx common.ExtendedContext, createdAt pgtype.Timestamp) error
+	TryEventMaintenanceLock(ctx common.ExtendedContext) (bool, error)
+	CreateEventPartition(ctx common.ExtendedContext, month pgtype.Timestamp) (string, error)
+	ListEventPartitions(ctx common.ExtendedContext) ([]ListEventPartitionsRow, error)
+	DropEventPartition(ctx common.ExtendedContext, name string) error
+	ListEventsBetween(ctx common.ExtendedContext, params ListEventsBetweenParams) ([]Event, error)
+	DeleteExpiredEvents(ctx common.ExtendedContext, params DeleteExpiredEventsParams) ([]Event, error)
 }
 
 type PgEventRepo struct {
@@ -81,15 +105,30 @@ func (r *PgEventRepo) UpdateEventLifecycle(ctx common.ExtendedContext, params Up
 	return row.Event, err
 }
 
+// Notify records the signal in the event_signal log before sending it, so that
+// instances which miss the notification can catch up from the log
 func (r *PgEventRepo) Notify(ctx common.ExtendedContext, eventId string, signal Signal, target SignalTarget) error {
+	row, err := r.queries.SaveEventSignal(ctx, r.GetConnOrTx(), SaveEventSignalParams{
+		EventID: eventId,
+		Signal:  string(signal),
+		Target:  string(target),
+		CreatedAt: pgtype.Timestamp{
+			Time:  time.Now(),
+			Valid: true,
+		},
+	})
+	if err != nil {
+		return err
+	}
 	data := NotifyData{
 		Event:  eventId,
 		Signal: signal,
 		Target: target,
+		Seq:    row.EventSignal.ID,
 	}
 	jsonData, _ := json.Marshal(data)
 	sql := fmt.Sprintf("NOTIFY crosslink_channel, '%s'", jsonData)
-	_, err := r.GetConnOrTx().Exec(ctx, sql)
+	_, err = r.GetConnOrTx().Exec(ctx, sql)
 	return err
 }
 
@@ -135,3 +174,150 @@ func (r *PgEventRepo) GetLatestRequestEventByAction(ctx common.ExtendedContext,
 	})
 	return row.Event, err
 }
+
+func (r *PgEventRepo) AcquireEventLease(ctx common.ExtendedContext, params AcquireEventLeaseParams) (EventLease, error) {
+	row, err := r.queries.AcquireEventLease(ctx, r.GetConnOrTx(), params)
+	return row.EventLease, err
+}
+
+func (r *PgEventRepo) RenewEventLease(ctx common.ExtendedContext, params RenewEventLeaseParams) (EventLease, error) {
+	row, err := r.queries.RenewEventLease(ctx, r.GetConnOrTx(), params)
+	return row.EventLease, err
+}
+
+func (r *PgEventRepo) GetEventLease(ctx common.ExtendedContext, eventId string) (EventLease, error) {
+	row, err := r.queries.GetEventLease(ctx, r.GetConnOrTx(), eventId)
+	return row.EventLease, err
+}
+
+func (r *PgEventRepo) DeleteEventLease(ctx common.ExtendedContext, eventId string) error {
+	return r.queries.DeleteEventLease(ctx, r.GetConnOrTx(), eventId)
+}
+
+func (r *PgEventRepo) ReleaseEventLease(ctx common.ExtendedContext, params ReleaseEventLeaseParams) (int64, error) {
+	return r.queries.ReleaseEventLease(ctx, r.GetConnOrTx(), params)
+}
+
+func (r *PgEventRepo) ListStuckEvents(ctx common.ExtendedContext, params ListStuckEventsParams) ([]ListStuckEventsRow, int64, error) {
+	rows, err := r.queries.ListStuckEvents(ctx, r.GetConnOrTx(), params)
+	var fullCount int64
+	if err == nil && len(rows) > 0 {
+		fullCount = rows[0].FullCount
+	}
+	return rows, fullCount, err
+}
+
+// ListFailedEvents returns the TASK events in ERROR or PROBLEM that have not been re-run or resolved
+func (r *PgEventRepo) ListFailedEvents(ctx common.ExtendedContext, params ListFailedEventsParams) ([]ListFailedEventsRow, int64, error) {
+	rows, err := r.queries.ListFailedEvents(ctx, r.GetConnOrTx(), params)
+	var fullCount int64
+	if err == nil && len(rows) > 0 {
+		fullCount = rows[0].FullCount
+	}
+	return rows, fullCount, err
+}
+
+func (r *PgEventRepo) GetEventResolution(ctx common.ExtendedContext, eventId string) (EventResolution, error) {
+	row, err := r.queries.GetEventResolution(ctx, r.GetConnOrTx(), eventId)
+	return row.EventResolution, err
+}
+
+func (r *PgEventRepo) SaveEventResolution(ctx common.ExtendedContext, params SaveEventResolutionParams) (EventResolution, error) {
+	row, err := r.queries.SaveEventResolution(ctx, r.GetConnOrTx(), params)
+	return row.EventResolution, err
+}
+
+func (r *PgEventRepo) GetLatestEventSignalId(ctx common.ExtendedContext) (int64, error) {
+	return r.queries.GetLatestEventSignalId(ctx, r.GetConnOrTx())
+}
+
+func (r *PgEventRepo) ListEventSignalsAfter(ctx common.ExtendedContext, params ListEventSignalsAfterParams) ([]EventSignal, error) {
+	rows, err := r.queries.ListEventSignalsAfter(ctx, r.GetConnOrTx(), params)
+	var signals []EventSignal
+	if err == nil {
+		for _, row := range rows {
+			signals = append(signals, row.EventSignal)
+		}
+	}
+	return signals, err
+}
+
+func (r *PgEventRepo) ListUnclaimedEventSignals(ctx common.ExtendedContext, params ListUnclaimedEventSignalsParams) ([]EventSignal, error) {
+	rows, err := r.queries.ListUnclaimedEventSignals(ctx, r.GetConnOrTx(), params)
+	var signals []EventSignal
+	if err == nil {
+		for _, row := range rows {
+			signals = append(signals, row.EventSignal)
+		}
+	}
+	return signals, err
+}
+
+func (r *PgEventRepo) DeleteEventSignalsBefore(ctx common.ExtendedContext, createdAt pgtype.Timestamp) error {
+	return r.queries.DeleteEventSignalsBefore(ctx, r.GetConnOrTx(), createdAt)
+}
+
+// SaveSseMessage stores a message in the SSE replay log. Saving the same event for
+// the same receiver again returns the existing message, so all instances agree on its id.
+func (r *PgEventRepo) SaveSseMessage(ctx common.ExtendedContext, params SaveSseMessageParams) (SseMessage, error) {
+	row, err := r.queries.SaveSseMessage(ctx, r.GetConnOrTx(), params)
+	return row.SseMessage, err
+}
+
+func (r *PgEventRepo) ListSseMessagesAfter(ctx common.ExtendedContext, params ListSseMessagesAfterParams) ([]SseMessage, error) {
+	rows, err := r.queries.ListSseMessagesAfter(ctx, r.GetConnOrTx(), params)
+	var messages []SseMessage
+	if err == nil {
+		for _, row := range rows {
+			messages = append(messages, row.SseMessage)
+		}
+	}
+	return messages, err
+}
+
+func (r *PgEventRepo) DeleteSseMessagesBefore(ctx common.ExtendedContext, createdAt pgtype.Timestamp) error {
+	return r.queries.DeleteSseMessagesBefore(ctx, r.GetConnOrTx(), createdAt)
+}
+
+// TryEventMaintenanceLock tells whether this transaction got the lock that keeps instances
+// from maintaining the event partitions at the same time, the lock is released on commit
+func (r *PgEventRepo) TryEventMaintenanceLock(ctx common.ExtendedContext) (bool, error) {
+	return r.queries.TryEventMaintenanceLock(ctx, r.GetConnOrTx())
+}
+
+// CreateEventPartition creates the partition holding the events of the month if it does not exist and returns its name
+func (r *PgEventRepo) CreateEventPartition(ctx common.ExtendedContext, month pgtype.Timestamp) (string, error) {
+	return r.queries.CreateEventPartition(ctx, r.GetConnOrTx(), month)
+}
+
+func (r *PgEventRepo) ListEventPartitions(ctx common.ExtendedContext) ([]ListEventPartitionsRow, error) {
+	return r.queries.ListEventPartitions(ctx, r.GetConnOrTx())
+}
+
+// DropEventPartition drops the monthly partition with all its events
+func (r *PgEventRepo) DropEventPartition(ctx common.ExtendedContext, name string) error {
+	return r.queries.DropEventPartition(ctx, r.GetConnOrTx(), name)
+}
+
+func (r *PgEventRepo) ListEventsBetween(ctx common.ExtendedContext, params ListEventsBetweenParams) ([]Event, error) {
+	rows, err := r.queries.ListEventsBetween(ctx, r.GetConnOrTx(), params)
+	var eventList []Event
+	if err == nil {
+		for _, row := range rows {
+			eventList = append(eventList, row.Event)
+		}
+	}
+	return eventList, err
+}
+
+// DeleteExpiredEvents deletes up to MaxRows events older than Before and returns them
+func (r *PgEventRepo) DeleteExpiredEvents(ctx common.ExtendedContext, params DeleteExpiredEventsParams) ([]Event, error) {
+	rows, err := r.queries.DeleteExpiredEvents(ctx, r.GetConnOrTx(), params)
+	var eventList []Event
+	if err == nil {
+		for _, row := range rows {
+			eventList = append(eventList, row.Event)
+		}
+	}
+	return eventList, err
+}
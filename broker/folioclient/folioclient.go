@@ -0,0 +1,147 @@
+package folioclient
+
+import (
+	"strconv"
+	"time"
+)
+
+type FolioLogFunc func(outgoing map[string]any, incoming map[string]any, err error)
+
+// FolioClient calls the FOLIO users, inventory and circulation REST APIs of a single tenant
+type FolioClient interface {
+	SetLogFunc(logFunc FolioLogFunc)
+
+	// LookupUserByBarcode returns nil if no user has the barcode
+	LookupUserByBarcode(barcode string) (*User, error)
+
+	// LookupInstanceByHrid returns nil if no instance has the HRID
+	LookupInstanceByHrid(hrid string) (*Instance, error)
+
+	// LookupItemByBarcode returns nil if no item has the barcode
+	LookupItemByBarcode(barcode string) (*Item, error)
+
+	GetItem(id string) (*Item, error)
+
+	// LookupHoldingsByInstanceId returns the holdings records of an instance
+	LookupHoldingsByInstanceId(instanceId string) ([]Holdings, error)
+
+	// LookupItemsByHoldingsId returns the items of a holdings record
+	LookupItemsByHoldingsId(holdingsId string) ([]Item, error)
+
+	CreateInstance(instance Instance) (*Instance, error)
+
+	CreateHoldings(holdings Holdings) (*Holdings, error)
+
+	CreateItem(item Item) (*Item, error)
+
+	DeleteInstance(id string) error
+
+	DeleteHoldings(id string) error
+
+	DeleteItem(id string) error
+
+	CreateRequest(request Request) (*Request, error)
+
+	CancelRequest(id string, cancellationReasonId string) error
+
+	CheckOutByBarcode(itemBarcode string, userBarcode string, servicePointId string) (*Loan, error)
+
+	CheckInByBarcode(itemBarcode string, servicePointId string) error
+
+	RenewByBarcode(itemBarcode string, userBarcode string) (*Loan, error)
+}
+
+type User struct {
+	Id      string `json:"id,omitempty"`
+	Barcode string `json:"barcode,omitempty"`
+	Active  bool   `json:"active"`
+}
+
+type Instance struct {
+	Id                string `json:"id,omitempty"`
+	Hrid              string `json:"hrid,omitempty"`
+	Title             string `json:"title,omitempty"`
+	Source            string `json:"source,omitempty"`
+	InstanceTypeId    string `json:"instanceTypeId,omitempty"`
+	DiscoverySuppress bool   `json:"discoverySuppress,omitempty"`
+}
+
+type Holdings struct {
+	Id                  string `json:"id,omitempty"`
+	InstanceId          string `json:"instanceId,omitempty"`
+	PermanentLocationId string `json:"permanentLocationId,omitempty"`
+	CallNumber          string `json:"callNumber,omitempty"`
+	DiscoverySuppress   bool   `json:"discoverySuppress,omitempty"`
+}
+
+type Ref struct {
+	Id   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+type ItemStatus struct {
+	Name string `json:"name"`
+}
+
+type CallNumberComponents struct {
+	CallNumber string `json:"callNumber,omitempty"`
+}
+
+type Item struct {
+	Id                            string                `json:"id,omitempty"`
+	HoldingsRecordId              string                `json:"holdingsRecordId,omitempty"`
+	Barcode                       string                `json:"barcode,omitempty"`
+	Title                         string                `json:"title,omitempty"`
+	Status                        ItemStatus            `json:"status"`
+	MaterialType                  *Ref                  `json:"materialType,omitempty"`
+	PermanentLoanType             *Ref                  `json:"permanentLoanType,omitempty"`
+	EffectiveCallNumberComponents *CallNumberComponents `json:"effectiveCallNumberComponents,omitempty"`
+	DiscoverySuppress             bool                  `json:"discoverySuppress,omitempty"`
+}
+
+type RequestItem struct {
+	Barcode    string `json:"barcode,omitempty"`
+	CallNumber string `json:"callNumber,omitempty"`
+}
+
+type RequestInstance struct {
+	Title string `json:"title,omitempty"`
+}
+
+type Request struct {
+	Id                    string           `json:"id,omitempty"`
+	RequestType           string           `json:"requestType"`
+	RequestLevel          string           `json:"requestLevel"`
+	RequestDate           time.Time        `json:"requestDate"`
+	InstanceId            string           `json:"instanceId,omitempty"`
+	HoldingsRecordId      string           `json:"holdingsRecordId,omitempty"`
+	ItemId                string           `json:"itemId,omitempty"`
+	RequesterId           string           `json:"requesterId"`
+	FulfillmentPreference string           `json:"fulfillmentPreference"`
+	PickupServicePointId  string           `json:"pickupServicePointId,omitempty"`
+	PatronComments        string           `json:"patronComments,omitempty"`
+	Status                string           `json:"status,omitempty"`
+	Item                  *RequestItem     `json:"item,omitempty"`
+	Instance              *RequestInstance `json:"instance,omitempty"`
+}
+
+type Loan struct {
+	Id      string    `json:"id,omitempty"`
+	DueDate time.Time `json:"dueDate"`
+	Item    *Item     `json:"item,omitempty"`
+}
+
+// FolioError is returned when FOLIO responds with an error status
+type FolioError struct {
+	Message string
+	Status  int
+	Detail  string
+}
+
+func (e *FolioError) Error() string {
+	s := e.Message + ": HTTP " + strconv.Itoa(e.Status)
+	if e.Detail != "" {
+		s += ": " + e.Detail
+	}
+	return s
+}
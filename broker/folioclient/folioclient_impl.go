@@ -0,0 +1,374 @@
+package folioclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/indexdata/cql-go/cqlbuilder"
+)
+
+const tokenCookie = "folioAccessToken"
+
+// lookupLimit is the maximum number of records returned by lookups that may match several records
+const lookupLimit = "1000"
+
+type Config struct {
+	Address  string
+	Tenant   string
+	Username string
+	Password string
+}
+
+type FolioClientImpl struct {
+	client  *http.Client
+	config  Config
+	logFunc FolioLogFunc
+	mu      sync.Mutex
+	token   string
+}
+
+// NewFolioClient creates a client for the FOLIO tenant at the given address.
+// If a username is configured, the client logs in before the first call.
+func NewFolioClient(client *http.Client, config Config) FolioClient {
+	config.Address = strings.TrimSuffix(config.Address, "/")
+	return &FolioClientImpl{client: client, config: config}
+}
+
+func (f *FolioClientImpl) SetLogFunc(logFunc FolioLogFunc) {
+	f.logFunc = logFunc
+}
+
+func (f *FolioClientImpl) LookupUserByBarcode(barcode string) (*User, error) {
+	var res struct {
+		Users []User `json:"users"`
+	}
+	err := f.send("FOLIO user lookup", http.MethodGet, "/users?"+barcodeQuery(barcode), nil, &res)
+	if err != nil || len(res.Users) == 0 {
+		return nil, err
+	}
+	return &res.Users[0], nil
+}
+
+func (f *FolioClientImpl) LookupInstanceByHrid(hrid string) (*Instance, error) {
+	var res struct {
+		Instances []Instance `json:"instances"`
+	}
+	query := url.Values{"query": {"hrid==" + quote(hrid)}}.Encode()
+	err := f.send("FOLIO instance lookup", http.MethodGet, "/inventory/instances?"+query, nil, &res)
+	if err != nil || len(res.Instances) == 0 {
+		return nil, err
+	}
+	return &res.Instances[0], nil
+}
+
+func (f *FolioClientImpl) LookupItemByBarcode(barcode string) (*Item, error) {
+	var res struct {
+		Items []Item `json:"items"`
+	}
+	err := f.send("FOLIO item lookup", http.MethodGet, "/inventory/items?"+barcodeQuery(barcode), nil, &res)
+	if err != nil || len(res.Items) == 0 {
+		return nil, err
+	}
+	return &res.Items[0], nil
+}
+
+func (f *FolioClientImpl) GetItem(id string) (*Item, error) {
+	var item Item
+	err := f.send("FOLIO item lookup", http.MethodGet, "/inventory/items/"+url.PathEscape(id), nil, &item)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (f *FolioClientImpl) LookupHoldingsByInstanceId(instanceId string) ([]Holdings, error) {
+	var res struct {
+		HoldingsRecords []Holdings `json:"holdingsRecords"`
+	}
+	query := url.Values{"query": {"instanceId==" + quote(instanceId)}, "limit": {lookupLimit}}.Encode()
+	err := f.send("FOLIO holdings lookup", http.MethodGet, "/holdings-storage/holdings?"+query, nil, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res.HoldingsRecords, nil
+}
+
+func (f *FolioClientImpl) LookupItemsByHoldingsId(holdingsId string) ([]Item, error) {
+	var res struct {
+		Items []Item `json:"items"`
+	}
+	query := url.Values{"query": {"holdingsRecordId==" + quote(holdingsId)}, "limit": {lookupLimit}}.Encode()
+	err := f.send("FOLIO item lookup", http.MethodGet, "/inventory/items?"+query, nil, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res.Items, nil
+}
+
+func (f *FolioClientImpl) CreateInstance(instance Instance) (*Instance, error) {
+	var res Instance
+	err := f.send("FOLIO create instance", http.MethodPost, "/inventory/instances", instance, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (f *FolioClientImpl) CreateHoldings(holdings Holdings) (*Holdings, error) {
+	var res Holdings
+	err := f.send("FOLIO create holdings", http.MethodPost, "/holdings-storage/holdings", holdings, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (f *FolioClientImpl) CreateItem(item Item) (*Item, error) {
+	var res Item
+	err := f.send("FOLIO create item", http.MethodPost, "/inventory/items", item, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (f *FolioClientImpl) DeleteInstance(id string) error {
+	return f.send("FOLIO delete instance", http.MethodDelete, "/inventory/instances/"+url.PathEscape(id), nil, nil)
+}
+
+func (f *FolioClientImpl) DeleteHoldings(id string) error {
+	return f.send("FOLIO delete holdings", http.MethodDelete, "/holdings-storage/holdings/"+url.PathEscape(id), nil, nil)
+}
+
+func (f *FolioClientImpl) DeleteItem(id string) error {
+	return f.send("FOLIO delete item", http.MethodDelete, "/inventory/items/"+url.PathEscape(id), nil, nil)
+}
+
+func (f *FolioClientImpl) CreateRequest(request Request) (*Request, error) {
+	var res Request
+	err := f.send("FOLIO create request", http.MethodPost, "/circulation/requests", request, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// CancelRequest closes a request. The request is fetched and put back as is, except for the
+// cancellation fields, so that properties unknown to this client are preserved.
+func (f *FolioClientImpl) CancelRequest(id string, cancellationReasonId string) error {
+	path := "/circulation/requests/" + url.PathEscape(id)
+	var request map[string]any
+	err := f.send("FOLIO cancel request", http.MethodGet, path, nil, &request)
+	if err != nil {
+		return err
+	}
+	request["status"] = "Closed - Cancelled"
+	request["cancelledDate"] = time.Now().UTC().Format(time.RFC3339)
+	if cancellationReasonId != "" {
+		request["cancellationReasonId"] = cancellationReasonId
+	}
+	return f.send("FOLIO cancel request", http.MethodPut, path, request, nil)
+}
+
+func (f *FolioClientImpl) CheckOutByBarcode(itemBarcode string, userBarcode string, servicePointId string) (*Loan, error) {
+	body := map[string]any{
+		"itemBarcode":    itemBarcode,
+		"userBarcode":    userBarcode,
+		"servicePointId": servicePointId,
+	}
+	var loan Loan
+	err := f.send("FOLIO check out", http.MethodPost, "/circulation/check-out-by-barcode", body, &loan)
+	if err != nil {
+		return nil, err
+	}
+	return &loan, nil
+}
+
+func (f *FolioClientImpl) CheckInByBarcode(itemBarcode string, servicePointId string) error {
+	body := map[string]any{
+		"itemBarcode":    itemBarcode,
+		"servicePointId": servicePointId,
+		"checkInDate":    time.Now().UTC().Format(time.RFC3339),
+	}
+	return f.send("FOLIO check in", http.MethodPost, "/circulation/check-in-by-barcode", body, nil)
+}
+
+func (f *FolioClientImpl) RenewByBarcode(itemBarcode string, userBarcode string) (*Loan, error) {
+	body := map[string]any{
+		"itemBarcode": itemBarcode,
+		"userBarcode": userBarcode,
+	}
+	var loan Loan
+	err := f.send("FOLIO renew", http.MethodPost, "/circulation/renew-by-barcode", body, &loan)
+	if err != nil {
+		return nil, err
+	}
+	return &loan, nil
+}
+
+func quote(value string) string {
+	return "\"" + cqlbuilder.EscapeMaskingChars(cqlbuilder.EscapeSpecialChars(value)) + "\""
+}
+
+func barcodeQuery(barcode string) string {
+	return url.Values{"query": {"barcode==" + quote(barcode)}}.Encode()
+}
+
+// login obtains an access token unless one is held already or no username is configured
+func (f *FolioClientImpl) login() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.token != "" || f.config.Username == "" {
+		return nil
+	}
+	body := map[string]any{"username": f.config.Username, "password": f.config.Password}
+	response, err := f.exchange(http.MethodPost, "/authn/login-with-expiry", body, "")
+	if err != nil {
+		return fmt.Errorf("FOLIO login failed: %s", err.Error())
+	}
+	defer response.Body.Close()
+	resBody, _ := io.ReadAll(response.Body)
+	if response.StatusCode != http.StatusCreated && response.StatusCode != http.StatusOK {
+		err = &FolioError{Message: "FOLIO login failed", Status: response.StatusCode, Detail: errorDetail(resBody)}
+	}
+	f.log(http.MethodPost, "/authn/login-with-expiry", body, response.StatusCode, resBody, err)
+	if err != nil {
+		return err
+	}
+	for _, cookie := range response.Cookies() {
+		if cookie.Name == tokenCookie {
+			f.token = cookie.Value
+		}
+	}
+	if f.token == "" {
+		f.token = response.Header.Get("X-Okapi-Token")
+	}
+	if f.token == "" {
+		return fmt.Errorf("FOLIO login failed: no access token in response")
+	}
+	return nil
+}
+
+func (f *FolioClientImpl) send(op string, method string, path string, body any, result any) error {
+	if f.config.Address == "" {
+		return fmt.Errorf("missing FOLIO address in configuration")
+	}
+	if err := f.login(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	token := f.token
+	f.mu.Unlock()
+	response, err := f.exchange(method, path, body, token)
+	if err != nil {
+		return fmt.Errorf("%s failed: %s", op, err.Error())
+	}
+	defer response.Body.Close()
+	resBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("%s failed: %s", op, err.Error())
+	}
+	if response.StatusCode == http.StatusUnauthorized {
+		// token expired: log in again on next call
+		f.mu.Lock()
+		f.token = ""
+		f.mu.Unlock()
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		err = &FolioError{Message: op + " failed", Status: response.StatusCode, Detail: errorDetail(resBody)}
+	} else if result != nil && len(resBody) > 0 {
+		err = json.Unmarshal(resBody, result)
+	}
+	f.log(method, path, body, response.StatusCode, resBody, err)
+	return err
+}
+
+func (f *FolioClientImpl) exchange(method string, path string, body any, token string) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(buf)
+	}
+	req, err := http.NewRequest(method, f.config.Address+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json, text/plain")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("X-Okapi-Tenant", f.config.Tenant)
+	if token != "" {
+		req.Header.Set("X-Okapi-Token", token)
+	}
+	response, err := f.client.Do(req)
+	if err != nil {
+		f.log(method, path, body, 0, nil, err)
+		return nil, err
+	}
+	return response, nil
+}
+
+func (f *FolioClientImpl) log(method string, path string, body any, status int, resBody []byte, err error) {
+	if f.logFunc == nil {
+		return
+	}
+	outgoing := map[string]any{"method": method, "path": path}
+	if body != nil {
+		outgoing["body"] = hideSensitive(body)
+	}
+	incoming := map[string]any{}
+	if status != 0 {
+		incoming["status"] = status
+	}
+	if len(resBody) > 0 {
+		var value any
+		if json.Unmarshal(resBody, &value) == nil {
+			incoming["body"] = value
+		} else {
+			incoming["body"] = string(resBody)
+		}
+	}
+	f.logFunc(outgoing, incoming, err)
+}
+
+// hideSensitive returns a copy of a login body with the password removed
+func hideSensitive(body any) any {
+	m, ok := body.(map[string]any)
+	if !ok || m["password"] == nil {
+		return body
+	}
+	c := make(map[string]any, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	c["password"] = "***"
+	return c
+}
+
+// errorDetail returns the messages of a FOLIO error response, or the body as is if it is not JSON
+func errorDetail(body []byte) string {
+	var res struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(body, &res) != nil || len(res.Errors) == 0 {
+		return strings.TrimSpace(string(body))
+	}
+	var messages []string
+	for _, e := range res.Errors {
+		messages = append(messages, e.Message)
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,234 @@
+package folioclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/indexdata/crosslink/illmock/foliomock"
+	"github.com/stretchr/testify/assert"
+)
+
+var server *httptest.Server
+
+func TestMain(m *testing.M) {
+	server = httptest.NewServer(foliomock.CreateFolioMock())
+	exitCode := m.Run()
+	server.Close()
+	os.Exit(exitCode)
+}
+
+func createTestClient() FolioClient {
+	return NewFolioClient(http.DefaultClient, Config{
+		Address:  server.URL + "/",
+		Tenant:   "diku",
+		Username: "ill",
+		Password: "secret",
+	})
+}
+
+func TestLookupUserByBarcode(t *testing.T) {
+	client := createTestClient()
+	user, err := client.LookupUserByBarcode("u1")
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", user.Barcode)
+	assert.True(t, user.Active)
+	assert.NotEmpty(t, user.Id)
+
+	user, err = client.LookupUserByBarcode("foo")
+	assert.NoError(t, err)
+	assert.Nil(t, user)
+}
+
+func TestLookupInstanceByHrid(t *testing.T) {
+	client := createTestClient()
+	instance, err := client.LookupInstanceByHrid("in1")
+	assert.NoError(t, err)
+	assert.Equal(t, "in1", instance.Hrid)
+
+	instance, err = client.LookupInstanceByHrid("foo")
+	assert.NoError(t, err)
+	assert.Nil(t, instance)
+}
+
+func TestLookupItem(t *testing.T) {
+	client := createTestClient()
+	item, err := client.LookupItemByBarcode("l1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Checked out", item.Status.Name)
+	assert.Equal(t, "QA76 L1", item.EffectiveCallNumberComponents.CallNumber)
+
+	item, err = client.LookupItemByBarcode("foo")
+	assert.NoError(t, err)
+	assert.Nil(t, item)
+
+	item, err = client.GetItem("i1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Available", item.Status.Name)
+
+	_, err = client.GetItem("foo")
+	assert.Equal(t, "FOLIO item lookup failed: HTTP 404: Not found", err.Error())
+}
+
+func TestLookupHoldingsItems(t *testing.T) {
+	client := createTestClient()
+	holdings, err := client.LookupHoldingsByInstanceId("in1")
+	assert.NoError(t, err)
+	assert.Len(t, holdings, 1)
+	assert.Equal(t, "in1", holdings[0].InstanceId)
+
+	holdings, err = client.LookupHoldingsByInstanceId("foo")
+	assert.NoError(t, err)
+	assert.Empty(t, holdings)
+
+	items, err := client.LookupItemsByHoldingsId("h1")
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+	assert.Equal(t, "h1", items[0].HoldingsRecordId)
+	assert.Equal(t, "Available", items[0].Status.Name)
+
+	items, err = client.LookupItemsByHoldingsId("foo")
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestTemporaryItem(t *testing.T) {
+	client := createTestClient()
+	instance, err := client.CreateInstance(Instance{Title: "A title", Source: "FOLIO", InstanceTypeId: "it1"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, instance.Id)
+	holdings, err := client.CreateHoldings(Holdings{InstanceId: instance.Id, PermanentLocationId: "loc1"})
+	assert.NoError(t, err)
+	assert.Equal(t, instance.Id, holdings.InstanceId)
+	item, err := client.CreateItem(Item{HoldingsRecordId: holdings.Id, Barcode: "b1", Status: ItemStatus{Name: "Available"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "b1", item.Barcode)
+
+	_, err = client.CreateItem(Item{Barcode: "foo"})
+	assert.Equal(t, "FOLIO create item failed: HTTP 422: Barcode must be unique", err.Error())
+
+	assert.NoError(t, client.DeleteItem(item.Id))
+	assert.NoError(t, client.DeleteHoldings(holdings.Id))
+	assert.NoError(t, client.DeleteInstance(instance.Id))
+	assert.Error(t, client.DeleteItem("foo"))
+}
+
+func TestRequest(t *testing.T) {
+	client := createTestClient()
+	request, err := client.CreateRequest(Request{
+		Id:                    "r1",
+		RequestType:           "Page",
+		RequestLevel:          "Title",
+		RequestDate:           time.Now(),
+		InstanceId:            "in1",
+		RequesterId:           "u1",
+		FulfillmentPreference: "Hold Shelf",
+		PickupServicePointId:  "sp1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "r1", request.Id)
+	assert.Equal(t, "in1", request.Item.Barcode)
+	assert.Equal(t, "Mock title in1", request.Instance.Title)
+
+	_, err = client.CreateRequest(Request{RequestType: "Page", RequestLevel: "Title", RequesterId: "u1", InstanceId: "foo"})
+	assert.Equal(t, "FOLIO create request failed: HTTP 422: Cannot create a request with no available items", err.Error())
+
+	assert.NoError(t, client.CancelRequest("r1", "c1"))
+	err = client.CancelRequest("r1", "")
+	assert.Equal(t, "FOLIO cancel request failed: HTTP 422: Cancellation reason is required", err.Error())
+	assert.Error(t, client.CancelRequest("foo", "c1"))
+}
+
+func TestCirculation(t *testing.T) {
+	client := createTestClient()
+	loan, err := client.CheckOutByBarcode("b1", "u1", "sp1")
+	assert.NoError(t, err)
+	assert.True(t, loan.DueDate.After(time.Now()))
+	assert.Equal(t, "Mock title b1", loan.Item.Title)
+
+	_, err = client.CheckOutByBarcode("foo", "u1", "sp1")
+	assert.Equal(t, "FOLIO check out failed: HTTP 422: No record found for itemBarcode foo", err.Error())
+
+	assert.NoError(t, client.CheckInByBarcode("b1", "sp1"))
+	err = client.CheckInByBarcode("b1", "")
+	assert.Equal(t, "FOLIO check in failed: HTTP 422: servicePointId is required", err.Error())
+
+	loan, err = client.RenewByBarcode("b1", "u1")
+	assert.NoError(t, err)
+	assert.True(t, loan.DueDate.After(time.Now()))
+	_, err = client.RenewByBarcode("b1", "foo")
+	assert.Error(t, err)
+}
+
+func TestLoginFailed(t *testing.T) {
+	client := NewFolioClient(http.DefaultClient, Config{Address: server.URL, Tenant: "diku", Username: "foo", Password: "secret"})
+	_, err := client.LookupUserByBarcode("u1")
+	assert.Equal(t, "FOLIO login failed: HTTP 422: Invalid credentials", err.Error())
+}
+
+func TestNoLogin(t *testing.T) {
+	client := NewFolioClient(http.DefaultClient, Config{Address: server.URL, Tenant: "diku"})
+	user, err := client.LookupUserByBarcode("u1")
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", user.Barcode)
+}
+
+func TestMissingTenant(t *testing.T) {
+	client := NewFolioClient(http.DefaultClient, Config{Address: server.URL})
+	_, err := client.LookupUserByBarcode("u1")
+	assert.Equal(t, "FOLIO user lookup failed: HTTP 400: Missing X-Okapi-Tenant header", err.Error())
+}
+
+func TestMissingAddress(t *testing.T) {
+	client := NewFolioClient(http.DefaultClient, Config{})
+	_, err := client.LookupUserByBarcode("u1")
+	assert.Equal(t, "missing FOLIO address in configuration", err.Error())
+}
+
+func TestConnectFailed(t *testing.T) {
+	client := NewFolioClient(http.DefaultClient, Config{Address: "http://localhost:1", Tenant: "diku"})
+	var incoming map[string]any
+	var logErr error
+	client.SetLogFunc(func(out map[string]any, in map[string]any, err error) {
+		incoming = in
+		logErr = err
+	})
+	_, err := client.LookupUserByBarcode("u1")
+	assert.ErrorContains(t, err, "FOLIO user lookup failed: ")
+	assert.Error(t, logErr)
+	assert.Empty(t, incoming)
+}
+
+func TestLogFunc(t *testing.T) {
+	client := createTestClient()
+	var outgoing []map[string]any
+	var incoming []map[string]any
+	client.SetLogFunc(func(out map[string]any, in map[string]any, err error) {
+		assert.NoError(t, err)
+		outgoing = append(outgoing, out)
+		incoming = append(incoming, in)
+	})
+	_, err := client.CheckOutByBarcode("b1", "u1", "sp1")
+	assert.NoError(t, err)
+	assert.Len(t, outgoing, 2)
+	assert.Equal(t, "/authn/login-with-expiry", outgoing[0]["path"])
+	assert.Equal(t, map[string]any{"username": "ill", "password": "***"}, outgoing[0]["body"])
+	assert.Equal(t, http.StatusCreated, incoming[0]["status"])
+	assert.Equal(t, "/circulation/check-out-by-barcode", outgoing[1]["path"])
+	assert.Equal(t, http.MethodPost, outgoing[1]["method"])
+	assert.Equal(t, "u1", outgoing[1]["body"].(map[string]any)["userBarcode"])
+	assert.NotEmpty(t, incoming[1]["body"].(map[string]any)["dueDate"])
+}
+
+func TestErrorDetail(t *testing.T) {
+	assert.Equal(t, "a; b", errorDetail([]byte(`{"errors":[{"message":"a"},{"message":"b"}]}`)))
+	assert.Equal(t, "plain text", errorDetail([]byte("plain text\n")))
+	assert.Equal(t, "{}", errorDetail([]byte(`{}`)))
+}
+
+func TestFolioError(t *testing.T) {
+	err := &FolioError{Message: "failed", Status: 500}
+	assert.Equal(t, "failed: HTTP 500", err.Error())
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/indexdata/crosslink/broker/common"
 	"github.com/indexdata/crosslink/broker/events"
 	"github.com/indexdata/crosslink/broker/ill_db"
+	"github.com/indexdata/crosslink/broker/metrics"
 	"github.com/indexdata/crosslink/iso18626"
 	"github.com/indexdata/go-utils/utils"
 	"github.com/jackc/pgerrcode"
@@ -138,21 +139,25 @@ func Iso18626PostHandler(repo ill_db.IllRepo, eventBus events.EventBus, dirAdapt
 		err = xml.Unmarshal(byteReq, illMessage)
 		if err != nil {
 			ctx.Logger().Error("failure parsing request", "error", err, "body", string(byteReq))
+			metrics.IsoMessagesReceived.WithLabelValues(invalidMessageType, string(iso18626.TypeMessageStatusERROR), unknownPeer).Inc()
 			http.Error(w, "failure parsing request", http.StatusBadRequest)
 			return
 		}
 
+		rec := &confirmationRecorder{ResponseWriter: w}
 		if illMessage.Request != nil {
-			handleRequest(ctx, illMessage, w, repo, eventBus, dirAdapter)
+			handleRequest(ctx, illMessage, rec, repo, eventBus, dirAdapter)
 		} else if illMessage.RequestingAgencyMessage != nil {
-			handleRequestingAgencyMessage(ctx, illMessage, w, repo, eventBus)
+			handleRequestingAgencyMessage(ctx, illMessage, rec, repo, eventBus)
 		} else if illMessage.SupplyingAgencyMessage != nil {
-			handleSupplyingAgencyMessage(ctx, illMessage, w, repo, eventBus)
+			handleSupplyingAgencyMessage(ctx, illMessage, rec, repo, eventBus)
 		} else {
 			ctx.Logger().Error("invalid ISO18626 message", "error", err, "body", string(byteReq))
+			metrics.IsoMessagesReceived.WithLabelValues(invalidMessageType, string(iso18626.TypeMessageStatusERROR), unknownPeer).Inc()
 			http.Error(w, "invalid ISO18626 message", http.StatusBadRequest)
 			return
 		}
+		observeConfirmation(rec)
 	}
 }
 
@@ -348,6 +353,7 @@ func handleRequest(ctx common.ExtendedContext, illMessage *iso18626.ISO18626Mess
 		handleRequestError(ctx, w, request, iso18626.TypeErrorTypeUnrecognisedDataValue, ReqAgencyNotFound)
 		return resultMap
 	}
+	setSenderPeer(w, requesterSymbol.String)
 
 	var err error
 	var id string
@@ -402,6 +408,9 @@ func handleRequest(ctx common.ExtendedContext, illMessage *iso18626.ISO18626Mess
 }
 
 func writeResponse(ctx common.ExtendedContext, resmsg *iso18626.ISO18626Message, w http.ResponseWriter) {
+	if rec, ok := w.(*confirmationRecorder); ok {
+		rec.confirmation = resmsg
+	}
 	output, err := xml.MarshalIndent(resmsg, "  ", "  ")
 	if err != nil {
 		ctx.Logger().Error("failed to produce response", "error", err, "body", string(output))
@@ -416,6 +425,53 @@ func writeResponse(ctx common.ExtendedContext, resmsg *iso18626.ISO18626Message,
 	}
 }
 
+const invalidMessageType = "invalid"
+
+// unknownPeer is the peer label of messages whose sender was not resolved to a known peer,
+// the sender symbol is not authenticated and would allow unbounded label values
+const unknownPeer = "unknown"
+
+// confirmationRecorder keeps the confirmation written in response to the received message
+// and the symbol of the sender once the handler has resolved it to a known peer
+type confirmationRecorder struct {
+	http.ResponseWriter
+	confirmation *iso18626.ISO18626Message
+	peer         string
+}
+
+// setSenderPeer records the symbol of the sender resolved by the handler as the peer label of the received message
+func setSenderPeer(w http.ResponseWriter, symbol string) {
+	if rec, ok := w.(*confirmationRecorder); ok {
+		rec.peer = symbol
+	}
+}
+
+// observeConfirmation counts the received message answered by the recorded confirmation
+func observeConfirmation(rec *confirmationRecorder) {
+	if rec.confirmation == nil {
+		return
+	}
+	var messageType string
+	var header *iso18626.ConfirmationHeader
+	if rec.confirmation.RequestConfirmation != nil {
+		messageType = "Request"
+		header = &rec.confirmation.RequestConfirmation.ConfirmationHeader
+	} else if rec.confirmation.RequestingAgencyMessageConfirmation != nil {
+		messageType = "RequestingAgencyMessage"
+		header = &rec.confirmation.RequestingAgencyMessageConfirmation.ConfirmationHeader
+	} else if rec.confirmation.SupplyingAgencyMessageConfirmation != nil {
+		messageType = "SupplyingAgencyMessage"
+		header = &rec.confirmation.SupplyingAgencyMessageConfirmation.ConfirmationHeader
+	} else {
+		return
+	}
+	peer := rec.peer
+	if peer == "" {
+		peer = unknownPeer
+	}
+	metrics.IsoMessagesReceived.WithLabelValues(messageType, string(header.MessageStatus), peer).Inc()
+}
+
 func handleRequestError(ctx common.ExtendedContext, w http.ResponseWriter, request *iso18626.Request, errorType iso18626.TypeErrorType, errorValue ErrorValue) {
 	ctx.Logger().Warn("request confirmation error", "errorType", errorType, "errorValue", errorValue,
 		"requesterSymbol", request.Header.RequestingAgencyId.AgencyIdValue,
@@ -499,6 +555,7 @@ func handleRequestingAgencyMessage(ctx common.ExtendedContext, illMessage *iso18
 			}
 			return err
 		}
+		setSenderPeer(w, illTrans.RequesterSymbol.String)
 		symbol := getSupplierSymbol(&illMessage.RequestingAgencyMessage.Header)
 		if len(symbol) == 0 {
 			return ErrSupplierNotFoundOrInvalid
@@ -656,7 +713,7 @@ func handleRequestingAgencyErrorWithNotice(ctx common.ExtendedContext, w http.Re
 			},
 		},
 	}
-	_, err := eventBus.CreateNotice(illTransId, events.EventNameRequesterMsgReceived, eventData, events.EventStatusProblem, events.EventDomainIllTransaction, events.SignalConsumers)
+	_, err := eventBus.CreateNotice(ctx, illTransId, events.EventNameRequesterMsgReceived, eventData, events.EventStatusProblem, events.EventDomainIllTransaction, events.SignalConsumers)
 	if err != nil {
 		ctx.Logger().Error(InternalFailedToCreateNotice, "error", err, "transactionId", illTransId)
 	}
@@ -729,6 +786,7 @@ func handleSupplyingAgencyMessage(ctx common.ExtendedContext, illMessage *iso186
 			eventBus, illTrans.ID)
 		return
 	}
+	setSenderPeer(w, symbol)
 	supplierPeer, err := repo.GetPeerById(ctx, supplier.SupplierID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -911,14 +969,14 @@ func handleSupplyingAgencyErrorWithNotice(ctx common.ExtendedContext, w http.Res
 			},
 		},
 	}
-	_, err := eventBus.CreateNotice(illTransId, events.EventNameSupplierMsgReceived, eventData, events.EventStatusProblem, events.EventDomainIllTransaction, events.SignalConsumers)
+	_, err := eventBus.CreateNotice(ctx, illTransId, events.EventNameSupplierMsgReceived, eventData, events.EventStatusProblem, events.EventDomainIllTransaction, events.SignalConsumers)
 	if err != nil {
 		ctx.Logger().Error(InternalFailedToCreateNotice, "error", err, "transactionId", illTransId)
 	}
 }
 
 func createNotice(ctx common.ExtendedContext, eventBus events.EventBus, illTransId string, eventName events.EventName, eventData events.EventData, eventStatus events.EventStatus) (string, error) {
-	id, err := eventBus.CreateNotice(illTransId, eventName, eventData, eventStatus, events.EventDomainIllTransaction, events.SignalConsumers)
+	id, err := eventBus.CreateNotice(ctx, illTransId, eventName, eventData, eventStatus, events.EventDomainIllTransaction, events.SignalConsumers)
 	if err != nil {
 		ctx.Logger().Error(InternalFailedToCreateNotice, "error", err, "transactionId", illTransId)
 		return "", err
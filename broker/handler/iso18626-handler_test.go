@@ -3,18 +3,21 @@ package handler
 import (
 	"context"
 	"errors"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/indexdata/crosslink/broker/common"
 	"github.com/indexdata/crosslink/broker/events"
 	"github.com/indexdata/crosslink/broker/ill_db"
+	"github.com/indexdata/crosslink/broker/metrics"
 	"github.com/indexdata/crosslink/broker/test/mocks"
 	dirapi "github.com/indexdata/crosslink/directory/api"
 
 	"github.com/indexdata/crosslink/iso18626"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -491,3 +494,38 @@ func TestCheckDuplicateRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestObserveConfirmation(t *testing.T) {
+	count := func(messageType string, status iso18626.TypeMessageStatus, peer string) float64 {
+		return testutil.ToFloat64(metrics.IsoMessagesReceived.WithLabelValues(messageType, string(status), peer))
+	}
+	requests := count("Request", iso18626.TypeMessageStatusOK, "ISIL:REQ")
+	supplierErrors := count("SupplyingAgencyMessage", iso18626.TypeMessageStatusERROR, unknownPeer)
+
+	header := iso18626.ConfirmationHeader{
+		RequestingAgencyId: &iso18626.TypeAgencyId{AgencyIdType: iso18626.TypeSchemeValuePair{Text: "ISIL"}, AgencyIdValue: "REQ"},
+		SupplyingAgencyId:  &iso18626.TypeAgencyId{AgencyIdType: iso18626.TypeSchemeValuePair{Text: "ISIL"}, AgencyIdValue: "SUP"},
+		MessageStatus:      iso18626.TypeMessageStatusOK,
+	}
+	ctx := common.CreateExtCtxWithArgs(context.Background(), nil)
+	rec := &confirmationRecorder{ResponseWriter: httptest.NewRecorder()}
+	resmsg := iso18626.NewISO18626Message()
+	resmsg.RequestConfirmation = &iso18626.RequestConfirmation{ConfirmationHeader: header}
+	setSenderPeer(rec, "ISIL:REQ")
+	writeResponse(ctx, resmsg, rec)
+	assert.Equal(t, resmsg, rec.confirmation)
+	observeConfirmation(rec)
+
+	// the sender was not resolved to a known peer
+	header.MessageStatus = iso18626.TypeMessageStatusERROR
+	resmsg = iso18626.NewISO18626Message()
+	resmsg.SupplyingAgencyMessageConfirmation = &iso18626.SupplyingAgencyMessageConfirmation{ConfirmationHeader: header}
+	observeConfirmation(&confirmationRecorder{confirmation: resmsg})
+
+	// no confirmation was written
+	observeConfirmation(&confirmationRecorder{})
+
+	assert.Equal(t, requests+1, count("Request", iso18626.TypeMessageStatusOK, "ISIL:REQ"))
+	assert.Equal(t, supplierErrors+1, count("SupplyingAgencyMessage", iso18626.TypeMessageStatusERROR, unknownPeer))
+	assert.Equal(t, 0.0, count("SupplyingAgencyMessage", iso18626.TypeMessageStatusERROR, "ISIL:SUP"))
+}
@@ -1,6 +1,16 @@
 package lms
 
-import "github.com/indexdata/crosslink/broker/ncipclient"
+import (
+	"errors"
+	"time"
+
+	"github.com/indexdata/crosslink/broker/ncipclient"
+	dirapi "github.com/indexdata/crosslink/directory/api"
+	"github.com/indexdata/crosslink/ncip"
+)
+
+// ErrNotSupported is returned by adapters for operations the LMS cannot perform
+var ErrNotSupported = errors.New("operation not supported by LMS")
 
 // LmsAdapter is an interface defining methods for interacting with a Library Management System (LMS)
 // https://github.com/openlibraryenvironment/mod-rs/blob/master/service/src/main/groovy/org/olf/rs/lms/HostLMSActions.groovy
@@ -44,6 +54,14 @@ type LmsAdapter interface {
 
 	CreateUserFiscalTransaction(userId string, itemId string) error
 
+	// LookupItems returns the circulation statuses of the items held for a bibliographic record.
+	// The supplier locator only knows the local record id of a holding, not a particular item,
+	// so the lookup is made by record and the supplier qualifies if any of its items is available.
+	// ErrNotSupported is returned if the LMS cannot look up items by record.
+	LookupItems(bibId string) (circulationStatuses []string, err error)
+
+	RenewItem(itemId string, userId string, desiredDateDue time.Time) (dateDue time.Time, err error)
+
 	InstitutionalPatron(requesterSymbol string) string
 
 	SupplierPickupLocation() string
@@ -52,3 +70,44 @@ type LmsAdapter interface {
 
 	RequesterPickupLocation() string
 }
+
+// IsLookupItemEnabled reports whether the LMS of a directory entry should be asked for item availability.
+// Only the LMS configuration has the option, as SIP2 cannot look up the items of a record.
+func IsLookupItemEnabled(entry dirapi.Entry) bool {
+	return entry.LmsConfig != nil && entry.LmsConfig.LookupItemEnabled != nil && *entry.LmsConfig.LookupItemEnabled
+}
+
+// Availability tells whether a supplier can fill a request from its items
+type Availability string
+
+const (
+	AvailabilityAvailable   Availability = "available"
+	AvailabilityUnavailable Availability = "unavailable"
+	AvailabilityUnknown     Availability = "unknown"
+)
+
+// ItemsAvailability tells whether any of the items with the given circulation statuses can be supplied.
+// Items with a status that is neither known to be available nor known to be unavailable make the
+// result unknown, unless another item is available. A record without items is unavailable.
+func ItemsAvailability(circulationStatuses []string) Availability {
+	availability := AvailabilityUnavailable
+	for _, status := range circulationStatuses {
+		switch ncip.CirculationStatusValue(status) {
+		case ncip.CirculationStatusAvailableOnShelf,
+			ncip.CirculationStatusWaitingToBeReshelved:
+			return AvailabilityAvailable
+		case ncip.CirculationStatusAvailableForPickup,
+			ncip.CirculationStatusInProcess,
+			ncip.CirculationStatusInTransit,
+			ncip.CirculationStatusLost,
+			ncip.CirculationStatusMissing,
+			ncip.CirculationStatusNotAvailable,
+			ncip.CirculationStatusOnLoan,
+			ncip.CirculationStatusOnOrder,
+			ncip.CirculationStatusRecalled:
+		default:
+			availability = AvailabilityUnknown
+		}
+	}
+	return availability
+}
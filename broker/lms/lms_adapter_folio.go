@@ -0,0 +1,365 @@
+package lms
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/indexdata/crosslink/broker/folioclient"
+	"github.com/indexdata/crosslink/broker/ncipclient"
+	dirapi "github.com/indexdata/crosslink/directory/api"
+	"github.com/indexdata/crosslink/ncip"
+)
+
+// folioNamespace is used for deriving the FOLIO identifiers of temporary items and requests
+// from broker identifiers, so that they can be deleted and cancelled without keeping state
+var folioNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://github.com/indexdata/crosslink/broker/lms/folio"))
+
+// folioCirculationStatus maps FOLIO item status names to NCIP circulation status values
+var folioCirculationStatus = map[string]ncip.CirculationStatusValue{
+	"Available":                    ncip.CirculationStatusAvailableOnShelf,
+	"Checked out":                  ncip.CirculationStatusOnLoan,
+	"In transit":                   ncip.CirculationStatusInTransit,
+	"Awaiting pickup":              ncip.CirculationStatusAvailableForPickup,
+	"Awaiting delivery":            ncip.CirculationStatusAvailableForPickup,
+	"On order":                     ncip.CirculationStatusOnOrder,
+	"In process":                   ncip.CirculationStatusInProcess,
+	"In process (non-requestable)": ncip.CirculationStatusInProcess,
+	"Paged":                        ncip.CirculationStatusNotAvailable,
+	"Missing":                      ncip.CirculationStatusMissing,
+	"Long missing":                 ncip.CirculationStatusMissing,
+	"Aged to lost":                 ncip.CirculationStatusLost,
+	"Declared lost":                ncip.CirculationStatusLost,
+	"Claimed returned":             ncip.CirculationStatusNotAvailable,
+	"Lost and paid":                ncip.CirculationStatusLost,
+	"Withdrawn":                    ncip.CirculationStatusNotAvailable,
+	"Restricted":                   ncip.CirculationStatusNotAvailable,
+	"Unavailable":                  ncip.CirculationStatusNotAvailable,
+	"Unknown":                      ncip.CirculationStatusUndefined,
+	"Intellectual item":            ncip.CirculationStatusNotAvailable,
+	"Order closed":                 ncip.CirculationStatusNotAvailable,
+}
+
+// FOLIO LMS Adapter, calling the FOLIO users, inventory and circulation APIs directly.
+// Temporary items are created as a suppressed instance, holdings record and item, with
+// identifiers derived from the item barcode. There are no fees, so CreateUserFiscalTransaction does nothing.
+type LmsAdapterFolio struct {
+	folioClient folioclient.FolioClient
+	config      dirapi.LmsConfig
+	folio       dirapi.FolioLmsConfig
+}
+
+func CreateLmsAdapterFolio(lmsConfig dirapi.LmsConfig) (LmsAdapter, error) {
+	l := &LmsAdapterFolio{config: lmsConfig}
+	if l.config.Address == "" {
+		return nil, fmt.Errorf("missing FOLIO address in LMS configuration")
+	}
+	if l.config.Folio == nil || l.config.Folio.Tenant == "" {
+		return nil, fmt.Errorf("missing FOLIO tenant in LMS configuration")
+	}
+	l.folio = *l.config.Folio
+	l.folioClient = folioclient.NewFolioClient(http.DefaultClient, folioclient.Config{
+		Address:  l.config.Address,
+		Tenant:   l.folio.Tenant,
+		Username: derefString(l.folio.Username),
+		Password: derefString(l.folio.Password),
+	})
+	return l, nil
+}
+
+func folioId(kind string, id string) string {
+	return uuid.NewSHA1(folioNamespace, []byte(kind+":"+id)).String()
+}
+
+func (l *LmsAdapterFolio) SetLogFunc(logFunc ncipclient.NcipLogFunc) {
+	l.folioClient.SetLogFunc(folioclient.FolioLogFunc(logFunc))
+}
+
+func (l *LmsAdapterFolio) requester(barcode string) (*folioclient.User, error) {
+	if barcode == "" {
+		return nil, fmt.Errorf("empty patron identifier")
+	}
+	user, err := l.folioClient.LookupUserByBarcode(barcode)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("FOLIO user not found: %s", barcode)
+	}
+	if !user.Active {
+		return nil, fmt.Errorf("FOLIO user is inactive: %s", barcode)
+	}
+	return user, nil
+}
+
+// LookupUser checks that an active user has the patron barcode. The barcode is
+// returned as the user identifier as FOLIO circulation is by barcode.
+func (l *LmsAdapterFolio) LookupUser(patron string) (string, error) {
+	if l.config.LookupUserEnabled != nil && !*l.config.LookupUserEnabled {
+		return patron, nil
+	}
+	user, err := l.requester(patron)
+	if err != nil {
+		return "", err
+	}
+	return user.Barcode, nil
+}
+
+func (l *LmsAdapterFolio) AcceptItem(
+	itemId string,
+	requestId string,
+	userId string,
+	author string,
+	title string,
+	isbn string,
+	callNumber string,
+	pickupLocation string,
+	requestedAction string,
+) error {
+	if l.config.AcceptItemEnabled != nil && !*l.config.AcceptItemEnabled {
+		return nil
+	}
+	if title == "" {
+		title = itemId
+	}
+	instance, err := l.folioClient.CreateInstance(folioclient.Instance{
+		Id:                folioId("instance", itemId),
+		Title:             title,
+		Source:            "FOLIO",
+		InstanceTypeId:    derefString(l.folio.InstanceTypeId),
+		DiscoverySuppress: true,
+	})
+	if err != nil {
+		return err
+	}
+	holdings, err := l.folioClient.CreateHoldings(folioclient.Holdings{
+		Id:                  folioId("holdings", itemId),
+		InstanceId:          instance.Id,
+		PermanentLocationId: derefString(l.folio.HoldingsLocationId),
+		CallNumber:          callNumber,
+		DiscoverySuppress:   true,
+	})
+	if err != nil {
+		return err
+	}
+	item := folioclient.Item{
+		Id:                folioId("item", itemId),
+		HoldingsRecordId:  holdings.Id,
+		Barcode:           itemId,
+		Status:            folioclient.ItemStatus{Name: "Available"},
+		DiscoverySuppress: true,
+	}
+	if l.folio.MaterialTypeId != nil {
+		item.MaterialType = &folioclient.Ref{Id: *l.folio.MaterialTypeId}
+	}
+	if l.folio.LoanTypeId != nil {
+		item.PermanentLoanType = &folioclient.Ref{Id: *l.folio.LoanTypeId}
+	}
+	_, err = l.folioClient.CreateItem(item)
+	if err != nil || userId == "" {
+		return err
+	}
+	user, err := l.requester(userId)
+	if err != nil {
+		return err
+	}
+	_, err = l.folioClient.CreateRequest(folioclient.Request{
+		Id:                    folioId("request", requestId),
+		RequestType:           "Page",
+		RequestLevel:          "Item",
+		RequestDate:           time.Now().UTC(),
+		InstanceId:            instance.Id,
+		HoldingsRecordId:      holdings.Id,
+		ItemId:                item.Id,
+		RequesterId:           user.Id,
+		FulfillmentPreference: "Hold Shelf",
+		PickupServicePointId:  derefString(l.folio.ServicePointId),
+	})
+	return err
+}
+
+// DeleteItem removes a temporary item created by AcceptItem along with its holdings record and instance
+func (l *LmsAdapterFolio) DeleteItem(itemId string) error {
+	if l.config.AcceptItemEnabled != nil && !*l.config.AcceptItemEnabled {
+		return nil
+	}
+	err := l.folioClient.DeleteItem(folioId("item", itemId))
+	if err == nil {
+		err = l.folioClient.DeleteHoldings(folioId("holdings", itemId))
+	}
+	if err == nil {
+		err = l.folioClient.DeleteInstance(folioId("instance", itemId))
+	}
+	return err
+}
+
+// RequestItem places a title-level request on the instance with the given UUID or HRID
+func (l *LmsAdapterFolio) RequestItem(
+	requestId string,
+	itemId string,
+	userId string,
+	pickupLocation string,
+	itemLocation string,
+) (string, string, string, error) {
+	instanceId := itemId
+	if _, err := uuid.Parse(itemId); err != nil {
+		instance, err := l.folioClient.LookupInstanceByHrid(itemId)
+		if err != nil {
+			return "", "", "", err
+		}
+		if instance == nil {
+			return "", "", "", fmt.Errorf("FOLIO instance not found: %s", itemId)
+		}
+		instanceId = instance.Id
+	}
+	user, err := l.requester(userId)
+	if err != nil {
+		return "", "", "", err
+	}
+	requestType := string(dirapi.Page)
+	if l.folio.RequestType != nil {
+		requestType = string(*l.folio.RequestType)
+	}
+	response, err := l.folioClient.CreateRequest(folioclient.Request{
+		Id:                    folioId("request", requestId),
+		RequestType:           requestType,
+		RequestLevel:          "Title",
+		RequestDate:           time.Now().UTC(),
+		InstanceId:            instanceId,
+		RequesterId:           user.Id,
+		FulfillmentPreference: "Hold Shelf",
+		PickupServicePointId:  derefString(l.folio.ServicePointId),
+	})
+	if err != nil {
+		return "", "", "", err
+	}
+	barcode := ""
+	callNumber := ""
+	if response.Item != nil {
+		barcode = response.Item.Barcode
+		callNumber = response.Item.CallNumber
+	}
+	title := ""
+	if response.Instance != nil {
+		title = response.Instance.Title
+	}
+	if barcode == "" {
+		return "", "", "", fmt.Errorf("missing item barcode in FOLIO request response")
+	}
+	return barcode, callNumber, title, nil
+}
+
+func (l *LmsAdapterFolio) CancelRequestItem(requestId string, userId string) error {
+	return l.folioClient.CancelRequest(folioId("request", requestId), derefString(l.folio.CancellationReasonId))
+}
+
+func (l *LmsAdapterFolio) CheckInItem(itemId string) error {
+	if l.config.CheckInItemEnabled != nil && !*l.config.CheckInItemEnabled {
+		return nil
+	}
+	return l.folioClient.CheckInByBarcode(itemId, derefString(l.folio.ServicePointId))
+}
+
+func (l *LmsAdapterFolio) CheckOutItem(
+	requestId string,
+	itemBarcode string,
+	userId string,
+	externalReferenceValue string,
+) (string, error) {
+	if l.config.CheckOutItemEnabled != nil && !*l.config.CheckOutItemEnabled {
+		return "", nil
+	}
+	loan, err := l.folioClient.CheckOutByBarcode(itemBarcode, userId, derefString(l.folio.ServicePointId))
+	if err != nil {
+		return "", err
+	}
+	if loan.Item == nil {
+		return "", nil
+	}
+	return loan.Item.Title, nil
+}
+
+func (l *LmsAdapterFolio) CreateUserFiscalTransaction(userId string, itemId string) error {
+	return nil
+}
+
+// LookupItems returns the circulation statuses of the items of the instance with the given UUID or HRID
+func (l *LmsAdapterFolio) LookupItems(bibId string) ([]string, error) {
+	instanceId := bibId
+	if _, parseErr := uuid.Parse(bibId); parseErr != nil {
+		instance, err := l.folioClient.LookupInstanceByHrid(bibId)
+		if err != nil {
+			return nil, err
+		}
+		if instance == nil {
+			return nil, fmt.Errorf("FOLIO instance not found: %s", bibId)
+		}
+		instanceId = instance.Id
+	}
+	holdings, err := l.folioClient.LookupHoldingsByInstanceId(instanceId)
+	if err != nil {
+		return nil, err
+	}
+	statuses := []string{}
+	for _, h := range holdings {
+		items, err := l.folioClient.LookupItemsByHoldingsId(h.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			status, ok := folioCirculationStatus[item.Status.Name]
+			if !ok {
+				status = ncip.CirculationStatusUndefined
+			}
+			statuses = append(statuses, string(status))
+		}
+	}
+	return statuses, nil
+}
+
+// RenewItem extends the loan of an item in the LMS. FOLIO applies its loan policy, so desiredDateDue is
+// only returned if FOLIO does not report a due date. ErrNotSupported is returned if renewals are disabled.
+func (l *LmsAdapterFolio) RenewItem(itemId string, userId string, desiredDateDue time.Time) (time.Time, error) {
+	if l.config.RenewItemEnabled != nil && !*l.config.RenewItemEnabled {
+		return time.Time{}, ErrNotSupported
+	}
+	loan, err := l.folioClient.RenewByBarcode(itemId, userId)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if loan.DueDate.IsZero() {
+		return desiredDateDue, nil
+	}
+	return loan.DueDate, nil
+}
+
+func (l *LmsAdapterFolio) InstitutionalPatron(requesterSymbol string) string {
+	patron := "INST-{requesterSymbol}"
+	if l.config.RequesterPatronPattern != nil {
+		patron = *l.config.RequesterPatronPattern
+	}
+	return strings.ReplaceAll(patron, "{requesterSymbol}", strings.ToUpper(requesterSymbol))
+}
+
+func (l *LmsAdapterFolio) SupplierPickupLocation() string {
+	if l.config.SupplierPickupLocation != nil {
+		return *l.config.SupplierPickupLocation
+	}
+	return "ILL Office"
+}
+
+func (l *LmsAdapterFolio) ItemLocation() string {
+	if l.config.ItemLocation != nil {
+		return *l.config.ItemLocation
+	}
+	return ""
+}
+
+func (l *LmsAdapterFolio) RequesterPickupLocation() string {
+	if l.config.RequesterPickupLocation != nil {
+		return *l.config.RequesterPickupLocation
+	}
+	return "Main Library"
+}
@@ -0,0 +1,222 @@
+package lms
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	dirapi "github.com/indexdata/crosslink/directory/api"
+	"github.com/indexdata/crosslink/illmock/foliomock"
+	"github.com/indexdata/crosslink/ncip"
+	"github.com/stretchr/testify/assert"
+)
+
+func createFolioAdapter(t *testing.T, config dirapi.LmsConfig) LmsAdapter {
+	server := httptest.NewServer(foliomock.CreateFolioMock())
+	t.Cleanup(server.Close)
+	config.Address = server.URL
+	if config.Folio == nil {
+		config.Folio = &dirapi.FolioLmsConfig{}
+	}
+	config.Folio.Tenant = "diku"
+	config.Folio.Username = strPtr("ill")
+	config.Folio.Password = strPtr("secret")
+	config.Folio.ServicePointId = strPtr("3a40852d-49fd-4df2-a1f9-6e2641a6e91f")
+	config.Folio.CancellationReasonId = strPtr("75187e8d-e25a-47a7-89ad-23ba612338de")
+	lmsAdapter, err := CreateLmsAdapterFolio(config)
+	assert.NoError(t, err)
+	return lmsAdapter
+}
+
+func TestCreateLmsAdapterFolio(t *testing.T) {
+	_, err := CreateLmsAdapterFolio(dirapi.LmsConfig{})
+	assert.Error(t, err)
+	assert.Equal(t, "missing FOLIO address in LMS configuration", err.Error())
+
+	_, err = CreateLmsAdapterFolio(dirapi.LmsConfig{Address: "http://folio.example.com", Folio: &dirapi.FolioLmsConfig{}})
+	assert.Error(t, err)
+	assert.Equal(t, "missing FOLIO tenant in LMS configuration", err.Error())
+
+	lmsAdapter, err := CreateLmsAdapterFolio(dirapi.LmsConfig{Address: "http://folio.example.com", Folio: &dirapi.FolioLmsConfig{Tenant: "diku"}})
+	assert.NoError(t, err)
+	assert.IsType(t, &LmsAdapterFolio{}, lmsAdapter)
+}
+
+func TestFolioLookupUser(t *testing.T) {
+	lmsAdapter := createFolioAdapter(t, dirapi.LmsConfig{})
+	userId, err := lmsAdapter.LookupUser("u1")
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", userId)
+
+	_, err = lmsAdapter.LookupUser("foo")
+	assert.Error(t, err)
+	assert.Equal(t, "FOLIO user not found: foo", err.Error())
+
+	_, err = lmsAdapter.LookupUser("")
+	assert.Error(t, err)
+	assert.Equal(t, "empty patron identifier", err.Error())
+
+	disabled := false
+	lmsAdapter = createFolioAdapter(t, dirapi.LmsConfig{LookupUserEnabled: &disabled})
+	userId, err = lmsAdapter.LookupUser("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", userId)
+}
+
+func TestFolioAcceptItem(t *testing.T) {
+	lmsAdapter := createFolioAdapter(t, dirapi.LmsConfig{})
+	assert.NoError(t, lmsAdapter.AcceptItem("b1", "req1", "u1", "author", "title", "isbn", "call", "pickup", ""))
+	assert.NoError(t, lmsAdapter.AcceptItem("b1", "req1", "", "author", "", "isbn", "", "", ""))
+
+	err := lmsAdapter.AcceptItem("b1", "req1", "foo", "author", "title", "isbn", "call", "pickup", "")
+	assert.Error(t, err)
+	assert.Equal(t, "FOLIO user not found: foo", err.Error())
+
+	err = lmsAdapter.AcceptItem("foo", "req1", "u1", "author", "title", "isbn", "call", "pickup", "")
+	assert.Error(t, err)
+	assert.Equal(t, "FOLIO create item failed: HTTP 422: Barcode must be unique", err.Error())
+
+	disabled := false
+	lmsAdapter = createFolioAdapter(t, dirapi.LmsConfig{AcceptItemEnabled: &disabled})
+	assert.NoError(t, lmsAdapter.AcceptItem("foo", "req1", "foo", "author", "title", "isbn", "call", "pickup", ""))
+	assert.NoError(t, lmsAdapter.DeleteItem("foo"))
+}
+
+func TestFolioDeleteItem(t *testing.T) {
+	lmsAdapter := createFolioAdapter(t, dirapi.LmsConfig{})
+	assert.NoError(t, lmsAdapter.DeleteItem("b1"))
+	assert.Equal(t, folioId("item", "b1"), folioId("item", "b1"))
+	assert.NotEqual(t, folioId("item", "b1"), folioId("holdings", "b1"))
+}
+
+func TestFolioRequestItem(t *testing.T) {
+	lmsAdapter := createFolioAdapter(t, dirapi.LmsConfig{})
+	barcode, callNumber, title, err := lmsAdapter.RequestItem("req1", "in1", "u1", "Main Library", "")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, barcode)
+	assert.NotEmpty(t, callNumber)
+	assert.Equal(t, "Mock title "+barcode, title)
+
+	instanceId := "1e5b7d8c-2d35-4d3a-9b3e-0f1d9a6b7c21"
+	barcode, _, _, err = lmsAdapter.RequestItem("req1", instanceId, "u1", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, instanceId, barcode)
+
+	_, _, _, err = lmsAdapter.RequestItem("req1", "foo", "u1", "", "")
+	assert.Error(t, err)
+	assert.Equal(t, "FOLIO instance not found: foo", err.Error())
+
+	_, _, _, err = lmsAdapter.RequestItem("req1", "in1", "foo", "", "")
+	assert.Error(t, err)
+	assert.Equal(t, "FOLIO user not found: foo", err.Error())
+
+	requestType := dirapi.Hold
+	lmsAdapter = createFolioAdapter(t, dirapi.LmsConfig{Folio: &dirapi.FolioLmsConfig{RequestType: &requestType}})
+	_, _, _, err = lmsAdapter.RequestItem("req1", "in1", "u1", "", "")
+	assert.NoError(t, err)
+}
+
+func TestFolioCancelRequestItem(t *testing.T) {
+	lmsAdapter := createFolioAdapter(t, dirapi.LmsConfig{})
+	assert.NoError(t, lmsAdapter.CancelRequestItem("req1", "u1"))
+}
+
+func TestFolioCheckInItem(t *testing.T) {
+	lmsAdapter := createFolioAdapter(t, dirapi.LmsConfig{})
+	assert.NoError(t, lmsAdapter.CheckInItem("b1"))
+	err := lmsAdapter.CheckInItem("foo")
+	assert.Error(t, err)
+	assert.Equal(t, "FOLIO check in failed: HTTP 422: No record found for itemBarcode foo", err.Error())
+
+	disabled := false
+	lmsAdapter = createFolioAdapter(t, dirapi.LmsConfig{CheckInItemEnabled: &disabled})
+	assert.NoError(t, lmsAdapter.CheckInItem("foo"))
+}
+
+func TestFolioCheckOutItem(t *testing.T) {
+	lmsAdapter := createFolioAdapter(t, dirapi.LmsConfig{})
+	title, err := lmsAdapter.CheckOutItem("req1", "b1", "u1", "ext1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Mock title b1", title)
+	_, err = lmsAdapter.CheckOutItem("req1", "b1", "foo", "")
+	assert.Error(t, err)
+	assert.Equal(t, "FOLIO check out failed: HTTP 422: No record found for userBarcode foo", err.Error())
+
+	disabled := false
+	lmsAdapter = createFolioAdapter(t, dirapi.LmsConfig{CheckOutItemEnabled: &disabled})
+	_, err = lmsAdapter.CheckOutItem("req1", "b1", "foo", "")
+	assert.NoError(t, err)
+}
+
+func TestFolioLookupItems(t *testing.T) {
+	lmsAdapter := createFolioAdapter(t, dirapi.LmsConfig{})
+	statuses, err := lmsAdapter.LookupItems("in1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{string(ncip.CirculationStatusAvailableOnShelf)}, statuses)
+
+	statuses, err = lmsAdapter.LookupItems("1e5b7d8c-2d35-4d3a-9b3e-0f1d9a6b7c21")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{string(ncip.CirculationStatusAvailableOnShelf)}, statuses)
+
+	_, err = lmsAdapter.LookupItems("foo")
+	assert.Error(t, err)
+	assert.Equal(t, "FOLIO instance not found: foo", err.Error())
+}
+
+func TestFolioRenewItem(t *testing.T) {
+	lmsAdapter := createFolioAdapter(t, dirapi.LmsConfig{})
+	desired := time.Date(2030, 3, 4, 5, 6, 7, 0, time.UTC)
+	dateDue, err := lmsAdapter.RenewItem("b1", "u1", desired)
+	assert.NoError(t, err)
+	assert.True(t, dateDue.After(time.Now()))
+
+	_, err = lmsAdapter.RenewItem("foo", "u1", desired)
+	assert.Error(t, err)
+	assert.Equal(t, "FOLIO renew failed: HTTP 422: No record found for itemBarcode foo", err.Error())
+
+	disabled := false
+	lmsAdapter = createFolioAdapter(t, dirapi.LmsConfig{RenewItemEnabled: &disabled})
+	_, err = lmsAdapter.RenewItem("foo", "u1", desired)
+	assert.Equal(t, ErrNotSupported, err)
+}
+
+func TestFolioNoOps(t *testing.T) {
+	lmsAdapter := createFolioAdapter(t, dirapi.LmsConfig{})
+	assert.NoError(t, lmsAdapter.CreateUserFiscalTransaction("foo", "item1"))
+}
+
+func TestFolioSetLogFunc(t *testing.T) {
+	lmsAdapter := createFolioAdapter(t, dirapi.LmsConfig{})
+	var outgoing []map[string]any
+	lmsAdapter.SetLogFunc(func(out map[string]any, in map[string]any, err error) {
+		outgoing = append(outgoing, out)
+	})
+	assert.NoError(t, lmsAdapter.CheckInItem("b1"))
+	assert.Len(t, outgoing, 2)
+	assert.Equal(t, "/authn/login-with-expiry", outgoing[0]["path"])
+	assert.Equal(t, "/circulation/check-in-by-barcode", outgoing[1]["path"])
+}
+
+func TestFolioLocations(t *testing.T) {
+	folio := &dirapi.FolioLmsConfig{Tenant: "diku"}
+	lmsAdapter, err := CreateLmsAdapterFolio(dirapi.LmsConfig{Address: "http://folio.example.com", Folio: folio})
+	assert.NoError(t, err)
+	assert.Equal(t, "INST-REQ1", lmsAdapter.InstitutionalPatron("req1"))
+	assert.Equal(t, "ILL Office", lmsAdapter.SupplierPickupLocation())
+	assert.Equal(t, "Main Library", lmsAdapter.RequesterPickupLocation())
+	assert.Equal(t, "", lmsAdapter.ItemLocation())
+
+	lmsAdapter, err = CreateLmsAdapterFolio(dirapi.LmsConfig{
+		Address:                 "http://folio.example.com",
+		Folio:                   folio,
+		RequesterPatronPattern:  strPtr("ILL-{requesterSymbol}"),
+		SupplierPickupLocation:  strPtr("Desk"),
+		RequesterPickupLocation: strPtr("Branch"),
+		ItemLocation:            strPtr("Stacks"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ILL-REQ1", lmsAdapter.InstitutionalPatron("req1"))
+	assert.Equal(t, "Desk", lmsAdapter.SupplierPickupLocation())
+	assert.Equal(t, "Branch", lmsAdapter.RequesterPickupLocation())
+	assert.Equal(t, "Stacks", lmsAdapter.ItemLocation())
+}
@@ -1,6 +1,10 @@
 package lms
 
-import "github.com/indexdata/crosslink/broker/ncipclient"
+import (
+	"time"
+
+	"github.com/indexdata/crosslink/broker/ncipclient"
+)
 
 type LmsAdapterManual struct {
 }
@@ -61,6 +65,14 @@ func (l *LmsAdapterManual) CreateUserFiscalTransaction(userId string, itemId str
 	return nil
 }
 
+func (l *LmsAdapterManual) LookupItems(bibId string) ([]string, error) {
+	return nil, ErrNotSupported
+}
+
+func (l *LmsAdapterManual) RenewItem(itemId string, userId string, desiredDateDue time.Time) (time.Time, error) {
+	return time.Time{}, ErrNotSupported
+}
+
 func CreateLmsAdapterMockOK() LmsAdapter {
 	return &LmsAdapterManual{}
 }
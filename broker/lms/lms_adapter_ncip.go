@@ -1,14 +1,17 @@
 package lms
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/indexdata/crosslink/broker/ncipclient"
 	dirapi "github.com/indexdata/crosslink/directory/api"
 	"github.com/indexdata/crosslink/ncip"
+	"github.com/indexdata/go-utils/utils"
 )
 
 type NcipUserElement string
@@ -22,6 +25,7 @@ type NcipItemElement string
 
 const (
 	NCIPBibliographicDescription NcipItemElement = "Bibliographic Description"
+	NCIPCirculationStatus        NcipItemElement = "Circulation Status"
 )
 
 // NCIP LMS Adapter, based on:
@@ -29,12 +33,13 @@ const (
 // https://github.com/openlibraryenvironment/lib-ncip-client/tree/master/lib-ncip-client/src/main/java/org/olf/rs/circ/client
 
 type LmsAdapterNcip struct {
+	ctx        context.Context
 	ncipClient ncipclient.NcipClient
 	config     dirapi.LmsConfig
 }
 
-func CreateLmsAdapterNcip(lmsConfig dirapi.LmsConfig) (LmsAdapter, error) {
-	l := &LmsAdapterNcip{config: lmsConfig}
+func CreateLmsAdapterNcip(ctx context.Context, lmsConfig dirapi.LmsConfig) (LmsAdapter, error) {
+	l := &LmsAdapterNcip{ctx: ctx, config: lmsConfig}
 	toAgency := "default-to-agency"
 	if l.config.ToAgency != nil {
 		toAgency = *l.config.ToAgency
@@ -68,7 +73,7 @@ func (l *LmsAdapterNcip) LookupUser(patron string) (string, error) {
 	arg := ncip.LookupUser{
 		UserId: &ncip.UserId{UserIdentifierValue: patron},
 	}
-	_, err := l.ncipClient.LookupUser(arg)
+	_, err := l.ncipClient.LookupUser(l.ctx, arg)
 	if err == nil {
 		return patron, nil
 	}
@@ -85,7 +90,7 @@ func (l *LmsAdapterNcip) LookupUser(patron string) (string, error) {
 		AuthenticationInput: authenticationInput,
 		UserElementType:     userElements,
 	}
-	response, err := l.ncipClient.LookupUser(arg)
+	response, err := l.ncipClient.LookupUser(l.ctx, arg)
 	if err != nil {
 		return "", err
 	}
@@ -147,7 +152,7 @@ func (l *LmsAdapterNcip) AcceptItem(
 		ItemOptionalFields:  itemOptionalFields,
 		PickupLocation:      pickupLocationField,
 	}
-	_, err := l.ncipClient.AcceptItem(arg)
+	_, err := l.ncipClient.AcceptItem(l.ctx, arg)
 	return err
 }
 
@@ -155,7 +160,7 @@ func (l *LmsAdapterNcip) DeleteItem(itemId string) error {
 	arg := ncip.DeleteItem{
 		ItemId: ncip.ItemId{ItemIdentifierValue: itemId},
 	}
-	_, err := l.ncipClient.DeleteItem(arg)
+	_, err := l.ncipClient.DeleteItem(l.ctx, arg)
 	return err
 }
 
@@ -174,15 +179,7 @@ func (l *LmsAdapterNcip) RequestItem(
 	if userId != "" {
 		userIdField = &ncip.UserId{UserIdentifierValue: userId}
 	}
-	code := "SYSNUMBER"
-	if l.config.RequestItemBibIdCode != nil {
-		code = *l.config.RequestItemBibIdCode
-	}
-	bibIdField := ncip.BibliographicId{
-		BibliographicRecordId: &ncip.BibliographicRecordId{
-			BibliographicRecordIdentifier:     itemId,
-			BibliographicRecordIdentifierCode: &ncip.SchemeValuePair{Text: code},
-		}}
+	bibIdField := l.bibliographicId(itemId)
 	scopeType := "Item"
 	if l.config.RequestItemRequestScopeType != nil {
 		scopeType = *l.config.RequestItemRequestScopeType
@@ -224,7 +221,7 @@ func (l *LmsAdapterNcip) RequestItem(
 		ItemOptionalFields: itemOptionalFields,
 		ItemElementType:    itemElements,
 	}
-	response, err := l.ncipClient.RequestItem(arg)
+	response, err := l.ncipClient.RequestItem(l.ctx, arg)
 	if err != nil {
 		return "", "", "", err
 	}
@@ -258,7 +255,7 @@ func (l *LmsAdapterNcip) CancelRequestItem(requestId string, userId string) erro
 		UserId:    &ncip.UserId{UserIdentifierValue: userId},
 		RequestId: &ncip.RequestId{RequestIdentifierValue: requestId},
 	}
-	_, err := l.ncipClient.CancelRequestItem(arg)
+	_, err := l.ncipClient.CancelRequestItem(l.ctx, arg)
 	return err
 }
 
@@ -273,7 +270,7 @@ func (l *LmsAdapterNcip) CheckInItem(itemId string) error {
 		ItemId:          ncip.ItemId{ItemIdentifierValue: itemId},
 		ItemElementType: itemElements,
 	}
-	_, err := l.ncipClient.CheckInItem(arg)
+	_, err := l.ncipClient.CheckInItem(l.ctx, arg)
 	// mod-rs does not seem to use the Bibliographic Description in response
 	return err
 }
@@ -306,7 +303,7 @@ func (l *LmsAdapterNcip) CheckOutItem(
 		ItemElementType: itemElements,
 		Ext:             ext,
 	}
-	response, err := l.ncipClient.CheckOutItem(arg)
+	response, err := l.ncipClient.CheckOutItem(l.ctx, arg)
 	if err != nil {
 		return "", err
 	}
@@ -324,10 +321,82 @@ func (l *LmsAdapterNcip) CreateUserFiscalTransaction(userId string, itemId strin
 	arg := ncip.CreateUserFiscalTransaction{
 		UserId: &ncip.UserId{UserIdentifierValue: userId},
 	}
-	_, err := l.ncipClient.CreateUserFiscalTransaction(arg)
+	_, err := l.ncipClient.CreateUserFiscalTransaction(l.ctx, arg)
 	return err
 }
 
+// bibliographicId identifies a bibliographic record by the local identifier of the supplier
+func (l *LmsAdapterNcip) bibliographicId(bibId string) ncip.BibliographicId {
+	code := "SYSNUMBER"
+	if l.config.RequestItemBibIdCode != nil {
+		code = *l.config.RequestItemBibIdCode
+	}
+	return ncip.BibliographicId{
+		BibliographicRecordId: &ncip.BibliographicRecordId{
+			BibliographicRecordIdentifier:     bibId,
+			BibliographicRecordIdentifierCode: &ncip.SchemeValuePair{Text: code},
+		}}
+}
+
+// LookupItems looks up the items of a bibliographic record with LookupItemSet
+func (l *LmsAdapterNcip) LookupItems(bibId string) ([]string, error) {
+	itemElements := []ncip.SchemeValuePair{
+		{Text: string(NCIPCirculationStatus)},
+	}
+	arg := ncip.LookupItemSet{
+		BibliographicId: []ncip.BibliographicId{l.bibliographicId(bibId)},
+		ItemElementType: itemElements,
+	}
+	response, err := l.ncipClient.LookupItemSet(l.ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	if response == nil {
+		return nil, fmt.Errorf("empty response from LookupItemSet")
+	}
+	statuses := []string{}
+	for _, bib := range response.BibInformation {
+		if len(bib.Problem) > 0 {
+			return nil, &ncipclient.NcipError{Message: "NCIP lookup item set failed", Problem: bib.Problem[0]}
+		}
+		for _, holdings := range bib.HoldingsSet {
+			for _, item := range holdings.ItemInformation {
+				status := string(ncip.CirculationStatusUndefined)
+				if item.ItemOptionalFields != nil && item.ItemOptionalFields.CirculationStatus != nil {
+					status = item.ItemOptionalFields.CirculationStatus.Text
+				}
+				statuses = append(statuses, status)
+			}
+		}
+	}
+	return statuses, nil
+}
+
+// RenewItem extends the loan of an item in the LMS. The due date granted by the LMS is returned;
+// if the LMS does not report one, desiredDateDue is returned. ErrNotSupported is returned if renewals are disabled.
+func (l *LmsAdapterNcip) RenewItem(itemId string, userId string, desiredDateDue time.Time) (time.Time, error) {
+	if l.config.RenewItemEnabled != nil && !*l.config.RenewItemEnabled {
+		return time.Time{}, ErrNotSupported
+	}
+	var desiredDateDueField *utils.XSDDateTime
+	if !desiredDateDue.IsZero() {
+		desiredDateDueField = &utils.XSDDateTime{Time: desiredDateDue}
+	}
+	arg := ncip.RenewItem{
+		UserId:         &ncip.UserId{UserIdentifierValue: userId},
+		ItemId:         ncip.ItemId{ItemIdentifierValue: itemId},
+		DesiredDateDue: desiredDateDueField,
+	}
+	response, err := l.ncipClient.RenewItem(l.ctx, arg)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if response == nil || response.DateDue == nil {
+		return desiredDateDue, nil
+	}
+	return response.DateDue.Time, nil
+}
+
 func (l *LmsAdapterNcip) InstitutionalPatron(requesterSymbol string) string {
 	patron := "INST-{requesterSymbol}"
 	if l.config.RequesterPatronPattern != nil {
@@ -0,0 +1,183 @@
+package lms
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/indexdata/crosslink/broker/ncipclient"
+	"github.com/indexdata/crosslink/broker/sip2client"
+	dirapi "github.com/indexdata/crosslink/directory/api"
+)
+
+// SIP2 LMS Adapter. SIP2 has no messages for temporary items, so AcceptItem, DeleteItem
+// and CreateUserFiscalTransaction do nothing. Holds are keyed by item, so CancelRequestItem does nothing either.
+type LmsAdapterSip2 struct {
+	sip2Client sip2client.Sip2Client
+	config     dirapi.Sip2Config
+}
+
+func CreateLmsAdapterSip2(sip2Config dirapi.Sip2Config) (LmsAdapter, error) {
+	l := &LmsAdapterSip2{config: sip2Config}
+	if l.config.Address == "" {
+		return nil, fmt.Errorf("missing SIP2 address in SIP2 configuration")
+	}
+	if l.config.InstitutionId == "" {
+		return nil, fmt.Errorf("missing Institution Id in SIP2 configuration")
+	}
+	l.sip2Client = sip2client.NewSip2Client(sip2client.Config{
+		Address:          l.config.Address,
+		InstitutionId:    l.config.InstitutionId,
+		LoginUserId:      derefString(l.config.LoginUserId),
+		LoginPassword:    derefString(l.config.LoginPassword),
+		LocationCode:     derefString(l.config.LocationCode),
+		TerminalPassword: derefString(l.config.TerminalPassword),
+		ErrorDetection:   l.config.ErrorDetection != nil && *l.config.ErrorDetection,
+	})
+	return l, nil
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func (l *LmsAdapterSip2) SetLogFunc(logFunc ncipclient.NcipLogFunc) {
+	l.sip2Client.SetLogFunc(sip2client.Sip2LogFunc(logFunc))
+}
+
+func (l *LmsAdapterSip2) LookupUser(patron string) (string, error) {
+	if l.config.LookupUserEnabled != nil && !*l.config.LookupUserEnabled {
+		return patron, nil
+	}
+	if patron == "" {
+		return "", fmt.Errorf("empty patron identifier")
+	}
+	response, err := l.sip2Client.PatronInformation(patron)
+	if err != nil {
+		return "", err
+	}
+	if response.Field("AA") != "" {
+		return response.Field("AA"), nil
+	}
+	return patron, nil
+}
+
+func (l *LmsAdapterSip2) AcceptItem(
+	itemId string,
+	requestId string,
+	userId string,
+	author string,
+	title string,
+	isbn string,
+	callNumber string,
+	pickupLocation string,
+	requestedAction string,
+) error {
+	return nil
+}
+
+func (l *LmsAdapterSip2) DeleteItem(itemId string) error {
+	return nil
+}
+
+func (l *LmsAdapterSip2) RequestItem(
+	requestId string,
+	itemId string,
+	userId string,
+	pickupLocation string,
+	itemLocation string,
+) (string, string, string, error) {
+	response, err := l.sip2Client.Hold(sip2client.HoldModeAdd, userId, itemId, pickupLocation)
+	if err != nil {
+		return "", "", "", err
+	}
+	barcode := response.Field("AB")
+	if barcode == "" {
+		barcode = itemId
+	}
+	return barcode, "", response.Field("AJ"), nil
+}
+
+func (l *LmsAdapterSip2) CancelRequestItem(requestId string, userId string) error {
+	return nil
+}
+
+func (l *LmsAdapterSip2) CheckInItem(itemId string) error {
+	if l.config.CheckInItemEnabled != nil && !*l.config.CheckInItemEnabled {
+		return nil
+	}
+	_, err := l.sip2Client.Checkin(itemId)
+	return err
+}
+
+func (l *LmsAdapterSip2) CheckOutItem(
+	requestId string,
+	itemBarcode string,
+	userId string,
+	externalReferenceValue string,
+) (string, error) {
+	if l.config.CheckOutItemEnabled != nil && !*l.config.CheckOutItemEnabled {
+		return "", nil
+	}
+	response, err := l.sip2Client.Checkout(userId, itemBarcode, time.Time{})
+	if err != nil {
+		return "", err
+	}
+	return response.Field("AJ"), nil
+}
+
+func (l *LmsAdapterSip2) CreateUserFiscalTransaction(userId string, itemId string) error {
+	return nil
+}
+
+// LookupItems is not supported as SIP2 has no lookup of the items of a bibliographic record
+func (l *LmsAdapterSip2) LookupItems(bibId string) ([]string, error) {
+	return nil, ErrNotSupported
+}
+
+// RenewItem extends the loan of an item in the LMS. The due date granted by the LMS is returned;
+// if the LMS does not report a valid one, desiredDateDue is returned. ErrNotSupported is returned if renewals are disabled.
+func (l *LmsAdapterSip2) RenewItem(itemId string, userId string, desiredDateDue time.Time) (time.Time, error) {
+	if l.config.RenewItemEnabled != nil && !*l.config.RenewItemEnabled {
+		return time.Time{}, ErrNotSupported
+	}
+	response, err := l.sip2Client.Renew(userId, itemId, desiredDateDue)
+	if err != nil {
+		return time.Time{}, err
+	}
+	dateDue, err := sip2client.ParseDate(response.Field("AH"))
+	if err != nil {
+		return desiredDateDue, nil
+	}
+	return dateDue, nil
+}
+
+func (l *LmsAdapterSip2) InstitutionalPatron(requesterSymbol string) string {
+	patron := "INST-{requesterSymbol}"
+	if l.config.RequesterPatronPattern != nil {
+		patron = *l.config.RequesterPatronPattern
+	}
+	return strings.ReplaceAll(patron, "{requesterSymbol}", strings.ToUpper(requesterSymbol))
+}
+
+func (l *LmsAdapterSip2) SupplierPickupLocation() string {
+	if l.config.SupplierPickupLocation != nil {
+		return *l.config.SupplierPickupLocation
+	}
+	return "ILL Office"
+}
+
+// ItemLocation is empty as the SIP2 Hold message has no item location field
+func (l *LmsAdapterSip2) ItemLocation() string {
+	return ""
+}
+
+func (l *LmsAdapterSip2) RequesterPickupLocation() string {
+	if l.config.RequesterPickupLocation != nil {
+		return *l.config.RequesterPickupLocation
+	}
+	return "Main Library"
+}
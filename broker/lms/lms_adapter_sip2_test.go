@@ -0,0 +1,184 @@
+package lms
+
+import (
+	"testing"
+	"time"
+
+	dirapi "github.com/indexdata/crosslink/directory/api"
+	"github.com/indexdata/crosslink/illmock/sip2mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func createSip2Adapter(t *testing.T, config dirapi.Sip2Config) LmsAdapter {
+	mock := sip2mock.CreateSip2Mock()
+	assert.NoError(t, mock.Start("localhost:0"))
+	t.Cleanup(mock.Shutdown)
+	config.Address = mock.Addr()
+	config.InstitutionId = "INST"
+	config.LoginUserId = strPtr("user")
+	config.LoginPassword = strPtr("pass")
+	config.LocationCode = strPtr("MAIN")
+	lmsAdapter, err := CreateLmsAdapterSip2(config)
+	assert.NoError(t, err)
+	return lmsAdapter
+}
+
+func TestCreateLmsAdapterSip2(t *testing.T) {
+	_, err := CreateLmsAdapterSip2(dirapi.Sip2Config{})
+	assert.Error(t, err)
+	assert.Equal(t, "missing SIP2 address in SIP2 configuration", err.Error())
+
+	_, err = CreateLmsAdapterSip2(dirapi.Sip2Config{Address: "localhost:6001"})
+	assert.Error(t, err)
+	assert.Equal(t, "missing Institution Id in SIP2 configuration", err.Error())
+
+	lmsAdapter, err := CreateLmsAdapterSip2(dirapi.Sip2Config{Address: "localhost:6001", InstitutionId: "INST"})
+	assert.NoError(t, err)
+	assert.IsType(t, &LmsAdapterSip2{}, lmsAdapter)
+}
+
+func TestSip2LookupUser(t *testing.T) {
+	lmsAdapter := createSip2Adapter(t, dirapi.Sip2Config{})
+	userId, err := lmsAdapter.LookupUser("patron1")
+	assert.NoError(t, err)
+	assert.Equal(t, "patron1", userId)
+
+	_, err = lmsAdapter.LookupUser("foo")
+	assert.Error(t, err)
+	assert.Equal(t, "SIP2 patron information: invalid patron: Unknown patron foo", err.Error())
+
+	_, err = lmsAdapter.LookupUser("")
+	assert.Error(t, err)
+	assert.Equal(t, "empty patron identifier", err.Error())
+
+	disabled := false
+	lmsAdapter = createSip2Adapter(t, dirapi.Sip2Config{LookupUserEnabled: &disabled})
+	userId, err = lmsAdapter.LookupUser("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", userId)
+}
+
+func TestSip2NoOps(t *testing.T) {
+	lmsAdapter := createSip2Adapter(t, dirapi.Sip2Config{})
+	assert.NoError(t, lmsAdapter.AcceptItem("foo", "req1", "patron1", "author", "title", "isbn", "call", "pickup", ""))
+	assert.NoError(t, lmsAdapter.DeleteItem("foo"))
+	assert.NoError(t, lmsAdapter.CancelRequestItem("req1", "foo"))
+	assert.NoError(t, lmsAdapter.CreateUserFiscalTransaction("foo", "item1"))
+}
+
+func TestSip2RequestItem(t *testing.T) {
+	lmsAdapter := createSip2Adapter(t, dirapi.Sip2Config{})
+	barcode, callNumber, title, err := lmsAdapter.RequestItem("req1", "item1", "patron1", "Main Library", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "item1", barcode)
+	assert.Equal(t, "", callNumber)
+	assert.Equal(t, "", title)
+
+	_, _, _, err = lmsAdapter.RequestItem("req1", "foo", "patron1", "", "")
+	assert.Error(t, err)
+	assert.Equal(t, "SIP2 hold failed: Unknown item foo", err.Error())
+
+	var outgoing string
+	lmsAdapter.SetLogFunc(func(out map[string]any, in map[string]any, err error) {
+		outgoing = out["message"].(string)
+	})
+	_, _, _, err = lmsAdapter.RequestItem("req1", "item1", "patron1", "Main Library", "Stacks")
+	assert.NoError(t, err)
+	assert.Contains(t, outgoing, "BSMain Library|AOINST|")
+	assert.NotContains(t, outgoing, "APStacks")
+}
+
+func TestSip2CheckInItem(t *testing.T) {
+	lmsAdapter := createSip2Adapter(t, dirapi.Sip2Config{})
+	assert.NoError(t, lmsAdapter.CheckInItem("item1"))
+	err := lmsAdapter.CheckInItem("foo")
+	assert.Error(t, err)
+	assert.Equal(t, "SIP2 checkin failed: Unknown item foo", err.Error())
+
+	disabled := false
+	lmsAdapter = createSip2Adapter(t, dirapi.Sip2Config{CheckInItemEnabled: &disabled})
+	assert.NoError(t, lmsAdapter.CheckInItem("foo"))
+}
+
+func TestSip2CheckOutItem(t *testing.T) {
+	lmsAdapter := createSip2Adapter(t, dirapi.Sip2Config{})
+	_, err := lmsAdapter.CheckOutItem("req1", "item1", "patron1", "ext1")
+	assert.NoError(t, err)
+	_, err = lmsAdapter.CheckOutItem("req1", "item1", "foo", "")
+	assert.Error(t, err)
+	assert.Equal(t, "SIP2 checkout failed: Unknown patron foo", err.Error())
+
+	disabled := false
+	lmsAdapter = createSip2Adapter(t, dirapi.Sip2Config{CheckOutItemEnabled: &disabled})
+	_, err = lmsAdapter.CheckOutItem("req1", "item1", "foo", "")
+	assert.NoError(t, err)
+}
+
+func TestSip2LookupItems(t *testing.T) {
+	lmsAdapter := createSip2Adapter(t, dirapi.Sip2Config{})
+	_, err := lmsAdapter.LookupItems("bib1")
+	assert.Equal(t, ErrNotSupported, err)
+}
+
+func TestSip2RenewItem(t *testing.T) {
+	lmsAdapter := createSip2Adapter(t, dirapi.Sip2Config{})
+	desired := time.Date(2030, 3, 4, 5, 6, 7, 0, time.UTC)
+	dateDue, err := lmsAdapter.RenewItem("item1", "patron1", desired)
+	assert.NoError(t, err)
+	assert.True(t, desired.Equal(dateDue))
+
+	dateDue, err = lmsAdapter.RenewItem("item1", "patron1", time.Time{})
+	assert.NoError(t, err)
+	assert.True(t, dateDue.After(time.Now()))
+
+	_, err = lmsAdapter.RenewItem("foo", "patron1", desired)
+	assert.Error(t, err)
+	assert.Equal(t, "SIP2 renew failed: Unknown item foo", err.Error())
+
+	disabled := false
+	lmsAdapter = createSip2Adapter(t, dirapi.Sip2Config{RenewItemEnabled: &disabled})
+	_, err = lmsAdapter.RenewItem("foo", "patron1", desired)
+	assert.Equal(t, ErrNotSupported, err)
+}
+
+func TestSip2SetLogFunc(t *testing.T) {
+	lmsAdapter := createSip2Adapter(t, dirapi.Sip2Config{})
+	var outgoing []map[string]any
+	lmsAdapter.SetLogFunc(func(out map[string]any, in map[string]any, err error) {
+		outgoing = append(outgoing, out)
+	})
+	assert.NoError(t, lmsAdapter.CheckInItem("item1"))
+	assert.Len(t, outgoing, 2)
+	assert.Equal(t, "9300CNuser|CO***|CPMAIN|", outgoing[0]["message"])
+}
+
+func TestSip2Locations(t *testing.T) {
+	lmsAdapter, err := CreateLmsAdapterSip2(dirapi.Sip2Config{Address: "localhost:6001", InstitutionId: "INST"})
+	assert.NoError(t, err)
+	assert.Equal(t, "INST-REQ1", lmsAdapter.InstitutionalPatron("req1"))
+	assert.Equal(t, "ILL Office", lmsAdapter.SupplierPickupLocation())
+	assert.Equal(t, "Main Library", lmsAdapter.RequesterPickupLocation())
+	assert.Equal(t, "", lmsAdapter.ItemLocation())
+
+	lmsAdapter, err = CreateLmsAdapterSip2(dirapi.Sip2Config{
+		Address:                 "localhost:6001",
+		InstitutionId:           "INST",
+		RequesterPatronPattern:  strPtr("ILL-{requesterSymbol}"),
+		SupplierPickupLocation:  strPtr("Desk"),
+		RequesterPickupLocation: strPtr("Branch"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ILL-REQ1", lmsAdapter.InstitutionalPatron("req1"))
+	assert.Equal(t, "Desk", lmsAdapter.SupplierPickupLocation())
+	assert.Equal(t, "Branch", lmsAdapter.RequesterPickupLocation())
+	assert.Equal(t, "", lmsAdapter.ItemLocation())
+}
+
+func TestIsLookupItemEnabled(t *testing.T) {
+	enabled := true
+	disabled := false
+	assert.False(t, IsLookupItemEnabled(dirapi.Entry{}))
+	assert.False(t, IsLookupItemEnabled(dirapi.Entry{Sip2Config: &dirapi.Sip2Config{}}))
+	assert.True(t, IsLookupItemEnabled(dirapi.Entry{LmsConfig: &dirapi.LmsConfig{LookupItemEnabled: &enabled}}))
+	assert.False(t, IsLookupItemEnabled(dirapi.Entry{LmsConfig: &dirapi.LmsConfig{LookupItemEnabled: &disabled}}))
+}
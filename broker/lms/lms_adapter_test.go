@@ -1,14 +1,17 @@
 package lms
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/indexdata/crosslink/broker/ncipclient"
 	dirapi "github.com/indexdata/crosslink/directory/api"
 	"github.com/indexdata/crosslink/ncip"
+	"github.com/indexdata/go-utils/utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -17,21 +20,21 @@ func TestCreateLmsAdapterNcip(t *testing.T) {
 		Address:    "http://ncip.example.com",
 		FromAgency: "MyAgency",
 	}
-	ad, err := CreateLmsAdapterNcip(config)
+	ad, err := CreateLmsAdapterNcip(context.Background(), config)
 	assert.NoError(t, err)
 	assert.NotNil(t, ad)
 
 	config = dirapi.LmsConfig{
 		FromAgency: "MyAgency",
 	}
-	_, err = CreateLmsAdapterNcip(config)
+	_, err = CreateLmsAdapterNcip(context.Background(), config)
 	assert.Error(t, err)
 	assert.Equal(t, "missing NCIP address in LMS configuration", err.Error())
 
 	config = dirapi.LmsConfig{
 		Address: "http://ncip.example.com",
 	}
-	_, err = CreateLmsAdapterNcip(config)
+	_, err = CreateLmsAdapterNcip(context.Background(), config)
 	assert.Error(t, err)
 	assert.Equal(t, "missing From Agency in LMS configuration", err.Error())
 }
@@ -339,6 +342,86 @@ func TestCreateUserFiscalTransaction(t *testing.T) {
 	assert.Equal(t, "testuser", req.UserId.UserIdentifierValue)
 }
 
+func TestLookupItems(t *testing.T) {
+	var mock ncipclient.NcipClient = new(ncipClientMock)
+	ad := &LmsAdapterNcip{
+		ncipClient: mock,
+	}
+	statuses, err := ad.LookupItems("bib1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		string(ncip.CirculationStatusOnLoan),
+		string(ncip.CirculationStatusUndefined),
+		string(ncip.CirculationStatusAvailableOnShelf),
+	}, statuses)
+	req := mock.(*ncipClientMock).lastRequest.(ncip.LookupItemSet)
+	assert.Equal(t, "bib1", req.BibliographicId[0].BibliographicRecordId.BibliographicRecordIdentifier)
+	assert.Equal(t, "SYSNUMBER", req.BibliographicId[0].BibliographicRecordId.BibliographicRecordIdentifierCode.Text)
+	assert.Empty(t, req.ItemId)
+	assert.Equal(t, string(NCIPCirculationStatus), req.ItemElementType[0].Text)
+
+	statuses, err = ad.LookupItems("noitems")
+	assert.NoError(t, err)
+	assert.Empty(t, statuses)
+
+	_, err = ad.LookupItems("problem")
+	assert.Equal(t, "NCIP lookup item set failed: Unknown Item", err.Error())
+
+	_, err = ad.LookupItems("error")
+	assert.Equal(t, "lookup error", err.Error())
+
+	mock.(*ncipClientMock).nilResponse = true
+	_, err = ad.LookupItems("bib1")
+	assert.Equal(t, "empty response from LookupItemSet", err.Error())
+}
+
+func TestRenewItem(t *testing.T) {
+	var mock ncipclient.NcipClient = new(ncipClientMock)
+	ad := &LmsAdapterNcip{
+		ncipClient: mock,
+	}
+	desired := time.Date(2030, 1, 15, 0, 0, 0, 0, time.UTC)
+	dateDue, err := ad.RenewItem("item1", "user1", desired)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2030, 2, 1, 0, 0, 0, 0, time.UTC), dateDue)
+	req := mock.(*ncipClientMock).lastRequest.(ncip.RenewItem)
+	assert.Equal(t, "item1", req.ItemId.ItemIdentifierValue)
+	assert.Equal(t, "user1", req.UserId.UserIdentifierValue)
+	assert.Equal(t, desired, req.DesiredDateDue.Time)
+
+	_, err = ad.RenewItem("item1", "user1", time.Time{})
+	assert.NoError(t, err)
+	req = mock.(*ncipClientMock).lastRequest.(ncip.RenewItem)
+	assert.Nil(t, req.DesiredDateDue)
+
+	_, err = ad.RenewItem("error", "user1", desired)
+	assert.Equal(t, "renew error", err.Error())
+
+	mock.(*ncipClientMock).nilResponse = true
+	dateDue, err = ad.RenewItem("item1", "user1", desired)
+	assert.NoError(t, err)
+	assert.Equal(t, desired, dateDue)
+
+	mock.(*ncipClientMock).lastRequest = nil
+	f := false
+	ad.config.RenewItemEnabled = &f
+	_, err = ad.RenewItem("item1", "user1", desired)
+	assert.Equal(t, ErrNotSupported, err)
+	assert.Nil(t, mock.(*ncipClientMock).lastRequest) // not called
+}
+
+func TestItemsAvailability(t *testing.T) {
+	onShelf := string(ncip.CirculationStatusAvailableOnShelf)
+	onLoan := string(ncip.CirculationStatusOnLoan)
+	assert.Equal(t, AvailabilityUnavailable, ItemsAvailability(nil))
+	assert.Equal(t, AvailabilityAvailable, ItemsAvailability([]string{onShelf}))
+	assert.Equal(t, AvailabilityAvailable, ItemsAvailability([]string{onLoan, "Local Status", onShelf}))
+	assert.Equal(t, AvailabilityUnavailable, ItemsAvailability([]string{onLoan, string(ncip.CirculationStatusMissing)}))
+	assert.Equal(t, AvailabilityUnknown, ItemsAvailability([]string{onLoan, ""}))
+	assert.Equal(t, AvailabilityUnknown, ItemsAvailability([]string{string(ncip.CirculationStatusUndefined)}))
+	assert.Equal(t, AvailabilityUnknown, ItemsAvailability([]string{"Local Status"}))
+}
+
 func TestInstitutionalPatron(t *testing.T) {
 	var mock ncipclient.NcipClient = new(ncipClientMock)
 	config := dirapi.LmsConfig{}
@@ -441,7 +524,7 @@ func (n *ncipClientMock) SetLogFunc(logFunc ncipclient.NcipLogFunc) {
 	n.lastLogFunc = logFunc
 }
 
-func (n *ncipClientMock) LookupUser(lookup ncip.LookupUser) (*ncip.LookupUserResponse, error) {
+func (n *ncipClientMock) LookupUser(ctx context.Context, lookup ncip.LookupUser) (*ncip.LookupUserResponse, error) {
 	n.lastRequest = lookup
 	if lookup.UserId != nil {
 		if lookup.UserId.UserIdentifierValue == "pass" {
@@ -483,12 +566,12 @@ func (n *ncipClientMock) LookupUser(lookup ncip.LookupUser) (*ncip.LookupUserRes
 	}, nil
 }
 
-func (n *ncipClientMock) AcceptItem(accept ncip.AcceptItem) (*ncip.AcceptItemResponse, error) {
+func (n *ncipClientMock) AcceptItem(ctx context.Context, accept ncip.AcceptItem) (*ncip.AcceptItemResponse, error) {
 	n.lastRequest = accept
 	return nil, nil
 }
 
-func (n *ncipClientMock) DeleteItem(delete ncip.DeleteItem) (*ncip.DeleteItemResponse, error) {
+func (n *ncipClientMock) DeleteItem(ctx context.Context, delete ncip.DeleteItem) (*ncip.DeleteItemResponse, error) {
 	if delete.ItemId.ItemIdentifierValue == "error" {
 		return nil, fmt.Errorf("deletion error")
 	}
@@ -496,7 +579,7 @@ func (n *ncipClientMock) DeleteItem(delete ncip.DeleteItem) (*ncip.DeleteItemRes
 	return nil, nil
 }
 
-func (n *ncipClientMock) RequestItem(request ncip.RequestItem) (*ncip.RequestItemResponse, error) {
+func (n *ncipClientMock) RequestItem(ctx context.Context, request ncip.RequestItem) (*ncip.RequestItemResponse, error) {
 	n.lastRequest = request
 	itemId := ""
 	if len(request.BibliographicId) > 0 {
@@ -531,17 +614,17 @@ func (n *ncipClientMock) RequestItem(request ncip.RequestItem) (*ncip.RequestIte
 	return res, nil
 }
 
-func (n *ncipClientMock) CancelRequestItem(cancel ncip.CancelRequestItem) (*ncip.CancelRequestItemResponse, error) {
+func (n *ncipClientMock) CancelRequestItem(ctx context.Context, cancel ncip.CancelRequestItem) (*ncip.CancelRequestItemResponse, error) {
 	n.lastRequest = cancel
 	return nil, nil
 }
 
-func (n *ncipClientMock) CheckInItem(checkin ncip.CheckInItem) (*ncip.CheckInItemResponse, error) {
+func (n *ncipClientMock) CheckInItem(ctx context.Context, checkin ncip.CheckInItem) (*ncip.CheckInItemResponse, error) {
 	n.lastRequest = checkin
 	return nil, nil
 }
 
-func (n *ncipClientMock) CheckOutItem(checkout ncip.CheckOutItem) (*ncip.CheckOutItemResponse, error) {
+func (n *ncipClientMock) CheckOutItem(ctx context.Context, checkout ncip.CheckOutItem) (*ncip.CheckOutItemResponse, error) {
 	n.lastRequest = checkout
 	if n.nilResponse {
 		return nil, nil
@@ -560,7 +643,55 @@ func (n *ncipClientMock) CheckOutItem(checkout ncip.CheckOutItem) (*ncip.CheckOu
 	return res, nil
 }
 
-func (n *ncipClientMock) CreateUserFiscalTransaction(create ncip.CreateUserFiscalTransaction) (*ncip.CreateUserFiscalTransactionResponse, error) {
+func (n *ncipClientMock) CreateUserFiscalTransaction(ctx context.Context, create ncip.CreateUserFiscalTransaction) (*ncip.CreateUserFiscalTransactionResponse, error) {
 	n.lastRequest = create
 	return nil, nil
 }
+
+func (n *ncipClientMock) LookupItem(ctx context.Context, lookup ncip.LookupItem) (*ncip.LookupItemResponse, error) {
+	n.lastRequest = lookup
+	return nil, nil
+}
+
+func (n *ncipClientMock) LookupItemSet(ctx context.Context, lookup ncip.LookupItemSet) (*ncip.LookupItemSetResponse, error) {
+	n.lastRequest = lookup
+	if n.nilResponse {
+		return nil, nil
+	}
+	item := func(status ncip.CirculationStatusValue) ncip.ItemInformation {
+		info := ncip.ItemInformation{}
+		if status != "" {
+			info.ItemOptionalFields = &ncip.ItemOptionalFields{
+				CirculationStatus: &ncip.SchemeValuePair{Text: string(status)},
+			}
+		}
+		return info
+	}
+	bibInformation := ncip.BibInformation{}
+	switch lookup.BibliographicId[0].BibliographicRecordId.BibliographicRecordIdentifier {
+	case "error":
+		return nil, fmt.Errorf("lookup error")
+	case "problem":
+		bibInformation.Problem = []ncip.Problem{{ProblemType: ncip.SchemeValuePair{Text: string(ncip.UnknownItem)}}}
+	case "noitems":
+	default:
+		bibInformation.HoldingsSet = []ncip.HoldingsSet{
+			{ItemInformation: []ncip.ItemInformation{item(ncip.CirculationStatusOnLoan), item("")}},
+			{ItemInformation: []ncip.ItemInformation{item(ncip.CirculationStatusAvailableOnShelf)}},
+		}
+	}
+	return &ncip.LookupItemSetResponse{BibInformation: []ncip.BibInformation{bibInformation}}, nil
+}
+
+func (n *ncipClientMock) RenewItem(ctx context.Context, renew ncip.RenewItem) (*ncip.RenewItemResponse, error) {
+	n.lastRequest = renew
+	if renew.ItemId.ItemIdentifierValue == "error" {
+		return nil, fmt.Errorf("renew error")
+	}
+	if n.nilResponse {
+		return nil, nil
+	}
+	return &ncip.RenewItemResponse{
+		DateDue: &utils.XSDDateTime{Time: time.Date(2030, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}, nil
+}
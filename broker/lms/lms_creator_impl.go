@@ -4,6 +4,7 @@ import (
 	"github.com/indexdata/crosslink/broker/adapter"
 	"github.com/indexdata/crosslink/broker/common"
 	"github.com/indexdata/crosslink/broker/ill_db"
+	dirapi "github.com/indexdata/crosslink/directory/api"
 )
 
 type lmsCreatorImpl struct {
@@ -26,7 +27,13 @@ func (l *lmsCreatorImpl) GetAdapter(ctx common.ExtendedContext, symbol string) (
 	for _, peer := range peers {
 		entry := peer.CustomData
 		if entry.LmsConfig != nil {
-			return CreateLmsAdapterNcip(*entry.LmsConfig)
+			if entry.LmsConfig.Type != nil && *entry.LmsConfig.Type == dirapi.Folio {
+				return CreateLmsAdapterFolio(*entry.LmsConfig)
+			}
+			return CreateLmsAdapterNcip(ctx, *entry.LmsConfig)
+		}
+		if entry.Sip2Config != nil {
+			return CreateLmsAdapterSip2(*entry.Sip2Config)
 		}
 	}
 	return CreateLmsAdapterMockOK(), nil
@@ -75,6 +75,78 @@ func TestGetAdapterNcipFail(t *testing.T) {
 	assert.Equal(t, "missing NCIP address in LMS configuration", err.Error())
 }
 
+func TestGetAdapterSip2OK(t *testing.T) {
+	illRepo := &MockIllRepo{}
+	peer := ill_db.Peer{
+		CustomData: dirapi.Entry{
+			Sip2Config: &dirapi.Sip2Config{
+				Address:       "localhost:6001",
+				InstitutionId: "INST",
+			},
+		},
+	}
+	illRepo.On("GetCachedPeersBySymbols", mock.Anything).Return([]ill_db.Peer{peer}, "", nil)
+	creator := NewLmsCreator(illRepo, nil)
+	ctx := common.CreateExtCtxWithArgs(context.Background(), nil)
+	LmsAdapter, err := creator.GetAdapter(ctx, "TEST")
+	assert.NoError(t, err)
+	assert.IsType(t, &LmsAdapterSip2{}, LmsAdapter)
+}
+
+func TestGetAdapterSip2Fail(t *testing.T) {
+	illRepo := &MockIllRepo{}
+	peer := ill_db.Peer{
+		CustomData: dirapi.Entry{
+			Sip2Config: &dirapi.Sip2Config{},
+		},
+	}
+	illRepo.On("GetCachedPeersBySymbols", mock.Anything).Return([]ill_db.Peer{peer}, "", nil)
+	creator := NewLmsCreator(illRepo, nil)
+	ctx := common.CreateExtCtxWithArgs(context.Background(), nil)
+	_, err := creator.GetAdapter(ctx, "TEST")
+	assert.Error(t, err)
+	assert.Equal(t, "missing SIP2 address in SIP2 configuration", err.Error())
+}
+
+func TestGetAdapterFolioOK(t *testing.T) {
+	illRepo := &MockIllRepo{}
+	lmsType := dirapi.Folio
+	peer := ill_db.Peer{
+		CustomData: dirapi.Entry{
+			LmsConfig: &dirapi.LmsConfig{
+				Type:    &lmsType,
+				Address: "http://folio.example.com",
+				Folio:   &dirapi.FolioLmsConfig{Tenant: "diku"},
+			},
+		},
+	}
+	illRepo.On("GetCachedPeersBySymbols", mock.Anything).Return([]ill_db.Peer{peer}, "", nil)
+	creator := NewLmsCreator(illRepo, nil)
+	ctx := common.CreateExtCtxWithArgs(context.Background(), nil)
+	LmsAdapter, err := creator.GetAdapter(ctx, "TEST")
+	assert.NoError(t, err)
+	assert.IsType(t, &LmsAdapterFolio{}, LmsAdapter)
+}
+
+func TestGetAdapterFolioFail(t *testing.T) {
+	illRepo := &MockIllRepo{}
+	lmsType := dirapi.Folio
+	peer := ill_db.Peer{
+		CustomData: dirapi.Entry{
+			LmsConfig: &dirapi.LmsConfig{
+				Type:    &lmsType,
+				Address: "http://folio.example.com",
+			},
+		},
+	}
+	illRepo.On("GetCachedPeersBySymbols", mock.Anything).Return([]ill_db.Peer{peer}, "", nil)
+	creator := NewLmsCreator(illRepo, nil)
+	ctx := common.CreateExtCtxWithArgs(context.Background(), nil)
+	_, err := creator.GetAdapter(ctx, "TEST")
+	assert.Error(t, err)
+	assert.Equal(t, "missing FOLIO tenant in LMS configuration", err.Error())
+}
+
 type MockIllRepo struct {
 	mock.Mock
 	ill_db.PgIllRepo
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type dbPoolCollector struct {
+	stat                 func() *pgxpool.Stat
+	acquiredConns        *prometheus.Desc
+	idleConns            *prometheus.Desc
+	totalConns           *prometheus.Desc
+	maxConns             *prometheus.Desc
+	acquireCount         *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+	emptyAcquireCount    *prometheus.Desc
+	canceledAcquireCount *prometheus.Desc
+}
+
+// NewDbPoolCollector returns a collector reading the statistics of a pgx pool on every scrape
+func NewDbPoolCollector(stat func() *pgxpool.Stat) prometheus.Collector {
+	desc := func(name string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+	return &dbPoolCollector{
+		stat:                 stat,
+		acquiredConns:        desc("acquired_connections", "Connections currently acquired from the pool"),
+		idleConns:            desc("idle_connections", "Idle connections in the pool"),
+		totalConns:           desc("total_connections", "Total connections in the pool"),
+		maxConns:             desc("max_connections", "Maximum size of the pool"),
+		acquireCount:         desc("acquire_total", "Successful acquires from the pool"),
+		acquireDuration:      desc("acquire_duration_seconds_total", "Total time spent on successful acquires"),
+		emptyAcquireCount:    desc("empty_acquire_total", "Acquires that had to wait for a connection"),
+		canceledAcquireCount: desc("canceled_acquire_total", "Acquires canceled by the context"),
+	}
+}
+
+func (c *dbPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquireCount
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquireCount
+	ch <- c.canceledAcquireCount
+}
+
+func (c *dbPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.stat()
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireCount, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquireCount, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "crosslink_broker"
+
+const (
+	OutcomeOk      = "ok"
+	OutcomeError   = "error"
+	OutcomeProblem = "problem"
+	OutcomeSkipped = "skipped"
+)
+
+// Registry holds all broker metrics, it is served by Handler
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var IsoMessagesReceived = factory.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "iso18626_messages_received_total",
+	Help:      "Inbound ISO18626 messages by message type, confirmation status and peer symbol",
+}, []string{"type", "status", "peer"})
+
+var IsoSendDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "iso18626_send_duration_seconds",
+	Help:      "Latency of outbound ISO18626 messages by peer",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"peer"})
+
+var IsoSendFailures = factory.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "iso18626_send_failures_total",
+	Help:      "Failed outbound ISO18626 messages by peer",
+}, []string{"peer"})
+
+var EventTaskDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "event_task_duration_seconds",
+	Help:      "Duration of event bus task handlers by event name and resulting status",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"event_name", "status"})
+
+var CatalogLookupDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "catalog_lookup_duration_seconds",
+	Help:      "Latency of catalog lookups by adapter and outcome",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"adapter", "outcome"})
+
+var NcipCalls = factory.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "ncip_calls_total",
+	Help:      "NCIP calls by operation and outcome (ok, problem or error)",
+}, []string{"operation", "outcome"})
+
+var SchedulerRuns = factory.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "scheduler_task_runs_total",
+	Help:      "Scheduled task runs by event name and outcome (ok, error or skipped)",
+}, []string{"event_name", "outcome"})
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+// If token is not empty, scrapes must send it as a bearer token.
+func Handler(token string) http.Handler {
+	h := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+	if token == "" {
+		return h
+	}
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Outcome returns OutcomeOk for a nil error and OutcomeError otherwise
+func Outcome(err error) string {
+	if err != nil {
+		return OutcomeError
+	}
+	return OutcomeOk
+}
+
+// Since returns the seconds elapsed since start, for observing durations
+func Since(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
+
+// RegisterDbPool exposes the connection pool statistics, only the first registered pool is exposed
+func RegisterDbPool(pool *pgxpool.Pool) error {
+	err := Registry.Register(NewDbPoolCollector(pool.Stat))
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_event_processing_task;
+DROP TABLE IF EXISTS event_lease;
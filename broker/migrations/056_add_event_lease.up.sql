@@ -0,0 +1,11 @@
+CREATE TABLE event_lease
+(
+    event_id   VARCHAR PRIMARY KEY,
+    owner      VARCHAR   NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    attempts   INT       NOT NULL DEFAULT 1,
+    FOREIGN KEY (event_id) REFERENCES event (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_event_lease_expires_at ON event_lease (expires_at);
+CREATE INDEX idx_event_processing_task ON event (timestamp) WHERE event_type = 'TASK' AND event_status = 'PROCESSING';
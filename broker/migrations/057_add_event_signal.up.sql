@@ -0,0 +1,12 @@
+CREATE TABLE event_signal
+(
+    id         BIGSERIAL PRIMARY KEY,
+    event_id   VARCHAR   NOT NULL,
+    signal     VARCHAR   NOT NULL,
+    target     VARCHAR   NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (event_id) REFERENCES event (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_event_signal_event_id ON event_signal (event_id);
+CREATE INDEX idx_event_signal_created_at ON event_signal (created_at);
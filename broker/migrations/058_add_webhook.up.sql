@@ -0,0 +1,37 @@
+CREATE TABLE webhook_subscription
+(
+    id          TEXT PRIMARY KEY,
+    owner       TEXT        NOT NULL,
+    url         TEXT        NOT NULL,
+    secret      TEXT        NOT NULL,
+    event_names TEXT[]      NOT NULL DEFAULT '{}',
+    side        TEXT,
+    states      TEXT[]      NOT NULL DEFAULT '{}',
+    active      BOOLEAN     NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_webhook_subscription_owner ON webhook_subscription (owner);
+
+CREATE TABLE webhook_delivery
+(
+    id              TEXT PRIMARY KEY,
+    subscription_id TEXT        NOT NULL,
+    event_id        TEXT        NOT NULL,
+    event_name      TEXT        NOT NULL,
+    signal          TEXT        NOT NULL,
+    payload         JSONB       NOT NULL,
+    status          TEXT        NOT NULL DEFAULT 'pending',
+    attempts        INT         NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ,
+    last_attempt_at TIMESTAMPTZ,
+    response_status INT,
+    error           TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    FOREIGN KEY (subscription_id) REFERENCES webhook_subscription (id) ON DELETE CASCADE,
+    UNIQUE (subscription_id, event_id, signal)
+);
+
+CREATE INDEX idx_webhook_delivery_next_attempt_at ON webhook_delivery (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_delivery_subscription_id ON webhook_delivery (subscription_id, created_at);
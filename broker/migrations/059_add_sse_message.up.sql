@@ -0,0 +1,12 @@
+CREATE TABLE sse_message
+(
+    id         BIGSERIAL PRIMARY KEY,
+    event_id   VARCHAR,
+    receiver   VARCHAR   NOT NULL,
+    message    TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (event_id, receiver)
+);
+
+CREATE INDEX idx_sse_message_receiver_id ON sse_message (receiver, id);
+CREATE INDEX idx_sse_message_created_at ON sse_message (created_at);
@@ -0,0 +1 @@
+ALTER TABLE event ADD COLUMN trace_parent VARCHAR NOT NULL DEFAULT '';
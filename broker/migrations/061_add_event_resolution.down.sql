@@ -0,0 +1,7 @@
+DELETE FROM event WHERE event_name = 'task-resolved';
+DELETE FROM event_config WHERE event_name = 'task-resolved';
+
+DROP FUNCTION IF EXISTS get_event_owner(VARCHAR, VARCHAR, jsonb);
+DROP FUNCTION IF EXISTS get_event_domain(VARCHAR, VARCHAR);
+DROP INDEX IF EXISTS idx_event_failed;
+DROP TABLE IF EXISTS event_resolution;
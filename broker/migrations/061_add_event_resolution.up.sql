@@ -0,0 +1,34 @@
+CREATE TABLE event_resolution
+(
+    event_id       VARCHAR PRIMARY KEY,
+    resolution     VARCHAR   NOT NULL,
+    note           TEXT,
+    rerun_event_id VARCHAR,
+    resolved_at    TIMESTAMP NOT NULL,
+    FOREIGN KEY (event_id) REFERENCES event (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_event_failed ON event (timestamp)
+    WHERE event_type = 'TASK' AND event_status IN ('ERROR', 'PROBLEM');
+
+CREATE OR REPLACE FUNCTION get_event_domain(ill_id VARCHAR, pr_id VARCHAR) RETURNS VARCHAR AS $$
+SELECT CASE
+           WHEN pr_id <> '00000000-0000-0000-0000-000000000002' THEN 'PATRON_REQUEST'
+           WHEN ill_id <> '00000000-0000-0000-0000-000000000001' THEN 'ILL_TRANSACTION'
+           ELSE 'SCHEDULER'
+           END;
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION get_event_owner(ill_id VARCHAR, pr_id VARCHAR, data jsonb) RETURNS VARCHAR AS $$
+SELECT COALESCE(
+               (SELECT CASE WHEN pr.side = 'lending' THEN pr.supplier_symbol ELSE pr.requester_symbol END
+                FROM patron_request pr
+                WHERE pr.id = pr_id),
+               (SELECT it.requester_symbol FROM ill_transaction it WHERE it.id = ill_id),
+               data -> 'batchActionData' ->> 'owner',
+               '');
+$$ LANGUAGE sql STABLE;
+
+INSERT INTO event_config (event_name, event_type, retry_count)
+VALUES ('task-resolved', 'NOTICE', 0)
+ON CONFLICT (event_name) DO NOTHING;
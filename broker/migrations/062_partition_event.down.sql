@@ -0,0 +1,70 @@
+DROP TRIGGER IF EXISTS event_delete_key ON event;
+DROP TRIGGER IF EXISTS event_save_key ON event;
+DROP FUNCTION IF EXISTS delete_event_key();
+DROP FUNCTION IF EXISTS save_event_key();
+DROP FUNCTION IF EXISTS drop_event_partition(VARCHAR);
+DROP FUNCTION IF EXISTS list_event_partitions();
+DROP FUNCTION IF EXISTS create_event_partition(TIMESTAMP);
+
+ALTER TABLE event RENAME TO event_partitioned;
+ALTER TABLE event_partitioned RENAME CONSTRAINT event_pkey TO event_partitioned_pkey;
+DROP INDEX IF EXISTS idx_event_ill_transaction_timestamp;
+DROP INDEX IF EXISTS idx_event_patron_request_timestamp;
+DROP INDEX IF EXISTS idx_event_incomplete_by_domain_name_timestamp;
+DROP INDEX IF EXISTS idx_event_batch_action_task_timestamp;
+DROP INDEX IF EXISTS idx_event_processing_task;
+DROP INDEX IF EXISTS idx_event_failed;
+
+CREATE TABLE event
+(
+    id                 VARCHAR PRIMARY KEY,
+    timestamp          TIMESTAMP NOT NULL,
+    ill_transaction_id VARCHAR   NOT NULL,
+    parent_id          VARCHAR,
+    event_type         VARCHAR   NOT NULL,
+    event_name         VARCHAR   NOT NULL,
+    event_status       VARCHAR   NOT NULL,
+    event_data         jsonb,
+    result_data        jsonb,
+    last_signal        VARCHAR   NOT NULL,
+    patron_request_id  VARCHAR   NOT NULL DEFAULT '00000000-0000-0000-0000-000000000002',
+    trace_parent       VARCHAR   NOT NULL DEFAULT '',
+    FOREIGN KEY (ill_transaction_id) REFERENCES ill_transaction (id) ON DELETE CASCADE,
+    FOREIGN KEY (patron_request_id) REFERENCES patron_request (id) ON DELETE CASCADE,
+    FOREIGN KEY (event_name) REFERENCES event_config (event_name)
+);
+
+INSERT INTO event (id, timestamp, ill_transaction_id, parent_id, event_type, event_name, event_status,
+                   event_data, result_data, last_signal, patron_request_id, trace_parent)
+SELECT id, timestamp, ill_transaction_id, parent_id, event_type, event_name, event_status,
+       event_data, result_data, last_signal, patron_request_id, trace_parent
+FROM event_partitioned;
+
+DROP TABLE event_partitioned;
+
+CREATE INDEX idx_event_ill_transaction_timestamp
+    ON event (ill_transaction_id, timestamp, id);
+
+CREATE INDEX idx_event_patron_request_timestamp
+    ON event (patron_request_id, timestamp, id);
+
+CREATE INDEX idx_event_incomplete_by_domain_name_timestamp
+    ON event (patron_request_id, ill_transaction_id, event_type, event_name, timestamp, id)
+    WHERE event_status IN ('NEW', 'PROCESSING');
+
+CREATE INDEX idx_event_batch_action_task_timestamp
+    ON event ((event_data -> 'batchActionData' ->> 'taskId'), timestamp DESC)
+    WHERE event_name IN ('invoke-batch-action', 'invoke-background-action');
+
+CREATE INDEX idx_event_processing_task ON event (timestamp) WHERE event_type = 'TASK' AND event_status = 'PROCESSING';
+
+CREATE INDEX idx_event_failed ON event (timestamp)
+    WHERE event_type = 'TASK' AND event_status IN ('ERROR', 'PROBLEM');
+
+ALTER TABLE event_lease DROP CONSTRAINT IF EXISTS event_lease_event_id_fkey;
+ALTER TABLE event_signal DROP CONSTRAINT IF EXISTS event_signal_event_id_fkey;
+ALTER TABLE event_resolution DROP CONSTRAINT IF EXISTS event_resolution_event_id_fkey;
+DROP TABLE event_key;
+ALTER TABLE event_lease ADD FOREIGN KEY (event_id) REFERENCES event (id) ON DELETE CASCADE;
+ALTER TABLE event_signal ADD FOREIGN KEY (event_id) REFERENCES event (id) ON DELETE CASCADE;
+ALTER TABLE event_resolution ADD FOREIGN KEY (event_id) REFERENCES event (id) ON DELETE CASCADE;
@@ -0,0 +1,154 @@
+-- a partitioned table cannot have a unique key on id alone, event_key keeps the event ids unique
+-- across partitions and is referenced by the tables depending on events
+CREATE TABLE event_key
+(
+    id        VARCHAR PRIMARY KEY,
+    timestamp TIMESTAMP NOT NULL
+);
+
+INSERT INTO event_key (id, timestamp)
+SELECT id, timestamp
+FROM event;
+
+ALTER TABLE event_lease DROP CONSTRAINT IF EXISTS event_lease_event_id_fkey;
+ALTER TABLE event_signal DROP CONSTRAINT IF EXISTS event_signal_event_id_fkey;
+ALTER TABLE event_resolution DROP CONSTRAINT IF EXISTS event_resolution_event_id_fkey;
+ALTER TABLE event_lease ADD FOREIGN KEY (event_id) REFERENCES event_key (id) ON DELETE CASCADE;
+ALTER TABLE event_signal ADD FOREIGN KEY (event_id) REFERENCES event_key (id) ON DELETE CASCADE;
+ALTER TABLE event_resolution ADD FOREIGN KEY (event_id) REFERENCES event_key (id) ON DELETE CASCADE;
+
+ALTER TABLE event RENAME TO event_unpartitioned;
+ALTER TABLE event_unpartitioned RENAME CONSTRAINT event_pkey TO event_unpartitioned_pkey;
+DROP INDEX IF EXISTS idx_event_ill_transaction_timestamp;
+DROP INDEX IF EXISTS idx_event_patron_request_timestamp;
+DROP INDEX IF EXISTS idx_event_incomplete_by_domain_name_timestamp;
+DROP INDEX IF EXISTS idx_event_batch_action_task_timestamp;
+DROP INDEX IF EXISTS idx_event_processing_task;
+DROP INDEX IF EXISTS idx_event_failed;
+
+CREATE TABLE event
+(
+    id                 VARCHAR   NOT NULL,
+    timestamp          TIMESTAMP NOT NULL,
+    ill_transaction_id VARCHAR   NOT NULL,
+    parent_id          VARCHAR,
+    event_type         VARCHAR   NOT NULL,
+    event_name         VARCHAR   NOT NULL,
+    event_status       VARCHAR   NOT NULL,
+    event_data         jsonb,
+    result_data        jsonb,
+    last_signal        VARCHAR   NOT NULL,
+    patron_request_id  VARCHAR   NOT NULL DEFAULT '00000000-0000-0000-0000-000000000002',
+    trace_parent       VARCHAR   NOT NULL DEFAULT '',
+    PRIMARY KEY (id, timestamp),
+    FOREIGN KEY (ill_transaction_id) REFERENCES ill_transaction (id) ON DELETE CASCADE,
+    FOREIGN KEY (patron_request_id) REFERENCES patron_request (id) ON DELETE CASCADE,
+    FOREIGN KEY (event_name) REFERENCES event_config (event_name)
+) PARTITION BY RANGE (timestamp);
+
+-- there is no default partition, the broker creates the monthly partitions ahead of time
+-- and an event outside of them fails instead of ending up in a partition that is never dropped
+
+CREATE OR REPLACE FUNCTION create_event_partition(p_month TIMESTAMP) RETURNS VARCHAR AS $$
+DECLARE
+    v_start TIMESTAMP := date_trunc('month', p_month);
+    v_name  VARCHAR   := 'event_p' || to_char(v_start, 'YYYYMM');
+BEGIN
+    -- serialize instances creating the same partition
+    PERFORM pg_advisory_xact_lock(8372910466);
+    IF to_regclass(v_name) IS NULL THEN
+        EXECUTE format('CREATE TABLE %I PARTITION OF event FOR VALUES FROM (%L) TO (%L)',
+                       v_name, v_start, v_start + INTERVAL '1 month');
+    END IF;
+    RETURN v_name;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION list_event_partitions()
+    RETURNS TABLE (partition_name VARCHAR, range_start TIMESTAMP, range_end TIMESTAMP) AS $$
+SELECT c.relname::VARCHAR,
+       to_date(substring(c.relname FROM 8), 'YYYYMM')::TIMESTAMP,
+       to_date(substring(c.relname FROM 8), 'YYYYMM') + INTERVAL '1 month'
+FROM pg_inherits i
+         JOIN pg_class c ON c.oid = i.inhrelid
+WHERE i.inhparent = 'event'::regclass
+  AND c.relname ~ '^event_p[0-9]{6}$'
+ORDER BY c.relname;
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION drop_event_partition(p_name VARCHAR) RETURNS VOID AS $$
+BEGIN
+    IF p_name !~ '^event_p[0-9]{6}$' THEN
+        RAISE EXCEPTION 'not an event partition: %', p_name;
+    END IF;
+    -- removing the keys removes the dependent rows
+    EXECUTE format('DELETE FROM event_key WHERE id IN (SELECT id FROM %I)', p_name);
+    EXECUTE format('DROP TABLE %I', p_name);
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+DECLARE
+    v_month TIMESTAMP;
+BEGIN
+    SELECT date_trunc('month', COALESCE(MIN(timestamp), now()::TIMESTAMP)) INTO v_month FROM event_unpartitioned;
+    WHILE v_month <= date_trunc('month', now()::TIMESTAMP) + INTERVAL '3 months' LOOP
+        PERFORM create_event_partition(v_month);
+        v_month := v_month + INTERVAL '1 month';
+    END LOOP;
+END;
+$$;
+
+INSERT INTO event (id, timestamp, ill_transaction_id, parent_id, event_type, event_name, event_status,
+                   event_data, result_data, last_signal, patron_request_id, trace_parent)
+SELECT id, timestamp, ill_transaction_id, parent_id, event_type, event_name, event_status,
+       event_data, result_data, last_signal, patron_request_id, trace_parent
+FROM event_unpartitioned;
+
+DROP TABLE event_unpartitioned;
+
+CREATE OR REPLACE FUNCTION save_event_key() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO event_key (id, timestamp) VALUES (NEW.id, NEW.timestamp)
+    ON CONFLICT (id) DO NOTHING;
+    -- an existing key is only accepted for the same event, which is then updated
+    IF NOT FOUND AND NOT EXISTS (SELECT 1 FROM event_key WHERE id = NEW.id AND timestamp = NEW.timestamp) THEN
+        RAISE EXCEPTION 'duplicate event id %', NEW.id USING ERRCODE = 'unique_violation';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER event_save_key
+    BEFORE INSERT OR UPDATE OF id, timestamp ON event
+    FOR EACH ROW EXECUTE FUNCTION save_event_key();
+
+CREATE OR REPLACE FUNCTION delete_event_key() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM event_key WHERE id = OLD.id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER event_delete_key
+    AFTER DELETE ON event
+    FOR EACH ROW EXECUTE FUNCTION delete_event_key();
+
+CREATE INDEX idx_event_ill_transaction_timestamp
+    ON event (ill_transaction_id, timestamp, id);
+
+CREATE INDEX idx_event_patron_request_timestamp
+    ON event (patron_request_id, timestamp, id);
+
+CREATE INDEX idx_event_incomplete_by_domain_name_timestamp
+    ON event (patron_request_id, ill_transaction_id, event_type, event_name, timestamp, id)
+    WHERE event_status IN ('NEW', 'PROCESSING');
+
+CREATE INDEX idx_event_batch_action_task_timestamp
+    ON event ((event_data -> 'batchActionData' ->> 'taskId'), timestamp DESC)
+    WHERE event_name IN ('invoke-batch-action', 'invoke-background-action');
+
+CREATE INDEX idx_event_processing_task ON event (timestamp) WHERE event_type = 'TASK' AND event_status = 'PROCESSING';
+
+CREATE INDEX idx_event_failed ON event (timestamp)
+    WHERE event_type = 'TASK' AND event_status IN ('ERROR', 'PROBLEM');
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS scheduled_task_run;
+
+ALTER TABLE scheduled_task
+    DROP COLUMN misfire_policy;
@@ -0,0 +1,12 @@
+ALTER TABLE scheduled_task
+    ADD COLUMN misfire_policy TEXT NOT NULL DEFAULT 'run_once';
+
+CREATE TABLE scheduled_task_run
+(
+    task_id       TEXT        NOT NULL,
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    event_id      TEXT,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (task_id, scheduled_for),
+    FOREIGN KEY (task_id) REFERENCES scheduled_task (id) ON DELETE CASCADE
+);
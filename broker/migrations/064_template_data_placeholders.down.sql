@@ -0,0 +1,10 @@
+UPDATE template
+SET subject = replace(replace(replace(subject,
+        '{{.Batch.Query}}', '{{batchQuery}}'),
+        '{{.Batch.ActualCount}}', '{{actualCount}}'),
+        '{{.Batch.FullCount}}', '{{fullCount}}'),
+    body = replace(replace(replace(body,
+        '{{.Batch.Query}}', '{{batchQuery}}'),
+        '{{.Batch.ActualCount}}', '{{actualCount}}'),
+        '{{.Batch.FullCount}}', '{{fullCount}}')
+WHERE subject LIKE '%{{.Batch.%' OR body LIKE '%{{.Batch.%';
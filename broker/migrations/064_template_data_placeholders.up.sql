@@ -0,0 +1,12 @@
+-- Email templates are now Go templates executed with the template data model
+UPDATE template
+SET subject = replace(replace(replace(subject,
+        '{{batchQuery}}', '{{.Batch.Query}}'),
+        '{{actualCount}}', '{{.Batch.ActualCount}}'),
+        '{{fullCount}}', '{{.Batch.FullCount}}'),
+    body = replace(replace(replace(body,
+        '{{batchQuery}}', '{{.Batch.Query}}'),
+        '{{actualCount}}', '{{.Batch.ActualCount}}'),
+        '{{fullCount}}', '{{.Batch.FullCount}}')
+WHERE subject LIKE '%{{batchQuery}}%' OR subject LIKE '%{{actualCount}}%' OR subject LIKE '%{{fullCount}}%'
+   OR body LIKE '%{{batchQuery}}%' OR body LIKE '%{{actualCount}}%' OR body LIKE '%{{fullCount}}%';
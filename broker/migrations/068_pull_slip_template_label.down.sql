@@ -0,0 +1 @@
+ALTER TABLE pull_slip DROP COLUMN template_label;
@@ -0,0 +1 @@
+ALTER TABLE pull_slip ADD COLUMN template_label VARCHAR;
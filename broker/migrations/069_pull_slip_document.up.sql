@@ -0,0 +1 @@
+ALTER TABLE pull_slip ADD COLUMN document VARCHAR NOT NULL DEFAULT 'pullslip';
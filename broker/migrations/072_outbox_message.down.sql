@@ -0,0 +1,5 @@
+ALTER INDEX idx_outbox_message_notification_id RENAME TO idx_email_message_notification_id;
+ALTER INDEX idx_outbox_message_next_attempt_at RENAME TO idx_email_message_next_attempt_at;
+ALTER TABLE outbox_message RENAME CONSTRAINT outbox_message_notification_id_fkey TO email_message_notification_id_fkey;
+ALTER TABLE outbox_message RENAME CONSTRAINT outbox_message_pkey TO email_message_pkey;
+ALTER TABLE outbox_message RENAME TO email_message;
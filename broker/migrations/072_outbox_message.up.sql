@@ -0,0 +1,6 @@
+-- the outbox holds sms and push texts as well as emails
+ALTER TABLE email_message RENAME TO outbox_message;
+ALTER TABLE outbox_message RENAME CONSTRAINT email_message_pkey TO outbox_message_pkey;
+ALTER TABLE outbox_message RENAME CONSTRAINT email_message_notification_id_fkey TO outbox_message_notification_id_fkey;
+ALTER INDEX idx_email_message_next_attempt_at RENAME TO idx_outbox_message_next_attempt_at;
+ALTER INDEX idx_email_message_notification_id RENAME TO idx_outbox_message_notification_id;
@@ -1,27 +1,38 @@
 package ncipclient
 
-import "github.com/indexdata/crosslink/ncip"
+import (
+	"context"
+
+	"github.com/indexdata/crosslink/ncip"
+)
 
 type NcipLogFunc func(outgoing map[string]any, incoming map[string]any, err error)
 
+// NcipClient sends NCIP messages, a span in the context of a call becomes the parent of its span
 type NcipClient interface {
 	SetLogFunc(logFunc NcipLogFunc)
 
-	LookupUser(arg ncip.LookupUser) (*ncip.LookupUserResponse, error)
+	LookupUser(ctx context.Context, arg ncip.LookupUser) (*ncip.LookupUserResponse, error)
+
+	AcceptItem(ctx context.Context, arg ncip.AcceptItem) (*ncip.AcceptItemResponse, error)
+
+	DeleteItem(ctx context.Context, arg ncip.DeleteItem) (*ncip.DeleteItemResponse, error)
+
+	RequestItem(ctx context.Context, arg ncip.RequestItem) (*ncip.RequestItemResponse, error)
 
-	AcceptItem(arg ncip.AcceptItem) (*ncip.AcceptItemResponse, error)
+	CancelRequestItem(ctx context.Context, arg ncip.CancelRequestItem) (*ncip.CancelRequestItemResponse, error)
 
-	DeleteItem(arg ncip.DeleteItem) (*ncip.DeleteItemResponse, error)
+	CheckInItem(ctx context.Context, arg ncip.CheckInItem) (*ncip.CheckInItemResponse, error)
 
-	RequestItem(arg ncip.RequestItem) (*ncip.RequestItemResponse, error)
+	CheckOutItem(ctx context.Context, arg ncip.CheckOutItem) (*ncip.CheckOutItemResponse, error)
 
-	CancelRequestItem(arg ncip.CancelRequestItem) (*ncip.CancelRequestItemResponse, error)
+	CreateUserFiscalTransaction(ctx context.Context, arg ncip.CreateUserFiscalTransaction) (*ncip.CreateUserFiscalTransactionResponse, error)
 
-	CheckInItem(arg ncip.CheckInItem) (*ncip.CheckInItemResponse, error)
+	LookupItem(ctx context.Context, arg ncip.LookupItem) (*ncip.LookupItemResponse, error)
 
-	CheckOutItem(arg ncip.CheckOutItem) (*ncip.CheckOutItemResponse, error)
+	LookupItemSet(ctx context.Context, arg ncip.LookupItemSet) (*ncip.LookupItemSetResponse, error)
 
-	CreateUserFiscalTransaction(arg ncip.CreateUserFiscalTransaction) (*ncip.CreateUserFiscalTransactionResponse, error)
+	RenewItem(ctx context.Context, arg ncip.RenewItem) (*ncip.RenewItemResponse, error)
 }
 
 type NcipError struct {
@@ -1,14 +1,20 @@
 package ncipclient
 
 import (
+	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
 
 	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/metrics"
+	"github.com/indexdata/crosslink/broker/tracing"
 	"github.com/indexdata/crosslink/httpclient"
 	"github.com/indexdata/crosslink/ncip"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type NcipClientImpl struct {
@@ -34,13 +40,15 @@ func (n *NcipClientImpl) SetLogFunc(logFunc NcipLogFunc) {
 	n.logFunc = logFunc
 }
 
-func (n *NcipClientImpl) LookupUser(lookup ncip.LookupUser) (*ncip.LookupUserResponse, error) {
+func (n *NcipClientImpl) LookupUser(ctx context.Context, lookup ncip.LookupUser) (_ *ncip.LookupUserResponse, err error) {
+	ctx, span := startCall(ctx, "LookupUser")
+	defer endCall("LookupUser", span, &err)
 	lookup.InitiationHeader = n.prepareHeader(lookup.InitiationHeader)
 
 	ncipMessage := &ncip.NCIPMessage{
 		LookupUser: &lookup,
 	}
-	ncipResponse, err := n.sendReceiveMessage(ncipMessage)
+	ncipResponse, err := n.sendReceiveMessage(ctx, ncipMessage)
 	if err != nil {
 		return nil, err
 	}
@@ -51,12 +59,14 @@ func (n *NcipClientImpl) LookupUser(lookup ncip.LookupUser) (*ncip.LookupUserRes
 	return response, n.checkProblem("NCIP user lookup", response.Problem)
 }
 
-func (n *NcipClientImpl) AcceptItem(accept ncip.AcceptItem) (*ncip.AcceptItemResponse, error) {
+func (n *NcipClientImpl) AcceptItem(ctx context.Context, accept ncip.AcceptItem) (_ *ncip.AcceptItemResponse, err error) {
+	ctx, span := startCall(ctx, "AcceptItem")
+	defer endCall("AcceptItem", span, &err)
 	accept.InitiationHeader = n.prepareHeader(accept.InitiationHeader)
 	ncipMessage := &ncip.NCIPMessage{
 		AcceptItem: &accept,
 	}
-	ncipResponse, err := n.sendReceiveMessage(ncipMessage)
+	ncipResponse, err := n.sendReceiveMessage(ctx, ncipMessage)
 	if err != nil {
 		return nil, err
 	}
@@ -67,12 +77,14 @@ func (n *NcipClientImpl) AcceptItem(accept ncip.AcceptItem) (*ncip.AcceptItemRes
 	return response, n.checkProblem("NCIP accept item", response.Problem)
 }
 
-func (n *NcipClientImpl) DeleteItem(delete ncip.DeleteItem) (*ncip.DeleteItemResponse, error) {
+func (n *NcipClientImpl) DeleteItem(ctx context.Context, delete ncip.DeleteItem) (_ *ncip.DeleteItemResponse, err error) {
+	ctx, span := startCall(ctx, "DeleteItem")
+	defer endCall("DeleteItem", span, &err)
 	delete.InitiationHeader = n.prepareHeader(delete.InitiationHeader)
 	ncipMessage := &ncip.NCIPMessage{
 		DeleteItem: &delete,
 	}
-	ncipResponse, err := n.sendReceiveMessage(ncipMessage)
+	ncipResponse, err := n.sendReceiveMessage(ctx, ncipMessage)
 	if err != nil {
 		return nil, err
 	}
@@ -83,12 +95,14 @@ func (n *NcipClientImpl) DeleteItem(delete ncip.DeleteItem) (*ncip.DeleteItemRes
 	return response, n.checkProblem("NCIP delete item", response.Problem)
 }
 
-func (n *NcipClientImpl) RequestItem(request ncip.RequestItem) (*ncip.RequestItemResponse, error) {
+func (n *NcipClientImpl) RequestItem(ctx context.Context, request ncip.RequestItem) (_ *ncip.RequestItemResponse, err error) {
+	ctx, span := startCall(ctx, "RequestItem")
+	defer endCall("RequestItem", span, &err)
 	request.InitiationHeader = n.prepareHeader(request.InitiationHeader)
 	ncipMessage := &ncip.NCIPMessage{
 		RequestItem: &request,
 	}
-	ncipResponse, err := n.sendReceiveMessage(ncipMessage)
+	ncipResponse, err := n.sendReceiveMessage(ctx, ncipMessage)
 	if err != nil {
 		return nil, err
 	}
@@ -99,12 +113,14 @@ func (n *NcipClientImpl) RequestItem(request ncip.RequestItem) (*ncip.RequestIte
 	return response, n.checkProblem("NCIP request item", response.Problem)
 }
 
-func (n *NcipClientImpl) CancelRequestItem(request ncip.CancelRequestItem) (*ncip.CancelRequestItemResponse, error) {
+func (n *NcipClientImpl) CancelRequestItem(ctx context.Context, request ncip.CancelRequestItem) (_ *ncip.CancelRequestItemResponse, err error) {
+	ctx, span := startCall(ctx, "CancelRequestItem")
+	defer endCall("CancelRequestItem", span, &err)
 	request.InitiationHeader = n.prepareHeader(request.InitiationHeader)
 	ncipMessage := &ncip.NCIPMessage{
 		CancelRequestItem: &request,
 	}
-	ncipResponse, err := n.sendReceiveMessage(ncipMessage)
+	ncipResponse, err := n.sendReceiveMessage(ctx, ncipMessage)
 	if err != nil {
 		return nil, err
 	}
@@ -115,12 +131,14 @@ func (n *NcipClientImpl) CancelRequestItem(request ncip.CancelRequestItem) (*nci
 	return response, n.checkProblem("NCIP cancel request item", response.Problem)
 }
 
-func (n *NcipClientImpl) CheckInItem(request ncip.CheckInItem) (*ncip.CheckInItemResponse, error) {
+func (n *NcipClientImpl) CheckInItem(ctx context.Context, request ncip.CheckInItem) (_ *ncip.CheckInItemResponse, err error) {
+	ctx, span := startCall(ctx, "CheckInItem")
+	defer endCall("CheckInItem", span, &err)
 	request.InitiationHeader = n.prepareHeader(request.InitiationHeader)
 	ncipMessage := &ncip.NCIPMessage{
 		CheckInItem: &request,
 	}
-	ncipResponse, err := n.sendReceiveMessage(ncipMessage)
+	ncipResponse, err := n.sendReceiveMessage(ctx, ncipMessage)
 	if err != nil {
 		return nil, err
 	}
@@ -131,12 +149,14 @@ func (n *NcipClientImpl) CheckInItem(request ncip.CheckInItem) (*ncip.CheckInIte
 	return response, n.checkProblem("NCIP check in item", response.Problem)
 }
 
-func (n *NcipClientImpl) CheckOutItem(request ncip.CheckOutItem) (*ncip.CheckOutItemResponse, error) {
+func (n *NcipClientImpl) CheckOutItem(ctx context.Context, request ncip.CheckOutItem) (_ *ncip.CheckOutItemResponse, err error) {
+	ctx, span := startCall(ctx, "CheckOutItem")
+	defer endCall("CheckOutItem", span, &err)
 	request.InitiationHeader = n.prepareHeader(request.InitiationHeader)
 	ncipMessage := &ncip.NCIPMessage{
 		CheckOutItem: &request,
 	}
-	ncipResponse, err := n.sendReceiveMessage(ncipMessage)
+	ncipResponse, err := n.sendReceiveMessage(ctx, ncipMessage)
 	if err != nil {
 		return nil, err
 	}
@@ -147,13 +167,15 @@ func (n *NcipClientImpl) CheckOutItem(request ncip.CheckOutItem) (*ncip.CheckOut
 	return response, n.checkProblem("NCIP check out item", response.Problem)
 }
 
-func (n *NcipClientImpl) CreateUserFiscalTransaction(request ncip.CreateUserFiscalTransaction) (*ncip.CreateUserFiscalTransactionResponse, error) {
+func (n *NcipClientImpl) CreateUserFiscalTransaction(ctx context.Context, request ncip.CreateUserFiscalTransaction) (_ *ncip.CreateUserFiscalTransactionResponse, err error) {
+	ctx, span := startCall(ctx, "CreateUserFiscalTransaction")
+	defer endCall("CreateUserFiscalTransaction", span, &err)
 	request.InitiationHeader = n.prepareHeader(request.InitiationHeader)
 
 	ncipMessage := &ncip.NCIPMessage{
 		CreateUserFiscalTransaction: &request,
 	}
-	ncipResponse, err := n.sendReceiveMessage(ncipMessage)
+	ncipResponse, err := n.sendReceiveMessage(ctx, ncipMessage)
 	if err != nil {
 		return nil, err
 	}
@@ -164,6 +186,83 @@ func (n *NcipClientImpl) CreateUserFiscalTransaction(request ncip.CreateUserFisc
 	return response, n.checkProblem("NCIP create user fiscal transaction", response.Problem)
 }
 
+func (n *NcipClientImpl) LookupItem(ctx context.Context, lookup ncip.LookupItem) (_ *ncip.LookupItemResponse, err error) {
+	ctx, span := startCall(ctx, "LookupItem")
+	defer endCall("LookupItem", span, &err)
+	lookup.InitiationHeader = n.prepareHeader(lookup.InitiationHeader)
+	ncipMessage := &ncip.NCIPMessage{
+		LookupItem: &lookup,
+	}
+	ncipResponse, err := n.sendReceiveMessage(ctx, ncipMessage)
+	if err != nil {
+		return nil, err
+	}
+	response := ncipResponse.LookupItemResponse
+	if response == nil {
+		return nil, fmt.Errorf("invalid NCIP response: missing LookupItemResponse")
+	}
+	return response, n.checkProblem("NCIP lookup item", response.Problem)
+}
+
+func (n *NcipClientImpl) LookupItemSet(ctx context.Context, lookup ncip.LookupItemSet) (_ *ncip.LookupItemSetResponse, err error) {
+	ctx, span := startCall(ctx, "LookupItemSet")
+	defer endCall("LookupItemSet", span, &err)
+	lookup.InitiationHeader = n.prepareHeader(lookup.InitiationHeader)
+	ncipMessage := &ncip.NCIPMessage{
+		LookupItemSet: &lookup,
+	}
+	ncipResponse, err := n.sendReceiveMessage(ctx, ncipMessage)
+	if err != nil {
+		return nil, err
+	}
+	response := ncipResponse.LookupItemSetResponse
+	if response == nil {
+		return nil, fmt.Errorf("invalid NCIP response: missing LookupItemSetResponse")
+	}
+	return response, n.checkProblem("NCIP lookup item set", response.Problem)
+}
+
+func (n *NcipClientImpl) RenewItem(ctx context.Context, renew ncip.RenewItem) (_ *ncip.RenewItemResponse, err error) {
+	ctx, span := startCall(ctx, "RenewItem")
+	defer endCall("RenewItem", span, &err)
+	renew.InitiationHeader = n.prepareHeader(renew.InitiationHeader)
+	ncipMessage := &ncip.NCIPMessage{
+		RenewItem: &renew,
+	}
+	ncipResponse, err := n.sendReceiveMessage(ctx, ncipMessage)
+	if err != nil {
+		return nil, err
+	}
+	response := ncipResponse.RenewItemResponse
+	if response == nil {
+		return nil, fmt.Errorf("invalid NCIP response: missing RenewItemResponse")
+	}
+	return response, n.checkProblem("NCIP renew item", response.Problem)
+}
+
+// startCall starts the span of an NCIP operation as a child of the span in the call context
+func startCall(ctx context.Context, operation string) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return tracing.Tracer().Start(ctx, "ncip "+operation, trace.WithAttributes(attribute.String("crosslink.ncip.operation", operation)))
+}
+
+// endCall ends the span and counts the call by operation, telling NCIP problems from failed exchanges
+func endCall(operation string, span trace.Span, err *error) {
+	outcome := metrics.OutcomeOk
+	if *err != nil {
+		outcome = metrics.OutcomeError
+		var ncipErr *NcipError
+		if errors.As(*err, &ncipErr) {
+			outcome = metrics.OutcomeProblem
+		}
+	}
+	metrics.NcipCalls.WithLabelValues(operation, outcome).Inc()
+	span.SetAttributes(attribute.String("crosslink.ncip.outcome", outcome))
+	tracing.End(span, *err)
+}
+
 func (n *NcipClientImpl) checkProblem(op string, responseProblems []ncip.Problem) error {
 	if len(responseProblems) > 0 {
 		return &NcipError{
@@ -188,7 +287,7 @@ func (n *NcipClientImpl) prepareHeader(header *ncip.InitiationHeader) *ncip.Init
 	return header
 }
 
-func (n *NcipClientImpl) sendReceiveMessage(message *ncip.NCIPMessage) (*ncip.NCIPMessage, error) {
+func (n *NcipClientImpl) sendReceiveMessage(ctx context.Context, message *ncip.NCIPMessage) (*ncip.NCIPMessage, error) {
 	if n.address == "" {
 		return nil, fmt.Errorf("missing NCIP address in configuration")
 	}
@@ -196,7 +295,7 @@ func (n *NcipClientImpl) sendReceiveMessage(message *ncip.NCIPMessage) (*ncip.NC
 
 	var respMessage ncip.NCIPMessage
 
-	err := httpclient.NewClient().RequestResponse(n.client, http.MethodPost, []string{httpclient.ContentTypeApplicationXml},
+	err := httpclient.NewClient().WithContext(ctx).RequestResponse(n.client, http.MethodPost, []string{httpclient.ContentTypeApplicationXml},
 		n.address, message, &respMessage, xml.Marshal, xml.Unmarshal)
 	if n.logFunc != nil {
 		hideSensitive(message)
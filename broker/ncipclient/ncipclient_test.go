@@ -1,6 +1,7 @@
 package ncipclient
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"net/http"
@@ -9,15 +10,25 @@ import (
 	"reflect"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/indexdata/go-utils/utils"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
 
 	mockapp "github.com/indexdata/crosslink/illmock/app"
 	"github.com/indexdata/crosslink/illmock/netutil"
 	"github.com/indexdata/crosslink/ncip"
 
+	"github.com/indexdata/crosslink/broker/metrics"
 	test "github.com/indexdata/crosslink/broker/test/utils"
+	"github.com/indexdata/crosslink/broker/tracing"
 )
 
 func TestMain(m *testing.M) {
@@ -63,7 +74,7 @@ func TestLookupUserAutoOK(t *testing.T) {
 			UserIdentifierValue: "validuser",
 		},
 	}
-	res, err := ncipClient.LookupUser(lookup)
+	res, err := ncipClient.LookupUser(context.Background(), lookup)
 	assert.NoError(t, err)
 	assert.NotNil(t, res)
 }
@@ -75,7 +86,7 @@ func TestLookupUserAutoInvalidUser(t *testing.T) {
 			UserIdentifierValue: "foo",
 		},
 	}
-	_, err := ncipClient.LookupUser(lookup)
+	_, err := ncipClient.LookupUser(context.Background(), lookup)
 	assert.Error(t, err)
 	assert.Equal(t, "NCIP user lookup failed: Unknown User: foo", err.Error())
 }
@@ -91,12 +102,53 @@ func TestLookupUserMissingAddress(t *testing.T) {
 			UserIdentifierValue: "validuser",
 		},
 	}
-	res, err := ncipClient.LookupUser(lookup)
+	res, err := ncipClient.LookupUser(context.Background(), lookup)
 	assert.Error(t, err)
 	assert.Equal(t, "missing NCIP address in configuration", err.Error())
 	assert.Nil(t, res)
 }
 
+func TestLookupUserMetrics(t *testing.T) {
+	count := func(outcome string) float64 {
+		return testutil.ToFloat64(metrics.NcipCalls.WithLabelValues("LookupUser", outcome))
+	}
+	ok, problem, failed := count(metrics.OutcomeOk), count(metrics.OutcomeProblem), count(metrics.OutcomeError)
+
+	ncipClient := createTestClient()
+	_, err := ncipClient.LookupUser(context.Background(), ncip.LookupUser{UserId: &ncip.UserId{UserIdentifierValue: "validuser"}})
+	assert.NoError(t, err)
+	_, err = ncipClient.LookupUser(context.Background(), ncip.LookupUser{UserId: &ncip.UserId{UserIdentifierValue: "foo"}})
+	assert.Error(t, err)
+	_, err = (&NcipClientImpl{}).LookupUser(context.Background(), ncip.LookupUser{})
+	assert.Error(t, err)
+
+	assert.Equal(t, ok+1, count(metrics.OutcomeOk))
+	assert.Equal(t, problem+1, count(metrics.OutcomeProblem))
+	assert.Equal(t, failed+1, count(metrics.OutcomeError))
+}
+
+func TestLookupUserSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(tracing.NewTracerProvider(sdktrace.WithSyncer(exporter), "test"))
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	parentCtx, parent := tracing.Tracer().Start(context.Background(), "task")
+	ncipClient := createTestClient()
+	_, err := ncipClient.LookupUser(parentCtx, ncip.LookupUser{UserId: &ncip.UserId{UserIdentifierValue: "foo"}})
+	assert.Error(t, err)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 3)
+	httpSpan, ncipSpan := spans[0], spans[1]
+	assert.Equal(t, "HTTP POST", httpSpan.Name)
+	assert.Equal(t, ncipSpan.SpanContext.SpanID(), httpSpan.Parent.SpanID())
+	assert.Equal(t, "ncip LookupUser", ncipSpan.Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), ncipSpan.Parent.SpanID())
+	assert.Contains(t, ncipSpan.Attributes, attribute.String("crosslink.ncip.outcome", metrics.OutcomeProblem))
+	assert.Equal(t, codes.Error, ncipSpan.Status.Code)
+}
+
 func TestBadNcipMessageResponse(t *testing.T) {
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/xml")
@@ -119,7 +171,7 @@ func TestBadNcipMessageResponse(t *testing.T) {
 			UserIdentifierValue: "validuser",
 		},
 	}
-	_, err := ncipClient.LookupUser(lookup)
+	_, err := ncipClient.LookupUser(context.Background(), lookup)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "NCIP message exchange failed:")
 
@@ -128,37 +180,52 @@ func TestBadNcipMessageResponse(t *testing.T) {
 			RequestIdentifierValue: "validrequest",
 		},
 	}
-	_, err = ncipClient.AcceptItem(accept)
+	_, err = ncipClient.AcceptItem(context.Background(), accept)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "NCIP message exchange failed:")
 
 	delete := ncip.DeleteItem{}
-	_, err = ncipClient.DeleteItem(delete)
+	_, err = ncipClient.DeleteItem(context.Background(), delete)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "NCIP message exchange failed:")
 
 	request := ncip.RequestItem{}
-	_, err = ncipClient.RequestItem(request)
+	_, err = ncipClient.RequestItem(context.Background(), request)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "NCIP message exchange failed:")
 
 	cancelRequest := ncip.CancelRequestItem{}
-	_, err = ncipClient.CancelRequestItem(cancelRequest)
+	_, err = ncipClient.CancelRequestItem(context.Background(), cancelRequest)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "NCIP message exchange failed:")
 
 	checkInItem := ncip.CheckInItem{}
-	_, err = ncipClient.CheckInItem(checkInItem)
+	_, err = ncipClient.CheckInItem(context.Background(), checkInItem)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "NCIP message exchange failed:")
 
 	checkOutItem := ncip.CheckOutItem{}
-	_, err = ncipClient.CheckOutItem(checkOutItem)
+	_, err = ncipClient.CheckOutItem(context.Background(), checkOutItem)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "NCIP message exchange failed:")
 
 	createUserFiscalTransaction := ncip.CreateUserFiscalTransaction{}
-	_, err = ncipClient.CreateUserFiscalTransaction(createUserFiscalTransaction)
+	_, err = ncipClient.CreateUserFiscalTransaction(context.Background(), createUserFiscalTransaction)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "NCIP message exchange failed:")
+
+	lookupItem := ncip.LookupItem{}
+	_, err = ncipClient.LookupItem(context.Background(), lookupItem)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "NCIP message exchange failed:")
+
+	lookupItemSet := ncip.LookupItemSet{}
+	_, err = ncipClient.LookupItemSet(context.Background(), lookupItemSet)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "NCIP message exchange failed:")
+
+	renewItem := ncip.RenewItem{}
+	_, err = ncipClient.RenewItem(context.Background(), renewItem)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "NCIP message exchange failed:")
 }
@@ -201,7 +268,7 @@ func TestEmptyNcipResponse(t *testing.T) {
 			UserIdentifierValue: "validuser",
 		},
 	}
-	_, err := ncipClient.LookupUser(lookup)
+	_, err := ncipClient.LookupUser(context.Background(), lookup)
 	assert.Error(t, err)
 	assert.Equal(t, "invalid NCIP response: missing LookupUserResponse", err.Error())
 	assert.NotNil(t, logOutgoing)
@@ -213,39 +280,54 @@ func TestEmptyNcipResponse(t *testing.T) {
 			RequestIdentifierValue: "validrequest",
 		},
 	}
-	_, err = ncipClient.AcceptItem(accept)
+	_, err = ncipClient.AcceptItem(context.Background(), accept)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid NCIP response: missing AcceptItemResponse")
 
 	delete := ncip.DeleteItem{}
-	_, err = ncipClient.DeleteItem(delete)
+	_, err = ncipClient.DeleteItem(context.Background(), delete)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid NCIP response: missing DeleteItemResponse")
 
 	request := ncip.RequestItem{}
-	_, err = ncipClient.RequestItem(request)
+	_, err = ncipClient.RequestItem(context.Background(), request)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid NCIP response: missing RequestItemResponse")
 
 	cancelRequest := ncip.CancelRequestItem{}
-	_, err = ncipClient.CancelRequestItem(cancelRequest)
+	_, err = ncipClient.CancelRequestItem(context.Background(), cancelRequest)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid NCIP response: missing CancelRequestItemResponse")
 
 	checkInItem := ncip.CheckInItem{}
-	_, err = ncipClient.CheckInItem(checkInItem)
+	_, err = ncipClient.CheckInItem(context.Background(), checkInItem)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid NCIP response: missing CheckInItemResponse")
 
 	checkOutItem := ncip.CheckOutItem{}
-	_, err = ncipClient.CheckOutItem(checkOutItem)
+	_, err = ncipClient.CheckOutItem(context.Background(), checkOutItem)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid NCIP response: missing CheckOutItemResponse")
 
 	createUserFiscalTransaction := ncip.CreateUserFiscalTransaction{}
-	_, err = ncipClient.CreateUserFiscalTransaction(createUserFiscalTransaction)
+	_, err = ncipClient.CreateUserFiscalTransaction(context.Background(), createUserFiscalTransaction)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid NCIP response: missing CreateUserFiscalTransactionResponse")
+
+	lookupItem := ncip.LookupItem{}
+	_, err = ncipClient.LookupItem(context.Background(), lookupItem)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid NCIP response: missing LookupItemResponse")
+
+	lookupItemSet := ncip.LookupItemSet{}
+	_, err = ncipClient.LookupItemSet(context.Background(), lookupItemSet)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid NCIP response: missing LookupItemSetResponse")
+
+	renewItem := ncip.RenewItem{}
+	_, err = ncipClient.RenewItem(context.Background(), renewItem)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid NCIP response: missing RenewItemResponse")
 }
 
 func setProblem(msg ncip.ProblemTypeMessage, detail string) []ncip.Problem {
@@ -288,7 +370,7 @@ func TestLookupUserProblemResponse(t *testing.T) {
 			UserIdentifierValue: "validuser",
 		},
 	}
-	_, err := ncipClient.LookupUser(lookup)
+	_, err := ncipClient.LookupUser(context.Background(), lookup)
 	assert.Error(t, err)
 	assert.Equal(t, "NCIP message processing failed: Some Problem: Details about the problem", err.Error())
 }
@@ -313,7 +395,7 @@ func TestAcceptItemOK(t *testing.T) {
 			},
 		},
 	}
-	res, err := ncipClient.AcceptItem(accept)
+	res, err := ncipClient.AcceptItem(context.Background(), accept)
 	assert.NoError(t, err)
 	assert.NotNil(t, res)
 }
@@ -333,7 +415,7 @@ func TestAcceptItemMissingTitle(t *testing.T) {
 			RequestIdentifierValue: "validrequest",
 		},
 	}
-	_, err := ncipClient.AcceptItem(accept)
+	_, err := ncipClient.AcceptItem(context.Background(), accept)
 	assert.Error(t, err)
 	assert.Equal(t, "NCIP accept item failed: Needed Data Missing: Title is required in ItemOptionalFields.BibliographicDescription for accepting the request", err.Error())
 }
@@ -358,7 +440,7 @@ func TestAcceptItemInvalidUser(t *testing.T) {
 			},
 		},
 	}
-	_, err := ncipClient.AcceptItem(accept)
+	_, err := ncipClient.AcceptItem(context.Background(), accept)
 	assert.Error(t, err)
 	assert.Equal(t, "NCIP accept item failed: Unknown User: foo", err.Error())
 }
@@ -372,7 +454,7 @@ func TestDeleteItemOK(t *testing.T) {
 	ncipClient.address = "http://localhost:" + os.Getenv("HTTP_PORT") + "/ncip"
 
 	delete := ncip.DeleteItem{}
-	res, err := ncipClient.DeleteItem(delete)
+	res, err := ncipClient.DeleteItem(context.Background(), delete)
 	assert.NoError(t, err)
 	assert.NotNil(t, res)
 }
@@ -399,7 +481,7 @@ func TestRequestItemOK(t *testing.T) {
 			Text: "Hold",
 		},
 	}
-	_, err := ncipClient.RequestItem(request)
+	_, err := ncipClient.RequestItem(context.Background(), request)
 	assert.NoError(t, err)
 }
 
@@ -422,7 +504,7 @@ func TestCancelRequestItemOK(t *testing.T) {
 			Text: "Hold",
 		},
 	}
-	res, err := ncipClient.CancelRequestItem(request)
+	res, err := ncipClient.CancelRequestItem(context.Background(), request)
 	assert.NoError(t, err)
 	assert.NotNil(t, res)
 }
@@ -439,7 +521,7 @@ func TestCheckInItemOK(t *testing.T) {
 			ItemIdentifierValue: "item-001",
 		},
 	}
-	res, err := ncipClient.CheckInItem(request)
+	res, err := ncipClient.CheckInItem(context.Background(), request)
 	assert.NoError(t, err)
 	assert.NotNil(t, res)
 }
@@ -460,7 +542,7 @@ func TestCheckOutItemOK(t *testing.T) {
 			ItemIdentifierValue: "item-001",
 		},
 	}
-	_, err := ncipClient.CheckOutItem(request)
+	_, err := ncipClient.CheckOutItem(context.Background(), request)
 	assert.NoError(t, err)
 }
 
@@ -483,11 +565,88 @@ func TestCreateUserFiscalTransactionOK(t *testing.T) {
 			},
 		},
 	}
-	res, err := ncipClient.CreateUserFiscalTransaction(lookup)
+	res, err := ncipClient.CreateUserFiscalTransaction(context.Background(), lookup)
 	assert.NoError(t, err)
 	assert.NotNil(t, res)
 }
 
+func TestLookupItemOK(t *testing.T) {
+	ncipClient := createTestClient()
+	lookup := ncip.LookupItem{
+		ItemId: &ncip.ItemId{
+			ItemIdentifierValue: "item-001",
+		},
+	}
+	res, err := ncipClient.LookupItem(context.Background(), lookup)
+	assert.NoError(t, err)
+	assert.Equal(t, string(ncip.CirculationStatusAvailableOnShelf), res.ItemOptionalFields.CirculationStatus.Text)
+}
+
+func TestLookupItemUnknownItem(t *testing.T) {
+	ncipClient := createTestClient()
+	lookup := ncip.LookupItem{
+		ItemId: &ncip.ItemId{
+			ItemIdentifierValue: "fitem-001",
+		},
+	}
+	_, err := ncipClient.LookupItem(context.Background(), lookup)
+	assert.Error(t, err)
+	assert.Equal(t, "NCIP lookup item failed: Unknown Item: fitem-001", err.Error())
+}
+
+func TestLookupItemSetOK(t *testing.T) {
+	ncipClient := createTestClient()
+	lookup := ncip.LookupItemSet{
+		ItemId: []ncip.ItemId{
+			{ItemIdentifierValue: "litem-001"},
+		},
+	}
+	res, err := ncipClient.LookupItemSet(context.Background(), lookup)
+	assert.NoError(t, err)
+	assert.Len(t, res.BibInformation, 1)
+	item := res.BibInformation[0].HoldingsSet[0].ItemInformation[0]
+	assert.Equal(t, string(ncip.CirculationStatusOnLoan), item.ItemOptionalFields.CirculationStatus.Text)
+}
+
+func TestLookupItemSetMissingId(t *testing.T) {
+	ncipClient := createTestClient()
+	_, err := ncipClient.LookupItemSet(context.Background(), ncip.LookupItemSet{})
+	assert.Error(t, err)
+	assert.Equal(t, "NCIP lookup item set failed: Needed Data Missing: BibliographicId, HoldingsSetId or ItemId is required", err.Error())
+}
+
+func TestRenewItemOK(t *testing.T) {
+	ncipClient := createTestClient()
+	desired := utils.XSDDateTime{Time: time.Date(2030, 1, 15, 0, 0, 0, 0, time.UTC)}
+	renew := ncip.RenewItem{
+		UserId: &ncip.UserId{
+			UserIdentifierValue: "validuser",
+		},
+		ItemId: ncip.ItemId{
+			ItemIdentifierValue: "item-001",
+		},
+		DesiredDateDue: &desired,
+	}
+	res, err := ncipClient.RenewItem(context.Background(), renew)
+	assert.NoError(t, err)
+	assert.True(t, desired.Equal(res.DateDue.Time))
+}
+
+func TestRenewItemUnknownItem(t *testing.T) {
+	ncipClient := createTestClient()
+	renew := ncip.RenewItem{
+		UserId: &ncip.UserId{
+			UserIdentifierValue: "validuser",
+		},
+		ItemId: ncip.ItemId{
+			ItemIdentifierValue: "fitem-001",
+		},
+	}
+	_, err := ncipClient.RenewItem(context.Background(), renew)
+	assert.Error(t, err)
+	assert.Equal(t, "NCIP renew item failed: Unknown Item: fitem-001", err.Error())
+}
+
 func TestHideSensitive(t *testing.T) {
 	sampleMessage := &ncip.NCIPMessage{
 		Version: ncip.NCIP_V2_02_XSD,
@@ -550,7 +709,7 @@ func TestSetLogFunc(t *testing.T) {
 		},
 	}
 
-	_, err := ncipClient.LookupUser(userMessage)
+	_, err := ncipClient.LookupUser(context.Background(), userMessage)
 	assert.NoError(t, err)
 	assert.NotNil(t, logOutgoing)
 	assert.NotNil(t, logIncoming)
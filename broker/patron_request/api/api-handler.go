@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
 	"io"
 	"net/http"
 	"reflect"
+	"slices"
 	"strings"
 	"time"
 
@@ -15,12 +17,15 @@ import (
 	"github.com/indexdata/cql-go/cqlbuilder"
 	"github.com/indexdata/crosslink/broker/api"
 	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/email"
 	"github.com/indexdata/crosslink/broker/events"
 	"github.com/indexdata/crosslink/broker/handler"
 	"github.com/indexdata/crosslink/broker/oapi"
 	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
 	"github.com/indexdata/crosslink/broker/patron_request/proapi"
 	prservice "github.com/indexdata/crosslink/broker/patron_request/service"
+	prtemplate "github.com/indexdata/crosslink/broker/patron_request/template"
+	psservice "github.com/indexdata/crosslink/broker/pullslip/service"
 	"github.com/indexdata/crosslink/broker/tenant"
 	"github.com/indexdata/crosslink/iso18626"
 	"github.com/indexdata/go-utils/utils"
@@ -34,6 +39,11 @@ type ActionTaskProcessor interface {
 	ProcessInvokeActionTask(ctx common.ExtendedContext, event events.Event) (events.Event, error)
 }
 
+type TemplatePreviewService interface {
+	GetTemplateData(ctx common.ExtendedContext, pr pr_db.PatronRequest) (prtemplate.Data, error)
+	SendTemplateEmail(ctx common.ExtendedContext, symbol string, emailData email.EmailData) ([]string, error)
+}
+
 var illRequestValidator = validator.New(validator.WithRequiredStructEnabled())
 var brokerSymbol = utils.GetEnv("BROKER_SYMBOL", "ISIL:BROKER")
 var errInvalidPatronRequest = errors.New("invalid patron request")
@@ -48,6 +58,7 @@ type PatronRequestApiHandler struct {
 	actionTaskProcessor  ActionTaskProcessor
 	tenantResolver       *tenant.TenantResolver
 	notificationSender   prservice.PatronRequestNotificationService
+	templatePreview      TemplatePreviewService
 }
 
 func NewPrApiHandler(prRepo pr_db.PrRepo, eventBus events.EventBus,
@@ -71,6 +82,10 @@ func (a *PatronRequestApiHandler) SetActionTaskProcessor(actionTaskProcessor Act
 	a.actionTaskProcessor = actionTaskProcessor
 }
 
+func (a *PatronRequestApiHandler) SetTemplatePreviewService(templatePreview TemplatePreviewService) {
+	a.templatePreview = templatePreview
+}
+
 func decodeRequiredBody[T any](r *http.Request, dst *T) error {
 	if r.Body == nil || r.Body == http.NoBody {
 		return errors.New("body is required")
@@ -516,6 +531,14 @@ func (a *PatronRequestApiHandler) PutPatronRequestsId(w http.ResponseWriter, r *
 		patron := existingPr.Patron.String
 		newPr.Patron = &patron
 	}
+	if newPr.PatronLanguage == nil && existingPr.PatronLanguage.Valid {
+		patronLanguage := existingPr.PatronLanguage.String
+		newPr.PatronLanguage = &patronLanguage
+	}
+	if newPr.PatronChannels == nil && existingPr.PatronChannels != nil {
+		patronChannels := toApiChannels(existingPr.PatronChannels)
+		newPr.PatronChannels = &patronChannels
+	}
 	illRequest, requesterReqId, err := a.parseAndValidateIllRequest(ctx, &newPr, creationTime)
 	if err != nil {
 		if errors.Is(err, errInvalidPatronRequest) {
@@ -535,6 +558,8 @@ func (a *PatronRequestApiHandler) PutPatronRequestsId(w http.ResponseWriter, r *
 	existingPr.IllRequest = illRequest
 	existingPr.StateModel = stateModelName
 	existingPr.Patron = getDbText(newPr.Patron)
+	existingPr.PatronLanguage = getDbText(newPr.PatronLanguage)
+	existingPr.PatronChannels = toDbChannels(newPr.PatronChannels)
 	if newPr.InternalNote != nil {
 		var note pgtype.Text
 		if trimmed := strings.TrimSpace(*newPr.InternalNote); trimmed != "" {
@@ -774,7 +799,7 @@ func (a *PatronRequestApiHandler) invokeActionAndWriteResponse(w http.ResponseWr
 		api.AddInternalError(ctx, w, errors.New("action task processor not configured"))
 		return
 	}
-	eventId, err := a.eventBus.CreateTask(prID, events.EventNameInvokeAction, data, events.EventDomainPatronRequest, nil, events.SignalConsumers)
+	eventId, err := a.eventBus.CreateTask(ctx, prID, events.EventNameInvokeAction, data, events.EventDomainPatronRequest, nil, events.SignalConsumers)
 	if err != nil {
 		api.AddInternalError(ctx, w, err)
 		return
@@ -854,6 +879,41 @@ func (a *PatronRequestApiHandler) GetPatronRequestsIdEvents(w http.ResponseWrite
 	api.WriteJsonResponse(w, resp)
 }
 
+func (a *PatronRequestApiHandler) GetPatronRequestsIdTimeline(w http.ResponseWriter, r *http.Request, id string, params proapi.GetPatronRequestsIdTimelineParams) {
+	logParams := map[string]string{"method": "GetPatronRequestsIdTimeline", "id": id}
+	if params.Side != nil {
+		logParams["side"] = *params.Side
+	}
+	ctx := common.CreateExtCtxWithArgs(r.Context(), &common.LoggerArgs{Other: logParams})
+	if events.IsSyntheticID(id) {
+		api.AddBadRequestError(ctx, w, errors.New("synthetic IDs are not allowed for timeline lookup"))
+		return
+	}
+
+	tenant, err := a.tenantResolver.Resolve(ctx, r, params.Symbol)
+	if err != nil {
+		api.AddBadRequestError(ctx, w, err)
+		return
+	}
+	symbol, err := tenant.GetRequestSymbol()
+	if err != nil {
+		api.AddBadRequestError(ctx, w, err)
+		return
+	}
+	logParams["symbol"] = symbol
+	ctx = common.CreateExtCtxWithArgs(r.Context(), &common.LoggerArgs{Other: logParams})
+	pr := a.getOwnedPatronRequest(w, ctx, id, params.Side, tenant)
+	if pr == nil {
+		return
+	}
+	eventsList, err := a.eventRepo.GetPatronRequestEvents(ctx, pr.ID)
+	if err != nil {
+		api.AddInternalError(ctx, w, err)
+		return
+	}
+	api.WriteJsonResponse(w, api.ToApiTimeline(eventsList))
+}
+
 func (a *PatronRequestApiHandler) GetPatronRequestsIdItems(w http.ResponseWriter, r *http.Request, id string, params proapi.GetPatronRequestsIdItemsParams) {
 	logParams := map[string]string{"method": "GetPatronRequestsIdItems", "id": id}
 	if params.Side != nil {
@@ -934,6 +994,12 @@ func (a *PatronRequestApiHandler) GetPatronRequestsIdNotifications(w http.Respon
 		return
 	}
 
+	emails, err := a.getOutboxMessages(ctx, list)
+	if err != nil {
+		api.AddInternalError(ctx, w, err)
+		return
+	}
+
 	responseList := make([]proapi.PrNotification, 0, len(list))
 	for _, n := range list {
 		apiN, inErr := toApiNotification(n)
@@ -941,6 +1007,9 @@ func (a *PatronRequestApiHandler) GetPatronRequestsIdNotifications(w http.Respon
 			api.AddInternalError(ctx, w, inErr)
 			return
 		}
+		if message, ok := emails[n.ID]; ok {
+			apiN.Email = toApiEmail(message)
+		}
 		responseList = append(responseList, apiN)
 	}
 	resp := proapi.PrNotifications{Items: responseList}
@@ -948,6 +1017,28 @@ func (a *PatronRequestApiHandler) GetPatronRequestsIdNotifications(w http.Respon
 	api.WriteJsonResponse(w, resp)
 }
 
+// getOutboxMessages returns the outbox messages of the email, sms and push notifications, by notification id.
+func (a *PatronRequestApiHandler) getOutboxMessages(ctx common.ExtendedContext, notifications []pr_db.Notification) (map[string]pr_db.OutboxMessage, error) {
+	var ids []string
+	for _, n := range notifications {
+		if n.Kind == pr_db.NotificationKindEmail || n.Kind == pr_db.NotificationKindSms || n.Kind == pr_db.NotificationKindPush {
+			ids = append(ids, n.ID)
+		}
+	}
+	emails := map[string]pr_db.OutboxMessage{}
+	if len(ids) == 0 {
+		return emails, nil
+	}
+	messages, err := a.prRepo.GetOutboxMessagesByNotificationIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, message := range messages {
+		emails[message.NotificationID.String] = message
+	}
+	return emails, nil
+}
+
 func (a *PatronRequestApiHandler) PostPatronRequestsIdNotifications(w http.ResponseWriter, r *http.Request, id string, params proapi.PostPatronRequestsIdNotificationsParams) {
 	logParams := map[string]string{"method": "PostPatronRequestsIdNotifications", "id": id}
 	if params.Side != nil {
@@ -1131,6 +1222,16 @@ func (a *PatronRequestApiHandler) PostTemplates(w http.ResponseWriter, r *http.R
 		api.AddBadRequestError(ctx, w, err)
 		return
 	}
+	err = validateTemplate(string(template.Purpose), string(template.ContentType), template.Subject, template.Body)
+	if err != nil {
+		api.AddBadRequestError(ctx, w, err)
+		return
+	}
+	language, err := normalizeLanguage(template.Language)
+	if err != nil {
+		api.AddBadRequestError(ctx, w, err)
+		return
+	}
 	tem, err := a.prRepo.SaveTemplate(ctx, pr_db.SaveTemplateParams{
 		ID:          uuid.NewString(),
 		Owner:       symbol,
@@ -1141,6 +1242,7 @@ func (a *PatronRequestApiHandler) PostTemplates(w http.ResponseWriter, r *http.R
 		Labels:      template.Labels,
 		Subject:     getDbText(template.Subject),
 		Audience:    getDbText((*string)(template.Audience)),
+		Language:    getDbText(language),
 		CreatedAt:   pgtype.Timestamp{Time: time.Now(), Valid: true},
 	})
 	if err != nil {
@@ -1185,6 +1287,16 @@ func (a *PatronRequestApiHandler) PutTemplatesId(w http.ResponseWriter, r *http.
 		api.AddBadRequestError(ctx, w, err)
 		return
 	}
+	err = validateTemplate(tem.Purpose, string(updated.ContentType), updated.Subject, updated.Body)
+	if err != nil {
+		api.AddBadRequestError(ctx, w, err)
+		return
+	}
+	language, err := normalizeLanguage(updated.Language)
+	if err != nil {
+		api.AddBadRequestError(ctx, w, err)
+		return
+	}
 	tem.Body = updated.Body
 	tem.ContentType = string(updated.ContentType)
 	tem.Labels = updated.Labels
@@ -1192,6 +1304,7 @@ func (a *PatronRequestApiHandler) PutTemplatesId(w http.ResponseWriter, r *http.
 	// PUT replaces optional fields; omitted values are cleared.
 	tem.Audience = getDbText((*string)(updated.Audience))
 	tem.Subject = getDbText(updated.Subject)
+	tem.Language = getDbText(language)
 	template, err := a.prRepo.SaveTemplate(ctx, pr_db.SaveTemplateParams(*tem))
 	if err != nil {
 		api.AddInternalError(ctx, w, err)
@@ -1200,6 +1313,169 @@ func (a *PatronRequestApiHandler) PutTemplatesId(w http.ResponseWriter, r *http.
 	api.WriteJsonResponse(w, toApiTemplate(template))
 }
 
+// validateTemplate validates a template's subject and body, templates with purpose sms have content type sms and vice versa.
+func validateTemplate(purpose string, contentType string, subject *string, body string) error {
+	if (contentType == string(proapi.TemplateContentTypeSms)) != (purpose == string(proapi.TemplatePurposeSms)) {
+		return errors.New("sms templates must have purpose sms and content type sms")
+	}
+	return prtemplate.ValidateTemplate(contentType, subject, body)
+}
+
+func (a *PatronRequestApiHandler) PostTemplatesPreview(w http.ResponseWriter, r *http.Request, params proapi.PostTemplatesPreviewParams) {
+	logParams := map[string]string{"method": "PostTemplatesPreview"}
+	ctx := common.CreateExtCtxWithArgs(r.Context(), &common.LoggerArgs{Other: logParams})
+	tenant, err := a.tenantResolver.Resolve(ctx, r, params.Symbol)
+	if err != nil {
+		api.AddBadRequestError(ctx, w, err)
+		return
+	}
+	symbol, err := tenant.GetRequestSymbol()
+	if err != nil {
+		api.AddBadRequestError(ctx, w, err)
+		return
+	}
+	var preview proapi.TemplatePreviewRequest
+	err = decodeRequiredBody(r, &preview)
+	if err != nil {
+		api.AddBadRequestError(ctx, w, err)
+		return
+	}
+	asPdf := preview.Pdf != nil && *preview.Pdf
+	send := preview.Send != nil && *preview.Send
+	template := a.getPreviewTemplate(w, ctx, preview, symbol)
+	if template == nil {
+		return
+	}
+	if send && (template.Purpose != proapi.TemplatePurposeEmail || template.ContentType == proapi.TemplateContentTypeSms) {
+		api.AddBadRequestError(ctx, w, errors.New("only email templates can be sent"))
+		return
+	}
+	unknown, err := getUnknownPlaceholders(template)
+	if err != nil {
+		api.AddBadRequestError(ctx, w, err)
+		return
+	}
+	result := proapi.TemplatePreview{ContentType: template.ContentType, UnknownPlaceholders: unknown}
+	if len(unknown) > 0 {
+		if asPdf || send {
+			api.AddBadRequestError(ctx, w, fmt.Errorf("unknown placeholders: %s", strings.Join(unknown, ", ")))
+			return
+		}
+		api.WriteJsonResponse(w, result)
+		return
+	}
+	data := prtemplate.SampleData()
+	if preview.PatronRequestId != nil {
+		pr := a.getOwnedPatronRequest(w, ctx, *preview.PatronRequestId, nil, tenant)
+		if pr == nil {
+			return
+		}
+		data, err = a.templatePreview.GetTemplateData(ctx, *pr)
+		if err != nil {
+			api.AddInternalError(ctx, w, err)
+			return
+		}
+	}
+	if template.Subject != nil {
+		subject, renderErr := prtemplate.Render(string(proapi.TemplateContentTypeText), *template.Subject, data)
+		if renderErr != nil {
+			api.AddBadRequestError(ctx, w, fmt.Errorf("invalid subject: %w", renderErr))
+			return
+		}
+		result.Subject = &subject
+	}
+	body, err := prtemplate.Render(string(template.ContentType), template.Body, data)
+	if err != nil {
+		api.AddBadRequestError(ctx, w, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+	result.Body = &body
+	if send {
+		subject := ""
+		if result.Subject != nil {
+			subject = *result.Subject
+		}
+		sentTo, sendErr := a.templatePreview.SendTemplateEmail(ctx, symbol, email.EmailData{
+			Subject: subject,
+			Body:    body,
+			IsHTML:  template.ContentType == proapi.TemplateContentTypeHtml,
+		})
+		if sendErr != nil {
+			api.AddInternalError(ctx, w, sendErr)
+			return
+		}
+		result.SentTo = &sentTo
+	}
+	if asPdf {
+		if template.ContentType != proapi.TemplateContentTypeHtml {
+			body = "<pre>" + html.EscapeString(body) + "</pre>"
+		}
+		// Labels and book bands are previewed on their own page size, other templates on A4 like a pull slip
+		doc, docErr := psservice.ParseDocument(string(template.Purpose))
+		if docErr != nil {
+			doc = psservice.DocumentPullSlip
+		}
+		pdf, pdfErr := doc.HtmlToPdf(body)
+		if pdfErr != nil {
+			api.AddInternalError(ctx, w, pdfErr)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", `inline; filename="template-preview.pdf"`)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(pdf) // #nosec G705 -- content is a generated PDF binary; Content-Type is set to application/pdf
+		return
+	}
+	api.WriteJsonResponse(w, result)
+}
+
+// getPreviewTemplate returns the unsaved template of a preview request or loads the stored one.
+func (a *PatronRequestApiHandler) getPreviewTemplate(w http.ResponseWriter, ctx common.ExtendedContext, preview proapi.TemplatePreviewRequest, symbol string) *proapi.CreateTemplate {
+	if preview.TemplateId == nil {
+		if preview.Template == nil {
+			api.AddBadRequestError(ctx, w, errors.New("templateId or template must be specified"))
+		}
+		return preview.Template
+	}
+	tem, err := a.prRepo.GetTemplateByIdAndOwner(ctx, *preview.TemplateId, symbol)
+	if err != nil {
+		handleDbError(w, ctx, err)
+		return nil
+	}
+	template := proapi.CreateTemplate{
+		Title:       tem.Title,
+		Purpose:     proapi.TemplatePurpose(tem.Purpose),
+		Body:        tem.Body,
+		ContentType: proapi.TemplateContentType(tem.ContentType),
+		Labels:      tem.Labels,
+	}
+	if tem.Subject.Valid {
+		template.Subject = &tem.Subject.String
+	}
+	return &template
+}
+
+func getUnknownPlaceholders(template *proapi.CreateTemplate) ([]string, error) {
+	unknown := []string{}
+	if template.Subject != nil {
+		subjectUnknown, err := prtemplate.UnknownPlaceholders(*template.Subject)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subject: %w", err)
+		}
+		unknown = append(unknown, subjectUnknown...)
+	}
+	bodyUnknown, err := prtemplate.UnknownPlaceholders(template.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body: %w", err)
+	}
+	for _, placeholder := range bodyUnknown {
+		if !slices.Contains(unknown, placeholder) {
+			unknown = append(unknown, placeholder)
+		}
+	}
+	return unknown, nil
+}
+
 func (a *PatronRequestApiHandler) getTemplateById(w http.ResponseWriter, r *http.Request, id string, symbolString *string, methodName string) (common.ExtendedContext, *pr_db.Template) {
 	logParams := map[string]string{"method": methodName, "id": id}
 	ctx := common.CreateExtCtxWithArgs(r.Context(), &common.LoggerArgs{Other: logParams})
@@ -1242,6 +1518,9 @@ func toApiTemplate(template pr_db.Template) proapi.Template {
 		audience := proapi.TemplateAudience(template.Audience.String)
 		t.Audience = &audience
 	}
+	if template.Language.Valid {
+		t.Language = &template.Language.String
+	}
 	if template.UpdatedAt.Valid {
 		t.UpdatedAt = &template.UpdatedAt.Time
 	}
@@ -1282,6 +1561,8 @@ func toApiPatronRequest(r *http.Request, request pr_db.PatronRequestSearchView)
 		StateModel:               request.StateModel,
 		Side:                     string(request.Side),
 		Patron:                   toString(request.Patron),
+		PatronLanguage:           toString(request.PatronLanguage),
+		PatronChannels:           toApiChannelsPtr(request.PatronChannels),
 		RequesterSymbol:          toString(request.RequesterSymbol),
 		SupplierSymbol:           toString(request.SupplierSymbol),
 		IllRequest:               request.IllRequest,
@@ -1323,6 +1604,14 @@ func toString(text pgtype.Text) *string {
 	return value
 }
 
+func toTime(timestamp pgtype.Timestamp) *time.Time {
+	var value *time.Time
+	if timestamp.Valid {
+		value = &timestamp.Time
+	}
+	return value
+}
+
 func (a *PatronRequestApiHandler) parseAndValidateIllRequest(
 	ctx common.ExtendedContext,
 	request *proapi.CreatePatronRequest,
@@ -1335,6 +1624,17 @@ func (a *PatronRequestApiHandler) parseAndValidateIllRequest(
 	if err != nil {
 		return iso18626.Request{}, "", fmt.Errorf("%w: requesterSymbol: %w", errInvalidPatronRequest, err)
 	}
+	request.PatronLanguage, err = normalizeLanguage(request.PatronLanguage)
+	if err != nil {
+		return iso18626.Request{}, "", fmt.Errorf("%w: patronLanguage: %w", errInvalidPatronRequest, err)
+	}
+	if request.PatronChannels != nil {
+		for _, channel := range *request.PatronChannels {
+			if !channel.Valid() {
+				return iso18626.Request{}, "", fmt.Errorf("%w: patronChannels: invalid channel %q", errInvalidPatronRequest, channel)
+			}
+		}
+	}
 	var requesterReqId string
 	if request.Id != nil {
 		requesterReqId = *request.Id
@@ -1367,6 +1667,48 @@ func getId(id string) string {
 	return id
 }
 
+// normalizeLanguage returns the canonical form of an optional language tag, an empty tag is treated as absent.
+func normalizeLanguage(tag *string) (*string, error) {
+	if tag == nil || strings.TrimSpace(*tag) == "" {
+		return nil, nil
+	}
+	normalized, err := prtemplate.NormalizeLanguage(*tag)
+	if err != nil {
+		return nil, err
+	}
+	return &normalized, nil
+}
+
+// toDbChannels returns the distinct channels in order, nil if they are not set.
+func toDbChannels(channels *[]proapi.NotificationChannel) []string {
+	if channels == nil {
+		return nil
+	}
+	values := []string{}
+	for _, channel := range *channels {
+		if !slices.Contains(values, string(channel)) {
+			values = append(values, string(channel))
+		}
+	}
+	return values
+}
+
+func toApiChannels(channels []string) []proapi.NotificationChannel {
+	values := make([]proapi.NotificationChannel, 0, len(channels))
+	for _, channel := range channels {
+		values = append(values, proapi.NotificationChannel(channel))
+	}
+	return values
+}
+
+func toApiChannelsPtr(channels []string) *[]proapi.NotificationChannel {
+	if channels == nil {
+		return nil
+	}
+	values := toApiChannels(channels)
+	return &values
+}
+
 func getDbText(value *string) pgtype.Text {
 	if value == nil {
 		return pgtype.Text{Valid: false}
@@ -1438,6 +1780,8 @@ func buildDbPatronRequest(
 		State:           initialState,
 		Side:            prservice.SideBorrowing,
 		Patron:          getDbText(request.Patron),
+		PatronLanguage:  getDbText(request.PatronLanguage),
+		PatronChannels:  toDbChannels(request.PatronChannels),
 		RequesterSymbol: getDbText(request.RequesterSymbol),
 		SupplierSymbol:  getDbText(nil),
 		IllRequest:      illRequest,
@@ -1521,6 +1865,19 @@ func toApiNotification(notification pr_db.Notification) (proapi.PrNotification,
 	}, nil
 }
 
+func toApiEmail(message pr_db.OutboxMessage) *proapi.PrEmail {
+	return &proapi.PrEmail{
+		From:          message.FromAddress,
+		Recipients:    message.Recipients,
+		Status:        proapi.PrEmailStatus(message.Status),
+		Attempts:      message.Attempts,
+		NextAttemptAt: toTime(message.NextAttemptAt),
+		LastAttemptAt: toTime(message.LastAttemptAt),
+		SentAt:        toTime(message.SentAt),
+		Error:         toString(message.Error),
+	}
+}
+
 func toDbNotification(create proapi.CreatePrNotification, pr pr_db.PatronRequest) pr_db.Notification {
 	fromSymbol := pr.RequesterSymbol.String
 	toSymbol := pr.SupplierSymbol.String
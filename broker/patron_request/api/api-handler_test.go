@@ -17,11 +17,14 @@ import (
 	"github.com/google/uuid"
 	"github.com/indexdata/cql-go/pgcql"
 	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/email"
 	"github.com/indexdata/crosslink/broker/events"
 	"github.com/indexdata/crosslink/broker/handler"
+	"github.com/indexdata/crosslink/broker/oapi"
 	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
 	"github.com/indexdata/crosslink/broker/patron_request/proapi"
 	prservice "github.com/indexdata/crosslink/broker/patron_request/service"
+	prtemplate "github.com/indexdata/crosslink/broker/patron_request/template"
 	"github.com/indexdata/crosslink/broker/tenant"
 	"github.com/indexdata/crosslink/broker/test/mocks"
 	"github.com/indexdata/crosslink/iso18626"
@@ -395,6 +398,53 @@ func TestPostPatronRequestsInvalidIllRequestShape(t *testing.T) {
 	assert.Contains(t, rr.Body.String(), "illRequest")
 }
 
+func TestPostPatronRequestsInvalidPatronLanguage(t *testing.T) {
+	handler := NewPrApiHandler(new(PrRepoError), mockEventBus, mockEventRepo, tenant.NewResolver(), nil, 10)
+	id := "1"
+	patronLanguage := "not a language"
+	jsonBytes, err := json.Marshal(proapi.CreatePatronRequest{
+		Id:              &id,
+		RequesterSymbol: &symbol,
+		PatronLanguage:  &patronLanguage,
+		IllRequest:      validIllRequest(),
+	})
+	assert.NoError(t, err)
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(jsonBytes))
+	rr := httptest.NewRecorder()
+	tenant := proapi.Tenant("test-lib")
+	handler.PostPatronRequests(rr, req, proapi.PostPatronRequestsParams{XOkapiTenant: &tenant})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "patronLanguage")
+}
+
+func TestPostPatronRequestsInvalidPatronChannels(t *testing.T) {
+	handler := NewPrApiHandler(new(PrRepoError), mockEventBus, mockEventRepo, tenant.NewResolver(), nil, 10)
+	id := "1"
+	jsonBytes, err := json.Marshal(proapi.CreatePatronRequest{
+		Id:              &id,
+		RequesterSymbol: &symbol,
+		PatronChannels:  &[]proapi.NotificationChannel{proapi.NotificationChannelSms, "fax"},
+		IllRequest:      validIllRequest(),
+	})
+	assert.NoError(t, err)
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(jsonBytes))
+	rr := httptest.NewRecorder()
+	tenant := proapi.Tenant("test-lib")
+	handler.PostPatronRequests(rr, req, proapi.PostPatronRequestsParams{XOkapiTenant: &tenant})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "patronChannels: invalid channel")
+}
+
+func TestPatronChannels(t *testing.T) {
+	channels := []proapi.NotificationChannel{proapi.NotificationChannelSms, proapi.NotificationChannelEmail, proapi.NotificationChannelSms}
+	assert.Equal(t, []string{"sms", "email"}, toDbChannels(&channels))
+	assert.Nil(t, toDbChannels(nil))
+	assert.Equal(t, []string{}, toDbChannels(&[]proapi.NotificationChannel{}))
+
+	assert.Equal(t, &[]proapi.NotificationChannel{proapi.NotificationChannelSms, proapi.NotificationChannelEmail}, toApiChannelsPtr([]string{"sms", "email"}))
+	assert.Nil(t, toApiChannelsPtr(nil))
+}
+
 func TestDeletePatronRequestsIdNotFound(t *testing.T) {
 	handler := NewPrApiHandler(new(PrRepoError), mockEventBus, mockEventRepo, tenant.NewResolver(), nil, 10)
 	req, _ := http.NewRequest("POST", "/", nil)
@@ -748,6 +798,48 @@ func TestGetPatronRequestsIdEventsErrorGettingEvents(t *testing.T) {
 	assert.Contains(t, rr.Body.String(), "DB error")
 }
 
+func TestGetPatronRequestsIdTimeline(t *testing.T) {
+	handler := NewPrApiHandler(new(PrRepoError), mockEventBus, mockEventRepo, tenant.NewResolver(), nil, 10)
+	req, _ := http.NewRequest("GET", "/", nil)
+	rr := httptest.NewRecorder()
+	handler.GetPatronRequestsIdTimeline(rr, req, "3", proapi.GetPatronRequestsIdTimelineParams{Symbol: &symbol, Side: &proapiBorrowingSide})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var timeline oapi.Timeline
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &timeline))
+	assert.Equal(t, int64(1), timeline.About.Count)
+	assert.Equal(t, oapi.TimelineEntryTypeEvent, timeline.Items[0].Type)
+}
+
+func TestGetPatronRequestsIdTimelineRejectsSyntheticIDs(t *testing.T) {
+	handler := NewPrApiHandler(nil, nil, nil, nil, nil, 10)
+	for _, id := range []string{events.DEFAULT_ILL_TRANSACTION_ID, events.DEFAULT_PATRON_REQUEST_ID} {
+		t.Run(id, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/patron_requests/"+id+"/timeline", nil)
+			rr := httptest.NewRecorder()
+			handler.GetPatronRequestsIdTimeline(rr, req, id, proapi.GetPatronRequestsIdTimelineParams{})
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Contains(t, rr.Body.String(), "synthetic IDs are not allowed")
+		})
+	}
+}
+
+func TestGetPatronRequestsIdTimelineNotFoundBecauseOfSide(t *testing.T) {
+	handler := NewPrApiHandler(new(PrRepoError), mockEventBus, mockEventRepo, tenant.NewResolver(), nil, 10)
+	req, _ := http.NewRequest("GET", "/", nil)
+	rr := httptest.NewRecorder()
+	handler.GetPatronRequestsIdTimeline(rr, req, "3", proapi.GetPatronRequestsIdTimelineParams{Symbol: &symbol, Side: &proapiLendingSide})
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestGetPatronRequestsIdTimelineErrorGettingEvents(t *testing.T) {
+	handler := NewPrApiHandler(new(PrRepoError), mockEventBus, new(mocks.MockEventRepositoryError), tenant.NewResolver(), nil, 10)
+	req, _ := http.NewRequest("GET", "/", nil)
+	rr := httptest.NewRecorder()
+	handler.GetPatronRequestsIdTimeline(rr, req, "3", proapi.GetPatronRequestsIdTimelineParams{Symbol: &symbol, Side: &proapiBorrowingSide})
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Contains(t, rr.Body.String(), "DB error")
+}
+
 func TestGetPatronRequestsIdNotificationsNoSymbol(t *testing.T) {
 	repo := &PrRepoNotificationsCapture{}
 	handler := NewPrApiHandler(repo, mockEventBus, mockEventRepo, tenant.NewResolver(), nil, 10)
@@ -847,6 +939,86 @@ func TestGetPatronRequestsIdNotificationsWithKindFilter(t *testing.T) {
 	assert.Equal(t, int64(1), response.About.Count)
 }
 
+func TestGetPatronRequestsIdNotificationsWithEmail(t *testing.T) {
+	now := time.Now()
+	repo := &PrRepoNotificationsCapture{
+		notifications: []pr_db.Notification{
+			{
+				ID:         "n-note-1",
+				PrID:       "3",
+				FromSymbol: "ISIL:REQ",
+				ToSymbol:   "ISIL:SUP",
+				Direction:  pr_db.NotificationDirectionSent,
+				Kind:       pr_db.NotificationKindNote,
+				CreatedAt:  pgtype.Timestamp{Time: now, Valid: true},
+			},
+			{
+				ID:         "n-email-1",
+				PrID:       "3",
+				FromSymbol: "ISIL:REQ",
+				ToSymbol:   "patron@example.com",
+				Direction:  pr_db.NotificationDirectionSent,
+				Kind:       pr_db.NotificationKindEmail,
+				Note:       pgtype.Text{String: "Your request", Valid: true},
+				CreatedAt:  pgtype.Timestamp{Time: now, Valid: true},
+			},
+		},
+		fullCount: 2,
+		emails: []pr_db.OutboxMessage{{
+			ID:             "email-1",
+			NotificationID: pgtype.Text{String: "n-email-1", Valid: true},
+			FromAddress:    "library@example.com",
+			Recipients:     []string{"patron@example.com"},
+			Status:         pr_db.OutboxMessageStatusQueued,
+			Attempts:       2,
+			NextAttemptAt:  pgtype.Timestamp{Time: now.Add(time.Minute), Valid: true},
+			LastAttemptAt:  pgtype.Timestamp{Time: now, Valid: true},
+			Error:          pgtype.Text{String: "connection refused", Valid: true},
+		}},
+	}
+	handler := NewPrApiHandler(repo, mockEventBus, mockEventRepo, tenant.NewResolver(), nil, 10)
+	req, _ := http.NewRequest("GET", "/", nil)
+	rr := httptest.NewRecorder()
+
+	handler.GetPatronRequestsIdNotifications(rr, req, "3", proapi.GetPatronRequestsIdNotificationsParams{Symbol: &symbol, Side: &proapiBorrowingSide})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response proapi.PrNotifications
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	if assert.Len(t, response.Items, 2) {
+		assert.Nil(t, response.Items[0].Email)
+		assert.Equal(t, proapi.PrNotificationKindEmail, response.Items[1].Kind)
+		if assert.NotNil(t, response.Items[1].Email) {
+			email := response.Items[1].Email
+			assert.Equal(t, "library@example.com", email.From)
+			assert.Equal(t, []string{"patron@example.com"}, email.Recipients)
+			assert.Equal(t, proapi.Queued, email.Status)
+			assert.Equal(t, int32(2), email.Attempts)
+			assert.NotNil(t, email.NextAttemptAt)
+			assert.NotNil(t, email.LastAttemptAt)
+			assert.Nil(t, email.SentAt)
+			assert.Equal(t, "connection refused", *email.Error)
+		}
+	}
+}
+
+func TestGetPatronRequestsIdNotificationsEmailError(t *testing.T) {
+	repo := &PrRepoNotificationsCapture{
+		notifications: []pr_db.Notification{{ID: "n-email-1", PrID: "3", Kind: pr_db.NotificationKindEmail}},
+		fullCount:     1,
+		emailsErr:     errors.New("DB error"),
+	}
+	handler := NewPrApiHandler(repo, mockEventBus, mockEventRepo, tenant.NewResolver(), nil, 10)
+	req, _ := http.NewRequest("GET", "/", nil)
+	rr := httptest.NewRecorder()
+
+	handler.GetPatronRequestsIdNotifications(rr, req, "3", proapi.GetPatronRequestsIdNotificationsParams{Symbol: &symbol, Side: &proapiBorrowingSide})
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Contains(t, rr.Body.String(), "DB error")
+}
+
 func TestParseAndValidateIllRequestAndBuildDbPatronRequest(t *testing.T) {
 	handler := NewPrApiHandler(new(PrRepoError), mockEventBus, mockEventRepo, tenant.NewResolver(), nil, 10)
 	ctx := common.CreateExtCtxWithArgs(context.Background(), &common.LoggerArgs{})
@@ -1081,7 +1253,7 @@ func TestGetStateModelBatchActions(t *testing.T) {
 	var actions []proapi.BatchActionDefault
 	err := json.Unmarshal(rr.Body.Bytes(), &actions)
 	assert.NoError(t, err)
-	assert.Len(t, actions, 4)
+	assert.Len(t, actions, 7)
 	// Clients key translated titles off titleKey, so each default needs a distinct one.
 	seen := map[string]bool{}
 	for _, action := range actions {
@@ -1100,7 +1272,7 @@ func TestGetStateModelTemplates(t *testing.T) {
 	var templates []proapi.CreateTemplate
 	err := json.Unmarshal(rr.Body.Bytes(), &templates)
 	assert.NoError(t, err)
-	assert.Len(t, templates, 5)
+	assert.Len(t, templates, 9)
 	labels := make([]string, 0, len(templates))
 	for _, template := range templates {
 		assert.NotEmpty(t, template.Title)
@@ -1113,6 +1285,10 @@ func TestGetStateModelTemplates(t *testing.T) {
 		"cancelled-notification",
 		"new-supply-request-notification",
 		"pullslip-email",
+		"overdue-first-notice",
+		"overdue-second-notice",
+		"overdue-final-notice",
+		"report-email",
 	}, labels)
 }
 
@@ -1177,6 +1353,8 @@ type PrRepoNotificationsCapture struct {
 	lastParams    *pr_db.GetNotificationsByPrIdParams
 	notifications []pr_db.Notification
 	fullCount     int64
+	emails        []pr_db.OutboxMessage
+	emailsErr     error
 }
 
 func (r *PrRepoCapture) ListPatronRequests(ctx common.ExtendedContext, args pr_db.ListPatronRequestsParams, pgcql pgcql.Query) ([]pr_db.PatronRequest, int64, error) {
@@ -1195,6 +1373,10 @@ func (r *PrRepoNotificationsCapture) GetNotificationsByPrId(ctx common.ExtendedC
 	return r.notifications, r.fullCount, nil
 }
 
+func (r *PrRepoNotificationsCapture) GetOutboxMessagesByNotificationIds(ctx common.ExtendedContext, notificationIds []string) ([]pr_db.OutboxMessage, error) {
+	return r.emails, r.emailsErr
+}
+
 func (r *PrRepoError) WithTxFunc(ctx common.ExtendedContext, fn func(repo pr_db.PrRepo) error) error {
 	return fn(r)
 }
@@ -1297,7 +1479,7 @@ type MockEventBus struct {
 	events.EventBus
 }
 
-func (h *MockEventBus) CreateTask(id string, eventName events.EventName, data events.EventData, eventDomain events.EventDomain, parentId *string, target events.SignalTarget) (string, error) {
+func (h *MockEventBus) CreateTask(ctx common.ExtendedContext, id string, eventName events.EventName, data events.EventData, eventDomain events.EventDomain, parentId *string, target events.SignalTarget) (string, error) {
 	return "", errors.New("DB error")
 }
 
@@ -1307,7 +1489,7 @@ type MockEventBusCapture struct {
 	lastData      events.EventData
 }
 
-func (h *MockEventBusCapture) CreateTask(id string, eventName events.EventName, data events.EventData, eventDomain events.EventDomain, parentId *string, target events.SignalTarget) (string, error) {
+func (h *MockEventBusCapture) CreateTask(ctx common.ExtendedContext, id string, eventName events.EventName, data events.EventData, eventDomain events.EventDomain, parentId *string, target events.SignalTarget) (string, error) {
 	h.lastEventName = eventName
 	h.lastData = data
 	return uuid.NewString(), nil
@@ -1688,3 +1870,252 @@ func TestPutPatronRequestsIdInvalidBrokerSymbol(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, rr.Code)
 	assert.Contains(t, rr.Body.String(), "invalid BROKER_SYMBOL")
 }
+
+func TestPostTemplatesInvalidTemplate(t *testing.T) {
+	handler := NewPrApiHandler(new(PrRepoError), mockEventBus, mockEventRepo, tenant.NewResolver(), nil, 10)
+	subject := "Ready: {{.Request.Title"
+	jsonBytes, err := json.Marshal(proapi.CreateTemplate{
+		Title:       "Ready",
+		Purpose:     proapi.TemplatePurposeEmail,
+		ContentType: proapi.TemplateContentTypeText,
+		Subject:     &subject,
+		Labels:      []string{"ready"},
+		Body:        "Dear {{.Request.Patron}}",
+	})
+	assert.NoError(t, err)
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(jsonBytes))
+	rr := httptest.NewRecorder()
+	handler.PostTemplates(rr, req, proapi.PostTemplatesParams{Symbol: &symbol})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "invalid subject")
+}
+
+func TestPostTemplatesInvalidLanguage(t *testing.T) {
+	handler := NewPrApiHandler(new(PrRepoError), mockEventBus, mockEventRepo, tenant.NewResolver(), nil, 10)
+	language := "english please"
+	jsonBytes, err := json.Marshal(proapi.CreateTemplate{
+		Title:       "Ready",
+		Purpose:     proapi.TemplatePurposeEmail,
+		ContentType: proapi.TemplateContentTypeText,
+		Labels:      []string{"ready"},
+		Body:        "Dear {{.Request.Patron}}",
+		Language:    &language,
+	})
+	assert.NoError(t, err)
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(jsonBytes))
+	rr := httptest.NewRecorder()
+	handler.PostTemplates(rr, req, proapi.PostTemplatesParams{Symbol: &symbol})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "invalid language")
+}
+
+func TestPostTemplatesSms(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		purpose     proapi.TemplatePurpose
+		contentType proapi.TemplateContentType
+		body        string
+		msg         string
+	}{
+		{"pull slip", proapi.TemplatePurposePullslip, proapi.TemplateContentTypeSms, "{{.Request.Title}} is ready", "sms templates must have purpose sms and content type sms"},
+		{"email", proapi.TemplatePurposeEmail, proapi.TemplateContentTypeSms, "{{.Request.Title}} is ready", "sms templates must have purpose sms and content type sms"},
+		{"text", proapi.TemplatePurposeSms, proapi.TemplateContentTypeText, "{{.Request.Title}} is ready", "sms templates must have purpose sms and content type sms"},
+		{"too long", proapi.TemplatePurposeSms, proapi.TemplateContentTypeSms, strings.Repeat("x", prtemplate.MAX_SMS_LENGTH+1), "at most 459 are allowed"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewPrApiHandler(new(PrRepoError), mockEventBus, mockEventRepo, tenant.NewResolver(), nil, 10)
+			jsonBytes, err := json.Marshal(proapi.CreateTemplate{
+				Title:       "Ready",
+				Purpose:     tc.purpose,
+				ContentType: tc.contentType,
+				Labels:      []string{"ready"},
+				Body:        tc.body,
+			})
+			assert.NoError(t, err)
+			req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(jsonBytes))
+			rr := httptest.NewRecorder()
+			handler.PostTemplates(rr, req, proapi.PostTemplatesParams{Symbol: &symbol})
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Contains(t, rr.Body.String(), tc.msg)
+		})
+	}
+}
+
+func TestPostTemplatesUnknownField(t *testing.T) {
+	handler := NewPrApiHandler(new(PrRepoError), mockEventBus, mockEventRepo, tenant.NewResolver(), nil, 10)
+	jsonBytes, err := json.Marshal(proapi.CreateTemplate{
+		Title:       "Ready",
+		Purpose:     proapi.TemplatePurposeEmail,
+		ContentType: proapi.TemplateContentTypeHtml,
+		Labels:      []string{"ready"},
+		Body:        "<p>Dear {{.Request.PatronName}}</p>",
+	})
+	assert.NoError(t, err)
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(jsonBytes))
+	rr := httptest.NewRecorder()
+	handler.PostTemplates(rr, req, proapi.PostTemplatesParams{Symbol: &symbol})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "invalid body")
+	assert.Contains(t, rr.Body.String(), "PatronName")
+}
+
+type PrRepoTemplates struct {
+	PrRepoError
+}
+
+func (r *PrRepoTemplates) GetTemplateByIdAndOwner(ctx common.ExtendedContext, id string, owner string) (pr_db.Template, error) {
+	if id == "t1" && owner == symbol {
+		return pr_db.Template{
+			ID:          id,
+			Owner:       owner,
+			Purpose:     string(proapi.TemplatePurposeEmail),
+			Subject:     pgtype.Text{String: "{{.Request.Title}} is ready", Valid: true},
+			Body:        "<p>Dear {{.Request.Patron}}</p>",
+			ContentType: string(proapi.TemplateContentTypeHtml),
+		}, nil
+	}
+	return pr_db.Template{}, pgx.ErrNoRows
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+type templatePreviewMock struct {
+	data      prtemplate.Data
+	prID      string
+	sent      []email.EmailData
+	sendError error
+}
+
+func (m *templatePreviewMock) GetTemplateData(ctx common.ExtendedContext, pr pr_db.PatronRequest) (prtemplate.Data, error) {
+	m.prID = pr.ID
+	return m.data, nil
+}
+
+func (m *templatePreviewMock) SendTemplateEmail(ctx common.ExtendedContext, symbol string, emailData email.EmailData) ([]string, error) {
+	m.sent = append(m.sent, emailData)
+	if m.sendError != nil {
+		return nil, m.sendError
+	}
+	return []string{"staff@example.com"}, nil
+}
+
+func postTemplatesPreview(t *testing.T, preview proapi.TemplatePreviewRequest, previewService *templatePreviewMock) *httptest.ResponseRecorder {
+	handler := NewPrApiHandler(new(PrRepoTemplates), mockEventBus, mockEventRepo, tenant.NewResolver(), nil, 10)
+	handler.SetTemplatePreviewService(previewService)
+	jsonBytes, err := json.Marshal(preview)
+	assert.NoError(t, err)
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(jsonBytes))
+	rr := httptest.NewRecorder()
+	handler.PostTemplatesPreview(rr, req, proapi.PostTemplatesPreviewParams{Symbol: &symbol})
+	return rr
+}
+
+func TestPostTemplatesPreviewSampleData(t *testing.T) {
+	subject := "{{.Request.Title}} is ready"
+	rr := postTemplatesPreview(t, proapi.TemplatePreviewRequest{Template: &proapi.CreateTemplate{
+		Purpose:     proapi.TemplatePurposeEmail,
+		ContentType: proapi.TemplateContentTypeText,
+		Subject:     &subject,
+		Body:        "Dear {{.Request.Patron}}{{range .Request.Items}}, {{.Barcode}}{{end}}",
+	}}, &templatePreviewMock{})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var preview proapi.TemplatePreview
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &preview))
+	assert.Equal(t, "The Go Programming Language is ready", *preview.Subject)
+	assert.Equal(t, "Dear Jane Doe, 39000001234567", *preview.Body)
+	assert.Equal(t, proapi.TemplateContentTypeText, preview.ContentType)
+	assert.Empty(t, preview.UnknownPlaceholders)
+	assert.Nil(t, preview.SentTo)
+}
+
+func TestPostTemplatesPreviewUnknownPlaceholders(t *testing.T) {
+	subject := "{{.Request.Name}}"
+	template := proapi.CreateTemplate{
+		Purpose:     proapi.TemplatePurposeEmail,
+		ContentType: proapi.TemplateContentTypeText,
+		Subject:     &subject,
+		Body:        "Dear {{.Request.PatronName}}{{range .Request.Items}} {{.Shelf}}{{end}} {{.Request.Name}}",
+	}
+	rr := postTemplatesPreview(t, proapi.TemplatePreviewRequest{Template: &template}, &templatePreviewMock{})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var preview proapi.TemplatePreview
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &preview))
+	assert.Equal(t, []string{".Request.Name", ".Request.PatronName", ".Shelf"}, preview.UnknownPlaceholders)
+	assert.Nil(t, preview.Subject)
+	assert.Nil(t, preview.Body)
+
+	asPdf := true
+	rr = postTemplatesPreview(t, proapi.TemplatePreviewRequest{Template: &template, Pdf: &asPdf}, &templatePreviewMock{})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "unknown placeholders: .Request.Name, .Request.PatronName, .Shelf")
+}
+
+func TestPostTemplatesPreviewInvalid(t *testing.T) {
+	send := true
+	for _, tc := range []struct {
+		name    string
+		preview proapi.TemplatePreviewRequest
+		msg     string
+	}{
+		{"no template", proapi.TemplatePreviewRequest{}, "templateId or template must be specified"},
+		{"parse error", proapi.TemplatePreviewRequest{Template: &proapi.CreateTemplate{Body: "{{.Request.Title"}}, "invalid body"},
+		{"render error", proapi.TemplatePreviewRequest{Template: &proapi.CreateTemplate{Body: "{{formatDate .Request.Title}}"}}, "formatDate expects a date"},
+		{"pull slip sent", proapi.TemplatePreviewRequest{Send: &send, Template: &proapi.CreateTemplate{Purpose: proapi.TemplatePurposePullslip, Body: "slip"}}, "only email templates can be sent"},
+		{"sms sent", proapi.TemplatePreviewRequest{Send: &send, Template: &proapi.CreateTemplate{Purpose: proapi.TemplatePurposeSms, ContentType: proapi.TemplateContentTypeSms, Body: "x"}}, "only email templates can be sent"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := postTemplatesPreview(t, tc.preview, &templatePreviewMock{})
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Contains(t, rr.Body.String(), tc.msg)
+		})
+	}
+}
+
+func TestPostTemplatesPreviewNotFound(t *testing.T) {
+	rr := postTemplatesPreview(t, proapi.TemplatePreviewRequest{TemplateId: strPtr("t2")}, &templatePreviewMock{})
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = postTemplatesPreview(t, proapi.TemplatePreviewRequest{TemplateId: strPtr("t1"), PatronRequestId: strPtr("1")}, &templatePreviewMock{})
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func TestPostTemplatesPreviewPatronRequestAndSend(t *testing.T) {
+	previewService := &templatePreviewMock{data: prtemplate.Data{Request: prtemplate.Request{Title: "Dune", Patron: "<Paul>"}}}
+	send := true
+	rr := postTemplatesPreview(t, proapi.TemplatePreviewRequest{
+		TemplateId:      strPtr("t1"),
+		PatronRequestId: strPtr("4"),
+		Send:            &send,
+	}, previewService)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "4", previewService.prID)
+	var preview proapi.TemplatePreview
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &preview))
+	assert.Equal(t, "Dune is ready", *preview.Subject)
+	assert.Equal(t, "<p>Dear &lt;Paul&gt;</p>", *preview.Body)
+	assert.Equal(t, []string{"staff@example.com"}, *preview.SentTo)
+	assert.Equal(t, []email.EmailData{{
+		Subject: "Dune is ready",
+		Body:    "<p>Dear &lt;Paul&gt;</p>",
+		IsHTML:  true,
+	}}, previewService.sent)
+
+	previewService.sendError = errors.New("SMTP unavailable")
+	rr = postTemplatesPreview(t, proapi.TemplatePreviewRequest{TemplateId: strPtr("t1"), Send: &send}, previewService)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Contains(t, rr.Body.String(), "SMTP unavailable")
+}
+
+func TestPostTemplatesPreviewPdf(t *testing.T) {
+	asPdf := true
+	rr := postTemplatesPreview(t, proapi.TemplatePreviewRequest{Pdf: &asPdf, Template: &proapi.CreateTemplate{
+		Purpose:     proapi.TemplatePurposePullslip,
+		ContentType: proapi.TemplateContentTypeHtml,
+		Body:        "<h1>{{.Request.Title}}</h1><p>{{.Request.Hrid}}</p>",
+	}}, &templatePreviewMock{})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/pdf", rr.Header().Get("Content-Type"))
+	assert.True(t, bytes.HasPrefix(rr.Body.Bytes(), []byte("%PDF")))
+}
@@ -143,6 +143,9 @@ func ParsePatronRequestsCql(cqlString string) (pgcql.Query, error) {
 	nf = pgcql.NewFieldDate()
 	def.AddField("needed_at", nf)
 
+	nf = pgcql.NewFieldDate()
+	def.AddField("due_at", nf)
+
 	f = pgcql.NewFieldString().WithFullText(LANGUAGE).WithColumn("ill_request->'bibliographicInfo'->>'title'")
 	def.AddField("title", f)
 
@@ -319,6 +322,8 @@ func (q *Queries) ListPatronRequestsCql(ctx context.Context, db DBTX, arg ListPa
 			&i.PatronRequestSearchView.PrevReqID,
 			&i.PatronRequestSearchView.RetryBibInfo,
 			&i.PatronRequestSearchView.StateModel,
+			&i.PatronRequestSearchView.PatronLanguage,
+			&i.PatronRequestSearchView.PatronChannels,
 			&i.PatronRequestSearchView.HasNotification,
 			&i.PatronRequestSearchView.HasCost,
 			&i.PatronRequestSearchView.HasUnreadNotification,
@@ -326,6 +331,7 @@ func (q *Queries) ListPatronRequestsCql(ctx context.Context, db DBTX, arg ListPa
 			&i.PatronRequestSearchView.ServiceType,
 			&i.PatronRequestSearchView.ServiceLevel,
 			&i.PatronRequestSearchView.NeededAt,
+			&i.PatronRequestSearchView.DueAt,
 			&i.PatronRequestSearchView.UnreadNotificationsCount,
 			&i.PatronRequestSearchView.RequesterName,
 			&i.PatronRequestSearchView.SupplierName,
@@ -10,6 +10,7 @@ type PatronRequestAction string
 type NotificationReceipt string
 type NotificationDirection string
 type NotificationKind string
+type OutboxMessageStatus string
 
 const (
 	NotificationAccepted     NotificationReceipt = "ACCEPTED"
@@ -23,6 +24,15 @@ const (
 
 	NotificationKindNote      NotificationKind = "note"
 	NotificationKindCondition NotificationKind = "condition"
+	NotificationKindEmail     NotificationKind = "email"
+	NotificationKindReminder  NotificationKind = "reminder"
+	NotificationKindSms       NotificationKind = "sms"
+	NotificationKindPush      NotificationKind = "push"
+
+	OutboxMessageStatusQueued  OutboxMessageStatus = "queued"
+	OutboxMessageStatusSent    OutboxMessageStatus = "sent"
+	OutboxMessageStatusFailed  OutboxMessageStatus = "failed"
+	OutboxMessageStatusBounced OutboxMessageStatus = "bounced"
 )
 
 type PrItem struct {
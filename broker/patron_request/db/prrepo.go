@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/indexdata/cql-go/pgcql"
 	"github.com/indexdata/crosslink/broker/common"
@@ -43,6 +44,11 @@ type PrRepo interface {
 	GetTemplatesByOwner(ctx common.ExtendedContext, params GetTemplatesByOwnerParams) ([]Template, int64, error)
 	GetTemplateByPurposeAudienceLabelAndOwner(ctx common.ExtendedContext, params GetTemplateByPurposeAudienceLabelAndOwnerParams) (Template, error)
 	DeleteTemplateByIdAndOwner(ctx common.ExtendedContext, id string, owner string) error
+
+	SaveOutboxMessage(ctx common.ExtendedContext, params SaveOutboxMessageParams) (OutboxMessage, error)
+	ClaimDueOutboxMessages(ctx common.ExtendedContext, claimFor time.Duration, batchSize int32) ([]OutboxMessage, error)
+	UpdateOutboxMessage(ctx common.ExtendedContext, params UpdateOutboxMessageParams) (OutboxMessage, error)
+	GetOutboxMessagesByNotificationIds(ctx common.ExtendedContext, notificationIds []string) ([]OutboxMessage, error)
 }
 
 var ErrUnsupportedFacet = errors.New("unsupported facet field")
@@ -362,3 +368,43 @@ func (r *PgPrRepo) DeleteTemplateByIdAndOwner(ctx common.ExtendedContext, id str
 		Owner: owner,
 	})
 }
+
+func (r *PgPrRepo) SaveOutboxMessage(ctx common.ExtendedContext, params SaveOutboxMessageParams) (OutboxMessage, error) {
+	row, err := r.queries.SaveOutboxMessage(ctx, r.GetConnOrTx(), params)
+	return row.OutboxMessage, err
+}
+
+// ClaimDueOutboxMessages returns queued outbox messages whose next attempt is due and
+// pushes their next attempt forward by claimFor, so that other instances skip them
+// while they are being sent.
+func (r *PgPrRepo) ClaimDueOutboxMessages(ctx common.ExtendedContext, claimFor time.Duration, batchSize int32) ([]OutboxMessage, error) {
+	rows, err := r.queries.ClaimDueOutboxMessages(ctx, r.GetConnOrTx(), ClaimDueOutboxMessagesParams{
+		ClaimFor:  pgtype.Interval{Microseconds: claimFor.Microseconds(), Valid: true},
+		BatchSize: batchSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]OutboxMessage, 0, len(rows))
+	for _, row := range rows {
+		messages = append(messages, row.OutboxMessage)
+	}
+	return messages, nil
+}
+
+func (r *PgPrRepo) UpdateOutboxMessage(ctx common.ExtendedContext, params UpdateOutboxMessageParams) (OutboxMessage, error) {
+	row, err := r.queries.UpdateOutboxMessage(ctx, r.GetConnOrTx(), params)
+	return row.OutboxMessage, err
+}
+
+func (r *PgPrRepo) GetOutboxMessagesByNotificationIds(ctx common.ExtendedContext, notificationIds []string) ([]OutboxMessage, error) {
+	rows, err := r.queries.GetOutboxMessagesByNotificationIds(ctx, r.GetConnOrTx(), notificationIds)
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]OutboxMessage, 0, len(rows))
+	for _, row := range rows {
+		messages = append(messages, row.OutboxMessage)
+	}
+	return messages, nil
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/indexdata/crosslink/broker/ncipclient"
 	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
 	"github.com/indexdata/crosslink/broker/patron_request/proapi"
+	prtemplate "github.com/indexdata/crosslink/broker/patron_request/template"
 	"github.com/indexdata/crosslink/broker/service"
 	"github.com/indexdata/crosslink/broker/shim"
 	dirapi "github.com/indexdata/crosslink/directory/api"
@@ -33,6 +34,12 @@ import (
 
 const COMP = "pr_action_service"
 
+// Electronic address types of the patron's phone numbers and push device tokens, they are not ISO 18626 open codes.
+const (
+	ElectronicAddressTypeSms  iso18626.ElectronicAddressType = "SMS"
+	ElectronicAddressTypePush iso18626.ElectronicAddressType = "Push"
+)
+
 type PatronRequestActionService struct {
 	PatronRequestMessageSender
 	prRepo                 pr_db.PrRepo
@@ -41,6 +48,7 @@ type PatronRequestActionService struct {
 	lmsCreator             lms.LmsCreator
 	actionMappingService   ActionMappingService
 	emailService           email.EmailService
+	outbox                 *Outbox
 	directoryLookupAdapter adapter.DirectoryLookupAdapter
 	lookupAdapterFactory   *service.LookupAdapterFactory
 }
@@ -106,11 +114,17 @@ func CreatePatronRequestActionService(prRepo pr_db.PrRepo, illRepo ill_db.IllRep
 		lmsCreator:                 lmsCreator,
 		actionMappingService:       ActionMappingService{SMService: &StateModelService{}},
 		emailService:               emailService,
+		outbox:                     NewOutbox(prRepo, emailService),
 		lookupAdapterFactory:       lookupAdapterFactory,
 		directoryLookupAdapter:     directoryLookupAdapter,
 	}
 }
 
+// Outbox returns the outbox that notification emails and texts are queued in.
+func (a *PatronRequestActionService) Outbox() *Outbox {
+	return a.outbox
+}
+
 func (a *PatronRequestActionService) InvokeAction(ctx common.ExtendedContext, event events.Event) {
 	ctx = ctx.WithArgs(ctx.LoggerArgs().WithComponent(COMP))
 	_, _ = a.processInvokeActionTask(ctx, event)
@@ -360,7 +374,7 @@ func (a *PatronRequestActionService) RunAutoActionsOnStateEntry(ctx common.Exten
 		if action.Params != nil {
 			data.CustomData = map[string]any{"autoActionParams": action.Params}
 		}
-		eventID, err := a.eventBus.CreateTask(pr.ID, events.EventNameInvokeAction, data, events.EventDomainPatronRequest, parentEventID, events.SignalConsumers)
+		eventID, err := a.eventBus.CreateTask(ctx, pr.ID, events.EventNameInvokeAction, data, events.EventDomainPatronRequest, parentEventID, events.SignalConsumers)
 		if err != nil {
 			return &autoActionFailure{action: actionName, msg: err.Error()}
 		}
@@ -432,10 +446,10 @@ func (a *PatronRequestActionService) handleBorrowingAction(ctx common.ExtendedCo
 			status = events.EventStatusError
 		}
 		var customData = make(map[string]any)
-		customData["lmsOutgoingMessage"] = outgoing
-		customData["lmsIncomingMessage"] = incoming
+		customData[events.LMS_OUTGOING_MESSAGE] = outgoing
+		customData[events.LMS_INCOMING_MESSAGE] = incoming
 		eventData := events.EventData{CustomData: customData}
-		_, createErr := a.eventBus.CreateNoticeWithParent(pr.ID, events.EventNameLmsRequesterMessage, eventData, status, events.EventDomainPatronRequest, eventID, events.SignalConsumers)
+		_, createErr := a.eventBus.CreateNoticeWithParent(ctx, pr.ID, events.EventNameLmsRequesterMessage, eventData, status, events.EventDomainPatronRequest, eventID, events.SignalConsumers)
 		if createErr != nil {
 			ctx.Logger().Error("failed to create LMS log event", "error", createErr)
 		}
@@ -501,10 +515,10 @@ func (a *PatronRequestActionService) handleLenderAction(ctx common.ExtendedConte
 			status = events.EventStatusError
 		}
 		var customData = make(map[string]any)
-		customData["lmsOutgoingMessage"] = outgoing
-		customData["lmsIncomingMessage"] = incoming
+		customData[events.LMS_OUTGOING_MESSAGE] = outgoing
+		customData[events.LMS_INCOMING_MESSAGE] = incoming
 		eventData := events.EventData{CustomData: customData}
-		_, createErr := a.eventBus.CreateNoticeWithParent(pr.ID, events.EventNameLmsSupplierMessage, eventData, status, events.EventDomainPatronRequest, eventID, events.SignalConsumers)
+		_, createErr := a.eventBus.CreateNoticeWithParent(ctx, pr.ID, events.EventNameLmsSupplierMessage, eventData, status, events.EventDomainPatronRequest, eventID, events.SignalConsumers)
 		if createErr != nil {
 			ctx.Logger().Error("failed to create LMS log event", "error", createErr)
 		}
@@ -650,7 +664,7 @@ func (a *PatronRequestActionService) metadataUpdateWithDetails(ctx common.Extend
 		Source:        metadataUpdateSource(configPeer),
 	}
 
-	lookupResult, err := lookupAdapter.Lookup(lookupParams)
+	lookupResult, err := catalog.Lookup(ctx, lookupAdapter, lookupParams)
 	if err != nil {
 		if errors.Is(err, catalog.ErrMissingLookupParameters) {
 			detail.Outcome = "skipped"
@@ -981,10 +995,7 @@ func (a *PatronRequestActionService) acceptRetryBorrowingRequest(ctx common.Exte
 }
 
 func (a *PatronRequestActionService) sendNotificationBorrowingRequest(ctx common.ExtendedContext, pr pr_db.PatronRequest, params actionParams) actionExecutionResult {
-	if !a.emailService.IsReadyToSend() {
-		return logNotificationErrorAndReturnSuccess(ctx, pr, "email service is not ready to send", nil)
-	}
-	return a.sendEmailNotification(ctx, pr, params, pr.RequesterSymbol.String)
+	return a.sendNotification(ctx, pr, params, pr.RequesterSymbol.String)
 }
 
 func (a *PatronRequestActionService) cancelLocalBorrowingRequest(ctx common.ExtendedContext, pr pr_db.PatronRequest) actionExecutionResult {
@@ -1424,10 +1435,37 @@ func (a *PatronRequestActionService) askRetryLenderRequest(ctx common.ExtendedCo
 }
 
 func (a *PatronRequestActionService) sendNotificationLenderRequest(ctx common.ExtendedContext, pr pr_db.PatronRequest, params actionParams) actionExecutionResult {
-	if !a.emailService.IsReadyToSend() {
-		return logNotificationErrorAndReturnSuccess(ctx, pr, "email service is not ready to send", nil)
+	return a.sendNotification(ctx, pr, params, pr.SupplierSymbol.String)
+}
+
+// sendNotification notifies on each channel of the action params, email if none are given.
+// Notification failures never fail the action, they are reported in the result note.
+func (a *PatronRequestActionService) sendNotification(ctx common.ExtendedContext, pr pr_db.PatronRequest, params actionParams, symbol string) actionExecutionResult {
+	channels := []proapi.NotificationChannel{proapi.NotificationChannelEmail}
+	if params.AutoActionParams != nil && params.AutoActionParams.Channels != nil {
+		channels = *params.AutoActionParams.Channels
+	}
+	var notes []string
+	for _, name := range channels {
+		var res actionExecutionResult
+		if name == proapi.NotificationChannelEmail {
+			if !a.emailService.IsReadyToSend() {
+				res = logNotificationErrorAndReturnSuccess(ctx, pr, "email service is not ready to send", nil)
+			} else {
+				res = a.sendEmailNotification(ctx, pr, params, symbol)
+			}
+		} else {
+			res = a.sendTextNotification(ctx, pr, params, symbol, name)
+		}
+		if len(channels) == 1 {
+			return res
+		}
+		if res.result != nil && res.result.Note != "" {
+			notes = append(notes, res.result.Note)
+		}
 	}
-	return a.sendEmailNotification(ctx, pr, params, pr.SupplierSymbol.String)
+	result := events.EventResult{CommonEventData: events.CommonEventData{Note: strings.Join(notes, "; ")}}
+	return actionExecutionResult{status: events.EventStatusSuccess, result: &result, pr: pr}
 }
 
 func logNotificationErrorAndReturnSuccess(ctx common.ExtendedContext, pr pr_db.PatronRequest, msg string, err error) actionExecutionResult {
@@ -1449,71 +1487,211 @@ func (a *PatronRequestActionService) sendEmailNotification(ctx common.ExtendedCo
 		if err != nil {
 			return logNotificationErrorAndReturnSuccess(ctx, pr, "error getting directory email data", err)
 		}
+		data, err := a.GetTemplateData(ctx, pr)
+		if err != nil {
+			return logNotificationErrorAndReturnSuccess(ctx, pr, "error reading patron request notifications", err)
+		}
+		ownerLanguage := a.getPeerLanguage(ctx, symbol)
 		if slices.Contains(*params.AutoActionParams.SendTo, proapi.ModelActionParamsSendToPatron) {
-			recipients := patronEmail(pr)
-			if len(recipients) == 0 {
+			recipients := PatronEmail(pr)
+			if !slices.Contains(PatronChannels(pr), proapi.NotificationChannelEmail) {
+				result.Note = "patron does not accept email"
+			} else if len(recipients) == 0 {
 				result.Note = "no recipients found for patron"
 			} else {
-				sendErr := a.createAndSendEmail(ctx, symbol, from, recipients, *params.AutoActionParams.TemplateLabel, proapi.ModelActionParamsSendToPatron)
+				languages := prtemplate.Languages(pr.PatronLanguage.String, ownerLanguage)
+				sendErr := a.createAndSendEmail(ctx, pr, symbol, from, recipients, *params.AutoActionParams.TemplateLabel, proapi.ModelActionParamsSendToPatron, languages, data)
 				if sendErr != nil {
 					return logNotificationErrorAndReturnSuccess(ctx, pr, "error sending email to patron", sendErr)
 				}
-				result.Note = "patron email sent successfully"
+				result.Note = "patron email queued"
 			}
 		}
 		if slices.Contains(*params.AutoActionParams.SendTo, proapi.ModelActionParamsSendToStaff) {
-			var recipients []string
-			for _, r := range strings.Split(*to, ";") {
-				if trimmed := strings.TrimSpace(r); trimmed != "" {
-					recipients = append(recipients, trimmed)
-				}
-			}
+			recipients := staffRecipients(*to)
 			if len(recipients) == 0 {
 				if result.Note != "" {
 					result.Note += "; "
 				}
 				result.Note += "no recipients found for staff"
 			} else {
-				sendErr := a.createAndSendEmail(ctx, symbol, from, recipients, *params.AutoActionParams.TemplateLabel, proapi.ModelActionParamsSendToStaff)
+				languages := prtemplate.Languages(ownerLanguage)
+				sendErr := a.createAndSendEmail(ctx, pr, symbol, from, recipients, *params.AutoActionParams.TemplateLabel, proapi.ModelActionParamsSendToStaff, languages, data)
 				if sendErr != nil {
 					return logNotificationErrorAndReturnSuccess(ctx, pr, "error sending email to staff", sendErr)
 				}
-				result.Note = "staff email sent successfully"
+				result.Note = "staff email queued"
 			}
 		}
 	}
 	return actionExecutionResult{status: events.EventStatusSuccess, result: &result, pr: pr}
 }
 
-func (a *PatronRequestActionService) createAndSendEmail(ctx common.ExtendedContext, symbol string, from string, recipients []string, label string, audience proapi.ModelActionParamsSendTo) error {
+// sendTextNotification queues the sms template of the action to the patron on a text channel, e.g. sms or push,
+// if the patron accepts notifications on that channel. Staff are only notified by email.
+func (a *PatronRequestActionService) sendTextNotification(ctx common.ExtendedContext, pr pr_db.PatronRequest, params actionParams, symbol string, name proapi.NotificationChannel) actionExecutionResult {
+	result := events.EventResult{}
+	if params.AutoActionParams == nil || params.AutoActionParams.SendTo == nil ||
+		!slices.Contains(*params.AutoActionParams.SendTo, proapi.ModelActionParamsSendToPatron) {
+		return actionExecutionResult{status: events.EventStatusSuccess, result: &result, pr: pr}
+	}
+	if params.AutoActionParams.TemplateLabel == nil {
+		return logNotificationErrorAndReturnSuccess(ctx, pr, "template label is not set", nil)
+	}
+	if !a.outbox.IsReadyToSend(name) {
+		return logNotificationErrorAndReturnSuccess(ctx, pr, string(name)+" service is not ready to send", nil)
+	}
+	if !slices.Contains(PatronChannels(pr), name) {
+		result.Note = "patron does not accept " + string(name)
+		return actionExecutionResult{status: events.EventStatusSuccess, result: &result, pr: pr}
+	}
+	recipients := patronAddresses(pr, channelAddressType(name))
+	if len(recipients) == 0 {
+		result.Note = "no " + string(name) + " recipients found for patron"
+		return actionExecutionResult{status: events.EventStatusSuccess, result: &result, pr: pr}
+	}
+	data, err := a.GetTemplateData(ctx, pr)
+	if err != nil {
+		return logNotificationErrorAndReturnSuccess(ctx, pr, "error reading patron request notifications", err)
+	}
+	label := *params.AutoActionParams.TemplateLabel
+	template, err := a.prRepo.GetTemplateByPurposeAudienceLabelAndOwner(ctx, pr_db.GetTemplateByPurposeAudienceLabelAndOwnerParams{
+		Purpose:   string(proapi.TemplatePurposeSms),
+		Owner:     symbol,
+		Label:     label,
+		Audience:  string(proapi.ModelActionParamsSendToPatron),
+		Languages: prtemplate.Languages(pr.PatronLanguage.String, a.getPeerLanguage(ctx, symbol)),
+	})
+	if err != nil {
+		return logNotificationErrorAndReturnSuccess(ctx, pr, "error sending "+string(name)+" to patron", err)
+	}
+	text, err := prtemplate.Render(template.ContentType, template.Body, data)
+	if err != nil {
+		return logNotificationErrorAndReturnSuccess(ctx, pr, "error sending "+string(name)+" to patron",
+			fmt.Errorf("failed to render body of template %s: %w", label, err))
+	}
+	text = prtemplate.TruncateSms(text)
+	for _, to := range recipients {
+		if _, err = a.outbox.QueueText(ctx, pr, symbol, name, to, text); err != nil {
+			return logNotificationErrorAndReturnSuccess(ctx, pr, "error sending "+string(name)+" to patron", err)
+		}
+	}
+	result.Note = "patron " + string(name) + " queued"
+	return actionExecutionResult{status: events.EventStatusSuccess, result: &result, pr: pr}
+}
+
+// createAndSendEmail renders the template variant in the first available of languages, or the default variant,
+// and queues the email in the outbox.
+func (a *PatronRequestActionService) createAndSendEmail(ctx common.ExtendedContext, pr pr_db.PatronRequest, symbol string, from string, recipients []string, label string, audience proapi.ModelActionParamsSendTo, languages []string, data prtemplate.Data) error {
 	template, err := a.prRepo.GetTemplateByPurposeAudienceLabelAndOwner(ctx, pr_db.GetTemplateByPurposeAudienceLabelAndOwnerParams{
-		Purpose:  string(proapi.Email),
-		Owner:    symbol,
-		Label:    label,
-		Audience: string(audience),
+		Purpose:   string(proapi.TemplatePurposeEmail),
+		Owner:     symbol,
+		Label:     label,
+		Audience:  string(audience),
+		Languages: languages,
 	})
 	if err != nil {
 		return err
 	}
-	emailData := email.EmailData{
+	subject, err := prtemplate.Render(string(proapi.TemplateContentTypeText), template.Subject.String, data)
+	if err != nil {
+		return fmt.Errorf("failed to render subject of template %s: %w", label, err)
+	}
+	body, err := prtemplate.Render(template.ContentType, template.Body, data)
+	if err != nil {
+		return fmt.Errorf("failed to render body of template %s: %w", label, err)
+	}
+	_, err = a.outbox.Queue(ctx, pr, symbol, from, email.EmailData{
 		To:         recipients,
-		Subject:    template.Subject.String,
-		Body:       template.Body,
-		IsHTML:     template.ContentType == string(proapi.Html),
+		Subject:    subject,
+		Body:       body,
+		IsHTML:     template.ContentType == string(proapi.TemplateContentTypeHtml),
 		IncludePdf: false,
+	})
+	return err
+}
+
+// SendTemplateEmail sends an already rendered email from the directory address of symbol to its staff, e.g. to test a template.
+// It returns the staff addresses the email was sent to.
+func (a *PatronRequestActionService) SendTemplateEmail(ctx common.ExtendedContext, symbol string, emailData email.EmailData) ([]string, error) {
+	if !a.emailService.IsReadyToSend() {
+		return nil, errors.New("email sending configuration missing")
 	}
-	raw, messageErr := email.BuildRawMessage(from, emailData, nil)
-	if messageErr != nil {
-		return messageErr
+	from, to, err := a.getDirectoryEmailData(ctx, symbol, true)
+	if err != nil {
+		return nil, err
 	}
-	sendErr := a.emailService.SendEmail(from, recipients, raw)
-	if sendErr != nil {
-		return sendErr
+	emailData.To = staffRecipients(*to)
+	if len(emailData.To) == 0 {
+		return nil, errors.New("no recipients found for staff")
 	}
-	return nil
+	raw, err := email.BuildRawMessage(from, emailData)
+	if err != nil {
+		return nil, err
+	}
+	return emailData.To, a.emailService.SendEmail(from, emailData.To, raw)
+}
+
+// staffRecipients splits the semicolon separated staff addresses of a directory entry
+func staffRecipients(to string) []string {
+	var recipients []string
+	for _, r := range strings.Split(to, ";") {
+		if trimmed := strings.TrimSpace(r); trimmed != "" {
+			recipients = append(recipients, trimmed)
+		}
+	}
+	return recipients
+}
+
+// GetTemplateData collects the template data of a patron request, a peer that cannot be read is left unnamed.
+func (a *PatronRequestActionService) GetTemplateData(ctx common.ExtendedContext, pr pr_db.PatronRequest) (prtemplate.Data, error) {
+	return TemplateData(ctx, a.prRepo, a.illRepo, pr, time.Now())
+}
+
+// TemplateData collects the template data of a patron request at now, a peer that cannot be read is left unnamed.
+func TemplateData(ctx common.ExtendedContext, prRepo pr_db.PrRepo, illRepo ill_db.IllRepo, pr pr_db.PatronRequest, now time.Time) (prtemplate.Data, error) {
+	notifications, _, err := prRepo.GetNotificationsByPrId(ctx, pr_db.GetNotificationsByPrIdParams{PrID: pr.ID, Limit: 100, Offset: 0})
+	if err != nil {
+		return prtemplate.Data{}, err
+	}
+	request := prtemplate.NewRequest(pr, notifications)
+	request.RequesterName = getPeerName(ctx, illRepo, pr.RequesterSymbol.String)
+	request.SupplierName = getPeerName(ctx, illRepo, pr.SupplierSymbol.String)
+	return prtemplate.Data{Request: request, Now: now}, nil
 }
 
-func patronEmail(pr pr_db.PatronRequest) []string {
+func getPeerName(ctx common.ExtendedContext, illRepo ill_db.IllRepo, symbol string) string {
+	if symbol == "" {
+		return ""
+	}
+	peer, err := illRepo.GetPeerBySymbol(ctx, symbol)
+	if err != nil {
+		ctx.Logger().Warn("failed to read peer for template data", "symbol", symbol, "error", err)
+		return ""
+	}
+	return peer.Name
+}
+
+// getPeerLanguage returns the language of the directory entry of symbol, or empty if it is not set or cannot be read.
+func (a *PatronRequestActionService) getPeerLanguage(ctx common.ExtendedContext, symbol string) string {
+	peer, err := a.illRepo.GetPeerBySymbol(ctx, symbol)
+	if err != nil {
+		ctx.Logger().Warn("failed to read peer for template language", "symbol", symbol, "error", err)
+		return ""
+	}
+	if peer.CustomData.Language == nil {
+		return ""
+	}
+	return *peer.CustomData.Language
+}
+
+// PatronEmail returns the patron's email addresses.
+func PatronEmail(pr pr_db.PatronRequest) []string {
+	return patronAddresses(pr, iso18626.ElectronicAddressTypeEmail)
+}
+
+// patronAddresses returns the patron's electronic addresses of the given type, e.g. phone numbers for SMS.
+func patronAddresses(pr pr_db.PatronRequest, addressType iso18626.ElectronicAddressType) []string {
 	var addresses []string
 	if pr.IllRequest.PatronInfo == nil {
 		return addresses
@@ -1524,19 +1702,42 @@ func patronEmail(pr pr_db.PatronRequest) []string {
 			continue
 		}
 
-		email := address.ElectronicAddress
-		if email.ElectronicAddressData == "" {
+		electronic := address.ElectronicAddress
+		if electronic.ElectronicAddressData == "" {
 			continue
 		}
 
-		if email.ElectronicAddressType.Text == string(iso18626.ElectronicAddressTypeEmail) {
-			addresses = append(addresses, email.ElectronicAddressData)
+		if electronic.ElectronicAddressType.Text == string(addressType) {
+			addresses = append(addresses, electronic.ElectronicAddressData)
 		}
 	}
 
 	return addresses
 }
 
+// PatronChannels returns the channels the patron accepts notifications on, email if not set.
+func PatronChannels(pr pr_db.PatronRequest) []proapi.NotificationChannel {
+	if pr.PatronChannels == nil {
+		return []proapi.NotificationChannel{proapi.NotificationChannelEmail}
+	}
+	channels := make([]proapi.NotificationChannel, 0, len(pr.PatronChannels))
+	for _, name := range pr.PatronChannels {
+		channels = append(channels, proapi.NotificationChannel(name))
+	}
+	return channels
+}
+
+func channelAddressType(name proapi.NotificationChannel) iso18626.ElectronicAddressType {
+	switch name {
+	case proapi.NotificationChannelSms:
+		return ElectronicAddressTypeSms
+	case proapi.NotificationChannelPush:
+		return ElectronicAddressTypePush
+	default:
+		return iso18626.ElectronicAddressTypeEmail
+	}
+}
+
 func (a *PatronRequestActionService) getDirectoryEmailData(ctx common.ExtendedContext, symbol string, toNeeded bool) (string, *string, error) {
 	dir, err := a.illRepo.GetPeerBySymbol(ctx, symbol)
 	toEmail := ""
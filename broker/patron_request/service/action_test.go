@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -14,6 +16,7 @@ import (
 	"github.com/indexdata/crosslink/broker/adapter"
 	"github.com/indexdata/crosslink/broker/catalog"
 	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/email"
 	"github.com/indexdata/crosslink/broker/events"
 	"github.com/indexdata/crosslink/broker/handler"
 	"github.com/indexdata/crosslink/broker/ill_db"
@@ -21,11 +24,13 @@ import (
 	"github.com/indexdata/crosslink/broker/ncipclient"
 	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
 	"github.com/indexdata/crosslink/broker/patron_request/proapi"
+	prtemplate "github.com/indexdata/crosslink/broker/patron_request/template"
 	"github.com/indexdata/crosslink/broker/service"
 	"github.com/indexdata/crosslink/broker/shim"
 	dirapi "github.com/indexdata/crosslink/directory/api"
 	"github.com/indexdata/crosslink/iso18626"
 	"github.com/indexdata/crosslink/ncip"
+	"github.com/indexdata/go-utils/utils"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -2967,7 +2972,7 @@ func TestPatronEmail(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			result := patronEmail(tc.pr)
+			result := PatronEmail(tc.pr)
 			assert.Equal(t, tc.expected, result)
 		})
 	}
@@ -3005,6 +3010,7 @@ func TestCreateAndSendEmail(t *testing.T) {
 		setupPrRepo   func(m *MockPrRepo)
 		setupEmail    func(m *EmailSenderMock)
 		assertEmail   func(t *testing.T, m *EmailSenderMock)
+		wantStatus    pr_db.OutboxMessageStatus
 		wantErrSubstr string
 	}{
 		{
@@ -3020,6 +3026,7 @@ func TestCreateAndSendEmail(t *testing.T) {
 			assertEmail: func(t *testing.T, m *EmailSenderMock) {
 				m.AssertCalled(t, "SendEmail", from)
 			},
+			wantStatus: pr_db.OutboxMessageStatusSent,
 		},
 		{
 			name:       "template not found returns error",
@@ -3035,7 +3042,7 @@ func TestCreateAndSendEmail(t *testing.T) {
 			wantErrSubstr: "no template found",
 		},
 		{
-			name:       "SendEmail error is propagated",
+			name:       "SendEmail error leaves the email queued for retry",
 			from:       from,
 			recipients: recipients,
 			setupPrRepo: func(m *MockPrRepo) {
@@ -3047,7 +3054,7 @@ func TestCreateAndSendEmail(t *testing.T) {
 			assertEmail: func(t *testing.T, m *EmailSenderMock) {
 				m.AssertCalled(t, "SendEmail", from)
 			},
-			wantErrSubstr: "smtp failure",
+			wantStatus: pr_db.OutboxMessageStatusQueued,
 		},
 		{
 			name:       "header injection in from triggers BuildRawMessage error",
@@ -3072,18 +3079,324 @@ func TestCreateAndSendEmail(t *testing.T) {
 			tc.setupEmail(mockEmail)
 			svc := newActionServiceWithEmail(mockPrRepo, mockEmail)
 
-			err := svc.createAndSendEmail(appCtx, symbol, tc.from, tc.recipients, label, audience)
+			err := svc.createAndSendEmail(appCtx, pr_db.PatronRequest{ID: "pr-1"}, symbol, tc.from, tc.recipients, label, audience, nil, prtemplate.Data{})
 
 			if tc.wantErrSubstr == "" {
 				assert.NoError(t, err)
 			} else {
 				assert.ErrorContains(t, err, tc.wantErrSubstr)
 			}
+			mockPrRepo.waitForOutboxDelivery(t)
+			if tc.wantStatus != "" {
+				assert.Equal(t, tc.wantStatus, mockPrRepo.outboxUpdates()[0].Status)
+				if assert.Len(t, mockPrRepo.savedNotifications, 1) {
+					assert.Equal(t, pr_db.NotificationKindEmail, mockPrRepo.savedNotifications[0].Kind)
+					assert.Equal(t, "pr-1", mockPrRepo.savedNotifications[0].PrID)
+				}
+			}
 			tc.assertEmail(t, mockEmail)
 		})
 	}
 }
 
+func TestSendEmailNotificationRendersPatronRequestData(t *testing.T) {
+	pr := prWithPatronEmail(testPatronTo)
+	pr.ID = "pr-1"
+	pr.Patron = pgtype.Text{String: "Jane Doe", Valid: true}
+	pr.RequesterSymbol = pgtype.Text{String: testSymbol, Valid: true}
+	pr.IllRequest.BibliographicInfo.Title = "Dune"
+	pr.IllResponse.StatusInfo.DueDate = &utils.XSDDateTime{Time: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)}
+	prRepo := &MockPrRepo{savedNotifications: []pr_db.Notification{{
+		PrID:      "pr-1",
+		Kind:      pr_db.NotificationKindCondition,
+		Condition: pgtype.Text{String: "LibraryUseOnly", Valid: true},
+	}}}
+	illRepo := new(IllRepoMock)
+	emailSvc := new(EmailSenderMock)
+	peer := peerWithFromEmailOnly(testFrom)
+	peer.Name = "Test Library"
+	illRepo.On("GetPeerBySymbol", testSymbol).Return(peer, nil)
+	prRepo.On("GetTemplateByPurposeAudienceLabelAndOwner", mock.Anything).Return(pr_db.Template{
+		Subject:     pgtype.Text{String: "{{.Request.Title}} is ready", Valid: true},
+		Body:        "Dear {{.Request.Patron}}, return by {{formatDate .Request.DueDate}} to {{.Request.RequesterName}}.{{range .Request.Conditions}} {{.Condition}}{{end}}",
+		ContentType: string(proapi.TemplateContentTypeText),
+	}, nil)
+	emailSvc.On("SendEmail", testFrom).Return(nil)
+	svc := CreatePatronRequestActionService(prRepo, illRepo, *new(events.EventBus), new(handler.Iso18626Handler), nil, emailSvc, nil, nil)
+
+	res := svc.sendEmailNotification(appCtx, pr, autoParams(testTemplate, proapi.ModelActionParamsSendToPatron), testSymbol)
+	prRepo.waitForOutboxDelivery(t)
+
+	assert.Equal(t, events.EventStatusSuccess, res.status)
+	assert.Equal(t, "patron email queued", res.result.Note)
+	assert.Contains(t, string(emailSvc.raw), "Subject: Dune is ready")
+	assert.Contains(t, string(emailSvc.raw), "Dear Jane Doe, return by 2026-03-01 to Test Library. LibraryUseOnly")
+}
+
+func TestSendEmailNotificationTemplateRenderError(t *testing.T) {
+	prRepo := new(MockPrRepo)
+	illRepo := new(IllRepoMock)
+	emailSvc := new(EmailSenderMock)
+	illRepo.On("GetPeerBySymbol", testSymbol).Return(peerWithFromEmailOnly(testFrom), nil)
+	prRepo.On("GetTemplateByPurposeAudienceLabelAndOwner", mock.Anything).Return(pr_db.Template{
+		Subject: pgtype.Text{String: "Subject", Valid: true},
+		Body:    "Dear {{.Request.PatronName}}",
+	}, nil)
+	svc := CreatePatronRequestActionService(prRepo, illRepo, *new(events.EventBus), new(handler.Iso18626Handler), nil, emailSvc, nil, nil)
+
+	res := svc.sendEmailNotification(appCtx, prWithPatronEmail(testPatronTo), autoParams(testTemplate, proapi.ModelActionParamsSendToPatron), testSymbol)
+
+	assert.Equal(t, events.EventStatusSuccess, res.status)
+	assert.Equal(t, "error sending email to patron", res.result.Note)
+	emailSvc.AssertNotCalled(t, "SendEmail", testFrom)
+}
+
+func TestSendEmailNotificationTemplateLanguages(t *testing.T) {
+	pr := prWithPatronEmail(testPatronTo)
+	pr.PatronLanguage = pgtype.Text{String: "es-MX", Valid: true}
+	prRepo := new(MockPrRepo)
+	illRepo := new(IllRepoMock)
+	emailSvc := new(EmailSenderMock)
+	peer := peerWithFromEmailOnly(testFrom)
+	peer.CustomData.Language = ptr("da")
+	illRepo.On("GetPeerBySymbol", testSymbol).Return(peer, nil)
+	template := pr_db.Template{Subject: pgtype.Text{String: "Subject", Valid: true}, Body: "Body"}
+	prRepo.On("GetTemplateByPurposeAudienceLabelAndOwner", mock.MatchedBy(func(params pr_db.GetTemplateByPurposeAudienceLabelAndOwnerParams) bool {
+		return params.Audience == string(proapi.ModelActionParamsSendToPatron) && slices.Equal(params.Languages, []string{"es-MX", "es", "da"})
+	})).Return(template, nil)
+	prRepo.On("GetTemplateByPurposeAudienceLabelAndOwner", mock.MatchedBy(func(params pr_db.GetTemplateByPurposeAudienceLabelAndOwnerParams) bool {
+		return params.Audience == string(proapi.ModelActionParamsSendToStaff) && slices.Equal(params.Languages, []string{"da"})
+	})).Return(template, nil)
+	emailSvc.On("SendEmail", testFrom).Return(nil)
+	svc := CreatePatronRequestActionService(prRepo, illRepo, *new(events.EventBus), new(handler.Iso18626Handler), nil, emailSvc, nil, nil)
+
+	res := svc.sendEmailNotification(appCtx, pr, autoParams(testTemplate, proapi.ModelActionParamsSendToPatron, proapi.ModelActionParamsSendToStaff), testSymbol)
+
+	assert.Equal(t, events.EventStatusSuccess, res.status)
+	assert.Equal(t, "staff email queued", res.result.Note)
+	prRepo.AssertNumberOfCalls(t, "GetTemplateByPurposeAudienceLabelAndOwner", 2)
+}
+
+func TestSendTemplateEmail(t *testing.T) {
+	illRepo := new(IllRepoMock)
+	emailSvc := new(EmailSenderMock)
+	illRepo.On("GetPeerBySymbol", testSymbol).Return(peerWithFromEmailOnly(testFrom), nil)
+	emailSvc.On("IsReadyToSend").Return(true)
+	emailSvc.On("SendEmail", testFrom).Return(nil)
+	svc := CreatePatronRequestActionService(new(MockPrRepo), illRepo, *new(events.EventBus), new(handler.Iso18626Handler), nil, emailSvc, nil, nil)
+
+	sentTo, err := svc.SendTemplateEmail(appCtx, testSymbol, email.EmailData{To: []string{"someone@example.com"}, Subject: "Preview", Body: "Hello"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{testFrom}, sentTo)
+	assert.Contains(t, string(emailSvc.raw), "To: "+testFrom)
+	assert.NotContains(t, string(emailSvc.raw), "someone@example.com")
+	assert.Contains(t, string(emailSvc.raw), "Subject: Preview")
+	emailSvc.AssertExpectations(t)
+}
+
+func TestSendTemplateEmailNotConfigured(t *testing.T) {
+	emailSvc := new(EmailSenderMock)
+	emailSvc.On("IsReadyToSend").Return(false)
+	svc := CreatePatronRequestActionService(new(MockPrRepo), new(IllRepoMock), *new(events.EventBus), new(handler.Iso18626Handler), nil, emailSvc, nil, nil)
+
+	_, err := svc.SendTemplateEmail(appCtx, testSymbol, email.EmailData{})
+
+	assert.EqualError(t, err, "email sending configuration missing")
+	emailSvc.AssertNotCalled(t, "SendEmail", mock.Anything)
+}
+
+func TestSendNotificationChannels(t *testing.T) {
+	const testPhone = "+4512345678"
+	smsTemplate := pr_db.Template{Body: "{{.Request.Title}} is ready", ContentType: string(proapi.TemplateContentTypeSms)}
+	emailTemplate := pr_db.Template{Body: "Hello", Subject: pgtype.Text{String: "Subject", Valid: true}}
+	prWithContacts := func(channels ...string) pr_db.PatronRequest {
+		pr := prWithPatronEmail(testPatronTo)
+		pr.IllRequest.PatronInfo.Address = append(pr.IllRequest.PatronInfo.Address, makeAddress(string(ElectronicAddressTypeSms), testPhone))
+		pr.IllRequest.BibliographicInfo.Title = "Dune"
+		pr.PatronChannels = channels
+		return pr
+	}
+	channelParams := func(channels ...proapi.NotificationChannel) actionParams {
+		params := autoParams(testTemplate, proapi.ModelActionParamsSendToPatron)
+		params.AutoActionParams.Channels = &channels
+		return params
+	}
+	isSms := func(sms bool) any {
+		return mock.MatchedBy(func(params pr_db.GetTemplateByPurposeAudienceLabelAndOwnerParams) bool {
+			return (params.Purpose == string(proapi.TemplatePurposeSms)) == sms
+		})
+	}
+
+	tests := []struct {
+		name       string
+		pr         pr_db.PatronRequest
+		params     actionParams
+		setupMocks func(*MockPrRepo, *EmailSenderMock, *ChannelMock)
+		wantNote   string
+		wantKinds  []pr_db.NotificationKind
+		wantStatus pr_db.OutboxMessageStatus
+	}{
+		{
+			name:   "email and sms sent",
+			pr:     prWithContacts("email", "sms"),
+			params: channelParams(proapi.NotificationChannelEmail, proapi.NotificationChannelSms),
+			setupMocks: func(prRepo *MockPrRepo, emailSvc *EmailSenderMock, sms *ChannelMock) {
+				prRepo.On("GetTemplateByPurposeAudienceLabelAndOwner", isSms(false)).Return(emailTemplate, nil)
+				prRepo.On("GetTemplateByPurposeAudienceLabelAndOwner", isSms(true)).Return(smsTemplate, nil)
+				emailSvc.On("IsReadyToSend").Return(true)
+				emailSvc.On("SendEmail", testFrom).Return(nil)
+				sms.On("IsReadyToSend").Return(true)
+				sms.On("Send", testPhone, "Dune is ready").Return(nil)
+			},
+			wantNote:   "patron email queued; patron sms queued",
+			wantKinds:  []pr_db.NotificationKind{pr_db.NotificationKindEmail, pr_db.NotificationKindSms},
+			wantStatus: pr_db.OutboxMessageStatusSent,
+		},
+		{
+			name:   "sms only",
+			pr:     prWithContacts("sms"),
+			params: channelParams(proapi.NotificationChannelSms),
+			setupMocks: func(prRepo *MockPrRepo, _ *EmailSenderMock, sms *ChannelMock) {
+				prRepo.On("GetTemplateByPurposeAudienceLabelAndOwner", isSms(true)).Return(smsTemplate, nil)
+				sms.On("IsReadyToSend").Return(true)
+				sms.On("Send", testPhone, "Dune is ready").Return(nil)
+			},
+			wantNote:   "patron sms queued",
+			wantKinds:  []pr_db.NotificationKind{pr_db.NotificationKindSms},
+			wantStatus: pr_db.OutboxMessageStatusSent,
+		},
+		{
+			name:   "long sms is truncated",
+			pr:     prWithContacts("sms"),
+			params: channelParams(proapi.NotificationChannelSms),
+			setupMocks: func(prRepo *MockPrRepo, _ *EmailSenderMock, sms *ChannelMock) {
+				prRepo.On("GetTemplateByPurposeAudienceLabelAndOwner", isSms(true)).Return(pr_db.Template{
+					Body: strings.Repeat("x", prtemplate.MAX_SMS_LENGTH+10), ContentType: string(proapi.TemplateContentTypeSms)}, nil)
+				sms.On("IsReadyToSend").Return(true)
+				sms.On("Send", testPhone, strings.Repeat("x", prtemplate.MAX_SMS_LENGTH-1)+"…").Return(nil)
+			},
+			wantNote: "patron sms queued",
+		},
+		{
+			name:   "patron without channel preferences gets email only",
+			pr:     prWithContacts(),
+			params: channelParams(proapi.NotificationChannelEmail, proapi.NotificationChannelSms),
+			setupMocks: func(prRepo *MockPrRepo, emailSvc *EmailSenderMock, sms *ChannelMock) {
+				prRepo.On("GetTemplateByPurposeAudienceLabelAndOwner", isSms(false)).Return(emailTemplate, nil)
+				emailSvc.On("IsReadyToSend").Return(true)
+				emailSvc.On("SendEmail", testFrom).Return(nil)
+				sms.On("IsReadyToSend").Return(true)
+			},
+			wantNote: "patron email queued; patron does not accept sms",
+		},
+		{
+			name:   "patron opted out of email",
+			pr:     prWithContacts("sms"),
+			params: channelParams(proapi.NotificationChannelEmail),
+			setupMocks: func(_ *MockPrRepo, emailSvc *EmailSenderMock, _ *ChannelMock) {
+				emailSvc.On("IsReadyToSend").Return(true)
+			},
+			wantNote: "patron does not accept email",
+		},
+		{
+			name:   "sms not configured",
+			pr:     prWithContacts("sms"),
+			params: channelParams(proapi.NotificationChannelSms),
+			setupMocks: func(_ *MockPrRepo, _ *EmailSenderMock, sms *ChannelMock) {
+				sms.On("IsReadyToSend").Return(false)
+			},
+			wantNote: "sms service is not ready to send",
+		},
+		{
+			name:       "push has no channel",
+			pr:         prWithContacts("push"),
+			params:     channelParams(proapi.NotificationChannelPush),
+			setupMocks: func(_ *MockPrRepo, _ *EmailSenderMock, _ *ChannelMock) {},
+			wantNote:   "push service is not ready to send",
+		},
+		{
+			name: "no sms recipients",
+			pr: func() pr_db.PatronRequest {
+				pr := prWithPatronEmail(testPatronTo)
+				pr.PatronChannels = []string{"sms"}
+				return pr
+			}(),
+			params: channelParams(proapi.NotificationChannelSms),
+			setupMocks: func(_ *MockPrRepo, _ *EmailSenderMock, sms *ChannelMock) {
+				sms.On("IsReadyToSend").Return(true)
+			},
+			wantNote: "no sms recipients found for patron",
+		},
+		{
+			name:   "sms template not found",
+			pr:     prWithContacts("sms"),
+			params: channelParams(proapi.NotificationChannelSms),
+			setupMocks: func(prRepo *MockPrRepo, _ *EmailSenderMock, sms *ChannelMock) {
+				prRepo.On("GetTemplateByPurposeAudienceLabelAndOwner", isSms(true)).Return(pr_db.Template{}, errors.New("no rows"))
+				sms.On("IsReadyToSend").Return(true)
+			},
+			wantNote: "error sending sms to patron",
+		},
+		{
+			name:   "sms provider error – sms stays queued",
+			pr:     prWithContacts("sms"),
+			params: channelParams(proapi.NotificationChannelSms),
+			setupMocks: func(prRepo *MockPrRepo, _ *EmailSenderMock, sms *ChannelMock) {
+				prRepo.On("GetTemplateByPurposeAudienceLabelAndOwner", isSms(true)).Return(smsTemplate, nil)
+				sms.On("IsReadyToSend").Return(true)
+				sms.On("Send", testPhone, "Dune is ready").Return(errors.New("provider down"))
+			},
+			wantNote:   "patron sms queued",
+			wantKinds:  []pr_db.NotificationKind{pr_db.NotificationKindSms},
+			wantStatus: pr_db.OutboxMessageStatusQueued,
+		},
+		{
+			name: "staff are not sent sms",
+			pr:   prWithContacts("sms"),
+			params: func() actionParams {
+				params := autoParams(testTemplate, proapi.ModelActionParamsSendToStaff)
+				params.AutoActionParams.Channels = &[]proapi.NotificationChannel{proapi.NotificationChannelSms}
+				return params
+			}(),
+			setupMocks: func(_ *MockPrRepo, _ *EmailSenderMock, _ *ChannelMock) {},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			prRepo := new(MockPrRepo)
+			illRepo := new(IllRepoMock)
+			emailSvc := new(EmailSenderMock)
+			sms := new(ChannelMock)
+			illRepo.On("GetPeerBySymbol", testSymbol).Return(peerWithFromEmailOnly(testFrom), nil)
+			tc.setupMocks(prRepo, emailSvc, sms)
+			svc := CreatePatronRequestActionService(prRepo, illRepo, *new(events.EventBus), new(handler.Iso18626Handler), nil, emailSvc, nil, nil)
+			svc.Outbox().SetChannel(proapi.NotificationChannelSms, sms)
+
+			res := svc.sendNotification(appCtx, tc.pr, tc.params, testSymbol)
+			prRepo.waitForOutboxDelivery(t)
+
+			assert.Equal(t, events.EventStatusSuccess, res.status)
+			if assert.NotNil(t, res.result) {
+				assert.Equal(t, tc.wantNote, res.result.Note)
+			}
+			if tc.wantKinds != nil {
+				var kinds []pr_db.NotificationKind
+				for _, n := range prRepo.savedNotifications {
+					kinds = append(kinds, n.Kind)
+				}
+				assert.Equal(t, tc.wantKinds, kinds)
+				for _, update := range prRepo.outboxUpdates() {
+					assert.Equal(t, tc.wantStatus, update.Status)
+				}
+			}
+			emailSvc.AssertExpectations(t)
+			sms.AssertExpectations(t)
+		})
+	}
+}
+
 // helpers for sendEmailNotification tests
 
 func ptr[T any](v T) *T { return &v }
@@ -3206,7 +3519,7 @@ func TestSendEmailNotification(t *testing.T) {
 			wantNote:   "no recipients found for patron",
 		},
 		{
-			name:   "SendTo patron – email sent successfully",
+			name:   "SendTo patron – email queued",
 			pr:     prWithPatronEmail(testPatronTo),
 			symbol: testSymbol,
 			params: autoParams(testTemplate, proapi.ModelActionParamsSendToPatron),
@@ -3216,10 +3529,10 @@ func TestSendEmailNotification(t *testing.T) {
 				emailSvc.On("SendEmail", testFrom).Return(nil)
 			},
 			wantStatus: events.EventStatusSuccess,
-			wantNote:   "patron email sent successfully",
+			wantNote:   "patron email queued",
 		},
 		{
-			name:   "SendTo patron – SendEmail fails – logged success",
+			name:   "SendTo patron – SendEmail fails – email stays queued",
 			pr:     prWithPatronEmail(testPatronTo),
 			symbol: testSymbol,
 			params: autoParams(testTemplate, proapi.ModelActionParamsSendToPatron),
@@ -3229,10 +3542,10 @@ func TestSendEmailNotification(t *testing.T) {
 				emailSvc.On("SendEmail", testFrom).Return(errors.New("smtp error"))
 			},
 			wantStatus: events.EventStatusSuccess,
-			wantNote:   "error sending email to patron",
+			wantNote:   "patron email queued",
 		},
 		{
-			name:   "SendTo staff – email sent successfully",
+			name:   "SendTo staff – email queued",
 			pr:     pr_db.PatronRequest{},
 			symbol: testSymbol,
 			params: autoParams(testTemplate, proapi.ModelActionParamsSendToStaff),
@@ -3242,10 +3555,10 @@ func TestSendEmailNotification(t *testing.T) {
 				emailSvc.On("SendEmail", testFrom).Return(nil)
 			},
 			wantStatus: events.EventStatusSuccess,
-			wantNote:   "staff email sent successfully",
+			wantNote:   "staff email queued",
 		},
 		{
-			name:   "SendTo staff – multiple semicolon-separated addresses all sent",
+			name:   "SendTo staff – multiple semicolon-separated addresses all queued",
 			pr:     pr_db.PatronRequest{},
 			symbol: testSymbol,
 			params: autoParams(testTemplate, proapi.ModelActionParamsSendToStaff),
@@ -3255,10 +3568,10 @@ func TestSendEmailNotification(t *testing.T) {
 				emailSvc.On("SendEmail", testFrom).Return(nil)
 			},
 			wantStatus: events.EventStatusSuccess,
-			wantNote:   "staff email sent successfully",
+			wantNote:   "staff email queued",
 		},
 		{
-			name:   "SendTo staff – trailing semicolon is ignored, email sent",
+			name:   "SendTo staff – trailing semicolon is ignored, email queued",
 			pr:     pr_db.PatronRequest{},
 			symbol: testSymbol,
 			params: autoParams(testTemplate, proapi.ModelActionParamsSendToStaff),
@@ -3268,7 +3581,7 @@ func TestSendEmailNotification(t *testing.T) {
 				emailSvc.On("SendEmail", testFrom).Return(nil)
 			},
 			wantStatus: events.EventStatusSuccess,
-			wantNote:   "staff email sent successfully",
+			wantNote:   "staff email queued",
 		},
 		{
 			name:   "SendTo staff – fromEmail is used as staff recipient",
@@ -3281,10 +3594,10 @@ func TestSendEmailNotification(t *testing.T) {
 				emailSvc.On("SendEmail", testFrom).Return(nil)
 			},
 			wantStatus: events.EventStatusSuccess,
-			wantNote:   "staff email sent successfully",
+			wantNote:   "staff email queued",
 		},
 		{
-			name:   "SendTo staff – SendEmail fails – logged success",
+			name:   "SendTo staff – SendEmail fails – email stays queued",
 			pr:     pr_db.PatronRequest{},
 			symbol: testSymbol,
 			params: autoParams(testTemplate, proapi.ModelActionParamsSendToStaff),
@@ -3294,10 +3607,10 @@ func TestSendEmailNotification(t *testing.T) {
 				emailSvc.On("SendEmail", testFrom).Return(errors.New("smtp error"))
 			},
 			wantStatus: events.EventStatusSuccess,
-			wantNote:   "error sending email to staff",
+			wantNote:   "staff email queued",
 		},
 		{
-			name:   "SendTo patron and staff – both emails sent – staff note wins",
+			name:   "SendTo patron and staff – both emails queued – staff note wins",
 			pr:     prWithPatronEmail(testPatronTo),
 			symbol: testSymbol,
 			params: autoParams(testTemplate, proapi.ModelActionParamsSendToPatron, proapi.ModelActionParamsSendToStaff),
@@ -3307,7 +3620,7 @@ func TestSendEmailNotification(t *testing.T) {
 				emailSvc.On("SendEmail", testFrom).Return(nil)
 			},
 			wantStatus: events.EventStatusSuccess,
-			wantNote:   "staff email sent successfully",
+			wantNote:   "staff email queued",
 		},
 	}
 
@@ -3330,6 +3643,7 @@ func TestSendEmailNotification(t *testing.T) {
 			)
 
 			res := svc.sendEmailNotification(appCtx, tc.pr, tc.params, tc.symbol)
+			prRepo.waitForOutboxDelivery(t)
 
 			assert.Equal(t, tc.wantStatus, res.status)
 			if tc.wantErr != "" {
@@ -3881,7 +4195,7 @@ func (m *MockEventBus) ProcessExclusiveTask(ctx common.ExtendedContext, event ev
 	return event, nil
 }
 
-func (m *MockEventBus) CreateTask(id string, eventName events.EventName, data events.EventData, eventClass events.EventDomain, parentId *string, target events.SignalTarget) (string, error) {
+func (m *MockEventBus) CreateTask(ctx common.ExtendedContext, id string, eventName events.EventName, data events.EventData, eventClass events.EventDomain, parentId *string, target events.SignalTarget) (string, error) {
 	m.createdTaskData = append(m.createdTaskData, data)
 	m.createdTaskNames = append(m.createdTaskNames, eventName)
 	if m.createTaskErr != nil {
@@ -3895,7 +4209,7 @@ func (m *MockEventBus) CreateTask(id string, eventName events.EventName, data ev
 	return taskID, nil
 }
 
-func (m *MockEventBus) CreateNotice(id string, eventName events.EventName, data events.EventData, status events.EventStatus, eventDomain events.EventDomain, target events.SignalTarget) (string, error) {
+func (m *MockEventBus) CreateNotice(ctx common.ExtendedContext, id string, eventName events.EventName, data events.EventData, status events.EventStatus, eventDomain events.EventDomain, target events.SignalTarget) (string, error) {
 	m.createdNoticeIDs = append(m.createdNoticeIDs, id)
 	m.createdNoticeData = append(m.createdNoticeData, data)
 	m.createdNoticeStatus = append(m.createdNoticeStatus, status)
@@ -3905,7 +4219,7 @@ func (m *MockEventBus) CreateNotice(id string, eventName events.EventName, data
 	return id, nil
 }
 
-func (m *MockEventBus) CreateNoticeWithParent(id string, eventName events.EventName, data events.EventData, status events.EventStatus, eventDomain events.EventDomain, parentId *string, target events.SignalTarget) (string, error) {
+func (m *MockEventBus) CreateNoticeWithParent(ctx common.ExtendedContext, id string, eventName events.EventName, data events.EventData, status events.EventStatus, eventDomain events.EventDomain, parentId *string, target events.SignalTarget) (string, error) {
 	if parentId == nil || id == "error" {
 		return "", errors.New("event bus error")
 	}
@@ -3930,6 +4244,9 @@ type MockPrRepo struct {
 	savedNotifications                   []pr_db.Notification
 	markedConditionNotificationsReceipts []pr_db.MarkConditionNotificationsReceiptParams
 	saveItemFail                         bool
+	outboxMu                             sync.Mutex
+	savedOutboxMessages                  []pr_db.SaveOutboxMessageParams
+	updatedOutboxMessages                []pr_db.UpdateOutboxMessageParams
 }
 
 func (r *MockPrRepo) WithTxFunc(ctx common.ExtendedContext, fn func(repo pr_db.PrRepo) error) error {
@@ -4055,6 +4372,52 @@ func (r *MockPrRepo) SaveNotification(ctx common.ExtendedContext, params pr_db.S
 	return pr_db.Notification(params), nil
 }
 
+func (r *MockPrRepo) SaveOutboxMessage(ctx common.ExtendedContext, params pr_db.SaveOutboxMessageParams) (pr_db.OutboxMessage, error) {
+	r.outboxMu.Lock()
+	defer r.outboxMu.Unlock()
+	r.savedOutboxMessages = append(r.savedOutboxMessages, params)
+	return pr_db.OutboxMessage{
+		ID:             params.ID,
+		NotificationID: params.NotificationID,
+		Owner:          params.Owner,
+		FromAddress:    params.FromAddress,
+		Recipients:     params.Recipients,
+		Message:        params.Message,
+		Status:         params.Status,
+		NextAttemptAt:  params.NextAttemptAt,
+		CreatedAt:      params.CreatedAt,
+		Channel:        params.Channel,
+	}, nil
+}
+
+func (r *MockPrRepo) UpdateOutboxMessage(ctx common.ExtendedContext, params pr_db.UpdateOutboxMessageParams) (pr_db.OutboxMessage, error) {
+	r.outboxMu.Lock()
+	defer r.outboxMu.Unlock()
+	r.updatedOutboxMessages = append(r.updatedOutboxMessages, params)
+	return pr_db.OutboxMessage{ID: params.ID, Status: params.Status, Attempts: params.Attempts}, nil
+}
+
+func (r *MockPrRepo) ClaimDueOutboxMessages(ctx common.ExtendedContext, claimFor time.Duration, batchSize int32) ([]pr_db.OutboxMessage, error) {
+	args := r.Called(claimFor, batchSize)
+	return args.Get(0).([]pr_db.OutboxMessage), args.Error(1)
+}
+
+// outboxUpdates returns the outbox message updates made so far, delivery happens in the background.
+func (r *MockPrRepo) outboxUpdates() []pr_db.UpdateOutboxMessageParams {
+	r.outboxMu.Lock()
+	defer r.outboxMu.Unlock()
+	return append([]pr_db.UpdateOutboxMessageParams(nil), r.updatedOutboxMessages...)
+}
+
+// waitForOutboxDelivery waits until the first delivery attempt of every queued email is recorded.
+func (r *MockPrRepo) waitForOutboxDelivery(t *testing.T) {
+	assert.Eventually(t, func() bool {
+		r.outboxMu.Lock()
+		defer r.outboxMu.Unlock()
+		return len(r.updatedOutboxMessages) >= len(r.savedOutboxMessages)
+	}, time.Second, 5*time.Millisecond)
+}
+
 func (r *MockPrRepo) GetNotificationById(ctx common.ExtendedContext, id string) (pr_db.Notification, error) {
 	args := r.Called(id)
 	return args.Get(0).(pr_db.Notification), args.Error(1)
@@ -4278,6 +4641,14 @@ func (l *MockLmsAdapterFail) CreateUserFiscalTransaction(userId string, itemId s
 	return errors.New("CreateUserFiscalTransaction failed")
 }
 
+func (l *MockLmsAdapterFail) LookupItems(bibId string) ([]string, error) {
+	return nil, errors.New("LookupItems failed")
+}
+
+func (l *MockLmsAdapterFail) RenewItem(itemId string, userId string, desiredDateDue time.Time) (time.Time, error) {
+	return time.Time{}, errors.New("RenewItem failed")
+}
+
 func (l *MockLmsAdapterFail) InstitutionalPatron(requesterSymbol string) string {
 	return ""
 }
@@ -4358,6 +4729,7 @@ func (m *mockLmsAdapter) AcceptItem(
 
 type EmailSenderMock struct {
 	mock.Mock
+	raw []byte
 }
 
 func (s *EmailSenderMock) IsReadyToSend() bool {
@@ -4365,9 +4737,22 @@ func (s *EmailSenderMock) IsReadyToSend() bool {
 }
 
 func (s *EmailSenderMock) SendEmail(from string, to []string, raw []byte) error {
+	s.raw = raw
 	return s.Called(from).Error(0)
 }
 
+type ChannelMock struct {
+	mock.Mock
+}
+
+func (c *ChannelMock) IsReadyToSend() bool {
+	return c.Called().Bool(0)
+}
+
+func (c *ChannelMock) Send(ctx context.Context, to string, text string) error {
+	return c.Called(to, text).Error(0)
+}
+
 type IllRepoMock struct {
 	ill_db.PgIllRepo
 	mock.Mock
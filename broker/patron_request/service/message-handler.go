@@ -13,6 +13,7 @@ import (
 	"github.com/indexdata/crosslink/broker/common"
 	"github.com/indexdata/crosslink/broker/events"
 	"github.com/indexdata/crosslink/broker/ill_db"
+	"github.com/indexdata/crosslink/broker/lms"
 	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
 	"github.com/indexdata/crosslink/broker/shim"
 	dirapi "github.com/indexdata/crosslink/directory/api"
@@ -34,6 +35,7 @@ type PatronRequestMessageHandler struct {
 	eventBus             events.EventBus
 	actionMappingService ActionMappingService
 	autoActionRunner     AutoActionRunner
+	lmsCreator           lms.LmsCreator
 }
 
 type AutoActionRunner interface {
@@ -54,6 +56,11 @@ func (m *PatronRequestMessageHandler) SetAutoActionRunner(autoActionRunner AutoA
 	m.autoActionRunner = autoActionRunner
 }
 
+// SetLmsCreator enables mirroring of renewals in the LMS of the requester
+func (m *PatronRequestMessageHandler) SetLmsCreator(lmsCreator lms.LmsCreator) {
+	m.lmsCreator = lmsCreator
+}
+
 func (m *PatronRequestMessageHandler) runAutoActionsOnStateEntry(ctx common.ExtendedContext, pr pr_db.PatronRequest, parentEventID *string, user string) error {
 	if m.autoActionRunner == nil {
 		return nil
@@ -109,7 +116,7 @@ func (m *PatronRequestMessageHandler) processPatronRequestMessageTask(
 	handler func(execCtx common.ExtendedContext, parentEventID *string) (events.EventStatus, *iso18626.ISO18626Message, error),
 ) (events.EventStatus, *iso18626.ISO18626Message, error) {
 	data := events.EventData{CommonEventData: events.CommonEventData{IncomingMessage: incoming}}
-	eventID, err := m.eventBus.CreateTask(prID, events.EventNamePatronRequestMessage, data, events.EventDomainPatronRequest, nil, events.SignalConsumers)
+	eventID, err := m.eventBus.CreateTask(ctx, prID, events.EventNamePatronRequestMessage, data, events.EventDomainPatronRequest, nil, events.SignalConsumers)
 	if err != nil {
 		return events.EventStatusError, nil, err
 	}
@@ -221,6 +228,8 @@ func (m *PatronRequestMessageHandler) handleSupplyingAgencyMessageWithParent(ctx
 			ctx.Logger().Error("failed to save sam notifications", "error", notErr)
 		}
 		return createSAMResponse(sam, iso18626.TypeMessageStatusOK, nil, nil)
+	case iso18626.TypeReasonForMessageRenewResponse:
+		return m.handleRenewResponse(ctx, sam, pr, parentEventID)
 	case iso18626.TypeReasonForMessageStatusChange,
 		iso18626.TypeReasonForMessageRequestResponse,
 		iso18626.TypeReasonForMessageCancelResponse:
@@ -331,6 +340,67 @@ func (m *PatronRequestMessageHandler) handleSupplyingAgencyMessageWithParent(ctx
 	return m.updatePatronRequestAndCreateSamResponse(ctx, updatedPr, sam, stateChanged, parentEventID)
 }
 
+// handleRenewResponse records the due date of an accepted renewal and mirrors it in the requester LMS.
+// Renewals do not drive state transitions.
+func (m *PatronRequestMessageHandler) handleRenewResponse(ctx common.ExtendedContext, sam iso18626.SupplyingAgencyMessage, pr pr_db.PatronRequest, parentEventID *string) (events.EventStatus, *iso18626.ISO18626Message, error) {
+	if sam.MessageInfo.AnswerYesNo == nil || *sam.MessageInfo.AnswerYesNo != iso18626.TypeYesNoY || sam.StatusInfo.DueDate == nil {
+		notErr := m.extractSamNotifications(ctx, pr, sam)
+		if notErr != nil {
+			ctx.Logger().Error("failed to save sam notifications", "error", notErr)
+		}
+		return createSAMResponse(sam, iso18626.TypeMessageStatusOK, nil, nil)
+	}
+	pr.IllResponse.StatusInfo.DueDate = sam.StatusInfo.DueDate
+	m.renewRequesterLmsItems(ctx, pr, sam.StatusInfo.DueDate.Time, parentEventID)
+	return m.updatePatronRequestAndCreateSamResponse(ctx, pr, sam, false, parentEventID)
+}
+
+// renewRequesterLmsItems extends the loans of the items in the requester LMS to the due date granted by the supplier.
+// Failures are logged only, as the supplier has renewed the loan already.
+func (m *PatronRequestMessageHandler) renewRequesterLmsItems(ctx common.ExtendedContext, pr pr_db.PatronRequest, dateDue time.Time, parentEventID *string) {
+	if m.lmsCreator == nil || !pr.RequesterSymbol.Valid {
+		return
+	}
+	lmsAdapter, err := m.lmsCreator.GetAdapter(ctx, pr.RequesterSymbol.String)
+	if err != nil {
+		ctx.Logger().Warn("failed to create LMS adapter for renewal", "error", err)
+		return
+	}
+	lmsAdapter.SetLogFunc(func(outgoing map[string]any, incoming map[string]any, err error) {
+		status := events.EventStatusSuccess
+		if err != nil {
+			status = events.EventStatusError
+		}
+		var customData = make(map[string]any)
+		customData[events.LMS_OUTGOING_MESSAGE] = outgoing
+		customData[events.LMS_INCOMING_MESSAGE] = incoming
+		eventData := events.EventData{CustomData: customData}
+		_, createErr := m.eventBus.CreateNoticeWithParent(ctx, pr.ID, events.EventNameLmsRequesterMessage, eventData, status, events.EventDomainPatronRequest, parentEventID, events.SignalConsumers)
+		if createErr != nil {
+			ctx.Logger().Error("failed to create LMS log event", "error", createErr)
+		}
+	})
+	items, err := m.prRepo.GetItemsByPrId(ctx, pr.ID)
+	if err != nil {
+		ctx.Logger().Warn("failed to get items for renewal", "error", err)
+		return
+	}
+	patron := ""
+	if pr.Patron.Valid {
+		patron = pr.Patron.String
+	}
+	for _, item := range items {
+		_, err = lmsAdapter.RenewItem(item.Barcode, patron, dateDue)
+		if errors.Is(err, lms.ErrNotSupported) {
+			ctx.Logger().Debug("renewal not supported by requester LMS", "requesterSymbol", pr.RequesterSymbol.String)
+			return
+		}
+		if err != nil {
+			ctx.Logger().Warn("LMS RenewItem failed", "error", err, "itemId", item.Barcode)
+		}
+	}
+}
+
 func (m *PatronRequestMessageHandler) updatePatronRequestAndCreateSamResponse(ctx common.ExtendedContext, pr pr_db.PatronRequest, sam iso18626.SupplyingAgencyMessage, stateChanged bool, parentEventID *string) (events.EventStatus, *iso18626.ISO18626Message, error) {
 	_, err := m.prRepo.UpdatePatronRequest(ctx, pr_db.UpdatePatronRequestParams(pr))
 	if err != nil {
@@ -8,6 +8,7 @@ import (
 	"github.com/indexdata/crosslink/broker/common"
 	"github.com/indexdata/crosslink/broker/events"
 	"github.com/indexdata/crosslink/broker/ill_db"
+	"github.com/indexdata/crosslink/broker/lms"
 	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
 	"github.com/indexdata/crosslink/broker/shim"
 	dirapi "github.com/indexdata/crosslink/directory/api"
@@ -994,6 +995,104 @@ func TestHandleSupplyingAgencyMessageNoImplemented(t *testing.T) {
 	assert.Contains(t, err.Error(), "status change not allowed:")
 }
 
+type mockLmsAdapterRenew struct {
+	lms.LmsAdapterManual
+	renewed []string
+	err     error
+}
+
+func (m *mockLmsAdapterRenew) RenewItem(itemId string, userId string, desiredDateDue time.Time) (time.Time, error) {
+	m.renewed = append(m.renewed, itemId+"/"+userId+"/"+desiredDateDue.Format(time.DateOnly))
+	return desiredDateDue, m.err
+}
+
+func renewResponse(answer iso18626.TypeYesNo) iso18626.SupplyingAgencyMessage {
+	return iso18626.SupplyingAgencyMessage{
+		Header: iso18626.Header{
+			RequestingAgencyRequestId: patronRequestId,
+		},
+		StatusInfo: iso18626.StatusInfo{
+			Status:  iso18626.TypeStatusLoaned,
+			DueDate: &utils.XSDDateTime{Time: time.Date(2030, 3, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		MessageInfo: iso18626.MessageInfo{
+			ReasonForMessage: iso18626.TypeReasonForMessageRenewResponse,
+			AnswerYesNo:      &answer,
+		},
+	}
+}
+
+func TestHandleSupplyingAgencyMessageRenewResponseAccepted(t *testing.T) {
+	mockPrRepo := new(MockPrRepo)
+	mockPrRepo.On("GetItemsByPrId", patronRequestId).Return([]pr_db.Item{{Barcode: "b1"}, {Barcode: "b2"}}, nil)
+	lmsAdapter := &mockLmsAdapterRenew{}
+	lmsCreator := new(MockLmsCreator)
+	lmsCreator.On("GetAdapter", "ISIL:REQ").Return(lmsAdapter, nil)
+	handler := CreatePatronRequestMessageHandler(mockPrRepo, *new(events.EventRepo), *new(ill_db.IllRepo), *new(events.EventBus))
+	handler.SetLmsCreator(lmsCreator)
+
+	status, resp, err := handler.handleSupplyingAgencyMessage(appCtx, renewResponse(iso18626.TypeYesNoY), pr_db.PatronRequest{
+		ID:              patronRequestId,
+		State:           BorrowerStateCheckedOut,
+		Side:            SideBorrowing,
+		RequesterSymbol: pgtype.Text{String: "ISIL:REQ", Valid: true},
+		Patron:          pgtype.Text{String: "p1", Valid: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, events.EventStatusSuccess, status)
+	assert.Equal(t, iso18626.TypeMessageStatusOK, resp.SupplyingAgencyMessageConfirmation.ConfirmationHeader.MessageStatus)
+	assert.Equal(t, BorrowerStateCheckedOut, mockPrRepo.savedPr.State)
+	assert.Equal(t, time.Date(2030, 3, 1, 0, 0, 0, 0, time.UTC), mockPrRepo.savedPr.IllResponse.StatusInfo.DueDate.Time)
+	assert.Equal(t, []string{"b1/p1/2030-03-01", "b2/p1/2030-03-01"}, lmsAdapter.renewed)
+}
+
+func TestHandleSupplyingAgencyMessageRenewResponseLmsFailure(t *testing.T) {
+	for _, lmsErr := range []error{lms.ErrNotSupported, errors.New("LMS down")} {
+		mockPrRepo := new(MockPrRepo)
+		mockPrRepo.On("GetItemsByPrId", patronRequestId).Return([]pr_db.Item{{Barcode: "b1"}, {Barcode: "b2"}}, nil)
+		lmsAdapter := &mockLmsAdapterRenew{err: lmsErr}
+		lmsCreator := new(MockLmsCreator)
+		lmsCreator.On("GetAdapter", "ISIL:REQ").Return(lmsAdapter, nil)
+		handler := CreatePatronRequestMessageHandler(mockPrRepo, *new(events.EventRepo), *new(ill_db.IllRepo), *new(events.EventBus))
+		handler.SetLmsCreator(lmsCreator)
+
+		status, resp, err := handler.handleSupplyingAgencyMessage(appCtx, renewResponse(iso18626.TypeYesNoY), pr_db.PatronRequest{
+			ID:              patronRequestId,
+			State:           BorrowerStateCheckedOut,
+			Side:            SideBorrowing,
+			RequesterSymbol: pgtype.Text{String: "ISIL:REQ", Valid: true},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, events.EventStatusSuccess, status)
+		assert.Equal(t, iso18626.TypeMessageStatusOK, resp.SupplyingAgencyMessageConfirmation.ConfirmationHeader.MessageStatus)
+		assert.NotNil(t, mockPrRepo.savedPr.IllResponse.StatusInfo.DueDate)
+		if errors.Is(lmsErr, lms.ErrNotSupported) {
+			assert.Len(t, lmsAdapter.renewed, 1)
+		} else {
+			assert.Len(t, lmsAdapter.renewed, 2)
+		}
+	}
+}
+
+func TestHandleSupplyingAgencyMessageRenewResponseRejected(t *testing.T) {
+	mockPrRepo := new(MockPrRepo)
+	lmsCreator := new(MockLmsCreator)
+	handler := CreatePatronRequestMessageHandler(mockPrRepo, *new(events.EventRepo), *new(ill_db.IllRepo), *new(events.EventBus))
+	handler.SetLmsCreator(lmsCreator)
+
+	status, resp, err := handler.handleSupplyingAgencyMessage(appCtx, renewResponse(iso18626.TypeYesNoN), pr_db.PatronRequest{
+		ID:              patronRequestId,
+		State:           BorrowerStateCheckedOut,
+		Side:            SideBorrowing,
+		RequesterSymbol: pgtype.Text{String: "ISIL:REQ", Valid: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, events.EventStatusSuccess, status)
+	assert.Equal(t, iso18626.TypeMessageStatusOK, resp.SupplyingAgencyMessageConfirmation.ConfirmationHeader.MessageStatus)
+	assert.Empty(t, mockPrRepo.savedPr.ID)
+	lmsCreator.AssertNotCalled(t, "GetAdapter", mock.Anything)
+}
+
 type MockIllRepo struct {
 	mock.Mock
 	ill_db.PgIllRepo
@@ -0,0 +1,195 @@
+package prservice
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/indexdata/crosslink/broker/channel"
+	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/email"
+	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
+	"github.com/indexdata/crosslink/broker/patron_request/proapi"
+	"github.com/indexdata/crosslink/broker/retry"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	DEFAULT_OUTBOX_MAX_ATTEMPTS  = 8
+	DEFAULT_OUTBOX_RETRY_BACKOFF = time.Minute
+	DEFAULT_OUTBOX_POLL_INTERVAL = 30 * time.Second
+	MAX_OUTBOX_RETRY_BACKOFF     = 2 * time.Hour
+	// OUTBOX_CLAIM is how long a message being sent is hidden from other instances.
+	OUTBOX_CLAIM      = 5 * time.Minute
+	OUTBOX_BATCH_SIZE = 50
+)
+
+// Outbox stores outgoing emails and sms or push texts and sends them in the background,
+// retrying failed attempts with an increasing delay until MaxAttempts is reached.
+type Outbox struct {
+	retry.Policy
+	prRepo       pr_db.PrRepo
+	emailService email.EmailService
+	channels     map[proapi.NotificationChannel]channel.Channel
+}
+
+func NewOutbox(prRepo pr_db.PrRepo, emailService email.EmailService) *Outbox {
+	return &Outbox{
+		Policy: retry.Policy{
+			MaxAttempts:  DEFAULT_OUTBOX_MAX_ATTEMPTS,
+			RetryBackoff: DEFAULT_OUTBOX_RETRY_BACKOFF,
+			MaxBackoff:   MAX_OUTBOX_RETRY_BACKOFF,
+			PollInterval: DEFAULT_OUTBOX_POLL_INTERVAL,
+		},
+		prRepo:       prRepo,
+		emailService: emailService,
+		channels:     map[proapi.NotificationChannel]channel.Channel{},
+	}
+}
+
+// SetChannel sets the channel that texts of the given kind, e.g. sms, are sent on.
+func (o *Outbox) SetChannel(name proapi.NotificationChannel, ch channel.Channel) {
+	o.channels[name] = ch
+}
+
+// IsReadyToSend reports whether texts can be sent on the channel.
+func (o *Outbox) IsReadyToSend(name proapi.NotificationChannel) bool {
+	ch, ok := o.channels[name]
+	return ok && ch.IsReadyToSend()
+}
+
+// Queue stores an email about pr as an email notification of owner and sends it in the background.
+// The further notifications given, e.g. the reminder the email is about, are stored in the same transaction.
+// The returned error is only about building and storing the message, delivery failures are
+// recorded on the message.
+func (o *Outbox) Queue(ctx common.ExtendedContext, pr pr_db.PatronRequest, owner string, from string, emailData email.EmailData, notifications ...pr_db.SaveNotificationParams) (pr_db.OutboxMessage, error) {
+	raw, err := email.BuildRawMessage(from, emailData)
+	if err != nil {
+		return pr_db.OutboxMessage{}, err
+	}
+	return o.queue(ctx, pr, owner, pr_db.NotificationKindEmail, emailData.Subject, pr_db.SaveOutboxMessageParams{
+		FromAddress: from,
+		Recipients:  emailData.To,
+		Message:     raw,
+		Channel:     string(proapi.NotificationChannelEmail),
+	}, notifications...)
+}
+
+// QueueText stores a text about pr to a single recipient as an sms or push notification of owner
+// and sends it on the channel in the background.
+func (o *Outbox) QueueText(ctx common.ExtendedContext, pr pr_db.PatronRequest, owner string, name proapi.NotificationChannel, to string, text string) (pr_db.OutboxMessage, error) {
+	return o.queue(ctx, pr, owner, pr_db.NotificationKind(name), text, pr_db.SaveOutboxMessageParams{
+		Recipients: []string{to},
+		Message:    []byte(text),
+		Channel:    string(name),
+	})
+}
+
+func (o *Outbox) queue(ctx common.ExtendedContext, pr pr_db.PatronRequest, owner string, kind pr_db.NotificationKind, note string, params pr_db.SaveOutboxMessageParams, notifications ...pr_db.SaveNotificationParams) (pr_db.OutboxMessage, error) {
+	now := time.Now()
+	var message pr_db.OutboxMessage
+	err := o.prRepo.WithTxFunc(ctx, func(repo pr_db.PrRepo) error {
+		notification, err := repo.SaveNotification(ctx, pr_db.SaveNotificationParams{
+			ID:             uuid.NewString(),
+			PrID:           pr.ID,
+			FromSymbol:     owner,
+			ToSymbol:       strings.Join(params.Recipients, "; "),
+			Direction:      pr_db.NotificationDirectionSent,
+			Kind:           kind,
+			Note:           pgtype.Text{String: note, Valid: true},
+			CreatedAt:      pgtype.Timestamp{Time: now, Valid: true},
+			AcknowledgedAt: pgtype.Timestamp{Time: now, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		params.ID = uuid.NewString()
+		params.NotificationID = pgtype.Text{String: notification.ID, Valid: true}
+		params.Owner = owner
+		params.Status = pr_db.OutboxMessageStatusQueued
+		params.NextAttemptAt = pgtype.Timestamp{Time: now.Add(OUTBOX_CLAIM), Valid: true}
+		params.CreatedAt = pgtype.Timestamp{Time: now, Valid: true}
+		message, err = repo.SaveOutboxMessage(ctx, params)
+		if err != nil {
+			return err
+		}
+		for _, n := range notifications {
+			if _, err = repo.SaveNotification(ctx, n); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return pr_db.OutboxMessage{}, err
+	}
+	go o.deliver(ctx, message)
+	return message, nil
+}
+
+// Run retries due messages every PollInterval until the context is done.
+func (o *Outbox) Run(ctx common.ExtendedContext) {
+	o.Poll(ctx, o.ProcessDueMessages)
+}
+
+// ProcessDueMessages claims queued messages whose next attempt is due and sends them.
+func (o *Outbox) ProcessDueMessages(ctx common.ExtendedContext) {
+	ctx = ctx.WithArgs(ctx.LoggerArgs().WithComponent(COMP))
+	messages, err := o.prRepo.ClaimDueOutboxMessages(ctx, OUTBOX_CLAIM, OUTBOX_BATCH_SIZE)
+	if err != nil {
+		ctx.Logger().Error("failed to claim outbox messages", "error", err)
+		return
+	}
+	for _, message := range messages {
+		o.deliver(ctx, message)
+	}
+}
+
+func (o *Outbox) deliver(ctx common.ExtendedContext, message pr_db.OutboxMessage) {
+	now := time.Now()
+	params := pr_db.UpdateOutboxMessageParams{
+		ID:            message.ID,
+		Attempts:      message.Attempts + 1,
+		LastAttemptAt: pgtype.Timestamp{Time: now, Valid: true},
+	}
+	sendErr := o.send(ctx, message)
+	result := o.Attempted(params.Attempts, now, sendErr, email.IsPermanentFailure(sendErr) || channel.IsPermanentFailure(sendErr))
+	switch result.Outcome {
+	case retry.OutcomeSent:
+		params.Status = pr_db.OutboxMessageStatusSent
+		params.SentAt = pgtype.Timestamp{Time: now, Valid: true}
+	case retry.OutcomeRejected:
+		params.Status = pr_db.OutboxMessageStatusBounced
+	case retry.OutcomeFailed:
+		params.Status = pr_db.OutboxMessageStatusFailed
+	default:
+		params.Status = pr_db.OutboxMessageStatusQueued
+		params.NextAttemptAt = pgtype.Timestamp{Time: result.NextAttemptAt, Valid: true}
+	}
+	if sendErr != nil {
+		params.Error = pgtype.Text{String: result.Error, Valid: true}
+		ctx.Logger().Warn("outbox delivery failed", "error", sendErr, "messageId", message.ID,
+			"channel", message.Channel, "attempts", params.Attempts, "status", params.Status)
+	}
+	_, err := o.prRepo.UpdateOutboxMessage(ctx, params)
+	if err != nil {
+		ctx.Logger().Error("failed to update outbox message", "error", err, "messageId", message.ID)
+	}
+}
+
+func (o *Outbox) send(ctx common.ExtendedContext, message pr_db.OutboxMessage) error {
+	if message.Channel == string(proapi.NotificationChannelEmail) {
+		return o.emailService.SendEmail(message.FromAddress, message.Recipients, message.Message)
+	}
+	ch, ok := o.channels[proapi.NotificationChannel(message.Channel)]
+	if !ok {
+		return errors.New("no channel " + message.Channel)
+	}
+	for _, to := range message.Recipients {
+		if err := ch.Send(ctx, to, string(message.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package prservice
+
+import (
+	"errors"
+	"net/http"
+	"net/textproto"
+	"testing"
+	"time"
+
+	"github.com/indexdata/crosslink/broker/channel"
+	"github.com/indexdata/crosslink/broker/email"
+	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
+	"github.com/indexdata/crosslink/broker/patron_request/proapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOutboxQueue(t *testing.T) {
+	prRepo := new(MockPrRepo)
+	emailSvc := new(EmailSenderMock)
+	emailSvc.On("SendEmail", testFrom).Return(nil)
+	outbox := NewOutbox(prRepo, emailSvc)
+
+	message, err := outbox.Queue(appCtx, pr_db.PatronRequest{ID: "pr-1"}, testSymbol, testFrom, emailData(testPatronTo, "staff@example.com"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, pr_db.OutboxMessageStatusQueued, message.Status)
+	if assert.Len(t, prRepo.savedNotifications, 1) {
+		notification := prRepo.savedNotifications[0]
+		assert.Equal(t, notification.ID, message.NotificationID.String)
+		assert.Equal(t, pr_db.NotificationKindEmail, notification.Kind)
+		assert.Equal(t, pr_db.NotificationDirectionSent, notification.Direction)
+		assert.Equal(t, testSymbol, notification.FromSymbol)
+		assert.Equal(t, testPatronTo+"; staff@example.com", notification.ToSymbol)
+		assert.Equal(t, "Subject", notification.Note.String)
+	}
+	assert.Contains(t, string(message.Message), "Subject: Subject")
+	assert.Equal(t, "email", prRepo.savedOutboxMessages[0].Channel)
+	prRepo.waitForOutboxDelivery(t)
+	updates := prRepo.outboxUpdates()
+	assert.Equal(t, message.ID, updates[0].ID)
+	assert.Equal(t, pr_db.OutboxMessageStatusSent, updates[0].Status)
+	assert.Equal(t, int32(1), updates[0].Attempts)
+	assert.True(t, updates[0].SentAt.Valid)
+	emailSvc.AssertExpectations(t)
+}
+
+func TestOutboxQueueWithNotifications(t *testing.T) {
+	prRepo := new(MockPrRepo)
+	emailSvc := new(EmailSenderMock)
+	emailSvc.On("SendEmail", testFrom).Return(nil)
+	outbox := NewOutbox(prRepo, emailSvc)
+	reminder := pr_db.SaveNotificationParams{ID: "reminder-1", PrID: "pr-1", Kind: pr_db.NotificationKindReminder}
+
+	_, err := outbox.Queue(appCtx, pr_db.PatronRequest{ID: "pr-1"}, testSymbol, testFrom, emailData(testPatronTo), reminder)
+
+	assert.NoError(t, err)
+	if assert.Len(t, prRepo.savedNotifications, 2) {
+		assert.Equal(t, pr_db.NotificationKindEmail, prRepo.savedNotifications[0].Kind)
+		assert.Equal(t, "reminder-1", prRepo.savedNotifications[1].ID)
+	}
+	prRepo.waitForOutboxDelivery(t)
+
+	prRepo = new(MockPrRepo)
+	outbox = NewOutbox(prRepo, emailSvc)
+	reminder.PrID = "error"
+	_, err = outbox.Queue(appCtx, pr_db.PatronRequest{ID: "pr-1"}, testSymbol, testFrom, emailData(testPatronTo), reminder)
+
+	assert.EqualError(t, err, "db error")
+	assert.Empty(t, prRepo.outboxUpdates())
+	emailSvc.AssertNumberOfCalls(t, "SendEmail", 1)
+}
+
+func TestOutboxQueueInvalidMessage(t *testing.T) {
+	prRepo := new(MockPrRepo)
+	emailSvc := new(EmailSenderMock)
+	outbox := NewOutbox(prRepo, emailSvc)
+
+	_, err := outbox.Queue(appCtx, pr_db.PatronRequest{ID: "pr-1"}, testSymbol, "bad\r\nfrom@example.com", emailData(testPatronTo))
+
+	assert.ErrorContains(t, err, "header injection")
+	assert.Empty(t, prRepo.savedNotifications)
+	emailSvc.AssertNotCalled(t, "SendEmail", mock.Anything)
+}
+
+func TestOutboxQueueSaveError(t *testing.T) {
+	prRepo := new(MockPrRepo)
+	emailSvc := new(EmailSenderMock)
+	outbox := NewOutbox(prRepo, emailSvc)
+
+	_, err := outbox.Queue(appCtx, pr_db.PatronRequest{ID: "error"}, testSymbol, testFrom, emailData(testPatronTo))
+
+	assert.EqualError(t, err, "db error")
+	assert.Empty(t, prRepo.savedOutboxMessages)
+	emailSvc.AssertNotCalled(t, "SendEmail", mock.Anything)
+}
+
+func TestOutboxQueueText(t *testing.T) {
+	prRepo := new(MockPrRepo)
+	sms := new(ChannelMock)
+	sms.On("Send", "+4512345678", "Dune is ready").Return(nil)
+	outbox := NewOutbox(prRepo, new(EmailSenderMock))
+	outbox.SetChannel(proapi.NotificationChannelSms, sms)
+
+	message, err := outbox.QueueText(appCtx, pr_db.PatronRequest{ID: "pr-1"}, testSymbol, proapi.NotificationChannelSms, "+4512345678", "Dune is ready")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "sms", message.Channel)
+	assert.Equal(t, []string{"+4512345678"}, message.Recipients)
+	assert.Empty(t, message.FromAddress)
+	if assert.Len(t, prRepo.savedNotifications, 1) {
+		notification := prRepo.savedNotifications[0]
+		assert.Equal(t, notification.ID, message.NotificationID.String)
+		assert.Equal(t, pr_db.NotificationKindSms, notification.Kind)
+		assert.Equal(t, "+4512345678", notification.ToSymbol)
+		assert.Equal(t, "Dune is ready", notification.Note.String)
+	}
+	prRepo.waitForOutboxDelivery(t)
+	updates := prRepo.outboxUpdates()
+	assert.Equal(t, pr_db.OutboxMessageStatusSent, updates[0].Status)
+	sms.AssertExpectations(t)
+}
+
+func TestOutboxDeliverText(t *testing.T) {
+	tests := []struct {
+		name       string
+		channel    string
+		sendErr    error
+		wantStatus pr_db.OutboxMessageStatus
+		wantError  string
+	}{
+		{
+			name:       "sent",
+			channel:    "sms",
+			wantStatus: pr_db.OutboxMessageStatusSent,
+		},
+		{
+			name:       "rate limit is retried",
+			channel:    "sms",
+			sendErr:    &channel.ProviderError{StatusCode: http.StatusTooManyRequests, Body: "slow down"},
+			wantStatus: pr_db.OutboxMessageStatusQueued,
+			wantError:  "provider returned HTTP status 429: slow down",
+		},
+		{
+			name:       "rejected text is bounced",
+			channel:    "sms",
+			sendErr:    &channel.ProviderError{StatusCode: http.StatusBadRequest, Body: "invalid number"},
+			wantStatus: pr_db.OutboxMessageStatusBounced,
+			wantError:  "provider returned HTTP status 400: invalid number",
+		},
+		{
+			name:       "channel not set is retried",
+			channel:    "push",
+			wantStatus: pr_db.OutboxMessageStatusQueued,
+			wantError:  "no channel push",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			prRepo := new(MockPrRepo)
+			sms := new(ChannelMock)
+			sms.On("Send", "+4512345678", "Dune is ready").Return(tc.sendErr)
+			outbox := NewOutbox(prRepo, new(EmailSenderMock))
+			outbox.SetChannel(proapi.NotificationChannelSms, sms)
+
+			outbox.deliver(appCtx, pr_db.OutboxMessage{ID: "sms-1", Recipients: []string{"+4512345678"}, Message: []byte("Dune is ready"), Channel: tc.channel})
+
+			updates := prRepo.outboxUpdates()
+			if assert.Len(t, updates, 1) {
+				assert.Equal(t, tc.wantStatus, updates[0].Status)
+				assert.Equal(t, tc.wantError, updates[0].Error.String)
+			}
+		})
+	}
+}
+
+func TestOutboxDeliver(t *testing.T) {
+	tests := []struct {
+		name          string
+		attempts      int32
+		sendErr       error
+		wantStatus    pr_db.OutboxMessageStatus
+		wantNextDelay time.Duration
+		wantError     string
+	}{
+		{
+			name:       "sent",
+			wantStatus: pr_db.OutboxMessageStatusSent,
+		},
+		{
+			name:          "temporary failure is retried after backoff",
+			sendErr:       &textproto.Error{Code: 451, Msg: "try again later"},
+			wantStatus:    pr_db.OutboxMessageStatusQueued,
+			wantNextDelay: time.Minute,
+			wantError:     `451 "try again later"`,
+		},
+		{
+			name:          "backoff doubles with every attempt",
+			attempts:      3,
+			sendErr:       errors.New("connection refused"),
+			wantStatus:    pr_db.OutboxMessageStatusQueued,
+			wantNextDelay: 8 * time.Minute,
+			wantError:     "connection refused",
+		},
+		{
+			name:       "permanent failure is bounced",
+			sendErr:    &textproto.Error{Code: 550, Msg: "no such user"},
+			wantStatus: pr_db.OutboxMessageStatusBounced,
+			wantError:  `550 "no such user"`,
+		},
+		{
+			name:       "last attempt failed",
+			attempts:   DEFAULT_OUTBOX_MAX_ATTEMPTS - 1,
+			sendErr:    errors.New("connection refused"),
+			wantStatus: pr_db.OutboxMessageStatusFailed,
+			wantError:  "connection refused",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			prRepo := new(MockPrRepo)
+			emailSvc := new(EmailSenderMock)
+			emailSvc.On("SendEmail", testFrom).Return(tc.sendErr)
+			outbox := NewOutbox(prRepo, emailSvc)
+
+			before := time.Now()
+			outbox.deliver(appCtx, pr_db.OutboxMessage{ID: "email-1", FromAddress: testFrom, Recipients: []string{testPatronTo}, Attempts: tc.attempts, Channel: "email"})
+
+			updates := prRepo.outboxUpdates()
+			if assert.Len(t, updates, 1) {
+				update := updates[0]
+				assert.Equal(t, "email-1", update.ID)
+				assert.Equal(t, tc.wantStatus, update.Status)
+				assert.Equal(t, tc.attempts+1, update.Attempts)
+				assert.True(t, update.LastAttemptAt.Valid)
+				assert.Equal(t, tc.wantError, update.Error.String)
+				assert.Equal(t, tc.wantStatus == pr_db.OutboxMessageStatusSent, update.SentAt.Valid)
+				if tc.wantNextDelay > 0 {
+					assert.WithinDuration(t, before.Add(tc.wantNextDelay), update.NextAttemptAt.Time, time.Second)
+				} else {
+					assert.False(t, update.NextAttemptAt.Valid)
+				}
+			}
+		})
+	}
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	outbox := NewOutbox(new(MockPrRepo), new(EmailSenderMock))
+
+	assert.Equal(t, time.Minute, outbox.Backoff(1))
+	assert.Equal(t, 2*time.Minute, outbox.Backoff(2))
+	assert.Equal(t, 64*time.Minute, outbox.Backoff(7))
+	assert.Equal(t, MAX_OUTBOX_RETRY_BACKOFF, outbox.Backoff(8))
+	assert.Equal(t, MAX_OUTBOX_RETRY_BACKOFF, outbox.Backoff(100))
+}
+
+func TestOutboxProcessDueMessages(t *testing.T) {
+	prRepo := new(MockPrRepo)
+	prRepo.On("ClaimDueOutboxMessages", OUTBOX_CLAIM, int32(OUTBOX_BATCH_SIZE)).Return([]pr_db.OutboxMessage{
+		{ID: "email-1", FromAddress: testFrom, Recipients: []string{testPatronTo}, Attempts: 1, Channel: "email"},
+		{ID: "email-2", FromAddress: testFrom, Recipients: []string{testPatronTo}, Attempts: 2, Channel: "email"},
+	}, nil)
+	emailSvc := new(EmailSenderMock)
+	emailSvc.On("SendEmail", testFrom).Return(nil)
+	outbox := NewOutbox(prRepo, emailSvc)
+
+	outbox.ProcessDueMessages(appCtx)
+
+	updates := prRepo.outboxUpdates()
+	if assert.Len(t, updates, 2) {
+		assert.Equal(t, "email-1", updates[0].ID)
+		assert.Equal(t, int32(2), updates[0].Attempts)
+		assert.Equal(t, "email-2", updates[1].ID)
+		assert.Equal(t, int32(3), updates[1].Attempts)
+	}
+	emailSvc.AssertNumberOfCalls(t, "SendEmail", 2)
+}
+
+func TestOutboxProcessDueMessagesClaimError(t *testing.T) {
+	prRepo := new(MockPrRepo)
+	prRepo.On("ClaimDueOutboxMessages", OUTBOX_CLAIM, int32(OUTBOX_BATCH_SIZE)).Return([]pr_db.OutboxMessage{}, errors.New("db error"))
+	emailSvc := new(EmailSenderMock)
+	outbox := NewOutbox(prRepo, emailSvc)
+
+	outbox.ProcessDueMessages(appCtx)
+
+	assert.Empty(t, prRepo.outboxUpdates())
+	emailSvc.AssertNotCalled(t, "SendEmail", mock.Anything)
+}
+
+func emailData(to ...string) email.EmailData {
+	return email.EmailData{To: to, Subject: "Subject", Body: "Body"}
+}
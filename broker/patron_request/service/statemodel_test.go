@@ -7,6 +7,7 @@ import (
 
 	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
 	"github.com/indexdata/crosslink/broker/patron_request/proapi"
+	prtemplate "github.com/indexdata/crosslink/broker/patron_request/template"
 	"github.com/indexdata/crosslink/iso18626"
 	"github.com/stretchr/testify/assert"
 )
@@ -943,3 +944,10 @@ func TestStateModelServiceConcurrentGetStateModel(t *testing.T) {
 	}
 	assert.NotNil(t, first)
 }
+
+func TestTemplateDefaultsAreValid(t *testing.T) {
+	for _, template := range GetStateModelTemplateDefaults() {
+		err := prtemplate.ValidateTemplate(string(template.ContentType), template.Subject, template.Body)
+		assert.NoError(t, err, template.Title)
+	}
+}
@@ -0,0 +1,264 @@
+package prtemplate
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
+	"github.com/indexdata/crosslink/iso18626"
+	"github.com/indexdata/go-utils/utils"
+)
+
+// Data is what email and printed document templates are executed with. Notifications about a single
+// patron request use Request, batch actions use Batch, the other one is left empty.
+// Pull slips, labels and book bands set Request and the embedded PullSlip, whose fields are used directly, e.g. {{.ReqId}}.
+type Data struct {
+	PullSlip
+	// Request is the patron request the notification is about
+	Request Request
+	// Batch describes the requests selected by a batch action
+	Batch Batch
+	// Now is the time the template is rendered
+	Now time.Time
+}
+
+// PullSlip is the template data of a pull slip, shipping label or book band, its fields are
+// formatted for printing and set to n/a when there is no value.
+type PullSlip struct {
+	BorrowerName   string
+	ReqId          string
+	PickupLocation string
+	Title          string
+	Author         string
+	// DueDate is formatted as 2006-01-02
+	DueDate       string
+	ReturnAddress string
+	// DeliveryAddress is the delivery address requested by the borrower, one line per address part
+	DeliveryAddress string
+	// BarcodeBase64 is a Code 128 barcode of ReqId as a base64 encoded PNG image
+	BarcodeBase64 string
+	// QrCodeBase64 is a QR code of ReqId as a base64 encoded PNG image
+	QrCodeBase64     string
+	ServiceType      string
+	ServiceLevel     string
+	SystemIdentifier string
+	Publisher        string
+	Volume           string
+	Issue            string
+	Pages            string
+	// StaffNotes are the notes exchanged with the peer, one per line
+	StaffNotes string
+	// CallNumber lists the call numbers of the items, separated by commas
+	CallNumber string
+	// LoanConditions are the loan conditions proposed by the supplier, one per line
+	LoanConditions string
+}
+
+// Batch is the template data of a batch action run.
+type Batch struct {
+	// Query is the CQL query selecting the requests
+	Query string
+	// ActualCount is the number of requests included, at most the batch limit
+	ActualCount int
+	// FullCount is the number of requests matching the query
+	FullCount int64
+	// Requests are the included requests
+	Requests []Request
+}
+
+// Request is the template data of a patron request.
+type Request struct {
+	ID string
+	// Hrid is the human readable request id shared with the peer
+	Hrid  string
+	State string
+	// Side is either borrowing or lending
+	Side            string
+	Patron          string
+	Title           string
+	Author          string
+	Publisher       string
+	Volume          string
+	Issue           string
+	Pages           string
+	PickupLocation  string
+	RequesterSymbol string
+	RequesterName   string
+	SupplierSymbol  string
+	SupplierName    string
+	// NeedBefore is the date the patron needs the item by, if given
+	NeedBefore *time.Time
+	// DueDate is the due date set by the supplier, if known
+	DueDate *time.Time
+	// MaxCost is the most the requester is willing to pay, if given
+	MaxCost *Amount
+	// OfferedCost is the cost the supplier asked for, if any
+	OfferedCost *Amount
+	CreatedAt   time.Time
+	Items       []Item
+	// Notes are the notes exchanged with the peer
+	Notes []Notification
+	// Conditions are the loan conditions proposed by the supplier
+	Conditions []Notification
+}
+
+// Item is a physical item shipped for a request.
+type Item struct {
+	Barcode    string
+	CallNumber string
+	Title      string
+}
+
+// Notification is a note or loan condition exchanged with the peer.
+type Notification struct {
+	From      string
+	Note      string
+	Condition string
+	Cost      *Amount
+	// Receipt is ACCEPTED, REJECTED, SEEN, SENT or FAILED_TO_SEND
+	Receipt   string
+	CreatedAt time.Time
+}
+
+// Amount is a monetary value in the given ISO 4217 currency.
+type Amount struct {
+	Value    float64
+	Currency string
+}
+
+// NewRequest creates the template data of a patron request and its notes and loan conditions.
+// The requester and supplier names are left for the caller to fill in.
+func NewRequest(pr pr_db.PatronRequest, notifications []pr_db.Notification) Request {
+	bibInfo := pr.IllRequest.BibliographicInfo
+	request := Request{
+		ID:              pr.ID,
+		Hrid:            pr.RequesterReqID.String,
+		State:           string(pr.State),
+		Side:            string(pr.Side),
+		Patron:          pr.Patron.String,
+		Title:           bibInfo.Title,
+		Author:          bibInfo.Author,
+		Volume:          bibInfo.Volume,
+		Issue:           bibInfo.Issue,
+		Pages:           bibInfo.EstimatedNoPages,
+		PickupLocation:  PickupLocation(pr),
+		RequesterSymbol: pr.RequesterSymbol.String,
+		SupplierSymbol:  pr.SupplierSymbol.String,
+		NeedBefore:      toTime(getNeedBefore(pr.IllRequest)),
+		DueDate:         toTime(pr.IllResponse.StatusInfo.DueDate),
+		CreatedAt:       pr.CreatedAt.Time,
+		Items:           []Item{},
+		Notes:           []Notification{},
+		Conditions:      []Notification{},
+	}
+	if pr.IllRequest.PublicationInfo != nil {
+		request.Publisher = pr.IllRequest.PublicationInfo.Publisher
+	}
+	if pr.IllRequest.BillingInfo != nil {
+		request.MaxCost = toAmount(pr.IllRequest.BillingInfo.MaximumCosts)
+	}
+	request.OfferedCost = toAmount(pr.IllResponse.MessageInfo.OfferedCosts)
+	for _, item := range pr.Items {
+		request.Items = append(request.Items, Item{
+			Barcode:    item.Barcode,
+			CallNumber: getString(item.CallNumber),
+			Title:      getString(item.Title),
+		})
+	}
+	for _, n := range notifications {
+		// messages sent to the patron are not notes exchanged with the peer
+		switch n.Kind {
+		case pr_db.NotificationKindEmail, pr_db.NotificationKindReminder, pr_db.NotificationKindSms, pr_db.NotificationKindPush:
+			continue
+		}
+		notification := Notification{
+			From:      n.FromSymbol,
+			Note:      n.Note.String,
+			Condition: n.Condition.String,
+			Receipt:   string(n.Receipt),
+			CreatedAt: n.CreatedAt.Time,
+		}
+		if n.Cost.Valid {
+			cost, err := n.Cost.Float64Value()
+			if err == nil && cost.Valid {
+				notification.Cost = &Amount{Value: cost.Float64, Currency: n.Currency.String}
+			}
+		}
+		if n.Kind == pr_db.NotificationKindCondition {
+			request.Conditions = append(request.Conditions, notification)
+		} else {
+			request.Notes = append(request.Notes, notification)
+		}
+	}
+	return request
+}
+
+// PickupLocation returns the requested delivery address of a patron request, or an empty string.
+func PickupLocation(pr pr_db.PatronRequest) string {
+	if len(pr.IllRequest.RequestedDeliveryInfo) > 0 && pr.IllRequest.RequestedDeliveryInfo[0].Address != nil {
+		address := *pr.IllRequest.RequestedDeliveryInfo[0].Address
+		if address.PhysicalAddress != nil {
+			return FormatPhysicalAddress(address.PhysicalAddress)
+		} else if address.ElectronicAddress != nil {
+			return address.ElectronicAddress.ElectronicAddressData
+		}
+	}
+	return ""
+}
+
+// FormatPhysicalAddress joins the non-empty parts of an address with commas.
+func FormatPhysicalAddress(a *iso18626.PhysicalAddress) string {
+	parts := []string{}
+	if a.Line1 != "" {
+		parts = append(parts, a.Line1)
+	}
+	if a.Line2 != "" {
+		parts = append(parts, a.Line2)
+	}
+	if a.Locality != "" {
+		parts = append(parts, a.Locality)
+	}
+	if a.PostalCode != "" {
+		parts = append(parts, a.PostalCode)
+	}
+	if a.Region != nil && a.Region.Text != "" {
+		parts = append(parts, a.Region.Text)
+	}
+	if a.Country != nil && a.Country.Text != "" {
+		parts = append(parts, a.Country.Text)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func getNeedBefore(request iso18626.Request) *utils.XSDDateTime {
+	if request.ServiceInfo == nil {
+		return nil
+	}
+	return request.ServiceInfo.NeedBeforeDate
+}
+
+func toTime(dt *utils.XSDDateTime) *time.Time {
+	if dt == nil || dt.IsZero() {
+		return nil
+	}
+	return &dt.Time
+}
+
+func toAmount(costs *iso18626.TypeCosts) *Amount {
+	if costs == nil {
+		return nil
+	}
+	value, err := strconv.ParseFloat(utils.FormatDecimal(costs.MonetaryValue.Base, costs.MonetaryValue.Exp), 64)
+	if err != nil {
+		return nil
+	}
+	return &Amount{Value: value, Currency: costs.CurrencyCode.Text}
+}
+
+func getString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
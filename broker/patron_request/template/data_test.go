@@ -0,0 +1,142 @@
+package prtemplate
+
+import (
+	"testing"
+	"time"
+
+	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
+	"github.com/indexdata/crosslink/iso18626"
+	"github.com/indexdata/go-utils/utils"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRequest(t *testing.T) {
+	dueDate := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	callNumber := "QA 1"
+	var maxCost utils.XSDDecimal
+	assert.NoError(t, maxCost.UnmarshalText([]byte("25.5")))
+	var cost pgtype.Numeric
+	assert.NoError(t, cost.Scan("12.5"))
+	pr := pr_db.PatronRequest{
+		ID:              "pr1",
+		RequesterReqID:  pgtype.Text{String: "REQ-1", Valid: true},
+		State:           "SHIPPED",
+		Side:            "borrowing",
+		Patron:          pgtype.Text{String: "Jane", Valid: true},
+		RequesterSymbol: pgtype.Text{String: "ISIL:REQ", Valid: true},
+		SupplierSymbol:  pgtype.Text{String: "ISIL:SUP", Valid: true},
+		IllRequest: iso18626.Request{
+			BibliographicInfo: iso18626.BibliographicInfo{Title: "Title", Author: "Author"},
+			PublicationInfo:   &iso18626.PublicationInfo{Publisher: "Publisher"},
+			BillingInfo: &iso18626.BillingInfo{MaximumCosts: &iso18626.TypeCosts{
+				CurrencyCode:  iso18626.TypeSchemeValuePair{Text: "EUR"},
+				MonetaryValue: maxCost,
+			}},
+			RequestedDeliveryInfo: []iso18626.RequestedDeliveryInfo{{Address: &iso18626.Address{
+				PhysicalAddress: &iso18626.PhysicalAddress{Line1: "1 Main St", Locality: "Springfield"},
+			}}},
+		},
+		IllResponse: iso18626.SupplyingAgencyMessage{
+			StatusInfo: iso18626.StatusInfo{DueDate: &utils.XSDDateTime{Time: dueDate}},
+		},
+		Items: []pr_db.PrItem{{Barcode: "b1", CallNumber: &callNumber}},
+	}
+	notifications := []pr_db.Notification{
+		{FromSymbol: "ISIL:SUP", Kind: pr_db.NotificationKindNote, Note: pgtype.Text{String: "hello", Valid: true}},
+		{FromSymbol: "ISIL:SUP", Kind: pr_db.NotificationKindCondition, Condition: pgtype.Text{String: "LibraryUseOnly", Valid: true},
+			Cost: cost, Currency: pgtype.Text{String: "USD", Valid: true}, Receipt: pr_db.NotificationAccepted},
+		{FromSymbol: "ISIL:SUP", Kind: pr_db.NotificationKindEmail, Note: pgtype.Text{String: "Your request", Valid: true}},
+		{FromSymbol: "ISIL:REQ", Kind: pr_db.NotificationKindReminder, Note: pgtype.Text{String: "first", Valid: true}},
+	}
+
+	request := NewRequest(pr, notifications)
+
+	assert.Equal(t, "REQ-1", request.Hrid)
+	assert.Equal(t, "Title", request.Title)
+	assert.Equal(t, "Publisher", request.Publisher)
+	assert.Equal(t, "1 Main St, Springfield", request.PickupLocation)
+	assert.Equal(t, &dueDate, request.DueDate)
+	assert.Nil(t, request.NeedBefore)
+	assert.Equal(t, &Amount{Value: 25.5, Currency: "EUR"}, request.MaxCost)
+	assert.Nil(t, request.OfferedCost)
+	assert.Equal(t, []Item{{Barcode: "b1", CallNumber: "QA 1"}}, request.Items)
+	if assert.Len(t, request.Notes, 1) {
+		assert.Equal(t, "hello", request.Notes[0].Note)
+	}
+	if assert.Len(t, request.Conditions, 1) {
+		assert.Equal(t, "LibraryUseOnly", request.Conditions[0].Condition)
+		assert.Equal(t, &Amount{Value: 12.5, Currency: "USD"}, request.Conditions[0].Cost)
+		assert.Equal(t, "ACCEPTED", request.Conditions[0].Receipt)
+	}
+}
+
+func TestNewRequest_Empty(t *testing.T) {
+	request := NewRequest(pr_db.PatronRequest{}, nil)
+	assert.Empty(t, request.PickupLocation)
+	assert.Nil(t, request.DueDate)
+	assert.Nil(t, request.MaxCost)
+	assert.NotNil(t, request.Items)
+	assert.NotNil(t, request.Notes)
+	assert.NotNil(t, request.Conditions)
+}
+
+func TestNewRequest_SkipsPatronMessages(t *testing.T) {
+	notifications := []pr_db.Notification{
+		{FromSymbol: "ISIL:REQ", Kind: pr_db.NotificationKindEmail, Note: pgtype.Text{String: "Your request", Valid: true}},
+		{FromSymbol: "ISIL:REQ", Kind: pr_db.NotificationKindReminder, Note: pgtype.Text{String: "first", Valid: true}},
+		{FromSymbol: "ISIL:REQ", Kind: pr_db.NotificationKindSms, Note: pgtype.Text{String: "Dune is ready", Valid: true}},
+		{FromSymbol: "ISIL:REQ", Kind: pr_db.NotificationKindPush, Note: pgtype.Text{String: "Dune is ready", Valid: true}},
+	}
+
+	request := NewRequest(pr_db.PatronRequest{}, notifications)
+
+	assert.Empty(t, request.Notes)
+	assert.Empty(t, request.Conditions)
+}
+
+func TestPickupLocation_ElectronicAddress(t *testing.T) {
+	pr := pr_db.PatronRequest{IllRequest: iso18626.Request{
+		RequestedDeliveryInfo: []iso18626.RequestedDeliveryInfo{{Address: &iso18626.Address{
+			ElectronicAddress: &iso18626.ElectronicAddress{ElectronicAddressData: "patron@library.org"},
+		}}},
+	}}
+	assert.Equal(t, "patron@library.org", PickupLocation(pr))
+}
+
+// ── FormatPhysicalAddress ─────────────────────────────────────────────────────
+
+func TestFormatPhysicalAddress_Full(t *testing.T) {
+	a := &iso18626.PhysicalAddress{
+		Line1:      "1 Main St",
+		Line2:      "Floor 2",
+		Locality:   "Springfield",
+		PostalCode: "12345",
+		Region:     &iso18626.TypeSchemeValuePair{Text: "IL"},
+		Country:    &iso18626.TypeSchemeValuePair{Text: "US"},
+	}
+	assert.Equal(t, "1 Main St, Floor 2, Springfield, 12345, IL, US", FormatPhysicalAddress(a))
+}
+
+func TestFormatPhysicalAddress_Partial(t *testing.T) {
+	// Only Line1 and Locality — Region/Country nil, Line2/PostalCode empty
+	a := &iso18626.PhysicalAddress{
+		Line1:    "42 Book Rd",
+		Locality: "Shelbyville",
+	}
+	assert.Equal(t, "42 Book Rd, Shelbyville", FormatPhysicalAddress(a))
+}
+
+func TestFormatPhysicalAddress_EmptyRegionText(t *testing.T) {
+	// Region present but empty Text — should be skipped
+	a := &iso18626.PhysicalAddress{
+		Line1:   "1 St",
+		Region:  &iso18626.TypeSchemeValuePair{Text: ""},
+		Country: &iso18626.TypeSchemeValuePair{Text: ""},
+	}
+	assert.Equal(t, "1 St", FormatPhysicalAddress(a))
+}
+
+func TestFormatPhysicalAddress_Empty(t *testing.T) {
+	assert.Equal(t, "", FormatPhysicalAddress(&iso18626.PhysicalAddress{}))
+}
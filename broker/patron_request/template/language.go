@@ -0,0 +1,43 @@
+package prtemplate
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// NormalizeLanguage checks that tag is a BCP 47 language tag and returns its canonical form, e.g. es-MX for es_mx.
+func NormalizeLanguage(tag string) (string, error) {
+	parsed, err := language.Parse(strings.TrimSpace(tag))
+	if err != nil {
+		return "", fmt.Errorf("invalid language %q: %w", tag, err)
+	}
+	return parsed.String(), nil
+}
+
+// Languages lists the template languages to look for, most preferred first. Each tag is followed by
+// its base language, so es-MX falls back to es. Empty and invalid tags are skipped.
+func Languages(preferred ...string) []string {
+	languages := []string{}
+	add := func(tag string) {
+		if !slices.Contains(languages, tag) {
+			languages = append(languages, tag)
+		}
+	}
+	for _, tag := range preferred {
+		if strings.TrimSpace(tag) == "" {
+			continue
+		}
+		parsed, err := language.Parse(strings.TrimSpace(tag))
+		if err != nil {
+			continue
+		}
+		add(parsed.String())
+		if base, confidence := parsed.Base(); confidence != language.No {
+			add(base.String())
+		}
+	}
+	return languages
+}
@@ -0,0 +1,29 @@
+package prtemplate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeLanguage(t *testing.T) {
+	tag, err := NormalizeLanguage("es_mx")
+	assert.NoError(t, err)
+	assert.Equal(t, "es-MX", tag)
+
+	tag, err = NormalizeLanguage(" EN ")
+	assert.NoError(t, err)
+	assert.Equal(t, "en", tag)
+
+	_, err = NormalizeLanguage("not a language")
+	assert.ErrorContains(t, err, "invalid language \"not a language\"")
+
+	_, err = NormalizeLanguage("")
+	assert.Error(t, err)
+}
+
+func TestLanguages(t *testing.T) {
+	assert.Equal(t, []string{"es-MX", "es", "en"}, Languages("es-mx", "", "en"))
+	assert.Equal(t, []string{"da"}, Languages("da", "DA", "???"))
+	assert.Equal(t, []string{}, Languages())
+}
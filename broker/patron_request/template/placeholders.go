@@ -0,0 +1,147 @@
+package prtemplate
+
+import (
+	"reflect"
+	"text/template"
+	"text/template/parse"
+)
+
+var dataType = reflect.TypeOf(Data{})
+
+// UnknownPlaceholders parses a template and lists the field references that are not part of Data,
+// e.g. {{.Request.PatronName}}. Fields reached through function results are not checked.
+func UnknownPlaceholders(text string) ([]string, error) {
+	tmpl, err := template.New("template").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	checker := placeholderChecker{vars: map[string]reflect.Type{"$": dataType}, seen: map[string]bool{}}
+	if tmpl.Tree != nil {
+		checker.walk(tmpl.Tree.Root, dataType)
+	}
+	return checker.unknown, nil
+}
+
+type placeholderChecker struct {
+	vars    map[string]reflect.Type
+	seen    map[string]bool
+	unknown []string
+}
+
+// walk checks the fields used below node, dot is the type of the data at that point or nil if unknown.
+func (c *placeholderChecker) walk(node parse.Node, dot reflect.Type) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			c.walk(child, dot)
+		}
+	case *parse.ActionNode:
+		c.pipe(n.Pipe, dot)
+	case *parse.IfNode:
+		c.pipe(n.Pipe, dot)
+		c.walk(n.List, dot)
+		c.walk(n.ElseList, dot)
+	case *parse.RangeNode:
+		elem := elemType(c.pipe(n.Pipe, dot))
+		if len(n.Pipe.Decl) > 0 {
+			c.vars[n.Pipe.Decl[len(n.Pipe.Decl)-1].Ident[0]] = elem
+		}
+		c.walk(n.List, elem)
+		c.walk(n.ElseList, dot)
+	case *parse.WithNode:
+		c.walk(n.List, c.pipe(n.Pipe, dot))
+		c.walk(n.ElseList, dot)
+	case *parse.TemplateNode:
+		c.pipe(n.Pipe, dot)
+	}
+}
+
+func (c *placeholderChecker) pipe(pipe *parse.PipeNode, dot reflect.Type) reflect.Type {
+	if pipe == nil {
+		return nil
+	}
+	var result reflect.Type
+	for _, cmd := range pipe.Cmds {
+		result = nil
+		for i, arg := range cmd.Args {
+			t := c.arg(arg, dot)
+			if i == 0 && len(cmd.Args) == 1 {
+				result = t
+			}
+		}
+	}
+	for _, decl := range pipe.Decl {
+		c.vars[decl.Ident[0]] = result
+	}
+	return result
+}
+
+func (c *placeholderChecker) arg(node parse.Node, dot reflect.Type) reflect.Type {
+	switch n := node.(type) {
+	case *parse.DotNode:
+		return dot
+	case *parse.FieldNode:
+		return c.fields(dot, n.Ident, n.String())
+	case *parse.ChainNode:
+		return c.fields(c.arg(n.Node, dot), n.Field, n.String())
+	case *parse.VariableNode:
+		return c.fields(c.vars[n.Ident[0]], n.Ident[1:], n.String())
+	case *parse.PipeNode:
+		return c.pipe(n, dot)
+	}
+	return nil
+}
+
+func (c *placeholderChecker) fields(t reflect.Type, names []string, text string) reflect.Type {
+	for _, name := range names {
+		if t == nil {
+			return nil
+		}
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			if field, ok := t.FieldByName(name); ok && field.IsExported() {
+				t = field.Type
+				continue
+			}
+		}
+		if method, ok := reflect.PointerTo(t).MethodByName(name); ok {
+			if method.Type.NumOut() == 0 {
+				return nil
+			}
+			t = method.Type.Out(0)
+			continue
+		}
+		switch t.Kind() {
+		case reflect.Map:
+			t = t.Elem()
+			continue
+		case reflect.Interface:
+			return nil
+		}
+		if !c.seen[text] {
+			c.seen[text] = true
+			c.unknown = append(c.unknown, text)
+		}
+		return nil
+	}
+	return t
+}
+
+func elemType(t reflect.Type) reflect.Type {
+	if t == nil {
+		return nil
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		return t.Elem()
+	}
+	return nil
+}
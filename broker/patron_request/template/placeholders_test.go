@@ -0,0 +1,30 @@
+package prtemplate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnknownPlaceholders(t *testing.T) {
+	unknown, err := UnknownPlaceholders("Dear {{.Request.Patron}}, {{.Request.PatronName}} {{.Request.PatronName}}" +
+		"{{range .Request.Items}}{{.Barcode}}{{.Shelf}}{{end}}" +
+		"{{with .Request.MaxCost}}{{.Value}}{{.Amount}}{{end}}" +
+		"{{range $i, $n := .Request.Notes}}{{$n.Note}}{{$n.Text}}{{$.Batch.Size}}{{end}}" +
+		"{{.Now.Year}}{{formatDate .Request.DueDate}}{{.Subtitle}}")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{".Request.PatronName", ".Shelf", ".Amount", "$n.Text", "$.Batch.Size", ".Subtitle"}, unknown)
+}
+
+func TestUnknownPlaceholders_None(t *testing.T) {
+	unknown, err := UnknownPlaceholders("{{if .Request.DueDate}}{{formatDate .Request.DueDate}}{{end}}" +
+		"{{range .Batch.Requests}}{{.Hrid}}{{range .Conditions}}{{formatCurrency .Cost}}{{end}}{{end}}" +
+		"{{.ReqId}}{{.Title}}{{.CallNumber}}{{.LoanConditions}}")
+	assert.NoError(t, err)
+	assert.Empty(t, unknown)
+}
+
+func TestUnknownPlaceholders_ParseError(t *testing.T) {
+	_, err := UnknownPlaceholders("{{.Request.Title")
+	assert.ErrorContains(t, err, "unclosed action")
+}
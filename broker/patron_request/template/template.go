@@ -0,0 +1,219 @@
+// Package prtemplate renders the email, sms and pull slip templates stored via /templates.
+//
+// Templates use the Go template syntax, HTML templates are escaped for their context.
+// They are executed with Data, e.g.
+//
+//	Dear {{.Request.Patron}}, {{.Request.Title}} is ready at {{.Request.PickupLocation}}.
+//	{{if .Request.DueDate}}Please return it by {{formatDate .Request.DueDate}}.{{end}}
+//	{{range .Request.Items}}{{.Barcode}} {{.CallNumber}}
+//	{{end}}
+//
+// Besides the built-in functions, formatDate formats a date using an optional Go layout
+// (default 2006-01-02) and formatCurrency formats an Amount with two decimals and its currency.
+package prtemplate
+
+import (
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/indexdata/crosslink/broker/patron_request/proapi"
+)
+
+const DATE_LAYOUT = "2006-01-02"
+
+// MAX_SMS_LENGTH is the number of characters an sms template may render to, three concatenated SMS.
+const MAX_SMS_LENGTH = 459
+
+// sampleBarcode is a blank 1x1 PNG standing in for the barcode and QR code of the sample pull slip.
+const sampleBarcode = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAQAAAC1HAwCAAAAC0lEQVR42mNkYAAAAAYAAjCB0C8AAAAASUVORK5CYII="
+
+var funcs = map[string]any{
+	"formatDate":     formatDate,
+	"formatCurrency": formatCurrency,
+}
+
+type executor interface {
+	Execute(w io.Writer, data any) error
+}
+
+func parseTemplate(contentType string, text string) (executor, error) {
+	if contentType == string(proapi.TemplateContentTypeHtml) {
+		return htmltemplate.New("template").Funcs(funcs).Option("missingkey=error").Parse(text)
+	}
+	return template.New("template").Funcs(funcs).Option("missingkey=error").Parse(text)
+}
+
+// Render executes a template with the given data.
+func Render(contentType string, text string, data Data) (string, error) {
+	tmpl, err := parseTemplate(contentType, text)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err = tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// Validate checks that a template parses and only refers to fields of the template data model.
+func Validate(contentType string, text string) error {
+	tmpl, err := parseTemplate(contentType, text)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(io.Discard, SampleData())
+}
+
+// ValidateTemplate validates the subject and body of a template, naming the failing one in the error.
+func ValidateTemplate(contentType string, subject *string, body string) error {
+	if subject != nil {
+		// The subject is a header, so it is never HTML escaped
+		if err := Validate("", *subject); err != nil {
+			return fmt.Errorf("invalid subject: %w", err)
+		}
+	}
+	if err := Validate(contentType, body); err != nil {
+		return fmt.Errorf("invalid body: %w", err)
+	}
+	if contentType == string(proapi.TemplateContentTypeSms) {
+		text, err := Render(contentType, body, SampleData())
+		if err != nil {
+			return fmt.Errorf("invalid body: %w", err)
+		}
+		if n := utf8.RuneCountInString(text); n > MAX_SMS_LENGTH {
+			return fmt.Errorf("invalid body: sms renders to %d characters with sample data, at most %d are allowed", n, MAX_SMS_LENGTH)
+		}
+	}
+	return nil
+}
+
+// TruncateSms shortens text to MAX_SMS_LENGTH characters, ending it with an ellipsis if it is cut.
+func TruncateSms(text string) string {
+	if utf8.RuneCountInString(text) <= MAX_SMS_LENGTH {
+		return text
+	}
+	return string([]rune(text)[:MAX_SMS_LENGTH-1]) + "…"
+}
+
+// SampleData returns template data with every field set, for validating and previewing templates.
+func SampleData() Data {
+	now := time.Now().UTC().Truncate(time.Second)
+	dueDate := now.AddDate(0, 0, 28)
+	needBefore := now.AddDate(0, 0, 14)
+	callNumber := "QA76.73.G63 D66 2015"
+	request := Request{
+		ID:              "8b1c5d2e-5f3a-4e4b-9a51-3a8d7c6e2f10",
+		Hrid:            "REQ-1001",
+		State:           "SHIPPED",
+		Side:            "borrowing",
+		Patron:          "Jane Doe",
+		Title:           "The Go Programming Language",
+		Author:          "Donovan, Alan A. A.",
+		Publisher:       "Addison-Wesley",
+		Volume:          "1",
+		Issue:           "2",
+		Pages:           "380",
+		PickupLocation:  "Main Library, 1 Library Square, Springfield",
+		RequesterSymbol: "ISIL:REQ",
+		RequesterName:   "Springfield Public Library",
+		SupplierSymbol:  "ISIL:SUP",
+		SupplierName:    "Shelbyville University Library",
+		NeedBefore:      &needBefore,
+		DueDate:         &dueDate,
+		MaxCost:         &Amount{Value: 25, Currency: "USD"},
+		OfferedCost:     &Amount{Value: 12.5, Currency: "USD"},
+		CreatedAt:       now.AddDate(0, 0, -7),
+		Items: []Item{
+			{Barcode: "39000001234567", CallNumber: callNumber, Title: "The Go Programming Language"},
+		},
+		Notes: []Notification{
+			{From: "ISIL:SUP", Note: "Shipped with tracking number 1Z999", Receipt: "SEEN", CreatedAt: now.AddDate(0, 0, -1)},
+		},
+		Conditions: []Notification{
+			{From: "ISIL:SUP", Condition: "LibraryUseOnly", Note: "Do not remove from the reading room",
+				Cost: &Amount{Value: 12.5, Currency: "USD"}, Receipt: "ACCEPTED", CreatedAt: now.AddDate(0, 0, -2)},
+		},
+	}
+	return Data{
+		PullSlip: PullSlip{
+			BorrowerName:     request.Patron,
+			ReqId:            request.Hrid,
+			PickupLocation:   request.PickupLocation,
+			Title:            request.Title,
+			Author:           request.Author,
+			DueDate:          dueDate.Format(DATE_LAYOUT),
+			ReturnAddress:    "Interlibrary Loan, 2 College Road, Shelbyville",
+			DeliveryAddress:  "Main Library\n1 Library Square\nSpringfield",
+			BarcodeBase64:    sampleBarcode,
+			QrCodeBase64:     sampleBarcode,
+			ServiceType:      "Loan",
+			ServiceLevel:     "Normal",
+			SystemIdentifier: "rec-0001",
+			Publisher:        request.Publisher,
+			Volume:           request.Volume,
+			Issue:            request.Issue,
+			Pages:            request.Pages,
+			StaffNotes:       "Shipped with tracking number 1Z999",
+			CallNumber:       callNumber,
+			LoanConditions:   "LibraryUseOnly",
+		},
+		Request: request,
+		Batch: Batch{
+			Query:       "state = SHIPPED",
+			ActualCount: 1,
+			FullCount:   1,
+			Requests:    []Request{request},
+		},
+		Now: now,
+	}
+}
+
+func formatDate(value any, layout ...string) (string, error) {
+	if len(layout) > 1 {
+		return "", errors.New("formatDate takes at most one layout")
+	}
+	format := DATE_LAYOUT
+	if len(layout) == 1 {
+		format = layout[0]
+	}
+	switch t := value.(type) {
+	case time.Time:
+		if t.IsZero() {
+			return "", nil
+		}
+		return t.Format(format), nil
+	case *time.Time:
+		if t == nil || t.IsZero() {
+			return "", nil
+		}
+		return t.Format(format), nil
+	case nil:
+		return "", nil
+	}
+	return "", fmt.Errorf("formatDate expects a date, got %T", value)
+}
+
+func formatCurrency(value any) (string, error) {
+	var amount Amount
+	switch a := value.(type) {
+	case Amount:
+		amount = a
+	case *Amount:
+		if a == nil {
+			return "", nil
+		}
+		amount = *a
+	case nil:
+		return "", nil
+	default:
+		return "", fmt.Errorf("formatCurrency expects an amount, got %T", value)
+	}
+	return strings.TrimSpace(fmt.Sprintf("%.2f %s", amount.Value, amount.Currency)), nil
+}
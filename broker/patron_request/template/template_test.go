@@ -0,0 +1,111 @@
+package prtemplate
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/indexdata/crosslink/broker/patron_request/proapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender_Text(t *testing.T) {
+	data := SampleData()
+	out, err := Render("text", "Dear {{.Request.Patron}}, {{.Request.Title}} is due {{formatDate .Request.DueDate}}."+
+		"{{range .Request.Items}} [{{.Barcode}}]{{end}}{{if .Request.OfferedCost}} Cost: {{formatCurrency .Request.OfferedCost}}{{end}}", data)
+	assert.NoError(t, err)
+	assert.Equal(t, "Dear Jane Doe, The Go Programming Language is due "+data.Request.DueDate.Format("2006-01-02")+
+		". [39000001234567] Cost: 12.50 USD", out)
+}
+
+func TestRender_Conditionals(t *testing.T) {
+	tmpl := "{{if .Request.DueDate}}due {{formatDate .Request.DueDate \"02 Jan 2006\"}}{{else}}no due date{{end}}"
+	out, err := Render("text", tmpl, Data{})
+	assert.NoError(t, err)
+	assert.Equal(t, "no due date", out)
+
+	dueDate := time.Date(2026, 2, 3, 0, 0, 0, 0, time.UTC)
+	out, err = Render("text", tmpl, Data{Request: Request{DueDate: &dueDate}})
+	assert.NoError(t, err)
+	assert.Equal(t, "due 03 Feb 2026", out)
+}
+
+func TestRender_HtmlEscapes(t *testing.T) {
+	out, err := Render(string(proapi.TemplateContentTypeHtml), "<p>{{.Request.Title}}</p>", Data{Request: Request{Title: "<b>Bold</b> & more"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>&lt;b&gt;Bold&lt;/b&gt; &amp; more</p>", out)
+
+	out, err = Render("text", "{{.Request.Title}}", Data{Request: Request{Title: "<b>"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "<b>", out)
+}
+
+func TestRender_Batch(t *testing.T) {
+	out, err := Render("text", "{{.Batch.Query}}: {{.Batch.ActualCount}} of {{.Batch.FullCount}}{{range .Batch.Requests}} {{.Hrid}}{{end}}", SampleData())
+	assert.NoError(t, err)
+	assert.Equal(t, "state = SHIPPED: 1 of 1 REQ-1001", out)
+}
+
+func TestRender_Errors(t *testing.T) {
+	_, err := Render("text", "{{.Request.Title", Data{})
+	assert.ErrorContains(t, err, "unclosed action")
+
+	_, err = Render("text", "{{formatDate .Request.Title}}", Data{})
+	assert.ErrorContains(t, err, "formatDate expects a date, got string")
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate("text", "Hello {{.Request.Patron}}"))
+	assert.NoError(t, Validate(string(proapi.TemplateContentTypeHtml), "{{range .Request.Conditions}}<li>{{.Condition}} {{formatCurrency .Cost}}</li>{{end}}"))
+	assert.ErrorContains(t, Validate("text", "{{.Request.Nope}}"), "can't evaluate field Nope")
+	assert.ErrorContains(t, Validate("text", "{{batchQuery}}"), `function "batchQuery" not defined`)
+	assert.ErrorContains(t, Validate("text", "{{formatDate .Now \"a\" \"b\"}}"), "at most one layout")
+}
+
+func TestValidateTemplate(t *testing.T) {
+	subject := "{{.Request.Title}}"
+	assert.NoError(t, ValidateTemplate("text", &subject, "body"))
+	assert.NoError(t, ValidateTemplate("text", nil, "body"))
+
+	subject = "{{.Request.Nope}}"
+	assert.ErrorContains(t, ValidateTemplate("text", &subject, "body"), "invalid subject")
+	assert.ErrorContains(t, ValidateTemplate("text", nil, "{{end}}"), "invalid body")
+}
+
+func TestValidateTemplateSmsLength(t *testing.T) {
+	assert.NoError(t, ValidateTemplate("sms", nil, "{{.Request.Title}} is ready for pickup at {{.Request.PickupLocation}}"))
+	assert.NoError(t, ValidateTemplate("sms", nil, strings.Repeat("x", MAX_SMS_LENGTH)))
+	assert.EqualError(t, ValidateTemplate("sms", nil, strings.Repeat("x", MAX_SMS_LENGTH+1)),
+		"invalid body: sms renders to 460 characters with sample data, at most 459 are allowed")
+	assert.ErrorContains(t, ValidateTemplate("sms", nil, "{{range .Batch.Requests}}"+strings.Repeat("x", 440)+"{{end}}{{.Request.Title}}"),
+		"at most 459 are allowed")
+	assert.NoError(t, ValidateTemplate("text", nil, strings.Repeat("x", MAX_SMS_LENGTH+1)))
+}
+
+func TestTruncateSms(t *testing.T) {
+	assert.Equal(t, "short", TruncateSms("short"))
+	text := strings.Repeat("æ", MAX_SMS_LENGTH)
+	assert.Equal(t, text, TruncateSms(text))
+	truncated := TruncateSms(text + "ø")
+	assert.Equal(t, MAX_SMS_LENGTH, utf8.RuneCountInString(truncated))
+	assert.True(t, strings.HasSuffix(truncated, "æ…"))
+}
+
+func TestFormatCurrency(t *testing.T) {
+	for _, tt := range []struct {
+		value    any
+		expected string
+	}{
+		{Amount{Value: 3, Currency: "EUR"}, "3.00 EUR"},
+		{&Amount{Value: 1.005}, "1.00"},
+		{(*Amount)(nil), ""},
+		{nil, ""},
+	} {
+		out, err := formatCurrency(tt.value)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expected, out)
+	}
+	_, err := formatCurrency(12)
+	assert.ErrorContains(t, err, "formatCurrency expects an amount, got int")
+}
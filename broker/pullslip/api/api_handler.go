@@ -12,8 +12,10 @@ import (
 	"github.com/indexdata/cql-go/cqlbuilder"
 	"github.com/indexdata/crosslink/broker/api"
 	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/ill_db"
 	prapi "github.com/indexdata/crosslink/broker/patron_request/api"
 	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
+	prtemplate "github.com/indexdata/crosslink/broker/patron_request/template"
 	ps_db "github.com/indexdata/crosslink/broker/pullslip/db"
 	psoapi "github.com/indexdata/crosslink/broker/pullslip/oapi"
 	psservice "github.com/indexdata/crosslink/broker/pullslip/service"
@@ -27,14 +29,16 @@ const MAX_RECORDS_PER_PDF = 100
 type PullSlipApiHandler struct {
 	psRepo         ps_db.PsRepo
 	prRepo         pr_db.PrRepo
+	illRepo        ill_db.IllRepo
 	pdfService     psservice.PdfService
 	tenantResolver *tenant.TenantResolver
 }
 
-func NewPsApiHandler(psRepo ps_db.PsRepo, prRepo pr_db.PrRepo, tenantResolver *tenant.TenantResolver) PullSlipApiHandler {
+func NewPsApiHandler(psRepo ps_db.PsRepo, prRepo pr_db.PrRepo, illRepo ill_db.IllRepo, tenantResolver *tenant.TenantResolver) PullSlipApiHandler {
 	return PullSlipApiHandler{
 		psRepo:         psRepo,
 		prRepo:         prRepo,
+		illRepo:        illRepo,
 		tenantResolver: tenantResolver,
 		pdfService:     psservice.NewPdfService(prRepo),
 	}
@@ -43,10 +47,18 @@ func NewPsApiHandler(psRepo ps_db.PsRepo, prRepo pr_db.PrRepo, tenantResolver *t
 func (p PullSlipApiHandler) GetPullslipsId(w http.ResponseWriter, r *http.Request, id string, params psoapi.GetPullslipsIdParams) {
 	logParams := map[string]string{"method": "GetPullslipsId", "id": id}
 	ctx := common.CreateExtCtxWithArgs(r.Context(), &common.LoggerArgs{Other: logParams})
-	ps := p.getPullSlip(ctx, w, r, id, params, logParams)
+	ps := p.getPullSlip(ctx, w, r, id, params.Symbol, logParams)
 	if ps == nil {
 		return
 	}
+	switch getFormat(r, params.Format) {
+	case psoapi.PullSlipFormatPdf:
+		writePdf(w, psservice.Document(ps.Document), ps.PdfBytes)
+		return
+	case psoapi.PullSlipFormatZpl:
+		p.writeZpl(ctx, w, ps)
+		return
+	}
 	resp := psoapi.PullSlip{
 		Id:             ps.ID,
 		CreatedAt:      ps.CreatedAt.Time,
@@ -58,17 +70,23 @@ func (p PullSlipApiHandler) GetPullslipsId(w http.ResponseWriter, r *http.Reques
 	if ps.GeneratedAt.Valid {
 		resp.GeneratedAt = &ps.GeneratedAt.Time
 	}
+	if ps.TemplateLabel.Valid {
+		resp.TemplateLabel = &ps.TemplateLabel.String
+	}
+	if ps.Document != "" {
+		resp.Document = new(psoapi.PullSlipDocument(ps.Document))
+	}
 	api.WriteJsonResponse(w, resp)
 }
 
 func (p PullSlipApiHandler) GetPullslipsIdPdf(w http.ResponseWriter, r *http.Request, id string, params psoapi.GetPullslipsIdPdfParams) {
 	logParams := map[string]string{"method": "GetPullslipsIdPdf", "id": id}
 	ctx := common.CreateExtCtxWithArgs(r.Context(), &common.LoggerArgs{Other: logParams})
-	ps := p.getPullSlip(ctx, w, r, id, psoapi.GetPullslipsIdParams(params), logParams)
+	ps := p.getPullSlip(ctx, w, r, id, params.Symbol, logParams)
 	if ps == nil {
 		return
 	}
-	writePdf(w, ps.PdfBytes)
+	writePdf(w, psservice.Document(ps.Document), ps.PdfBytes)
 }
 
 func (p PullSlipApiHandler) PostPullslips(w http.ResponseWriter, r *http.Request, params psoapi.PostPullslipsParams) {
@@ -85,6 +103,15 @@ func (p PullSlipApiHandler) PostPullslips(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	doc := psservice.DocumentPullSlip
+	if create.Document != nil {
+		doc, err = psservice.ParseDocument(string(*create.Document))
+		if err != nil {
+			api.AddBadRequestError(ctx, w, err)
+			return
+		}
+	}
+
 	tenant, err := p.tenantResolver.Resolve(ctx, r, params.Symbol)
 	if err != nil {
 		api.AddBadRequestError(ctx, w, err)
@@ -133,7 +160,11 @@ func (p PullSlipApiHandler) PostPullslips(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	pdf, err := p.getPdfByte(ctx, w, cqlQuery.String())
+	templateLabel := pgtype.Text{}
+	if create.TemplateLabel != nil && *create.TemplateLabel != "" {
+		templateLabel = pgtype.Text{String: *create.TemplateLabel, Valid: true}
+	}
+	pdf, err := p.getPdfByte(ctx, w, cqlQuery.String(), psservice.PullSlipTemplate{Document: doc, Owner: symbol, Label: templateLabel.String})
 	if err != nil {
 		return // http response already added
 	}
@@ -153,24 +184,31 @@ func (p PullSlipApiHandler) PostPullslips(w http.ResponseWriter, r *http.Request
 		Owner:          symbol,
 		SearchCriteria: cqlQuery.String(),
 		PdfBytes:       pdf,
+		TemplateLabel:  templateLabel,
+		Document:       string(doc),
 	})
 	if err != nil {
 		api.AddInternalError(ctx, w, err)
 		return
 	}
 	w.Header().Set("Location", api.Link(r, api.Path("pullslips", psId, "pdf"), nil))
-	writePdf(w, pdf)
+	writePdf(w, doc, pdf)
 }
 
 func (p PullSlipApiHandler) PostPullslipsIdRegenerate(w http.ResponseWriter, r *http.Request, id string, params psoapi.PostPullslipsIdRegenerateParams) {
 	logParams := map[string]string{"method": "PostPullslipsIdRegenerate", "id": id}
 	ctx := common.CreateExtCtxWithArgs(r.Context(), &common.LoggerArgs{Other: logParams})
-	ps := p.getPullSlip(ctx, w, r, id, psoapi.GetPullslipsIdParams(params), logParams)
+	ps := p.getPullSlip(ctx, w, r, id, params.Symbol, logParams)
 	if ps == nil {
 		return
 	}
 
-	pdf, err := p.getPdfByte(ctx, w, ps.SearchCriteria)
+	doc, err := psservice.ParseDocument(ps.Document)
+	if err != nil {
+		api.AddInternalError(ctx, w, err)
+		return
+	}
+	pdf, err := p.getPdfByte(ctx, w, ps.SearchCriteria, psservice.PullSlipTemplate{Document: doc, Owner: ps.Owner, Label: ps.TemplateLabel.String})
 	if err != nil {
 		return // http response already added
 	}
@@ -186,11 +224,11 @@ func (p PullSlipApiHandler) PostPullslipsIdRegenerate(w http.ResponseWriter, r *
 		return
 	}
 	w.Header().Set("Location", api.Link(r, api.Path("pullslips", ps.ID, "pdf"), nil))
-	writePdf(w, pdf)
+	writePdf(w, doc, pdf)
 }
 
-func (p PullSlipApiHandler) getPullSlip(ctx common.ExtendedContext, w http.ResponseWriter, r *http.Request, id string, params psoapi.GetPullslipsIdParams, logParams map[string]string) *ps_db.PullSlip {
-	tenant, err := p.tenantResolver.Resolve(ctx, r, params.Symbol)
+func (p PullSlipApiHandler) getPullSlip(ctx common.ExtendedContext, w http.ResponseWriter, r *http.Request, id string, requestSymbol *string, logParams map[string]string) *ps_db.PullSlip {
+	tenant, err := p.tenantResolver.Resolve(ctx, r, requestSymbol)
 	if err != nil {
 		api.AddBadRequestError(ctx, w, err)
 		return nil
@@ -215,30 +253,82 @@ func (p PullSlipApiHandler) getPullSlip(ctx common.ExtendedContext, w http.Respo
 	return &ps
 }
 
-func (p PullSlipApiHandler) getPdfByte(ctx common.ExtendedContext, w http.ResponseWriter, cql string) ([]byte, error) {
+// getFormat returns the format named by the format parameter, or else by the Accept header, JSON by default.
+func getFormat(r *http.Request, format *psoapi.PullSlipFormat) psoapi.PullSlipFormat {
+	if format != nil {
+		return *format
+	}
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "application/pdf"):
+		return psoapi.PullSlipFormatPdf
+	case strings.Contains(accept, "application/zpl"):
+		return psoapi.PullSlipFormatZpl
+	}
+	return psoapi.PullSlipFormatJson
+}
+
+// writeZpl renders the patron requests of the pull slip as ZPL, the stored PDF is not used.
+func (p PullSlipApiHandler) writeZpl(ctx common.ExtendedContext, w http.ResponseWriter, ps *ps_db.PullSlip) {
+	doc, err := psservice.ParseDocument(ps.Document)
+	if err != nil {
+		api.AddInternalError(ctx, w, err)
+		return
+	}
+	if !doc.HasZpl() {
+		api.AddBadRequestError(ctx, w, fmt.Errorf("no ZPL layout for document %q", doc))
+		return
+	}
+	prs, err := p.listPatronRequests(ctx, w, ps.SearchCriteria)
+	if err != nil {
+		return // http response already added
+	}
+	zpl, err := p.pdfService.GenerateZplPullSlipForPrs(ctx, prs, doc)
+	if err != nil {
+		api.AddInternalError(ctx, w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zpl")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+doc.ZplFilename()+`"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(zpl) // #nosec G705 -- content is generated ZPL; Content-Type is set to application/zpl
+}
+
+func (p PullSlipApiHandler) listPatronRequests(ctx common.ExtendedContext, w http.ResponseWriter, cql string) ([]pr_db.PatronRequest, error) {
 	pgcql, err := pr_db.ParsePatronRequestsCql(cql)
 	if err != nil {
 		wrappedErr := fmt.Errorf("invalid CQL query: %w", err)
 		api.AddBadRequestError(ctx, w, wrappedErr)
-		return []byte{}, wrappedErr
+		return nil, wrappedErr
 	}
 
 	prs, _, err := p.prRepo.ListPatronRequests(ctx, pr_db.ListPatronRequestsParams{Limit: MAX_RECORDS_PER_PDF, Offset: 0}, pgcql)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			api.AddNotFoundError(w)
-			return []byte{}, err
+			return nil, err
 		}
 		api.AddInternalError(ctx, w, err)
-		return []byte{}, err
+		return nil, err
 	}
 
 	if len(prs) == 0 {
 		api.AddBadRequestError(ctx, w, errors.New("no patron requests found"))
-		return []byte{}, errors.New("no patron requests found")
+		return nil, errors.New("no patron requests found")
+	}
+	return prs, nil
+}
+
+func (p PullSlipApiHandler) getPdfByte(ctx common.ExtendedContext, w http.ResponseWriter, cql string, template psservice.PullSlipTemplate) ([]byte, error) {
+	prs, err := p.listPatronRequests(ctx, w, cql)
+	if err != nil {
+		return []byte{}, err
 	}
 
-	pdf, err := p.pdfService.GeneratePdfPullSlipForPrs(ctx, prs)
+	if template.Label != "" {
+		template.Languages = prtemplate.Languages(p.getOwnerLanguage(ctx, template.Owner))
+	}
+	pdf, err := p.pdfService.GeneratePdfPullSlipForPrs(ctx, prs, template)
 	if err != nil {
 		api.AddInternalError(ctx, w, err)
 		return []byte{}, err
@@ -246,9 +336,22 @@ func (p PullSlipApiHandler) getPdfByte(ctx common.ExtendedContext, w http.Respon
 	return pdf, nil
 }
 
-func writePdf(w http.ResponseWriter, bytes []byte) {
+// getOwnerLanguage returns the language of the owner's directory entry, or empty if it is not set or cannot be read.
+func (p PullSlipApiHandler) getOwnerLanguage(ctx common.ExtendedContext, owner string) string {
+	peer, err := p.illRepo.GetPeerBySymbol(ctx, owner)
+	if err != nil {
+		ctx.Logger().Warn("failed to read peer for template language", "symbol", owner, "error", err)
+		return ""
+	}
+	if peer.CustomData.Language == nil {
+		return ""
+	}
+	return *peer.CustomData.Language
+}
+
+func writePdf(w http.ResponseWriter, doc psservice.Document, bytes []byte) {
 	w.Header().Set("Content-Type", "application/pdf")
-	w.Header().Set("Content-Disposition", `attachment; filename="pull-slips.pdf"`)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+doc.Filename()+`"`)
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write(bytes) // #nosec G705 -- content is a generated PDF binary; Content-Type is set to application/pdf
 }
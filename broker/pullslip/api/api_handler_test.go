@@ -10,10 +10,13 @@ import (
 
 	"github.com/indexdata/cql-go/pgcql"
 	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/ill_db"
 	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
 	ps_db "github.com/indexdata/crosslink/broker/pullslip/db"
 	psoapi "github.com/indexdata/crosslink/broker/pullslip/oapi"
+	psservice "github.com/indexdata/crosslink/broker/pullslip/service"
 	"github.com/indexdata/crosslink/broker/tenant"
+	dirapi "github.com/indexdata/crosslink/directory/api"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/assert"
@@ -52,6 +55,21 @@ func (m *MockPrRepo) GetNotificationsByPrId(ctx common.ExtendedContext, params p
 	return args.Get(0).([]pr_db.Notification), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockPrRepo) GetTemplateByPurposeAudienceLabelAndOwner(ctx common.ExtendedContext, params pr_db.GetTemplateByPurposeAudienceLabelAndOwnerParams) (pr_db.Template, error) {
+	args := m.Called(params)
+	return args.Get(0).(pr_db.Template), args.Error(1)
+}
+
+type MockIllRepo struct {
+	mock.Mock
+	ill_db.IllRepo
+}
+
+func (m *MockIllRepo) GetPeerBySymbol(ctx common.ExtendedContext, symbol string) (ill_db.Peer, error) {
+	args := m.Called(symbol)
+	return args.Get(0).(ill_db.Peer), args.Error(1)
+}
+
 // ── helpers ───────────────────────────────────────────────────────────────────
 
 var sym = "ISIL:TEST"
@@ -67,7 +85,7 @@ func withOwnerRestriction() interface{} {
 }
 
 func newHandler(psRepo ps_db.PsRepo, prRepo pr_db.PrRepo) PullSlipApiHandler {
-	return NewPsApiHandler(psRepo, prRepo, tenant.NewResolver())
+	return NewPsApiHandler(psRepo, prRepo, nil, tenant.NewResolver())
 }
 
 func newRequest(method, body string) *http.Request {
@@ -106,6 +124,23 @@ func TestGetPullslipsId_OK(t *testing.T) {
 	psRepo.AssertExpectations(t)
 }
 
+func TestGetPullslipsId_TemplateLabel(t *testing.T) {
+	slip := pullSlipFixture("ps-1")
+	slip.TemplateLabel = pgtype.Text{String: "shelf-slip", Valid: true}
+	psRepo := new(MockPsRepo)
+	psRepo.On("GetPullSlipByIdAndOwner", "ps-1", sym).Return(slip, nil)
+
+	h := newHandler(psRepo, nil)
+	req := newRequest(http.MethodGet, "")
+	rr := httptest.NewRecorder()
+	h.GetPullslipsId(rr, req, "ps-1", psoapi.GetPullslipsIdParams{Symbol: &sym})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp psoapi.PullSlip
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "shelf-slip", *resp.TemplateLabel)
+}
+
 func TestGetPullslipsId_NotFound(t *testing.T) {
 	psRepo := new(MockPsRepo)
 	psRepo.On("GetPullSlipByIdAndOwner", "missing", sym).Return(ps_db.PullSlip{}, pgx.ErrNoRows)
@@ -151,6 +186,107 @@ func TestGetPullslipsId_WithGeneratedAt(t *testing.T) {
 	psRepo.AssertExpectations(t)
 }
 
+func TestGetPullslipsId_FormatPdf(t *testing.T) {
+	psRepo := new(MockPsRepo)
+	psRepo.On("GetPullSlipByIdAndOwner", "ps-1", sym).Return(pullSlipFixture("ps-1"), nil)
+
+	h := newHandler(psRepo, nil)
+	rr := httptest.NewRecorder()
+	format := psoapi.PullSlipFormatPdf
+	h.GetPullslipsId(rr, newRequest(http.MethodGet, ""), "ps-1", psoapi.GetPullslipsIdParams{Symbol: &sym, Format: &format})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/pdf", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "%PDF-fixture", rr.Body.String())
+}
+
+func TestGetPullslipsId_FormatZpl(t *testing.T) {
+	pr := patronRequest("pr-1", sym)
+	prRepo := new(MockPrRepo)
+	prRepo.On("ListPatronRequests", mock.Anything).Return([]pr_db.PatronRequest{pr}, int64(1), nil)
+	prRepo.On("GetNotificationsByPrId", "pr-1", mock.Anything).Return([]pr_db.Notification{}, int64(0), nil)
+	psRepo := new(MockPsRepo)
+	psRepo.On("GetPullSlipByIdAndOwner", "ps-1", sym).Return(pullSlipFixture("ps-1"), nil)
+
+	h := newHandler(psRepo, prRepo)
+	rr := httptest.NewRecorder()
+	format := psoapi.PullSlipFormatZpl
+	h.GetPullslipsId(rr, newRequest(http.MethodGet, ""), "ps-1", psoapi.GetPullslipsIdParams{Symbol: &sym, Format: &format})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/zpl", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Header().Get("Content-Disposition"), "pull-slips.zpl")
+	assert.True(t, strings.HasPrefix(rr.Body.String(), "^XA"))
+	assert.Contains(t, rr.Body.String(), "^FDREQ-pr-1^FS")
+	prRepo.AssertExpectations(t)
+}
+
+func TestGetPullslipsId_AcceptZpl(t *testing.T) {
+	slip := pullSlipFixture("ps-1")
+	slip.Document = "label"
+	pr := patronRequest("pr-1", sym)
+	prRepo := new(MockPrRepo)
+	prRepo.On("ListPatronRequests", mock.Anything).Return([]pr_db.PatronRequest{pr}, int64(1), nil)
+	prRepo.On("GetNotificationsByPrId", "pr-1", mock.Anything).Return([]pr_db.Notification{}, int64(0), nil)
+	psRepo := new(MockPsRepo)
+	psRepo.On("GetPullSlipByIdAndOwner", "ps-1", sym).Return(slip, nil)
+
+	h := newHandler(psRepo, prRepo)
+	req := newRequest(http.MethodGet, "")
+	req.Header.Set("Accept", "application/zpl")
+	rr := httptest.NewRecorder()
+	h.GetPullslipsId(rr, req, "ps-1", psoapi.GetPullslipsIdParams{Symbol: &sym})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/zpl", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Header().Get("Content-Disposition"), "shipping-labels.zpl")
+	assert.Contains(t, rr.Body.String(), "SHIP TO")
+}
+
+func TestGetPullslipsId_FormatOverridesAccept(t *testing.T) {
+	psRepo := new(MockPsRepo)
+	psRepo.On("GetPullSlipByIdAndOwner", "ps-1", sym).Return(pullSlipFixture("ps-1"), nil)
+
+	h := newHandler(psRepo, nil)
+	req := newRequest(http.MethodGet, "")
+	req.Header.Set("Accept", "application/zpl")
+	rr := httptest.NewRecorder()
+	format := psoapi.PullSlipFormatJson
+	h.GetPullslipsId(rr, req, "ps-1", psoapi.GetPullslipsIdParams{Symbol: &sym, Format: &format})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Type"), "application/json")
+}
+
+func TestGetPullslipsId_ZplBookBand(t *testing.T) {
+	slip := pullSlipFixture("ps-1")
+	slip.Document = "bookband"
+	psRepo := new(MockPsRepo)
+	psRepo.On("GetPullSlipByIdAndOwner", "ps-1", sym).Return(slip, nil)
+
+	h := newHandler(psRepo, nil)
+	rr := httptest.NewRecorder()
+	format := psoapi.PullSlipFormatZpl
+	h.GetPullslipsId(rr, newRequest(http.MethodGet, ""), "ps-1", psoapi.GetPullslipsIdParams{Symbol: &sym, Format: &format})
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `no ZPL layout for document \"bookband\"`)
+}
+
+func TestGetPullslipsId_ZplPrRepoError(t *testing.T) {
+	prRepo := new(MockPrRepo)
+	prRepo.On("ListPatronRequests", mock.Anything).Return([]pr_db.PatronRequest{}, int64(0), errors.New("db down"))
+	psRepo := new(MockPsRepo)
+	psRepo.On("GetPullSlipByIdAndOwner", "ps-1", sym).Return(pullSlipFixture("ps-1"), nil)
+
+	h := newHandler(psRepo, prRepo)
+	rr := httptest.NewRecorder()
+	format := psoapi.PullSlipFormatZpl
+	h.GetPullslipsId(rr, newRequest(http.MethodGet, ""), "ps-1", psoapi.GetPullslipsIdParams{Symbol: &sym, Format: &format})
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
 // ── GetPullslipsIdPdf ─────────────────────────────────────────────────────────
 
 func TestGetPullslipsIdPdf_OK(t *testing.T) {
@@ -241,6 +377,68 @@ func TestPostPullslips_OK(t *testing.T) {
 	psRepo.AssertExpectations(t)
 }
 
+func TestPostPullslips_TemplateLabel(t *testing.T) {
+	pr := patronRequest("pr-1", sym)
+	prRepo := new(MockPrRepo)
+	prRepo.On("ListPatronRequests", withOwnerRestriction()).Return([]pr_db.PatronRequest{pr}, int64(1), nil)
+	prRepo.On("GetNotificationsByPrId", "pr-1", mock.Anything).Return([]pr_db.Notification{}, int64(0), nil)
+	prRepo.On("GetTemplateByPurposeAudienceLabelAndOwner", pr_db.GetTemplateByPurposeAudienceLabelAndOwnerParams{
+		Owner:     sym,
+		Purpose:   "pullslip",
+		Label:     "shelf-slip",
+		Audience:  "staff",
+		Languages: []string{"da"},
+	}).Return(pr_db.Template{ContentType: "html", Body: "<p>{{.ReqId}}</p>"}, nil)
+	language := "da"
+	illRepo := new(MockIllRepo)
+	illRepo.On("GetPeerBySymbol", sym).Return(ill_db.Peer{CustomData: dirapi.Entry{Language: &language}}, nil)
+	psRepo := new(MockPsRepo)
+	psRepo.On("SavePullSlip", mock.MatchedBy(func(params ps_db.SavePullSlipParams) bool {
+		return params.TemplateLabel == pgtype.Text{String: "shelf-slip", Valid: true}
+	})).Return(ps_db.PullSlip{}, nil)
+
+	h := NewPsApiHandler(psRepo, prRepo, illRepo, tenant.NewResolver())
+	ids := []string{"pr-1"}
+	label := "shelf-slip"
+	body, _ := json.Marshal(psoapi.CreatePullSlip{IllTransactionIds: &ids, TemplateLabel: &label})
+	rr := httptest.NewRecorder()
+	h.PostPullslips(rr, newRequest(http.MethodPost, string(body)), psoapi.PostPullslipsParams{Symbol: &sym})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	prRepo.AssertExpectations(t)
+	illRepo.AssertExpectations(t)
+	psRepo.AssertExpectations(t)
+}
+
+func TestPostPullslips_Document(t *testing.T) {
+	pr := patronRequest("pr-1", sym)
+	prRepo := new(MockPrRepo)
+	prRepo.On("ListPatronRequests", withOwnerRestriction()).Return([]pr_db.PatronRequest{pr}, int64(1), nil)
+	prRepo.On("GetNotificationsByPrId", "pr-1", mock.Anything).Return([]pr_db.Notification{}, int64(0), nil)
+	psRepo := new(MockPsRepo)
+	psRepo.On("SavePullSlip", mock.MatchedBy(func(params ps_db.SavePullSlipParams) bool {
+		return params.Document == "label"
+	})).Return(ps_db.PullSlip{}, nil)
+
+	h := newHandler(psRepo, prRepo)
+	rr := httptest.NewRecorder()
+	h.PostPullslips(rr, newRequest(http.MethodPost, `{"illTransactionIds":["pr-1"],"document":"label"}`), psoapi.PostPullslipsParams{Symbol: &sym})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Disposition"), "shipping-labels.pdf")
+	prRepo.AssertExpectations(t)
+	psRepo.AssertExpectations(t)
+}
+
+func TestPostPullslips_InvalidDocument(t *testing.T) {
+	h := newHandler(nil, nil)
+	rr := httptest.NewRecorder()
+	h.PostPullslips(rr, newRequest(http.MethodPost, `{"illTransactionIds":["pr-1"],"document":"poster"}`), psoapi.PostPullslipsParams{Symbol: &sym})
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `unknown document \"poster\"`)
+}
+
 func TestPostPullslips_NilBody(t *testing.T) {
 	h := newHandler(nil, nil)
 	req := newRequest(http.MethodPost, "")
@@ -395,6 +593,54 @@ func TestPostPullslipsIdRegenerate_OK(t *testing.T) {
 	psRepo.AssertExpectations(t)
 }
 
+func TestPostPullslipsIdRegenerate_TemplateLabel(t *testing.T) {
+	slip := pullSlipFixture("ps-1")
+	slip.SearchCriteria = "id any pr-1 and (side = lending and supplier_symbol_exact = ISIL:TEST or (side = borrowing and requester_symbol_exact = ISIL:TEST))"
+	slip.TemplateLabel = pgtype.Text{String: "shelf-slip", Valid: true}
+
+	pr := patronRequest("pr-1", sym)
+	prRepo := new(MockPrRepo)
+	prRepo.On("ListPatronRequests", withOwnerRestriction()).Return([]pr_db.PatronRequest{pr}, int64(1), nil)
+	prRepo.On("GetNotificationsByPrId", "pr-1", mock.Anything).Return([]pr_db.Notification{}, int64(0), nil)
+	prRepo.On("GetTemplateByPurposeAudienceLabelAndOwner", pr_db.GetTemplateByPurposeAudienceLabelAndOwnerParams{
+		Owner:     sym,
+		Purpose:   "pullslip",
+		Label:     "shelf-slip",
+		Audience:  "staff",
+		Languages: []string{},
+	}).Return(pr_db.Template{}, pgx.ErrNoRows)
+	illRepo := new(MockIllRepo)
+	illRepo.On("GetPeerBySymbol", sym).Return(ill_db.Peer{}, errors.New("peer db error"))
+	psRepo := new(MockPsRepo)
+	psRepo.On("GetPullSlipByIdAndOwner", "ps-1", sym).Return(slip, nil)
+	psRepo.On("SavePullSlip", mock.MatchedBy(func(params ps_db.SavePullSlipParams) bool {
+		return params.TemplateLabel == slip.TemplateLabel
+	})).Return(slip, nil)
+
+	h := NewPsApiHandler(psRepo, prRepo, illRepo, tenant.NewResolver())
+	rr := httptest.NewRecorder()
+	h.PostPullslipsIdRegenerate(rr, newRequest(http.MethodPost, ""), "ps-1", psoapi.PostPullslipsIdRegenerateParams{Symbol: &sym})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	prRepo.AssertExpectations(t)
+	illRepo.AssertExpectations(t)
+	psRepo.AssertExpectations(t)
+}
+
+func TestGetPullslipsIdPdf_BookBand(t *testing.T) {
+	slip := pullSlipFixture("ps-1")
+	slip.Document = "bookband"
+	psRepo := new(MockPsRepo)
+	psRepo.On("GetPullSlipByIdAndOwner", "ps-1", sym).Return(slip, nil)
+
+	h := newHandler(psRepo, nil)
+	rr := httptest.NewRecorder()
+	h.GetPullslipsIdPdf(rr, newRequest(http.MethodGet, ""), "ps-1", psoapi.GetPullslipsIdPdfParams{Symbol: &sym})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Disposition"), "book-bands.pdf")
+}
+
 func TestPostPullslipsIdRegenerate_NotFound(t *testing.T) {
 	psRepo := new(MockPsRepo)
 	psRepo.On("GetPullSlipByIdAndOwner", "missing", sym).Return(ps_db.PullSlip{}, pgx.ErrNoRows)
@@ -456,7 +702,7 @@ func TestPostPullslipsIdRegenerate_SaveError(t *testing.T) {
 
 func TestWritePdf(t *testing.T) {
 	rr := httptest.NewRecorder()
-	writePdf(rr, []byte("%PDF-direct"))
+	writePdf(rr, psservice.DocumentPullSlip, []byte("%PDF-direct"))
 	assert.Equal(t, http.StatusOK, rr.Code)
 	assert.Equal(t, "application/pdf", rr.Header().Get("Content-Type"))
 	assert.Contains(t, rr.Header().Get("Content-Disposition"), "pull-slips")
@@ -0,0 +1,88 @@
+package psservice
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"github.com/carlos7ags/folio/document"
+)
+
+// Document is a kind of printable document generated for patron requests.
+type Document string
+
+const (
+	DocumentPullSlip Document = "pullslip"
+	DocumentLabel    Document = "label"
+	DocumentBookBand Document = "bookband"
+)
+
+// Page sizes in points of the 4x6 inch shipping label and the 3x11 inch book band.
+var (
+	PageSizeLabel    = document.PageSize{Width: 288, Height: 432}
+	PageSizeBookBand = document.PageSize{Width: 216, Height: 792}
+)
+
+//go:embed label_template.html
+var labelTemplate string
+
+//go:embed book_band_template.html
+var bookBandTemplate string
+
+// ParseDocument returns the document named by s, a pull slip if s is empty.
+func ParseDocument(s string) (Document, error) {
+	switch Document(s) {
+	case "", DocumentPullSlip:
+		return DocumentPullSlip, nil
+	case DocumentLabel, DocumentBookBand:
+		return Document(s), nil
+	}
+	return "", fmt.Errorf("unknown document %q", s)
+}
+
+// Filename is the name the PDF of the document is downloaded or attached as.
+func (d Document) Filename() string {
+	switch d {
+	case DocumentLabel:
+		return "shipping-labels.pdf"
+	case DocumentBookBand:
+		return "book-bands.pdf"
+	}
+	return "pull-slips.pdf"
+}
+
+// ZplFilename is the name the ZPL of the document is downloaded as.
+func (d Document) ZplFilename() string {
+	return strings.TrimSuffix(d.Filename(), ".pdf") + ".zpl"
+}
+
+// HtmlToPdf lays out an HTML document on pages of the document's size.
+func (d Document) HtmlToPdf(html string) ([]byte, error) {
+	doc := document.NewDocument(d.pageSize())
+	err := doc.AddHTML(html, nil)
+	if err != nil {
+		return nil, err
+	}
+	return doc.ToBytes()
+}
+
+func (d Document) pageSize() document.PageSize {
+	switch d {
+	case DocumentLabel:
+		return PageSizeLabel
+	case DocumentBookBand:
+		return PageSizeBookBand
+	}
+	return document.PageSizeA4
+}
+
+// embeddedTemplate is the built-in template used when the owner has no stored one.
+func (d Document) embeddedTemplate() string {
+	switch d {
+	case DocumentLabel:
+		return labelTemplate
+	case DocumentBookBand:
+		return bookBandTemplate
+	}
+	return pullSlipTemplate
+}
@@ -0,0 +1,90 @@
+package psservice
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/carlos7ags/folio/document"
+	prtemplate "github.com/indexdata/crosslink/broker/patron_request/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Document
+		err      string
+	}{
+		{"default", "", DocumentPullSlip, ""},
+		{"pull slip", "pullslip", DocumentPullSlip, ""},
+		{"label", "label", DocumentLabel, ""},
+		{"book band", "bookband", DocumentBookBand, ""},
+		{"unknown", "poster", "", `unknown document "poster"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := ParseDocument(tc.input)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, doc)
+		})
+	}
+}
+
+func TestDocumentFilename(t *testing.T) {
+	assert.Equal(t, "pull-slips.pdf", DocumentPullSlip.Filename())
+	assert.Equal(t, "shipping-labels.pdf", DocumentLabel.Filename())
+	assert.Equal(t, "book-bands.pdf", DocumentBookBand.Filename())
+	assert.Equal(t, "pull-slips.pdf", Document("").Filename())
+}
+
+func TestDocumentPageSize(t *testing.T) {
+	assert.Equal(t, document.PageSizeA4, DocumentPullSlip.pageSize())
+	assert.Equal(t, PageSizeLabel, DocumentLabel.pageSize())
+	assert.Equal(t, PageSizeBookBand, DocumentBookBand.pageSize())
+}
+
+func TestDocumentHtmlToPdf(t *testing.T) {
+	for _, doc := range []Document{DocumentPullSlip, DocumentLabel, DocumentBookBand} {
+		pdf, err := doc.HtmlToPdf("<p>slip</p>")
+		assert.NoError(t, err)
+		assert.Equal(t, "%PDF", string(pdf[:4]))
+	}
+}
+
+func TestRenderLabelHTML(t *testing.T) {
+	data := prtemplate.Data{PullSlip: prtemplate.PullSlip{
+		ReqId:           "REQ-123",
+		DeliveryAddress: "Main Library\n1 Library Square",
+		ReturnAddress:   "2 College Road",
+		BarcodeBase64:   "abc123",
+		QrCodeBase64:    "qr456",
+	}}
+	html, err := renderPullSlipHTML(data, DocumentLabel, nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(html, "<title>Shipping label</title>"))
+	assert.True(t, strings.Contains(html, "Main Library\n1 Library Square"))
+	assert.True(t, strings.Contains(html, "2 College Road"))
+	assert.True(t, strings.Contains(html, "data:image/png;base64,abc123"))
+	assert.True(t, strings.Contains(html, "data:image/png;base64,qr456"))
+}
+
+func TestRenderBookBandHTML(t *testing.T) {
+	data := prtemplate.Data{PullSlip: prtemplate.PullSlip{
+		ReqId:          "REQ-123",
+		Title:          "Big Shark",
+		DueDate:        "2026-01-01",
+		ReturnAddress:  "2 College Road",
+		LoanConditions: "LibraryUseOnly",
+	}}
+	html, err := renderPullSlipHTML(data, DocumentBookBand, nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(html, "<title>Book band</title>"))
+	assert.True(t, strings.Contains(html, "2026-01-01"))
+	assert.True(t, strings.Contains(html, "2 College Road"))
+	assert.True(t, strings.Contains(html, "LibraryUseOnly"))
+}
@@ -4,24 +4,41 @@ import (
 	"bytes"
 	_ "embed"
 	"encoding/base64"
+	"errors"
+	"html"
 	"html/template"
 	"image/png"
 	"strings"
+	"time"
 
 	"github.com/boombuler/barcode"
 	"github.com/boombuler/barcode/code128"
-	"github.com/carlos7ags/folio/document"
+	"github.com/boombuler/barcode/qr"
 	"github.com/carlos7ags/folio/reader"
 	"github.com/indexdata/crosslink/broker/common"
 	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
-	"github.com/indexdata/crosslink/iso18626"
+	"github.com/indexdata/crosslink/broker/patron_request/proapi"
+	prtemplate "github.com/indexdata/crosslink/broker/patron_request/template"
+	"github.com/indexdata/crosslink/broker/shim"
+	"github.com/jackc/pgx/v5"
 )
 
 const DEFAULT_FOR_NO_VALUE = "n/a"
 const DATE_LAYOUT = "2006-01-02"
 
 type PdfService interface {
-	GeneratePdfPullSlipForPrs(ctx common.ExtendedContext, prs []pr_db.PatronRequest) ([]byte, error)
+	GeneratePdfPullSlipForPrs(ctx common.ExtendedContext, prs []pr_db.PatronRequest, template PullSlipTemplate) ([]byte, error)
+	GenerateZplPullSlipForPrs(ctx common.ExtendedContext, prs []pr_db.PatronRequest, doc Document) ([]byte, error)
+}
+
+// PullSlipTemplate selects the document to print, a pull slip if not set, and the owner's stored template
+// for it by label, in the first of Languages available. Without a label, or if no template matches,
+// the built-in template of the document is used.
+type PullSlipTemplate struct {
+	Document  Document
+	Owner     string
+	Label     string
+	Languages []string
 }
 
 type PdfServiceImpl struct {
@@ -34,42 +51,24 @@ func NewPdfService(prRepo pr_db.PrRepo) PdfService {
 	}
 }
 
-type PullSlipData struct {
-	BorrowerName     string
-	ReqId            string
-	PickupLocation   string
-	Title            string
-	Author           string
-	DueDate          string
-	ReturnAddress    string
-	BarcodeBase64    string
-	ServiceType      string
-	ServiceLevel     string
-	SystemIdentifier string
-	Publisher        string
-	Volume           string
-	Issue            string
-	Pages            string
-	StaffNotes       string
-	CallNumber       string
-	LoanConditions   string
-}
-
 //go:embed pull_slip_template.html
 var pullSlipTemplate string
 
-func (p *PdfServiceImpl) GeneratePdfPullSlipForPrs(ctx common.ExtendedContext, prs []pr_db.PatronRequest) ([]byte, error) {
+func (p *PdfServiceImpl) GeneratePdfPullSlipForPrs(ctx common.ExtendedContext, prs []pr_db.PatronRequest, template PullSlipTemplate) ([]byte, error) {
+	if template.Document == "" {
+		template.Document = DocumentPullSlip
+	}
+	stored, err := p.getTemplate(ctx, template)
+	if err != nil {
+		return []byte{}, err
+	}
 	pdfs := []*reader.PdfReader{}
 	for _, pr := range prs {
-		notes, _, err := p.prRepo.GetNotificationsByPrId(ctx, pr_db.GetNotificationsByPrIdParams{Limit: 100, Offset: 0, PrID: pr.ID, Kind: string(pr_db.NotificationKindNote)})
-		if err != nil {
-			return []byte{}, err
-		}
-		conditions, _, err := p.prRepo.GetNotificationsByPrId(ctx, pr_db.GetNotificationsByPrIdParams{Limit: 100, Offset: 0, PrID: pr.ID, Kind: string(pr_db.NotificationKindCondition)})
+		notes, conditions, err := p.getNotifications(ctx, pr)
 		if err != nil {
 			return []byte{}, err
 		}
-		pdf, err := p.GeneratePdfPullSlip(pr, notes, conditions)
+		pdf, err := p.GeneratePdfPullSlip(pr, notes, conditions, template.Document, stored)
 		if err != nil {
 			return []byte{}, err
 		}
@@ -90,19 +89,102 @@ func (p *PdfServiceImpl) GeneratePdfPullSlipForPrs(ctx common.ExtendedContext, p
 	return buf.Bytes(), nil
 }
 
-func (p *PdfServiceImpl) GeneratePdfPullSlip(pr pr_db.PatronRequest, notes []pr_db.Notification, conditions []pr_db.Notification) ([]byte, error) {
-	barcodeData, err := getBarcodeBase64(pr.RequesterReqID.String)
+// GenerateZplPullSlipForPrs renders a document of each patron request with its ZPL layout, for thermal printers.
+func (p *PdfServiceImpl) GenerateZplPullSlipForPrs(ctx common.ExtendedContext, prs []pr_db.PatronRequest, doc Document) ([]byte, error) {
+	layout, err := doc.zplLayout()
+	if err != nil {
+		return []byte{}, err
+	}
+	var sb strings.Builder
+	for _, pr := range prs {
+		notes, conditions, err := p.getNotifications(ctx, pr)
+		if err != nil {
+			return []byte{}, err
+		}
+		slip, err := newPullSlip(pr, notes, conditions)
+		if err != nil {
+			return []byte{}, err
+		}
+		if err = layout.Render(&sb, slip); err != nil {
+			return []byte{}, err
+		}
+	}
+	return []byte(sb.String()), nil
+}
+
+// getNotifications reads the notes and loan conditions printed on the documents of a patron request.
+func (p *PdfServiceImpl) getNotifications(ctx common.ExtendedContext, pr pr_db.PatronRequest) ([]pr_db.Notification, []pr_db.Notification, error) {
+	notes, _, err := p.prRepo.GetNotificationsByPrId(ctx, pr_db.GetNotificationsByPrIdParams{Limit: 100, Offset: 0, PrID: pr.ID, Kind: string(pr_db.NotificationKindNote)})
+	if err != nil {
+		return nil, nil, err
+	}
+	conditions, _, err := p.prRepo.GetNotificationsByPrId(ctx, pr_db.GetNotificationsByPrIdParams{Limit: 100, Offset: 0, PrID: pr.ID, Kind: string(pr_db.NotificationKindCondition)})
+	if err != nil {
+		return nil, nil, err
+	}
+	return notes, conditions, nil
+}
+
+// getTemplate loads the stored template selected by template, or returns nil for the built-in one.
+func (p *PdfServiceImpl) getTemplate(ctx common.ExtendedContext, template PullSlipTemplate) (*pr_db.Template, error) {
+	if template.Label == "" {
+		return nil, nil
+	}
+	stored, err := p.prRepo.GetTemplateByPurposeAudienceLabelAndOwner(ctx, pr_db.GetTemplateByPurposeAudienceLabelAndOwnerParams{
+		Owner:     template.Owner,
+		Purpose:   string(template.Document),
+		Label:     template.Label,
+		Audience:  string(proapi.ModelActionParamsSendToStaff),
+		Languages: template.Languages,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.Logger().Info("no document template found, using the built-in one", "document", template.Document, "owner", template.Owner, "label", template.Label)
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// GeneratePdfPullSlip renders a document of a patron request with the stored template, or the built-in one if it is nil.
+func (p *PdfServiceImpl) GeneratePdfPullSlip(pr pr_db.PatronRequest, notes []pr_db.Notification, conditions []pr_db.Notification, doc Document, template *pr_db.Template) ([]byte, error) {
+	slip, err := newPullSlip(pr, notes, conditions)
 	if err != nil {
 		return nil, err
 	}
-	data := PullSlipData{
+	data := prtemplate.Data{
+		PullSlip: slip,
+		Request:  prtemplate.NewRequest(pr, append(append([]pr_db.Notification{}, notes...), conditions...)),
+		Now:      time.Now(),
+	}
+	html, err := renderPullSlipHTML(data, doc, template)
+	if err != nil {
+		return nil, err
+	}
+	return doc.HtmlToPdf(html)
+}
+
+// newPullSlip collects the printed fields of a patron request, its notes and loan conditions.
+func newPullSlip(pr pr_db.PatronRequest, notes []pr_db.Notification, conditions []pr_db.Notification) (prtemplate.PullSlip, error) {
+	barcodeData, err := getBarcodeBase64(pr.RequesterReqID.String)
+	if err != nil {
+		return prtemplate.PullSlip{}, err
+	}
+	qrCodeData, err := getQrCodeBase64(pr.RequesterReqID.String)
+	if err != nil {
+		return prtemplate.PullSlip{}, err
+	}
+	slip := prtemplate.PullSlip{
 		ReqId:            pr.RequesterReqID.String,
 		PickupLocation:   getPickupLocation(pr),
 		Title:            DEFAULT_FOR_NO_VALUE,
 		Author:           DEFAULT_FOR_NO_VALUE,
 		DueDate:          DEFAULT_FOR_NO_VALUE,
 		ReturnAddress:    DEFAULT_FOR_NO_VALUE,
+		DeliveryAddress:  getDeliveryAddress(pr),
 		BarcodeBase64:    barcodeData,
+		QrCodeBase64:     qrCodeData,
 		ServiceType:      DEFAULT_FOR_NO_VALUE,
 		ServiceLevel:     DEFAULT_FOR_NO_VALUE,
 		SystemIdentifier: DEFAULT_FOR_NO_VALUE,
@@ -115,57 +197,55 @@ func (p *PdfServiceImpl) GeneratePdfPullSlip(pr pr_db.PatronRequest, notes []pr_
 		LoanConditions:   getLoanConditions(conditions),
 	}
 	if pr.IllRequest.BibliographicInfo.Author != "" {
-		data.Author = pr.IllRequest.BibliographicInfo.Author
+		slip.Author = pr.IllRequest.BibliographicInfo.Author
 	}
 	if pr.IllRequest.BibliographicInfo.Title != "" {
-		data.Title = pr.IllRequest.BibliographicInfo.Title
+		slip.Title = pr.IllRequest.BibliographicInfo.Title
 	}
 	if pr.IllRequest.BibliographicInfo.Volume != "" {
-		data.Volume = pr.IllRequest.BibliographicInfo.Volume
+		slip.Volume = pr.IllRequest.BibliographicInfo.Volume
 	}
 	if pr.IllRequest.BibliographicInfo.Issue != "" {
-		data.Issue = pr.IllRequest.BibliographicInfo.Issue
+		slip.Issue = pr.IllRequest.BibliographicInfo.Issue
 	}
 	if pr.IllRequest.BibliographicInfo.EstimatedNoPages != "" {
-		data.Pages = pr.IllRequest.BibliographicInfo.EstimatedNoPages
+		slip.Pages = pr.IllRequest.BibliographicInfo.EstimatedNoPages
 	}
 	if pr.IllRequest.BibliographicInfo.SupplierUniqueRecordId != "" {
-		data.SystemIdentifier = pr.IllRequest.BibliographicInfo.SupplierUniqueRecordId
+		slip.SystemIdentifier = pr.IllRequest.BibliographicInfo.SupplierUniqueRecordId
 	}
 	if pr.IllRequest.PublicationInfo != nil && pr.IllRequest.PublicationInfo.Publisher != "" {
-		data.Publisher = pr.IllRequest.PublicationInfo.Publisher
+		slip.Publisher = pr.IllRequest.PublicationInfo.Publisher
 	}
 	if pr.IllResponse.StatusInfo.DueDate != nil {
-		data.DueDate = pr.IllResponse.StatusInfo.DueDate.Format(DATE_LAYOUT)
+		slip.DueDate = pr.IllResponse.StatusInfo.DueDate.Format(DATE_LAYOUT)
 	}
 	if pr.IllResponse.ReturnInfo != nil && pr.IllResponse.ReturnInfo.PhysicalAddress != nil {
-		data.ReturnAddress = formatPhysicalAddress(pr.IllResponse.ReturnInfo.PhysicalAddress)
+		slip.ReturnAddress = prtemplate.FormatPhysicalAddress(pr.IllResponse.ReturnInfo.PhysicalAddress)
 	}
 	if pr.IllRequest.ServiceInfo != nil {
 		if pr.IllRequest.ServiceInfo.ServiceLevel != nil && pr.IllRequest.ServiceInfo.ServiceLevel.Text != "" {
-			data.ServiceLevel = pr.IllRequest.ServiceInfo.ServiceLevel.Text
+			slip.ServiceLevel = pr.IllRequest.ServiceInfo.ServiceLevel.Text
 		}
 		if pr.IllRequest.ServiceInfo.ServiceType != "" {
-			data.ServiceType = string(pr.IllRequest.ServiceInfo.ServiceType)
+			slip.ServiceType = string(pr.IllRequest.ServiceInfo.ServiceType)
 		}
 	}
-	doc := document.NewDocument(document.PageSizeA4)
-
-	html, err := renderPullSlipHTML(data)
-	if err != nil {
-		return nil, err
-	}
-
-	err = doc.AddHTML(html, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	return doc.ToBytes()
+	return slip, nil
 }
 
-func renderPullSlipHTML(data PullSlipData) (string, error) {
-	tmpl, err := template.New("pull-slip").Parse(pullSlipTemplate)
+func renderPullSlipHTML(data prtemplate.Data, doc Document, stored *pr_db.Template) (string, error) {
+	if stored != nil {
+		body, err := prtemplate.Render(stored.ContentType, stored.Body, data)
+		if err != nil {
+			return "", err
+		}
+		if stored.ContentType != string(proapi.TemplateContentTypeHtml) {
+			body = "<pre>" + html.EscapeString(body) + "</pre>"
+		}
+		return body, nil
+	}
+	tmpl, err := template.New(string(doc)).Parse(doc.embeddedTemplate())
 	if err != nil {
 		return "", err
 	}
@@ -212,27 +292,21 @@ func getBarcodeBase64(data string) (string, error) {
 	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
 }
 
-func formatPhysicalAddress(a *iso18626.PhysicalAddress) string {
-	parts := []string{}
-	if a.Line1 != "" {
-		parts = append(parts, a.Line1)
-	}
-	if a.Line2 != "" {
-		parts = append(parts, a.Line2)
-	}
-	if a.Locality != "" {
-		parts = append(parts, a.Locality)
-	}
-	if a.PostalCode != "" {
-		parts = append(parts, a.PostalCode)
+func getQrCodeBase64(data string) (string, error) {
+	qrCode, err := qr.Encode(data, qr.M, qr.Auto)
+	if err != nil {
+		return "", err
 	}
-	if a.Region != nil && a.Region.Text != "" {
-		parts = append(parts, a.Region.Text)
+	scaled, err := barcode.Scale(qrCode, 150, 150)
+	if err != nil {
+		return "", err
 	}
-	if a.Country != nil && a.Country.Text != "" {
-		parts = append(parts, a.Country.Text)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, scaled); err != nil {
+		return "", err
 	}
-	return strings.Join(parts, ", ")
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
 }
 
 func getStaffNotes(noteList []pr_db.Notification) string {
@@ -277,14 +351,26 @@ func getCallNumber(request pr_db.PatronRequest) string {
 	return callNumber
 }
 
-func getPickupLocation(request pr_db.PatronRequest) string {
+// getDeliveryAddress formats the requested delivery address for a shipping label, one line per address part.
+func getDeliveryAddress(request pr_db.PatronRequest) string {
 	if len(request.IllRequest.RequestedDeliveryInfo) > 0 && request.IllRequest.RequestedDeliveryInfo[0].Address != nil {
-		address := *request.IllRequest.RequestedDeliveryInfo[0].Address
+		address := request.IllRequest.RequestedDeliveryInfo[0].Address
 		if address.PhysicalAddress != nil {
-			return formatPhysicalAddress(address.PhysicalAddress)
+			var sb strings.Builder
+			shim.MarshalAddress(&sb, address.PhysicalAddress)
+			if lines := strings.TrimSpace(sb.String()); lines != "" {
+				return lines
+			}
 		} else if address.ElectronicAddress != nil && address.ElectronicAddress.ElectronicAddressData != "" {
 			return address.ElectronicAddress.ElectronicAddressData
 		}
 	}
 	return DEFAULT_FOR_NO_VALUE
 }
+
+func getPickupLocation(request pr_db.PatronRequest) string {
+	if location := prtemplate.PickupLocation(request); location != "" {
+		return location
+	}
+	return DEFAULT_FOR_NO_VALUE
+}
@@ -12,8 +12,10 @@ import (
 
 	"github.com/indexdata/crosslink/broker/common"
 	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
+	prtemplate "github.com/indexdata/crosslink/broker/patron_request/template"
 	"github.com/indexdata/crosslink/iso18626"
 	"github.com/indexdata/go-utils/utils"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/assert"
 )
@@ -53,7 +55,7 @@ func TestGetBarcodeBase64(t *testing.T) {
 }
 
 func TestRenderPullSlipHTML(t *testing.T) {
-	html, err := renderPullSlipHTML(PullSlipData{
+	html, err := renderPullSlipHTML(prtemplate.Data{PullSlip: prtemplate.PullSlip{
 		ReqId:          "REQ-123",
 		PickupLocation: "Main Library",
 		Title:          "Big Shark",
@@ -61,20 +63,42 @@ func TestRenderPullSlipHTML(t *testing.T) {
 		DueDate:        "2026-01-01",
 		ReturnAddress:  "1 Test Street",
 		BarcodeBase64:  "abc123",
-	})
+	}}, DocumentPullSlip, nil)
 	assert.NoError(t, err)
 	assert.True(t, strings.Contains(html, "REQ-123"))
 	assert.True(t, strings.Contains(html, "Main Library"))
 	assert.True(t, strings.Contains(html, "data:image/png;base64,abc123"))
 }
 
+func TestRenderPullSlipHTML_StoredHtml(t *testing.T) {
+	data := prtemplate.Data{
+		PullSlip: prtemplate.PullSlip{ReqId: "REQ-123", CallNumber: "QA76 <ref>"},
+		Request:  prtemplate.Request{Patron: "Jane Doe"},
+	}
+	html, err := renderPullSlipHTML(data, DocumentPullSlip, &pr_db.Template{ContentType: "html", Body: "<h1>{{.ReqId}}</h1>{{.CallNumber}} for {{.Request.Patron}}"})
+	assert.NoError(t, err)
+	assert.Equal(t, "<h1>REQ-123</h1>QA76 &lt;ref&gt; for Jane Doe", html)
+}
+
+func TestRenderPullSlipHTML_StoredText(t *testing.T) {
+	data := prtemplate.Data{PullSlip: prtemplate.PullSlip{ReqId: "REQ-123", StaffNotes: "Fragile & heavy"}}
+	html, err := renderPullSlipHTML(data, DocumentPullSlip, &pr_db.Template{ContentType: "text", Body: "{{.ReqId}}\n{{.StaffNotes}}"})
+	assert.NoError(t, err)
+	assert.Equal(t, "<pre>REQ-123\nFragile &amp; heavy</pre>", html)
+}
+
+func TestRenderPullSlipHTML_StoredTemplateError(t *testing.T) {
+	_, err := renderPullSlipHTML(prtemplate.Data{}, DocumentPullSlip, &pr_db.Template{ContentType: "html", Body: "{{.Shelf}}"})
+	assert.ErrorContains(t, err, "can't evaluate field Shelf")
+}
+
 func TestRenderPullSlipHTML_InvalidTemplate(t *testing.T) {
 	// Temporarily swap pullSlipTemplate with an invalid one
 	orig := pullSlipTemplate
 	defer func() { pullSlipTemplate = orig }()
 	pullSlipTemplate = `{{.Unclosed`
 
-	_, err := renderPullSlipHTML(PullSlipData{ReqId: "X"})
+	_, err := renderPullSlipHTML(prtemplate.Data{PullSlip: prtemplate.PullSlip{ReqId: "X"}}, DocumentPullSlip, nil)
 	assert.Error(t, err)
 }
 
@@ -86,7 +110,7 @@ func TestRenderPullSlipHTML_ExecuteError(t *testing.T) {
 	// The only reliable way in Go templates: call.option "missingkey=error" with unknown key on a map
 	pullSlipTemplate = `{{index . "nonexistent"}}`
 
-	_, err := renderPullSlipHTML(PullSlipData{ReqId: "X"})
+	_, err := renderPullSlipHTML(prtemplate.Data{PullSlip: prtemplate.PullSlip{ReqId: "X"}}, DocumentPullSlip, nil)
 	// Execute on a struct with map-access fails
 	assert.Error(t, err)
 }
@@ -100,7 +124,7 @@ func TestGeneratePdfPullSlip_Defaults(t *testing.T) {
 		},
 		// No bibliographic info — all fields should fall back to DEFAULT_FOR_NO_VALUE
 	}
-	pdfBytes, err := svc.GeneratePdfPullSlip(pr, []pr_db.Notification{}, []pr_db.Notification{})
+	pdfBytes, err := svc.GeneratePdfPullSlip(pr, []pr_db.Notification{}, []pr_db.Notification{}, DocumentPullSlip, nil)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, pdfBytes)
 	// PDF magic bytes: %PDF
@@ -122,7 +146,7 @@ func TestGeneratePdfPullSlip_WithBibliographicInfo(t *testing.T) {
 			},
 		},
 	}
-	pdfBytes, err := svc.GeneratePdfPullSlip(pr, []pr_db.Notification{}, []pr_db.Notification{})
+	pdfBytes, err := svc.GeneratePdfPullSlip(pr, []pr_db.Notification{}, []pr_db.Notification{}, DocumentPullSlip, nil)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, pdfBytes)
 	assert.Equal(t, "%PDF", string(pdfBytes[:4]))
@@ -195,12 +219,37 @@ func TestGeneratePdfPullSlip_FullData(t *testing.T) {
 		{Condition: pgtype.Text{String: "No photocopying", Valid: true}},
 	}
 
-	pdfBytes, err := svc.GeneratePdfPullSlip(pr, notes, conditions)
+	pdfBytes, err := svc.GeneratePdfPullSlip(pr, notes, conditions, DocumentPullSlip, nil)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, pdfBytes)
 	assert.Equal(t, "%PDF", string(pdfBytes[:4]))
 }
 
+func TestGetQrCodeBase64(t *testing.T) {
+	encoded, err := getQrCodeBase64("REQ-123")
+	assert.NoError(t, err)
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	assert.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(raw))
+	assert.NoError(t, err)
+	assert.Equal(t, 150, img.Bounds().Dx())
+	assert.Equal(t, 150, img.Bounds().Dy())
+}
+
+func TestGeneratePdfPullSlip_Documents(t *testing.T) {
+	svc := &PdfServiceImpl{}
+	pr := pr_db.PatronRequest{
+		ID:             "REQ-DOC",
+		RequesterReqID: pgtype.Text{String: "REQ-DOC", Valid: true},
+	}
+	for _, doc := range []Document{DocumentLabel, DocumentBookBand} {
+		pdfBytes, err := svc.GeneratePdfPullSlip(pr, nil, nil, doc, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "%PDF", string(pdfBytes[:4]))
+	}
+}
+
 func TestGetBarcodeBase64_EncodeError(t *testing.T) {
 	// Characters > 127 are outside code128 charset, causing encode to fail
 	_, err := getBarcodeBase64("\x80invalid")
@@ -215,7 +264,7 @@ func TestGeneratePdfPullSlip_BarcodeError(t *testing.T) {
 			Valid:  true,
 		},
 	}
-	_, err := svc.GeneratePdfPullSlip(pr, []pr_db.Notification{}, []pr_db.Notification{})
+	_, err := svc.GeneratePdfPullSlip(pr, []pr_db.Notification{}, []pr_db.Notification{}, DocumentPullSlip, nil)
 	assert.Error(t, err)
 }
 
@@ -232,47 +281,10 @@ func TestGeneratePdfPullSlip_TemplateError(t *testing.T) {
 			Valid:  true,
 		},
 	}
-	_, err := svc.GeneratePdfPullSlip(pr, []pr_db.Notification{}, []pr_db.Notification{})
+	_, err := svc.GeneratePdfPullSlip(pr, []pr_db.Notification{}, []pr_db.Notification{}, DocumentPullSlip, nil)
 	assert.Error(t, err)
 }
 
-// ── formatPhysicalAddress ─────────────────────────────────────────────────────
-
-func TestFormatPhysicalAddress_Full(t *testing.T) {
-	a := &iso18626.PhysicalAddress{
-		Line1:      "1 Main St",
-		Line2:      "Floor 2",
-		Locality:   "Springfield",
-		PostalCode: "12345",
-		Region:     &iso18626.TypeSchemeValuePair{Text: "IL"},
-		Country:    &iso18626.TypeSchemeValuePair{Text: "US"},
-	}
-	assert.Equal(t, "1 Main St, Floor 2, Springfield, 12345, IL, US", formatPhysicalAddress(a))
-}
-
-func TestFormatPhysicalAddress_Partial(t *testing.T) {
-	// Only Line1 and Locality — Region/Country nil, Line2/PostalCode empty
-	a := &iso18626.PhysicalAddress{
-		Line1:    "42 Book Rd",
-		Locality: "Shelbyville",
-	}
-	assert.Equal(t, "42 Book Rd, Shelbyville", formatPhysicalAddress(a))
-}
-
-func TestFormatPhysicalAddress_EmptyRegionText(t *testing.T) {
-	// Region present but empty Text — should be skipped
-	a := &iso18626.PhysicalAddress{
-		Line1:   "1 St",
-		Region:  &iso18626.TypeSchemeValuePair{Text: ""},
-		Country: &iso18626.TypeSchemeValuePair{Text: ""},
-	}
-	assert.Equal(t, "1 St", formatPhysicalAddress(a))
-}
-
-func TestFormatPhysicalAddress_Empty(t *testing.T) {
-	assert.Equal(t, "", formatPhysicalAddress(&iso18626.PhysicalAddress{}))
-}
-
 // ── getStaffNotes ─────────────────────────────────────────────────────────────
 
 func TestGetStaffNotes_Empty(t *testing.T) {
@@ -343,6 +355,55 @@ func TestGetCallNumber_Multiple(t *testing.T) {
 	assert.Equal(t, "QA76, PR9199", getCallNumber(pr))
 }
 
+// ── getDeliveryAddress ────────────────────────────────────────────────────────
+
+func TestGetDeliveryAddress_NoDeliveryInfo(t *testing.T) {
+	assert.Equal(t, DEFAULT_FOR_NO_VALUE, getDeliveryAddress(pr_db.PatronRequest{}))
+}
+
+func TestGetDeliveryAddress_PhysicalAddress(t *testing.T) {
+	pr := pr_db.PatronRequest{
+		IllRequest: iso18626.Request{
+			RequestedDeliveryInfo: []iso18626.RequestedDeliveryInfo{
+				{Address: &iso18626.Address{
+					PhysicalAddress: &iso18626.PhysicalAddress{
+						Line1:      "Main Library",
+						Line2:      "1 Library Square",
+						Locality:   "Springfield",
+						PostalCode: "12345",
+						Country:    &iso18626.TypeSchemeValuePair{Text: "US"},
+					},
+				}},
+			},
+		},
+	}
+	assert.Equal(t, "Main Library\n1 Library Square\nSpringfield, 12345\nUS", getDeliveryAddress(pr))
+}
+
+func TestGetDeliveryAddress_EmptyPhysicalAddress(t *testing.T) {
+	pr := pr_db.PatronRequest{
+		IllRequest: iso18626.Request{
+			RequestedDeliveryInfo: []iso18626.RequestedDeliveryInfo{
+				{Address: &iso18626.Address{PhysicalAddress: &iso18626.PhysicalAddress{}}},
+			},
+		},
+	}
+	assert.Equal(t, DEFAULT_FOR_NO_VALUE, getDeliveryAddress(pr))
+}
+
+func TestGetDeliveryAddress_ElectronicAddress(t *testing.T) {
+	pr := pr_db.PatronRequest{
+		IllRequest: iso18626.Request{
+			RequestedDeliveryInfo: []iso18626.RequestedDeliveryInfo{
+				{Address: &iso18626.Address{
+					ElectronicAddress: &iso18626.ElectronicAddress{ElectronicAddressData: "ill@library.org"},
+				}},
+			},
+		},
+	}
+	assert.Equal(t, "ill@library.org", getDeliveryAddress(pr))
+}
+
 // ── getPickupLocation ─────────────────────────────────────────────────────────
 
 func TestGetPickupLocation_NoDeliveryInfo(t *testing.T) {
@@ -406,6 +467,14 @@ type mockPrRepo struct {
 	conditions   []pr_db.Notification
 	noteErr      error
 	condErr      error
+	template     pr_db.Template
+	templateErr  error
+	gotTemplate  pr_db.GetTemplateByPurposeAudienceLabelAndOwnerParams
+}
+
+func (m *mockPrRepo) GetTemplateByPurposeAudienceLabelAndOwner(_ common.ExtendedContext, params pr_db.GetTemplateByPurposeAudienceLabelAndOwnerParams) (pr_db.Template, error) {
+	m.gotTemplate = params
+	return m.template, m.templateErr
 }
 
 func (m *mockPrRepo) GetNotificationsByPrId(_ common.ExtendedContext, params pr_db.GetNotificationsByPrIdParams) ([]pr_db.Notification, int64, error) {
@@ -426,7 +495,7 @@ func TestGeneratePdfPullSlipForPrs_Single(t *testing.T) {
 		ID:             "pr-1",
 		RequesterReqID: pgtype.Text{String: "REQ-1", Valid: true},
 	}
-	pdfBytes, err := svc.GeneratePdfPullSlipForPrs(appCtx, []pr_db.PatronRequest{pr})
+	pdfBytes, err := svc.GeneratePdfPullSlipForPrs(appCtx, []pr_db.PatronRequest{pr}, PullSlipTemplate{})
 	assert.NoError(t, err)
 	assert.True(t, len(pdfBytes) > 0)
 }
@@ -438,16 +507,61 @@ func TestGeneratePdfPullSlipForPrs_Multiple(t *testing.T) {
 		{ID: "pr-1", RequesterReqID: pgtype.Text{String: "REQ-1", Valid: true}},
 		{ID: "pr-2", RequesterReqID: pgtype.Text{String: "REQ-2", Valid: true}},
 	}
-	pdfBytes, err := svc.GeneratePdfPullSlipForPrs(appCtx, prs)
+	pdfBytes, err := svc.GeneratePdfPullSlipForPrs(appCtx, prs, PullSlipTemplate{})
 	assert.NoError(t, err)
 	assert.True(t, len(pdfBytes) > 0)
 }
 
+func TestGeneratePdfPullSlipForPrs_StoredTemplate(t *testing.T) {
+	repo := &mockPrRepo{template: pr_db.Template{ContentType: "html", Body: "<p>{{.ReqId}}: {{.Request.Title}}</p>"}}
+	svc := newSvcWithMock(repo)
+	pr := pr_db.PatronRequest{ID: "pr-1", RequesterReqID: pgtype.Text{String: "REQ-1", Valid: true}}
+	pdfBytes, err := svc.GeneratePdfPullSlipForPrs(appCtx, []pr_db.PatronRequest{pr},
+		PullSlipTemplate{Owner: "ISIL:OWNER", Label: "shelf-slip", Languages: []string{"de"}})
+	assert.NoError(t, err)
+	assert.True(t, len(pdfBytes) > 0)
+	assert.Equal(t, pr_db.GetTemplateByPurposeAudienceLabelAndOwnerParams{
+		Owner:     "ISIL:OWNER",
+		Purpose:   "pullslip",
+		Label:     "shelf-slip",
+		Audience:  "staff",
+		Languages: []string{"de"},
+	}, repo.gotTemplate)
+}
+
+func TestGeneratePdfPullSlipForPrs_StoredLabelTemplate(t *testing.T) {
+	repo := &mockPrRepo{template: pr_db.Template{ContentType: "text", Body: "{{.DeliveryAddress}}"}}
+	svc := newSvcWithMock(repo)
+	pr := pr_db.PatronRequest{ID: "pr-1", RequesterReqID: pgtype.Text{String: "REQ-1", Valid: true}}
+	_, err := svc.GeneratePdfPullSlipForPrs(appCtx, []pr_db.PatronRequest{pr},
+		PullSlipTemplate{Document: DocumentLabel, Owner: "ISIL:OWNER", Label: "parcel"})
+	assert.NoError(t, err)
+	assert.Equal(t, "label", repo.gotTemplate.Purpose)
+	assert.Equal(t, "parcel", repo.gotTemplate.Label)
+}
+
+func TestGeneratePdfPullSlipForPrs_StoredTemplateNotFound(t *testing.T) {
+	repo := &mockPrRepo{templateErr: pgx.ErrNoRows}
+	svc := newSvcWithMock(repo)
+	pr := pr_db.PatronRequest{ID: "pr-1", RequesterReqID: pgtype.Text{String: "REQ-1", Valid: true}}
+	pdfBytes, err := svc.GeneratePdfPullSlipForPrs(appCtx, []pr_db.PatronRequest{pr}, PullSlipTemplate{Owner: "ISIL:OWNER", Label: "missing"})
+	assert.NoError(t, err)
+	assert.True(t, len(pdfBytes) > 0)
+}
+
+func TestGeneratePdfPullSlipForPrs_StoredTemplateError(t *testing.T) {
+	repo := &mockPrRepo{templateErr: errors.New("template db error")}
+	svc := newSvcWithMock(repo)
+	pr := pr_db.PatronRequest{ID: "pr-1", RequesterReqID: pgtype.Text{String: "REQ-1", Valid: true}}
+	_, err := svc.GeneratePdfPullSlipForPrs(appCtx, []pr_db.PatronRequest{pr}, PullSlipTemplate{Owner: "ISIL:OWNER", Label: "shelf-slip"})
+	assert.EqualError(t, err, "template db error")
+}
+
 func TestGeneratePdfPullSlipForPrs_NoteError(t *testing.T) {
 	repo := &mockPrRepo{noteErr: errors.New("note db error")}
 	svc := newSvcWithMock(repo)
 	pr := pr_db.PatronRequest{ID: "pr-1", RequesterReqID: pgtype.Text{String: "REQ-1", Valid: true}}
-	_, err := svc.GeneratePdfPullSlipForPrs(appCtx, []pr_db.PatronRequest{pr})
+	_, err := svc.GeneratePdfPullSlipForPrs(appCtx, []pr_db.PatronRequest{pr}, PullSlipTemplate{})
 	assert.Error(t, err)
 }
 
@@ -455,7 +569,7 @@ func TestGeneratePdfPullSlipForPrs_ConditionError(t *testing.T) {
 	repo := &mockPrRepo{condErr: errors.New("condition db error")}
 	svc := newSvcWithMock(repo)
 	pr := pr_db.PatronRequest{ID: "pr-1", RequesterReqID: pgtype.Text{String: "REQ-1", Valid: true}}
-	_, err := svc.GeneratePdfPullSlipForPrs(appCtx, []pr_db.PatronRequest{pr})
+	_, err := svc.GeneratePdfPullSlipForPrs(appCtx, []pr_db.PatronRequest{pr}, PullSlipTemplate{})
 	assert.Error(t, err)
 }
 
@@ -472,7 +586,36 @@ func TestGeneratePdfPullSlip_ServiceInfoEmptyServiceLevel(t *testing.T) {
 			},
 		},
 	}
-	pdfBytes, err := svc.GeneratePdfPullSlip(pr, nil, nil)
+	pdfBytes, err := svc.GeneratePdfPullSlip(pr, nil, nil, DocumentPullSlip, nil)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, pdfBytes)
 }
+
+// ── GenerateZplPullSlipForPrs ─────────────────────────────────────────────────
+
+func TestGenerateZplPullSlipForPrs_Multiple(t *testing.T) {
+	repo := &mockPrRepo{notes: []pr_db.Notification{{Note: pgtype.Text{String: "Fragile", Valid: true}}}}
+	svc := newSvcWithMock(repo)
+	prs := []pr_db.PatronRequest{
+		{ID: "pr-1", RequesterReqID: pgtype.Text{String: "REQ-1", Valid: true}},
+		{ID: "pr-2", RequesterReqID: pgtype.Text{String: "REQ-2", Valid: true}},
+	}
+	zpl, err := svc.GenerateZplPullSlipForPrs(appCtx, prs, DocumentPullSlip)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(zpl), "^XA"))
+	assert.Contains(t, string(zpl), "^FDREQ-1^FS")
+	assert.Contains(t, string(zpl), "^FDREQ-2^FS")
+	assert.Contains(t, string(zpl), "Fragile")
+}
+
+func TestGenerateZplPullSlipForPrs_BookBand(t *testing.T) {
+	svc := newSvcWithMock(&mockPrRepo{})
+	_, err := svc.GenerateZplPullSlipForPrs(appCtx, []pr_db.PatronRequest{{ID: "pr-1"}}, DocumentBookBand)
+	assert.EqualError(t, err, `no ZPL layout for document "bookband"`)
+}
+
+func TestGenerateZplPullSlipForPrs_NotesError(t *testing.T) {
+	svc := newSvcWithMock(&mockPrRepo{noteErr: errors.New("db error")})
+	_, err := svc.GenerateZplPullSlipForPrs(appCtx, []pr_db.PatronRequest{{ID: "pr-1"}}, DocumentLabel)
+	assert.EqualError(t, err, "db error")
+}
@@ -0,0 +1,142 @@
+package psservice
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	prtemplate "github.com/indexdata/crosslink/broker/patron_request/template"
+)
+
+// ZplKind is the way a ZplElement prints its field.
+type ZplKind string
+
+const (
+	// ZplText prints a single line of text
+	ZplText ZplKind = "text"
+	// ZplBlock wraps text within Width over at most Lines lines, a line break in the value starts a new line
+	ZplBlock ZplKind = "block"
+	// ZplBarcode prints a Code 128 barcode with the value below it
+	ZplBarcode ZplKind = "barcode"
+	// ZplQrCode prints a QR code, magnified Height times
+	ZplQrCode ZplKind = "qrcode"
+)
+
+// ZplElement prints Label followed by the value of Field, a field of prtemplate.PullSlip, at X,Y.
+// Height is the font or barcode height. An element without Field prints only Label.
+// Positions and sizes are in dots.
+type ZplElement struct {
+	Kind   ZplKind
+	X      int
+	Y      int
+	Height int
+	Width  int
+	Lines  int
+	Label  string
+	Field  string
+}
+
+// ZplLayout places pull slip data on a label of Width x Length dots.
+type ZplLayout struct {
+	Width    int
+	Length   int
+	Elements []ZplElement
+}
+
+// Layouts of 4x6 inch labels printed at 203 dpi.
+var (
+	PullSlipZplLayout = ZplLayout{
+		Width:  812,
+		Length: 1218,
+		Elements: []ZplElement{
+			{Kind: ZplText, X: 30, Y: 30, Height: 40, Label: "PULL SLIP"},
+			{Kind: ZplBarcode, X: 30, Y: 90, Height: 100, Field: "ReqId"},
+			{Kind: ZplBlock, X: 30, Y: 240, Height: 28, Width: 750, Lines: 2, Label: "Pickup location: ", Field: "PickupLocation"},
+			{Kind: ZplBlock, X: 30, Y: 320, Height: 28, Width: 750, Lines: 2, Label: "Title: ", Field: "Title"},
+			{Kind: ZplText, X: 30, Y: 390, Height: 24, Label: "Author: ", Field: "Author"},
+			{Kind: ZplText, X: 30, Y: 425, Height: 24, Label: "Publisher: ", Field: "Publisher"},
+			{Kind: ZplText, X: 30, Y: 460, Height: 24, Label: "Volume(s): ", Field: "Volume"},
+			{Kind: ZplText, X: 420, Y: 460, Height: 24, Label: "Issue: ", Field: "Issue"},
+			{Kind: ZplText, X: 30, Y: 495, Height: 24, Label: "Pages: ", Field: "Pages"},
+			{Kind: ZplText, X: 30, Y: 530, Height: 24, Label: "Service type: ", Field: "ServiceType"},
+			{Kind: ZplText, X: 420, Y: 530, Height: 24, Label: "Level: ", Field: "ServiceLevel"},
+			{Kind: ZplText, X: 30, Y: 565, Height: 24, Label: "System identifier: ", Field: "SystemIdentifier"},
+			{Kind: ZplBlock, X: 30, Y: 620, Height: 24, Width: 750, Lines: 2, Label: "Call number: ", Field: "CallNumber"},
+			{Kind: ZplBlock, X: 30, Y: 690, Height: 24, Width: 750, Lines: 4, Label: "Staff notes: ", Field: "StaffNotes"},
+			{Kind: ZplText, X: 30, Y: 820, Height: 32, Label: "Due date: ", Field: "DueDate"},
+			{Kind: ZplBlock, X: 30, Y: 870, Height: 24, Width: 750, Lines: 3, Label: "Loan conditions: ", Field: "LoanConditions"},
+			{Kind: ZplBlock, X: 30, Y: 970, Height: 24, Width: 750, Lines: 3, Label: "Return address: ", Field: "ReturnAddress"},
+			{Kind: ZplText, X: 30, Y: 1120, Height: 32, Label: "DO NOT REMOVE THIS SLIP"},
+		},
+	}
+	LabelZplLayout = ZplLayout{
+		Width:  812,
+		Length: 1218,
+		Elements: []ZplElement{
+			{Kind: ZplText, X: 30, Y: 30, Height: 28, Label: "SHIP TO"},
+			{Kind: ZplBlock, X: 30, Y: 70, Height: 45, Width: 750, Lines: 5, Field: "DeliveryAddress"},
+			{Kind: ZplText, X: 30, Y: 330, Height: 24, Label: "FROM"},
+			{Kind: ZplBlock, X: 30, Y: 360, Height: 28, Width: 750, Lines: 3, Field: "ReturnAddress"},
+			{Kind: ZplBarcode, X: 30, Y: 500, Height: 120, Field: "ReqId"},
+			{Kind: ZplQrCode, X: 560, Y: 480, Height: 6, Field: "ReqId"},
+			{Kind: ZplBlock, X: 30, Y: 720, Height: 28, Width: 750, Lines: 2, Label: "Title: ", Field: "Title"},
+			{Kind: ZplText, X: 30, Y: 800, Height: 24, Label: "Service type: ", Field: "ServiceType"},
+			{Kind: ZplText, X: 420, Y: 800, Height: 24, Label: "Level: ", Field: "ServiceLevel"},
+		},
+	}
+)
+
+// zplLayout returns the ZPL layout of the document, book bands are not printed on thermal printers.
+func (d Document) zplLayout() (ZplLayout, error) {
+	switch d {
+	case "", DocumentPullSlip:
+		return PullSlipZplLayout, nil
+	case DocumentLabel:
+		return LabelZplLayout, nil
+	}
+	return ZplLayout{}, fmt.Errorf("no ZPL layout for document %q", d)
+}
+
+// HasZpl reports whether the document can be printed as ZPL.
+func (d Document) HasZpl() bool {
+	_, err := d.zplLayout()
+	return err == nil
+}
+
+// Render writes one label with the fields of slip, in UTF-8.
+func (l ZplLayout) Render(sb *strings.Builder, slip prtemplate.PullSlip) error {
+	sb.WriteString("^XA\n^CI28\n")
+	fmt.Fprintf(sb, "^PW%d\n^LL%d\n", l.Width, l.Length)
+	for _, e := range l.Elements {
+		value := e.Label
+		if e.Field != "" {
+			field := reflect.ValueOf(slip).FieldByName(e.Field)
+			if !field.IsValid() || field.Kind() != reflect.String {
+				return fmt.Errorf("unknown pull slip field %q in ZPL layout", e.Field)
+			}
+			value += field.String()
+		}
+		fmt.Fprintf(sb, "^FO%d,%d", e.X, e.Y)
+		switch e.Kind {
+		case ZplText:
+			fmt.Fprintf(sb, "^A0N,%d,%d^FH^FD%s^FS\n", e.Height, e.Height, zplEscape(strings.ReplaceAll(value, "\n", " ")))
+		case ZplBlock:
+			text := strings.ReplaceAll(zplEscape(value), "\\", "\\\\")
+			text = strings.ReplaceAll(text, "\n", "\\&")
+			fmt.Fprintf(sb, "^A0N,%d,%d^FB%d,%d,0,L^FH^FD%s^FS\n", e.Height, e.Height, e.Width, e.Lines, text)
+		case ZplBarcode:
+			fmt.Fprintf(sb, "^BY2^BCN,%d,Y,N,N^FH^FD%s^FS\n", e.Height, zplEscape(value))
+		case ZplQrCode:
+			fmt.Fprintf(sb, "^BQN,2,%d^FH^FDQA,%s^FS\n", e.Height, zplEscape(value))
+		default:
+			return fmt.Errorf("unknown ZPL element kind %q", e.Kind)
+		}
+	}
+	sb.WriteString("^XZ\n")
+	return nil
+}
+
+// zplEscape hex encodes the characters starting commands so field data is printed as is, ^FH decodes them.
+func zplEscape(s string) string {
+	return strings.NewReplacer("_", "_5F", "^", "_5E", "~", "_7E").Replace(s)
+}
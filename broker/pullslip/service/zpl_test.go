@@ -0,0 +1,77 @@
+package psservice
+
+import (
+	"strings"
+	"testing"
+
+	prtemplate "github.com/indexdata/crosslink/broker/patron_request/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZplLayoutRender(t *testing.T) {
+	layout := ZplLayout{
+		Width:  812,
+		Length: 1218,
+		Elements: []ZplElement{
+			{Kind: ZplText, X: 10, Y: 20, Height: 30, Label: "Title: ", Field: "Title"},
+			{Kind: ZplBlock, X: 10, Y: 60, Height: 24, Width: 700, Lines: 3, Field: "DeliveryAddress"},
+			{Kind: ZplBarcode, X: 10, Y: 200, Height: 100, Field: "ReqId"},
+			{Kind: ZplQrCode, X: 500, Y: 200, Height: 5, Field: "ReqId"},
+		},
+	}
+	var sb strings.Builder
+	err := layout.Render(&sb, prtemplate.PullSlip{
+		Title:           "Big_Shark ^1~",
+		DeliveryAddress: "Main Library\nC:\\Shelf",
+		ReqId:           "REQ-1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "^XA\n^CI28\n^PW812\n^LL1218\n"+
+		"^FO10,20^A0N,30,30^FH^FDTitle: Big_5FShark _5E1_7E^FS\n"+
+		"^FO10,60^A0N,24,24^FB700,3,0,L^FH^FDMain Library\\&C:\\\\Shelf^FS\n"+
+		"^FO10,200^BY2^BCN,100,Y,N,N^FH^FDREQ-1^FS\n"+
+		"^FO500,200^BQN,2,5^FH^FDQA,REQ-1^FS\n"+
+		"^XZ\n", sb.String())
+}
+
+func TestZplLayoutRenderTextLineBreak(t *testing.T) {
+	layout := ZplLayout{Elements: []ZplElement{{Kind: ZplText, Height: 20, Field: "ReturnAddress"}}}
+	var sb strings.Builder
+	err := layout.Render(&sb, prtemplate.PullSlip{ReturnAddress: "1 Road\nTown"})
+	assert.NoError(t, err)
+	assert.Contains(t, sb.String(), "^FD1 Road Town^FS")
+}
+
+func TestZplLayoutRenderUnknownField(t *testing.T) {
+	layout := ZplLayout{Elements: []ZplElement{{Kind: ZplText, Field: "Subtitle"}}}
+	var sb strings.Builder
+	err := layout.Render(&sb, prtemplate.PullSlip{})
+	assert.EqualError(t, err, `unknown pull slip field "Subtitle" in ZPL layout`)
+}
+
+func TestZplLayoutRenderUnknownKind(t *testing.T) {
+	layout := ZplLayout{Elements: []ZplElement{{Kind: "circle", Label: "x"}}}
+	var sb strings.Builder
+	err := layout.Render(&sb, prtemplate.PullSlip{})
+	assert.EqualError(t, err, `unknown ZPL element kind "circle"`)
+}
+
+func TestZplLayouts(t *testing.T) {
+	for _, doc := range []Document{DocumentPullSlip, DocumentLabel} {
+		layout, err := doc.zplLayout()
+		assert.NoError(t, err)
+		assert.True(t, doc.HasZpl())
+		var sb strings.Builder
+		assert.NoError(t, layout.Render(&sb, prtemplate.PullSlip{ReqId: "REQ-1"}))
+		assert.True(t, strings.HasPrefix(sb.String(), "^XA"))
+		assert.True(t, strings.HasSuffix(sb.String(), "^XZ\n"))
+	}
+	_, err := DocumentBookBand.zplLayout()
+	assert.EqualError(t, err, `no ZPL layout for document "bookband"`)
+	assert.False(t, DocumentBookBand.HasZpl())
+}
+
+func TestDocumentZplFilename(t *testing.T) {
+	assert.Equal(t, "pull-slips.zpl", DocumentPullSlip.ZplFilename())
+	assert.Equal(t, "shipping-labels.zpl", DocumentLabel.ZplFilename())
+}
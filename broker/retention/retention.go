@@ -0,0 +1,442 @@
+package retention
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/events"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const COMP = "event_retention"
+
+const (
+	DEFAULT_INTERVAL     = 24 * time.Hour
+	DEFAULT_MONTHS_AHEAD = 3
+	DELETE_BATCH_SIZE    = 1000
+	EXPORT_BATCH_SIZE    = 1000
+)
+
+// Rule removes the events of a domain and status that are older than MaxAge.
+// An empty Domain or Status matches all domains or statuses.
+type Rule struct {
+	Domain events.EventDomain
+	Status events.EventStatus
+	MaxAge time.Duration
+}
+
+func (r Rule) String() string {
+	domain := string(r.Domain)
+	if domain == "" {
+		domain = "*"
+	}
+	if r.Status != "" {
+		domain += ":" + string(r.Status)
+	}
+	return domain + "=" + r.MaxAge.String()
+}
+
+var domains = []events.EventDomain{events.EventDomainPatronRequest, events.EventDomainIllTransaction, events.EventDomainScheduler}
+
+var statuses = []events.EventStatus{events.EventStatusNew, events.EventStatusProcessing, events.EventStatusSuccess,
+	events.EventStatusProblem, events.EventStatusError}
+
+// ParseRules parses a comma separated list of DOMAIN[:STATUS]=AGE rules, for example
+// "SCHEDULER=30d,ILL_TRANSACTION:SUCCESS=180d". DOMAIN may be * for all domains. Rules must keep
+// events for less than maxAge, the retention of all events, unless maxAge is zero.
+func ParseRules(value string, maxAge time.Duration) ([]Rule, error) {
+	var rules []Rule
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		selector, age, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid retention rule %q, expected DOMAIN[:STATUS]=AGE", item)
+		}
+		var rule Rule
+		domain, status, _ := strings.Cut(selector, ":")
+		if domain != "*" {
+			rule.Domain = events.EventDomain(domain)
+			if !contains(domains, rule.Domain) {
+				return nil, fmt.Errorf("invalid retention rule %q, unknown domain %s", item, domain)
+			}
+		}
+		if status != "" {
+			rule.Status = events.EventStatus(status)
+			if !contains(statuses, rule.Status) {
+				return nil, fmt.Errorf("invalid retention rule %q, unknown status %s", item, status)
+			}
+		}
+		d, err := common.ParseDurationWithDays(age)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid retention rule %q, invalid age %s", item, age)
+		}
+		if maxAge > 0 && d >= maxAge {
+			return nil, fmt.Errorf("invalid retention rule %q, events are kept for %s at most", item, maxAge)
+		}
+		rule.MaxAge = d
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func contains[T comparable](list []T, value T) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// EventRetention keeps monthly partitions of the event table ahead of time and removes expired events.
+// Partitions ending more than MaxAge ago are dropped and events matching a rule are deleted once
+// older than the rule allows. If ExportDir is set, the removed events are written to JSON Lines
+// files in it first, one file per dropped partition and one per run for deleted events.
+type EventRetention struct {
+	repo events.EventRepo
+	// MaxAge is how long events are kept, zero keeps them forever
+	MaxAge      time.Duration
+	Rules       []Rule
+	ExportDir   string
+	MonthsAhead int
+	Interval    time.Duration
+}
+
+func NewEventRetention(repo events.EventRepo) *EventRetention {
+	return &EventRetention{
+		repo:        repo,
+		MonthsAhead: DEFAULT_MONTHS_AHEAD,
+		Interval:    DEFAULT_INTERVAL,
+	}
+}
+
+// Run maintains the event table right away and then every Interval until ctx is done
+func (r *EventRetention) Run(ctx common.ExtendedContext) {
+	ctx = ctx.WithArgs(ctx.LoggerArgs().WithComponent(COMP))
+	r.maintainAndLog(ctx)
+	if r.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.maintainAndLog(ctx)
+		}
+	}
+}
+
+func (r *EventRetention) maintainAndLog(ctx common.ExtendedContext) {
+	err := r.Maintain(ctx)
+	if err != nil {
+		ctx.Logger().Error("failed to maintain event table", "error", err)
+	}
+}
+
+// Maintain creates the partitions for the coming months and removes the expired events. Each step
+// runs in its own transaction, dropping one partition per transaction. Only one instance maintains
+// the table at a time, the others return without doing anything.
+func (r *EventRetention) Maintain(ctx common.ExtendedContext) error {
+	now := time.Now()
+	locked, err := r.maintainInTx(ctx, func(repo events.EventRepo) ([]*exportFile, error) {
+		return nil, r.createPartitions(ctx, repo, now)
+	})
+	if err != nil || !locked {
+		return err
+	}
+	locked, err = r.maintainInTx(ctx, func(repo events.EventRepo) ([]*exportFile, error) {
+		return r.deleteExpiredEvents(ctx, repo, now)
+	})
+	if err != nil || !locked {
+		return err
+	}
+	partitions, err := r.expiredPartitions(ctx, now)
+	if err != nil {
+		return err
+	}
+	for _, partition := range partitions {
+		locked, err = r.maintainInTx(ctx, func(repo events.EventRepo) ([]*exportFile, error) {
+			export, err := r.dropPartition(ctx, repo, partition)
+			return []*exportFile{export}, err
+		})
+		if err != nil || !locked {
+			return err
+		}
+	}
+	return nil
+}
+
+// maintainInTx runs f in a transaction holding the maintenance lock, it returns false if another
+// instance holds the lock. The exports of f are saved if the transaction is committed.
+func (r *EventRetention) maintainInTx(ctx common.ExtendedContext, f func(repo events.EventRepo) ([]*exportFile, error)) (bool, error) {
+	locked := false
+	var exports []*exportFile
+	err := r.repo.WithTxFunc(ctx, func(repo events.EventRepo) error {
+		var err error
+		locked, err = repo.TryEventMaintenanceLock(ctx)
+		if err != nil {
+			return err
+		}
+		if !locked {
+			ctx.Logger().Debug("event table maintained by another instance")
+			return nil
+		}
+		exports, err = f(repo)
+		return err
+	})
+	for _, export := range exports {
+		if err != nil {
+			export.discard()
+		} else if commitErr := export.commit(); commitErr != nil {
+			ctx.Logger().Error("failed to save event export", "error", commitErr, "file", export.tmpPath)
+		}
+	}
+	return locked, err
+}
+
+// storedTime returns t as stored in the event table, timestamps are saved in local time without a zone
+func storedTime(t time.Time) pgtype.Timestamp {
+	t = t.In(time.Local)
+	return pgtype.Timestamp{Time: time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC), Valid: true}
+}
+
+func (r *EventRetention) createPartitions(ctx common.ExtendedContext, repo events.EventRepo, now time.Time) error {
+	month := storedTime(now).Time
+	month = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i <= r.MonthsAhead; i++ {
+		_, err := repo.CreateEventPartition(ctx, pgtype.Timestamp{Time: month.AddDate(0, i, 0), Valid: true})
+		if err != nil {
+			return fmt.Errorf("failed to create event partition: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *EventRetention) deleteExpiredEvents(ctx common.ExtendedContext, repo events.EventRepo, now time.Time) ([]*exportFile, error) {
+	if len(r.Rules) == 0 {
+		return nil, nil
+	}
+	export, err := r.createExport("event_deleted_" + now.Format("20060102T150405"))
+	if err != nil {
+		return nil, err
+	}
+	exports := []*exportFile{export}
+	for _, rule := range r.Rules {
+		params := events.DeleteExpiredEventsParams{
+			Before:      storedTime(now.Add(-rule.MaxAge)),
+			Domain:      pgtype.Text{String: string(rule.Domain), Valid: rule.Domain != ""},
+			EventStatus: pgtype.Text{String: string(rule.Status), Valid: rule.Status != ""},
+			MaxRows:     DELETE_BATCH_SIZE,
+		}
+		deleted := 0
+		for {
+			eventList, err := repo.DeleteExpiredEvents(ctx, params)
+			if err != nil {
+				return exports, fmt.Errorf("failed to delete expired events: %w", err)
+			}
+			err = export.write(eventList)
+			if err != nil {
+				return exports, err
+			}
+			deleted += len(eventList)
+			if len(eventList) < DELETE_BATCH_SIZE {
+				break
+			}
+		}
+		if deleted > 0 {
+			ctx.Logger().Info("deleted expired events", "rule", rule.String(), "count", deleted)
+		}
+	}
+	return exports, export.close()
+}
+
+// expiredPartitions lists the partitions ending more than MaxAge ago
+func (r *EventRetention) expiredPartitions(ctx common.ExtendedContext, now time.Time) ([]events.ListEventPartitionsRow, error) {
+	if r.MaxAge <= 0 {
+		return nil, nil
+	}
+	partitions, err := r.repo.ListEventPartitions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list event partitions: %w", err)
+	}
+	cutoff := storedTime(now.Add(-r.MaxAge)).Time
+	var expired []events.ListEventPartitionsRow
+	for _, partition := range partitions {
+		if !partition.RangeEnd.Time.After(cutoff) {
+			expired = append(expired, partition)
+		}
+	}
+	return expired, nil
+}
+
+func (r *EventRetention) dropPartition(ctx common.ExtendedContext, repo events.EventRepo, partition events.ListEventPartitionsRow) (*exportFile, error) {
+	export, err := r.createExport(partition.PartitionName)
+	if err != nil {
+		return nil, err
+	}
+	count, err := r.exportPartition(ctx, repo, partition, export)
+	if err != nil {
+		return export, err
+	}
+	err = repo.DropEventPartition(ctx, partition.PartitionName)
+	if err != nil {
+		return export, fmt.Errorf("failed to drop event partition %s: %w", partition.PartitionName, err)
+	}
+	ctx.Logger().Info("dropped expired event partition", "partition", partition.PartitionName, "exported", count)
+	return export, nil
+}
+
+func (r *EventRetention) exportPartition(ctx common.ExtendedContext, repo events.EventRepo, partition events.ListEventPartitionsRow, export *exportFile) (int, error) {
+	params := events.ListEventsBetweenParams{
+		FromTime:       partition.RangeStart,
+		ToTime:         partition.RangeEnd,
+		AfterTimestamp: partition.RangeStart,
+		MaxRows:        EXPORT_BATCH_SIZE,
+	}
+	count := 0
+	if export == nil {
+		return count, nil
+	}
+	for {
+		eventList, err := repo.ListEventsBetween(ctx, params)
+		if err != nil {
+			return count, fmt.Errorf("failed to read event partition %s: %w", partition.PartitionName, err)
+		}
+		err = export.write(eventList)
+		if err != nil {
+			return count, err
+		}
+		count += len(eventList)
+		if len(eventList) < EXPORT_BATCH_SIZE {
+			break
+		}
+		last := eventList[len(eventList)-1]
+		params.AfterTimestamp = last.Timestamp
+		params.AfterID = last.ID
+	}
+	return count, export.close()
+}
+
+// ExportedEvent is the JSON Lines representation of an event, it uses the column names of the event table
+type ExportedEvent struct {
+	ID               string             `json:"id"`
+	Timestamp        time.Time          `json:"timestamp"`
+	IllTransactionID string             `json:"ill_transaction_id"`
+	ParentID         *string            `json:"parent_id"`
+	EventType        events.EventType   `json:"event_type"`
+	EventName        events.EventName   `json:"event_name"`
+	EventStatus      events.EventStatus `json:"event_status"`
+	EventData        events.EventData   `json:"event_data"`
+	ResultData       events.EventResult `json:"result_data"`
+	LastSignal       string             `json:"last_signal"`
+	PatronRequestID  string             `json:"patron_request_id"`
+	TraceParent      string             `json:"trace_parent"`
+}
+
+func toExportedEvent(event events.Event) ExportedEvent {
+	exported := ExportedEvent{
+		ID:               event.ID,
+		Timestamp:        event.Timestamp.Time,
+		IllTransactionID: event.IllTransactionID,
+		EventType:        event.EventType,
+		EventName:        event.EventName,
+		EventStatus:      event.EventStatus,
+		EventData:        event.EventData,
+		ResultData:       event.ResultData,
+		LastSignal:       event.LastSignal,
+		PatronRequestID:  event.PatronRequestID,
+		TraceParent:      event.TraceParent,
+	}
+	if event.ParentID.Valid {
+		exported.ParentID = &event.ParentID.String
+	}
+	return exported
+}
+
+// exportFile is written under a temporary name and renamed once the removal of its events is committed.
+// A nil exportFile ignores all calls, it is used when no export directory is configured.
+type exportFile struct {
+	path    string
+	tmpPath string
+	file    *os.File
+	writer  *bufio.Writer
+	encoder *json.Encoder
+	count   int
+}
+
+func (r *EventRetention) createExport(name string) (*exportFile, error) {
+	if r.ExportDir == "" {
+		return nil, nil
+	}
+	path := filepath.Join(r.ExportDir, name+".jsonl")
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event export: %w", err)
+	}
+	writer := bufio.NewWriter(file)
+	return &exportFile{path: path, tmpPath: file.Name(), file: file, writer: writer, encoder: json.NewEncoder(writer)}, nil
+}
+
+func (f *exportFile) write(eventList []events.Event) error {
+	if f == nil {
+		return nil
+	}
+	for _, event := range eventList {
+		err := f.encoder.Encode(toExportedEvent(event))
+		if err != nil {
+			return fmt.Errorf("failed to write event export: %w", err)
+		}
+		f.count++
+	}
+	return nil
+}
+
+func (f *exportFile) close() error {
+	if f == nil || f.file == nil {
+		return nil
+	}
+	file := f.file
+	f.file = nil
+	err := errors.Join(f.writer.Flush(), file.Sync(), file.Close())
+	if err != nil {
+		return fmt.Errorf("failed to write event export: %w", err)
+	}
+	return nil
+}
+
+// commit renames the export to its final name, an export without events is removed
+func (f *exportFile) commit() error {
+	if f == nil {
+		return nil
+	}
+	if f.count == 0 {
+		f.discard()
+		return nil
+	}
+	err := f.close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.tmpPath, f.path)
+}
+
+func (f *exportFile) discard() {
+	if f == nil {
+		return
+	}
+	_ = f.close()
+	_ = os.Remove(f.tmpPath)
+}
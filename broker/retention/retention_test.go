@@ -0,0 +1,277 @@
+package retention
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/events"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+type retentionRepo struct {
+	events.EventRepo
+	locked     bool
+	created    []time.Time
+	partitions []events.ListEventPartitionsRow
+	stored     map[string][]events.Event
+	dropped    []string
+	deleted    []events.DeleteExpiredEventsParams
+	expired    []events.Event
+	dropErr    map[string]error
+	txs        int
+}
+
+func (r *retentionRepo) WithTxFunc(ctx common.ExtendedContext, fn func(events.EventRepo) error) error {
+	r.txs++
+	return fn(r)
+}
+
+func (r *retentionRepo) TryEventMaintenanceLock(ctx common.ExtendedContext) (bool, error) {
+	return r.locked, nil
+}
+
+func (r *retentionRepo) CreateEventPartition(ctx common.ExtendedContext, month pgtype.Timestamp) (string, error) {
+	r.created = append(r.created, month.Time)
+	return "event_p" + month.Time.Format("200601"), nil
+}
+
+func (r *retentionRepo) ListEventPartitions(ctx common.ExtendedContext) ([]events.ListEventPartitionsRow, error) {
+	return r.partitions, nil
+}
+
+func (r *retentionRepo) ListEventsBetween(ctx common.ExtendedContext, params events.ListEventsBetweenParams) ([]events.Event, error) {
+	var eventList []events.Event
+	for _, partition := range r.partitions {
+		if partition.RangeStart == params.FromTime && params.AfterID == "" {
+			eventList = r.stored[partition.PartitionName]
+		}
+	}
+	return eventList, nil
+}
+
+func (r *retentionRepo) DropEventPartition(ctx common.ExtendedContext, name string) error {
+	r.dropped = append(r.dropped, name)
+	return r.dropErr[name]
+}
+
+func (r *retentionRepo) DeleteExpiredEvents(ctx common.ExtendedContext, params events.DeleteExpiredEventsParams) ([]events.Event, error) {
+	r.deleted = append(r.deleted, params)
+	expired := r.expired
+	r.expired = nil
+	return expired, nil
+}
+
+func monthPartition(start time.Time) events.ListEventPartitionsRow {
+	return events.ListEventPartitionsRow{
+		PartitionName: "event_p" + start.Format("200601"),
+		RangeStart:    pgtype.Timestamp{Time: start, Valid: true},
+		RangeEnd:      pgtype.Timestamp{Time: start.AddDate(0, 1, 0), Valid: true},
+	}
+}
+
+func readExport(t *testing.T, path string) []ExportedEvent {
+	file, err := os.Open(path)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	defer file.Close()
+	var exported []ExportedEvent
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event ExportedEvent
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		exported = append(exported, event)
+	}
+	return exported
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("SCHEDULER=30d, ILL_TRANSACTION:SUCCESS=4320h,*:ERROR=90d,", 365*24*time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, []Rule{
+		{Domain: events.EventDomainScheduler, MaxAge: 30 * 24 * time.Hour},
+		{Domain: events.EventDomainIllTransaction, Status: events.EventStatusSuccess, MaxAge: 4320 * time.Hour},
+		{Status: events.EventStatusError, MaxAge: 90 * 24 * time.Hour},
+	}, rules)
+	assert.Equal(t, "ILL_TRANSACTION:SUCCESS=4320h0m0s", rules[1].String())
+	assert.Equal(t, "*:ERROR=2160h0m0s", rules[2].String())
+
+	rules, err = ParseRules("", 0)
+	assert.NoError(t, err)
+	assert.Empty(t, rules)
+
+	for value, msg := range map[string]string{
+		"SCHEDULER":          "expected DOMAIN[:STATUS]=AGE",
+		"BOGUS=30d":          "unknown domain BOGUS",
+		"SCHEDULER:DONE=30d": "unknown status DONE",
+		"SCHEDULER=soon":     "invalid age soon",
+		"SCHEDULER=-1h":      "invalid age -1h",
+		"SCHEDULER=400d":     "events are kept for 8760h0m0s at most",
+	} {
+		_, err = ParseRules(value, 365*24*time.Hour)
+		assert.ErrorContains(t, err, msg, value)
+	}
+}
+
+func TestMaintainCreatesPartitionsAhead(t *testing.T) {
+	repo := &retentionRepo{locked: true}
+	retention := NewEventRetention(repo)
+	retention.MonthsAhead = 2
+	err := retention.Maintain(common.CreateExtCtxWithArgs(context.Background(), nil))
+	assert.NoError(t, err)
+	if assert.Len(t, repo.created, 3) {
+		now := time.Now()
+		assert.Equal(t, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC), repo.created[0])
+		assert.Equal(t, repo.created[0].AddDate(0, 2, 0), repo.created[2])
+	}
+	assert.Empty(t, repo.deleted)
+	assert.Empty(t, repo.dropped)
+}
+
+func TestMaintainSkipsWhenLockedByOtherInstance(t *testing.T) {
+	repo := &retentionRepo{locked: false}
+	retention := NewEventRetention(repo)
+	retention.MaxAge = time.Hour
+	err := retention.Maintain(common.CreateExtCtxWithArgs(context.Background(), nil))
+	assert.NoError(t, err)
+	assert.Empty(t, repo.created)
+}
+
+func TestMaintainExportsAndDropsExpiredPartitions(t *testing.T) {
+	now := time.Now().UTC()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	old := monthPartition(thisMonth.AddDate(-2, 0, 0))
+	recent := monthPartition(thisMonth.AddDate(0, -1, 0))
+	repo := &retentionRepo{
+		locked:     true,
+		partitions: []events.ListEventPartitionsRow{old, recent},
+		stored: map[string][]events.Event{
+			old.PartitionName: {{
+				ID:          "e1",
+				Timestamp:   pgtype.Timestamp{Time: old.RangeStart.Time.Add(time.Hour), Valid: true},
+				ParentID:    pgtype.Text{String: "p1", Valid: true},
+				EventType:   events.EventTypeTask,
+				EventName:   events.EventNameMessageSupplier,
+				EventStatus: events.EventStatusSuccess,
+				EventData:   events.EventData{CommonEventData: events.CommonEventData{Note: "hello"}},
+			}},
+		},
+	}
+	dir := t.TempDir()
+	retention := NewEventRetention(repo)
+	retention.MaxAge = 365 * 24 * time.Hour
+	retention.ExportDir = dir
+	err := retention.Maintain(common.CreateExtCtxWithArgs(context.Background(), nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{old.PartitionName}, repo.dropped)
+
+	exported := readExport(t, filepath.Join(dir, old.PartitionName+".jsonl"))
+	if assert.Len(t, exported, 1) {
+		assert.Equal(t, "e1", exported[0].ID)
+		assert.Equal(t, "p1", *exported[0].ParentID)
+		assert.Equal(t, "hello", exported[0].EventData.Note)
+	}
+	files, _ := os.ReadDir(dir)
+	assert.Len(t, files, 1)
+}
+
+func TestMaintainDiscardsExportWhenDropFails(t *testing.T) {
+	old := monthPartition(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	repo := &retentionRepo{
+		locked:     true,
+		partitions: []events.ListEventPartitionsRow{old},
+		stored:     map[string][]events.Event{old.PartitionName: {{ID: "e1"}}},
+		dropErr:    map[string]error{old.PartitionName: errors.New("DB error")},
+	}
+	dir := t.TempDir()
+	retention := NewEventRetention(repo)
+	retention.MaxAge = 24 * time.Hour
+	retention.ExportDir = dir
+	err := retention.Maintain(common.CreateExtCtxWithArgs(context.Background(), nil))
+	assert.ErrorContains(t, err, "failed to drop event partition event_p202001: DB error")
+	files, _ := os.ReadDir(dir)
+	assert.Empty(t, files)
+}
+
+func TestMaintainDropsEachPartitionInOwnTransaction(t *testing.T) {
+	first := monthPartition(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	second := monthPartition(time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC))
+	repo := &retentionRepo{
+		locked:     true,
+		partitions: []events.ListEventPartitionsRow{first, second},
+		stored: map[string][]events.Event{
+			first.PartitionName:  {{ID: "e1"}},
+			second.PartitionName: {{ID: "e2"}},
+		},
+		dropErr: map[string]error{second.PartitionName: errors.New("DB error")},
+	}
+	dir := t.TempDir()
+	retention := NewEventRetention(repo)
+	retention.MaxAge = 24 * time.Hour
+	retention.ExportDir = dir
+	err := retention.Maintain(common.CreateExtCtxWithArgs(context.Background(), nil))
+	assert.ErrorContains(t, err, "failed to drop event partition event_p202002: DB error")
+	// partitions, rules and one transaction per partition
+	assert.Equal(t, 4, repo.txs)
+	assert.Equal(t, []string{first.PartitionName, second.PartitionName}, repo.dropped)
+	files, _ := os.ReadDir(dir)
+	if assert.Len(t, files, 1) {
+		assert.Equal(t, first.PartitionName+".jsonl", files[0].Name())
+	}
+}
+
+func TestStoredTime(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC+2", 2*60*60)
+	defer func() { time.Local = local }()
+
+	stored := storedTime(time.Date(2030, 1, 31, 23, 30, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2030, 2, 1, 1, 30, 0, 0, time.UTC), stored.Time)
+
+	repo := &retentionRepo{locked: true}
+	retention := NewEventRetention(repo)
+	retention.MonthsAhead = 0
+	assert.NoError(t, retention.createPartitions(common.CreateExtCtxWithArgs(context.Background(), nil), repo,
+		time.Date(2030, 1, 31, 23, 30, 0, 0, time.UTC)))
+	assert.Equal(t, []time.Time{time.Date(2030, 2, 1, 0, 0, 0, 0, time.UTC)}, repo.created)
+}
+
+func TestMaintainDeletesEventsByRule(t *testing.T) {
+	repo := &retentionRepo{
+		locked:  true,
+		expired: []events.Event{{ID: "e1", EventStatus: events.EventStatusSuccess}},
+	}
+	dir := t.TempDir()
+	retention := NewEventRetention(repo)
+	retention.Rules = []Rule{
+		{Domain: events.EventDomainScheduler, MaxAge: time.Hour},
+		{Status: events.EventStatusSuccess, MaxAge: 2 * time.Hour},
+	}
+	retention.ExportDir = dir
+	before := time.Now()
+	err := retention.Maintain(common.CreateExtCtxWithArgs(context.Background(), nil))
+	assert.NoError(t, err)
+	if assert.Len(t, repo.deleted, 2) {
+		assert.Equal(t, pgtype.Text{String: "SCHEDULER", Valid: true}, repo.deleted[0].Domain)
+		assert.False(t, repo.deleted[0].EventStatus.Valid)
+		assert.WithinDuration(t, before.Add(-time.Hour), repo.deleted[0].Before.Time, time.Minute)
+		assert.False(t, repo.deleted[1].Domain.Valid)
+		assert.Equal(t, pgtype.Text{String: "SUCCESS", Valid: true}, repo.deleted[1].EventStatus)
+	}
+	files, _ := os.ReadDir(dir)
+	if assert.Len(t, files, 1) {
+		assert.Regexp(t, `^event_deleted_\d{8}T\d{6}\.jsonl$`, files[0].Name())
+		exported := readExport(t, filepath.Join(dir, files[0].Name()))
+		if assert.Len(t, exported, 1) {
+			assert.Equal(t, "e1", exported[0].ID)
+		}
+	}
+}
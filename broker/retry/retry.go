@@ -0,0 +1,91 @@
+// Package retry sends stored messages in the background, e.g. webhook deliveries and outbox messages,
+// retrying failed attempts with a delay that doubles for every attempt made.
+package retry
+
+import (
+	"time"
+
+	"github.com/indexdata/crosslink/broker/common"
+)
+
+const MAX_ERROR_LENGTH = 1024
+
+// Outcome is what an attempt to send a message leads to.
+type Outcome string
+
+const (
+	OutcomeSent     Outcome = "sent"
+	OutcomeRetry    Outcome = "retry"
+	OutcomeFailed   Outcome = "failed"
+	OutcomeRejected Outcome = "rejected"
+)
+
+// Policy is how often and for how long a failed message is retried.
+type Policy struct {
+	MaxAttempts  int32
+	RetryBackoff time.Duration
+	MaxBackoff   time.Duration
+	PollInterval time.Duration
+}
+
+// Result is the outcome of an attempt, with the error to store on the message
+// and, if the message is retried, when the next attempt is due.
+type Result struct {
+	Outcome       Outcome
+	Error         string
+	NextAttemptAt time.Time
+}
+
+// Poll calls processDue every PollInterval until the context is done.
+func (p *Policy) Poll(ctx common.ExtendedContext, processDue func(ctx common.ExtendedContext)) {
+	if p.PollInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(p.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			processDue(ctx)
+		}
+	}
+}
+
+// Attempted returns the result of the attempts-th attempt, made at now and failed with sendErr unless it is nil.
+// A permanent failure is rejected without further attempts, other failures are retried until MaxAttempts is reached.
+func (p *Policy) Attempted(attempts int32, now time.Time, sendErr error, permanent bool) Result {
+	if sendErr == nil {
+		return Result{Outcome: OutcomeSent}
+	}
+	result := Result{Error: TruncateError(sendErr)}
+	switch {
+	case permanent:
+		result.Outcome = OutcomeRejected
+	case attempts >= p.MaxAttempts:
+		result.Outcome = OutcomeFailed
+	default:
+		result.Outcome = OutcomeRetry
+		result.NextAttemptAt = now.Add(p.Backoff(attempts))
+	}
+	return result
+}
+
+// Backoff doubles the retry delay for every attempt made, up to MaxBackoff.
+func (p *Policy) Backoff(attempts int32) time.Duration {
+	d := p.RetryBackoff
+	for i := int32(1); i < attempts && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxBackoff)
+}
+
+// TruncateError returns the error message cut to MAX_ERROR_LENGTH bytes for storing on the message.
+func TruncateError(err error) string {
+	s := err.Error()
+	if len(s) > MAX_ERROR_LENGTH {
+		return s[:MAX_ERROR_LENGTH]
+	}
+	return s
+}
@@ -0,0 +1,69 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/indexdata/crosslink/broker/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttempted(t *testing.T) {
+	policy := Policy{MaxAttempts: 3, RetryBackoff: time.Minute, MaxBackoff: time.Hour}
+	now := time.Now()
+	sendErr := errors.New("connection refused")
+
+	assert.Equal(t, Result{Outcome: OutcomeSent}, policy.Attempted(1, now, nil, false))
+	assert.Equal(t, Result{Outcome: OutcomeRetry, Error: "connection refused", NextAttemptAt: now.Add(time.Minute)},
+		policy.Attempted(1, now, sendErr, false))
+	assert.Equal(t, Result{Outcome: OutcomeRetry, Error: "connection refused", NextAttemptAt: now.Add(2 * time.Minute)},
+		policy.Attempted(2, now, sendErr, false))
+	assert.Equal(t, Result{Outcome: OutcomeFailed, Error: "connection refused"}, policy.Attempted(3, now, sendErr, false))
+	assert.Equal(t, Result{Outcome: OutcomeRejected, Error: "connection refused"}, policy.Attempted(1, now, sendErr, true))
+}
+
+func TestBackoff(t *testing.T) {
+	policy := Policy{RetryBackoff: time.Minute, MaxBackoff: time.Hour}
+	assert.Equal(t, time.Minute, policy.Backoff(1))
+	assert.Equal(t, 2*time.Minute, policy.Backoff(2))
+	assert.Equal(t, 32*time.Minute, policy.Backoff(6))
+	assert.Equal(t, time.Hour, policy.Backoff(7))
+	assert.Equal(t, time.Hour, policy.Backoff(100))
+}
+
+func TestTruncateError(t *testing.T) {
+	assert.Equal(t, "failed", TruncateError(errors.New("failed")))
+	assert.Len(t, TruncateError(errors.New(strings.Repeat("x", 2*MAX_ERROR_LENGTH))), MAX_ERROR_LENGTH)
+}
+
+func TestPoll(t *testing.T) {
+	goCtx, cancel := context.WithCancel(context.Background())
+	ctx := common.CreateExtCtxWithArgs(goCtx, nil)
+	var calls atomic.Int32
+	policy := Policy{PollInterval: time.Millisecond}
+	done := make(chan struct{})
+	go func() {
+		policy.Poll(ctx, func(ctx common.ExtendedContext) {
+			if calls.Add(1) == 3 {
+				cancel()
+			}
+		})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("poll did not stop when the context was done")
+	}
+	assert.GreaterOrEqual(t, calls.Load(), int32(3))
+
+	// polling is disabled without an interval
+	policy.PollInterval = 0
+	policy.Poll(common.CreateExtCtxWithArgs(context.Background(), nil), func(ctx common.ExtendedContext) {
+		t.Fatal("poll must not process without an interval")
+	})
+}
@@ -122,6 +122,14 @@ func (h SchedulerApiHandler) PostBatchActions(w http.ResponseWriter, r *http.Req
 		brokerapi.AddBadRequestError(ctx, w, errors.New("batchQuery must not be empty"))
 		return
 	}
+	misfirePolicy := sched_db.MisfirePolicyRunOnce
+	if create.MisfirePolicy != nil {
+		if !create.MisfirePolicy.Valid() {
+			brokerapi.AddBadRequestError(ctx, w, errors.New("unknown misfirePolicy: "+string(*create.MisfirePolicy)))
+			return
+		}
+		misfirePolicy = sched_db.MisfirePolicy(*create.MisfirePolicy)
+	}
 
 	owner, ok := h.resolveBatchActionOwner(ctx, w, r, params.Symbol)
 	if !ok {
@@ -156,9 +164,10 @@ func (h SchedulerApiHandler) PostBatchActions(w http.ResponseWriter, r *http.Req
 			},
 			CustomData: paramsMap,
 		},
-		Title:     toPgText(create.Title),
-		RunAt:     next,
-		CreatedAt: now,
+		Title:         toPgText(create.Title),
+		RunAt:         next,
+		CreatedAt:     now,
+		MisfirePolicy: misfirePolicy,
 	})
 	if err != nil {
 		brokerapi.AddInternalError(ctx, w, err)
@@ -277,6 +286,10 @@ func (h SchedulerApiHandler) PutBatchActionsId(w http.ResponseWriter, r *http.Re
 		brokerapi.AddBadRequestError(ctx, w, errors.New("batchQuery must not be empty"))
 		return
 	}
+	if update.MisfirePolicy != nil && !update.MisfirePolicy.Valid() {
+		brokerapi.AddBadRequestError(ctx, w, errors.New("unknown misfirePolicy: "+string(*update.MisfirePolicy)))
+		return
+	}
 	next, err := sched_service.NextScheduleTime(update.Schedule)
 	if err != nil {
 		brokerapi.AddBadRequestError(ctx, w, err)
@@ -290,6 +303,9 @@ func (h SchedulerApiHandler) PutBatchActionsId(w http.ResponseWriter, r *http.Re
 		if update.ActionParams != nil {
 			task.ActionData.CustomData = *update.ActionParams
 		}
+		if update.MisfirePolicy != nil {
+			task.MisfirePolicy = sched_db.MisfirePolicy(*update.MisfirePolicy)
+		}
 	})
 	if err != nil {
 		h.writeScheduledTaskMutationError(ctx, w, err)
@@ -412,14 +428,15 @@ func toBatchAction(r *http.Request, task sched_db.ScheduledTask) schedoapi.Batch
 	}
 	active := task.Status != sched_db.ScheduledTaskStatusStopped
 	resp := schedoapi.BatchAction{
-		Id:         task.ID,
-		Owner:      task.Owner,
-		Schedule:   task.Schedule,
-		ActionName: schedoapi.BatchActionName(actionData.ActionName),
-		CreatedAt:  task.CreatedAt.Time,
-		BatchQuery: actionData.Selector,
-		Active:     active,
-		EventsLink: brokerapi.Link(r, brokerapi.Path("batch_actions", task.ID, "events"), nil),
+		Id:            task.ID,
+		Owner:         task.Owner,
+		Schedule:      task.Schedule,
+		ActionName:    schedoapi.BatchActionName(actionData.ActionName),
+		CreatedAt:     task.CreatedAt.Time,
+		BatchQuery:    actionData.Selector,
+		MisfirePolicy: schedoapi.BatchActionMisfirePolicy(task.MisfirePolicy),
+		Active:        active,
+		EventsLink:    brokerapi.Link(r, brokerapi.Path("batch_actions", task.ID, "events"), nil),
 	}
 	if len(task.ActionData.CustomData) > 0 {
 		resp.ActionParams = &task.ActionData.CustomData
@@ -361,7 +361,8 @@ func TestPostBatchActions_OK(t *testing.T) {
 			p.ActionData.BatchActionData.Selector == "title=test" &&
 			p.ActionData.BatchActionData.TaskId == p.ID &&
 			p.ActionData.BatchActionData.Owner == testSymbol &&
-			len(p.ActionData.CustomData) == 0
+			len(p.ActionData.CustomData) == 0 &&
+			p.MisfirePolicy == sched_db.MisfirePolicyRunOnce
 	})).Return(saveScheduledTaskReturn, nil)
 
 	h := newHandler(repo)
@@ -379,11 +380,31 @@ func TestPostBatchActions_OK(t *testing.T) {
 	assert.Equal(t, testSymbol, resp.Owner)
 	assert.Equal(t, validRrule, resp.Schedule)
 	assert.Equal(t, "title=test", resp.BatchQuery)
+	assert.Equal(t, schedoapi.RunOnce, resp.MisfirePolicy)
 	assert.True(t, resp.Active)
 	assert.NotNil(t, resp.NextRun)
 	repo.AssertExpectations(t)
 }
 
+func TestPostBatchActions_MisfirePolicyPersisted(t *testing.T) {
+	repo := new(MockSchedRepo)
+
+	repo.On("SaveScheduledTask", mock.MatchedBy(func(p sched_db.SaveScheduledTaskParams) bool {
+		return p.MisfirePolicy == sched_db.MisfirePolicyCatchUp
+	})).Return(saveScheduledTaskReturn, nil)
+
+	h := newHandler(repo)
+	req := newReq(http.MethodPost, `{"actionName":"email-pullslips","batchQuery":"title=test","schedule":"`+validRrule+`","misfirePolicy":"catch_up"}`)
+	rr := httptest.NewRecorder()
+	h.PostBatchActions(rr, req, schedoapi.PostBatchActionsParams{Symbol: symPtr(testSymbol)})
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var resp schedoapi.BatchAction
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, schedoapi.CatchUp, resp.MisfirePolicy)
+	repo.AssertExpectations(t)
+}
+
 func TestPostBatchActions_ValidDailySchedule_ComputesMidnightRunAt(t *testing.T) {
 	repo := new(MockSchedRepo)
 	before := time.Now().UTC()
@@ -499,6 +520,16 @@ func TestPostBatchActions_InvalidActionName(t *testing.T) {
 	assertErrorStatus(t, rr, http.StatusBadRequest)
 }
 
+func TestPostBatchActions_InvalidMisfirePolicy(t *testing.T) {
+	h := newHandler(new(MockSchedRepo))
+	req := newReq(http.MethodPost, `{"actionName":"email-pullslips","batchQuery":"title=test","schedule":"`+validRrule+`","misfirePolicy":"later"}`)
+	rr := httptest.NewRecorder()
+	h.PostBatchActions(rr, req, schedoapi.PostBatchActionsParams{Symbol: symPtr(testSymbol)})
+
+	assertErrorStatus(t, rr, http.StatusBadRequest)
+	assert.Contains(t, rr.Body.String(), "unknown misfirePolicy: later")
+}
+
 func TestPostBatchActions_EmptySchedule(t *testing.T) {
 	h := newHandler(new(MockSchedRepo))
 	req := newReq(http.MethodPost, `{"actionName":"email-pullslips","batchQuery":"title=test","schedule":""}`)
@@ -640,6 +671,32 @@ func TestPutBatchActionsId_OK_RecomputesRunAtAndPersistsActionData(t *testing.T)
 	repo.AssertExpectations(t)
 }
 
+func TestPutBatchActionsId_MisfirePolicy(t *testing.T) {
+	repo := new(MockSchedRepo)
+	task := scheduledTaskFixture("task-1")
+	task.MisfirePolicy = sched_db.MisfirePolicyRunOnce
+	repo.On("GetScheduledTaskByIdForUpdate", "task-1", testOwnerScope).Return(task, nil)
+	repo.On("SaveScheduledTask", mock.MatchedBy(func(p sched_db.SaveScheduledTaskParams) bool {
+		return p.MisfirePolicy == sched_db.MisfirePolicySkip
+	})).Return(saveScheduledTaskReturn, nil)
+
+	h := newHandler(repo)
+	req := newReq(http.MethodPut, `{"batchQuery":"title=test","schedule":"`+validRrule+`","misfirePolicy":"skip"}`)
+	rr := httptest.NewRecorder()
+	h.PutBatchActionsId(rr, req, "task-1", schedoapi.PutBatchActionsIdParams{Symbol: symPtr(testSymbol)})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp schedoapi.BatchAction
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, schedoapi.Skip, resp.MisfirePolicy)
+	repo.AssertExpectations(t)
+
+	req = newReq(http.MethodPut, `{"batchQuery":"title=test","schedule":"`+validRrule+`","misfirePolicy":"never"}`)
+	rr = httptest.NewRecorder()
+	h.PutBatchActionsId(rr, req, "task-1", schedoapi.PutBatchActionsIdParams{Symbol: symPtr(testSymbol)})
+	assertErrorStatus(t, rr, http.StatusBadRequest)
+}
+
 func TestPutBatchActionsId_InvalidSchedule(t *testing.T) {
 	repo := new(MockSchedRepo)
 
@@ -7,3 +7,24 @@ const (
 	ScheduledTaskStatusRunning ScheduledTaskStatus = "running"
 	ScheduledTaskStatusStopped ScheduledTaskStatus = "stopped"
 )
+
+// MisfirePolicy decides what happens to a run that was missed because no
+// scheduler was up at the scheduled time.
+type MisfirePolicy string
+
+const (
+	// MisfirePolicySkip drops the missed runs and waits for the next scheduled time.
+	MisfirePolicySkip MisfirePolicy = "skip"
+	// MisfirePolicyRunOnce fires once for all missed runs.
+	MisfirePolicyRunOnce MisfirePolicy = "run_once"
+	// MisfirePolicyCatchUp fires once for every missed run.
+	MisfirePolicyCatchUp MisfirePolicy = "catch_up"
+)
+
+func (p MisfirePolicy) Valid() bool {
+	switch p {
+	case MisfirePolicySkip, MisfirePolicyRunOnce, MisfirePolicyCatchUp:
+		return true
+	}
+	return false
+}
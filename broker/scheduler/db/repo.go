@@ -1,11 +1,13 @@
 package sched_db
 
 import (
+	"errors"
 	"time"
 
 	"github.com/indexdata/crosslink/broker/common"
 	"github.com/indexdata/crosslink/broker/events"
 	"github.com/indexdata/crosslink/broker/repo"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -25,6 +27,11 @@ type SchedRepo interface {
 	DeleteOldBatchActionRunEvents(ctx common.ExtendedContext, currentEventId string, taskID string, retention int32) error
 	DeleteScheduledTask(ctx common.ExtendedContext, id string, owners []string) error
 	GetScheduledTasks(ctx common.ExtendedContext, params GetScheduledTasksParams) ([]ScheduledTask, int64, error)
+	// ClaimScheduledTaskRun records the run of a task for the given scheduled time, it returns false when
+	// the run was already claimed, possibly by another instance.
+	ClaimScheduledTaskRun(ctx common.ExtendedContext, taskID string, scheduledFor pgtype.Timestamptz) (bool, error)
+	SetScheduledTaskRunEvent(ctx common.ExtendedContext, params SetScheduledTaskRunEventParams) error
+	DeleteScheduledTaskRunsBefore(ctx common.ExtendedContext, taskID string, before pgtype.Timestamptz) error
 }
 
 type PgSchedRepo struct {
@@ -56,6 +63,9 @@ func (r *PgSchedRepo) SaveScheduledTask(ctx common.ExtendedContext, params SaveS
 	if !params.UpdatedAt.Valid {
 		params.UpdatedAt = params.CreatedAt
 	}
+	if params.MisfirePolicy == "" {
+		params.MisfirePolicy = MisfirePolicyRunOnce
+	}
 	row, err := r.queries.SaveScheduledTask(ctx, r.GetConnOrTx(), params)
 	if err == nil {
 		r.notify(ctx)
@@ -158,3 +168,25 @@ func (r *PgSchedRepo) GetScheduledTasks(ctx common.ExtendedContext, params GetSc
 	}
 	return tasks, fullCount, err
 }
+
+func (r *PgSchedRepo) ClaimScheduledTaskRun(ctx common.ExtendedContext, taskID string, scheduledFor pgtype.Timestamptz) (bool, error) {
+	_, err := r.queries.ClaimScheduledTaskRun(ctx, r.GetConnOrTx(), ClaimScheduledTaskRunParams{
+		TaskID:       taskID,
+		ScheduledFor: scheduledFor,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *PgSchedRepo) SetScheduledTaskRunEvent(ctx common.ExtendedContext, params SetScheduledTaskRunEventParams) error {
+	return r.queries.SetScheduledTaskRunEvent(ctx, r.GetConnOrTx(), params)
+}
+
+func (r *PgSchedRepo) DeleteScheduledTaskRunsBefore(ctx common.ExtendedContext, taskID string, before pgtype.Timestamptz) error {
+	return r.queries.DeleteScheduledTaskRunsBefore(ctx, r.GetConnOrTx(), DeleteScheduledTaskRunsBeforeParams{
+		TaskID: taskID,
+		Before: before,
+	})
+}
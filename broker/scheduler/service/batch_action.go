@@ -55,6 +55,10 @@ func (s *BatchActionService) batchAction(ctx common.ExtendedContext, event event
 		action = s.emailSenderService.EmailPullslip
 	case string(schedoapi.RequestAging):
 		action = s.RequestAging
+	case string(schedoapi.OverdueReminders):
+		action = s.emailSenderService.OverdueReminders
+	case string(schedoapi.ReportExport):
+		action = s.emailSenderService.ReportExport
 	default:
 		ctx.Logger().Error("unknown batch action",
 			"actionName", event.EventData.BatchActionData.ActionName,
@@ -181,7 +185,7 @@ func (s *BatchActionService) RequestAging(ctx common.ExtendedContext, event even
 				Action:          action,
 				BatchActionData: &childBatchActionData,
 			}, CustomData: backgroundActionParams(event.EventData.CustomData)}
-			_, eventErr := s.eventBus.CreateTask(pr.ID, events.EventNameInvokeBackgroundAction, data, events.EventDomainPatronRequest, &event.ID, events.SignalConsumers)
+			_, eventErr := s.eventBus.CreateTask(ctx, pr.ID, events.EventNameInvokeBackgroundAction, data, events.EventDomainPatronRequest, &event.ID, events.SignalConsumers)
 			if eventErr != nil {
 				result.CustomData[pr.ID] = "error creating close action: " + eventErr.Error()
 			}
@@ -72,7 +72,7 @@ func (m *mockBatchActionEventBus) ProcessTask(
 	return event, m.processErr
 }
 
-func (m *mockBatchActionEventBus) CreateTask(id string, eventName events.EventName, data events.EventData, eventDomain events.EventDomain, parentID *string, target events.SignalTarget) (string, error) {
+func (m *mockBatchActionEventBus) CreateTask(ctx common.ExtendedContext, id string, eventName events.EventName, data events.EventData, eventDomain events.EventDomain, parentID *string, target events.SignalTarget) (string, error) {
 	m.createTaskCalls = append(m.createTaskCalls, createTaskCall{
 		id:          id,
 		eventName:   eventName,
@@ -245,6 +245,32 @@ func TestBatchAction_EmailPullslipsDispatchesToEmailSender(t *testing.T) {
 	assert.Equal(t, "email sending configuration missing", result.EventError.Cause)
 }
 
+func TestBatchAction_OverdueRemindersDispatchesToEmailSender(t *testing.T) {
+	emailSender := EmailSenderServiceWithClient(nil, nil, &mockEmailService{ready: false}, nil)
+	svc := NewBatchActionService(nil, &mockEmailPrRepo{}, &mockBatchActionCleanupRepo{}, emailSender)
+
+	status, result := svc.batchAction(testCtx, batchActionEvent(string(schedoapi.OverdueReminders)))
+
+	assert.Equal(t, events.EventStatusError, status)
+	assert.NotNil(t, result)
+	assert.NotNil(t, result.EventError)
+	assert.Equal(t, "email not sent", result.EventError.Message)
+	assert.Equal(t, "email sending configuration missing", result.EventError.Cause)
+}
+
+func TestBatchAction_ReportExportDispatchesToEmailSender(t *testing.T) {
+	emailSender := EmailSenderServiceWithClient(nil, nil, &mockEmailService{ready: false}, nil)
+	svc := NewBatchActionService(nil, &mockEmailPrRepo{}, &mockBatchActionCleanupRepo{}, emailSender)
+
+	status, result := svc.batchAction(testCtx, batchActionEvent(string(schedoapi.ReportExport)))
+
+	assert.Equal(t, events.EventStatusError, status)
+	assert.NotNil(t, result)
+	assert.NotNil(t, result.EventError)
+	assert.Equal(t, "email not sent", result.EventError.Message)
+	assert.Equal(t, "email sending configuration missing", result.EventError.Cause)
+}
+
 func TestBatchAction_RequestAgingDispatches(t *testing.T) {
 	repo := &mockEmailPrRepo{}
 	svc := NewBatchActionService(&mockBatchActionEventBus{}, repo, &mockBatchActionCleanupRepo{}, nil)
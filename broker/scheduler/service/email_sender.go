@@ -3,7 +3,7 @@ package sched_service
 import (
 	"errors"
 	"fmt"
-	"strings"
+	"time"
 
 	"github.com/indexdata/crosslink/broker/common"
 	"github.com/indexdata/crosslink/broker/email"
@@ -11,6 +11,7 @@ import (
 	"github.com/indexdata/crosslink/broker/ill_db"
 	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
 	"github.com/indexdata/crosslink/broker/patron_request/proapi"
+	prtemplate "github.com/indexdata/crosslink/broker/patron_request/template"
 	psservice "github.com/indexdata/crosslink/broker/pullslip/service"
 	"github.com/indexdata/go-utils/utils"
 )
@@ -22,9 +23,11 @@ var (
 )
 
 type pullslipEmailData struct {
-	To            []string `json:"to"`
-	TemplateLabel string   `json:"templateLabel"`
-	IncludePdf    bool     `json:"includePdf"`
+	To                    []string `json:"to"`
+	TemplateLabel         string   `json:"templateLabel"`
+	IncludePdf            bool     `json:"includePdf"`
+	PullslipTemplateLabel string   `json:"pullslipTemplateLabel"`
+	Document              string   `json:"document"`
 }
 
 type EmailSenderService struct {
@@ -32,6 +35,7 @@ type EmailSenderService struct {
 	illRepo      ill_db.IllRepo
 	pdf          psservice.PdfService
 	emailService email.EmailService
+	emailQueue   EmailQueue
 }
 
 func NewEmailSenderService(prRepo pr_db.PrRepo, illRepo ill_db.IllRepo) (*EmailSenderService, error) {
@@ -96,11 +100,17 @@ func (s *EmailSenderService) generateAndEmailPullslip(ctx common.ExtendedContext
 		return events.NewErrorResult("invalid email event data", "templateLabel field is required")
 	}
 
+	// Pull slip emails go to staff, so the owner's directory language picks the template variant.
+	ownerLanguage := ""
+	if owner.CustomData.Language != nil {
+		ownerLanguage = *owner.CustomData.Language
+	}
 	template, err := s.prRepo.GetTemplateByPurposeAudienceLabelAndOwner(ctx, pr_db.GetTemplateByPurposeAudienceLabelAndOwnerParams{
-		Owner:    event.EventData.BatchActionData.Owner,
-		Purpose:  string(proapi.Email),
-		Label:    emailData.TemplateLabel,
-		Audience: string(proapi.ModelActionParamsSendToStaff),
+		Owner:     event.EventData.BatchActionData.Owner,
+		Purpose:   string(proapi.TemplatePurposeEmail),
+		Label:     emailData.TemplateLabel,
+		Audience:  string(proapi.ModelActionParamsSendToStaff),
+		Languages: prtemplate.Languages(ownerLanguage),
 	})
 	if err != nil {
 		return events.NewErrorResult("failed to load email template", err.Error())
@@ -122,32 +132,56 @@ func (s *EmailSenderService) generateAndEmailPullslip(ctx common.ExtendedContext
 	}
 
 	// Optionally generate a pull-slip PDF and attach it.
-	var pdfAttachment *email.PdfAttach
+	var attachments []email.Attachment
 	if emailData.IncludePdf {
 		if s.pdf == nil {
 			return events.NewErrorResult("pdf not configured", "no PDF generator is available on this service instance")
 		}
-		pdfBytes, pdfErr := s.pdf.GeneratePdfPullSlipForPrs(ctx, prs)
+		doc, docErr := psservice.ParseDocument(emailData.Document)
+		if docErr != nil {
+			return events.NewErrorResult("invalid email event data", docErr.Error())
+		}
+		pdfBytes, pdfErr := s.pdf.GeneratePdfPullSlipForPrs(ctx, prs, psservice.PullSlipTemplate{
+			Document:  doc,
+			Owner:     event.EventData.BatchActionData.Owner,
+			Label:     emailData.PullslipTemplateLabel,
+			Languages: prtemplate.Languages(ownerLanguage),
+		})
 		if pdfErr != nil {
 			return events.NewErrorResult("failed to generate pdf file", pdfErr.Error())
 		}
-		pdfAttachment = &email.PdfAttach{Filename: "pull-slips.pdf", Data: pdfBytes}
+		attachments = append(attachments, email.Attachment{Filename: doc.Filename(), Data: pdfBytes})
 	}
 
-	placeholders := map[string]string{
-		"fullCount":   fmt.Sprintf("%d", fullCount),
-		"actualCount": fmt.Sprintf("%d", len(prs)),
-		"batchQuery":  event.EventData.BatchActionData.Selector,
+	data := prtemplate.Data{
+		Batch: prtemplate.Batch{
+			Query:       event.EventData.BatchActionData.Selector,
+			ActualCount: len(prs),
+			FullCount:   fullCount,
+			Requests:    make([]prtemplate.Request, 0, len(prs)),
+		},
+		Now: time.Now(),
+	}
+	for _, pr := range prs {
+		data.Batch.Requests = append(data.Batch.Requests, prtemplate.NewRequest(pr, nil))
+	}
+	subject, err := prtemplate.Render(string(proapi.TemplateContentTypeText), template.Subject.String, data)
+	if err != nil {
+		return events.NewErrorResult("failed to render email template", "subject: "+err.Error())
+	}
+	body, err := prtemplate.Render(template.ContentType, template.Body, data)
+	if err != nil {
+		return events.NewErrorResult("failed to render email template", "body: "+err.Error())
 	}
 	messageData := email.EmailData{
 		To:         emailData.To,
-		Subject:    renderEmailTemplate(template.Subject.String, placeholders),
-		Body:       renderEmailTemplate(template.Body, placeholders),
-		IsHTML:     template.ContentType == string(proapi.Html),
+		Subject:    subject,
+		Body:       body,
+		IsHTML:     template.ContentType == string(proapi.TemplateContentTypeHtml),
 		IncludePdf: emailData.IncludePdf,
 	}
 
-	raw, err := email.BuildRawMessage(*owner.CustomData.FromEmail, messageData, pdfAttachment)
+	raw, err := email.BuildRawMessage(*owner.CustomData.FromEmail, messageData, attachments...)
 	if err != nil {
 		return events.NewErrorResult("failed to build email message", err.Error())
 	}
@@ -159,22 +193,36 @@ func (s *EmailSenderService) generateAndEmailPullslip(ctx common.ExtendedContext
 	return events.EventStatusSuccess, nil
 }
 
-func renderEmailTemplate(value string, placeholders map[string]string) string {
-	for key, replacement := range placeholders {
-		value = strings.ReplaceAll(value, "{{"+key+"}}", replacement)
-	}
-	return value
-}
-
 // extractEmailData retrieves email pullslip parameters from the event's CustomData map.
 func extractEmailData(eventData events.EventData) (pullslipEmailData, error) {
 	if eventData.CustomData == nil {
 		return pullslipEmailData{}, fmt.Errorf("customData is nil")
 	}
 
-	toRaw, ok := eventData.CustomData["to"]
+	toAddrs, err := extractRecipients(eventData.CustomData)
+	if err != nil {
+		return pullslipEmailData{}, err
+	}
+
+	templateLabel, _ := eventData.CustomData["templateLabel"].(string)
+	includePdf, _ := eventData.CustomData["includePdf"].(bool)
+	pullslipTemplateLabel, _ := eventData.CustomData["pullslipTemplateLabel"].(string)
+	document, _ := eventData.CustomData["document"].(string)
+
+	return pullslipEmailData{
+		To:                    toAddrs,
+		TemplateLabel:         templateLabel,
+		IncludePdf:            includePdf,
+		PullslipTemplateLabel: pullslipTemplateLabel,
+		Document:              document,
+	}, nil
+}
+
+// extractRecipients retrieves the 'to' addresses from the event's CustomData map.
+func extractRecipients(customData map[string]any) ([]string, error) {
+	toRaw, ok := customData["to"]
 	if !ok {
-		return pullslipEmailData{}, fmt.Errorf("missing 'to' field in customData")
+		return nil, fmt.Errorf("missing 'to' field in customData")
 	}
 	var toAddrs []string
 	switch v := toRaw.(type) {
@@ -184,20 +232,12 @@ func extractEmailData(eventData events.EventData) (pullslipEmailData, error) {
 		for _, item := range v {
 			s, ok := item.(string)
 			if !ok {
-				return pullslipEmailData{}, fmt.Errorf("'to' field contains non-string value")
+				return nil, fmt.Errorf("'to' field contains non-string value")
 			}
 			toAddrs = append(toAddrs, s)
 		}
 	default:
-		return pullslipEmailData{}, fmt.Errorf("'to' field has unexpected type %T", toRaw)
+		return nil, fmt.Errorf("'to' field has unexpected type %T", toRaw)
 	}
-
-	templateLabel, _ := eventData.CustomData["templateLabel"].(string)
-	includePdf, _ := eventData.CustomData["includePdf"].(bool)
-
-	return pullslipEmailData{
-		To:            toAddrs,
-		TemplateLabel: templateLabel,
-		IncludePdf:    includePdf,
-	}, nil
+	return toAddrs, nil
 }
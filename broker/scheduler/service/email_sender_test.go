@@ -63,6 +63,7 @@ func (m *mockEmailPrRepo) GetTemplateByPurposeAudienceLabelAndOwner(_ common.Ext
 type mockEmailIllRepo struct {
 	ill_db.IllRepo
 	fromEmail string
+	language  *string
 	err       error
 }
 
@@ -70,7 +71,7 @@ func (m *mockEmailIllRepo) GetPeerBySymbol(_ common.ExtendedContext, _ string) (
 	if m.err != nil {
 		return ill_db.Peer{}, m.err
 	}
-	return ill_db.Peer{CustomData: dirapi.Entry{FromEmail: &m.fromEmail}}, nil
+	return ill_db.Peer{CustomData: dirapi.Entry{FromEmail: &m.fromEmail, Language: m.language}}, nil
 }
 
 // mockEmailService records the raw message bytes passed to SendMail.
@@ -93,11 +94,17 @@ func (m *mockEmailService) SendEmail(from string, to []string, raw []byte) error
 
 // mockPdfGen implements PdfGenerator.
 type mockPdfGen struct {
-	data []byte
-	err  error
+	data        []byte
+	err         error
+	gotTemplate psservice.PullSlipTemplate
 }
 
-func (m *mockPdfGen) GeneratePdfPullSlipForPrs(_ common.ExtendedContext, _ []pr_db.PatronRequest) ([]byte, error) {
+func (m *mockPdfGen) GeneratePdfPullSlipForPrs(_ common.ExtendedContext, _ []pr_db.PatronRequest, template psservice.PullSlipTemplate) ([]byte, error) {
+	m.gotTemplate = template
+	return m.data, m.err
+}
+
+func (m *mockPdfGen) GenerateZplPullSlipForPrs(_ common.ExtendedContext, _ []pr_db.PatronRequest, _ psservice.Document) ([]byte, error) {
 	return m.data, m.err
 }
 
@@ -206,14 +213,16 @@ func TestExtractEmailData_ToUnexpectedType(t *testing.T) {
 func TestExtractEmailData_AllOptionalFields(t *testing.T) {
 	ed, err := extractEmailData(events.EventData{
 		CustomData: map[string]any{
-			"to":            []string{"a@b.com"},
-			"templateLabel": "pullslips",
-			"includePdf":    true,
+			"to":                    []string{"a@b.com"},
+			"templateLabel":         "pullslips",
+			"includePdf":            true,
+			"pullslipTemplateLabel": "shelf-slip",
 		},
 	})
 	assert.NoError(t, err)
 	assert.Equal(t, "pullslips", ed.TemplateLabel)
 	assert.True(t, ed.IncludePdf)
+	assert.Equal(t, "shelf-slip", ed.PullslipTemplateLabel)
 }
 
 // ---------------------------------------------------------------------------
@@ -365,21 +374,32 @@ func TestGenerateAndEmailPullslip_Success(t *testing.T) {
 
 	assert.True(t, strings.Contains(string(mailer.data), "user@example.com"))
 	assert.Equal(t, pr_db.GetTemplateByPurposeAudienceLabelAndOwnerParams{
-		Owner:    "ISIL:OWNER",
-		Purpose:  "email",
-		Label:    "pullslips",
-		Audience: "staff",
+		Owner:     "ISIL:OWNER",
+		Purpose:   "email",
+		Label:     "pullslips",
+		Audience:  "staff",
+		Languages: []string{},
 	}, prRepo.gotTemplate)
 }
 
-func TestGenerateAndEmailPullslip_PerformsPlaceholderSubstitution(t *testing.T) {
+func TestGenerateAndEmailPullslip_OwnerLanguage(t *testing.T) {
+	prRepo := &mockEmailPrRepo{listResult: []pr_db.PatronRequest{}}
+	language := "fr-CA"
+	illRepo := &mockEmailIllRepo{fromEmail: "from@example.com", language: &language}
+	svc := EmailSenderServiceWithClient(prRepo, illRepo, &mockEmailService{}, nil)
+	status, _ := svc.generateAndEmailPullslip(testCtx, validEmailEvent())
+	assert.Equal(t, events.EventStatusSuccess, status)
+	assert.Equal(t, []string{"fr-CA", "fr"}, prRepo.gotTemplate.Languages)
+}
+
+func TestGenerateAndEmailPullslip_RendersBatchData(t *testing.T) {
 	prRepo := &mockEmailPrRepo{
 		listResult: []pr_db.PatronRequest{{ID: "pr-1"}, {ID: "pr-2"}},
 		fullCount:  5,
 		template: pr_db.Template{
 			ID:          "template-id",
-			Subject:     pgtype.Text{String: "Selected {{fullCount}}", Valid: true},
-			Body:        "Attached {{actualCount}} of {{fullCount}} from {{batchQuery}}",
+			Subject:     pgtype.Text{String: "Selected {{.Batch.FullCount}}", Valid: true},
+			Body:        "Attached {{.Batch.ActualCount}} of {{.Batch.FullCount}} from {{.Batch.Query}}:{{range .Batch.Requests}} {{.ID}}{{end}}",
 			ContentType: "text",
 		},
 	}
@@ -392,7 +412,27 @@ func TestGenerateAndEmailPullslip_PerformsPlaceholderSubstitution(t *testing.T)
 	assert.Nil(t, result)
 	message := string(mailer.data)
 	assert.Contains(t, message, "Selected 5")
-	assert.Contains(t, message, "Attached 2 of 5 from cql.allRecords=3D1")
+	assert.Contains(t, message, "Attached 2 of 5 from cql.allRecords=3D1: pr-1 pr-2")
+}
+
+func TestGenerateAndEmailPullslip_TemplateRenderError(t *testing.T) {
+	prRepo := &mockEmailPrRepo{template: pr_db.Template{
+		ID:          "template-id",
+		Subject:     pgtype.Text{String: "Subject", Valid: true},
+		Body:        "Attached {{.Batch.Unknown}}",
+		ContentType: "text",
+	}}
+	mailer := &mockEmailService{}
+	svc := newEmailSvc(prRepo, mailer, nil)
+
+	status, result := svc.generateAndEmailPullslip(testCtx, validEmailEvent())
+
+	assert.Equal(t, events.EventStatusError, status)
+	if assert.NotNil(t, result) {
+		assert.Equal(t, "failed to render email template", result.EventError.Message)
+		assert.Contains(t, result.EventError.Cause, "body:")
+	}
+	assert.False(t, mailer.called)
 }
 
 func TestGenerateAndEmailPullslip_HtmlTemplate(t *testing.T) {
@@ -426,6 +466,56 @@ func TestGenerateAndEmailPullslip_WithPDF_Success(t *testing.T) {
 	assert.Nil(t, result)
 	assert.True(t, mailer.called)
 	assert.Contains(t, string(mailer.data), "application/pdf")
+	assert.Equal(t, psservice.PullSlipTemplate{Document: psservice.DocumentPullSlip, Owner: "ISIL:OWNER", Languages: []string{}}, pdf.gotTemplate)
+}
+
+func TestGenerateAndEmailPullslip_WithPDF_Document(t *testing.T) {
+	prRepo := &mockEmailPrRepo{listResult: []pr_db.PatronRequest{}}
+	mailer := &mockEmailService{}
+	pdf := &mockPdfGen{data: []byte("%PDF fake")}
+	svc := newEmailSvc(prRepo, mailer, pdf)
+
+	event := validEmailEvent()
+	event.EventData.CustomData["includePdf"] = true
+	event.EventData.CustomData["document"] = "label"
+
+	status, result := svc.generateAndEmailPullslip(testCtx, event)
+	assert.Equal(t, events.EventStatusSuccess, status)
+	assert.Nil(t, result)
+	assert.Equal(t, psservice.DocumentLabel, pdf.gotTemplate.Document)
+	assert.Contains(t, string(mailer.data), "shipping-labels.pdf")
+}
+
+func TestGenerateAndEmailPullslip_WithPDF_InvalidDocument(t *testing.T) {
+	prRepo := &mockEmailPrRepo{listResult: []pr_db.PatronRequest{}}
+	mailer := &mockEmailService{}
+	svc := newEmailSvc(prRepo, mailer, &mockPdfGen{data: []byte("%PDF fake")})
+
+	event := validEmailEvent()
+	event.EventData.CustomData["includePdf"] = true
+	event.EventData.CustomData["document"] = "poster"
+
+	status, result := svc.generateAndEmailPullslip(testCtx, event)
+	assert.Equal(t, events.EventStatusError, status)
+	assert.Equal(t, `unknown document "poster"`, result.EventError.Cause)
+	assert.False(t, mailer.called)
+}
+
+func TestGenerateAndEmailPullslip_WithPDF_PullslipTemplate(t *testing.T) {
+	prRepo := &mockEmailPrRepo{listResult: []pr_db.PatronRequest{}}
+	language := "de"
+	illRepo := &mockEmailIllRepo{fromEmail: "from@example.com", language: &language}
+	pdf := &mockPdfGen{data: []byte("%PDF fake")}
+	svc := EmailSenderServiceWithClient(prRepo, illRepo, &mockEmailService{}, pdf)
+
+	event := validEmailEvent()
+	event.EventData.CustomData["includePdf"] = true
+	event.EventData.CustomData["pullslipTemplateLabel"] = "shelf-slip"
+
+	status, result := svc.generateAndEmailPullslip(testCtx, event)
+	assert.Equal(t, events.EventStatusSuccess, status)
+	assert.Nil(t, result)
+	assert.Equal(t, psservice.PullSlipTemplate{Document: psservice.DocumentPullSlip, Owner: "ISIL:OWNER", Label: "shelf-slip", Languages: []string{"de"}}, pdf.gotTemplate)
 }
 
 func TestGenerateAndEmailPullslip_WithPDF_NilGenerator(t *testing.T) {
@@ -0,0 +1,276 @@
+package sched_service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/indexdata/cql-go/cql"
+	"github.com/indexdata/cql-go/cqlbuilder"
+	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/email"
+	"github.com/indexdata/crosslink/broker/events"
+	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
+	"github.com/indexdata/crosslink/broker/patron_request/proapi"
+	prservice "github.com/indexdata/crosslink/broker/patron_request/service"
+	prtemplate "github.com/indexdata/crosslink/broker/patron_request/template"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	DEFAULT_SECOND_NOTICE_AFTER = 7 * 24 * time.Hour
+	DEFAULT_FINAL_NOTICE_AFTER  = 7 * 24 * time.Hour
+)
+
+// ReminderLevel is the escalation level of an overdue reminder, stored as the note of its reminder notification.
+type ReminderLevel string
+
+const (
+	ReminderLevelFirst  ReminderLevel = "first"
+	ReminderLevelSecond ReminderLevel = "second"
+	ReminderLevelFinal  ReminderLevel = "final"
+)
+
+// reminderLevels are the levels in the order they are sent.
+var reminderLevels = []ReminderLevel{ReminderLevelFirst, ReminderLevelSecond, ReminderLevelFinal}
+
+// EmailQueue queues emails about patron requests for delivery, storing the notifications given
+// in the same transaction, see prservice.Outbox.
+type EmailQueue interface {
+	Queue(ctx common.ExtendedContext, pr pr_db.PatronRequest, owner string, from string, emailData email.EmailData, notifications ...pr_db.SaveNotificationParams) (pr_db.OutboxMessage, error)
+}
+
+// reminderNotice is a configured reminder level, sent with templateLabel once after has passed since
+// the previous level was sent, or since the due date for the first level.
+type reminderNotice struct {
+	level         ReminderLevel
+	templateLabel string
+	after         time.Duration
+}
+
+// SetEmailQueue sets the queue overdue reminders are sent through.
+func (s *EmailSenderService) SetEmailQueue(queue EmailQueue) {
+	s.emailQueue = queue
+}
+
+// OverdueReminders emails the patrons of the selected borrowing requests past their due date the next
+// reminder level that is due, and records each level sent as a reminder notification so it is sent only once.
+func (s *EmailSenderService) OverdueReminders(ctx common.ExtendedContext, event events.Event) (events.EventStatus, *events.EventResult) {
+	ctx = ctx.WithArgs(ctx.LoggerArgs().WithComponent(COMP))
+	if !s.emailService.IsReadyToSend() {
+		return events.NewErrorResult("email not sent", "email sending configuration missing")
+	}
+	if s.emailQueue == nil {
+		return events.NewErrorResult("email not sent", "email outbox not configured")
+	}
+	if event.EventData.BatchActionData == nil || event.EventData.BatchActionData.Selector == "" {
+		return events.NewErrorResult("cannot process event", "selector is empty")
+	}
+	notices, err := extractReminderNotices(event.EventData.CustomData)
+	if err != nil {
+		return events.NewErrorResult("invalid reminder event data", err.Error())
+	}
+
+	qb, err := cqlbuilder.NewQueryFromString(event.EventData.BatchActionData.Selector)
+	if err != nil {
+		return events.NewErrorResult("invalid cql selector", err.Error())
+	}
+	now := time.Now()
+	qb.And().Search("side").Term(string(prservice.SideBorrowing)).
+		And().Search("due_at").Rel(cql.LT).Term(now.UTC().Format(TIME_FORMAT))
+	builtCQL, err := qb.Build()
+	if err != nil {
+		return events.NewErrorResult("invalid cql selector", err.Error())
+	}
+	pgcql, err := pr_db.ParsePatronRequestsCql(builtCQL.String())
+	if err != nil {
+		return events.NewErrorResult("invalid cql selector", err.Error())
+	}
+
+	prs, fullCount, err := s.prRepo.ListPatronRequests(ctx, pr_db.ListPatronRequestsParams{Limit: MAX_RECORDS_PER_EMAIL, Offset: 0}, pgcql)
+	if err != nil {
+		return events.NewErrorResult("did not select data for processing", err.Error())
+	}
+	if fullCount > int64(MAX_RECORDS_PER_EMAIL) {
+		ctx.Logger().Warn("overdue reminders truncated: selector matched more records than the batch limit",
+			"matched", fullCount, "limit", MAX_RECORDS_PER_EMAIL)
+	}
+
+	var result = &events.EventResult{CustomData: map[string]any{}}
+	sentCount := 0
+	for _, pr := range prs {
+		level, sendErr := s.sendOverdueReminder(ctx, pr, notices, now)
+		if sendErr != nil {
+			result.CustomData[pr.ID] = sendErr.Error()
+			continue
+		}
+		if level != "" {
+			sentCount++
+		}
+	}
+	result.Note = "processed patron request count: " + strconv.Itoa(len(prs)) + ", reminders sent: " + strconv.Itoa(sentCount)
+	status := events.EventStatusSuccess
+	if len(result.CustomData) > 0 {
+		status = events.EventStatusError
+		result.Note += ", failed: " + strconv.Itoa(len(result.CustomData)) + " with ids and errors in custom data"
+	}
+	return status, result
+}
+
+// sendOverdueReminder queues the next reminder level of pr if it is due and returns the level, or
+// an empty level if no reminder is due or the patron does not accept email.
+func (s *EmailSenderService) sendOverdueReminder(ctx common.ExtendedContext, pr pr_db.PatronRequest, notices []reminderNotice, now time.Time) (ReminderLevel, error) {
+	reminders, _, err := s.prRepo.GetNotificationsByPrId(ctx, pr_db.GetNotificationsByPrIdParams{
+		PrID: pr.ID, Limit: 100, Offset: 0, Kind: string(pr_db.NotificationKindReminder)})
+	if err != nil {
+		return "", fmt.Errorf("failed to read notifications: %w", err)
+	}
+	notice, previous := nextReminderNotice(notices, reminders)
+	if notice == nil {
+		return "", nil
+	}
+	request := prtemplate.NewRequest(pr, nil)
+	if request.DueDate == nil {
+		return "", nil
+	}
+	since := *request.DueDate
+	if previous != nil {
+		since = *previous
+	}
+	if now.Sub(since) < notice.after {
+		return "", nil
+	}
+	if !slices.Contains(prservice.PatronChannels(pr), proapi.NotificationChannelEmail) {
+		ctx.Logger().Debug("patron does not accept email, overdue reminder not sent", "pr_id", pr.ID)
+		return "", nil
+	}
+	recipients := prservice.PatronEmail(pr)
+	if len(recipients) == 0 {
+		return "", errors.New("no recipients found for patron")
+	}
+
+	symbol := pr.RequesterSymbol.String
+	requester, err := s.illRepo.GetPeerBySymbol(ctx, symbol)
+	if err != nil {
+		return "", fmt.Errorf("requester not found: %w", err)
+	}
+	if requester.CustomData.FromEmail == nil || *requester.CustomData.FromEmail == "" {
+		return "", errors.New("requester is missing fromEmail in customData")
+	}
+	requesterLanguage := ""
+	if requester.CustomData.Language != nil {
+		requesterLanguage = *requester.CustomData.Language
+	}
+	template, err := s.prRepo.GetTemplateByPurposeAudienceLabelAndOwner(ctx, pr_db.GetTemplateByPurposeAudienceLabelAndOwnerParams{
+		Owner:     symbol,
+		Purpose:   string(proapi.TemplatePurposeEmail),
+		Label:     notice.templateLabel,
+		Audience:  string(proapi.ModelActionParamsSendToPatron),
+		Languages: prtemplate.Languages(pr.PatronLanguage.String, requesterLanguage),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to load email template %s: %w", notice.templateLabel, err)
+	}
+
+	data, err := prservice.TemplateData(ctx, s.prRepo, s.illRepo, pr, now)
+	if err != nil {
+		return "", fmt.Errorf("failed to read notifications: %w", err)
+	}
+	subject, err := prtemplate.Render(string(proapi.TemplateContentTypeText), template.Subject.String, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to render subject of template %s: %w", notice.templateLabel, err)
+	}
+	body, err := prtemplate.Render(template.ContentType, template.Body, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to render body of template %s: %w", notice.templateLabel, err)
+	}
+	// the reminder is stored with the email, so a level is recorded exactly when its email is queued
+	_, err = s.emailQueue.Queue(ctx, pr, symbol, *requester.CustomData.FromEmail, email.EmailData{
+		To:      recipients,
+		Subject: subject,
+		Body:    body,
+		IsHTML:  template.ContentType == string(proapi.TemplateContentTypeHtml),
+	}, pr_db.SaveNotificationParams{
+		ID:             uuid.NewString(),
+		PrID:           pr.ID,
+		FromSymbol:     symbol,
+		ToSymbol:       strings.Join(recipients, "; "),
+		Direction:      pr_db.NotificationDirectionSent,
+		Kind:           pr_db.NotificationKindReminder,
+		Note:           pgtype.Text{String: string(notice.level), Valid: true},
+		CreatedAt:      pgtype.Timestamp{Time: now, Valid: true},
+		AcknowledgedAt: pgtype.Timestamp{Time: now, Valid: true},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to queue email: %w", err)
+	}
+	return notice.level, nil
+}
+
+// nextReminderNotice returns the first configured notice above the highest level already sent, or nil if there is none,
+// and the time the last reminder was sent, or nil if none was.
+func nextReminderNotice(notices []reminderNotice, notifications []pr_db.Notification) (*reminderNotice, *time.Time) {
+	sent := -1
+	var previous *time.Time
+	for _, n := range notifications {
+		if n.Kind != pr_db.NotificationKindReminder {
+			continue
+		}
+		sent = max(sent, slices.Index(reminderLevels, ReminderLevel(n.Note.String)))
+		if n.CreatedAt.Valid && (previous == nil || n.CreatedAt.Time.After(*previous)) {
+			previous = &n.CreatedAt.Time
+		}
+	}
+	for i := range notices {
+		if slices.Index(reminderLevels, notices[i].level) > sent {
+			return &notices[i], previous
+		}
+	}
+	return nil, previous
+}
+
+// extractReminderNotices retrieves the configured reminder levels from the event's CustomData map.
+func extractReminderNotices(customData map[string]any) ([]reminderNotice, error) {
+	first, _ := customData["firstNoticeTemplateLabel"].(string)
+	if first == "" {
+		return nil, errors.New("firstNoticeTemplateLabel field is required")
+	}
+	notices := []reminderNotice{{level: ReminderLevelFirst, templateLabel: first}}
+	second, _ := customData["secondNoticeTemplateLabel"].(string)
+	if second != "" {
+		after, err := extractDuration(customData, "secondNoticeAfter", DEFAULT_SECOND_NOTICE_AFTER)
+		if err != nil {
+			return nil, err
+		}
+		notices = append(notices, reminderNotice{level: ReminderLevelSecond, templateLabel: second, after: after})
+	}
+	final, _ := customData["finalNoticeTemplateLabel"].(string)
+	if final != "" {
+		after, err := extractDuration(customData, "finalNoticeAfter", DEFAULT_FINAL_NOTICE_AFTER)
+		if err != nil {
+			return nil, err
+		}
+		notices = append(notices, reminderNotice{level: ReminderLevelFinal, templateLabel: final, after: after})
+	}
+	return notices, nil
+}
+
+func extractDuration(customData map[string]any, key string, defaultValue time.Duration) (time.Duration, error) {
+	value, ok := customData[key]
+	if !ok {
+		return defaultValue, nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return 0, fmt.Errorf("%s is not a string", key)
+	}
+	d, err := common.ParseDurationWithDays(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s is invalid: %w", key, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,350 @@
+package sched_service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/email"
+	"github.com/indexdata/crosslink/broker/events"
+	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
+	"github.com/indexdata/crosslink/iso18626"
+	"github.com/indexdata/go-utils/utils"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockReminderPrRepo adds the notifications read and saved by overdue reminders.
+type mockReminderPrRepo struct {
+	mockEmailPrRepo
+	notifications map[string][]pr_db.Notification
+	notifyErr     error
+	kinds         []string
+}
+
+func (m *mockReminderPrRepo) GetNotificationsByPrId(_ common.ExtendedContext, params pr_db.GetNotificationsByPrIdParams) ([]pr_db.Notification, int64, error) {
+	m.kinds = append(m.kinds, params.Kind)
+	var list []pr_db.Notification
+	for _, n := range m.notifications[params.PrID] {
+		if params.Kind == "" || string(n.Kind) == params.Kind {
+			list = append(list, n)
+		}
+	}
+	return list, int64(len(list)), m.notifyErr
+}
+
+// mockEmailQueue records the queued emails and the notifications stored with them.
+type mockEmailQueue struct {
+	err           error
+	queued        []email.EmailData
+	owners        []string
+	froms         []string
+	notifications []pr_db.SaveNotificationParams
+}
+
+func (m *mockEmailQueue) Queue(_ common.ExtendedContext, _ pr_db.PatronRequest, owner string, from string, emailData email.EmailData, notifications ...pr_db.SaveNotificationParams) (pr_db.OutboxMessage, error) {
+	if m.err != nil {
+		return pr_db.OutboxMessage{}, m.err
+	}
+	m.queued = append(m.queued, emailData)
+	m.owners = append(m.owners, owner)
+	m.froms = append(m.froms, from)
+	m.notifications = append(m.notifications, notifications...)
+	return pr_db.OutboxMessage{}, nil
+}
+
+func overduePr(id string, overdue time.Duration) pr_db.PatronRequest {
+	return pr_db.PatronRequest{
+		ID:              id,
+		Side:            "borrowing",
+		RequesterSymbol: pgtype.Text{String: "ISIL:REQ", Valid: true},
+		RequesterReqID:  pgtype.Text{String: "REQ-" + id, Valid: true},
+		IllRequest: iso18626.Request{
+			BibliographicInfo: iso18626.BibliographicInfo{Title: "Big Shark"},
+			PatronInfo: &iso18626.PatronInfo{Address: []iso18626.Address{{ElectronicAddress: &iso18626.ElectronicAddress{
+				ElectronicAddressType: iso18626.TypeSchemeValuePair{Text: string(iso18626.ElectronicAddressTypeEmail)},
+				ElectronicAddressData: "patron@example.com",
+			}}}},
+		},
+		IllResponse: iso18626.SupplyingAgencyMessage{StatusInfo: iso18626.StatusInfo{
+			DueDate: &utils.XSDDateTime{Time: time.Now().Add(-overdue)},
+		}},
+	}
+}
+
+func reminder(level ReminderLevel, sentAgo time.Duration) pr_db.Notification {
+	return pr_db.Notification{Kind: pr_db.NotificationKindReminder, Note: pgtype.Text{String: string(level), Valid: true},
+		CreatedAt: pgtype.Timestamp{Time: time.Now().Add(-sentAgo), Valid: true}}
+}
+
+func reminderCustomData() map[string]any {
+	return map[string]any{
+		"firstNoticeTemplateLabel":  "overdue-first",
+		"secondNoticeTemplateLabel": "overdue-second",
+		"finalNoticeTemplateLabel":  "overdue-final",
+	}
+}
+
+func reminderEvent(customData map[string]any) events.Event {
+	return events.Event{
+		EventData: events.EventData{
+			CommonEventData: events.CommonEventData{
+				BatchActionData: &events.BatchActionData{Selector: "state = CHECKED_OUT", Owner: "ISIL:REQ"},
+			},
+			CustomData: customData,
+		},
+	}
+}
+
+func newReminderSvc(prRepo pr_db.PrRepo, queue EmailQueue) *EmailSenderService {
+	svc := EmailSenderServiceWithClient(prRepo, &mockEmailIllRepo{fromEmail: "library@example.com"}, &mockEmailService{ready: true}, nil)
+	if queue != nil {
+		svc.SetEmailQueue(queue)
+	}
+	return svc
+}
+
+func TestExtractReminderNotices(t *testing.T) {
+	notices, err := extractReminderNotices(reminderCustomData())
+	assert.NoError(t, err)
+	assert.Equal(t, []reminderNotice{
+		{level: ReminderLevelFirst, templateLabel: "overdue-first"},
+		{level: ReminderLevelSecond, templateLabel: "overdue-second", after: DEFAULT_SECOND_NOTICE_AFTER},
+		{level: ReminderLevelFinal, templateLabel: "overdue-final", after: DEFAULT_FINAL_NOTICE_AFTER},
+	}, notices)
+
+	notices, err = extractReminderNotices(map[string]any{
+		"firstNoticeTemplateLabel":  "overdue-first",
+		"secondNoticeTemplateLabel": "overdue-second",
+		"secondNoticeAfter":         "10d",
+		"finalNoticeTemplateLabel":  "overdue-final",
+		"finalNoticeAfter":          "72h",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []reminderNotice{
+		{level: ReminderLevelFirst, templateLabel: "overdue-first"},
+		{level: ReminderLevelSecond, templateLabel: "overdue-second", after: 10 * 24 * time.Hour},
+		{level: ReminderLevelFinal, templateLabel: "overdue-final", after: 72 * time.Hour},
+	}, notices)
+}
+
+func TestExtractReminderNotices_Errors(t *testing.T) {
+	_, err := extractReminderNotices(map[string]any{})
+	assert.EqualError(t, err, "firstNoticeTemplateLabel field is required")
+
+	customData := reminderCustomData()
+	customData["secondNoticeAfter"] = "a week"
+	_, err = extractReminderNotices(customData)
+	assert.ErrorContains(t, err, "secondNoticeAfter is invalid")
+
+	customData = reminderCustomData()
+	customData["finalNoticeAfter"] = 14
+	_, err = extractReminderNotices(customData)
+	assert.EqualError(t, err, "finalNoticeAfter is not a string")
+}
+
+func TestNextReminderNotice(t *testing.T) {
+	notices, _ := extractReminderNotices(reminderCustomData())
+	next := func(notices []reminderNotice, notifications ...pr_db.Notification) *reminderNotice {
+		notice, _ := nextReminderNotice(notices, notifications)
+		return notice
+	}
+	assert.Equal(t, ReminderLevelFirst, next(notices).level)
+	assert.Equal(t, ReminderLevelSecond, next(notices, reminder(ReminderLevelFirst, 0)).level)
+	assert.Equal(t, ReminderLevelFinal, next(notices, reminder(ReminderLevelFirst, 0), reminder(ReminderLevelSecond, 0)).level)
+	assert.Nil(t, next(notices, reminder(ReminderLevelFinal, 0)))
+	// notes and emails are not reminders
+	assert.Equal(t, ReminderLevelFirst, next(notices, pr_db.Notification{Kind: pr_db.NotificationKindNote, Note: pgtype.Text{String: "final", Valid: true}}).level)
+	// a level that is not configured is skipped
+	withoutSecond := []reminderNotice{notices[0], notices[2]}
+	assert.Equal(t, ReminderLevelFinal, next(withoutSecond, reminder(ReminderLevelFirst, 0)).level)
+}
+
+func TestNextReminderNoticePrevious(t *testing.T) {
+	notices, _ := extractReminderNotices(reminderCustomData())
+	_, previous := nextReminderNotice(notices, nil)
+	assert.Nil(t, previous)
+
+	second := reminder(ReminderLevelSecond, time.Hour)
+	_, previous = nextReminderNotice(notices, []pr_db.Notification{second, reminder(ReminderLevelFirst, 8*24*time.Hour)})
+	if assert.NotNil(t, previous) {
+		assert.Equal(t, second.CreatedAt.Time, *previous)
+	}
+}
+
+func TestOverdueReminders_EmailNotReady(t *testing.T) {
+	svc := EmailSenderServiceWithClient(nil, nil, &mockEmailService{ready: false}, nil)
+	status, result := svc.OverdueReminders(testCtx, reminderEvent(reminderCustomData()))
+	assert.Equal(t, events.EventStatusError, status)
+	assert.Equal(t, "email sending configuration missing", result.EventError.Cause)
+}
+
+func TestOverdueReminders_NoQueue(t *testing.T) {
+	svc := newReminderSvc(&mockReminderPrRepo{}, nil)
+	status, result := svc.OverdueReminders(testCtx, reminderEvent(reminderCustomData()))
+	assert.Equal(t, events.EventStatusError, status)
+	assert.Equal(t, "email outbox not configured", result.EventError.Cause)
+}
+
+func TestOverdueReminders_InvalidData(t *testing.T) {
+	svc := newReminderSvc(&mockReminderPrRepo{}, &mockEmailQueue{})
+	status, result := svc.OverdueReminders(testCtx, reminderEvent(map[string]any{}))
+	assert.Equal(t, events.EventStatusError, status)
+	assert.Equal(t, "invalid reminder event data", result.EventError.Message)
+
+	event := reminderEvent(reminderCustomData())
+	event.EventData.BatchActionData.Selector = ""
+	status, result = svc.OverdueReminders(testCtx, event)
+	assert.Equal(t, events.EventStatusError, status)
+	assert.Equal(t, "selector is empty", result.EventError.Cause)
+
+	event.EventData.BatchActionData.Selector = "title ="
+	status, result = svc.OverdueReminders(testCtx, event)
+	assert.Equal(t, events.EventStatusError, status)
+	assert.Equal(t, "invalid cql selector", result.EventError.Message)
+}
+
+func TestOverdueReminders_SelectsOverdueBorrowingRequests(t *testing.T) {
+	repo := &mockReminderPrRepo{}
+	svc := newReminderSvc(repo, &mockEmailQueue{})
+	status, result := svc.OverdueReminders(testCtx, reminderEvent(reminderCustomData()))
+	assert.Equal(t, events.EventStatusSuccess, status)
+	assert.Equal(t, "processed patron request count: 0, reminders sent: 0", result.Note)
+	where := repo.gotQuery.GetWhereClause()
+	assert.Contains(t, where, "side")
+	assert.Contains(t, where, "due_at")
+}
+
+func TestOverdueReminders_SendsNextLevel(t *testing.T) {
+	repo := &mockReminderPrRepo{
+		mockEmailPrRepo: mockEmailPrRepo{
+			listResult: []pr_db.PatronRequest{overduePr("pr-1", 24*time.Hour), overduePr("pr-2", 10*24*time.Hour), overduePr("pr-3", 30*24*time.Hour)},
+			template: pr_db.Template{ID: "t", Subject: pgtype.Text{String: "Overdue: {{.Request.Title}}", Valid: true},
+				Body: "Return {{.Request.Hrid}}", ContentType: "text"},
+		},
+		notifications: map[string][]pr_db.Notification{
+			"pr-2": {reminder(ReminderLevelFirst, 8*24*time.Hour)},
+			"pr-3": {reminder(ReminderLevelFirst, 16*24*time.Hour), reminder(ReminderLevelSecond, 8*24*time.Hour)},
+		},
+	}
+	queue := &mockEmailQueue{}
+	svc := newReminderSvc(repo, queue)
+
+	status, result := svc.OverdueReminders(testCtx, reminderEvent(reminderCustomData()))
+
+	assert.Equal(t, events.EventStatusSuccess, status)
+	assert.Equal(t, "processed patron request count: 3, reminders sent: 3", result.Note)
+	if assert.Len(t, queue.queued, 3) {
+		assert.Equal(t, []string{"patron@example.com"}, queue.queued[0].To)
+		assert.Equal(t, "Overdue: Big Shark", queue.queued[0].Subject)
+		assert.Equal(t, "Return REQ-pr-1", queue.queued[0].Body)
+	}
+	assert.Equal(t, []string{"ISIL:REQ", "ISIL:REQ", "ISIL:REQ"}, queue.owners)
+	assert.Equal(t, "library@example.com", queue.froms[0])
+	if assert.Len(t, queue.notifications, 3) {
+		for i, level := range []ReminderLevel{ReminderLevelFirst, ReminderLevelSecond, ReminderLevelFinal} {
+			assert.Equal(t, pr_db.NotificationKindReminder, queue.notifications[i].Kind)
+			assert.Equal(t, string(level), queue.notifications[i].Note.String)
+			assert.Equal(t, "patron@example.com", queue.notifications[i].ToSymbol)
+		}
+	}
+	assert.Contains(t, repo.kinds, string(pr_db.NotificationKindReminder))
+	assert.Equal(t, "overdue-final", repo.gotTemplate.Label)
+	assert.Equal(t, "patron", repo.gotTemplate.Audience)
+	assert.Equal(t, "ISIL:REQ", repo.gotTemplate.Owner)
+}
+
+func TestOverdueReminders_SkipsSentAndNotYetDueLevels(t *testing.T) {
+	repo := &mockReminderPrRepo{
+		mockEmailPrRepo: mockEmailPrRepo{
+			listResult: []pr_db.PatronRequest{overduePr("pr-1", 24*time.Hour), overduePr("pr-2", 30*24*time.Hour)},
+		},
+		notifications: map[string][]pr_db.Notification{
+			"pr-1": {reminder(ReminderLevelFirst, 0)},
+			"pr-2": {reminder(ReminderLevelFirst, 0), reminder(ReminderLevelSecond, 0), reminder(ReminderLevelFinal, 0)},
+		},
+	}
+	queue := &mockEmailQueue{}
+	svc := newReminderSvc(repo, queue)
+
+	status, result := svc.OverdueReminders(testCtx, reminderEvent(reminderCustomData()))
+
+	assert.Equal(t, events.EventStatusSuccess, status)
+	assert.Equal(t, "processed patron request count: 2, reminders sent: 0", result.Note)
+	assert.Empty(t, queue.queued)
+	assert.Empty(t, queue.notifications)
+}
+
+func TestOverdueReminders_WaitsAfterPreviousNotice(t *testing.T) {
+	// long overdue, but the first notice went out only two days ago
+	repo := &mockReminderPrRepo{
+		mockEmailPrRepo: mockEmailPrRepo{
+			listResult: []pr_db.PatronRequest{overduePr("pr-1", 30*24*time.Hour)},
+		},
+		notifications: map[string][]pr_db.Notification{
+			"pr-1": {reminder(ReminderLevelFirst, 2*24*time.Hour)},
+		},
+	}
+	queue := &mockEmailQueue{}
+	svc := newReminderSvc(repo, queue)
+
+	status, result := svc.OverdueReminders(testCtx, reminderEvent(reminderCustomData()))
+
+	assert.Equal(t, events.EventStatusSuccess, status)
+	assert.Equal(t, "processed patron request count: 1, reminders sent: 0", result.Note)
+	assert.Empty(t, queue.queued)
+}
+
+func TestOverdueReminders_PatronWithoutEmail(t *testing.T) {
+	noEmail := overduePr("pr-1", 24*time.Hour)
+	noEmail.IllRequest.PatronInfo = nil
+	optedOut := overduePr("pr-2", 24*time.Hour)
+	optedOut.PatronChannels = []string{"sms"}
+	repo := &mockReminderPrRepo{mockEmailPrRepo: mockEmailPrRepo{listResult: []pr_db.PatronRequest{noEmail, optedOut}}}
+	queue := &mockEmailQueue{}
+	svc := newReminderSvc(repo, queue)
+
+	status, result := svc.OverdueReminders(testCtx, reminderEvent(reminderCustomData()))
+
+	assert.Equal(t, events.EventStatusError, status)
+	assert.Equal(t, "processed patron request count: 2, reminders sent: 0, failed: 1 with ids and errors in custom data", result.Note)
+	assert.Equal(t, "no recipients found for patron", result.CustomData["pr-1"])
+	assert.Empty(t, queue.queued)
+}
+
+func TestOverdueReminders_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		repo  *mockReminderPrRepo
+		queue *mockEmailQueue
+		err   string
+	}{
+		{"notifications", &mockReminderPrRepo{notifyErr: errors.New("db down")}, &mockEmailQueue{},
+			"failed to read notifications: db down"},
+		{"template", &mockReminderPrRepo{mockEmailPrRepo: mockEmailPrRepo{templateErr: errors.New("no rows")}}, &mockEmailQueue{},
+			"failed to load email template overdue-first: no rows"},
+		{"queue", &mockReminderPrRepo{}, &mockEmailQueue{err: errors.New("smtp")},
+			"failed to queue email: smtp"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.repo.listResult = []pr_db.PatronRequest{overduePr("pr-1", 24*time.Hour)}
+			svc := newReminderSvc(tc.repo, tc.queue)
+			status, result := svc.OverdueReminders(testCtx, reminderEvent(reminderCustomData()))
+			assert.Equal(t, events.EventStatusError, status)
+			assert.Equal(t, tc.err, result.CustomData["pr-1"])
+		})
+	}
+}
+
+func TestOverdueReminders_RequesterWithoutFromEmail(t *testing.T) {
+	repo := &mockReminderPrRepo{mockEmailPrRepo: mockEmailPrRepo{listResult: []pr_db.PatronRequest{overduePr("pr-1", 24*time.Hour)}}}
+	svc := EmailSenderServiceWithClient(repo, &mockEmailIllRepo{}, &mockEmailService{ready: true}, nil)
+	svc.SetEmailQueue(&mockEmailQueue{})
+
+	status, result := svc.OverdueReminders(testCtx, reminderEvent(reminderCustomData()))
+
+	assert.Equal(t, events.EventStatusError, status)
+	assert.Equal(t, "requester is missing fromEmail in customData", result.CustomData["pr-1"])
+}
@@ -0,0 +1,389 @@
+package sched_service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/indexdata/cql-go/cql"
+	"github.com/indexdata/cql-go/cqlbuilder"
+	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/email"
+	"github.com/indexdata/crosslink/broker/events"
+	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
+	"github.com/indexdata/crosslink/broker/patron_request/proapi"
+	prtemplate "github.com/indexdata/crosslink/broker/patron_request/template"
+	"github.com/indexdata/go-utils/utils"
+)
+
+const (
+	DEFAULT_REPORT_FILENAME = "report"
+	CSV_CONTENT_TYPE        = "text/csv; charset=UTF-8"
+	XLSX_CONTENT_TYPE       = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+var (
+	MAX_RECORDS_PER_REPORT = int32(utils.Must(utils.GetEnvInt("BATCH_REPORT_MAX_COUNT", 10000)))
+	// DEFAULT_REPORT_COLUMNS are exported when a report-export batch action does not list its columns.
+	DEFAULT_REPORT_COLUMNS = []string{"requester_req_id", "side", "state", "title", "author", "patron",
+		"requester_symbol", "supplier_symbol", "created_at", "updated_at"}
+)
+
+// reportColumns maps the column names of a report, named like the CQL indexes, to their value in a patron request.
+var reportColumns = map[string]func(pr pr_db.PatronRequest) string{
+	"id":               func(pr pr_db.PatronRequest) string { return pr.ID },
+	"requester_req_id": func(pr pr_db.PatronRequest) string { return pr.RequesterReqID.String },
+	"side":             func(pr pr_db.PatronRequest) string { return string(pr.Side) },
+	"state":            func(pr pr_db.PatronRequest) string { return string(pr.State) },
+	"title":            func(pr pr_db.PatronRequest) string { return pr.IllRequest.BibliographicInfo.Title },
+	"author":           func(pr pr_db.PatronRequest) string { return pr.IllRequest.BibliographicInfo.Author },
+	"patron":           func(pr pr_db.PatronRequest) string { return pr.Patron.String },
+	"pickup_location":  prtemplate.PickupLocation,
+	"requester_symbol": func(pr pr_db.PatronRequest) string { return pr.RequesterSymbol.String },
+	"supplier_symbol":  func(pr pr_db.PatronRequest) string { return pr.SupplierSymbol.String },
+	"service_type": func(pr pr_db.PatronRequest) string {
+		if pr.IllRequest.ServiceInfo == nil {
+			return ""
+		}
+		return string(pr.IllRequest.ServiceInfo.ServiceType)
+	},
+	"service_level": func(pr pr_db.PatronRequest) string {
+		if pr.IllRequest.ServiceInfo == nil || pr.IllRequest.ServiceInfo.ServiceLevel == nil {
+			return ""
+		}
+		return pr.IllRequest.ServiceInfo.ServiceLevel.Text
+	},
+	"needs_attention": func(pr pr_db.PatronRequest) string { return strconv.FormatBool(pr.NeedsAttention) },
+	"terminal_state":  func(pr pr_db.PatronRequest) string { return strconv.FormatBool(pr.TerminalState) },
+	"due_at": func(pr pr_db.PatronRequest) string {
+		if pr.IllResponse.StatusInfo.DueDate == nil {
+			return ""
+		}
+		return pr.IllResponse.StatusInfo.DueDate.UTC().Format(TIME_FORMAT)
+	},
+	"created_at": func(pr pr_db.PatronRequest) string { return formatReportTime(pr.CreatedAt.Time, pr.CreatedAt.Valid) },
+	"updated_at": func(pr pr_db.PatronRequest) string { return formatReportTime(pr.UpdatedAt.Time, pr.UpdatedAt.Valid) },
+}
+
+type reportExportData struct {
+	To            []string
+	TemplateLabel string
+	Columns       []string
+	Xlsx          bool
+	Filename      string
+	UpdatedWithin time.Duration
+}
+
+// ReportExport emails the selected patron requests as a CSV spreadsheet, and optionally an XLSX one,
+// with the columns configured for the batch action.
+func (s *EmailSenderService) ReportExport(ctx common.ExtendedContext, event events.Event) (events.EventStatus, *events.EventResult) {
+	ctx = ctx.WithArgs(ctx.LoggerArgs().WithComponent(COMP))
+	if !s.emailService.IsReadyToSend() {
+		return events.NewErrorResult("email not sent", "email sending configuration missing")
+	}
+	if event.EventData.BatchActionData == nil || event.EventData.BatchActionData.Selector == "" ||
+		event.EventData.BatchActionData.Owner == "" {
+		return events.NewErrorResult("invalid report event data", "batch action data is empty")
+	}
+	reportData, err := extractReportData(event.EventData.CustomData)
+	if err != nil {
+		return events.NewErrorResult("invalid report event data", err.Error())
+	}
+
+	now := time.Now()
+	selector := event.EventData.BatchActionData.Selector
+	if reportData.UpdatedWithin > 0 {
+		qb, qbErr := cqlbuilder.NewQueryFromString(selector)
+		if qbErr != nil {
+			return events.NewErrorResult("invalid cql selector", qbErr.Error())
+		}
+		qb.And().Search("updated_at").Rel(cql.GE).Term(now.UTC().Add(-reportData.UpdatedWithin).Format(TIME_FORMAT))
+		builtCQL, qbErr := qb.Build()
+		if qbErr != nil {
+			return events.NewErrorResult("invalid cql selector", qbErr.Error())
+		}
+		selector = builtCQL.String()
+	}
+	pgcql, err := pr_db.ParsePatronRequestsCql(selector)
+	if err != nil {
+		return events.NewErrorResult("invalid cql selector", err.Error())
+	}
+
+	owner, err := s.illRepo.GetPeerBySymbol(ctx, event.EventData.BatchActionData.Owner)
+	if err != nil {
+		return events.NewErrorResult("invalid report event data", "owner not found: "+err.Error())
+	}
+	if owner.CustomData.FromEmail == nil || *owner.CustomData.FromEmail == "" {
+		return events.NewErrorResult("invalid report event data", "owner is missing fromEmail in customData")
+	}
+	ownerLanguage := ""
+	if owner.CustomData.Language != nil {
+		ownerLanguage = *owner.CustomData.Language
+	}
+	template, err := s.prRepo.GetTemplateByPurposeAudienceLabelAndOwner(ctx, pr_db.GetTemplateByPurposeAudienceLabelAndOwnerParams{
+		Owner:     event.EventData.BatchActionData.Owner,
+		Purpose:   string(proapi.TemplatePurposeEmail),
+		Label:     reportData.TemplateLabel,
+		Audience:  string(proapi.ModelActionParamsSendToStaff),
+		Languages: prtemplate.Languages(ownerLanguage),
+	})
+	if err != nil {
+		return events.NewErrorResult("failed to load email template", err.Error())
+	}
+
+	prs, fullCount, err := s.prRepo.ListPatronRequests(ctx, pr_db.ListPatronRequestsParams{Limit: MAX_RECORDS_PER_REPORT, Offset: 0}, pgcql)
+	if err != nil {
+		return events.NewErrorResult("did not select data for processing", err.Error())
+	}
+	if fullCount > int64(MAX_RECORDS_PER_REPORT) {
+		ctx.Logger().Warn("report truncated: selector matched more records than the per-report limit",
+			"matched", fullCount, "limit", MAX_RECORDS_PER_REPORT)
+	}
+
+	rows := reportRows(reportData.Columns, prs)
+	csvData, err := renderCsv(rows)
+	if err != nil {
+		return events.NewErrorResult("failed to render report", "csv: "+err.Error())
+	}
+	attachments := []email.Attachment{{Filename: reportData.Filename + ".csv", ContentType: CSV_CONTENT_TYPE, Data: csvData}}
+	if reportData.Xlsx {
+		xlsxData, xlsxErr := renderXlsx(rows)
+		if xlsxErr != nil {
+			return events.NewErrorResult("failed to render report", "xlsx: "+xlsxErr.Error())
+		}
+		attachments = append(attachments, email.Attachment{Filename: reportData.Filename + ".xlsx", ContentType: XLSX_CONTENT_TYPE, Data: xlsxData})
+	}
+
+	data := prtemplate.Data{
+		Batch: prtemplate.Batch{
+			Query:       selector,
+			ActualCount: len(prs),
+			FullCount:   fullCount,
+			Requests:    make([]prtemplate.Request, 0, len(prs)),
+		},
+		Now: now,
+	}
+	for _, pr := range prs {
+		data.Batch.Requests = append(data.Batch.Requests, prtemplate.NewRequest(pr, nil))
+	}
+	subject, err := prtemplate.Render(string(proapi.TemplateContentTypeText), template.Subject.String, data)
+	if err != nil {
+		return events.NewErrorResult("failed to render email template", "subject: "+err.Error())
+	}
+	body, err := prtemplate.Render(template.ContentType, template.Body, data)
+	if err != nil {
+		return events.NewErrorResult("failed to render email template", "body: "+err.Error())
+	}
+	messageData := email.EmailData{
+		To:      reportData.To,
+		Subject: subject,
+		Body:    body,
+		IsHTML:  template.ContentType == string(proapi.TemplateContentTypeHtml),
+	}
+	raw, err := email.BuildRawMessage(*owner.CustomData.FromEmail, messageData, attachments...)
+	if err != nil {
+		return events.NewErrorResult("failed to build email message", err.Error())
+	}
+	err = s.emailService.SendEmail(*owner.CustomData.FromEmail, messageData.To, raw)
+	if err != nil {
+		return events.NewErrorResult("failed to send email via SMTP", err.Error())
+	}
+	var result = &events.EventResult{}
+	result.Note = "exported patron request count: " + strconv.Itoa(len(prs))
+	return events.EventStatusSuccess, result
+}
+
+// extractReportData retrieves the report parameters from the event's CustomData map.
+func extractReportData(customData map[string]any) (reportExportData, error) {
+	if customData == nil {
+		return reportExportData{}, errors.New("customData is nil")
+	}
+	to, err := extractRecipients(customData)
+	if err != nil {
+		return reportExportData{}, err
+	}
+	if len(to) == 0 {
+		return reportExportData{}, errors.New("to field is required")
+	}
+	templateLabel, _ := customData["templateLabel"].(string)
+	if templateLabel == "" {
+		return reportExportData{}, errors.New("templateLabel field is required")
+	}
+	columns, err := extractReportColumns(customData)
+	if err != nil {
+		return reportExportData{}, err
+	}
+	xlsx, _ := customData["xlsx"].(bool)
+	filename, _ := customData["filename"].(string)
+	if filename == "" {
+		filename = DEFAULT_REPORT_FILENAME
+	}
+	if strings.ContainsAny(filename, "/\\\"\r\n") {
+		return reportExportData{}, fmt.Errorf("filename %q is invalid", filename)
+	}
+	updatedWithin, err := extractDuration(customData, "updatedWithin", 0)
+	if err != nil {
+		return reportExportData{}, err
+	}
+	return reportExportData{
+		To:            to,
+		TemplateLabel: templateLabel,
+		Columns:       columns,
+		Xlsx:          xlsx,
+		Filename:      filename,
+		UpdatedWithin: updatedWithin,
+	}, nil
+}
+
+// extractReportColumns retrieves the report columns from the event's CustomData map, DEFAULT_REPORT_COLUMNS if none are given.
+func extractReportColumns(customData map[string]any) ([]string, error) {
+	columnsRaw, ok := customData["columns"]
+	if !ok {
+		return DEFAULT_REPORT_COLUMNS, nil
+	}
+	var columns []string
+	switch v := columnsRaw.(type) {
+	case []string:
+		columns = v
+	case []interface{}:
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, errors.New("'columns' field contains non-string value")
+			}
+			columns = append(columns, s)
+		}
+	default:
+		return nil, fmt.Errorf("'columns' field has unexpected type %T", columnsRaw)
+	}
+	if len(columns) == 0 {
+		return DEFAULT_REPORT_COLUMNS, nil
+	}
+	for _, column := range columns {
+		if _, ok := reportColumns[column]; !ok {
+			return nil, fmt.Errorf("unknown report column %q", column)
+		}
+	}
+	return columns, nil
+}
+
+// reportRows returns a header row with the column names followed by a row for each patron request.
+func reportRows(columns []string, prs []pr_db.PatronRequest) [][]string {
+	rows := make([][]string, 0, len(prs)+1)
+	rows = append(rows, columns)
+	for _, pr := range prs {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = reportColumns[column](pr)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func formatReportTime(t time.Time, valid bool) string {
+	if !valid {
+		return ""
+	}
+	return t.UTC().Format(TIME_FORMAT)
+}
+
+// renderCsv writes rows as CSV. Values that spreadsheet programs would evaluate as formulas are prefixed with
+// a quote, as request data such as titles comes from patrons and peers.
+func renderCsv(rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	for _, row := range rows {
+		escaped := make([]string, len(row))
+		for i, value := range row {
+			if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+				value = "'" + value
+			}
+			escaped[i] = value
+		}
+		if err := w.Write(escaped); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// xlsxParts are the fixed parts of a workbook with a single sheet, see renderXlsx.
+var xlsxParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Report" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// renderXlsx writes rows as an XLSX workbook with a single sheet of inline string cells.
+func renderXlsx(rows [][]string) ([]byte, error) {
+	var sheet bytes.Buffer
+	sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range rows {
+		fmt.Fprintf(&sheet, `<row r="%d">`, r+1)
+		for c, value := range row {
+			fmt.Fprintf(&sheet, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, xlsxColumn(c), r+1)
+			if err := xml.EscapeText(&sheet, []byte(value)); err != nil {
+				return nil, err
+			}
+			sheet.WriteString(`</t></is></c>`)
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, part := range xlsxParts {
+		w, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = w.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+	}
+	w, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(sheet.Bytes()); err != nil {
+		return nil, err
+	}
+	if err = zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// xlsxColumn returns the letters of the zero based column index, A to Z, AA to AZ and so on.
+func xlsxColumn(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
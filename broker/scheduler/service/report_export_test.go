@@ -0,0 +1,277 @@
+package sched_service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/indexdata/crosslink/broker/events"
+	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
+	"github.com/indexdata/crosslink/iso18626"
+	"github.com/indexdata/go-utils/utils"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+func reportCustomData() map[string]any {
+	return map[string]any{
+		"to":            []any{"manager@example.com"},
+		"templateLabel": "report-email",
+		"columns":       []any{"requester_req_id", "state", "title"},
+	}
+}
+
+func reportEvent(customData map[string]any) events.Event {
+	event := validEmailEvent()
+	event.EventData.CustomData = customData
+	return event
+}
+
+func reportPr(id string, title string) pr_db.PatronRequest {
+	return pr_db.PatronRequest{
+		ID:             id,
+		State:          "COMPLETED",
+		RequesterReqID: pgtype.Text{String: "REQ-" + id, Valid: true},
+		IllRequest:     iso18626.Request{BibliographicInfo: iso18626.BibliographicInfo{Title: title}},
+	}
+}
+
+// reportAttachments returns the attachments of a raw email message by filename.
+func reportAttachments(t *testing.T, raw []byte) map[string][]byte {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	assert.NoError(t, err)
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	attachments := map[string][]byte{}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, partErr := mr.NextPart()
+		if partErr == io.EOF {
+			break
+		}
+		assert.NoError(t, partErr)
+		if part.FileName() == "" {
+			continue
+		}
+		data, readErr := io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+		assert.NoError(t, readErr)
+		attachments[part.FileName()] = data
+	}
+	return attachments
+}
+
+func TestExtractReportData(t *testing.T) {
+	data, err := extractReportData(map[string]any{"to": []string{"a@example.com"}, "templateLabel": "report"})
+	assert.NoError(t, err)
+	assert.Equal(t, reportExportData{
+		To:            []string{"a@example.com"},
+		TemplateLabel: "report",
+		Columns:       DEFAULT_REPORT_COLUMNS,
+		Filename:      DEFAULT_REPORT_FILENAME,
+	}, data)
+
+	customData := reportCustomData()
+	customData["xlsx"] = true
+	customData["filename"] = "open-requests"
+	customData["updatedWithin"] = "168h"
+	data, err = extractReportData(customData)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"requester_req_id", "state", "title"}, data.Columns)
+	assert.True(t, data.Xlsx)
+	assert.Equal(t, "open-requests", data.Filename)
+	assert.Equal(t, 168*time.Hour, data.UpdatedWithin)
+}
+
+func TestExtractReportData_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(map[string]any)
+		errMsg string
+	}{
+		{"missing to", func(m map[string]any) { delete(m, "to") }, "missing 'to' field in customData"},
+		{"empty to", func(m map[string]any) { m["to"] = []string{} }, "to field is required"},
+		{"missing template", func(m map[string]any) { delete(m, "templateLabel") }, "templateLabel field is required"},
+		{"unknown column", func(m map[string]any) { m["columns"] = []string{"title", "isbn"} }, `unknown report column "isbn"`},
+		{"non-string column", func(m map[string]any) { m["columns"] = []any{"title", 1} }, "'columns' field contains non-string value"},
+		{"columns type", func(m map[string]any) { m["columns"] = "title" }, "'columns' field has unexpected type string"},
+		{"filename", func(m map[string]any) { m["filename"] = "../report" }, `filename "../report" is invalid`},
+		{"updatedWithin", func(m map[string]any) { m["updatedWithin"] = "week" }, `updatedWithin is invalid: time: invalid duration "week"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customData := reportCustomData()
+			tt.modify(customData)
+			_, err := extractReportData(customData)
+			assert.EqualError(t, err, tt.errMsg)
+		})
+	}
+	_, err := extractReportData(nil)
+	assert.EqualError(t, err, "customData is nil")
+}
+
+func TestReportColumns(t *testing.T) {
+	for _, column := range DEFAULT_REPORT_COLUMNS {
+		assert.Contains(t, reportColumns, column)
+	}
+	due := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	pr := reportPr("pr-1", "Big Shark")
+	pr.CreatedAt = pgtype.Timestamp{Time: due.Add(-24 * time.Hour), Valid: true}
+	pr.IllResponse.StatusInfo.DueDate = &utils.XSDDateTime{Time: due}
+	pr.IllRequest.ServiceInfo = &iso18626.ServiceInfo{ServiceType: iso18626.TypeServiceTypeLoan}
+	rows := reportRows([]string{"id", "title", "service_type", "service_level", "created_at", "updated_at", "due_at", "needs_attention"}, []pr_db.PatronRequest{pr})
+	assert.Equal(t, [][]string{
+		{"id", "title", "service_type", "service_level", "created_at", "updated_at", "due_at", "needs_attention"},
+		{"pr-1", "Big Shark", "Loan", "", "2026-03-03 05:06:07", "", "2026-03-04 05:06:07", "false"},
+	}, rows)
+}
+
+func TestRenderCsv(t *testing.T) {
+	data, err := renderCsv([][]string{{"id", "title"}, {"pr-1", "Sharks, \"big\" ones"}, {"pr-2", "=HYPERLINK(\"x\")"}, {"pr-3", "-1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "id,title\npr-1,\"Sharks, \"\"big\"\" ones\"\npr-2,\"'=HYPERLINK(\"\"x\"\")\"\npr-3,'-1\n", string(data))
+}
+
+func TestRenderXlsx(t *testing.T) {
+	data, err := renderXlsx([][]string{{"id", "title"}, {"pr-1", "Sharks & <Rays>"}})
+	assert.NoError(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, openErr := f.Open()
+		assert.NoError(t, openErr)
+		content, readErr := io.ReadAll(rc)
+		assert.NoError(t, readErr)
+		files[f.Name] = string(content)
+	}
+	assert.Len(t, files, 5)
+	assert.Contains(t, files, "[Content_Types].xml")
+	assert.Contains(t, files["xl/workbook.xml"], `<sheet name="Report" sheetId="1" r:id="rId1"/>`)
+	sheet := files["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<row r="1"><c r="A1" t="inlineStr"><is><t xml:space="preserve">id</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="B2" t="inlineStr"><is><t xml:space="preserve">Sharks &amp; &lt;Rays&gt;</t></is></c></row>`)
+}
+
+func TestXlsxColumn(t *testing.T) {
+	assert.Equal(t, "A", xlsxColumn(0))
+	assert.Equal(t, "Z", xlsxColumn(25))
+	assert.Equal(t, "AA", xlsxColumn(26))
+	assert.Equal(t, "AZ", xlsxColumn(51))
+	assert.Equal(t, "BA", xlsxColumn(52))
+}
+
+func TestReportExport_EmailNotReady(t *testing.T) {
+	svc := EmailSenderServiceWithClient(nil, nil, &mockEmailService{ready: false}, nil)
+	status, result := svc.ReportExport(testCtx, reportEvent(reportCustomData()))
+	assert.Equal(t, events.EventStatusError, status)
+	assert.Equal(t, "email sending configuration missing", result.EventError.Cause)
+}
+
+func TestReportExport_InvalidData(t *testing.T) {
+	svc := newEmailSvc(&mockEmailPrRepo{}, &mockEmailService{ready: true}, nil)
+	status, result := svc.ReportExport(testCtx, reportEvent(map[string]any{"to": []string{"a@example.com"}}))
+	assert.Equal(t, events.EventStatusError, status)
+	assert.Equal(t, "invalid report event data", result.EventError.Message)
+	assert.Equal(t, "templateLabel field is required", result.EventError.Cause)
+
+	event := reportEvent(reportCustomData())
+	event.EventData.BatchActionData.Owner = ""
+	status, result = svc.ReportExport(testCtx, event)
+	assert.Equal(t, events.EventStatusError, status)
+	assert.Equal(t, "batch action data is empty", result.EventError.Cause)
+
+	event = reportEvent(reportCustomData())
+	event.EventData.BatchActionData.Selector = "title ="
+	status, result = svc.ReportExport(testCtx, event)
+	assert.Equal(t, events.EventStatusError, status)
+	assert.Equal(t, "invalid cql selector", result.EventError.Message)
+}
+
+func TestReportExport_Errors(t *testing.T) {
+	svc := EmailSenderServiceWithClient(&mockEmailPrRepo{}, &mockEmailIllRepo{err: errors.New("no peer")}, &mockEmailService{ready: true}, nil)
+	status, result := svc.ReportExport(testCtx, reportEvent(reportCustomData()))
+	assert.Equal(t, events.EventStatusError, status)
+	assert.Equal(t, "owner not found: no peer", result.EventError.Cause)
+
+	svc = EmailSenderServiceWithClient(&mockEmailPrRepo{}, &mockEmailIllRepo{}, &mockEmailService{ready: true}, nil)
+	status, result = svc.ReportExport(testCtx, reportEvent(reportCustomData()))
+	assert.Equal(t, events.EventStatusError, status)
+	assert.Equal(t, "owner is missing fromEmail in customData", result.EventError.Cause)
+
+	svc = newEmailSvc(&mockEmailPrRepo{templateErr: errors.New("no template")}, &mockEmailService{ready: true}, nil)
+	status, result = svc.ReportExport(testCtx, reportEvent(reportCustomData()))
+	assert.Equal(t, events.EventStatusError, status)
+	assert.Equal(t, "failed to load email template", result.EventError.Message)
+
+	svc = newEmailSvc(&mockEmailPrRepo{listErr: errors.New("db down")}, &mockEmailService{ready: true}, nil)
+	status, result = svc.ReportExport(testCtx, reportEvent(reportCustomData()))
+	assert.Equal(t, events.EventStatusError, status)
+	assert.Equal(t, "did not select data for processing", result.EventError.Message)
+
+	svc = newEmailSvc(&mockEmailPrRepo{}, &mockEmailService{ready: true, err: errors.New("smtp down")}, nil)
+	status, result = svc.ReportExport(testCtx, reportEvent(reportCustomData()))
+	assert.Equal(t, events.EventStatusError, status)
+	assert.Equal(t, "failed to send email via SMTP", result.EventError.Message)
+}
+
+func TestReportExport_SendsCsv(t *testing.T) {
+	prRepo := &mockEmailPrRepo{
+		listResult: []pr_db.PatronRequest{reportPr("pr-1", "Big Shark"), reportPr("pr-2", "Small Fish")},
+		template: pr_db.Template{ID: "t", Subject: pgtype.Text{String: "Report of {{.Batch.ActualCount}}", Valid: true},
+			Body: "See attached", ContentType: "text"},
+	}
+	mailer := &mockEmailService{ready: true}
+	svc := newEmailSvc(prRepo, mailer, nil)
+
+	status, result := svc.ReportExport(testCtx, reportEvent(reportCustomData()))
+
+	assert.Equal(t, events.EventStatusSuccess, status)
+	assert.Equal(t, "exported patron request count: 2", result.Note)
+	assert.Equal(t, "report-email", prRepo.gotTemplate.Label)
+	assert.Equal(t, "staff", prRepo.gotTemplate.Audience)
+	assert.Equal(t, MAX_RECORDS_PER_REPORT, prRepo.gotParams.Limit)
+	assert.Contains(t, string(mailer.data), "Subject: Report of 2")
+	assert.Contains(t, string(mailer.data), "manager@example.com")
+	attachments := reportAttachments(t, mailer.data)
+	assert.Len(t, attachments, 1)
+	assert.Equal(t, "requester_req_id,state,title\nREQ-pr-1,COMPLETED,Big Shark\nREQ-pr-2,COMPLETED,Small Fish\n",
+		string(attachments["report.csv"]))
+}
+
+func TestReportExport_SendsXlsx(t *testing.T) {
+	prRepo := &mockEmailPrRepo{listResult: []pr_db.PatronRequest{reportPr("pr-1", "Big Shark")}}
+	mailer := &mockEmailService{ready: true}
+	svc := newEmailSvc(prRepo, mailer, nil)
+	customData := reportCustomData()
+	customData["xlsx"] = true
+	customData["filename"] = "completed-loans"
+
+	status, _ := svc.ReportExport(testCtx, reportEvent(customData))
+
+	assert.Equal(t, events.EventStatusSuccess, status)
+	assert.Contains(t, string(mailer.data), `Content-Type: `+XLSX_CONTENT_TYPE+`; name="completed-loans.xlsx"`)
+	attachments := reportAttachments(t, mailer.data)
+	assert.Len(t, attachments, 2)
+	assert.Contains(t, attachments, "completed-loans.csv")
+	_, err := zip.NewReader(bytes.NewReader(attachments["completed-loans.xlsx"]), int64(len(attachments["completed-loans.xlsx"])))
+	assert.NoError(t, err)
+}
+
+func TestReportExport_UpdatedWithin(t *testing.T) {
+	prRepo := &mockEmailPrRepo{}
+	svc := newEmailSvc(prRepo, &mockEmailService{ready: true}, nil)
+	customData := reportCustomData()
+	customData["updatedWithin"] = "168h"
+
+	status, _ := svc.ReportExport(testCtx, reportEvent(customData))
+
+	assert.Equal(t, events.EventStatusSuccess, status)
+	assert.Contains(t, prRepo.gotQuery.GetWhereClause(), "updated_at")
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/indexdata/crosslink/broker/common"
 	"github.com/indexdata/crosslink/broker/events"
+	"github.com/indexdata/crosslink/broker/metrics"
 	sched_db "github.com/indexdata/crosslink/broker/scheduler/db"
 	"github.com/indexdata/go-utils/utils"
 	"github.com/jackc/pgx/v5"
@@ -24,6 +25,19 @@ var SCHEDULER_RETRY_DELAY, _ = utils.GetEnvAny("SCHEDULER_RETRY_DELAY", time.Dur
 	return d, nil
 })
 
+// SCHEDULER_MISFIRE_THRESHOLD is how late a run may start before it is
+// handled according to the task's misfire policy.
+var SCHEDULER_MISFIRE_THRESHOLD, _ = utils.GetEnvAny("SCHEDULER_MISFIRE_THRESHOLD", time.Minute, func(val string) (time.Duration, error) {
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid SCHEDULER_MISFIRE_THRESHOLD value: %s", val)
+	}
+	return d, nil
+})
+
+// runKeyRetention is how long run keys are kept before the scheduled time of the latest run of a task.
+const runKeyRetention = 7 * 24 * time.Hour
+
 type SchedulerService struct {
 	schedRepo  sched_db.SchedRepo
 	eventBus   events.EventBus
@@ -119,6 +133,9 @@ func (s *SchedulerService) Listen(ctx common.ExtendedContext) error {
 					newConn, connErr := openConn()
 					if connErr == nil {
 						conn = newConn
+						// Notifications sent while disconnected are lost, so
+						// re-check the schedule table for anything that became due.
+						s.wake()
 						break
 					}
 					ctx.Logger().Error("scheduler: reconnect failed", "error", connErr, "next_try_in", delay+jitter)
@@ -129,16 +146,21 @@ func (s *SchedulerService) Listen(ctx common.ExtendedContext) error {
 				}
 				continue
 			}
-			select {
-			case s.notifyCh <- struct{}{}:
-			default:
-			}
+			s.wake()
 		}
 	}()
 
 	return nil
 }
 
+// wake signals the scheduler loop without blocking; a pending wake-up is enough.
+func (s *SchedulerService) wake() {
+	select {
+	case s.notifyCh <- struct{}{}:
+	default:
+	}
+}
+
 // Run starts the scheduler loop, blocking until ctx is cancelled.
 // Call Listen before Run to enable early wake-up via Postgres notifications.
 func (s *SchedulerService) Run(ctx common.ExtendedContext) {
@@ -162,38 +184,28 @@ func (s *SchedulerService) schedulerLoop(ctx common.ExtendedContext) {
 func (s *SchedulerService) runDueTasks(ctx common.ExtendedContext) bool {
 	madeProgress := false
 	for {
+		var eventName string
+		var outcome string
 		err := s.schedRepo.WithTxFunc(ctx, func(txRepo sched_db.SchedRepo) error {
 			task, txErr := txRepo.ClaimNextScheduledTask(ctx)
 			if txErr != nil {
 				return txErr
 			}
-
-			// Publish the event. If this fails the transaction rolls back,
-			// the claim is undone, and the task stays 'pending' for the next cycle.
-			_, txErr = s.eventBus.CreateTask(events.DEFAULT_ILL_TRANSACTION_ID, task.EventName, task.ActionData, events.EventDomainScheduler, nil, events.SignalConsumers)
+			eventName = string(task.EventName)
+			outcome, txErr = s.runTask(ctx, txRepo, &task)
 			if txErr != nil {
 				return txErr
 			}
-
-			// Compute and persist the task's next state.
-			if task.Schedule != "" {
-				next, innerErr := NextScheduleTime(task.Schedule)
-				if innerErr != nil {
-					ctx.Logger().Error("invalid rrule string, disabling task", "error", innerErr, "taskId", task.ID)
-					task.Status = sched_db.ScheduledTaskStatusStopped
-					task.RunAt = pgtype.Timestamptz{Valid: false}
-				} else {
-					task.RunAt = next
-					task.Status = sched_db.ScheduledTaskStatusPending
-				}
-			} else {
-				task.Status = sched_db.ScheduledTaskStatusStopped
-				task.RunAt = pgtype.Timestamptz{Valid: false}
-			}
 			_, txErr = txRepo.SaveScheduledTask(ctx, sched_db.SaveScheduledTaskParams(task))
 			return txErr
 		})
 
+		if eventName != "" {
+			if err != nil {
+				outcome = metrics.OutcomeError
+			}
+			metrics.SchedulerRuns.WithLabelValues(eventName, outcome).Inc()
+		}
 		if err != nil {
 			if !errors.Is(err, pgx.ErrNoRows) {
 				ctx.Logger().Error("failed to process scheduled task", "error", err)
@@ -204,6 +216,74 @@ func (s *SchedulerService) runDueTasks(ctx common.ExtendedContext) bool {
 	}
 }
 
+// runTask fires a claimed task unless its misfire policy says otherwise and
+// sets the task's next state. The run is keyed by task and scheduled time so
+// it fires once even when instances race or disagree about the current time.
+func (s *SchedulerService) runTask(ctx common.ExtendedContext, txRepo sched_db.SchedRepo, task *sched_db.ScheduledTask) (string, error) {
+	now := time.Now().UTC()
+	scheduledFor := now
+	if task.RunAt.Valid {
+		scheduledFor = task.RunAt.Time.UTC()
+	}
+	misfired := now.Sub(scheduledFor) > SCHEDULER_MISFIRE_THRESHOLD
+	outcome := metrics.OutcomeOk
+	if misfired && task.MisfirePolicy == sched_db.MisfirePolicySkip {
+		ctx.Logger().Warn("skipping missed scheduled run", "taskId", task.ID, "scheduledFor", scheduledFor)
+		outcome = metrics.OutcomeSkipped
+	} else {
+		runAt := pgtype.Timestamptz{Time: scheduledFor, Valid: true}
+		claimed, err := txRepo.ClaimScheduledTaskRun(ctx, task.ID, runAt)
+		if err != nil {
+			return outcome, err
+		}
+		if claimed {
+			// Publish the event. If this fails the transaction rolls back,
+			// the claim is undone, and the task stays 'pending' for the next cycle.
+			eventId, err := s.eventBus.CreateTask(ctx, events.DEFAULT_ILL_TRANSACTION_ID, task.EventName, task.ActionData, events.EventDomainScheduler, nil, events.SignalConsumers)
+			if err != nil {
+				return outcome, err
+			}
+			err = txRepo.SetScheduledTaskRunEvent(ctx, sched_db.SetScheduledTaskRunEventParams{
+				TaskID:       task.ID,
+				ScheduledFor: runAt,
+				EventID:      pgtype.Text{String: eventId, Valid: true},
+			})
+			if err != nil {
+				return outcome, err
+			}
+		} else {
+			ctx.Logger().Info("scheduled run already fired, skipping", "taskId", task.ID, "scheduledFor", scheduledFor)
+			outcome = metrics.OutcomeSkipped
+		}
+		err = txRepo.DeleteScheduledTaskRunsBefore(ctx, task.ID, pgtype.Timestamptz{Time: scheduledFor.Add(-runKeyRetention), Valid: true})
+		if err != nil {
+			return outcome, err
+		}
+	}
+
+	// Compute the task's next state. The next run is never at or before the
+	// one just handled, and a task catching up walks through the missed runs.
+	if task.Schedule == "" {
+		task.Status = sched_db.ScheduledTaskStatusStopped
+		task.RunAt = pgtype.Timestamptz{Valid: false}
+		return outcome, nil
+	}
+	after := now
+	if scheduledFor.After(now) || (misfired && task.MisfirePolicy == sched_db.MisfirePolicyCatchUp) {
+		after = scheduledFor
+	}
+	next, err := nextScheduleTimeAt(task.Schedule, after)
+	if err != nil {
+		ctx.Logger().Error("invalid rrule string, disabling task", "error", err, "taskId", task.ID)
+		task.Status = sched_db.ScheduledTaskStatusStopped
+		task.RunAt = pgtype.Timestamptz{Valid: false}
+	} else {
+		task.RunAt = next
+		task.Status = sched_db.ScheduledTaskStatusPending
+	}
+	return outcome, nil
+}
+
 // getNextRunAt returns the run_at timestamp of the earliest pending scheduled
 // task, or a zero Timestamptz if no pending tasks exist.
 func (s *SchedulerService) getNextRunAt(ctx common.ExtendedContext) pgtype.Timestamptz {
@@ -50,6 +50,8 @@ type mockSchedRepo struct {
 	stuckAfter    time.Duration
 	lockedTasks   map[string]sched_db.ScheduledTask
 	lockErrors    map[string]error
+	claimedRuns   map[string]bool
+	runEvents     []sched_db.SetScheduledTaskRunEventParams
 }
 
 func (m *mockSchedRepo) WithTxFunc(ctx common.ExtendedContext, fn func(sched_db.SchedRepo) error) error {
@@ -74,6 +76,31 @@ func (m *mockSchedRepo) SaveScheduledTask(_ common.ExtendedContext, p sched_db.S
 	return sched_db.ScheduledTask(p), m.saveError
 }
 
+func (m *mockSchedRepo) ClaimScheduledTaskRun(_ common.ExtendedContext, taskID string, scheduledFor pgtype.Timestamptz) (bool, error) {
+	if m.claimedRuns == nil {
+		m.claimedRuns = map[string]bool{}
+	}
+	key := runKey(taskID, scheduledFor.Time)
+	if m.claimedRuns[key] {
+		return false, nil
+	}
+	m.claimedRuns[key] = true
+	return true, nil
+}
+
+func (m *mockSchedRepo) SetScheduledTaskRunEvent(_ common.ExtendedContext, p sched_db.SetScheduledTaskRunEventParams) error {
+	m.runEvents = append(m.runEvents, p)
+	return nil
+}
+
+func (m *mockSchedRepo) DeleteScheduledTaskRunsBefore(_ common.ExtendedContext, _ string, _ pgtype.Timestamptz) error {
+	return nil
+}
+
+func runKey(taskID string, scheduledFor time.Time) string {
+	return taskID + "@" + scheduledFor.UTC().Format(time.RFC3339Nano)
+}
+
 func (m *mockSchedRepo) GetNextRunAt(_ common.ExtendedContext) (pgtype.Timestamptz, error) {
 	return m.nextRunAt, m.nextRunAtErr
 }
@@ -108,7 +135,7 @@ type mockEventBus struct {
 	createdTaskNames []events.EventName
 }
 
-func (m *mockEventBus) CreateTask(_ string, name events.EventName, _ events.EventData, _ events.EventDomain, _ *string, _ events.SignalTarget) (string, error) {
+func (m *mockEventBus) CreateTask(_ common.ExtendedContext, _ string, name events.EventName, _ events.EventData, _ events.EventDomain, _ *string, _ events.SignalTarget) (string, error) {
 	m.createdTaskNames = append(m.createdTaskNames, name)
 	return "task-id", m.createTaskErr
 }
@@ -413,6 +440,117 @@ func TestRunDueTasks_ValidJsonPayload_Dispatched(t *testing.T) {
 	assert.Equal(t, []events.EventName{"payload-ev"}, bus.createdTaskNames)
 }
 
+func TestRunDueTasks_RunAlreadyClaimed_NotDispatched(t *testing.T) {
+	runAt := time.Now().Add(-time.Second).Truncate(time.Second)
+	task := sched_db.ScheduledTask{ID: "t6", EventName: "dup-ev", Schedule: "FREQ=MINUTELY", RunAt: tstz(runAt)}
+	repo := &mockSchedRepo{
+		claimResults: []sched_db.ScheduledTask{task},
+		claimedRuns:  map[string]bool{runKey("t6", runAt): true},
+	}
+	bus := &mockEventBus{}
+	svc := &SchedulerService{schedRepo: repo, eventBus: bus}
+
+	progress := svc.runDueTasks(testCtx)
+
+	assert.True(t, progress)
+	assert.Empty(t, bus.createdTaskNames)
+	assert.Empty(t, repo.runEvents)
+	if assert.Len(t, repo.savedTasks, 1) {
+		assert.True(t, repo.savedTasks[0].RunAt.Time.After(time.Now()))
+	}
+}
+
+func TestRunDueTasks_RecordsRunEvent(t *testing.T) {
+	runAt := time.Now().Truncate(time.Second)
+	task := sched_db.ScheduledTask{ID: "t7", EventName: "ev", Schedule: "FREQ=MINUTELY", RunAt: tstz(runAt)}
+	repo := &mockSchedRepo{claimResults: []sched_db.ScheduledTask{task}}
+	svc := &SchedulerService{schedRepo: repo, eventBus: &mockEventBus{}}
+
+	svc.runDueTasks(testCtx)
+
+	if assert.Len(t, repo.runEvents, 1) {
+		assert.Equal(t, "t7", repo.runEvents[0].TaskID)
+		assert.True(t, runAt.Equal(repo.runEvents[0].ScheduledFor.Time))
+		assert.Equal(t, "task-id", repo.runEvents[0].EventID.String)
+	}
+}
+
+// TestRunDueTasks_ClockBehind_NextRunAfterClaimedRun verifies that an instance
+// whose clock is behind the database does not reschedule the run it just fired.
+func TestRunDueTasks_ClockBehind_NextRunAfterClaimedRun(t *testing.T) {
+	runAt := time.Now().UTC().Truncate(time.Minute).Add(time.Minute)
+	task := sched_db.ScheduledTask{ID: "t8", EventName: "ev", Schedule: "FREQ=MINUTELY", RunAt: tstz(runAt)}
+	repo := &mockSchedRepo{claimResults: []sched_db.ScheduledTask{task}}
+	bus := &mockEventBus{}
+	svc := &SchedulerService{schedRepo: repo, eventBus: bus}
+
+	svc.runDueTasks(testCtx)
+
+	assert.Len(t, bus.createdTaskNames, 1)
+	if assert.Len(t, repo.savedTasks, 1) {
+		assert.Equal(t, runAt.Add(time.Minute), repo.savedTasks[0].RunAt.Time)
+	}
+}
+
+func TestRunDueTasks_MisfirePolicies(t *testing.T) {
+	runAt := time.Now().UTC().Add(-3 * time.Hour).Truncate(time.Hour)
+	tests := []struct {
+		policy     sched_db.MisfirePolicy
+		dispatched int
+		catchUp    bool
+	}{
+		{policy: sched_db.MisfirePolicySkip, dispatched: 0},
+		{policy: sched_db.MisfirePolicyRunOnce, dispatched: 1},
+		{policy: "", dispatched: 1},
+		{policy: sched_db.MisfirePolicyCatchUp, dispatched: 1, catchUp: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			task := sched_db.ScheduledTask{ID: "t9", EventName: "ev", Schedule: "FREQ=HOURLY", RunAt: tstz(runAt), MisfirePolicy: tt.policy}
+			repo := &mockSchedRepo{claimResults: []sched_db.ScheduledTask{task}}
+			bus := &mockEventBus{}
+			svc := &SchedulerService{schedRepo: repo, eventBus: bus}
+
+			progress := svc.runDueTasks(testCtx)
+
+			assert.True(t, progress)
+			assert.Len(t, bus.createdTaskNames, tt.dispatched)
+			if assert.Len(t, repo.savedTasks, 1) {
+				saved := repo.savedTasks[0]
+				assert.Equal(t, sched_db.ScheduledTaskStatusPending, saved.Status)
+				if tt.catchUp {
+					assert.Equal(t, runAt.Add(time.Hour), saved.RunAt.Time)
+				} else {
+					assert.True(t, saved.RunAt.Time.After(time.Now()))
+				}
+			}
+		})
+	}
+}
+
+// TestRunDueTasks_CatchUp_FiresEveryMissedRun verifies that a catching up task
+// fires for each missed run and then returns to its schedule.
+func TestRunDueTasks_CatchUp_FiresEveryMissedRun(t *testing.T) {
+	runAt := time.Now().UTC().Add(-3 * time.Hour).Truncate(time.Hour)
+	task := sched_db.ScheduledTask{ID: "t10", EventName: "ev", Schedule: "FREQ=HOURLY", RunAt: tstz(runAt), MisfirePolicy: sched_db.MisfirePolicyCatchUp}
+	repo := &mockSchedRepo{}
+	bus := &mockEventBus{}
+	svc := &SchedulerService{schedRepo: repo, eventBus: bus}
+
+	for range 10 {
+		repo.claimResults = append(repo.claimResults, task)
+		svc.runDueTasks(testCtx)
+		task = sched_db.ScheduledTask(repo.savedTasks[len(repo.savedTasks)-1])
+		if task.RunAt.Time.After(time.Now()) {
+			break
+		}
+	}
+
+	assert.Len(t, bus.createdTaskNames, 4)
+	assert.Len(t, repo.claimedRuns, 4)
+	assert.True(t, task.RunAt.Time.After(time.Now()))
+}
+
 // ---------------------------------------------------------------------------
 // rescheduleLongRunningTasks
 // ---------------------------------------------------------------------------
@@ -595,3 +733,20 @@ func TestNewSchedulerService_ChannelWired(t *testing.T) {
 		t.Fatal("notify channel is not wired to notifyCh")
 	}
 }
+
+func TestWake_DoesNotBlock(t *testing.T) {
+	svc := NewSchedulerService(&mockSchedRepo{}, &mockEventBus{}, "")
+
+	svc.wake()
+	svc.wake()
+	select {
+	case <-svc.notify:
+	default:
+		t.Fatal("wake did not signal the scheduler loop")
+	}
+	select {
+	case <-svc.notify:
+		t.Fatal("pending wake-ups should be coalesced")
+	default:
+	}
+}
@@ -1,7 +1,6 @@
 package service
 
 import (
-	"strconv"
 	"strings"
 	"time"
 
@@ -9,19 +8,8 @@ import (
 	"github.com/indexdata/crosslink/broker/ill_db"
 )
 
-func ParseDurationWithDays(archiveDelay string) (time.Duration, error) {
-	if !strings.HasSuffix(archiveDelay, "d") {
-		return time.ParseDuration(archiveDelay)
-	}
-	days, err := strconv.Atoi(strings.TrimSuffix(archiveDelay, "d"))
-	if err != nil {
-		return 0, err
-	}
-	return time.Duration(days) * 24 * time.Hour, nil
-}
-
 func Archive(ctx common.ExtendedContext, illRepo ill_db.IllRepo, statusList string, archiveDelay string, background bool) error {
-	delayInterval, err := ParseDurationWithDays(archiveDelay)
+	delayInterval, err := common.ParseDurationWithDays(archiveDelay)
 	if err != nil {
 		return err
 	}
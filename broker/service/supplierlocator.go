@@ -14,6 +14,7 @@ import (
 	"github.com/indexdata/crosslink/broker/common"
 	"github.com/indexdata/crosslink/broker/events"
 	"github.com/indexdata/crosslink/broker/ill_db"
+	"github.com/indexdata/crosslink/broker/lms"
 	dirapi "github.com/indexdata/crosslink/directory/api"
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -28,14 +29,16 @@ type SupplierLocator struct {
 	illRepo              ill_db.IllRepo
 	dirAdapter           adapter.DirectoryLookupAdapter
 	lookupAdapterFactory *LookupAdapterFactory
+	lmsCreator           lms.LmsCreator
 }
 
-func CreateSupplierLocator(eventBus events.EventBus, illRepo ill_db.IllRepo, dirAdapter adapter.DirectoryLookupAdapter, lookupAdapterFactory *LookupAdapterFactory) SupplierLocator {
+func CreateSupplierLocator(eventBus events.EventBus, illRepo ill_db.IllRepo, dirAdapter adapter.DirectoryLookupAdapter, lookupAdapterFactory *LookupAdapterFactory, lmsCreator lms.LmsCreator) SupplierLocator {
 	return SupplierLocator{
 		eventBus:             eventBus,
 		illRepo:              illRepo,
 		dirAdapter:           dirAdapter,
 		lookupAdapterFactory: lookupAdapterFactory,
+		lmsCreator:           lmsCreator,
 	}
 }
 
@@ -90,7 +93,7 @@ func (s *SupplierLocator) locateSuppliers(ctx common.ExtendedContext, event even
 	}
 
 	var query string
-	lookupResult, err := lookupAdapter.Lookup(lookupParams)
+	lookupResult, err := catalog.Lookup(ctx, lookupAdapter, lookupParams)
 	if lookupResult != nil {
 		query = lookupResult.GetQuery() // get the query even if there was an error, for logging purposes
 	}
@@ -127,6 +130,9 @@ func (s *SupplierLocator) locateSuppliers(ctx common.ExtendedContext, event even
 	}
 	var holdingsLog = map[string]any{}
 	holdingsLog["lookupQuery"] = query
+	if sourcesResult, ok := lookupResult.(catalog.SourcesResult); ok {
+		holdingsLog["sources"] = sourcesResult.GetSources()
+	}
 
 	// save symbols from holdings results for later use in determining if a supplier is a match for the original holdings results or
 	// just a last resort match - this is needed because last resort symbols are added to the holdings results before filtering and
@@ -389,33 +395,57 @@ func (s *SupplierLocator) checkAvailability(ctx common.ExtendedContext, event ev
 	}
 	if aa == nil {
 		ctx.Logger().Debug("skipping availability check for supplier without availability config", "supplierSymbol", sup.SupplierSymbol)
+	} else {
+		illTrans, err := s.illRepo.GetIllTransactionById(ctx, event.IllTransactionID)
+		if err != nil {
+			return events.LogErrorAndReturnResult(ctx, "failed to read ILL transaction", err)
+		}
+		lookupParams := catalog.LookupParamsFromBibliographicInfo(illTrans.IllTransactionData.BibliographicInfo, illTrans.IllTransactionData.ServiceInfo)
+		lookupParams.Identifier = sup.LocalID.String
+		lookupResult, err := catalog.Lookup(ctx, aa, lookupParams)
+		if err != nil {
+			return events.LogErrorAndReturnResult(ctx, "failed to perform availability lookup", err)
+		}
+		holdingsResults, err := lookupResult.GetHoldings()
+		if err != nil {
+			return events.LogErrorAndReturnResult(ctx, "failed to get holdings for availability lookup", err)
+		}
+		if len(holdingsResults) == 0 {
+			ctx.Logger().Debug("availability lookup returned no results for supplier, skipping", "supplierSymbol", sup.SupplierSymbol)
+			return s.skipUnavailableSupplier(ctx, sup, eventData)
+		}
+		ctx.Logger().Debug("availability lookup returned results for supplier, not skipping", "supplierSymbol", sup.SupplierSymbol)
+	}
+	if !lms.IsLookupItemEnabled(peer.CustomData) || !sup.LocalID.Valid {
 		return events.EventStatusSuccess, &events.EventResult{CustomData: eventData}
 	}
-
-	illTrans, err := s.illRepo.GetIllTransactionById(ctx, event.IllTransactionID)
+	lmsAdapter, err := s.lmsCreator.GetAdapter(ctx, sup.SupplierSymbol)
 	if err != nil {
-		return events.LogErrorAndReturnResult(ctx, "failed to read ILL transaction", err)
+		return events.LogErrorAndReturnResult(ctx, "failed to create LMS adapter", err)
 	}
-	lookupParams := catalog.LookupParamsFromBibliographicInfo(illTrans.IllTransactionData.BibliographicInfo, illTrans.IllTransactionData.ServiceInfo)
-	lookupParams.Identifier = sup.LocalID.String
-	lookupResult, err := aa.Lookup(lookupParams)
+	circulationStatuses, err := lmsAdapter.LookupItems(sup.LocalID.String)
 	if err != nil {
-		return events.LogErrorAndReturnResult(ctx, "failed to perform availability lookup", err)
+		// the supplier is kept as the LMS cannot tell whether it holds an available item
+		ctx.Logger().Warn("LMS item lookup failed, availability unknown", "supplierSymbol", sup.SupplierSymbol, "error", err)
+		eventData["availability"] = lms.AvailabilityUnknown
+		return events.EventStatusSuccess, &events.EventResult{CustomData: eventData}
 	}
-	holdingsResults, err := lookupResult.GetHoldings()
-	if err != nil {
-		return events.LogErrorAndReturnResult(ctx, "failed to get holdings for availability lookup", err)
+	availability := lms.ItemsAvailability(circulationStatuses)
+	eventData["circulationStatuses"] = circulationStatuses
+	eventData["availability"] = availability
+	if availability == lms.AvailabilityUnavailable {
+		ctx.Logger().Debug("no available item in supplier LMS, skipping", "supplierSymbol", sup.SupplierSymbol, "circulationStatuses", circulationStatuses)
+		return s.skipUnavailableSupplier(ctx, sup, eventData)
 	}
-	if len(holdingsResults) == 0 {
-		ctx.Logger().Debug("availability lookup returned no results for supplier, skipping", "supplierSymbol", sup.SupplierSymbol)
-		eventData["skipped"] = true
-		sup.SupplierStatus = ill_db.SupplierStateSkippedPg
-		_, err = s.illRepo.SaveLocatedSupplier(ctx, ill_db.SaveLocatedSupplierParams(sup))
-		if err != nil {
-			return events.LogErrorAndReturnResult(ctx, "could not save located supplier", err)
-		}
-	} else {
-		ctx.Logger().Debug("availability lookup returned results for supplier, not skipping", "supplierSymbol", sup.SupplierSymbol)
+	return events.EventStatusSuccess, &events.EventResult{CustomData: eventData}
+}
+
+func (s *SupplierLocator) skipUnavailableSupplier(ctx common.ExtendedContext, sup ill_db.LocatedSupplier, eventData map[string]any) (events.EventStatus, *events.EventResult) {
+	eventData["skipped"] = true
+	sup.SupplierStatus = ill_db.SupplierStateSkippedPg
+	_, err := s.illRepo.SaveLocatedSupplier(ctx, ill_db.SaveLocatedSupplierParams(sup))
+	if err != nil {
+		return events.LogErrorAndReturnResult(ctx, "could not save located supplier", err)
 	}
 	return events.EventStatusSuccess, &events.EventResult{CustomData: eventData}
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/indexdata/crosslink/broker/common"
 	"github.com/indexdata/crosslink/broker/events"
 	"github.com/indexdata/crosslink/broker/ill_db"
+	"github.com/indexdata/crosslink/broker/lms"
 	"github.com/indexdata/crosslink/broker/test/mocks"
 	dirapi "github.com/indexdata/crosslink/directory/api"
 	"github.com/indexdata/crosslink/iso18626"
@@ -139,7 +140,7 @@ func TestGetNextSupplierEmptyMap(t *testing.T) {
 	mockIllRepo := new(MockIllRepoRequester)
 	mockIllRepo.On("GetPeerById", peerId).Return(ill_db.Peer{}, nil)
 	lookupAdapterFactory := NewLookupAdapterFactory(mockIllRepo, new(adapter.ApiDirectory), "", new(catalog.SruLookupAdapter), new(catalog.LookupAdapterCreatorImpl))
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.ApiDirectory), lookupAdapterFactory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.ApiDirectory), lookupAdapterFactory, nil)
 
 	locSup, skipped, err := locator.getNextSupplier(appCtx, []ill_db.LocatedSupplier{{ID: "1", SupplierID: peerId}})
 	assert.NoError(t, err)
@@ -166,7 +167,7 @@ func TestGetNextSupplierClosed(t *testing.T) {
 	assert.NoError(t, err)
 	mockIllRepo.On("GetPeerById", peerId).Return(ill_db.Peer{CustomData: data}, nil)
 	lookupAdapterFactory := NewLookupAdapterFactory(mockIllRepo, new(adapter.ApiDirectory), "", new(catalog.SruLookupAdapter), new(catalog.LookupAdapterCreatorImpl))
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.ApiDirectory), lookupAdapterFactory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.ApiDirectory), lookupAdapterFactory, nil)
 
 	locSup, skipped, err := locator.getNextSupplier(appCtx, []ill_db.LocatedSupplier{{ID: "1", SupplierID: peerId, SupplierSymbol: "ISIL:SUP"}})
 	assert.NoError(t, err)
@@ -181,7 +182,7 @@ func TestGetNextSupplierFailToLoadPeer(t *testing.T) {
 	mockIllRepo := new(MockIllRepoRequester)
 	mockIllRepo.On("GetPeerById", peerId).Return(ill_db.Peer{}, errors.New("db error"))
 	lookupAdapterFactory := NewLookupAdapterFactory(mockIllRepo, new(adapter.ApiDirectory), "", new(catalog.SruLookupAdapter), new(catalog.LookupAdapterCreatorImpl))
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.ApiDirectory), lookupAdapterFactory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.ApiDirectory), lookupAdapterFactory, nil)
 
 	locSup, skipped, err := locator.getNextSupplier(appCtx, []ill_db.LocatedSupplier{{ID: "1", SupplierID: peerId}})
 	assert.Equal(t, "db error", err.Error())
@@ -200,7 +201,7 @@ func TestGetNextSupplierNoClosures(t *testing.T) {
 	assert.NoError(t, err)
 	mockIllRepo.On("GetPeerById", peerId).Return(ill_db.Peer{CustomData: data}, nil)
 	lookupAdapterFactory := NewLookupAdapterFactory(mockIllRepo, new(adapter.ApiDirectory), "", new(catalog.SruLookupAdapter), new(catalog.LookupAdapterCreatorImpl))
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.ApiDirectory), lookupAdapterFactory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.ApiDirectory), lookupAdapterFactory, nil)
 
 	locSup, skipped, err := locator.getNextSupplier(appCtx, []ill_db.LocatedSupplier{{ID: "1", SupplierID: peerId}})
 	assert.NoError(t, err)
@@ -225,7 +226,7 @@ func TestGetNextSupplierNoStartDate(t *testing.T) {
 	assert.NoError(t, err)
 	mockIllRepo.On("GetPeerById", peerId).Return(ill_db.Peer{CustomData: data}, nil)
 	lookupAdapterFactory := NewLookupAdapterFactory(mockIllRepo, new(adapter.ApiDirectory), "", new(catalog.SruLookupAdapter), new(catalog.LookupAdapterCreatorImpl))
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.ApiDirectory), lookupAdapterFactory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.ApiDirectory), lookupAdapterFactory, nil)
 
 	locSup, skipped, err := locator.getNextSupplier(appCtx, []ill_db.LocatedSupplier{{ID: "1", SupplierID: peerId}})
 	assert.NoError(t, err)
@@ -250,7 +251,7 @@ func TestGetNextSupplierNoEndDate(t *testing.T) {
 	assert.NoError(t, err)
 	mockIllRepo.On("GetPeerById", peerId).Return(ill_db.Peer{CustomData: data}, nil)
 	lookupAdapterFactory := NewLookupAdapterFactory(mockIllRepo, new(adapter.ApiDirectory), "", new(catalog.SruLookupAdapter), new(catalog.LookupAdapterCreatorImpl))
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.ApiDirectory), lookupAdapterFactory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.ApiDirectory), lookupAdapterFactory, nil)
 
 	locSup, skipped, err := locator.getNextSupplier(appCtx, []ill_db.LocatedSupplier{{ID: "1", SupplierID: peerId}})
 	assert.NoError(t, err)
@@ -277,7 +278,7 @@ func TestGetNextSupplierBothInPast(t *testing.T) {
 	assert.NoError(t, err)
 	mockIllRepo.On("GetPeerById", peerId).Return(ill_db.Peer{CustomData: data}, nil)
 	lookupAdapterFactory := NewLookupAdapterFactory(mockIllRepo, new(adapter.ApiDirectory), "", new(catalog.SruLookupAdapter), new(catalog.LookupAdapterCreatorImpl))
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.ApiDirectory), lookupAdapterFactory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.ApiDirectory), lookupAdapterFactory, nil)
 
 	locSup, skipped, err := locator.getNextSupplier(appCtx, []ill_db.LocatedSupplier{{ID: "1", SupplierID: peerId}})
 	assert.NoError(t, err)
@@ -304,7 +305,7 @@ func TestGetNextSupplierBothInFuture(t *testing.T) {
 	assert.NoError(t, err)
 	mockIllRepo.On("GetPeerById", peerId).Return(ill_db.Peer{CustomData: data}, nil)
 	lookupAdapterFactory := NewLookupAdapterFactory(mockIllRepo, new(adapter.ApiDirectory), "", new(catalog.SruLookupAdapter), new(catalog.LookupAdapterCreatorImpl))
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.ApiDirectory), lookupAdapterFactory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.ApiDirectory), lookupAdapterFactory, nil)
 
 	locSup, skipped, err := locator.getNextSupplier(appCtx, []ill_db.LocatedSupplier{{ID: "1", SupplierID: peerId}})
 	assert.NoError(t, err)
@@ -371,7 +372,7 @@ func TestGetNextSupplierBetweenHolidays(t *testing.T) {
 	assert.NoError(t, err)
 	mockIllRepo.On("GetPeerById", peerId).Return(ill_db.Peer{CustomData: data}, nil)
 	lookupAdapterFactory := NewLookupAdapterFactory(mockIllRepo, new(adapter.ApiDirectory), "", new(catalog.SruLookupAdapter), new(catalog.LookupAdapterCreatorImpl))
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.ApiDirectory), lookupAdapterFactory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.ApiDirectory), lookupAdapterFactory, nil)
 
 	locSup, skipped, err := locator.getNextSupplier(appCtx, []ill_db.LocatedSupplier{{ID: "l1", SupplierID: peerId}})
 	assert.NoError(t, err)
@@ -398,7 +399,7 @@ func TestGetNextSupplierClosedEventFailed(t *testing.T) {
 	assert.NoError(t, err)
 	mockIllRepo.On("GetPeerById", peerId).Return(ill_db.Peer{CustomData: data}, nil)
 	lookupAdapterFactory := NewLookupAdapterFactory(mockIllRepo, new(adapter.ApiDirectory), "", new(catalog.SruLookupAdapter), new(catalog.LookupAdapterCreatorImpl))
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.ApiDirectory), lookupAdapterFactory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.ApiDirectory), lookupAdapterFactory, nil)
 	status, result := locator.selectSupplier(appCtx, events.Event{IllTransactionID: "1"})
 
 	assert.Equal(t, events.EventStatusProblem, status)
@@ -433,7 +434,7 @@ func TestLocateSuppliersDeduplicatesHoldingSymbolsForDirectoryLookup(t *testing.
 	}
 
 	lookupAdapterFactory := NewLookupAdapterFactory(mockIllRepo, new(adapter.MockDirectoryLookupAdapter), "", new(catalog.MockLookupShared), new(catalog.LookupAdapterCreatorImpl))
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.MockDirectoryLookupAdapter), lookupAdapterFactory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.MockDirectoryLookupAdapter), lookupAdapterFactory, nil)
 	status, _ := locator.locateSuppliers(appCtx, events.Event{IllTransactionID: "ill-1"})
 
 	assert.Equal(t, events.EventStatusSuccess, status)
@@ -458,7 +459,7 @@ func TestLocateSuppliersRetiresOldRotaBeforeNoHoldingsResult(t *testing.T) {
 	}
 	lookupAdapter := &catalog.MockLookupAdapter{}
 	lookupAdapterFactory := NewLookupAdapterFactory(mockIllRepo, new(adapter.MockDirectoryLookupAdapter), "", lookupAdapter, nil)
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.MockDirectoryLookupAdapter), lookupAdapterFactory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.MockDirectoryLookupAdapter), lookupAdapterFactory, nil)
 
 	status, result := locator.locateSuppliers(appCtx, events.Event{IllTransactionID: "ill-1"})
 
@@ -488,7 +489,7 @@ func TestLocateSuppliersUsesFirstHoldingLocalIdentifierForDuplicateSymbol(t *tes
 	}
 
 	lookupAdapterFactory := NewLookupAdapterFactory(mockIllRepo, new(adapter.MockDirectoryLookupAdapter), "", new(catalog.MockLookupShared), new(catalog.LookupAdapterCreatorImpl))
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.MockDirectoryLookupAdapter), lookupAdapterFactory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.MockDirectoryLookupAdapter), lookupAdapterFactory, nil)
 	status, _ := locator.locateSuppliers(appCtx, events.Event{IllTransactionID: "ill-1"})
 
 	assert.Equal(t, events.EventStatusSuccess, status)
@@ -524,7 +525,7 @@ func TestLocateSuppliersLastResortRequester(t *testing.T) {
 	}
 
 	lookupAdapterFactory := NewLookupAdapterFactory(mockIllRepo, new(adapter.MockDirectoryLookupAdapter), "", new(catalog.MockLookupShared), new(catalog.LookupAdapterCreatorImpl))
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.MockDirectoryLookupAdapter), lookupAdapterFactory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.MockDirectoryLookupAdapter), lookupAdapterFactory, nil)
 	status, _ := locator.locateSuppliers(appCtx, events.Event{IllTransactionID: "ill-1"})
 
 	assert.Equal(t, events.EventStatusSuccess, status)
@@ -568,7 +569,7 @@ func TestLocateSuppliersLastResortLookupEmpty(t *testing.T) {
 	}
 
 	lookupAdapterFactory := NewLookupAdapterFactory(mockIllRepo, new(adapter.MockDirectoryLookupAdapter), "", new(catalog.MockLookupShared), new(catalog.LookupAdapterCreatorImpl))
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.MockDirectoryLookupAdapter), lookupAdapterFactory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.MockDirectoryLookupAdapter), lookupAdapterFactory, nil)
 	status, _ := locator.locateSuppliers(appCtx, events.Event{IllTransactionID: "ill-1"})
 
 	assert.Equal(t, events.EventStatusSuccess, status)
@@ -611,7 +612,7 @@ func TestLocateSuppliersLastResortConsortium(t *testing.T) {
 	}
 
 	lookupAdapterFactory := NewLookupAdapterFactory(mockIllRepo, new(adapter.MockDirectoryLookupAdapter), "ISIL:SUPC", new(catalog.MockLookupShared), new(catalog.LookupAdapterCreatorImpl))
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.MockDirectoryLookupAdapter), lookupAdapterFactory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockIllRepo, new(adapter.MockDirectoryLookupAdapter), lookupAdapterFactory, nil)
 	status, _ := locator.locateSuppliers(appCtx, events.Event{IllTransactionID: "ill-1"})
 
 	assert.Equal(t, events.EventStatusSuccess, status)
@@ -769,7 +770,7 @@ func TestLocateSuppliersMetadataModeNoneSkipsUpdate(t *testing.T) {
 		Holdings: []catalog.Holding{{Symbol: "ISIL:SUP1"}},
 	}
 	factory := NewLookupAdapterFactory(mockRepo, new(adapter.MockDirectoryLookupAdapter), "", holdingsAdapter, nil)
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory, nil)
 
 	status, _ := locator.locateSuppliers(appCtx, events.Event{IllTransactionID: "ill-1"})
 
@@ -796,7 +797,7 @@ func TestLocateSuppliersMetadataSkippedForCrossLinkVendor(t *testing.T) {
 		Holdings: []catalog.Holding{{Symbol: "ISIL:SUP1"}},
 	}
 	factory := NewLookupAdapterFactory(mockRepo, new(adapter.MockDirectoryLookupAdapter), "", holdingsAdapter, nil)
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory, nil)
 
 	status, _ := locator.locateSuppliers(appCtx, events.Event{IllTransactionID: "ill-1"})
 
@@ -822,7 +823,7 @@ func TestLocateSuppliersMetadataMergePopulatesEmptyFields(t *testing.T) {
 		Holdings: []catalog.Holding{{Symbol: "ISIL:SUP1"}},
 	}
 	factory := NewLookupAdapterFactory(mockRepo, new(adapter.MockDirectoryLookupAdapter), "", holdingsAdapter, nil)
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory, nil)
 
 	status, _ := locator.locateSuppliers(appCtx, events.Event{IllTransactionID: "ill-1"})
 
@@ -852,7 +853,7 @@ func TestLocateSuppliersMetadataMergePreservesExistingFields(t *testing.T) {
 		Holdings: []catalog.Holding{{Symbol: "ISIL:SUP1"}},
 	}
 	factory := NewLookupAdapterFactory(mockRepo, new(adapter.MockDirectoryLookupAdapter), "", holdingsAdapter, nil)
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory, nil)
 
 	status, _ := locator.locateSuppliers(appCtx, events.Event{IllTransactionID: "ill-1"})
 
@@ -881,7 +882,7 @@ func TestLocateSuppliersMetadataAutoWithIdentifierReplaces(t *testing.T) {
 		Holdings: []catalog.Holding{{Symbol: "ISIL:SUP1"}},
 	}
 	factory := NewLookupAdapterFactory(mockRepo, new(adapter.MockDirectoryLookupAdapter), "", holdingsAdapter, nil)
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory, nil)
 
 	status, _ := locator.locateSuppliers(appCtx, events.Event{IllTransactionID: "ill-1"})
 
@@ -916,7 +917,7 @@ func TestLocateSuppliersMetadataAutoWithoutIdentifierMerges(t *testing.T) {
 		Holdings: []catalog.Holding{{Symbol: "ISIL:SUP1"}},
 	}
 	factory := NewLookupAdapterFactory(mockRepo, new(adapter.MockDirectoryLookupAdapter), "", holdingsAdapter, nil)
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory, nil)
 
 	status, _ := locator.locateSuppliers(appCtx, events.Event{IllTransactionID: "ill-1"})
 
@@ -943,7 +944,7 @@ func TestLocateSuppliersMetadataLookupError(t *testing.T) {
 		Err: errors.New("metadata lookup failed"),
 	}
 	factory := NewLookupAdapterFactory(mockRepo, new(adapter.MockDirectoryLookupAdapter), "", holdingsAdapter, nil)
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory, nil)
 
 	status, _ := locator.locateSuppliers(appCtx, events.Event{IllTransactionID: "ill-1"})
 
@@ -968,9 +969,174 @@ func TestLocateSuppliersMetadataSaveTransactionError(t *testing.T) {
 		Metadata: catalog.Metadata{Title: "Catalog Title"},
 	}
 	factory := NewLookupAdapterFactory(mockRepo, new(adapter.MockDirectoryLookupAdapter), "", holdingsAdapter, nil)
-	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory)
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory, nil)
 
 	status, _ := locator.locateSuppliers(appCtx, events.Event{IllTransactionID: "ill-1"})
 
 	assert.Equal(t, events.EventStatusError, status)
 }
+
+type MockIllRepoCheckAvailability struct {
+	mocks.MockIllRepositorySuccess
+	supplier       ill_db.LocatedSupplier
+	peer           ill_db.Peer
+	savedSuppliers []ill_db.SaveLocatedSupplierParams
+}
+
+func (r *MockIllRepoCheckAvailability) GetLocatedSuppliersByIllTransactionAndStatus(ctx common.ExtendedContext, params ill_db.GetLocatedSuppliersByIllTransactionAndStatusParams) ([]ill_db.LocatedSupplier, error) {
+	return []ill_db.LocatedSupplier{r.supplier}, nil
+}
+
+func (r *MockIllRepoCheckAvailability) GetPeerById(ctx common.ExtendedContext, id string) (ill_db.Peer, error) {
+	return r.peer, nil
+}
+
+func (r *MockIllRepoCheckAvailability) SaveLocatedSupplier(ctx common.ExtendedContext, params ill_db.SaveLocatedSupplierParams) (ill_db.LocatedSupplier, error) {
+	r.savedSuppliers = append(r.savedSuppliers, params)
+	return ill_db.LocatedSupplier(params), nil
+}
+
+type mockLmsCreatorLookupItem struct {
+	adapter lms.LmsAdapter
+	err     error
+}
+
+func (m *mockLmsCreatorLookupItem) GetAdapter(ctx common.ExtendedContext, symbol string) (lms.LmsAdapter, error) {
+	return m.adapter, m.err
+}
+
+type mockLmsAdapterLookupItem struct {
+	lms.LmsAdapterManual
+	bibIds   []string
+	statuses []string
+	err      error
+}
+
+func (m *mockLmsAdapterLookupItem) LookupItems(bibId string) ([]string, error) {
+	m.bibIds = append(m.bibIds, bibId)
+	return m.statuses, m.err
+}
+
+func checkAvailabilityLmsRepo(lookupItemEnabled *bool) *MockIllRepoCheckAvailability {
+	return &MockIllRepoCheckAvailability{
+		supplier: ill_db.LocatedSupplier{
+			ID:             "ls1",
+			SupplierID:     "p1",
+			SupplierSymbol: "ISIL:SUP",
+			SupplierStatus: ill_db.SupplierStateSelectedPg,
+			LocalID:        pgtype.Text{String: "bib-1", Valid: true},
+		},
+		peer: ill_db.Peer{
+			ID: "p1",
+			CustomData: dirapi.Entry{
+				LmsConfig: &dirapi.LmsConfig{
+					Address:           "http://localhost/ncip",
+					FromAgency:        "SUP",
+					LookupItemEnabled: lookupItemEnabled,
+				},
+			},
+		},
+	}
+}
+
+func TestCheckAvailabilityLmsAvailable(t *testing.T) {
+	enabled := true
+	mockRepo := checkAvailabilityLmsRepo(&enabled)
+	lmsAdapter := &mockLmsAdapterLookupItem{statuses: []string{"On Loan", "Available On Shelf"}}
+	factory := NewLookupAdapterFactory(mockRepo, new(adapter.MockDirectoryLookupAdapter), "", nil, new(catalog.LookupAdapterCreatorImpl))
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory, &mockLmsCreatorLookupItem{adapter: lmsAdapter})
+
+	status, result := locator.checkAvailability(appCtx, events.Event{IllTransactionID: "ill-1"})
+
+	assert.Equal(t, events.EventStatusSuccess, status)
+	assert.Equal(t, false, result.CustomData["skipped"])
+	assert.Equal(t, lms.AvailabilityAvailable, result.CustomData["availability"])
+	assert.Equal(t, []string{"bib-1"}, lmsAdapter.bibIds)
+	assert.Empty(t, mockRepo.savedSuppliers)
+}
+
+func TestCheckAvailabilityLmsOnLoan(t *testing.T) {
+	enabled := true
+	mockRepo := checkAvailabilityLmsRepo(&enabled)
+	lmsAdapter := &mockLmsAdapterLookupItem{statuses: []string{"On Loan"}}
+	factory := NewLookupAdapterFactory(mockRepo, new(adapter.MockDirectoryLookupAdapter), "", nil, new(catalog.LookupAdapterCreatorImpl))
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory, &mockLmsCreatorLookupItem{adapter: lmsAdapter})
+
+	status, result := locator.checkAvailability(appCtx, events.Event{IllTransactionID: "ill-1"})
+
+	assert.Equal(t, events.EventStatusSuccess, status)
+	assert.Equal(t, true, result.CustomData["skipped"])
+	assert.Equal(t, lms.AvailabilityUnavailable, result.CustomData["availability"])
+	assert.Equal(t, []string{"On Loan"}, result.CustomData["circulationStatuses"])
+	if assert.Len(t, mockRepo.savedSuppliers, 1) {
+		assert.Equal(t, ill_db.SupplierStateSkippedPg, mockRepo.savedSuppliers[0].SupplierStatus)
+	}
+}
+
+func TestCheckAvailabilityLmsSip2NotLookedUp(t *testing.T) {
+	mockRepo := checkAvailabilityLmsRepo(nil)
+	mockRepo.peer.CustomData = dirapi.Entry{
+		Sip2Config: &dirapi.Sip2Config{
+			Address:       "localhost:6001",
+			InstitutionId: "SUP",
+		},
+	}
+	lmsAdapter := &mockLmsAdapterLookupItem{statuses: []string{"On Loan"}}
+	factory := NewLookupAdapterFactory(mockRepo, new(adapter.MockDirectoryLookupAdapter), "", nil, new(catalog.LookupAdapterCreatorImpl))
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory, &mockLmsCreatorLookupItem{adapter: lmsAdapter})
+
+	status, result := locator.checkAvailability(appCtx, events.Event{IllTransactionID: "ill-1"})
+
+	assert.Equal(t, events.EventStatusSuccess, status)
+	assert.Equal(t, false, result.CustomData["skipped"])
+	assert.Nil(t, result.CustomData["availability"])
+	assert.Empty(t, lmsAdapter.bibIds)
+	assert.Empty(t, mockRepo.savedSuppliers)
+}
+
+func TestCheckAvailabilityLmsLookupError(t *testing.T) {
+	enabled := true
+	mockRepo := checkAvailabilityLmsRepo(&enabled)
+	lmsAdapter := &mockLmsAdapterLookupItem{err: errors.New("NCIP lookup item set failed")}
+	factory := NewLookupAdapterFactory(mockRepo, new(adapter.MockDirectoryLookupAdapter), "", nil, new(catalog.LookupAdapterCreatorImpl))
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory, &mockLmsCreatorLookupItem{adapter: lmsAdapter})
+
+	status, result := locator.checkAvailability(appCtx, events.Event{IllTransactionID: "ill-1"})
+
+	assert.Equal(t, events.EventStatusSuccess, status)
+	assert.Equal(t, false, result.CustomData["skipped"])
+	assert.Equal(t, lms.AvailabilityUnknown, result.CustomData["availability"])
+	assert.Empty(t, mockRepo.savedSuppliers)
+}
+
+func TestCheckAvailabilityLmsUnknownStatus(t *testing.T) {
+	enabled := true
+	mockRepo := checkAvailabilityLmsRepo(&enabled)
+	lmsAdapter := &mockLmsAdapterLookupItem{statuses: []string{"On Loan", "Circulation Status Undefined"}}
+	factory := NewLookupAdapterFactory(mockRepo, new(adapter.MockDirectoryLookupAdapter), "", nil, new(catalog.LookupAdapterCreatorImpl))
+	locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory, &mockLmsCreatorLookupItem{adapter: lmsAdapter})
+
+	status, result := locator.checkAvailability(appCtx, events.Event{IllTransactionID: "ill-1"})
+
+	assert.Equal(t, events.EventStatusSuccess, status)
+	assert.Equal(t, false, result.CustomData["skipped"])
+	assert.Equal(t, lms.AvailabilityUnknown, result.CustomData["availability"])
+	assert.Empty(t, mockRepo.savedSuppliers)
+}
+
+func TestCheckAvailabilityLmsDisabled(t *testing.T) {
+	disabled := false
+	for _, enabled := range []*bool{nil, &disabled} {
+		mockRepo := checkAvailabilityLmsRepo(enabled)
+		lmsAdapter := &mockLmsAdapterLookupItem{statuses: []string{"On Loan"}}
+		factory := NewLookupAdapterFactory(mockRepo, new(adapter.MockDirectoryLookupAdapter), "", nil, new(catalog.LookupAdapterCreatorImpl))
+		locator := CreateSupplierLocator(new(events.PostgresEventBus), mockRepo, new(adapter.MockDirectoryLookupAdapter), factory, &mockLmsCreatorLookupItem{adapter: lmsAdapter})
+
+		status, result := locator.checkAvailability(appCtx, events.Event{IllTransactionID: "ill-1"})
+
+		assert.Equal(t, events.EventStatusSuccess, status)
+		assert.Equal(t, false, result.CustomData["skipped"])
+		assert.Nil(t, result.CustomData["availability"])
+		assert.Empty(t, lmsAdapter.bibIds)
+	}
+}
@@ -40,10 +40,10 @@ func (w *WorkflowManager) RequestReceived(ctx common.ExtendedContext, event even
 	ctx = ctx.WithArgs(ctx.LoggerArgs().WithComponent(WF_COMP))
 	common.Must(ctx, func() (string, error) {
 		if event.EventData.CustomData[events.MUST_LOCATE] == true {
-			return w.eventBus.CreateTask(event.IllTransactionID, events.EventNameLocateSuppliers, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
+			return w.eventBus.CreateTask(ctx, event.IllTransactionID, events.EventNameLocateSuppliers, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
 		} else {
 			// call message supplier directly, skipping locate suppliers step
-			return w.eventBus.CreateTask(event.IllTransactionID, events.EventNameMessageSupplier, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
+			return w.eventBus.CreateTask(ctx, event.IllTransactionID, events.EventNameMessageSupplier, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
 		}
 	}, "")
 }
@@ -52,9 +52,9 @@ func (w *WorkflowManager) OnLocateSupplierComplete(ctx common.ExtendedContext, e
 	ctx = ctx.WithArgs(ctx.LoggerArgs().WithComponent(WF_COMP))
 	common.Must(ctx, func() (string, error) {
 		if event.EventStatus == events.EventStatusSuccess {
-			return w.eventBus.CreateTask(event.IllTransactionID, events.EventNameSelectSupplier, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
+			return w.eventBus.CreateTask(ctx, event.IllTransactionID, events.EventNameSelectSupplier, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
 		} else {
-			return w.eventBus.CreateTask(event.IllTransactionID, events.EventNameMessageRequester, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
+			return w.eventBus.CreateTask(ctx, event.IllTransactionID, events.EventNameMessageRequester, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
 		}
 	}, "")
 }
@@ -63,16 +63,16 @@ func (w *WorkflowManager) OnCheckAvailabilityComplete(ctx common.ExtendedContext
 	ctx = ctx.WithArgs(ctx.LoggerArgs().WithComponent(WF_COMP))
 	common.Must(ctx, func() (string, error) {
 		if event.EventStatus != events.EventStatusSuccess {
-			return w.eventBus.CreateTask(event.IllTransactionID, events.EventNameMessageRequester, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
+			return w.eventBus.CreateTask(ctx, event.IllTransactionID, events.EventNameMessageRequester, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
 		}
 		skipped, ok := event.ResultData.CustomData["skipped"].(bool)
 		if !ok {
 			return "", fmt.Errorf("failed to detect if supplier is skipped by availability check")
 		}
 		if skipped {
-			return w.eventBus.CreateTask(event.IllTransactionID, events.EventNameSelectSupplier, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
+			return w.eventBus.CreateTask(ctx, event.IllTransactionID, events.EventNameSelectSupplier, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
 		}
-		id, err := w.eventBus.CreateTask(event.IllTransactionID, events.EventNameMessageRequester, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
+		id, err := w.eventBus.CreateTask(ctx, event.IllTransactionID, events.EventNameMessageRequester, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
 		if err != nil {
 			return id, err
 		}
@@ -83,7 +83,7 @@ func (w *WorkflowManager) OnCheckAvailabilityComplete(ctx common.ExtendedContext
 		if local {
 			return "", nil
 		}
-		return w.eventBus.CreateTask(event.IllTransactionID, events.EventNameMessageSupplier, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
+		return w.eventBus.CreateTask(ctx, event.IllTransactionID, events.EventNameMessageSupplier, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
 	}, "")
 }
 
@@ -91,9 +91,9 @@ func (w *WorkflowManager) OnSelectSupplierComplete(ctx common.ExtendedContext, e
 	ctx = ctx.WithArgs(ctx.LoggerArgs().WithComponent(WF_COMP))
 	common.Must(ctx, func() (string, error) {
 		if event.EventStatus != events.EventStatusSuccess {
-			return w.eventBus.CreateTask(event.IllTransactionID, events.EventNameMessageRequester, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
+			return w.eventBus.CreateTask(ctx, event.IllTransactionID, events.EventNameMessageRequester, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
 		}
-		return w.eventBus.CreateTask(event.IllTransactionID, events.EventNameCheckAvailability, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
+		return w.eventBus.CreateTask(ctx, event.IllTransactionID, events.EventNameCheckAvailability, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
 	}, "")
 }
 
@@ -108,15 +108,15 @@ func (w *WorkflowManager) SupplierMessageReceived(ctx common.ExtendedContext, ev
 	}
 	if w.shouldForwardSAM(ctx, *event.EventData.IncomingMessage.SupplyingAgencyMessage, event.IllTransactionID) {
 		common.Must(ctx, func() (string, error) {
-			return w.eventBus.CreateTask(event.IllTransactionID, events.EventNameMessageRequester,
+			return w.eventBus.CreateTask(ctx, event.IllTransactionID, events.EventNameMessageRequester,
 				events.EventData{CommonEventData: events.CommonEventData{IncomingMessage: event.EventData.IncomingMessage}, CustomData: map[string]any{common.DO_NOT_SEND: !w.shouldForwardMessage(ctx, event)}}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
 		}, "")
 	} else {
 		common.Must(ctx, func() (string, error) {
-			return w.eventBus.CreateTask(event.IllTransactionID, events.EventNameConfirmSupplierMsg, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalObservers)
+			return w.eventBus.CreateTask(ctx, event.IllTransactionID, events.EventNameConfirmSupplierMsg, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalObservers)
 		}, "")
 		common.Must(ctx, func() (string, error) { // This will also send unfilled message if no more suppliers
-			return w.eventBus.CreateTask(event.IllTransactionID, events.EventNameSelectSupplier, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
+			return w.eventBus.CreateTask(ctx, event.IllTransactionID, events.EventNameSelectSupplier, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
 		}, "")
 	}
 }
@@ -130,7 +130,7 @@ func (w *WorkflowManager) RequesterMessageReceived(ctx common.ExtendedContext, e
 			w.skipAllSuppliersByStatus(ctx, event.IllTransactionID, ill_db.SupplierStateNewPg)
 		}
 		common.Must(ctx, func() (string, error) {
-			return w.eventBus.CreateTask(event.IllTransactionID, events.EventNameMessageSupplier,
+			return w.eventBus.CreateTask(ctx, event.IllTransactionID, events.EventNameMessageSupplier,
 				events.EventData{CommonEventData: events.CommonEventData{IncomingMessage: event.EventData.IncomingMessage}, CustomData: map[string]any{common.DO_NOT_SEND: !w.shouldForwardMessage(ctx, event)}}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
 		}, "")
 	}
@@ -154,12 +154,12 @@ func (w *WorkflowManager) OnMessageSupplierComplete(ctx common.ExtendedContext,
 	if event.EventData.IncomingMessage != nil && event.EventData.IncomingMessage.RequestingAgencyMessage != nil {
 		// action message was send by requester so we must relay the confirmation
 		common.Must(ctx, func() (string, error) {
-			return w.eventBus.CreateTask(event.IllTransactionID, events.EventNameConfirmRequesterMsg, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalObservers)
+			return w.eventBus.CreateTask(ctx, event.IllTransactionID, events.EventNameConfirmRequesterMsg, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalObservers)
 		}, "")
 	} else if event.EventStatus != events.EventStatusSuccess {
 		// if the last requester action was Request and messaging supplier failed, we try next supplier
 		common.Must(ctx, func() (string, error) {
-			return w.eventBus.CreateTask(event.IllTransactionID, events.EventNameSelectSupplier, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
+			return w.eventBus.CreateTask(ctx, event.IllTransactionID, events.EventNameSelectSupplier, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
 		}, "")
 	}
 }
@@ -239,11 +239,11 @@ func (w *WorkflowManager) OnMessageRequesterComplete(ctx common.ExtendedContext,
 		sam := *event.EventData.IncomingMessage.SupplyingAgencyMessage
 		// action message was send by supplier so we must relay the confirmation
 		common.Must(ctx, func() (string, error) {
-			return w.eventBus.CreateTask(event.IllTransactionID, events.EventNameConfirmSupplierMsg, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalObservers)
+			return w.eventBus.CreateTask(ctx, event.IllTransactionID, events.EventNameConfirmSupplierMsg, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalObservers)
 		}, "")
 		if supplierUnfilled(sam) && !supplierTerminalUnfilled(sam) {
 			common.Must(ctx, func() (string, error) {
-				return w.eventBus.CreateTask(event.IllTransactionID, events.EventNameSelectSupplier, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
+				return w.eventBus.CreateTask(ctx, event.IllTransactionID, events.EventNameSelectSupplier, events.EventData{}, events.EventDomainIllTransaction, &event.ID, events.SignalConsumers)
 			}, "")
 		}
 		if w.supplierAcceptedTerminalCancel(ctx, sam, event.IllTransactionID) {
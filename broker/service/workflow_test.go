@@ -507,7 +507,7 @@ type MockEventBus struct {
 	BroadcastCreated int
 }
 
-func (r *MockEventBus) CreateTask(illTransactionID string, eventName events.EventName, data events.EventData, eventClass events.EventDomain, parentId *string, target events.SignalTarget) (string, error) {
+func (r *MockEventBus) CreateTask(ctx common.ExtendedContext, illTransactionID string, eventName events.EventName, data events.EventData, eventClass events.EventDomain, parentId *string, target events.SignalTarget) (string, error) {
 	if target == events.SignalObservers {
 		r.BroadcastCreated++
 		return "id2", nil
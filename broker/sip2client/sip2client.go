@@ -0,0 +1,55 @@
+package sip2client
+
+import "time"
+
+type Sip2LogFunc func(outgoing map[string]any, incoming map[string]any, err error)
+
+type HoldMode string
+
+const (
+	HoldModeAdd    HoldMode = "+"
+	HoldModeDelete HoldMode = "-"
+	HoldModeChange HoldMode = "*"
+)
+
+// Sip2Client sends SIP2 messages to an Automated Circulation System (ACS).
+// All messages are sent on behalf of the configured institution and terminal.
+type Sip2Client interface {
+	SetLogFunc(logFunc Sip2LogFunc)
+
+	PatronInformation(patron string) (*Response, error)
+
+	Checkout(patron string, item string, nbDueDate time.Time) (*Response, error)
+
+	Checkin(item string) (*Response, error)
+
+	Renew(patron string, item string, nbDueDate time.Time) (*Response, error)
+
+	// Hold sends the pickup location in field BS, if not empty
+	Hold(mode HoldMode, patron string, item string, pickupLocation string) (*Response, error)
+}
+
+// Response is a SIP2 response split into command identifier, fixed-length fields and variable-length fields
+type Response struct {
+	Code   string
+	Fixed  string
+	Fields map[string]string
+}
+
+// Field returns the value of the variable-length field with the given identifier
+func (r *Response) Field(id string) string {
+	return r.Fields[id]
+}
+
+type Sip2Error struct {
+	Message       string
+	ScreenMessage string
+}
+
+func (e *Sip2Error) Error() string {
+	s := e.Message
+	if e.ScreenMessage != "" {
+		s += ": " + e.ScreenMessage
+	}
+	return s
+}
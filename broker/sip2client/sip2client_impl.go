@@ -0,0 +1,272 @@
+package sip2client
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DateLayout is the SIP2 18-character date format with a UTC time zone indicator
+const DateLayout = "20060102   Z150405"
+
+const defaultTimeout = 30 * time.Second
+
+// responseFixedLength is the length of the fixed part of each response, excluding the command code
+var responseFixedLength = map[string]int{
+	"94": 1,
+	"64": 59,
+	"12": 22,
+	"10": 22,
+	"18": 24,
+	"30": 22,
+	"16": 20,
+}
+
+// sensitiveFields are variable-length fields holding passwords
+var sensitiveFields = []string{"CO", "AC", "AD"}
+
+type Config struct {
+	Address          string
+	InstitutionId    string
+	LoginUserId      string
+	LoginPassword    string
+	LocationCode     string
+	TerminalPassword string
+	ErrorDetection   bool
+	Timeout          time.Duration
+}
+
+type Sip2ClientImpl struct {
+	config   Config
+	logFunc  Sip2LogFunc
+	mu       sync.Mutex
+	sequence int
+}
+
+func NewSip2Client(config Config) Sip2Client {
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+	return &Sip2ClientImpl{config: config}
+}
+
+func (s *Sip2ClientImpl) SetLogFunc(logFunc Sip2LogFunc) {
+	s.logFunc = logFunc
+}
+
+func (s *Sip2ClientImpl) PatronInformation(patron string) (*Response, error) {
+	msg := "63" + "001" + FormatDate(time.Now()) + strings.Repeat(" ", 10) +
+		field("AO", s.config.InstitutionId) + field("AA", patron) + field("AC", s.config.TerminalPassword)
+	response, err := s.send(msg, "64")
+	if err != nil {
+		return nil, err
+	}
+	if response.Field("BL") != "Y" {
+		return response, &Sip2Error{Message: "SIP2 patron information: invalid patron", ScreenMessage: response.Field("AF")}
+	}
+	return response, nil
+}
+
+func (s *Sip2ClientImpl) Checkout(patron string, item string, nbDueDate time.Time) (*Response, error) {
+	msg := "11" + "NN" + FormatDate(time.Now()) + FormatDate(nbDueDate) +
+		field("AO", s.config.InstitutionId) + field("AA", patron) + field("AB", item) + field("AC", s.config.TerminalPassword)
+	response, err := s.send(msg, "12")
+	if err != nil {
+		return nil, err
+	}
+	return response, checkOk("SIP2 checkout", response)
+}
+
+func (s *Sip2ClientImpl) Checkin(item string) (*Response, error) {
+	now := FormatDate(time.Now())
+	msg := "09" + "N" + now + now +
+		field("AP", s.config.LocationCode) + field("AO", s.config.InstitutionId) + field("AB", item) + field("AC", s.config.TerminalPassword)
+	response, err := s.send(msg, "10")
+	if err != nil {
+		return nil, err
+	}
+	return response, checkOk("SIP2 checkin", response)
+}
+
+func (s *Sip2ClientImpl) Renew(patron string, item string, nbDueDate time.Time) (*Response, error) {
+	msg := "29" + "NN" + FormatDate(time.Now()) + FormatDate(nbDueDate) +
+		field("AO", s.config.InstitutionId) + field("AA", patron) + field("AB", item) + field("AC", s.config.TerminalPassword)
+	response, err := s.send(msg, "30")
+	if err != nil {
+		return nil, err
+	}
+	return response, checkOk("SIP2 renew", response)
+}
+
+func (s *Sip2ClientImpl) Hold(mode HoldMode, patron string, item string, pickupLocation string) (*Response, error) {
+	msg := "15" + string(mode) + FormatDate(time.Now())
+	if pickupLocation != "" {
+		msg += field("BS", pickupLocation)
+	}
+	msg += field("AO", s.config.InstitutionId) + field("AA", patron) + field("AB", item) + field("AC", s.config.TerminalPassword)
+	response, err := s.send(msg, "16")
+	if err != nil {
+		return nil, err
+	}
+	return response, checkOk("SIP2 hold", response)
+}
+
+// FormatDate formats t as a SIP2 date in UTC. The zero time is formatted as blanks.
+func FormatDate(t time.Time) string {
+	if t.IsZero() {
+		return strings.Repeat(" ", len(DateLayout))
+	}
+	return t.UTC().Format(DateLayout)
+}
+
+// ParseDate parses a SIP2 date. A blank time zone is taken as local time.
+func ParseDate(value string) (time.Time, error) {
+	if len(value) != len(DateLayout) {
+		return time.Time{}, fmt.Errorf("invalid SIP2 date: %q", value)
+	}
+	zone := value[8:12]
+	value = value[:8] + value[12:]
+	loc := time.Local
+	if strings.TrimSpace(zone) == "Z" {
+		loc = time.UTC
+	} else if strings.TrimSpace(zone) != "" {
+		tz, err := time.LoadLocation(strings.TrimSpace(zone))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid SIP2 date time zone: %q", zone)
+		}
+		loc = tz
+	}
+	return time.ParseInLocation("20060102150405", value, loc)
+}
+
+func field(id string, value string) string {
+	return id + value + "|"
+}
+
+func checkOk(op string, response *Response) error {
+	if !strings.HasPrefix(response.Fixed, "1") {
+		return &Sip2Error{Message: op + " failed", ScreenMessage: response.Field("AF")}
+	}
+	return nil
+}
+
+// checksum returns the SIP2 checksum of msg, which must end with "AZ"
+func checksum(msg string) string {
+	var sum uint16
+	for i := 0; i < len(msg); i++ {
+		sum += uint16(msg[i])
+	}
+	return fmt.Sprintf("%04X", -sum)
+}
+
+func (s *Sip2ClientImpl) send(msg string, expectedCode string) (*Response, error) {
+	conn, err := net.DialTimeout("tcp", s.config.Address, s.config.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("SIP2 connect failed: %s", err.Error())
+	}
+	defer conn.Close()
+	err = conn.SetDeadline(time.Now().Add(s.config.Timeout))
+	if err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(conn)
+	if s.config.LoginUserId != "" {
+		login := "93" + "00" + field("CN", s.config.LoginUserId) + field("CO", s.config.LoginPassword)
+		if s.config.LocationCode != "" {
+			login += field("CP", s.config.LocationCode)
+		}
+		response, err := s.exchange(conn, reader, login, "94")
+		if err != nil {
+			return nil, err
+		}
+		if err = checkOk("SIP2 login", response); err != nil {
+			return nil, err
+		}
+	}
+	return s.exchange(conn, reader, msg, expectedCode)
+}
+
+func (s *Sip2ClientImpl) exchange(conn net.Conn, reader *bufio.Reader, msg string, expectedCode string) (*Response, error) {
+	if s.config.ErrorDetection {
+		msg += fmt.Sprintf("AY%dAZ", s.nextSequence())
+		msg += checksum(msg)
+	}
+	var line string
+	_, err := conn.Write([]byte(msg + "\r"))
+	if err == nil {
+		line, err = reader.ReadString('\r')
+		line = strings.Trim(line, "\r\n")
+	}
+	var response *Response
+	if err == nil {
+		response, err = s.parseResponse(line, expectedCode)
+	}
+	if s.logFunc != nil {
+		outgoing := map[string]any{"message": hideSensitive(msg)}
+		incoming := map[string]any{"message": line}
+		s.logFunc(outgoing, incoming, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("SIP2 message exchange failed: %s", err.Error())
+	}
+	return response, nil
+}
+
+// nextSequence returns the sequence number for error detection, which is shared by concurrent exchanges
+func (s *Sip2ClientImpl) nextSequence() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sequence := s.sequence
+	s.sequence = (s.sequence + 1) % 10
+	return sequence
+}
+
+func (s *Sip2ClientImpl) parseResponse(line string, expectedCode string) (*Response, error) {
+	if line == "96" {
+		return nil, fmt.Errorf("resend requested by ACS")
+	}
+	if s.config.ErrorDetection {
+		n := len(line)
+		if n < 11 || line[n-9:n-7] != "AY" || line[n-6:n-4] != "AZ" {
+			return nil, fmt.Errorf("missing checksum in response")
+		}
+		if checksum(line[:n-4]) != line[n-4:] {
+			return nil, fmt.Errorf("bad checksum in response")
+		}
+		line = line[:n-9]
+	}
+	if !strings.HasPrefix(line, expectedCode) {
+		return nil, fmt.Errorf("unexpected response code %q, expected %s", line[:min(2, len(line))], expectedCode)
+	}
+	length := responseFixedLength[expectedCode]
+	if len(line) < 2+length {
+		return nil, fmt.Errorf("response %s too short", expectedCode)
+	}
+	response := &Response{Code: expectedCode, Fixed: line[2 : 2+length], Fields: make(map[string]string)}
+	for _, f := range strings.Split(line[2+length:], "|") {
+		if len(f) < 2 {
+			continue
+		}
+		if _, ok := response.Fields[f[:2]]; !ok {
+			response.Fields[f[:2]] = f[2:]
+		}
+	}
+	return response, nil
+}
+
+// hideSensitive removes the password values from an outgoing message.
+// The first part holds the fixed-length fields and is left as is.
+func hideSensitive(msg string) string {
+	parts := strings.Split(msg, "|")
+	for i := 1; i < len(parts); i++ {
+		for _, id := range sensitiveFields {
+			if strings.HasPrefix(parts[i], id) && len(parts[i]) > len(id) {
+				parts[i] = id + "***"
+			}
+		}
+	}
+	return strings.Join(parts, "|")
+}
@@ -0,0 +1,216 @@
+package sip2client
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/indexdata/crosslink/illmock/sip2mock"
+	"github.com/stretchr/testify/assert"
+
+	test "github.com/indexdata/crosslink/broker/test/utils"
+)
+
+var mockAddr string
+
+func TestMain(m *testing.M) {
+	mock := sip2mock.CreateSip2Mock()
+	test.Expect(mock.Start("localhost:0"), "failed to start SIP2 mock")
+	mockAddr = mock.Addr()
+	code := m.Run()
+	mock.Shutdown()
+	os.Exit(code)
+}
+
+func createTestClient() Sip2Client {
+	return NewSip2Client(Config{
+		Address:          mockAddr,
+		InstitutionId:    "INST",
+		LoginUserId:      "user",
+		LoginPassword:    "pass",
+		LocationCode:     "MAIN",
+		TerminalPassword: "term",
+	})
+}
+
+func TestPatronInformationOK(t *testing.T) {
+	client := createTestClient()
+	res, err := client.PatronInformation("patron1")
+	assert.NoError(t, err)
+	assert.Equal(t, "64", res.Code)
+	assert.Equal(t, "patron1", res.Field("AA"))
+	assert.Equal(t, "Y", res.Field("BL"))
+}
+
+func TestPatronInformationInvalidPatron(t *testing.T) {
+	client := createTestClient()
+	_, err := client.PatronInformation("foo")
+	assert.Error(t, err)
+	assert.Equal(t, "SIP2 patron information: invalid patron: Unknown patron foo", err.Error())
+}
+
+func TestCheckoutOK(t *testing.T) {
+	client := createTestClient()
+	due := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	res, err := client.Checkout("patron1", "item1", due)
+	assert.NoError(t, err)
+	assert.Equal(t, "item1", res.Field("AB"))
+	dateDue, err := ParseDate(res.Field("AH"))
+	assert.NoError(t, err)
+	assert.True(t, due.Equal(dateDue))
+}
+
+func TestCheckoutUnknownItem(t *testing.T) {
+	client := createTestClient()
+	_, err := client.Checkout("patron1", "foo", time.Time{})
+	assert.Error(t, err)
+	assert.Equal(t, "SIP2 checkout failed: Unknown item foo", err.Error())
+}
+
+func TestCheckinOK(t *testing.T) {
+	client := createTestClient()
+	res, err := client.Checkin("item1")
+	assert.NoError(t, err)
+	assert.Equal(t, "MAIN", res.Field("AQ"))
+}
+
+func TestCheckinUnknownItem(t *testing.T) {
+	client := createTestClient()
+	_, err := client.Checkin("foo")
+	assert.Error(t, err)
+	assert.Equal(t, "SIP2 checkin failed: Unknown item foo", err.Error())
+}
+
+func TestRenew(t *testing.T) {
+	client := createTestClient()
+	due := time.Date(2030, 2, 3, 4, 5, 6, 0, time.UTC)
+	res, err := client.Renew("patron1", "item1", due)
+	assert.NoError(t, err)
+	assert.Equal(t, FormatDate(due), res.Field("AH"))
+
+	_, err = client.Renew("foo", "item1", due)
+	assert.Error(t, err)
+	assert.Equal(t, "SIP2 renew failed: Unknown patron foo", err.Error())
+}
+
+func TestHold(t *testing.T) {
+	client := createTestClient()
+	res, err := client.Hold(HoldModeAdd, "patron1", "item1", "Main Library")
+	assert.NoError(t, err)
+	assert.Equal(t, "Main Library", res.Field("BS"))
+	assert.Equal(t, "item1", res.Field("AB"))
+
+	res, err = client.Hold(HoldModeAdd, "patron1", "item1", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "", res.Field("BS"))
+
+	_, err = client.Hold(HoldModeAdd, "patron1", "foo", "")
+	assert.Error(t, err)
+	assert.Equal(t, "SIP2 hold failed: Unknown item foo", err.Error())
+}
+
+func TestLoginFailed(t *testing.T) {
+	client := NewSip2Client(Config{Address: mockAddr, InstitutionId: "INST", LoginUserId: "fuser"})
+	_, err := client.Checkin("item1")
+	assert.Error(t, err)
+	assert.Equal(t, "SIP2 login failed", err.Error())
+}
+
+func TestNoLogin(t *testing.T) {
+	client := NewSip2Client(Config{Address: mockAddr, InstitutionId: "INST"})
+	_, err := client.Checkin("item1")
+	assert.NoError(t, err)
+}
+
+func TestErrorDetection(t *testing.T) {
+	client := NewSip2Client(Config{Address: mockAddr, InstitutionId: "INST", LoginUserId: "user", ErrorDetection: true})
+	for i := 0; i < 12; i++ {
+		_, err := client.Checkin("item1")
+		assert.NoError(t, err)
+	}
+}
+
+func TestErrorDetectionConcurrent(t *testing.T) {
+	client := NewSip2Client(Config{Address: mockAddr, InstitutionId: "INST", LoginUserId: "user", ErrorDetection: true})
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Checkin("item1")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	// each call exchanges a login and a checkin message
+	assert.Equal(t, 2*12%10, client.(*Sip2ClientImpl).sequence)
+}
+
+func TestConnectFailed(t *testing.T) {
+	client := NewSip2Client(Config{Address: "localhost:1", Timeout: time.Second})
+	_, err := client.Checkin("item1")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "SIP2 connect failed")
+}
+
+func TestLogFunc(t *testing.T) {
+	client := createTestClient()
+	var outgoing []map[string]any
+	var incoming []map[string]any
+	client.SetLogFunc(func(out map[string]any, in map[string]any, err error) {
+		assert.NoError(t, err)
+		outgoing = append(outgoing, out)
+		incoming = append(incoming, in)
+	})
+	_, err := client.Checkin("item1")
+	assert.NoError(t, err)
+	assert.Len(t, outgoing, 2)
+	assert.Equal(t, "9300CNuser|CO***|CPMAIN|", outgoing[0]["message"])
+	assert.Equal(t, "941", incoming[0]["message"])
+	assert.Contains(t, outgoing[1]["message"], "|ABitem1|AC***|")
+}
+
+func TestParseResponse(t *testing.T) {
+	client := &Sip2ClientImpl{}
+	_, err := client.parseResponse("96", "18")
+	assert.Equal(t, "resend requested by ACS", err.Error())
+	_, err = client.parseResponse("12", "18")
+	assert.Equal(t, "unexpected response code \"12\", expected 18", err.Error())
+	_, err = client.parseResponse("1803", "18")
+	assert.Equal(t, "response 18 too short", err.Error())
+	res, err := client.parseResponse("941|AFfirst|AFsecond|", "94")
+	assert.NoError(t, err)
+	assert.Equal(t, "first", res.Field("AF"))
+
+	client.config.ErrorDetection = true
+	_, err = client.parseResponse("941", "94")
+	assert.Equal(t, "missing checksum in response", err.Error())
+	_, err = client.parseResponse("941AY0AZ0000", "94")
+	assert.Equal(t, "bad checksum in response", err.Error())
+}
+
+func TestDates(t *testing.T) {
+	assert.Equal(t, "                  ", FormatDate(time.Time{}))
+	date := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.Equal(t, "20300102   Z030405", FormatDate(date))
+
+	parsed, err := ParseDate("20300102   Z030405")
+	assert.NoError(t, err)
+	assert.True(t, date.Equal(parsed))
+
+	parsed, err = ParseDate("20300102    030405")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Local, parsed.Location())
+
+	_, err = ParseDate("2030")
+	assert.Equal(t, "invalid SIP2 date: \"2030\"", err.Error())
+
+	_, err = ParseDate("20300102 XYZ030405")
+	assert.Equal(t, "invalid SIP2 date time zone: \" XYZ\"", err.Error())
+}
+
+func TestSip2Error(t *testing.T) {
+	err := &Sip2Error{Message: "failed"}
+	assert.Equal(t, "failed", err.Error())
+}
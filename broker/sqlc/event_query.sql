@@ -79,13 +79,12 @@ ORDER BY timestamp DESC;
 
 -- name: SaveEvent :one
 INSERT INTO event (
-    id, timestamp, ill_transaction_id, parent_id, event_type, event_name, event_status, event_data, result_data, last_signal, patron_request_id
+    id, timestamp, ill_transaction_id, parent_id, event_type, event_name, event_status, event_data, result_data, last_signal, patron_request_id, trace_parent
 ) VALUES (
-             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
          )
-ON CONFLICT (id) DO UPDATE
-    SET timestamp = EXCLUDED.timestamp,
-    ill_transaction_id = EXCLUDED.ill_transaction_id,
+ON CONFLICT (id, timestamp) DO UPDATE
+    SET ill_transaction_id = EXCLUDED.ill_transaction_id,
     parent_id = EXCLUDED.parent_id,
     event_name = EXCLUDED.event_name,
     event_type = EXCLUDED.event_type,
@@ -93,7 +92,8 @@ ON CONFLICT (id) DO UPDATE
     event_data = EXCLUDED.event_data,
     result_data = EXCLUDED.result_data,
     last_signal = EXCLUDED.last_signal,
-    patron_request_id = EXCLUDED.patron_request_id
+    patron_request_id = EXCLUDED.patron_request_id,
+    trace_parent = EXCLUDED.trace_parent
 RETURNING sqlc.embed(event);
 
 -- name: DeleteEvent :exec
@@ -157,3 +157,159 @@ SELECT sqlc.embed(event) FROM event
 WHERE ill_transaction_id = sqlc.arg(IllTransactionID) AND event_name = 'requester-msg-received' AND
     (event_data -> 'incomingMessage' -> 'requestingAgencyMessage' ->> 'action')::text = sqlc.arg(Action)::text
 ORDER BY timestamp DESC LIMIT 1;
+
+-- name: AcquireEventLease :one
+INSERT INTO event_lease (
+    event_id, owner, expires_at
+) VALUES (
+             $1, $2, $3
+         )
+ON CONFLICT (event_id) DO UPDATE
+    SET owner = EXCLUDED.owner,
+    expires_at = EXCLUDED.expires_at,
+    attempts = event_lease.attempts + 1
+RETURNING sqlc.embed(event_lease);
+
+-- name: RenewEventLease :one
+UPDATE event_lease SET expires_at = $3
+WHERE event_id = $1 AND owner = $2
+RETURNING sqlc.embed(event_lease);
+
+-- name: GetEventLease :one
+SELECT sqlc.embed(event_lease) FROM event_lease
+WHERE event_id = $1;
+
+-- name: DeleteEventLease :exec
+DELETE FROM event_lease
+WHERE event_id = $1;
+
+-- name: ReleaseEventLease :execrows
+DELETE FROM event_lease
+WHERE event_id = $1 AND owner = $2;
+
+-- name: ListStuckEvents :many
+SELECT sqlc.embed(event),
+       event_lease.owner AS lease_owner,
+       event_lease.expires_at AS lease_expires_at,
+       COALESCE(event_lease.attempts, 0)::int AS lease_attempts,
+       COUNT(*) OVER () as full_count
+FROM event
+    LEFT JOIN event_lease ON event_lease.event_id = event.id
+WHERE event.event_type = 'TASK'
+  AND event.event_status = 'PROCESSING'
+  AND (event_lease.expires_at IS NULL OR event_lease.expires_at < sqlc.arg(expired_before))
+ORDER BY event.timestamp, event.id
+LIMIT $1 OFFSET $2;
+
+-- name: ListFailedEvents :many
+SELECT sqlc.embed(event),
+       get_event_domain(event.ill_transaction_id, event.patron_request_id)::text AS domain,
+       get_event_owner(event.ill_transaction_id, event.patron_request_id, event.event_data)::text AS owner,
+       COUNT(*) OVER () as full_count
+FROM event
+    LEFT JOIN event_resolution ON event_resolution.event_id = event.id
+WHERE event.event_type = 'TASK'
+  AND event.event_status IN ('ERROR', 'PROBLEM')
+  AND event_resolution.event_id IS NULL
+  AND (sqlc.narg(event_name)::text IS NULL OR event.event_name = sqlc.narg(event_name)::text)
+  AND (sqlc.narg(domain)::text IS NULL OR get_event_domain(event.ill_transaction_id, event.patron_request_id) = sqlc.narg(domain)::text)
+  AND (sqlc.narg(owner)::text IS NULL OR get_event_owner(event.ill_transaction_id, event.patron_request_id, event.event_data) = sqlc.narg(owner)::text)
+  AND (sqlc.narg(created_before)::timestamp IS NULL OR event.timestamp < sqlc.narg(created_before)::timestamp)
+  AND (sqlc.narg(created_after)::timestamp IS NULL OR event.timestamp >= sqlc.narg(created_after)::timestamp)
+ORDER BY event.timestamp, event.id
+LIMIT $1 OFFSET $2;
+
+-- name: GetEventResolution :one
+SELECT sqlc.embed(event_resolution) FROM event_resolution
+WHERE event_id = $1;
+
+-- name: SaveEventResolution :one
+INSERT INTO event_resolution (
+    event_id, resolution, note, rerun_event_id, resolved_at
+) VALUES (
+             $1, $2, $3, $4, $5
+         )
+RETURNING sqlc.embed(event_resolution);
+
+-- name: TryEventMaintenanceLock :one
+SELECT pg_try_advisory_xact_lock(8372910467);
+
+-- name: CreateEventPartition :one
+SELECT create_event_partition($1)::varchar;
+
+-- name: ListEventPartitions :many
+SELECT partition_name::varchar, range_start::timestamp, range_end::timestamp
+FROM list_event_partitions();
+
+-- name: DropEventPartition :exec
+SELECT drop_event_partition($1);
+
+-- name: ListEventsBetween :many
+SELECT sqlc.embed(event) FROM event
+WHERE event.timestamp >= sqlc.arg(from_time) AND event.timestamp < sqlc.arg(to_time)
+  AND (event.timestamp, event.id) > (sqlc.arg(after_timestamp)::timestamp, sqlc.arg(after_id)::varchar)
+ORDER BY event.timestamp, event.id
+LIMIT sqlc.arg(max_rows);
+
+-- name: DeleteExpiredEvents :many
+DELETE FROM event
+WHERE event.timestamp < sqlc.arg(before)
+  AND event.id IN (SELECT e.id FROM event e
+                   WHERE e.timestamp < sqlc.arg(before)
+                     AND (sqlc.narg(domain)::text IS NULL OR get_event_domain(e.ill_transaction_id, e.patron_request_id) = sqlc.narg(domain)::text)
+                     AND (sqlc.narg(event_status)::text IS NULL OR e.event_status = sqlc.narg(event_status)::text)
+                   ORDER BY e.timestamp
+                   LIMIT sqlc.arg(max_rows))
+RETURNING sqlc.embed(event);
+
+-- name: SaveEventSignal :one
+INSERT INTO event_signal (
+    event_id, signal, target, created_at
+) VALUES (
+             $1, $2, $3, $4
+         )
+RETURNING sqlc.embed(event_signal);
+
+-- name: GetLatestEventSignalId :one
+SELECT COALESCE(MAX(id), 0)::bigint AS id FROM event_signal;
+
+-- name: ListEventSignalsAfter :many
+SELECT sqlc.embed(event_signal) FROM event_signal
+WHERE id > $1
+ORDER BY id
+LIMIT $2;
+
+-- name: ListUnclaimedEventSignals :many
+SELECT sqlc.embed(event_signal)
+FROM event_signal
+    JOIN event ON event.id = event_signal.event_id
+WHERE event_signal.target IN ('consumers', 'all')
+  AND event.last_signal = event_signal.signal
+  AND event_signal.created_at < sqlc.arg(created_before)
+ORDER BY event_signal.id
+LIMIT $1;
+
+-- name: DeleteEventSignalsBefore :exec
+DELETE FROM event_signal
+WHERE created_at < $1;
+
+-- name: SaveSseMessage :one
+INSERT INTO sse_message (
+    event_id, receiver, message, created_at
+) VALUES (
+             $1, $2, $3, $4
+         )
+ON CONFLICT (event_id, receiver) DO UPDATE SET event_id = EXCLUDED.event_id
+RETURNING sqlc.embed(sse_message);
+
+-- name: ListSseMessagesAfter :many
+SELECT sqlc.embed(sse_message) FROM sse_message
+WHERE receiver = $1
+  AND id > $2
+  AND created_at >= sqlc.arg(created_after)
+ORDER BY id
+LIMIT $3;
+
+-- name: DeleteSseMessagesBefore :exec
+DELETE FROM sse_message
+WHERE created_at < $1;
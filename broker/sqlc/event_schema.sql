@@ -7,7 +7,7 @@ CREATE TABLE event_config
 
 CREATE TABLE event
 (
-    id                 VARCHAR PRIMARY KEY,
+    id                 VARCHAR   NOT NULL,
     timestamp          TIMESTAMP NOT NULL,
     ill_transaction_id VARCHAR   NOT NULL,
     parent_id          VARCHAR,
@@ -18,7 +18,108 @@ CREATE TABLE event
     result_data        jsonb,
     last_signal        VARCHAR   NOT NULL,
     patron_request_id  VARCHAR   NOT NULL DEFAULT '00000000-0000-0000-0000-000000000002',
+    trace_parent       VARCHAR   NOT NULL DEFAULT '',
+    PRIMARY KEY (id, timestamp),
     FOREIGN KEY (ill_transaction_id) REFERENCES ill_transaction (id) ON DELETE CASCADE,
     FOREIGN KEY (patron_request_id) REFERENCES patron_request (id) ON DELETE CASCADE,
     FOREIGN KEY (event_name) REFERENCES event_config (event_name)
+) PARTITION BY RANGE (timestamp);
+
+CREATE TABLE event_key
+(
+    id        VARCHAR PRIMARY KEY,
+    timestamp TIMESTAMP NOT NULL
+);
+
+CREATE OR REPLACE FUNCTION create_event_partition(p_month TIMESTAMP) RETURNS VARCHAR AS $$
+DECLARE
+    v_start TIMESTAMP := date_trunc('month', p_month);
+    v_name  VARCHAR   := 'event_p' || to_char(v_start, 'YYYYMM');
+BEGIN
+    PERFORM pg_advisory_xact_lock(8372910466);
+    IF to_regclass(v_name) IS NULL THEN
+        EXECUTE format('CREATE TABLE %I PARTITION OF event FOR VALUES FROM (%L) TO (%L)',
+                       v_name, v_start, v_start + INTERVAL '1 month');
+    END IF;
+    RETURN v_name;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION list_event_partitions()
+    RETURNS TABLE (partition_name VARCHAR, range_start TIMESTAMP, range_end TIMESTAMP) AS $$
+SELECT c.relname::VARCHAR,
+       to_date(substring(c.relname FROM 8), 'YYYYMM')::TIMESTAMP,
+       to_date(substring(c.relname FROM 8), 'YYYYMM') + INTERVAL '1 month'
+FROM pg_inherits i
+         JOIN pg_class c ON c.oid = i.inhrelid
+WHERE i.inhparent = 'event'::regclass
+  AND c.relname ~ '^event_p[0-9]{6}$'
+ORDER BY c.relname;
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION drop_event_partition(p_name VARCHAR) RETURNS VOID AS $$
+BEGIN
+    IF p_name !~ '^event_p[0-9]{6}$' THEN
+        RAISE EXCEPTION 'not an event partition: %', p_name;
+    END IF;
+    EXECUTE format('DELETE FROM event_key WHERE id IN (SELECT id FROM %I)', p_name);
+    EXECUTE format('DROP TABLE %I', p_name);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TABLE event_lease
+(
+    event_id   VARCHAR PRIMARY KEY,
+    owner      VARCHAR   NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    attempts   INT       NOT NULL DEFAULT 1,
+    FOREIGN KEY (event_id) REFERENCES event_key (id) ON DELETE CASCADE
+);
+
+CREATE TABLE event_resolution
+(
+    event_id       VARCHAR PRIMARY KEY,
+    resolution     VARCHAR   NOT NULL,
+    note           TEXT,
+    rerun_event_id VARCHAR,
+    resolved_at    TIMESTAMP NOT NULL,
+    FOREIGN KEY (event_id) REFERENCES event_key (id) ON DELETE CASCADE
+);
+
+CREATE OR REPLACE FUNCTION get_event_domain(ill_id VARCHAR, pr_id VARCHAR) RETURNS VARCHAR AS $$
+SELECT CASE
+           WHEN pr_id <> '00000000-0000-0000-0000-000000000002' THEN 'PATRON_REQUEST'
+           WHEN ill_id <> '00000000-0000-0000-0000-000000000001' THEN 'ILL_TRANSACTION'
+           ELSE 'SCHEDULER'
+           END;
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION get_event_owner(ill_id VARCHAR, pr_id VARCHAR, data jsonb) RETURNS VARCHAR AS $$
+SELECT COALESCE(
+               (SELECT CASE WHEN pr.side = 'lending' THEN pr.supplier_symbol ELSE pr.requester_symbol END
+                FROM patron_request pr
+                WHERE pr.id = pr_id),
+               (SELECT it.requester_symbol FROM ill_transaction it WHERE it.id = ill_id),
+               data -> 'batchActionData' ->> 'owner',
+               '');
+$$ LANGUAGE sql STABLE;
+
+CREATE TABLE event_signal
+(
+    id         BIGSERIAL PRIMARY KEY,
+    event_id   VARCHAR   NOT NULL,
+    signal     VARCHAR   NOT NULL,
+    target     VARCHAR   NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (event_id) REFERENCES event_key (id) ON DELETE CASCADE
+);
+
+CREATE TABLE sse_message
+(
+    id         BIGSERIAL PRIMARY KEY,
+    event_id   VARCHAR,
+    receiver   VARCHAR   NOT NULL,
+    message    TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (event_id, receiver)
 );
@@ -61,13 +61,15 @@ SET ill_request         = $3,
     next_req_id         = $21,
     prev_req_id         = $22,
     retry_bib_info       = $23,
-    state_model          = $24
+    state_model          = $24,
+    patron_language      = $25,
+    patron_channels      = $26
 WHERE id = $1 AND created_at = $2 AND (updated_at is null OR updated_at = $18)
 RETURNING sqlc.embed(patron_request);
 
 -- name: CreatePatronRequest :one
-INSERT INTO patron_request (id, created_at, ill_request, state, side, patron, requester_symbol, supplier_symbol, tenant, requester_req_id, needs_attention, last_action, last_action_outcome, last_action_result, items, language, terminal_state, updated_at, ill_response, internal_note, next_req_id, prev_req_id, retry_bib_info, state_model)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24)
+INSERT INTO patron_request (id, created_at, ill_request, state, side, patron, requester_symbol, supplier_symbol, tenant, requester_req_id, needs_attention, last_action, last_action_outcome, last_action_result, items, language, terminal_state, updated_at, ill_response, internal_note, next_req_id, prev_req_id, retry_bib_info, state_model, patron_language, patron_channels)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26)
 RETURNING sqlc.embed(patron_request);
 
 -- name: UpdatePatronRequestInternalNote :exec
@@ -166,8 +168,8 @@ FROM notification
 WHERE id = $1;
 
 -- name: SaveTemplate :one
-INSERT INTO template (id, owner, title, purpose, subject, body, content_type, labels, audience, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+INSERT INTO template (id, owner, title, purpose, subject, body, content_type, labels, audience, created_at, updated_at, language)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 ON CONFLICT (id) DO UPDATE
     SET title        = EXCLUDED.title,
         purpose      = EXCLUDED.purpose,
@@ -176,6 +178,7 @@ ON CONFLICT (id) DO UPDATE
         content_type = EXCLUDED.content_type,
         labels       = EXCLUDED.labels,
         audience     = EXCLUDED.audience,
+        language     = EXCLUDED.language,
         updated_at   = now()
 RETURNING sqlc.embed(template);
 
@@ -202,13 +205,51 @@ WHERE id = $1 AND owner = $2;
 -- Finds the best-matching template for a given owner, purpose, and label.
 -- Audience is optional: a template with a NULL audience matches any requested audience.
 -- When multiple templates match, prefer one with a specific audience over a NULL audience.
+-- Language is matched against the preferred languages in order; a template with a NULL
+-- language is the default variant used when none of the preferred languages is available.
 SELECT sqlc.embed(template)
 FROM template
 WHERE owner = $1
   AND purpose = $2
   AND labels @> ARRAY[sqlc.arg(label)::text]
   AND (audience IS NULL OR audience = sqlc.arg(audience)::text)
+  AND (language IS NULL OR language = ANY(sqlc.arg(languages)::text[]))
 ORDER BY
+    array_position(sqlc.arg(languages)::text[], language::text) NULLS LAST,
     CASE WHEN audience IS NOT NULL THEN 0 ELSE 1 END,
     created_at
 LIMIT 1;
+
+-- name: SaveOutboxMessage :one
+INSERT INTO outbox_message (id, notification_id, owner, from_address, recipients, message, status, next_attempt_at, created_at, channel)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING sqlc.embed(outbox_message);
+
+-- name: ClaimDueOutboxMessages :many
+UPDATE outbox_message
+SET next_attempt_at = now() + sqlc.arg(claim_for)::interval
+WHERE id IN (SELECT id
+             FROM outbox_message
+             WHERE status = 'queued'
+               AND next_attempt_at <= now()
+             ORDER BY next_attempt_at
+             LIMIT sqlc.arg(batch_size)
+             FOR UPDATE SKIP LOCKED)
+RETURNING sqlc.embed(outbox_message);
+
+-- name: UpdateOutboxMessage :one
+UPDATE outbox_message
+SET status          = $2,
+    attempts        = $3,
+    next_attempt_at = $4,
+    last_attempt_at = $5,
+    sent_at         = $6,
+    error           = $7
+WHERE id = $1
+RETURNING sqlc.embed(outbox_message);
+
+-- name: GetOutboxMessagesByNotificationIds :many
+SELECT sqlc.embed(outbox_message)
+FROM outbox_message
+WHERE notification_id = ANY(sqlc.arg(notification_ids)::text[])
+ORDER BY created_at;
@@ -24,7 +24,9 @@ CREATE TABLE patron_request
     next_req_id         VARCHAR,
     prev_req_id         VARCHAR,
     retry_bib_info       JSONB,
-    state_model         VARCHAR NOT NULL DEFAULT 'default'
+    state_model         VARCHAR NOT NULL DEFAULT 'default',
+    patron_language     VARCHAR,
+    patron_channels     TEXT[]
 );
 
 CREATE OR REPLACE FUNCTION get_next_hrid(prefix VARCHAR) RETURNS VARCHAR AS $$
@@ -62,6 +64,27 @@ CREATE TABLE notification
     acknowledged_at TIMESTAMP
 );
 
+CREATE TABLE outbox_message
+(
+    id              VARCHAR PRIMARY KEY,
+    notification_id VARCHAR REFERENCES notification (id) ON DELETE CASCADE,
+    owner           VARCHAR   NOT NULL,
+    from_address    VARCHAR   NOT NULL,
+    recipients      TEXT[]    NOT NULL,
+    message         BYTEA     NOT NULL,
+    status          VARCHAR   NOT NULL DEFAULT 'queued',
+    attempts        INT       NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP,
+    last_attempt_at TIMESTAMP,
+    sent_at         TIMESTAMP,
+    error           TEXT,
+    created_at      TIMESTAMP NOT NULL DEFAULT now(),
+    channel         VARCHAR   NOT NULL DEFAULT 'email'
+);
+
+CREATE INDEX idx_outbox_message_next_attempt_at ON outbox_message (next_attempt_at) WHERE status = 'queued';
+CREATE INDEX idx_outbox_message_notification_id ON outbox_message (notification_id);
+
 CREATE TABLE template
 (
     id           VARCHAR PRIMARY KEY,
@@ -74,7 +97,8 @@ CREATE TABLE template
     labels       TEXT[]    NOT NULL DEFAULT '{}',
     audience     VARCHAR,
     created_at   TIMESTAMP NOT NULL DEFAULT now(),
-    updated_at   TIMESTAMP NOT NULL DEFAULT now()
+    updated_at   TIMESTAMP NOT NULL DEFAULT now(),
+    language     VARCHAR
 );
 
 CREATE OR REPLACE FUNCTION immutable_to_timestamp(text)
@@ -116,7 +140,7 @@ SELECT
     EXISTS (
         SELECT 1
         FROM notification n
-        WHERE n.pr_id = pr.id
+        WHERE n.pr_id = pr.id and n.kind NOT IN ('email', 'reminder', 'sms', 'push')
     ) AS has_notification,
     EXISTS (
         SELECT 1
@@ -128,6 +152,7 @@ SELECT
     pr.ill_request -> 'serviceInfo' ->> 'serviceType' AS service_type,
     pr.ill_request -> 'serviceInfo' -> 'serviceLevel' ->> '#text' AS service_level,
     immutable_to_timestamp(pr.ill_request -> 'serviceInfo' ->> 'needBeforeDate') AS needed_at,
+    immutable_to_timestamp(pr.ill_response -> 'statusInfo' ->> 'dueDate') AS due_at,
     unread.unread_notifications_count AS unread_notifications_count,
     req_peer.name AS requester_name,
     sup_peer.name AS supplier_name
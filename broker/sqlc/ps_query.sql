@@ -1,12 +1,14 @@
 -- name: SavePullSlip :one
-INSERT INTO pull_slip (id, created_at, generated_at, type, owner, search_criteria, pdf_bytes)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
+INSERT INTO pull_slip (id, created_at, generated_at, type, owner, search_criteria, pdf_bytes, template_label, document)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 ON CONFLICT (id) DO UPDATE
     SET generated_at    = EXCLUDED.generated_at,
         type            = EXCLUDED.type,
         owner           = EXCLUDED.owner,
         search_criteria = EXCLUDED.search_criteria,
-        pdf_bytes       = EXCLUDED.pdf_bytes
+        pdf_bytes       = EXCLUDED.pdf_bytes,
+        template_label  = EXCLUDED.template_label,
+        document        = EXCLUDED.document
 RETURNING sqlc.embed(pull_slip);
 
 -- name: GetPullSlipByIdAndOwner :one
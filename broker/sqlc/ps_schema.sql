@@ -6,6 +6,8 @@ CREATE TABLE pull_slip
     type            VARCHAR     NOT NULL,
     owner           VARCHAR     NOT NULL,
     search_criteria VARCHAR     NOT NULL,
-    pdf_bytes       BYTEA
+    pdf_bytes       BYTEA,
+    template_label  VARCHAR,
+    document        VARCHAR     NOT NULL DEFAULT 'pullslip'
 );
 
@@ -1,6 +1,7 @@
 -- name: SaveScheduledTask :one
-INSERT INTO scheduled_task (id, event_name, schedule, action_data, title, run_at, status, owner, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT (id) DO
+INSERT INTO scheduled_task (id, event_name, schedule, action_data, title, run_at, status, owner, created_at, updated_at,
+                            misfire_policy)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) ON CONFLICT (id) DO
 UPDATE
     SET event_name = EXCLUDED.event_name,
     schedule = EXCLUDED.schedule,
@@ -9,6 +10,7 @@ UPDATE
     run_at = EXCLUDED.run_at,
     status = EXCLUDED.status,
     owner = EXCLUDED.owner,
+    misfire_policy = EXCLUDED.misfire_policy,
     updated_at = now()
     RETURNING sqlc.embed(scheduled_task);
 
@@ -66,3 +68,20 @@ DELETE
 FROM scheduled_task
 WHERE id = sqlc.arg(id)
   AND (sqlc.arg(owners)::text[] IS NULL OR owner = ANY(sqlc.arg(owners)::text[]));
+
+-- name: ClaimScheduledTaskRun :one
+INSERT INTO scheduled_task_run (task_id, scheduled_for)
+VALUES ($1, $2) ON CONFLICT (task_id, scheduled_for) DO NOTHING
+RETURNING sqlc.embed(scheduled_task_run);
+
+-- name: SetScheduledTaskRunEvent :exec
+UPDATE scheduled_task_run
+SET event_id = $3
+WHERE task_id = $1
+  AND scheduled_for = $2;
+
+-- name: DeleteScheduledTaskRunsBefore :exec
+DELETE
+FROM scheduled_task_run
+WHERE task_id = $1
+  AND scheduled_for < sqlc.arg(before);
@@ -10,6 +10,7 @@ CREATE TABLE scheduled_task
     owner       TEXT        NOT NULL,
     created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
     updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    misfire_policy TEXT NOT NULL DEFAULT 'run_once',
     FOREIGN KEY (event_name) REFERENCES event_config (event_name)
 );
 
@@ -17,3 +18,13 @@ CREATE INDEX idx_scheduled_task_run_at ON scheduled_task (run_at) WHERE status =
 
 CREATE INDEX idx_scheduled_task_id_owner ON scheduled_task (id, owner);
 CREATE INDEX idx_scheduled_task_owner ON scheduled_task (owner);
+
+CREATE TABLE scheduled_task_run
+(
+    task_id       TEXT        NOT NULL,
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    event_id      TEXT,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (task_id, scheduled_for),
+    FOREIGN KEY (task_id) REFERENCES scheduled_task (id) ON DELETE CASCADE
+);
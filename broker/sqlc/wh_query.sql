@@ -0,0 +1,81 @@
+-- name: SaveWebhookSubscription :one
+INSERT INTO webhook_subscription (id, owner, url, secret, event_names, side, states, active, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT (id) DO
+UPDATE
+    SET owner = EXCLUDED.owner,
+    url = EXCLUDED.url,
+    secret = EXCLUDED.secret,
+    event_names = EXCLUDED.event_names,
+    side = EXCLUDED.side,
+    states = EXCLUDED.states,
+    active = EXCLUDED.active,
+    updated_at = now()
+    RETURNING sqlc.embed(webhook_subscription);
+
+-- name: GetWebhookSubscriptionById :one
+SELECT sqlc.embed(webhook_subscription)
+FROM webhook_subscription
+WHERE id = sqlc.arg(id)
+  AND (sqlc.arg(owners)::text[] IS NULL OR owner = ANY(sqlc.arg(owners)::text[]));
+
+-- name: GetWebhookSubscriptions :many
+SELECT sqlc.embed(webhook_subscription), COUNT(*) OVER () as full_count
+FROM webhook_subscription
+WHERE sqlc.arg(owners)::text[] IS NULL OR owner = ANY(sqlc.arg(owners)::text[])
+ORDER BY created_at LIMIT $1
+OFFSET $2;
+
+-- name: DeleteWebhookSubscription :exec
+DELETE
+FROM webhook_subscription
+WHERE id = sqlc.arg(id)
+  AND (sqlc.arg(owners)::text[] IS NULL OR owner = ANY(sqlc.arg(owners)::text[]));
+
+-- name: GetMatchingWebhookSubscriptions :many
+SELECT sqlc.embed(webhook_subscription)
+FROM webhook_subscription
+WHERE active
+  AND owner = sqlc.arg(owner)
+  AND (cardinality(event_names) = 0 OR sqlc.arg(event_name)::text = ANY(event_names))
+  AND (side IS NULL OR side = sqlc.narg(side)::text)
+  AND (cardinality(states) = 0 OR sqlc.narg(state)::text = ANY(states))
+ORDER BY created_at;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_delivery (id, subscription_id, event_id, event_name, signal, payload, status, next_attempt_at, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (subscription_id, event_id, signal) DO NOTHING
+RETURNING sqlc.embed(webhook_delivery);
+
+-- name: ClaimDueWebhookDeliveries :many
+UPDATE webhook_delivery
+SET next_attempt_at = now() + sqlc.arg(claim_for)::interval
+WHERE id IN (SELECT id
+             FROM webhook_delivery
+             WHERE status = 'pending'
+               AND next_attempt_at <= now()
+             ORDER BY next_attempt_at
+    LIMIT sqlc.arg(batch_size)
+    FOR
+UPDATE SKIP LOCKED
+    )
+    RETURNING sqlc.embed(webhook_delivery);
+
+-- name: UpdateWebhookDelivery :one
+UPDATE webhook_delivery
+SET status          = $2,
+    attempts        = $3,
+    next_attempt_at = $4,
+    last_attempt_at = $5,
+    response_status = $6,
+    error           = $7
+WHERE id = $1
+    RETURNING sqlc.embed(webhook_delivery);
+
+-- name: GetWebhookDeliveries :many
+SELECT sqlc.embed(webhook_delivery), COUNT(*) OVER () as full_count
+FROM webhook_delivery
+WHERE subscription_id = sqlc.arg(subscription_id)
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+ORDER BY created_at DESC, id LIMIT $1
+OFFSET $2;
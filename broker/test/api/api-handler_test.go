@@ -45,7 +45,7 @@ var eventRepo events.EventRepo
 var sseBroker *api.SseBroker
 var mockIllRepoError = new(mocks.MockIllRepositoryError)
 var mockEventRepoError = new(mocks.MockEventRepositoryError)
-var handlerMock = api.NewApiHandler(mockEventRepoError, mockIllRepoError, tenant.NewResolver(), api.LIMIT_DEFAULT)
+var handlerMock = api.NewApiHandler(mockEventRepoError, nil, mockIllRepoError, tenant.NewResolver(), api.LIMIT_DEFAULT)
 
 func TestMain(m *testing.M) {
 	app.TENANT_TO_SYMBOL = "ISIL:DK-{tenant}"
@@ -68,6 +68,7 @@ func TestMain(m *testing.M) {
 	app.ConnectionString = connStr
 	app.MigrationsFolder = "file://../../migrations"
 	app.HTTP_PORT = utils.Must(test.GetFreePort())
+	app.TASK_SWEEP_INTERVAL = 0
 
 	ctx, cancel := context.WithCancel(context.Background())
 	appContext := apptest.StartAppReturnContext(ctx)
@@ -918,6 +919,163 @@ func TestPostArchiveIllTransactions(t *testing.T) {
 	assert.Equal(t, illId3, illTr.ID)
 }
 
+func TestStuckEvents(t *testing.T) {
+	illId := apptest.GetIllTransId(t, illRepo)
+	eventId := apptest.GetEventId(t, eventRepo, illId, events.EventTypeTask, events.EventStatusProcessing, events.EventNameLocateSuppliers)
+	activeId := apptest.GetEventId(t, eventRepo, illId, events.EventTypeTask, events.EventStatusProcessing, events.EventNameLocateSuppliers)
+	_, err := eventRepo.AcquireEventLease(common.CreateExtCtxWithArgs(context.Background(), nil), events.AcquireEventLeaseParams{
+		EventID:   activeId,
+		Owner:     "broker-1",
+		ExpiresAt: pgtype.Timestamp{Time: time.Now().Add(time.Hour), Valid: true},
+	})
+	assert.NoError(t, err)
+
+	body := getResponseBody(t, "/stuck_events?limit=100")
+	var resp oapi.StuckEvents
+	err = json.Unmarshal(body, &resp)
+	assert.NoError(t, err)
+	var ids []string
+	for _, item := range resp.Items {
+		ids = append(ids, item.Event.Id)
+	}
+	assert.Contains(t, ids, eventId)
+	assert.NotContains(t, ids, activeId)
+	assert.GreaterOrEqual(t, resp.About.Count, int64(1))
+
+	body = httpRequest(t, "POST", "/stuck_events/"+eventId+"/redrive", nil, "", http.StatusOK)
+	var event oapi.Event
+	err = json.Unmarshal(body, &event)
+	assert.NoError(t, err)
+	assert.Equal(t, eventId, event.Id)
+	assert.Equal(t, string(events.EventStatusNew), event.EventStatus)
+
+	body = httpRequest(t, "POST", "/stuck_events/"+eventId+"/redrive", nil, "", http.StatusBadRequest)
+	var errResp oapi.Error
+	err = json.Unmarshal(body, &errResp)
+	assert.NoError(t, err)
+	assert.Equal(t, "task is not stuck, event is TASK NEW", *errResp.Error)
+
+	body = httpRequest(t, "POST", "/stuck_events/"+activeId+"/redrive", nil, "", http.StatusBadRequest)
+	err = json.Unmarshal(body, &errResp)
+	assert.NoError(t, err)
+	assert.Contains(t, *errResp.Error, "lease held by broker-1")
+
+	httpRequest(t, "POST", "/stuck_events/"+uuid.NewString()+"/redrive", nil, "", http.StatusNotFound)
+}
+
+func TestGetStuckEventsDbError(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	rr := httptest.NewRecorder()
+	handlerMock.GetStuckEvents(rr, req, oapi.GetStuckEventsParams{})
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func TestFailedEvents(t *testing.T) {
+	illId := apptest.GetIllTransId(t, illRepo)
+	failedId := apptest.GetEventId(t, eventRepo, illId, events.EventTypeTask, events.EventStatusError, events.EventNameLocateSuppliers)
+	problemId := apptest.GetEventId(t, eventRepo, illId, events.EventTypeTask, events.EventStatusProblem, events.EventNameLocateSuppliers)
+	successId := apptest.GetEventId(t, eventRepo, illId, events.EventTypeTask, events.EventStatusSuccess, events.EventNameLocateSuppliers)
+
+	body := getResponseBody(t, "/failed_events?limit=1000&event_name=locate-suppliers&domain=ILL_TRANSACTION&max_age=1h")
+	var resp oapi.FailedEvents
+	err := json.Unmarshal(body, &resp)
+	assert.NoError(t, err)
+	var ids []string
+	for _, item := range resp.Items {
+		ids = append(ids, item.Event.Id)
+		assert.Equal(t, string(events.EventDomainIllTransaction), item.Domain)
+	}
+	assert.Contains(t, ids, failedId)
+	assert.Contains(t, ids, problemId)
+	assert.NotContains(t, ids, successId)
+
+	body = getResponseBody(t, "/failed_events?limit=1000&event_name=locate-suppliers&min_age=1h")
+	err = json.Unmarshal(body, &resp)
+	assert.NoError(t, err)
+	for _, item := range resp.Items {
+		assert.NotEqual(t, failedId, item.Event.Id)
+	}
+
+	body = httpRequest(t, "POST", "/failed_events/"+failedId+"/rerun", []byte(`{"note":"peer fixed"}`), "", http.StatusOK)
+	var rerun oapi.Event
+	err = json.Unmarshal(body, &rerun)
+	assert.NoError(t, err)
+	assert.NotEqual(t, failedId, rerun.Id)
+	assert.Equal(t, failedId, *rerun.ParentID)
+	assert.Equal(t, string(events.EventNameLocateSuppliers), rerun.EventName)
+
+	body = httpRequest(t, "POST", "/failed_events/"+failedId+"/resolve", nil, "", http.StatusBadRequest)
+	var errResp oapi.Error
+	err = json.Unmarshal(body, &errResp)
+	assert.NoError(t, err)
+	assert.Contains(t, *errResp.Error, "task is not failed, task was marked RERUN")
+
+	body = httpRequest(t, "POST", "/failed_events/"+problemId+"/resolve", []byte(`{"note":"handled by phone"}`), "", http.StatusOK)
+	var notice oapi.Event
+	err = json.Unmarshal(body, &notice)
+	assert.NoError(t, err)
+	assert.Equal(t, string(events.EventNameTaskResolved), notice.EventName)
+	assert.Equal(t, problemId, *notice.ParentID)
+
+	body = httpRequest(t, "POST", "/failed_events/"+successId+"/rerun", nil, "", http.StatusBadRequest)
+	err = json.Unmarshal(body, &errResp)
+	assert.NoError(t, err)
+	assert.Equal(t, "task is not failed, event is TASK SUCCESS", *errResp.Error)
+
+	httpRequest(t, "POST", "/failed_events/"+uuid.NewString()+"/rerun", nil, "", http.StatusNotFound)
+
+	body = getResponseBody(t, "/failed_events?limit=1000&event_name=locate-suppliers")
+	err = json.Unmarshal(body, &resp)
+	assert.NoError(t, err)
+	for _, item := range resp.Items {
+		assert.NotEqual(t, failedId, item.Event.Id)
+		assert.NotEqual(t, problemId, item.Event.Id)
+	}
+}
+
+func TestPostFailedEventsRerun(t *testing.T) {
+	illId := apptest.GetIllTransId(t, illRepo)
+	failedId := apptest.GetEventId(t, eventRepo, illId, events.EventTypeTask, events.EventStatusError, events.EventNameLocateSuppliers)
+
+	body := httpRequest(t, "POST", "/failed_events/rerun?limit=1000&event_name=locate-suppliers", nil, "", http.StatusOK)
+	var resp oapi.Events
+	err := json.Unmarshal(body, &resp)
+	assert.NoError(t, err)
+	var parents []string
+	for _, item := range resp.Items {
+		parents = append(parents, *item.ParentID)
+	}
+	assert.Contains(t, parents, failedId)
+	assert.Equal(t, int64(len(resp.Items)), resp.About.Count)
+
+	httpRequest(t, "POST", "/failed_events/rerun?min_age=2x", nil, "", http.StatusBadRequest)
+
+	body = httpRequest(t, "POST", "/failed_events/rerun", nil, "", http.StatusBadRequest)
+	var errResp oapi.Error
+	err = json.Unmarshal(body, &errResp)
+	assert.NoError(t, err)
+	assert.Equal(t, "at least one filter or all=true is required", *errResp.Error)
+	httpRequest(t, "POST", "/failed_events/rerun?all=false", nil, "", http.StatusBadRequest)
+
+	failedId = apptest.GetEventId(t, eventRepo, illId, events.EventTypeTask, events.EventStatusError, events.EventNameLocateSuppliers)
+	body = httpRequest(t, "POST", "/failed_events/rerun?limit=1000&all=true", nil, "", http.StatusOK)
+	resp = oapi.Events{}
+	err = json.Unmarshal(body, &resp)
+	assert.NoError(t, err)
+	parents = nil
+	for _, item := range resp.Items {
+		parents = append(parents, *item.ParentID)
+	}
+	assert.Contains(t, parents, failedId)
+}
+
+func TestGetFailedEventsDbError(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	rr := httptest.NewRecorder()
+	handlerMock.GetFailedEvents(rr, req, oapi.GetFailedEventsParams{})
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
 func TestPostArchiveIllTransactionsBadRequest(t *testing.T) {
 	body := httpRequest(t, "POST", "/archive_ill_transactions?archive_delay=2x&archive_status=LoanCompleted,CopyCompleted,Unfilled", nil, "", http.StatusBadRequest)
 	var resp oapi.Error
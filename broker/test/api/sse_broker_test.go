@@ -3,14 +3,17 @@ package api
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/indexdata/crosslink/broker/api"
 	"github.com/indexdata/crosslink/broker/common"
 	"github.com/indexdata/crosslink/broker/events"
 	"github.com/indexdata/crosslink/iso18626"
@@ -122,7 +125,69 @@ func TestSseEndpointNoTenant(t *testing.T) {
 	assert.Equal(t, "header X-Okapi-Tenant must be specified\n", string(bodyBytes))
 }
 
+func TestSseEndpointLastEventIdReplay(t *testing.T) {
+	symbol := "REPLAY" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	publishIsoMessage("e-"+symbol+"-1", symbol, "first")
+	publishIsoMessage("e-"+symbol+"-2", symbol, "second")
+	// the same event observed again, e.g. by another instance, is not stored twice
+	publishIsoMessage("e-"+symbol+"-1", symbol, "first")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, getLocalhostWithPort()+"/sse/events?side=borrowing&symbol=ISIL:"+symbol, nil)
+	assert.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "0")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	lines := make(chan string, 10)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if line := scanner.Text(); line != "" && !strings.HasPrefix(line, ":") {
+				lines <- line
+			}
+		}
+	}()
+	var ids []int64
+	var notes []string
+	for len(notes) < 2 {
+		select {
+		case line := <-lines:
+			if id, found := strings.CutPrefix(line, "id: "); found {
+				n, err := strconv.ParseInt(id, 10, 64)
+				assert.NoError(t, err)
+				ids = append(ids, n)
+			} else if data, found := strings.CutPrefix(line, "data: "); found {
+				var sseEvent api.SseIsoMessageEvent
+				assert.NoError(t, json.Unmarshal([]byte(data), &sseEvent))
+				notes = append(notes, sseEvent.Data.SupplyingAgencyMessage.MessageInfo.Note)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for replayed messages")
+		}
+	}
+	assert.Equal(t, []string{"first", "second"}, notes)
+	assert.Len(t, ids, 2)
+	assert.Less(t, ids[0], ids[1])
+
+	// resuming after the first message only replays the second
+	req.Header.Set("Last-Event-ID", strconv.FormatInt(ids[0], 10))
+	resp2, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp2.Body.Close()
+	scanner := bufio.NewScanner(resp2.Body)
+	assert.True(t, scanner.Scan())
+	assert.Equal(t, "id: "+strconv.FormatInt(ids[1], 10), scanner.Text())
+}
+
 func executeTask(t time.Time) {
+	publishIsoMessage("", "REQ", t.String())
+}
+
+func publishIsoMessage(eventId string, symbol string, note string) {
 	ctx := common.CreateExtCtxWithArgs(context.Background(), nil)
 	var message = iso18626.NewISO18626Message()
 	message.SupplyingAgencyMessage = &iso18626.SupplyingAgencyMessage{
@@ -131,14 +196,14 @@ func executeTask(t time.Time) {
 				AgencyIdType: iso18626.TypeSchemeValuePair{
 					Text: "ISIL",
 				},
-				AgencyIdValue: "REQ",
+				AgencyIdValue: symbol,
 			},
 		},
 		MessageInfo: iso18626.MessageInfo{
-			Note: t.String(),
+			Note: note,
 		},
 	}
-	sseBroker.IncomingIsoMessage(ctx, events.Event{EventName: events.EventNameMessageRequester,
+	sseBroker.IncomingIsoMessage(ctx, events.Event{ID: eventId, EventName: events.EventNameMessageRequester,
 		ResultData: events.EventResult{
 			CommonEventData: events.CommonEventData{
 				OutgoingMessage: message,
@@ -525,7 +525,7 @@ func TestSendHttpPost(t *testing.T) {
 			peer := ill_db.Peer{
 				Url: tt.url,
 			}
-			result, err := isoClient.SendHttpPost(&peer, tt.msg)
+			result, err := isoClient.SendHttpPost(common.CreateExtCtxWithArgs(context.Background(), nil), &peer, tt.msg)
 
 			if tt.expectedError == "" && err != nil {
 				t.Fatalf("expected no error, got %v", err)
@@ -592,7 +592,7 @@ func requestLocallyAvailableSetup(t *testing.T, appCtx common.ExtendedContext, b
 func TestRequestLocallyAvailableRequesterMessage(t *testing.T) {
 	appCtx := common.CreateExtCtxWithArgs(context.Background(), nil)
 	illTrans := requestLocallyAvailableSetup(t, appCtx, common.BrokerModeOpaque)
-	_, err := eventBus.CreateNotice(illTrans.ID, events.EventNameRequesterMsgReceived, events.EventData{}, events.EventStatusSuccess, events.EventDomainIllTransaction, events.SignalConsumers)
+	_, err := eventBus.CreateNotice(appCtx, illTrans.ID, events.EventNameRequesterMsgReceived, events.EventData{}, events.EventStatusSuccess, events.EventDomainIllTransaction, events.SignalConsumers)
 	assert.NoError(t, err)
 	assert.Equal(t,
 		"NOTICE, request-received = SUCCESS\n"+
@@ -622,7 +622,7 @@ func TestRequestLocallyAvailableSupplierMessage(t *testing.T) {
 			Status: iso18626.TypeStatusLoaned,
 		},
 	}
-	_, err := eventBus.CreateNotice(illTrans.ID, events.EventNameSupplierMsgReceived, events.EventData{
+	_, err := eventBus.CreateNotice(appCtx, illTrans.ID, events.EventNameSupplierMsgReceived, events.EventData{
 		CommonEventData: events.CommonEventData{
 			IncomingMessage: message,
 		},
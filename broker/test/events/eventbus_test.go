@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -17,6 +18,7 @@ import (
 	"github.com/indexdata/crosslink/broker/dbutil"
 	"github.com/indexdata/crosslink/broker/events"
 	"github.com/indexdata/crosslink/broker/ill_db"
+	"github.com/indexdata/crosslink/broker/retention"
 	"github.com/indexdata/crosslink/testutil"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -31,6 +33,7 @@ import (
 var eventBus events.EventBus
 var illRepo ill_db.IllRepo
 var eventRepo events.EventRepo
+var extCtx = common.CreateExtCtxWithArgs(context.Background(), nil)
 
 func TestMain(m *testing.M) {
 	ctx := context.Background()
@@ -109,7 +112,7 @@ func TestMultipleEventHandlers(t *testing.T) {
 
 	for i := 0; i < noEvents; i++ {
 		illId := apptest.GetIllTransId(t, illRepo)
-		_, err := eventBus.CreateTask(illId, events.EventNameRequestReceived, events.EventData{}, events.EventDomainIllTransaction, nil, events.SignalConsumers)
+		_, err := eventBus.CreateTask(extCtx, illId, events.EventNameRequestReceived, events.EventData{}, events.EventDomainIllTransaction, nil, events.SignalConsumers)
 		assert.NoError(t, err, "Task should be created without errors")
 	}
 
@@ -187,7 +190,7 @@ func TestBroadcastEventHandlers(t *testing.T) {
 
 	for i := 0; i < noEvents; i++ {
 		illId := apptest.GetIllTransId(t, illRepo)
-		_, err := eventBus.CreateTask(illId, events.EventNameConfirmRequesterMsg, events.EventData{}, events.EventDomainIllTransaction, nil, events.SignalObservers)
+		_, err := eventBus.CreateTask(extCtx, illId, events.EventNameConfirmRequesterMsg, events.EventData{}, events.EventDomainIllTransaction, nil, events.SignalObservers)
 		assert.NoError(t, err, "Task should be created without errors")
 	}
 
@@ -274,7 +277,7 @@ func TestBroadcastTaskLifecycleHandlersDoNotDuplicateCoreHandlers(t *testing.T)
 	})
 
 	illId := apptest.GetIllTransId(t, illRepo)
-	eventId, err := eventBus.CreateTask(illId, events.EventNameRequestReceived, events.EventData{}, events.EventDomainIllTransaction, nil, events.SignalConsumers)
+	eventId, err := eventBus.CreateTask(extCtx, illId, events.EventNameRequestReceived, events.EventData{}, events.EventDomainIllTransaction, nil, events.SignalConsumers)
 	assert.NoError(t, err, "Task should be created without errors")
 	event, err := eventRepo.GetEvent(common.CreateExtCtxWithArgs(context.Background(), nil), eventId)
 	assert.NoError(t, err, "event should exist")
@@ -316,7 +319,7 @@ func TestCreateTask(t *testing.T) {
 	})
 	illId := apptest.GetIllTransId(t, illRepo)
 
-	_, err := eventBus.CreateTask(illId, events.EventNameRequestReceived, events.EventData{}, events.EventDomainIllTransaction, nil, events.SignalConsumers)
+	_, err := eventBus.CreateTask(extCtx, illId, events.EventNameRequestReceived, events.EventData{}, events.EventDomainIllTransaction, nil, events.SignalConsumers)
 	if err != nil {
 		t.Errorf("Task should be created without errors: %s", err)
 	}
@@ -424,7 +427,7 @@ func TestCreateNotice(t *testing.T) {
 
 	illId := apptest.GetIllTransId(t, illRepo)
 
-	_, err := eventBus.CreateNotice(illId, events.EventNameSupplierMsgReceived, events.EventData{}, events.EventStatusSuccess, events.EventDomainIllTransaction, events.SignalConsumers)
+	_, err := eventBus.CreateNotice(extCtx, illId, events.EventNameSupplierMsgReceived, events.EventData{}, events.EventStatusSuccess, events.EventDomainIllTransaction, events.SignalConsumers)
 	if err != nil {
 		t.Errorf("Task should be created without errors: %s", err)
 	}
@@ -460,7 +463,7 @@ func TestBeginAndCompleteTask(t *testing.T) {
 
 	illId := apptest.GetIllTransId(t, illRepo)
 
-	_, err := eventBus.CreateTask(illId, events.EventNameRequestReceived, events.EventData{}, events.EventDomainIllTransaction, nil, events.SignalConsumers)
+	_, err := eventBus.CreateTask(extCtx, illId, events.EventNameRequestReceived, events.EventData{}, events.EventDomainIllTransaction, nil, events.SignalConsumers)
 	if err != nil {
 		t.Errorf("Task should be created without errors: %s", err)
 	}
@@ -520,9 +523,9 @@ func TestProcessExclusiveTaskFailsWhenOlderIncompleteTaskExists(t *testing.T) {
 	appCtx := common.CreateExtCtxWithArgs(context.Background(), nil)
 	prID := uuid.NewString()
 	createPatronRequestForEventTest(t, prID)
-	_, err := eventBus.CreateTask(prID, events.EventNameInvokeAction, events.EventData{}, events.EventDomainPatronRequest, nil, events.SignalConsumers)
+	_, err := eventBus.CreateTask(extCtx, prID, events.EventNameInvokeAction, events.EventData{}, events.EventDomainPatronRequest, nil, events.SignalConsumers)
 	assert.NoError(t, err)
-	currentID, err := eventBus.CreateTask(prID, events.EventNameInvokeAction, events.EventData{}, events.EventDomainPatronRequest, nil, events.SignalConsumers)
+	currentID, err := eventBus.CreateTask(extCtx, prID, events.EventNameInvokeAction, events.EventData{}, events.EventDomainPatronRequest, nil, events.SignalConsumers)
 	assert.NoError(t, err)
 
 	handlerCalled := false
@@ -543,11 +546,11 @@ func TestProcessExclusiveTaskAllowsOlderAncestorTask(t *testing.T) {
 	appCtx := common.CreateExtCtxWithArgs(context.Background(), nil)
 	prID := uuid.NewString()
 	createPatronRequestForEventTest(t, prID)
-	parentID, err := eventBus.CreateTask(prID, events.EventNameInvokeAction, events.EventData{}, events.EventDomainPatronRequest, nil, events.SignalConsumers)
+	parentID, err := eventBus.CreateTask(extCtx, prID, events.EventNameInvokeAction, events.EventData{}, events.EventDomainPatronRequest, nil, events.SignalConsumers)
 	assert.NoError(t, err)
 	_, err = eventBus.BeginTask(parentID, events.SignalConsumers)
 	assert.NoError(t, err)
-	childID, err := eventBus.CreateTask(prID, events.EventNameInvokeAction, events.EventData{}, events.EventDomainPatronRequest, &parentID, events.SignalConsumers)
+	childID, err := eventBus.CreateTask(extCtx, prID, events.EventNameInvokeAction, events.EventData{}, events.EventDomainPatronRequest, &parentID, events.SignalConsumers)
 	assert.NoError(t, err)
 
 	handlerCalled := false
@@ -621,6 +624,32 @@ func TestCompleteTaskNegative(t *testing.T) {
 	}
 }
 
+func TestCompleteTaskLeaseLost(t *testing.T) {
+	appCtx := common.CreateExtCtxWithArgs(context.Background(), nil)
+	illId := apptest.GetIllTransId(t, illRepo)
+	eventId, err := eventBus.CreateTask(extCtx, illId, events.EventNameCheckAvailability, events.EventData{}, events.EventDomainIllTransaction, nil, events.SignalObservers)
+	assert.NoError(t, err)
+	_, err = eventBus.BeginTask(eventId, events.SignalObservers)
+	assert.NoError(t, err)
+
+	// another instance took over the task after the lease expired
+	_, err = eventRepo.AcquireEventLease(appCtx, events.AcquireEventLeaseParams{
+		EventID:   eventId,
+		Owner:     "other",
+		ExpiresAt: pgtype.Timestamp{Time: time.Now().Add(time.Minute), Valid: true},
+	})
+	assert.NoError(t, err)
+
+	_, err = eventBus.CompleteTask(eventId, &events.EventResult{}, events.EventStatusSuccess, events.SignalObservers)
+	assert.ErrorIs(t, err, events.ErrLeaseLost)
+	event, err := eventRepo.GetEvent(appCtx, eventId)
+	assert.NoError(t, err)
+	assert.Equal(t, events.EventStatusProcessing, event.EventStatus)
+	lease, err := eventRepo.GetEventLease(appCtx, eventId)
+	assert.NoError(t, err)
+	assert.Equal(t, "other", lease.Owner)
+}
+
 func TestFailedToConnect(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -658,7 +687,7 @@ func TestReconnectListener(t *testing.T) {
 
 	illId := apptest.GetIllTransId(t, illRepo)
 
-	_, err := eventBus.CreateNotice(illId, events.EventNameSupplierMsgReceived, events.EventData{}, events.EventStatusSuccess, events.EventDomainIllTransaction, events.SignalConsumers)
+	_, err := eventBus.CreateNotice(extCtx, illId, events.EventNameSupplierMsgReceived, events.EventData{}, events.EventStatusSuccess, events.EventDomainIllTransaction, events.SignalConsumers)
 	if err != nil {
 		t.Errorf("Task should be created without errors: %s", err)
 	}
@@ -669,3 +698,235 @@ func TestReconnectListener(t *testing.T) {
 		t.Error("Expected to have request event received")
 	}
 }
+
+func TestSweepOrphanedTask(t *testing.T) {
+	appCtx := common.CreateExtCtxWithArgs(context.Background(), nil)
+	var created atomic.Int32
+	eventBus.HandleEventCreated(events.EventNameCheckAvailability, events.HandlerRoleConsumer, func(ctx common.ExtendedContext, event events.Event) {
+		created.Add(1)
+	})
+	illId := apptest.GetIllTransId(t, illRepo)
+	eventId, err := eventBus.CreateTask(extCtx, illId, events.EventNameCheckAvailability, events.EventData{}, events.EventDomainIllTransaction, nil, events.SignalConsumers)
+	assert.NoError(t, err)
+	assert.True(t, test.WaitForPredicateToBeTrue(func() bool {
+		return created.Load() == 1
+	}))
+	_, err = eventBus.BeginTask(eventId, events.SignalConsumers)
+	assert.NoError(t, err)
+
+	// lease still held, nothing to recover
+	_, err = eventBus.RequeueTask(eventId)
+	assert.ErrorIs(t, err, events.ErrTaskNotStuck)
+	rows, _, err := eventRepo.ListStuckEvents(appCtx, events.ListStuckEventsParams{Limit: 100, ExpiredBefore: test.GetNow()})
+	assert.NoError(t, err)
+	for _, row := range rows {
+		assert.NotEqual(t, eventId, row.Event.ID)
+	}
+
+	// simulate the owner of the task being killed
+	expireLease(t, eventId)
+	rows, _, err = eventRepo.ListStuckEvents(appCtx, events.ListStuckEventsParams{Limit: 100, ExpiredBefore: test.GetNow()})
+	assert.NoError(t, err)
+	found := false
+	for _, row := range rows {
+		if row.Event.ID == eventId {
+			found = true
+			assert.Equal(t, int32(1), row.LeaseAttempts)
+			assert.True(t, row.LeaseOwner.Valid)
+		}
+	}
+	assert.True(t, found)
+
+	eventBus.(*events.PostgresEventBus).SweepExpiredTasks()
+	assert.True(t, test.WaitForPredicateToBeTrue(func() bool {
+		return created.Load() == 2
+	}))
+	event, err := eventRepo.GetEvent(appCtx, eventId)
+	assert.NoError(t, err)
+	assert.Equal(t, events.EventStatusNew, event.EventStatus)
+
+	// started again and orphaned until attempts are used up
+	for i := 2; i <= events.DEFAULT_TASK_MAX_ATTEMPTS; i++ {
+		_, err = eventBus.BeginTask(eventId, events.SignalConsumers)
+		assert.NoError(t, err)
+		expireLease(t, eventId)
+		eventBus.(*events.PostgresEventBus).SweepExpiredTasks()
+	}
+	event, err = eventRepo.GetEvent(appCtx, eventId)
+	assert.NoError(t, err)
+	assert.Equal(t, events.EventStatusError, event.EventStatus)
+	if assert.NotNil(t, event.ResultData.EventError) {
+		assert.Equal(t, "task lease expired", event.ResultData.EventError.Message)
+	}
+	_, err = eventRepo.GetEventLease(appCtx, eventId)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func expireLease(t *testing.T, eventId string) {
+	t.Helper()
+	conn, err := pgx.Connect(context.Background(), app.ConnectionString)
+	assert.NoError(t, err)
+	defer conn.Close(context.Background())
+	_, err = conn.Exec(context.Background(), "UPDATE event_lease SET expires_at = $2 WHERE event_id = $1", eventId, time.Now().Add(-time.Minute))
+	assert.NoError(t, err)
+}
+
+func TestCatchUpAfterListenerKilled(t *testing.T) {
+	var received atomic.Int32
+	eventBus.HandleEventCreated(events.EventNameConfirmRequesterMsg, events.HandlerRoleConsumer, func(ctx common.ExtendedContext, event events.Event) {
+		received.Add(1)
+	})
+	conn, err := pgx.Connect(context.Background(), app.ConnectionString)
+	assert.NoError(t, err)
+	defer conn.Close(context.Background())
+	_, err = conn.Exec(context.Background(), "SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE state = 'idle' AND query LIKE 'LISTEN%'")
+	assert.NoError(t, err)
+
+	// reconnect backoff starts at 1s, so nobody listens for this notification
+	illId := apptest.GetIllTransId(t, illRepo)
+	_, err = eventBus.CreateTask(extCtx, illId, events.EventNameConfirmRequesterMsg, events.EventData{}, events.EventDomainIllTransaction, nil, events.SignalConsumers)
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return received.Load() == 1
+	}, 5*time.Second, 20*time.Millisecond, "missed task not handled after reconnect")
+}
+
+func TestPollUnclaimedSignals(t *testing.T) {
+	var received atomic.Int32
+	eventBus.HandleEventCreated(events.EventNameConfirmSupplierMsg, events.HandlerRoleConsumer, func(ctx common.ExtendedContext, event events.Event) {
+		received.Add(1)
+	})
+	illId := apptest.GetIllTransId(t, illRepo)
+	eventId := apptest.GetEventId(t, eventRepo, illId, events.EventTypeTask, events.EventStatusNew, events.EventNameConfirmSupplierMsg)
+	// a signal logged while no instance was listening
+	conn, err := pgx.Connect(context.Background(), app.ConnectionString)
+	assert.NoError(t, err)
+	defer conn.Close(context.Background())
+	_, err = conn.Exec(context.Background(), "INSERT INTO event_signal (event_id, signal, target, created_at) VALUES ($1, $2, $3, $4)",
+		eventId, string(events.SignalTaskCreated), string(events.SignalConsumers), time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+
+	eventBus.(*events.PostgresEventBus).PollUnclaimedSignals()
+	assert.True(t, test.WaitForPredicateToBeTrue(func() bool {
+		return received.Load() == 1
+	}))
+	event, err := eventRepo.GetEvent(common.CreateExtCtxWithArgs(context.Background(), nil), eventId)
+	assert.NoError(t, err)
+	assert.Equal(t, "", event.LastSignal)
+
+	// claimed signals are not sent again
+	eventBus.(*events.PostgresEventBus).PollUnclaimedSignals()
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(1), received.Load())
+}
+
+func TestEventIdUniqueAcrossPartitions(t *testing.T) {
+	appCtx := common.CreateExtCtxWithArgs(context.Background(), nil)
+	eventId := uuid.NewString()
+	params := events.SaveEventParams{
+		ID:               eventId,
+		IllTransactionID: events.DEFAULT_ILL_TRANSACTION_ID,
+		PatronRequestID:  events.DEFAULT_PATRON_REQUEST_ID,
+		Timestamp:        pgtype.Timestamp{Time: time.Now(), Valid: true},
+		EventType:        events.EventTypeTask,
+		EventName:        events.EventNameInvokeBatchAction,
+		EventStatus:      events.EventStatusNew,
+		LastSignal:       string(events.SignalTaskCreated),
+	}
+	_, err := eventRepo.SaveEvent(appCtx, params)
+	assert.NoError(t, err)
+
+	// saving the same event again updates it
+	params.EventStatus = events.EventStatusProcessing
+	_, err = eventRepo.SaveEvent(appCtx, params)
+	assert.NoError(t, err)
+
+	// another event with the same id in another partition is rejected
+	params.Timestamp = pgtype.Timestamp{Time: time.Now().AddDate(0, 1, 0), Valid: true}
+	_, err = eventRepo.SaveEvent(appCtx, params)
+	assert.ErrorContains(t, err, "duplicate event id "+eventId)
+
+	// dependent rows need an event and are removed with it
+	_, err = eventRepo.AcquireEventLease(appCtx, events.AcquireEventLeaseParams{
+		EventID:   uuid.NewString(),
+		Owner:     "owner",
+		ExpiresAt: pgtype.Timestamp{Time: time.Now().Add(time.Minute), Valid: true},
+	})
+	assert.ErrorContains(t, err, "event_lease_event_id_fkey")
+	_, err = eventRepo.AcquireEventLease(appCtx, events.AcquireEventLeaseParams{
+		EventID:   eventId,
+		Owner:     "owner",
+		ExpiresAt: pgtype.Timestamp{Time: time.Now().Add(time.Minute), Valid: true},
+	})
+	assert.NoError(t, err)
+	conn, err := pgx.Connect(context.Background(), app.ConnectionString)
+	assert.NoError(t, err)
+	defer conn.Close(context.Background())
+	_, err = conn.Exec(context.Background(), "DELETE FROM event WHERE id = $1", eventId)
+	assert.NoError(t, err)
+	_, err = eventRepo.GetEventLease(appCtx, eventId)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestEventRetention(t *testing.T) {
+	appCtx := common.CreateExtCtxWithArgs(context.Background(), nil)
+	oldMonth := time.Date(time.Now().Year()-3, time.January, 1, 0, 0, 0, 0, time.UTC)
+	name, err := eventRepo.CreateEventPartition(appCtx, pgtype.Timestamp{Time: oldMonth, Valid: true})
+	assert.NoError(t, err)
+	assert.Equal(t, oldMonth.Format("event_p200601"), name)
+	saveEvent := func(timestamp time.Time, status events.EventStatus) string {
+		eventId := uuid.NewString()
+		_, err := eventRepo.SaveEvent(appCtx, events.SaveEventParams{
+			ID:               eventId,
+			IllTransactionID: events.DEFAULT_ILL_TRANSACTION_ID,
+			PatronRequestID:  events.DEFAULT_PATRON_REQUEST_ID,
+			Timestamp:        pgtype.Timestamp{Time: timestamp, Valid: true},
+			EventType:        events.EventTypeTask,
+			EventName:        events.EventNameInvokeBatchAction,
+			EventStatus:      status,
+			LastSignal:       string(events.SignalTaskComplete),
+		})
+		assert.NoError(t, err)
+		return eventId
+	}
+	oldId := saveEvent(oldMonth.Add(time.Hour), events.EventStatusSuccess)
+	expiredId := saveEvent(time.Now().Add(-48*time.Hour), events.EventStatusSuccess)
+	keptId := saveEvent(time.Now().Add(-48*time.Hour), events.EventStatusError)
+
+	dir := t.TempDir()
+	eventRetention := retention.NewEventRetention(eventRepo)
+	eventRetention.MaxAge = 365 * 24 * time.Hour
+	eventRetention.Rules = []retention.Rule{{Domain: events.EventDomainScheduler, Status: events.EventStatusSuccess, MaxAge: 24 * time.Hour}}
+	eventRetention.ExportDir = dir
+	assert.NoError(t, eventRetention.Maintain(appCtx))
+
+	partitions, err := eventRepo.ListEventPartitions(appCtx)
+	assert.NoError(t, err)
+	var names []string
+	for _, partition := range partitions {
+		names = append(names, partition.PartitionName)
+	}
+	assert.NotContains(t, names, name)
+	now := time.Now()
+	assert.Contains(t, names, time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC).Format("event_p200601"))
+
+	_, err = eventRepo.GetEvent(appCtx, oldId)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	_, err = eventRepo.GetEvent(appCtx, expiredId)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	_, err = eventRepo.GetEvent(appCtx, keptId)
+	assert.NoError(t, err)
+
+	partitionExport, err := os.ReadFile(filepath.Join(dir, name+".jsonl"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(partitionExport), oldId)
+	files, err := filepath.Glob(filepath.Join(dir, "event_deleted_*.jsonl"))
+	assert.NoError(t, err)
+	if assert.Len(t, files, 1) {
+		deletedExport, err := os.ReadFile(files[0])
+		assert.NoError(t, err)
+		assert.Contains(t, string(deletedExport), expiredId)
+		assert.NotContains(t, string(deletedExport), keptId)
+	}
+}
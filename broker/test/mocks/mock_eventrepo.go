@@ -8,6 +8,7 @@ import (
 	"github.com/indexdata/crosslink/broker/events"
 	test "github.com/indexdata/crosslink/broker/test/utils"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -100,6 +101,100 @@ func (r *MockEventRepositorySuccess) GetPatronRequestEvents(ctx common.ExtendedC
 	}}, nil
 }
 
+func (r *MockEventRepositorySuccess) AcquireEventLease(ctx common.ExtendedContext, params events.AcquireEventLeaseParams) (events.EventLease, error) {
+	return events.EventLease{EventID: params.EventID, Owner: params.Owner, ExpiresAt: params.ExpiresAt, Attempts: 1}, nil
+}
+
+func (r *MockEventRepositorySuccess) RenewEventLease(ctx common.ExtendedContext, params events.RenewEventLeaseParams) (events.EventLease, error) {
+	return events.EventLease{EventID: params.EventID, Owner: params.Owner, ExpiresAt: params.ExpiresAt, Attempts: 1}, nil
+}
+
+func (r *MockEventRepositorySuccess) GetEventLease(ctx common.ExtendedContext, eventId string) (events.EventLease, error) {
+	return events.EventLease{}, pgx.ErrNoRows
+}
+
+func (r *MockEventRepositorySuccess) DeleteEventLease(ctx common.ExtendedContext, eventId string) error {
+	return nil
+}
+
+func (r *MockEventRepositorySuccess) ReleaseEventLease(ctx common.ExtendedContext, params events.ReleaseEventLeaseParams) (int64, error) {
+	return 1, nil
+}
+
+func (r *MockEventRepositorySuccess) ListStuckEvents(ctx common.ExtendedContext, params events.ListStuckEventsParams) ([]events.ListStuckEventsRow, int64, error) {
+	return []events.ListStuckEventsRow{}, 0, nil
+}
+
+func (r *MockEventRepositorySuccess) ListFailedEvents(ctx common.ExtendedContext, params events.ListFailedEventsParams) ([]events.ListFailedEventsRow, int64, error) {
+	return []events.ListFailedEventsRow{}, 0, nil
+}
+
+func (r *MockEventRepositorySuccess) GetEventResolution(ctx common.ExtendedContext, eventId string) (events.EventResolution, error) {
+	return events.EventResolution{}, pgx.ErrNoRows
+}
+
+func (r *MockEventRepositorySuccess) SaveEventResolution(ctx common.ExtendedContext, params events.SaveEventResolutionParams) (events.EventResolution, error) {
+	return events.EventResolution(params), nil
+}
+
+func (r *MockEventRepositorySuccess) GetLatestEventSignalId(ctx common.ExtendedContext) (int64, error) {
+	return 0, nil
+}
+
+func (r *MockEventRepositorySuccess) ListEventSignalsAfter(ctx common.ExtendedContext, params events.ListEventSignalsAfterParams) ([]events.EventSignal, error) {
+	return []events.EventSignal{}, nil
+}
+
+func (r *MockEventRepositorySuccess) ListUnclaimedEventSignals(ctx common.ExtendedContext, params events.ListUnclaimedEventSignalsParams) ([]events.EventSignal, error) {
+	return []events.EventSignal{}, nil
+}
+
+func (r *MockEventRepositorySuccess) DeleteEventSignalsBefore(ctx common.ExtendedContext, createdAt pgtype.Timestamp) error {
+	return nil
+}
+
+func (r *MockEventRepositorySuccess) SaveSseMessage(ctx common.ExtendedContext, params events.SaveSseMessageParams) (events.SseMessage, error) {
+	return events.SseMessage{
+		ID:        1,
+		EventID:   params.EventID,
+		Receiver:  params.Receiver,
+		Message:   params.Message,
+		CreatedAt: params.CreatedAt,
+	}, nil
+}
+
+func (r *MockEventRepositorySuccess) ListSseMessagesAfter(ctx common.ExtendedContext, params events.ListSseMessagesAfterParams) ([]events.SseMessage, error) {
+	return []events.SseMessage{}, nil
+}
+
+func (r *MockEventRepositorySuccess) DeleteSseMessagesBefore(ctx common.ExtendedContext, createdAt pgtype.Timestamp) error {
+	return nil
+}
+
+func (r *MockEventRepositorySuccess) TryEventMaintenanceLock(ctx common.ExtendedContext) (bool, error) {
+	return false, nil
+}
+
+func (r *MockEventRepositorySuccess) CreateEventPartition(ctx common.ExtendedContext, month pgtype.Timestamp) (string, error) {
+	return "", nil
+}
+
+func (r *MockEventRepositorySuccess) ListEventPartitions(ctx common.ExtendedContext) ([]events.ListEventPartitionsRow, error) {
+	return []events.ListEventPartitionsRow{}, nil
+}
+
+func (r *MockEventRepositorySuccess) DropEventPartition(ctx common.ExtendedContext, name string) error {
+	return nil
+}
+
+func (r *MockEventRepositorySuccess) ListEventsBetween(ctx common.ExtendedContext, params events.ListEventsBetweenParams) ([]events.Event, error) {
+	return []events.Event{}, nil
+}
+
+func (r *MockEventRepositorySuccess) DeleteExpiredEvents(ctx common.ExtendedContext, params events.DeleteExpiredEventsParams) ([]events.Event, error) {
+	return []events.Event{}, nil
+}
+
 type MockEventRepositoryError struct {
 	mock.Mock
 }
@@ -151,3 +246,91 @@ func (r *MockEventRepositoryError) GetLatestRequestEventByAction(ctx common.Exte
 func (r *MockEventRepositoryError) GetPatronRequestEvents(ctx common.ExtendedContext, id string) ([]events.Event, error) {
 	return []events.Event{}, errors.New("DB error")
 }
+
+func (r *MockEventRepositoryError) AcquireEventLease(ctx common.ExtendedContext, params events.AcquireEventLeaseParams) (events.EventLease, error) {
+	return events.EventLease{}, errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) RenewEventLease(ctx common.ExtendedContext, params events.RenewEventLeaseParams) (events.EventLease, error) {
+	return events.EventLease{}, errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) GetEventLease(ctx common.ExtendedContext, eventId string) (events.EventLease, error) {
+	return events.EventLease{}, errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) DeleteEventLease(ctx common.ExtendedContext, eventId string) error {
+	return errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) ReleaseEventLease(ctx common.ExtendedContext, params events.ReleaseEventLeaseParams) (int64, error) {
+	return 0, errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) ListStuckEvents(ctx common.ExtendedContext, params events.ListStuckEventsParams) ([]events.ListStuckEventsRow, int64, error) {
+	return []events.ListStuckEventsRow{}, 0, errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) ListFailedEvents(ctx common.ExtendedContext, params events.ListFailedEventsParams) ([]events.ListFailedEventsRow, int64, error) {
+	return []events.ListFailedEventsRow{}, 0, errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) GetEventResolution(ctx common.ExtendedContext, eventId string) (events.EventResolution, error) {
+	return events.EventResolution{}, errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) SaveEventResolution(ctx common.ExtendedContext, params events.SaveEventResolutionParams) (events.EventResolution, error) {
+	return events.EventResolution{}, errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) GetLatestEventSignalId(ctx common.ExtendedContext) (int64, error) {
+	return 0, errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) ListEventSignalsAfter(ctx common.ExtendedContext, params events.ListEventSignalsAfterParams) ([]events.EventSignal, error) {
+	return []events.EventSignal{}, errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) ListUnclaimedEventSignals(ctx common.ExtendedContext, params events.ListUnclaimedEventSignalsParams) ([]events.EventSignal, error) {
+	return []events.EventSignal{}, errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) DeleteEventSignalsBefore(ctx common.ExtendedContext, createdAt pgtype.Timestamp) error {
+	return errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) SaveSseMessage(ctx common.ExtendedContext, params events.SaveSseMessageParams) (events.SseMessage, error) {
+	return events.SseMessage{}, errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) ListSseMessagesAfter(ctx common.ExtendedContext, params events.ListSseMessagesAfterParams) ([]events.SseMessage, error) {
+	return []events.SseMessage{}, errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) DeleteSseMessagesBefore(ctx common.ExtendedContext, createdAt pgtype.Timestamp) error {
+	return errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) TryEventMaintenanceLock(ctx common.ExtendedContext) (bool, error) {
+	return false, errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) CreateEventPartition(ctx common.ExtendedContext, month pgtype.Timestamp) (string, error) {
+	return "", errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) ListEventPartitions(ctx common.ExtendedContext) ([]events.ListEventPartitionsRow, error) {
+	return []events.ListEventPartitionsRow{}, errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) DropEventPartition(ctx common.ExtendedContext, name string) error {
+	return errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) ListEventsBetween(ctx common.ExtendedContext, params events.ListEventsBetweenParams) ([]events.Event, error) {
+	return []events.Event{}, errors.New("DB error")
+}
+
+func (r *MockEventRepositoryError) DeleteExpiredEvents(ctx common.ExtendedContext, params events.DeleteExpiredEventsParams) ([]events.Event, error) {
+	return []events.Event{}, errors.New("DB error")
+}
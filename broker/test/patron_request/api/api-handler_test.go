@@ -1543,15 +1543,15 @@ func TestCRUDTemplate(t *testing.T) {
 
 	// POST – create a template
 	audience := proapi.TemplateAudiencePatron
-	subject := "Your ILL request {{title}} is ready"
+	subject := "Your ILL request {{.Request.Title}} is ready"
 	newTemplate := proapi.CreateTemplate{
 		Title:       "Ready notification",
-		Purpose:     proapi.Email,
-		ContentType: proapi.Text,
+		Purpose:     proapi.TemplatePurposeEmail,
+		ContentType: proapi.TemplateContentTypeText,
 		Audience:    &audience,
 		Subject:     &subject,
 		Labels:      []string{"borrower-loaned"},
-		Body:        "Dear {{patronName}}, your item {{title}} has arrived.",
+		Body:        "Dear {{.Request.Patron}}, your item {{.Request.Title}} has arrived.",
 	}
 	newTemplateBytes, err := json.Marshal(newTemplate)
 	assert.NoError(t, err)
@@ -1608,14 +1608,14 @@ func TestCRUDTemplate(t *testing.T) {
 
 	// PUT – update the template
 	updatedAudience := proapi.TemplateAudienceStaff
-	updatedSubject := "Staff: ILL item {{title}} ready for {{patronName}}"
+	updatedSubject := "Staff: ILL item {{.Request.Title}} ready for {{.Request.Patron}}"
 	updateTemplate := proapi.UpdateTemplate{
 		Title:       "Ready notification – updated",
-		ContentType: proapi.Html,
+		ContentType: proapi.TemplateContentTypeHtml,
 		Audience:    &updatedAudience,
 		Subject:     &updatedSubject,
 		Labels:      []string{"borrower-loaned", "staff"},
-		Body:        "<p>Dear {{patronName}}, your item is ready.</p>",
+		Body:        "<p>Dear {{.Request.Patron}}, your item is ready.</p>",
 	}
 	updateBytes, err := json.Marshal(updateTemplate)
 	assert.NoError(t, err)
@@ -1626,7 +1626,7 @@ func TestCRUDTemplate(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, templateId, updatedTemplate.Id)
 	assert.Equal(t, updateTemplate.Title, updatedTemplate.Title)
-	assert.Equal(t, proapi.Html, updatedTemplate.ContentType)
+	assert.Equal(t, proapi.TemplateContentTypeHtml, updatedTemplate.ContentType)
 	assert.Equal(t, updatedAudience, *updatedTemplate.Audience)
 	assert.Equal(t, updatedSubject, *updatedTemplate.Subject)
 	assert.Equal(t, updateTemplate.Labels, updatedTemplate.Labels)
@@ -1657,6 +1657,29 @@ func TestCRUDTemplate(t *testing.T) {
 	assert.Nil(t, reReadTemplate.Subject)
 	assert.Equal(t, updateTemplate.Title, reReadTemplate.Title)
 
+	// PUT – 400 for a body referring to an unknown field
+	invalidBytes, err := json.Marshal(proapi.UpdateTemplate{
+		Title:       updateTemplate.Title,
+		ContentType: updateTemplate.ContentType,
+		Labels:      updateTemplate.Labels,
+		Body:        "<p>Dear {{.Request.PatronName}}</p>",
+	})
+	assert.NoError(t, err)
+	respBytes = httpRequest(t, "PUT", thisTemplatePath+queryParams, invalidBytes, 400)
+	assert.Contains(t, string(respBytes), "invalid body")
+
+	// POST preview – stored template rendered with sample data
+	previewBytes, err := json.Marshal(proapi.TemplatePreviewRequest{TemplateId: &templateId})
+	assert.NoError(t, err)
+	respBytes = httpRequest(t, "POST", templatePath+"/preview"+queryParams, previewBytes, 200)
+	var preview proapi.TemplatePreview
+	err = json.Unmarshal(respBytes, &preview)
+	assert.NoError(t, err)
+	assert.Empty(t, preview.UnknownPlaceholders)
+	if assert.NotNil(t, preview.Body) {
+		assert.Equal(t, "<p>Dear Jane Doe, your item is ready.</p>", *preview.Body)
+	}
+
 	// PUT – 404 for wrong owner
 	httpRequest(t, "PUT", thisTemplatePath+"?symbol="+url.QueryEscape(otherSymbol), updateBytes, 404)
 
@@ -276,6 +276,77 @@ func TestTemplateUpdatedAtInitializedFromCreatedAt(t *testing.T) {
 	assert.Equal(t, template.CreatedAt.Time, template.UpdatedAt.Time)
 }
 
+func TestGetTemplateByLanguage(t *testing.T) {
+	owner := "ISIL:" + uuid.NewString()
+	save := func(language pgtype.Text) string {
+		template, err := prRepo.SaveTemplate(appCtx, pr_db.SaveTemplateParams{
+			ID:          uuid.NewString(),
+			Owner:       owner,
+			Title:       "Ready",
+			Purpose:     "email",
+			Body:        "Body",
+			ContentType: "text/plain",
+			Labels:      []string{"ready"},
+			Language:    language,
+			CreatedAt:   pgtype.Timestamp{Time: time.Now(), Valid: true},
+		})
+		assert.NoError(t, err)
+		return template.ID
+	}
+	defaultId := save(pgtype.Text{})
+	esId := save(pgtype.Text{String: "es", Valid: true})
+	daId := save(pgtype.Text{String: "da", Valid: true})
+
+	find := func(languages ...string) string {
+		template, err := prRepo.GetTemplateByPurposeAudienceLabelAndOwner(appCtx, pr_db.GetTemplateByPurposeAudienceLabelAndOwnerParams{
+			Owner:     owner,
+			Purpose:   "email",
+			Label:     "ready",
+			Audience:  "patron",
+			Languages: languages,
+		})
+		assert.NoError(t, err)
+		return template.ID
+	}
+	assert.Equal(t, esId, find("es-MX", "es", "da"))
+	assert.Equal(t, daId, find("fr", "da"))
+	assert.Equal(t, defaultId, find("fr"))
+	assert.Equal(t, defaultId, find())
+}
+
+func TestGetTemplateSms(t *testing.T) {
+	owner := "ISIL:" + uuid.NewString()
+	save := func(purpose string, contentType string) string {
+		template, err := prRepo.SaveTemplate(appCtx, pr_db.SaveTemplateParams{
+			ID:          uuid.NewString(),
+			Owner:       owner,
+			Title:       "Ready",
+			Purpose:     purpose,
+			Body:        "Body",
+			ContentType: contentType,
+			Labels:      []string{"ready"},
+			CreatedAt:   pgtype.Timestamp{Time: time.Now(), Valid: true},
+		})
+		assert.NoError(t, err)
+		return template.ID
+	}
+	emailId := save("email", "text")
+	smsId := save("sms", "sms")
+
+	find := func(purpose string) string {
+		template, err := prRepo.GetTemplateByPurposeAudienceLabelAndOwner(appCtx, pr_db.GetTemplateByPurposeAudienceLabelAndOwnerParams{
+			Owner:    owner,
+			Purpose:  purpose,
+			Label:    "ready",
+			Audience: "patron",
+		})
+		assert.NoError(t, err)
+		return template.ID
+	}
+	assert.Equal(t, emailId, find("email"))
+	assert.Equal(t, smsId, find("sms"))
+}
+
 func TestNotification(t *testing.T) {
 	prId := uuid.NewString()
 	_, err := prRepo.CreatePatronRequest(appCtx, pr_db.CreatePatronRequestParams{
@@ -414,6 +485,116 @@ func TestNotification(t *testing.T) {
 	assert.ErrorIs(t, err, pgx.ErrNoRows)
 }
 
+func TestOutboxMessage(t *testing.T) {
+	prID := uuid.NewString()
+	_, err := prRepo.CreatePatronRequest(appCtx, pr_db.CreatePatronRequestParams{
+		ID: prID, CreatedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+		Language: "english", Items: []pr_db.PrItem{},
+	})
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, prRepo.DeletePatronRequest(appCtx, prID))
+	})
+	notificationID := uuid.NewString()
+	_, err = prRepo.SaveNotification(appCtx, pr_db.SaveNotificationParams{
+		ID: notificationID, PrID: prID, FromSymbol: "ISIL:REQ", ToSymbol: "patron@example.com",
+		Direction: pr_db.NotificationDirectionSent, Kind: pr_db.NotificationKindEmail,
+		CreatedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+	})
+	assert.NoError(t, err)
+
+	message, err := prRepo.SaveOutboxMessage(appCtx, pr_db.SaveOutboxMessageParams{
+		ID:             uuid.NewString(),
+		NotificationID: pgtype.Text{String: notificationID, Valid: true},
+		Owner:          "ISIL:REQ",
+		FromAddress:    "library@example.com",
+		Recipients:     []string{"patron@example.com"},
+		Message:        []byte("Subject: Ready"),
+		Status:         pr_db.OutboxMessageStatusQueued,
+		NextAttemptAt:  pgtype.Timestamp{Time: time.Now().Add(-time.Minute), Valid: true},
+		CreatedAt:      pgtype.Timestamp{Time: time.Now(), Valid: true},
+		Channel:        "email",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), message.Attempts)
+
+	claimed, err := prRepo.ClaimDueOutboxMessages(appCtx, time.Minute, 1000)
+	assert.NoError(t, err)
+	ids := []string{}
+	for _, m := range claimed {
+		ids = append(ids, m.ID)
+	}
+	assert.Contains(t, ids, message.ID)
+	claimed, err = prRepo.ClaimDueOutboxMessages(appCtx, time.Minute, 1000)
+	assert.NoError(t, err)
+	for _, m := range claimed {
+		assert.NotEqual(t, message.ID, m.ID)
+	}
+
+	sentAt := pgtype.Timestamp{Time: time.Now(), Valid: true}
+	updated, err := prRepo.UpdateOutboxMessage(appCtx, pr_db.UpdateOutboxMessageParams{
+		ID:            message.ID,
+		Status:        pr_db.OutboxMessageStatusSent,
+		Attempts:      1,
+		LastAttemptAt: sentAt,
+		SentAt:        sentAt,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, pr_db.OutboxMessageStatusSent, updated.Status)
+	assert.Equal(t, int32(1), updated.Attempts)
+
+	messages, err := prRepo.GetOutboxMessagesByNotificationIds(appCtx, []string{notificationID, uuid.NewString()})
+	assert.NoError(t, err)
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, message.ID, messages[0].ID)
+		assert.Equal(t, []string{"patron@example.com"}, messages[0].Recipients)
+		assert.True(t, messages[0].SentAt.Valid)
+	}
+
+	assert.NoError(t, prRepo.DeleteNotificationById(appCtx, notificationID))
+	messages, err = prRepo.GetOutboxMessagesByNotificationIds(appCtx, []string{notificationID})
+	assert.NoError(t, err)
+	assert.Empty(t, messages)
+}
+
+func TestOverdueSearch(t *testing.T) {
+	prID := uuid.NewString()
+	_, err := prRepo.CreatePatronRequest(appCtx, pr_db.CreatePatronRequestParams{
+		ID: prID, CreatedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+		Side: prservice.SideBorrowing, Language: "english", Items: []pr_db.PrItem{},
+		IllResponse: iso18626.SupplyingAgencyMessage{StatusInfo: iso18626.StatusInfo{
+			DueDate: &utils.XSDDateTime{Time: time.Date(2001, 2, 3, 12, 0, 0, 0, time.UTC)},
+		}},
+	})
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, prRepo.DeletePatronRequest(appCtx, prID))
+	})
+	_, err = prRepo.SaveNotification(appCtx, pr_db.SaveNotificationParams{
+		ID: uuid.NewString(), PrID: prID, FromSymbol: "ISIL:REQ", ToSymbol: "patron@example.com",
+		Direction: pr_db.NotificationDirectionSent, Kind: pr_db.NotificationKindReminder,
+		Note:      pgtype.Text{String: "first", Valid: true},
+		CreatedAt: pgtype.Timestamp{Time: time.Now(), Valid: true}, AcknowledgedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+	})
+	assert.NoError(t, err)
+
+	for _, tc := range []struct {
+		cql   string
+		count int
+	}{
+		{"id = " + prID + " and due_at < 2001-02-04", 1},
+		{"id = " + prID + " and due_at < 2001-02-03", 0},
+		// reminders are not notifications to read
+		{"id = " + prID + " and has_notification = false", 1},
+	} {
+		pgcql, err := pr_db.ParsePatronRequestsCql(tc.cql)
+		assert.NoError(t, err)
+		list, _, err := prRepo.ListPatronRequests(appCtx, pr_db.ListPatronRequestsParams{Limit: 10}, pgcql)
+		assert.NoError(t, err)
+		assert.Len(t, list, tc.count, tc.cql)
+	}
+}
+
 func TestMarkConditionNotificationsReceipt(t *testing.T) {
 	prId := uuid.NewString()
 	_, err := prRepo.CreatePatronRequest(appCtx, pr_db.CreatePatronRequestParams{
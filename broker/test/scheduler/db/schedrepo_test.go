@@ -496,3 +496,61 @@ func TestDisableTask_InvalidRunAt(t *testing.T) {
 
 	stopTask(t, disabled)
 }
+
+// ---------------------------------------------------------------------------
+// Run keys
+// ---------------------------------------------------------------------------
+
+func TestClaimScheduledTaskRun_OncePerScheduledTime(t *testing.T) {
+	params := newTask("FREQ=MINUTELY", tstz(time.Now().Add(-time.Minute)))
+	saved, err := schedRepo.SaveScheduledTask(appCtx, params)
+	assert.NoError(t, err)
+	assert.Equal(t, sched_db.MisfirePolicyRunOnce, saved.MisfirePolicy)
+	t.Cleanup(func() {
+		assert.NoError(t, schedRepo.DeleteScheduledTask(appCtx, saved.ID, nil))
+	})
+
+	claimed, err := schedRepo.ClaimScheduledTaskRun(appCtx, saved.ID, saved.RunAt)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	claimed, err = schedRepo.ClaimScheduledTaskRun(appCtx, saved.ID, saved.RunAt)
+	assert.NoError(t, err)
+	assert.False(t, claimed, "a run is claimed once")
+
+	next := tstz(saved.RunAt.Time.Add(time.Minute))
+	claimed, err = schedRepo.ClaimScheduledTaskRun(appCtx, saved.ID, next)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	assert.NoError(t, schedRepo.SetScheduledTaskRunEvent(appCtx, sched_db.SetScheduledTaskRunEventParams{
+		TaskID:       saved.ID,
+		ScheduledFor: next,
+		EventID:      pgtype.Text{String: uuid.NewString(), Valid: true},
+	}))
+	assert.NoError(t, schedRepo.DeleteScheduledTaskRunsBefore(appCtx, saved.ID, next))
+	claimed, err = schedRepo.ClaimScheduledTaskRun(appCtx, saved.ID, saved.RunAt)
+	assert.NoError(t, err)
+	assert.True(t, claimed, "deleted run keys can be claimed again")
+}
+
+func TestClaimScheduledTaskRun_RolledBackClaimIsReleased(t *testing.T) {
+	params := newTask("FREQ=MINUTELY", tstz(time.Now().Add(-time.Minute)))
+	saved, err := schedRepo.SaveScheduledTask(appCtx, params)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, schedRepo.DeleteScheduledTask(appCtx, saved.ID, nil))
+	})
+
+	expected := errors.New("force rollback")
+	err = schedRepo.WithTxFunc(appCtx, func(txRepo sched_db.SchedRepo) error {
+		claimed, claimErr := txRepo.ClaimScheduledTaskRun(appCtx, saved.ID, saved.RunAt)
+		assert.NoError(t, claimErr)
+		assert.True(t, claimed)
+		return expected
+	})
+	assert.ErrorIs(t, err, expected)
+
+	claimed, err := schedRepo.ClaimScheduledTaskRun(appCtx, saved.ID, saved.RunAt)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+}
@@ -74,7 +74,7 @@ type countingEventBus struct {
 	claims []string
 }
 
-func (b *countingEventBus) CreateTask(_ string, _ events.EventName, data events.EventData, _ events.EventDomain, _ *string, _ events.SignalTarget) (string, error) {
+func (b *countingEventBus) CreateTask(_ common.ExtendedContext, _ string, _ events.EventName, data events.EventData, _ events.EventDomain, _ *string, _ events.SignalTarget) (string, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	taskId := uuid.New().String()
@@ -278,6 +278,40 @@ func TestListen_ReconnectsAfterConnectionLoss(t *testing.T) {
 	}), "scheduler did not recover after connection loss")
 }
 
+// TestListen_CatchesUpAfterConnectionLoss inserts a task while the LISTEN
+// connection is down, so its NOTIFY is lost, and verifies that the scheduler
+// still dispatches it right after reconnecting instead of waiting for the
+// fallback poll.
+func TestListen_CatchesUpAfterConnectionLoss(t *testing.T) {
+	bus := &countingEventBus{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	startScheduler(t, ctx, bus)
+	time.Sleep(150 * time.Millisecond)
+
+	adminPool, err := app.InitDbPool()
+	assert.NoError(t, err)
+	t.Cleanup(adminPool.Close)
+	killCtx := common.CreateExtCtxWithArgs(context.Background(), nil)
+	_, err = adminPool.Exec(killCtx,
+		`SELECT pg_terminate_backend(pid)
+         FROM pg_stat_activity
+         WHERE query LIKE $1
+           AND pid <> pg_backend_pid()`,
+		"LISTEN%")
+	assert.NoError(t, err)
+
+	// Reconnect backoff starts at 1 s, so this NOTIFY has no listener.
+	_, err = schedRepo.SaveScheduledTask(appCtx, overdueTask())
+	assert.NoError(t, err)
+
+	// Allow for the reconnect backoff, well below the 5-min fallback poll.
+	assert.Eventually(t, func() bool {
+		return bus.totalClaims() >= 1
+	}, 5*time.Second, 20*time.Millisecond, "scheduler did not catch up after connection loss")
+}
+
 // ---------------------------------------------------------------------------
 // Context cancellation
 // ---------------------------------------------------------------------------
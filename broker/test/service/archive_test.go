@@ -18,25 +18,25 @@ func TestArchiveInvalidDelay(t *testing.T) {
 }
 
 func TestInvalidUnit(t *testing.T) {
-	_, err := service.ParseDurationWithDays("2x")
+	_, err := common.ParseDurationWithDays("2x")
 	assert.Error(t, err)
 	assert.Equal(t, "time: unknown unit \"x\" in duration \"2x\"", err.Error())
 }
 
 func TestValidDelayDays(t *testing.T) {
-	duration, err := service.ParseDurationWithDays("5d")
+	duration, err := common.ParseDurationWithDays("5d")
 	assert.NoError(t, err)
 	assert.Equal(t, 5*24*time.Hour, duration)
 }
 
 func TestInvalidDelayDays(t *testing.T) {
-	_, err := service.ParseDurationWithDays("ad")
+	_, err := common.ParseDurationWithDays("ad")
 	assert.Error(t, err)
 	assert.Equal(t, "strconv.Atoi: parsing \"a\": invalid syntax", err.Error())
 }
 
 func TestValidDelayHours(t *testing.T) {
-	duration, err := service.ParseDurationWithDays("5h")
+	duration, err := common.ParseDurationWithDays("5h")
 	assert.NoError(t, err)
 	assert.Equal(t, 5*time.Hour, duration)
 }
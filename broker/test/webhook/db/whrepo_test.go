@@ -0,0 +1,217 @@
+package wh_db
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/indexdata/crosslink/broker/app"
+	"github.com/indexdata/crosslink/broker/common"
+	test "github.com/indexdata/crosslink/broker/test/utils"
+	wh_db "github.com/indexdata/crosslink/broker/webhook/db"
+	"github.com/indexdata/go-utils/utils"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+var whRepo wh_db.WhRepo
+var appCtx = common.CreateExtCtxWithArgs(context.Background(), nil)
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+
+	pgContainer, err := postgres.Run(ctx, "postgres",
+		postgres.WithDatabase("crosslink"),
+		postgres.WithUsername("crosslink"),
+		postgres.WithPassword("crosslink"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).WithStartupTimeout(30*time.Second)),
+	)
+	test.Expect(err, "failed to start db container")
+
+	connStr, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
+	test.Expect(err, "failed to get conn string")
+
+	app.ConnectionString = connStr
+	app.MigrationsFolder = "file://../../../migrations"
+	app.HTTP_PORT = utils.Must(test.GetFreePort())
+	app.DB_PROVISION = true
+
+	test.Expect(app.RunDbUp(), "failed to run db migrations")
+
+	pool, err := app.InitDbPool()
+	test.Expect(err, "failed to init db pool")
+
+	whRepo = wh_db.CreateWhRepo(pool)
+
+	code := m.Run()
+	pool.Close()
+	test.Expect(test.TerminatePGContainer(ctx, pgContainer), "failed to stop db container")
+	os.Exit(code)
+}
+
+func saveSubscription(t *testing.T, owner string, eventNames []string, side string, states []string, active bool) wh_db.WebhookSubscription {
+	t.Helper()
+	now := pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	sub, err := whRepo.SaveWebhookSubscription(appCtx, wh_db.SaveWebhookSubscriptionParams{
+		ID:         uuid.NewString(),
+		Owner:      owner,
+		Url:        "http://localhost/hook",
+		Secret:     "s3cret",
+		EventNames: eventNames,
+		Side:       pgtype.Text{String: side, Valid: side != ""},
+		States:     states,
+		Active:     active,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	})
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, whRepo.DeleteWebhookSubscription(appCtx, sub.ID, nil))
+	})
+	return sub
+}
+
+func createDelivery(t *testing.T, subscriptionID string, eventID string, next time.Time) (wh_db.WebhookDelivery, error) {
+	t.Helper()
+	return whRepo.CreateWebhookDelivery(appCtx, wh_db.CreateWebhookDeliveryParams{
+		ID:             uuid.NewString(),
+		SubscriptionID: subscriptionID,
+		EventID:        eventID,
+		EventName:      "invoke-action",
+		Signal:         "task_complete",
+		Payload:        []byte(`{"event":{"id":"` + eventID + `"}}`),
+		Status:         wh_db.WebhookDeliveryStatusPending,
+		NextAttemptAt:  pgtype.Timestamptz{Time: next, Valid: true},
+		CreatedAt:      pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	})
+}
+
+func TestWebhookSubscriptionOwnerScope(t *testing.T) {
+	owner := "ISIL:" + uuid.NewString()
+	sub := saveSubscription(t, owner, []string{}, "", []string{}, true)
+
+	found, err := whRepo.GetWebhookSubscriptionById(appCtx, sub.ID, []string{owner})
+	assert.NoError(t, err)
+	assert.Equal(t, owner, found.Owner)
+	assert.Empty(t, found.EventNames)
+	assert.False(t, found.Side.Valid)
+
+	_, err = whRepo.GetWebhookSubscriptionById(appCtx, sub.ID, []string{"ISIL:OTHER"})
+	assert.True(t, errors.Is(err, pgx.ErrNoRows))
+
+	subs, count, err := whRepo.GetWebhookSubscriptions(appCtx, wh_db.GetWebhookSubscriptionsParams{
+		Owners: []string{owner}, Limit: 10,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.Len(t, subs, 1)
+
+	assert.NoError(t, whRepo.DeleteWebhookSubscription(appCtx, sub.ID, []string{"ISIL:OTHER"}))
+	_, err = whRepo.GetWebhookSubscriptionById(appCtx, sub.ID, nil)
+	assert.NoError(t, err)
+}
+
+func TestGetMatchingWebhookSubscriptions(t *testing.T) {
+	owner := "ISIL:" + uuid.NewString()
+	all := saveSubscription(t, owner, []string{}, "", []string{}, true)
+	byEvent := saveSubscription(t, owner, []string{"invoke-action"}, "", []string{}, true)
+	bySide := saveSubscription(t, owner, []string{}, "lending", []string{}, true)
+	byState := saveSubscription(t, owner, []string{}, "", []string{"LOANED", "SHIPPED"}, true)
+	saveSubscription(t, owner, []string{}, "", []string{}, false)
+	saveSubscription(t, "ISIL:"+uuid.NewString(), []string{}, "", []string{}, true)
+
+	ids := func(params wh_db.GetMatchingWebhookSubscriptionsParams) []string {
+		subs, err := whRepo.GetMatchingWebhookSubscriptions(appCtx, params)
+		assert.NoError(t, err)
+		var ids []string
+		for _, s := range subs {
+			ids = append(ids, s.ID)
+		}
+		return ids
+	}
+
+	assert.ElementsMatch(t, []string{all.ID, byEvent.ID, bySide.ID, byState.ID}, ids(wh_db.GetMatchingWebhookSubscriptionsParams{
+		Owner:     owner,
+		EventName: "invoke-action",
+		Side:      pgtype.Text{String: "lending", Valid: true},
+		State:     pgtype.Text{String: "LOANED", Valid: true},
+	}))
+	assert.ElementsMatch(t, []string{all.ID}, ids(wh_db.GetMatchingWebhookSubscriptionsParams{
+		Owner:     owner,
+		EventName: "send-notification",
+		Side:      pgtype.Text{String: "borrowing", Valid: true},
+		State:     pgtype.Text{String: "VALIDATED", Valid: true},
+	}))
+	assert.ElementsMatch(t, []string{all.ID, byEvent.ID}, ids(wh_db.GetMatchingWebhookSubscriptionsParams{
+		Owner:     owner,
+		EventName: "invoke-action",
+	}))
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	sub := saveSubscription(t, "ISIL:"+uuid.NewString(), []string{}, "", []string{}, true)
+	eventID := uuid.NewString()
+
+	due, err := createDelivery(t, sub.ID, eventID, time.Now().Add(-time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), due.Attempts)
+
+	// the same event signal is only delivered once per subscription
+	_, err = createDelivery(t, sub.ID, eventID, time.Now())
+	assert.True(t, errors.Is(err, pgx.ErrNoRows))
+
+	_, err = createDelivery(t, sub.ID, uuid.NewString(), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	claimed, err := whRepo.ClaimDueWebhookDeliveries(appCtx, time.Minute, 100)
+	assert.NoError(t, err)
+	assert.Contains(t, deliveryIds(claimed), due.ID)
+	claimed, err = whRepo.ClaimDueWebhookDeliveries(appCtx, time.Minute, 100)
+	assert.NoError(t, err)
+	assert.NotContains(t, deliveryIds(claimed), due.ID)
+
+	updated, err := whRepo.UpdateWebhookDelivery(appCtx, wh_db.UpdateWebhookDeliveryParams{
+		ID:             due.ID,
+		Status:         wh_db.WebhookDeliveryStatusDelivered,
+		Attempts:       1,
+		LastAttemptAt:  pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		ResponseStatus: pgtype.Int4{Int32: 200, Valid: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, wh_db.WebhookDeliveryStatusDelivered, updated.Status)
+	assert.False(t, updated.NextAttemptAt.Valid)
+
+	deliveries, count, err := whRepo.GetWebhookDeliveries(appCtx, wh_db.GetWebhookDeliveriesParams{
+		SubscriptionID: sub.ID,
+		Limit:          10,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.Len(t, deliveries, 2)
+
+	deliveries, count, err = whRepo.GetWebhookDeliveries(appCtx, wh_db.GetWebhookDeliveriesParams{
+		SubscriptionID: sub.ID,
+		Status:         pgtype.Text{String: string(wh_db.WebhookDeliveryStatusDelivered), Valid: true},
+		Limit:          10,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, due.ID, deliveries[0].ID)
+}
+
+func deliveryIds(deliveries []wh_db.WebhookDelivery) []string {
+	ids := make([]string, 0, len(deliveries))
+	for _, d := range deliveries {
+		ids = append(ids, d.ID)
+	}
+	return ids
+}
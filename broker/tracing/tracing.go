@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/vcs"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const TracerName = "github.com/indexdata/crosslink/broker"
+
+const TraceParentKey = "traceparent"
+
+// propagator is used for the trace context stored with events, independent of the global one
+var propagator = propagation.TraceContext{}
+
+// Init installs the global propagator and, if endpoint is not empty, a tracer provider
+// exporting spans via OTLP over HTTP. As with OTEL_EXPORTER_OTLP_ENDPOINT, endpoint is the
+// base URL of the collector and spans are sent to its /v1/traces path. Without an endpoint spans are not recorded but the
+// trace context is still propagated. The returned function flushes and stops the exporter.
+func Init(ctx context.Context, endpoint string, serviceName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(strings.TrimSuffix(endpoint, "/")+"/v1/traces"))
+	if err != nil {
+		return nil, err
+	}
+	provider := NewTracerProvider(sdktrace.WithBatcher(exporter), serviceName)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// NewTracerProvider returns a provider sending spans to the processor, tests can use
+// sdktrace.WithSyncer with an in-memory exporter
+func NewTracerProvider(processor sdktrace.TracerProviderOption, serviceName string) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(processor,
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(serviceName),
+			semconv.ServiceVersion(vcs.GetCommit()))))
+}
+
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// Start starts a span as a child of the span in ctx and returns a context carrying it
+func Start(ctx common.ExtendedContext, name string, opts ...trace.SpanStartOption) (common.ExtendedContext, trace.Span) {
+	spanCtx, span := Tracer().Start(ctx, name, opts...)
+	return ctx.WithContext(spanCtx), span
+}
+
+// End marks the span as failed if err is not nil and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceParent returns the W3C traceparent of the span in ctx, or an empty string if there is none
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	return carrier.Get(TraceParentKey)
+}
+
+// WithTraceParent returns ctx continuing the trace of traceParent, e.g. one stored with an event
+// by another instance. ctx is returned as is if traceParent is empty or invalid.
+func WithTraceParent(ctx common.ExtendedContext, traceParent string) common.ExtendedContext {
+	if traceParent == "" {
+		return ctx
+	}
+	remoteCtx := propagator.Extract(ctx, propagation.MapCarrier{TraceParentKey: traceParent})
+	if !trace.SpanContextFromContext(remoteCtx).IsValid() {
+		return ctx
+	}
+	return ctx.WithContext(remoteCtx)
+}
@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/indexdata/crosslink/broker/common"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func setupExporter(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(NewTracerProvider(sdktrace.WithSyncer(exporter), "test"))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+	return exporter
+}
+
+func TestInitWithoutEndpoint(t *testing.T) {
+	shutdown, err := Init(context.Background(), "", "test")
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+	assert.Contains(t, otel.GetTextMapPropagator().Fields(), TraceParentKey)
+}
+
+func TestInitWithEndpoint(t *testing.T) {
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+	shutdown, err := Init(context.Background(), "http://localhost:4318/", "test")
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestStartAndEnd(t *testing.T) {
+	exporter := setupExporter(t)
+	ctx := common.CreateExtCtxWithArgs(context.Background(), nil)
+
+	parentCtx, parent := Start(ctx, "parent")
+	childCtx, child := Start(parentCtx, "child")
+	assert.NotEmpty(t, TraceParent(childCtx))
+	End(child, errors.New("boom"))
+	End(parent, nil)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, "boom", spans[0].Status.Description)
+	assert.Len(t, spans[0].Events, 1)
+	assert.Equal(t, "parent", spans[1].Name)
+	assert.Equal(t, codes.Unset, spans[1].Status.Code)
+	assert.Equal(t, spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID())
+}
+
+func TestTraceParent(t *testing.T) {
+	setupExporter(t)
+	ctx := common.CreateExtCtxWithArgs(context.Background(), nil)
+	assert.Empty(t, TraceParent(ctx))
+
+	spanCtx, span := Start(ctx, "request")
+	defer span.End()
+	traceParent := TraceParent(spanCtx)
+	assert.Regexp(t, "^00-[0-9a-f]{32}-[0-9a-f]{16}-01$", traceParent)
+
+	remoteCtx := WithTraceParent(ctx, traceParent)
+	remote := trace.SpanContextFromContext(remoteCtx)
+	assert.True(t, remote.IsRemote())
+	assert.Equal(t, span.SpanContext().TraceID(), remote.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), remote.SpanID())
+	assert.Equal(t, traceParent, TraceParent(remoteCtx))
+}
+
+func TestWithTraceParentInvalid(t *testing.T) {
+	ctx := common.CreateExtCtxWithArgs(context.Background(), nil)
+	assert.Equal(t, ctx, WithTraceParent(ctx, ""))
+	assert.Equal(t, ctx, WithTraceParent(ctx, "not-a-trace-parent"))
+}
@@ -0,0 +1,355 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	brokerapi "github.com/indexdata/crosslink/broker/api"
+	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/tenant"
+	wh_db "github.com/indexdata/crosslink/broker/webhook/db"
+	whoapi "github.com/indexdata/crosslink/broker/webhook/oapi"
+	wh_service "github.com/indexdata/crosslink/broker/webhook/service"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// WebhookApiHandler implements whoapi.ServerInterface.
+type WebhookApiHandler struct {
+	limitDefault   int32
+	whRepo         wh_db.WhRepo
+	tenantResolver *tenant.TenantResolver
+}
+
+// NewWebhookApiHandler creates a WebhookApiHandler.
+func NewWebhookApiHandler(limitDefault int32, whRepo wh_db.WhRepo, tenantResolver *tenant.TenantResolver) WebhookApiHandler {
+	return WebhookApiHandler{
+		limitDefault:   limitDefault,
+		whRepo:         whRepo,
+		tenantResolver: tenantResolver,
+	}
+}
+
+// GetWebhooks lists the webhook subscriptions of the resolved owners, with pagination.
+func (h WebhookApiHandler) GetWebhooks(w http.ResponseWriter, r *http.Request, params whoapi.GetWebhooksParams) {
+	logParams := map[string]string{"method": "GetWebhooks"}
+	ctx := common.CreateExtCtxWithArgs(r.Context(), &common.LoggerArgs{Other: logParams})
+
+	owners, ok := h.resolveOwnerScope(ctx, w, r, params.Symbol)
+	if !ok {
+		return
+	}
+	limit, offset := h.getPage(params.Limit, params.Offset)
+	items, count, err := h.whRepo.GetWebhookSubscriptions(ctx, wh_db.GetWebhookSubscriptionsParams{
+		Owners: owners,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		brokerapi.AddInternalError(ctx, w, err)
+		return
+	}
+	list := make([]whoapi.WebhookSubscription, 0, len(items))
+	for _, sub := range items {
+		list = append(list, toWebhookSubscription(r, sub))
+	}
+	brokerapi.WriteJsonResponse(w, whoapi.WebhookSubscriptions{
+		About: whoapi.About(brokerapi.CollectAboutData(count, offset, limit, r)),
+		Items: list,
+	})
+}
+
+// PostWebhooks creates a webhook subscription for the request symbol.
+func (h WebhookApiHandler) PostWebhooks(w http.ResponseWriter, r *http.Request, params whoapi.PostWebhooksParams) {
+	logParams := map[string]string{"method": "PostWebhooks"}
+	ctx := common.CreateExtCtxWithArgs(r.Context(), &common.LoggerArgs{Other: logParams})
+
+	create, ok := readSubscription(ctx, w, r)
+	if !ok {
+		return
+	}
+	t, err := h.tenantResolver.Resolve(ctx, r, params.Symbol)
+	if err != nil {
+		brokerapi.AddBadRequestError(ctx, w, err)
+		return
+	}
+	owner, err := t.GetRequestSymbol()
+	if err != nil {
+		brokerapi.AddBadRequestError(ctx, w, err)
+		return
+	}
+	if owner == "" {
+		brokerapi.AddBadRequestError(ctx, w, errors.New("symbol must be specified"))
+		return
+	}
+	now := pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	sub := wh_db.WebhookSubscription{
+		ID:        uuid.New().String(),
+		Owner:     owner,
+		CreatedAt: now,
+	}
+	applySubscription(&sub, create)
+	sub, err = h.whRepo.SaveWebhookSubscription(ctx, wh_db.SaveWebhookSubscriptionParams(sub))
+	if err != nil {
+		brokerapi.AddInternalError(ctx, w, err)
+		return
+	}
+	w.Header().Set("Location", brokerapi.Link(r, brokerapi.Path("webhooks", sub.ID), nil))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(toWebhookSubscription(r, sub))
+}
+
+// GetWebhooksId returns a single webhook subscription by ID.
+func (h WebhookApiHandler) GetWebhooksId(w http.ResponseWriter, r *http.Request, id string, params whoapi.GetWebhooksIdParams) {
+	sub, _, done := h.getSubscription(w, r, "GetWebhooksId", id, params.Symbol)
+	if done {
+		return
+	}
+	brokerapi.WriteJsonResponse(w, toWebhookSubscription(r, sub))
+}
+
+// PutWebhooksId replaces the settings of a webhook subscription.
+func (h WebhookApiHandler) PutWebhooksId(w http.ResponseWriter, r *http.Request, id string, params whoapi.PutWebhooksIdParams) {
+	logParams := map[string]string{"method": "PutWebhooksId", "id": id}
+	ctx := common.CreateExtCtxWithArgs(r.Context(), &common.LoggerArgs{Other: logParams})
+
+	owners, ok := h.resolveOwnerScope(ctx, w, r, params.Symbol)
+	if !ok {
+		return
+	}
+	update, ok := readSubscription(ctx, w, r)
+	if !ok {
+		return
+	}
+	var sub wh_db.WebhookSubscription
+	err := h.whRepo.WithTxFunc(ctx, func(repo wh_db.WhRepo) error {
+		var inErr error
+		sub, inErr = repo.GetWebhookSubscriptionById(ctx, id, owners)
+		if inErr != nil {
+			return inErr
+		}
+		applySubscription(&sub, update)
+		sub, inErr = repo.SaveWebhookSubscription(ctx, wh_db.SaveWebhookSubscriptionParams(sub))
+		return inErr
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			brokerapi.AddNotFoundError(w)
+			return
+		}
+		brokerapi.AddInternalError(ctx, w, err)
+		return
+	}
+	brokerapi.WriteJsonResponse(w, toWebhookSubscription(r, sub))
+}
+
+// DeleteWebhooksId deletes a webhook subscription and its delivery log.
+func (h WebhookApiHandler) DeleteWebhooksId(w http.ResponseWriter, r *http.Request, id string, params whoapi.DeleteWebhooksIdParams) {
+	sub, ctx, done := h.getSubscription(w, r, "DeleteWebhooksId", id, params.Symbol)
+	if done {
+		return
+	}
+	if err := h.whRepo.DeleteWebhookSubscription(ctx, sub.ID, []string{sub.Owner}); err != nil {
+		brokerapi.AddInternalError(ctx, w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWebhooksIdDeliveries lists the deliveries of a webhook subscription, newest first.
+func (h WebhookApiHandler) GetWebhooksIdDeliveries(w http.ResponseWriter, r *http.Request, id string, params whoapi.GetWebhooksIdDeliveriesParams) {
+	sub, ctx, done := h.getSubscription(w, r, "GetWebhooksIdDeliveries", id, params.Symbol)
+	if done {
+		return
+	}
+	var status pgtype.Text
+	if params.Status != nil {
+		if !params.Status.Valid() {
+			brokerapi.AddBadRequestError(ctx, w, errors.New("unknown status: "+string(*params.Status)))
+			return
+		}
+		status = pgtype.Text{String: string(*params.Status), Valid: true}
+	}
+	limit, offset := h.getPage(params.Limit, params.Offset)
+	items, count, err := h.whRepo.GetWebhookDeliveries(ctx, wh_db.GetWebhookDeliveriesParams{
+		SubscriptionID: sub.ID,
+		Status:         status,
+		Limit:          limit,
+		Offset:         offset,
+	})
+	if err != nil {
+		brokerapi.AddInternalError(ctx, w, err)
+		return
+	}
+	list := make([]whoapi.WebhookDelivery, 0, len(items))
+	for _, delivery := range items {
+		list = append(list, toWebhookDelivery(delivery))
+	}
+	brokerapi.WriteJsonResponse(w, whoapi.WebhookDeliveries{
+		About: whoapi.About(brokerapi.CollectAboutData(count, offset, limit, r)),
+		Items: list,
+	})
+}
+
+func (h WebhookApiHandler) getSubscription(w http.ResponseWriter, r *http.Request, methodName string, id string, symbol *whoapi.Symbol) (wh_db.WebhookSubscription, common.ExtendedContext, bool) {
+	logParams := map[string]string{"method": methodName, "id": id}
+	ctx := common.CreateExtCtxWithArgs(r.Context(), &common.LoggerArgs{Other: logParams})
+
+	owners, ok := h.resolveOwnerScope(ctx, w, r, symbol)
+	if !ok {
+		return wh_db.WebhookSubscription{}, ctx, true
+	}
+	sub, err := h.whRepo.GetWebhookSubscriptionById(ctx, id, owners)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			brokerapi.AddNotFoundError(w)
+			return wh_db.WebhookSubscription{}, ctx, true
+		}
+		brokerapi.AddInternalError(ctx, w, err)
+		return wh_db.WebhookSubscription{}, ctx, true
+	}
+	return sub, ctx, false
+}
+
+// resolveOwnerScope returns the owners the request may access. A nil scope
+// means unrestricted master access.
+func (h WebhookApiHandler) resolveOwnerScope(ctx common.ExtendedContext, w http.ResponseWriter, r *http.Request, symbol *string) ([]string, bool) {
+	t, err := h.tenantResolver.Resolve(ctx, r, symbol)
+	if err != nil {
+		brokerapi.AddBadRequestError(ctx, w, err)
+		return nil, false
+	}
+	owners, err := t.GetOwnedSymbols()
+	if err != nil {
+		brokerapi.AddBadRequestError(ctx, w, err)
+		return nil, false
+	}
+	return owners, true
+}
+
+func (h WebhookApiHandler) getPage(limitParam *int32, offsetParam *int32) (int32, int32) {
+	limit := h.limitDefault
+	if limitParam != nil && *limitParam > 0 {
+		limit = *limitParam
+	}
+	offset := int32(0)
+	if offsetParam != nil && *offsetParam > 0 {
+		offset = *offsetParam
+	}
+	return limit, offset
+}
+
+func readSubscription(ctx common.ExtendedContext, w http.ResponseWriter, r *http.Request) (whoapi.CreateWebhookSubscription, bool) {
+	var create whoapi.CreateWebhookSubscription
+	if r.Body == nil || r.Body == http.NoBody {
+		brokerapi.AddBadRequestError(ctx, w, errors.New("missing body"))
+		return create, false
+	}
+	if err := json.NewDecoder(r.Body).Decode(&create); err != nil {
+		brokerapi.AddBadRequestError(ctx, w, err)
+		return create, false
+	}
+	if err := validateSubscription(create); err != nil {
+		brokerapi.AddBadRequestError(ctx, w, err)
+		return create, false
+	}
+	return create, true
+}
+
+func validateSubscription(create whoapi.CreateWebhookSubscription) error {
+	u, err := url.Parse(create.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	if create.Secret == "" {
+		return errors.New("secret must not be empty")
+	}
+	if create.Side != nil && !create.Side.Valid() {
+		return errors.New("unknown side: " + string(*create.Side))
+	}
+	if create.EventNames != nil {
+		for _, name := range *create.EventNames {
+			if !wh_service.IsObservedEventName(name) {
+				return errors.New("unknown eventName: " + name)
+			}
+		}
+	}
+	return nil
+}
+
+func applySubscription(sub *wh_db.WebhookSubscription, create whoapi.CreateWebhookSubscription) {
+	sub.Url = create.Url
+	sub.Secret = create.Secret
+	sub.EventNames = []string{}
+	if create.EventNames != nil {
+		sub.EventNames = *create.EventNames
+	}
+	sub.States = []string{}
+	if create.States != nil {
+		sub.States = *create.States
+	}
+	sub.Side = pgtype.Text{}
+	if create.Side != nil {
+		sub.Side = pgtype.Text{String: string(*create.Side), Valid: true}
+	}
+	sub.Active = create.Active == nil || *create.Active
+}
+
+func toWebhookSubscription(r *http.Request, sub wh_db.WebhookSubscription) whoapi.WebhookSubscription {
+	resp := whoapi.WebhookSubscription{
+		Id:             sub.ID,
+		Owner:          sub.Owner,
+		Url:            sub.Url,
+		EventNames:     sub.EventNames,
+		States:         sub.States,
+		Active:         sub.Active,
+		CreatedAt:      sub.CreatedAt.Time,
+		DeliveriesLink: brokerapi.Link(r, brokerapi.Path("webhooks", sub.ID, "deliveries"), nil),
+	}
+	if resp.EventNames == nil {
+		resp.EventNames = []string{}
+	}
+	if resp.States == nil {
+		resp.States = []string{}
+	}
+	if sub.Side.Valid {
+		side := whoapi.WebhookSide(sub.Side.String)
+		resp.Side = &side
+	}
+	if sub.UpdatedAt.Valid {
+		resp.UpdatedAt = &sub.UpdatedAt.Time
+	}
+	return resp
+}
+
+func toWebhookDelivery(delivery wh_db.WebhookDelivery) whoapi.WebhookDelivery {
+	resp := whoapi.WebhookDelivery{
+		Id:             delivery.ID,
+		SubscriptionId: delivery.SubscriptionID,
+		EventId:        delivery.EventID,
+		EventName:      delivery.EventName,
+		Signal:         delivery.Signal,
+		Status:         whoapi.WebhookDeliveryStatus(delivery.Status),
+		Attempts:       delivery.Attempts,
+		CreatedAt:      delivery.CreatedAt.Time,
+	}
+	_ = json.Unmarshal(delivery.Payload, &resp.Payload)
+	if delivery.NextAttemptAt.Valid && delivery.Status == wh_db.WebhookDeliveryStatusPending {
+		resp.NextAttemptAt = &delivery.NextAttemptAt.Time
+	}
+	if delivery.LastAttemptAt.Valid {
+		resp.LastAttemptAt = &delivery.LastAttemptAt.Time
+	}
+	if delivery.ResponseStatus.Valid {
+		resp.ResponseStatus = &delivery.ResponseStatus.Int32
+	}
+	if delivery.Error.Valid {
+		resp.Error = &delivery.Error.String
+	}
+	return resp
+}
@@ -0,0 +1,311 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/tenant"
+	testmocks "github.com/indexdata/crosslink/broker/test/mocks"
+	wh_db "github.com/indexdata/crosslink/broker/webhook/db"
+	whoapi "github.com/indexdata/crosslink/broker/webhook/oapi"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ── mock ──────────────────────────────────────────────────────────────────────
+
+type MockWhRepo struct {
+	mock.Mock
+	wh_db.WhRepo // satisfies unimplemented interface methods
+}
+
+// WithTxFunc calls fn(mock) directly, simulating a pass-through transaction.
+func (m *MockWhRepo) WithTxFunc(_ common.ExtendedContext, fn func(wh_db.WhRepo) error) error {
+	return fn(m)
+}
+
+func (m *MockWhRepo) SaveWebhookSubscription(_ common.ExtendedContext, params wh_db.SaveWebhookSubscriptionParams) (wh_db.WebhookSubscription, error) {
+	args := m.Called(params)
+	if args.Error(1) != nil {
+		return wh_db.WebhookSubscription{}, args.Error(1)
+	}
+	return wh_db.WebhookSubscription(params), nil
+}
+
+func (m *MockWhRepo) GetWebhookSubscriptionById(_ common.ExtendedContext, id string, owners []string) (wh_db.WebhookSubscription, error) {
+	args := m.Called(id, owners)
+	return args.Get(0).(wh_db.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWhRepo) GetWebhookSubscriptions(_ common.ExtendedContext, params wh_db.GetWebhookSubscriptionsParams) ([]wh_db.WebhookSubscription, int64, error) {
+	args := m.Called(params)
+	return args.Get(0).([]wh_db.WebhookSubscription), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockWhRepo) DeleteWebhookSubscription(_ common.ExtendedContext, id string, owners []string) error {
+	args := m.Called(id, owners)
+	return args.Error(0)
+}
+
+func (m *MockWhRepo) GetWebhookDeliveries(_ common.ExtendedContext, params wh_db.GetWebhookDeliveriesParams) ([]wh_db.WebhookDelivery, int64, error) {
+	args := m.Called(params)
+	return args.Get(0).([]wh_db.WebhookDelivery), args.Get(1).(int64), args.Error(2)
+}
+
+// ── helpers ───────────────────────────────────────────────────────────────────
+
+const testSymbol = "ISIL:TEST"
+
+var testOwnerScope = []string{testSymbol, "ISIL:S1"}
+
+func newHandler(repo wh_db.WhRepo) WebhookApiHandler {
+	resolver := tenant.NewResolver().WithIllRepo(new(testmocks.MockIllRepositorySuccess))
+	return NewWebhookApiHandler(10, repo, resolver)
+}
+
+func newReq(method, body string) *http.Request {
+	if body != "" {
+		return httptest.NewRequest(method, "/webhooks", strings.NewReader(body))
+	}
+	return httptest.NewRequest(method, "/webhooks", nil)
+}
+
+func symPtr(s string) *string { return &s }
+
+func subscriptionFixture(id string) wh_db.WebhookSubscription {
+	now := time.Now().UTC()
+	return wh_db.WebhookSubscription{
+		ID:         id,
+		Owner:      testSymbol,
+		Url:        "https://hooks.example.com/crosslink",
+		Secret:     "s3cret",
+		EventNames: []string{"invoke-action"},
+		Side:       pgtype.Text{String: "borrowing", Valid: true},
+		States:     []string{},
+		Active:     true,
+		CreatedAt:  pgtype.Timestamptz{Time: now, Valid: true},
+		UpdatedAt:  pgtype.Timestamptz{Time: now, Valid: true},
+	}
+}
+
+// ── subscriptions ─────────────────────────────────────────────────────────────
+
+func TestGetWebhooks_OK(t *testing.T) {
+	repo := new(MockWhRepo)
+	repo.On("GetWebhookSubscriptions", wh_db.GetWebhookSubscriptionsParams{
+		Owners: testOwnerScope, Limit: 10, Offset: 0,
+	}).Return([]wh_db.WebhookSubscription{subscriptionFixture("wh-1")}, int64(1), nil)
+
+	rr := httptest.NewRecorder()
+	newHandler(repo).GetWebhooks(rr, newReq(http.MethodGet, ""), whoapi.GetWebhooksParams{Symbol: symPtr(testSymbol)})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp whoapi.WebhookSubscriptions
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, int64(1), resp.About.Count)
+	assert.Len(t, resp.Items, 1)
+	assert.Equal(t, "wh-1", resp.Items[0].Id)
+	assert.Equal(t, whoapi.Borrowing, *resp.Items[0].Side)
+	assert.Equal(t, "https://example.com/webhooks/wh-1/deliveries", resp.Items[0].DeliveriesLink)
+	assert.NotContains(t, rr.Body.String(), "s3cret")
+	repo.AssertExpectations(t)
+}
+
+func TestGetWebhooks_DBError(t *testing.T) {
+	repo := new(MockWhRepo)
+	repo.On("GetWebhookSubscriptions", mock.Anything).Return([]wh_db.WebhookSubscription{}, int64(0), errors.New("db error"))
+
+	rr := httptest.NewRecorder()
+	newHandler(repo).GetWebhooks(rr, newReq(http.MethodGet, ""), whoapi.GetWebhooksParams{Symbol: symPtr(testSymbol)})
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestPostWebhooks_OK(t *testing.T) {
+	repo := new(MockWhRepo)
+	repo.On("SaveWebhookSubscription", mock.MatchedBy(func(p wh_db.SaveWebhookSubscriptionParams) bool {
+		return p.ID != "" &&
+			p.Owner == testSymbol &&
+			p.Url == "https://hooks.example.com/crosslink" &&
+			p.Secret == "s3cret" &&
+			assert.ObjectsAreEqual([]string{"invoke-action"}, p.EventNames) &&
+			assert.ObjectsAreEqual([]string{"LOANED"}, p.States) &&
+			p.Side.String == "lending" &&
+			p.Active &&
+			p.CreatedAt.Valid
+	})).Return(nil, nil)
+
+	body := `{"url":"https://hooks.example.com/crosslink","secret":"s3cret","eventNames":["invoke-action"],"side":"lending","states":["LOANED"]}`
+	rr := httptest.NewRecorder()
+	newHandler(repo).PostWebhooks(rr, newReq(http.MethodPost, body), whoapi.PostWebhooksParams{Symbol: symPtr(testSymbol)})
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.NotEmpty(t, rr.Header().Get("Location"))
+	var resp whoapi.WebhookSubscription
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, testSymbol, resp.Owner)
+	assert.True(t, resp.Active)
+	assert.NotContains(t, rr.Body.String(), "s3cret")
+	repo.AssertExpectations(t)
+}
+
+func TestPostWebhooks_MasterWithoutSymbol(t *testing.T) {
+	body := `{"url":"https://hooks.example.com/crosslink","secret":"s3cret"}`
+	rr := httptest.NewRecorder()
+	newHandler(new(MockWhRepo)).PostWebhooks(rr, newReq(http.MethodPost, body), whoapi.PostWebhooksParams{})
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "symbol must be specified")
+}
+
+func TestPostWebhooks_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		body string
+		err  string
+	}{
+		{"", "missing body"},
+		{"{", "unexpected EOF"},
+		{`{"url":"ftp://hooks.example.com","secret":"s"}`, "url must be an absolute http or https URL"},
+		{`{"url":"/relative","secret":"s"}`, "url must be an absolute http or https URL"},
+		{`{"url":"https://hooks.example.com"}`, "secret must not be empty"},
+		{`{"url":"https://hooks.example.com","secret":"s","side":"both"}`, "unknown side: both"},
+		{`{"url":"https://hooks.example.com","secret":"s","eventNames":["foo"]}`, "unknown eventName: foo"},
+	} {
+		rr := httptest.NewRecorder()
+		newHandler(new(MockWhRepo)).PostWebhooks(rr, newReq(http.MethodPost, tc.body), whoapi.PostWebhooksParams{Symbol: symPtr(testSymbol)})
+		assert.Equal(t, http.StatusBadRequest, rr.Code, tc.body)
+		assert.Contains(t, rr.Body.String(), tc.err, tc.body)
+	}
+}
+
+func TestGetWebhooksId_NotFound(t *testing.T) {
+	repo := new(MockWhRepo)
+	repo.On("GetWebhookSubscriptionById", "wh-1", testOwnerScope).Return(wh_db.WebhookSubscription{}, pgx.ErrNoRows)
+
+	rr := httptest.NewRecorder()
+	newHandler(repo).GetWebhooksId(rr, newReq(http.MethodGet, ""), "wh-1", whoapi.GetWebhooksIdParams{Symbol: symPtr(testSymbol)})
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestPutWebhooksId_OK(t *testing.T) {
+	repo := new(MockWhRepo)
+	repo.On("GetWebhookSubscriptionById", "wh-1", testOwnerScope).Return(subscriptionFixture("wh-1"), nil)
+	repo.On("SaveWebhookSubscription", mock.MatchedBy(func(p wh_db.SaveWebhookSubscriptionParams) bool {
+		return p.ID == "wh-1" &&
+			p.Owner == testSymbol &&
+			p.Url == "http://other.example.com" &&
+			len(p.EventNames) == 0 &&
+			!p.Side.Valid &&
+			!p.Active
+	})).Return(nil, nil)
+
+	body := `{"url":"http://other.example.com","secret":"new","active":false}`
+	rr := httptest.NewRecorder()
+	newHandler(repo).PutWebhooksId(rr, newReq(http.MethodPut, body), "wh-1", whoapi.PutWebhooksIdParams{Symbol: symPtr(testSymbol)})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp whoapi.WebhookSubscription
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.False(t, resp.Active)
+	assert.Nil(t, resp.Side)
+	repo.AssertExpectations(t)
+}
+
+func TestPutWebhooksId_NotFound(t *testing.T) {
+	repo := new(MockWhRepo)
+	repo.On("GetWebhookSubscriptionById", "wh-1", testOwnerScope).Return(wh_db.WebhookSubscription{}, pgx.ErrNoRows)
+
+	body := `{"url":"http://other.example.com","secret":"new"}`
+	rr := httptest.NewRecorder()
+	newHandler(repo).PutWebhooksId(rr, newReq(http.MethodPut, body), "wh-1", whoapi.PutWebhooksIdParams{Symbol: symPtr(testSymbol)})
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestDeleteWebhooksId_OK(t *testing.T) {
+	repo := new(MockWhRepo)
+	repo.On("GetWebhookSubscriptionById", "wh-1", testOwnerScope).Return(subscriptionFixture("wh-1"), nil)
+	repo.On("DeleteWebhookSubscription", "wh-1", []string{testSymbol}).Return(nil)
+
+	rr := httptest.NewRecorder()
+	newHandler(repo).DeleteWebhooksId(rr, newReq(http.MethodDelete, ""), "wh-1", whoapi.DeleteWebhooksIdParams{Symbol: symPtr(testSymbol)})
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+// ── deliveries ────────────────────────────────────────────────────────────────
+
+func TestGetWebhooksIdDeliveries_OK(t *testing.T) {
+	repo := new(MockWhRepo)
+	repo.On("GetWebhookSubscriptionById", "wh-1", testOwnerScope).Return(subscriptionFixture("wh-1"), nil)
+	now := time.Now().UTC()
+	repo.On("GetWebhookDeliveries", wh_db.GetWebhookDeliveriesParams{
+		SubscriptionID: "wh-1",
+		Status:         pgtype.Text{String: "pending", Valid: true},
+		Limit:          10,
+		Offset:         0,
+	}).Return([]wh_db.WebhookDelivery{{
+		ID:             "d-1",
+		SubscriptionID: "wh-1",
+		EventID:        "e-1",
+		EventName:      "invoke-action",
+		Signal:         "task_complete",
+		Payload:        []byte(`{"deliveryId":"d-1","subscriptionId":"wh-1","signal":"task_complete","owner":"ISIL:TEST","event":{"id":"e-1"}}`),
+		Status:         wh_db.WebhookDeliveryStatusPending,
+		Attempts:       2,
+		NextAttemptAt:  pgtype.Timestamptz{Time: now.Add(time.Minute), Valid: true},
+		LastAttemptAt:  pgtype.Timestamptz{Time: now, Valid: true},
+		ResponseStatus: pgtype.Int4{Int32: 503, Valid: true},
+		Error:          pgtype.Text{String: "unexpected HTTP status 503", Valid: true},
+		CreatedAt:      pgtype.Timestamptz{Time: now, Valid: true},
+	}}, int64(1), nil)
+
+	status := whoapi.Pending
+	rr := httptest.NewRecorder()
+	newHandler(repo).GetWebhooksIdDeliveries(rr, newReq(http.MethodGet, ""), "wh-1", whoapi.GetWebhooksIdDeliveriesParams{
+		Symbol: symPtr(testSymbol),
+		Status: &status,
+	})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp whoapi.WebhookDeliveries
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Len(t, resp.Items, 1)
+	item := resp.Items[0]
+	assert.Equal(t, "d-1", item.Id)
+	assert.Equal(t, whoapi.Pending, item.Status)
+	assert.Equal(t, int32(2), item.Attempts)
+	assert.Equal(t, int32(503), *item.ResponseStatus)
+	assert.Equal(t, "unexpected HTTP status 503", *item.Error)
+	assert.NotNil(t, item.NextAttemptAt)
+	assert.Equal(t, "e-1", item.Payload.Event.Id)
+	repo.AssertExpectations(t)
+}
+
+func TestGetWebhooksIdDeliveries_InvalidStatus(t *testing.T) {
+	repo := new(MockWhRepo)
+	repo.On("GetWebhookSubscriptionById", "wh-1", testOwnerScope).Return(subscriptionFixture("wh-1"), nil)
+
+	status := whoapi.WebhookDeliveryStatus("lost")
+	rr := httptest.NewRecorder()
+	newHandler(repo).GetWebhooksIdDeliveries(rr, newReq(http.MethodGet, ""), "wh-1", whoapi.GetWebhooksIdDeliveriesParams{
+		Symbol: symPtr(testSymbol),
+		Status: &status,
+	})
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,156 @@
+package wh_db
+
+import (
+	"time"
+
+	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/repo"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WhRepo interface {
+	repo.Transactional[WhRepo]
+	SaveWebhookSubscription(ctx common.ExtendedContext, params SaveWebhookSubscriptionParams) (WebhookSubscription, error)
+	GetWebhookSubscriptionById(ctx common.ExtendedContext, id string, owners []string) (WebhookSubscription, error)
+	GetWebhookSubscriptions(ctx common.ExtendedContext, params GetWebhookSubscriptionsParams) ([]WebhookSubscription, int64, error)
+	DeleteWebhookSubscription(ctx common.ExtendedContext, id string, owners []string) error
+	GetMatchingWebhookSubscriptions(ctx common.ExtendedContext, params GetMatchingWebhookSubscriptionsParams) ([]WebhookSubscription, error)
+	CreateWebhookDelivery(ctx common.ExtendedContext, params CreateWebhookDeliveryParams) (WebhookDelivery, error)
+	ClaimDueWebhookDeliveries(ctx common.ExtendedContext, claimFor time.Duration, batchSize int32) ([]WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx common.ExtendedContext, params UpdateWebhookDeliveryParams) (WebhookDelivery, error)
+	GetWebhookDeliveries(ctx common.ExtendedContext, params GetWebhookDeliveriesParams) ([]WebhookDelivery, int64, error)
+}
+
+type PgWhRepo struct {
+	repo.PgBaseRepo[WhRepo]
+	queries Queries
+}
+
+// WithTxFunc delegates transaction handling to PgBaseRepo.
+func (r *PgWhRepo) WithTxFunc(ctx common.ExtendedContext, fn func(WhRepo) error) error {
+	return r.PgBaseRepo.WithTxFunc(ctx, r, fn)
+}
+
+// CreateWithPgBaseRepo creates a derived repo bound to the provided tx-aware base.
+func (r *PgWhRepo) CreateWithPgBaseRepo(base *repo.PgBaseRepo[WhRepo]) WhRepo {
+	derived := new(PgWhRepo)
+	derived.PgBaseRepo = *base
+	return derived
+}
+
+// CreateWhRepo creates a new WhRepo backed by the given connection pool.
+func CreateWhRepo(dbPool *pgxpool.Pool) WhRepo {
+	r := new(PgWhRepo)
+	r.Pool = dbPool
+	return r
+}
+
+func (r *PgWhRepo) SaveWebhookSubscription(ctx common.ExtendedContext, params SaveWebhookSubscriptionParams) (WebhookSubscription, error) {
+	if !params.UpdatedAt.Valid {
+		params.UpdatedAt = params.CreatedAt
+	}
+	row, err := r.queries.SaveWebhookSubscription(ctx, r.GetConnOrTx(), params)
+	return row.WebhookSubscription, err
+}
+
+func (r *PgWhRepo) GetWebhookSubscriptionById(ctx common.ExtendedContext, id string, owners []string) (WebhookSubscription, error) {
+	row, err := r.queries.GetWebhookSubscriptionById(ctx, r.GetConnOrTx(), GetWebhookSubscriptionByIdParams{
+		ID:     id,
+		Owners: owners,
+	})
+	return row.WebhookSubscription, err
+}
+
+func (r *PgWhRepo) GetWebhookSubscriptions(ctx common.ExtendedContext, params GetWebhookSubscriptionsParams) ([]WebhookSubscription, int64, error) {
+	rows, err := r.queries.GetWebhookSubscriptions(ctx, r.GetConnOrTx(), params)
+	var subscriptions []WebhookSubscription
+	var fullCount int64
+	if err == nil {
+		if len(rows) > 0 {
+			fullCount = rows[0].FullCount
+			for _, r := range rows {
+				subscriptions = append(subscriptions, r.WebhookSubscription)
+			}
+		} else {
+			params.Limit = 1
+			params.Offset = 0
+			rows, err = r.queries.GetWebhookSubscriptions(ctx, r.GetConnOrTx(), params)
+			if err == nil && len(rows) > 0 {
+				fullCount = rows[0].FullCount
+			}
+		}
+	}
+	return subscriptions, fullCount, err
+}
+
+func (r *PgWhRepo) DeleteWebhookSubscription(ctx common.ExtendedContext, id string, owners []string) error {
+	return r.queries.DeleteWebhookSubscription(ctx, r.GetConnOrTx(), DeleteWebhookSubscriptionParams{
+		ID:     id,
+		Owners: owners,
+	})
+}
+
+func (r *PgWhRepo) GetMatchingWebhookSubscriptions(ctx common.ExtendedContext, params GetMatchingWebhookSubscriptionsParams) ([]WebhookSubscription, error) {
+	rows, err := r.queries.GetMatchingWebhookSubscriptions(ctx, r.GetConnOrTx(), params)
+	if err != nil {
+		return nil, err
+	}
+	subscriptions := make([]WebhookSubscription, 0, len(rows))
+	for _, row := range rows {
+		subscriptions = append(subscriptions, row.WebhookSubscription)
+	}
+	return subscriptions, nil
+}
+
+// CreateWebhookDelivery returns pgx.ErrNoRows if the subscription already has a
+// delivery for the event and signal.
+func (r *PgWhRepo) CreateWebhookDelivery(ctx common.ExtendedContext, params CreateWebhookDeliveryParams) (WebhookDelivery, error) {
+	row, err := r.queries.CreateWebhookDelivery(ctx, r.GetConnOrTx(), params)
+	return row.WebhookDelivery, err
+}
+
+// ClaimDueWebhookDeliveries returns pending deliveries whose next attempt is due and
+// pushes their next attempt forward by claimFor, so that other instances skip them
+// while they are being sent.
+func (r *PgWhRepo) ClaimDueWebhookDeliveries(ctx common.ExtendedContext, claimFor time.Duration, batchSize int32) ([]WebhookDelivery, error) {
+	rows, err := r.queries.ClaimDueWebhookDeliveries(ctx, r.GetConnOrTx(), ClaimDueWebhookDeliveriesParams{
+		ClaimFor:  pgtype.Interval{Microseconds: claimFor.Microseconds(), Valid: true},
+		BatchSize: batchSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	deliveries := make([]WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, row.WebhookDelivery)
+	}
+	return deliveries, nil
+}
+
+func (r *PgWhRepo) UpdateWebhookDelivery(ctx common.ExtendedContext, params UpdateWebhookDeliveryParams) (WebhookDelivery, error) {
+	row, err := r.queries.UpdateWebhookDelivery(ctx, r.GetConnOrTx(), params)
+	return row.WebhookDelivery, err
+}
+
+func (r *PgWhRepo) GetWebhookDeliveries(ctx common.ExtendedContext, params GetWebhookDeliveriesParams) ([]WebhookDelivery, int64, error) {
+	rows, err := r.queries.GetWebhookDeliveries(ctx, r.GetConnOrTx(), params)
+	var deliveries []WebhookDelivery
+	var fullCount int64
+	if err == nil {
+		if len(rows) > 0 {
+			fullCount = rows[0].FullCount
+			for _, r := range rows {
+				deliveries = append(deliveries, r.WebhookDelivery)
+			}
+		} else {
+			params.Limit = 1
+			params.Offset = 0
+			rows, err = r.queries.GetWebhookDeliveries(ctx, r.GetConnOrTx(), params)
+			if err == nil && len(rows) > 0 {
+				fullCount = rows[0].FullCount
+			}
+		}
+	}
+	return deliveries, fullCount, err
+}
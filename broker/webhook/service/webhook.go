@@ -0,0 +1,343 @@
+package wh_service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	brokerapi "github.com/indexdata/crosslink/broker/api"
+	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/events"
+	"github.com/indexdata/crosslink/broker/ill_db"
+	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
+	prservice "github.com/indexdata/crosslink/broker/patron_request/service"
+	"github.com/indexdata/crosslink/broker/retry"
+	wh_db "github.com/indexdata/crosslink/broker/webhook/db"
+	whoapi "github.com/indexdata/crosslink/broker/webhook/oapi"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const COMP = "webhook"
+
+const (
+	DEFAULT_MAX_ATTEMPTS  = 8
+	DEFAULT_RETRY_BACKOFF = 30 * time.Second
+	DEFAULT_POLL_INTERVAL = 15 * time.Second
+	DEFAULT_TIMEOUT       = 10 * time.Second
+	MAX_RETRY_BACKOFF     = time.Hour
+	// DELIVERY_CLAIM is how long a delivery being sent is hidden from other
+	// instances. It must exceed the HTTP client timeout.
+	DELIVERY_CLAIM      = 5 * time.Minute
+	DELIVERY_BATCH_SIZE = 100
+)
+
+const (
+	HeaderSignature = "X-Crosslink-Signature"
+	HeaderDelivery  = "X-Crosslink-Delivery"
+	HeaderEvent     = "X-Crosslink-Event"
+	SignaturePrefix = "sha256="
+)
+
+// ObservedEventNames are the patron request and ILL transaction events that
+// can be delivered to webhook subscriptions.
+var ObservedEventNames = []events.EventName{
+	events.EventNameRequestTerminated,
+	events.EventNameRequestReceived,
+	events.EventNameLocateSuppliers,
+	events.EventNameSelectSupplier,
+	events.EventNameSupplierMsgReceived,
+	events.EventNameMessageRequester,
+	events.EventNameRequesterMsgReceived,
+	events.EventNameMessageSupplier,
+	events.EventNameInvokeAction,
+	events.EventNamePatronRequestMessage,
+	events.EventNameLmsRequesterMessage,
+	events.EventNameLmsSupplierMessage,
+	events.EventNameSendNotification,
+	events.EventNameCheckAvailability,
+	events.EventNameInvokeBackgroundAction,
+}
+
+func IsObservedEventName(name string) bool {
+	for _, n := range ObservedEventNames {
+		if string(n) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns the X-Crosslink-Signature header value for a delivery body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return SignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+type WebhookService struct {
+	retry.Policy
+	whRepo  wh_db.WhRepo
+	prRepo  pr_db.PrRepo
+	illRepo ill_db.IllRepo
+	client  *http.Client
+}
+
+func NewWebhookService(whRepo wh_db.WhRepo, prRepo pr_db.PrRepo, illRepo ill_db.IllRepo, client *http.Client) *WebhookService {
+	return &WebhookService{
+		Policy: retry.Policy{
+			MaxAttempts:  DEFAULT_MAX_ATTEMPTS,
+			RetryBackoff: DEFAULT_RETRY_BACKOFF,
+			MaxBackoff:   MAX_RETRY_BACKOFF,
+			PollInterval: DEFAULT_POLL_INTERVAL,
+		},
+		whRepo:  whRepo,
+		prRepo:  prRepo,
+		illRepo: illRepo,
+		client:  client,
+	}
+}
+
+// EventCreated is a consumer handler for the task_created and notice_created signals.
+func (s *WebhookService) EventCreated(ctx common.ExtendedContext, event events.Event) {
+	signal := events.SignalTaskCreated
+	if event.EventType == events.EventTypeNotice {
+		signal = events.SignalNoticeCreated
+	}
+	s.enqueue(ctx, event, signal)
+}
+
+// TaskCompleted is a consumer handler for the task_complete signal.
+func (s *WebhookService) TaskCompleted(ctx common.ExtendedContext, event events.Event) {
+	s.enqueue(ctx, event, events.SignalTaskComplete)
+}
+
+// eventOwner is an institution that an event is delivered for, with the side
+// and state used for subscription filtering.
+type eventOwner struct {
+	symbol string
+	side   pgtype.Text
+	state  pgtype.Text
+}
+
+// enqueue creates a delivery for every subscription matching the event and sends
+// them in the background. A signal re-sent after a missed notification may reach
+// the handler again, so the delivery is only sent if it was not created before.
+func (s *WebhookService) enqueue(ctx common.ExtendedContext, event events.Event, signal events.Signal) {
+	ctx = ctx.WithArgs(ctx.LoggerArgs().WithComponent(COMP))
+	owners, err := s.resolveOwners(ctx, event)
+	if err != nil {
+		ctx.Logger().Error("failed to resolve webhook owners", "error", err, "eventId", event.ID, "eventName", event.EventName)
+		return
+	}
+	var deliveries []wh_db.WebhookDelivery
+	for _, owner := range owners {
+		subscriptions, err := s.whRepo.GetMatchingWebhookSubscriptions(ctx, wh_db.GetMatchingWebhookSubscriptionsParams{
+			Owner:     owner.symbol,
+			EventName: string(event.EventName),
+			Side:      owner.side,
+			State:     owner.state,
+		})
+		if err != nil {
+			ctx.Logger().Error("failed to read webhook subscriptions", "error", err, "owner", owner.symbol)
+			continue
+		}
+		for _, sub := range subscriptions {
+			delivery, err := s.createDelivery(ctx, sub, owner, event, signal)
+			if err != nil {
+				if !errors.Is(err, pgx.ErrNoRows) {
+					ctx.Logger().Error("failed to create webhook delivery", "error", err, "subscriptionId", sub.ID)
+				}
+				continue
+			}
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	if len(deliveries) > 0 {
+		go func() {
+			for _, delivery := range deliveries {
+				s.deliver(ctx, delivery)
+			}
+		}()
+	}
+}
+
+func (s *WebhookService) resolveOwners(ctx common.ExtendedContext, event events.Event) ([]eventOwner, error) {
+	if event.PatronRequestID != "" && !events.IsSyntheticID(event.PatronRequestID) {
+		pr, err := s.prRepo.GetPatronRequestById(ctx, event.PatronRequestID)
+		if err != nil {
+			return nil, err
+		}
+		symbol := pr.SupplierSymbol
+		if pr.Side == prservice.SideBorrowing {
+			symbol = pr.RequesterSymbol
+		}
+		if symbol.String == "" {
+			return nil, nil
+		}
+		return []eventOwner{{
+			symbol: symbol.String,
+			side:   pgtype.Text{String: string(pr.Side), Valid: true},
+			state:  pgtype.Text{String: string(pr.State), Valid: true},
+		}}, nil
+	}
+	if event.IllTransactionID != "" && !events.IsSyntheticID(event.IllTransactionID) {
+		illTrans, err := s.illRepo.GetIllTransactionById(ctx, event.IllTransactionID)
+		if err != nil {
+			return nil, err
+		}
+		var owners []eventOwner
+		if illTrans.RequesterSymbol.String != "" {
+			owners = append(owners, eventOwner{
+				symbol: illTrans.RequesterSymbol.String,
+				side:   pgtype.Text{String: string(prservice.SideBorrowing), Valid: true},
+				state:  illTrans.LastSupplierStatus,
+			})
+		}
+		if illTrans.SupplierSymbol.String != "" {
+			owners = append(owners, eventOwner{
+				symbol: illTrans.SupplierSymbol.String,
+				side:   pgtype.Text{String: string(prservice.SideLending), Valid: true},
+				state:  illTrans.LastSupplierStatus,
+			})
+		}
+		return owners, nil
+	}
+	return nil, nil
+}
+
+func (s *WebhookService) createDelivery(ctx common.ExtendedContext, sub wh_db.WebhookSubscription, owner eventOwner, event events.Event, signal events.Signal) (wh_db.WebhookDelivery, error) {
+	id := uuid.New().String()
+	payload := whoapi.WebhookPayload{
+		DeliveryId:     id,
+		SubscriptionId: sub.ID,
+		Signal:         string(signal),
+		Owner:          owner.symbol,
+		Event:          whoapi.Event(brokerapi.ToApiEvent(event, event.IllTransactionID, toPatronRequestId(event))),
+	}
+	if owner.side.Valid {
+		payload.Side = &owner.side.String
+	}
+	if owner.state.Valid {
+		payload.State = &owner.state.String
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return wh_db.WebhookDelivery{}, err
+	}
+	now := time.Now()
+	return s.whRepo.CreateWebhookDelivery(ctx, wh_db.CreateWebhookDeliveryParams{
+		ID:             id,
+		SubscriptionID: sub.ID,
+		EventID:        event.ID,
+		EventName:      string(event.EventName),
+		Signal:         string(signal),
+		Payload:        body,
+		Status:         wh_db.WebhookDeliveryStatusPending,
+		NextAttemptAt:  pgtype.Timestamptz{Time: now.Add(DELIVERY_CLAIM), Valid: true},
+		CreatedAt:      pgtype.Timestamptz{Time: now, Valid: true},
+	})
+}
+
+func toPatronRequestId(event events.Event) *string {
+	if event.PatronRequestID != "" && !events.IsSyntheticID(event.PatronRequestID) {
+		return &event.PatronRequestID
+	}
+	return nil
+}
+
+// Run retries due deliveries every PollInterval until the context is done.
+func (s *WebhookService) Run(ctx common.ExtendedContext) {
+	s.Poll(ctx, s.ProcessDueDeliveries)
+}
+
+// ProcessDueDeliveries claims pending deliveries whose next attempt is due and sends them.
+func (s *WebhookService) ProcessDueDeliveries(ctx common.ExtendedContext) {
+	ctx = ctx.WithArgs(ctx.LoggerArgs().WithComponent(COMP))
+	deliveries, err := s.whRepo.ClaimDueWebhookDeliveries(ctx, DELIVERY_CLAIM, DELIVERY_BATCH_SIZE)
+	if err != nil {
+		ctx.Logger().Error("failed to claim webhook deliveries", "error", err)
+		return
+	}
+	for _, delivery := range deliveries {
+		s.deliver(ctx, delivery)
+	}
+}
+
+func (s *WebhookService) deliver(ctx common.ExtendedContext, delivery wh_db.WebhookDelivery) {
+	sub, err := s.whRepo.GetWebhookSubscriptionById(ctx, delivery.SubscriptionID, nil)
+	if err != nil {
+		// a deleted subscription takes its deliveries with it
+		if !errors.Is(err, pgx.ErrNoRows) {
+			ctx.Logger().Error("failed to read webhook subscription", "error", err, "subscriptionId", delivery.SubscriptionID)
+		}
+		return
+	}
+	params := wh_db.UpdateWebhookDeliveryParams{
+		ID:            delivery.ID,
+		Attempts:      delivery.Attempts,
+		LastAttemptAt: delivery.LastAttemptAt,
+	}
+	if !sub.Active {
+		params.Status = wh_db.WebhookDeliveryStatusFailed
+		params.Error = pgtype.Text{String: "subscription is not active", Valid: true}
+	} else {
+		now := time.Now()
+		params.Attempts++
+		params.LastAttemptAt = pgtype.Timestamptz{Time: now, Valid: true}
+		status, sendErr := s.send(ctx, sub, delivery)
+		if status != 0 {
+			params.ResponseStatus = pgtype.Int4{Int32: int32(status), Valid: true}
+		}
+		result := s.Attempted(params.Attempts, now, sendErr, false)
+		switch result.Outcome {
+		case retry.OutcomeSent:
+			params.Status = wh_db.WebhookDeliveryStatusDelivered
+		case retry.OutcomeRetry:
+			params.Status = wh_db.WebhookDeliveryStatusPending
+			params.NextAttemptAt = pgtype.Timestamptz{Time: result.NextAttemptAt, Valid: true}
+		default:
+			params.Status = wh_db.WebhookDeliveryStatusFailed
+		}
+		if sendErr != nil {
+			params.Error = pgtype.Text{String: result.Error, Valid: true}
+			ctx.Logger().Warn("webhook delivery failed", "error", sendErr, "deliveryId", delivery.ID,
+				"subscriptionId", sub.ID, "attempts", params.Attempts, "status", params.Status)
+		}
+	}
+	_, err = s.whRepo.UpdateWebhookDelivery(ctx, params)
+	if err != nil {
+		ctx.Logger().Error("failed to update webhook delivery", "error", err, "deliveryId", delivery.ID)
+	}
+}
+
+// send posts the delivery payload and returns the HTTP status, or 0 if no response was received.
+func (s *WebhookService) send(ctx context.Context, sub wh_db.WebhookSubscription, delivery wh_db.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderEvent, delivery.EventName)
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, delivery.Payload))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected HTTP status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,268 @@
+package wh_service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/indexdata/crosslink/broker/common"
+	"github.com/indexdata/crosslink/broker/events"
+	"github.com/indexdata/crosslink/broker/ill_db"
+	pr_db "github.com/indexdata/crosslink/broker/patron_request/db"
+	prservice "github.com/indexdata/crosslink/broker/patron_request/service"
+	wh_db "github.com/indexdata/crosslink/broker/webhook/db"
+	whoapi "github.com/indexdata/crosslink/broker/webhook/oapi"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var appCtx = common.CreateExtCtxWithArgs(context.Background(), nil)
+
+type MockWhRepo struct {
+	mock.Mock
+	wh_db.WhRepo
+}
+
+func (m *MockWhRepo) GetMatchingWebhookSubscriptions(_ common.ExtendedContext, params wh_db.GetMatchingWebhookSubscriptionsParams) ([]wh_db.WebhookSubscription, error) {
+	args := m.Called(params)
+	return args.Get(0).([]wh_db.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWhRepo) CreateWebhookDelivery(_ common.ExtendedContext, params wh_db.CreateWebhookDeliveryParams) (wh_db.WebhookDelivery, error) {
+	args := m.Called(params)
+	if args.Error(0) != nil {
+		return wh_db.WebhookDelivery{}, args.Error(0)
+	}
+	return wh_db.WebhookDelivery{
+		ID:             params.ID,
+		SubscriptionID: params.SubscriptionID,
+		EventID:        params.EventID,
+		EventName:      params.EventName,
+		Signal:         params.Signal,
+		Payload:        params.Payload,
+		Status:         params.Status,
+		NextAttemptAt:  params.NextAttemptAt,
+		CreatedAt:      params.CreatedAt,
+	}, nil
+}
+
+func (m *MockWhRepo) GetWebhookSubscriptionById(_ common.ExtendedContext, id string, owners []string) (wh_db.WebhookSubscription, error) {
+	args := m.Called(id, owners)
+	return args.Get(0).(wh_db.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWhRepo) UpdateWebhookDelivery(_ common.ExtendedContext, params wh_db.UpdateWebhookDeliveryParams) (wh_db.WebhookDelivery, error) {
+	args := m.Called(params)
+	return wh_db.WebhookDelivery{}, args.Error(0)
+}
+
+type MockPrRepo struct {
+	mock.Mock
+	pr_db.PrRepo
+}
+
+func (m *MockPrRepo) GetPatronRequestById(_ common.ExtendedContext, id string) (pr_db.PatronRequest, error) {
+	args := m.Called(id)
+	return args.Get(0).(pr_db.PatronRequest), args.Error(1)
+}
+
+type MockIllRepo struct {
+	mock.Mock
+	ill_db.IllRepo
+}
+
+func (m *MockIllRepo) GetIllTransactionById(_ common.ExtendedContext, id string) (ill_db.IllTransaction, error) {
+	args := m.Called(id)
+	return args.Get(0).(ill_db.IllTransaction), args.Error(1)
+}
+
+func subscription(url string, active bool) wh_db.WebhookSubscription {
+	return wh_db.WebhookSubscription{ID: "wh-1", Owner: "ISIL:REQ", Url: url, Secret: "s3cret", Active: active}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{"a":1}' | openssl dgst -sha256 -hmac key
+	assert.Equal(t, "sha256=88a67f24bbcdaed0e6c997404bb79a743baf44c6bab2f4c27328e3009d22e342", Sign("key", []byte(`{"a":1}`)))
+	assert.NotEqual(t, Sign("key", []byte("body")), Sign("other", []byte("body")))
+}
+
+func TestBackoff(t *testing.T) {
+	s := NewWebhookService(nil, nil, nil, nil)
+	s.RetryBackoff = time.Minute
+	assert.Equal(t, time.Minute, s.Backoff(1))
+	assert.Equal(t, 2*time.Minute, s.Backoff(2))
+	assert.Equal(t, 8*time.Minute, s.Backoff(4))
+	assert.Equal(t, MAX_RETRY_BACKOFF, s.Backoff(20))
+}
+
+func TestIsObservedEventName(t *testing.T) {
+	assert.True(t, IsObservedEventName(string(events.EventNameInvokeAction)))
+	assert.False(t, IsObservedEventName(string(events.EventNameInvokeBatchAction)))
+	assert.False(t, IsObservedEventName("foo"))
+}
+
+func TestEnqueuePatronRequestEvent(t *testing.T) {
+	whRepo := new(MockWhRepo)
+	prRepo := new(MockPrRepo)
+	prRepo.On("GetPatronRequestById", "pr-1").Return(pr_db.PatronRequest{
+		ID:              "pr-1",
+		Side:            prservice.SideBorrowing,
+		State:           "VALIDATED",
+		RequesterSymbol: pgtype.Text{String: "ISIL:REQ", Valid: true},
+		SupplierSymbol:  pgtype.Text{String: "ISIL:SUP", Valid: true},
+	}, nil)
+	whRepo.On("GetMatchingWebhookSubscriptions", wh_db.GetMatchingWebhookSubscriptionsParams{
+		Owner:     "ISIL:REQ",
+		EventName: string(events.EventNameInvokeAction),
+		Side:      pgtype.Text{String: "borrowing", Valid: true},
+		State:     pgtype.Text{String: "VALIDATED", Valid: true},
+	}).Return([]wh_db.WebhookSubscription{subscription("http://localhost:0", false)}, nil)
+	var created wh_db.CreateWebhookDeliveryParams
+	whRepo.On("CreateWebhookDelivery", mock.Anything).Run(func(args mock.Arguments) {
+		created = args.Get(0).(wh_db.CreateWebhookDeliveryParams)
+	}).Return(nil)
+	done := make(chan wh_db.UpdateWebhookDeliveryParams, 1)
+	whRepo.On("GetWebhookSubscriptionById", "wh-1", []string(nil)).Return(subscription("http://localhost:0", false), nil)
+	whRepo.On("UpdateWebhookDelivery", mock.Anything).Run(func(args mock.Arguments) {
+		done <- args.Get(0).(wh_db.UpdateWebhookDeliveryParams)
+	}).Return(nil)
+
+	s := NewWebhookService(whRepo, prRepo, nil, nil)
+	s.TaskCompleted(appCtx, events.Event{
+		ID:              "e-1",
+		EventName:       events.EventNameInvokeAction,
+		EventType:       events.EventTypeTask,
+		EventStatus:     events.EventStatusSuccess,
+		PatronRequestID: "pr-1",
+	})
+
+	update := <-done
+	assert.Equal(t, "e-1", created.EventID)
+	assert.Equal(t, string(events.SignalTaskComplete), created.Signal)
+	assert.Equal(t, wh_db.WebhookDeliveryStatusPending, created.Status)
+	var payload whoapi.WebhookPayload
+	assert.NoError(t, json.Unmarshal(created.Payload, &payload))
+	assert.Equal(t, created.ID, payload.DeliveryId)
+	assert.Equal(t, "ISIL:REQ", payload.Owner)
+	assert.Equal(t, "borrowing", *payload.Side)
+	assert.Equal(t, "VALIDATED", *payload.State)
+	assert.Equal(t, "e-1", payload.Event.Id)
+	assert.Equal(t, "pr-1", *payload.Event.PatronRequestID)
+	// the subscription is inactive so the delivery fails without being sent
+	assert.Equal(t, created.ID, update.ID)
+	assert.Equal(t, wh_db.WebhookDeliveryStatusFailed, update.Status)
+	assert.Equal(t, int32(0), update.Attempts)
+}
+
+func TestEnqueueIllTransactionEventDuplicate(t *testing.T) {
+	whRepo := new(MockWhRepo)
+	illRepo := new(MockIllRepo)
+	illRepo.On("GetIllTransactionById", "ill-1").Return(ill_db.IllTransaction{
+		ID:                 "ill-1",
+		RequesterSymbol:    pgtype.Text{String: "ISIL:REQ", Valid: true},
+		SupplierSymbol:     pgtype.Text{String: "ISIL:SUP", Valid: true},
+		LastSupplierStatus: pgtype.Text{String: "Loaned", Valid: true},
+	}, nil)
+	whRepo.On("GetMatchingWebhookSubscriptions", mock.MatchedBy(func(p wh_db.GetMatchingWebhookSubscriptionsParams) bool {
+		return p.Owner == "ISIL:REQ" && p.Side.String == "borrowing" && p.State.String == "Loaned"
+	})).Return([]wh_db.WebhookSubscription{subscription("http://localhost:0", true)}, nil)
+	whRepo.On("GetMatchingWebhookSubscriptions", mock.MatchedBy(func(p wh_db.GetMatchingWebhookSubscriptionsParams) bool {
+		return p.Owner == "ISIL:SUP" && p.Side.String == "lending" && p.State.String == "Loaned"
+	})).Return([]wh_db.WebhookSubscription{}, nil)
+	// another instance already created the delivery
+	whRepo.On("CreateWebhookDelivery", mock.Anything).Return(pgx.ErrNoRows)
+
+	s := NewWebhookService(whRepo, nil, illRepo, nil)
+	s.EventCreated(appCtx, events.Event{
+		ID:               "e-2",
+		EventName:        events.EventNameSupplierMsgReceived,
+		EventType:        events.EventTypeNotice,
+		IllTransactionID: "ill-1",
+	})
+
+	whRepo.AssertNumberOfCalls(t, "GetMatchingWebhookSubscriptions", 2)
+	whRepo.AssertCalled(t, "CreateWebhookDelivery", mock.MatchedBy(func(p wh_db.CreateWebhookDeliveryParams) bool {
+		return p.Signal == string(events.SignalNoticeCreated)
+	}))
+	whRepo.AssertNotCalled(t, "GetWebhookSubscriptionById", mock.Anything, mock.Anything)
+}
+
+func TestDeliverSuccess(t *testing.T) {
+	var gotHeaders http.Header
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	whRepo := new(MockWhRepo)
+	whRepo.On("GetWebhookSubscriptionById", "wh-1", []string(nil)).Return(subscription(server.URL, true), nil)
+	whRepo.On("UpdateWebhookDelivery", mock.MatchedBy(func(p wh_db.UpdateWebhookDeliveryParams) bool {
+		return p.ID == "d-1" &&
+			p.Status == wh_db.WebhookDeliveryStatusDelivered &&
+			p.Attempts == 1 &&
+			p.LastAttemptAt.Valid &&
+			p.ResponseStatus.Int32 == http.StatusNoContent &&
+			!p.Error.Valid
+	})).Return(nil)
+
+	s := NewWebhookService(whRepo, nil, nil, server.Client())
+	payload := []byte(`{"deliveryId":"d-1"}`)
+	s.deliver(appCtx, wh_db.WebhookDelivery{ID: "d-1", SubscriptionID: "wh-1", EventName: "invoke-action", Payload: payload})
+
+	whRepo.AssertExpectations(t)
+	assert.Equal(t, payload, gotBody)
+	assert.Equal(t, "application/json", gotHeaders.Get("Content-Type"))
+	assert.Equal(t, "d-1", gotHeaders.Get(HeaderDelivery))
+	assert.Equal(t, "invoke-action", gotHeaders.Get(HeaderEvent))
+	assert.Equal(t, Sign("s3cret", payload), gotHeaders.Get(HeaderSignature))
+}
+
+func TestDeliverRetryAndFail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	whRepo := new(MockWhRepo)
+	whRepo.On("GetWebhookSubscriptionById", "wh-1", []string(nil)).Return(subscription(server.URL, true), nil)
+	var updates []wh_db.UpdateWebhookDeliveryParams
+	whRepo.On("UpdateWebhookDelivery", mock.Anything).Run(func(args mock.Arguments) {
+		updates = append(updates, args.Get(0).(wh_db.UpdateWebhookDeliveryParams))
+	}).Return(nil)
+
+	s := NewWebhookService(whRepo, nil, nil, server.Client())
+	s.MaxAttempts = 2
+	s.RetryBackoff = time.Minute
+	before := time.Now()
+	s.deliver(appCtx, wh_db.WebhookDelivery{ID: "d-1", SubscriptionID: "wh-1", Payload: []byte("{}")})
+	s.deliver(appCtx, wh_db.WebhookDelivery{ID: "d-1", SubscriptionID: "wh-1", Payload: []byte("{}"), Attempts: 1})
+
+	assert.Len(t, updates, 2)
+	assert.Equal(t, wh_db.WebhookDeliveryStatusPending, updates[0].Status)
+	assert.Equal(t, int32(1), updates[0].Attempts)
+	assert.Equal(t, int32(http.StatusServiceUnavailable), updates[0].ResponseStatus.Int32)
+	assert.Equal(t, "unexpected HTTP status 503", updates[0].Error.String)
+	assert.WithinDuration(t, before.Add(time.Minute), updates[0].NextAttemptAt.Time, 5*time.Second)
+	assert.Equal(t, wh_db.WebhookDeliveryStatusFailed, updates[1].Status)
+	assert.Equal(t, int32(2), updates[1].Attempts)
+	assert.False(t, updates[1].NextAttemptAt.Valid)
+}
+
+func TestDeliverSubscriptionDeleted(t *testing.T) {
+	whRepo := new(MockWhRepo)
+	whRepo.On("GetWebhookSubscriptionById", "wh-1", []string(nil)).Return(wh_db.WebhookSubscription{}, pgx.ErrNoRows)
+
+	s := NewWebhookService(whRepo, nil, nil, nil)
+	s.deliver(appCtx, wh_db.WebhookDelivery{ID: "d-1", SubscriptionID: "wh-1"})
+
+	whRepo.AssertNotCalled(t, "UpdateWebhookDelivery", mock.Anything)
+}
@@ -10,7 +10,7 @@ import (
 	"github.com/indexdata/crosslink/directory/db"
 )
 
-func catalogConfigToDBParams(entryID uuid.UUID, cfg CatalogConfig) db.UpsertCatalogConfigParams {
+func catalogConfigToDBParams(entryID uuid.UUID, cfg CatalogConfig) (db.UpsertCatalogConfigParams, error) {
 	params := db.UpsertCatalogConfigParams{
 		Entry: &entryID,
 	}
@@ -63,8 +63,15 @@ func catalogConfigToDBParams(entryID uuid.UUID, cfg CatalogConfig) db.UpsertCata
 		params.MetadataMarc21Subtitle = marc.Subtitle
 		params.MetadataMarc21Title = marc.Title
 	}
+	if cfg.Sources != nil {
+		var err error
+		params.Sources, err = json.Marshal(*cfg.Sources)
+		if err != nil {
+			return db.UpsertCatalogConfigParams{}, err
+		}
+	}
 
-	return params
+	return params, nil
 }
 
 func validateCatalogConfigPatch(cfg CatalogConfigPatch, original db.CatalogConfig) error {
@@ -106,6 +113,7 @@ func catalogConfigPatchToDBParams(entryID uuid.UUID, cfg CatalogConfigPatch, ori
 		MetadataMarc21Issn:                   original.MetadataMarc21Issn,
 		MetadataMarc21Subtitle:               original.MetadataMarc21Subtitle,
 		MetadataMarc21Title:                  original.MetadataMarc21Title,
+		Sources:                              original.Sources,
 	}
 
 	if cfg.MetadataUpdateMode != nil {
@@ -182,6 +190,13 @@ func catalogConfigPatchToDBParams(entryID uuid.UUID, cfg CatalogConfigPatch, ori
 		params.MetadataMarc21Subtitle = derefOrDefaultPtr(marc.Subtitle, params.MetadataMarc21Subtitle)
 		params.MetadataMarc21Title = derefOrDefaultPtr(marc.Title, params.MetadataMarc21Title)
 	}
+	if cfg.Sources != nil {
+		var err error
+		params.Sources, err = json.Marshal(*cfg.Sources)
+		if err != nil {
+			return db.UpsertCatalogConfigParams{}, err
+		}
+	}
 
 	return params, nil
 }
@@ -73,10 +73,12 @@ func scanEntryRow(rows pgx.Rows) (Entry, int, error) {
 		lmsLocationCode    *string
 		illConfigJSON      []byte
 		lmsConfigJSON      []byte
+		sip2ConfigJSON     []byte
 		catalogConfigJSON  []byte
 		holdingsPolicyJSON []byte
 		hrid               *string
 		timeZone           *string
+		language           *string
 		entryType          *string
 		parent             *uuid.UUID
 		symbolsJSON        [][]byte
@@ -89,7 +91,7 @@ func scanEntryRow(rows pgx.Rows) (Entry, int, error) {
 	)
 
 	if err := rows.Scan(&id, &name, &description, &organizationId, &contactName, &email, &fromEmail, &tenant, &vendor, &phoneNumber,
-		&lmsLocationCode, &illConfigJSON, &lmsConfigJSON, &catalogConfigJSON, &holdingsPolicyJSON, &hrid, &timeZone, &entryType, &parent, &symbolsJSON, &endpointsJSON,
+		&lmsLocationCode, &illConfigJSON, &lmsConfigJSON, &sip2ConfigJSON, &catalogConfigJSON, &holdingsPolicyJSON, &hrid, &timeZone, &language, &entryType, &parent, &symbolsJSON, &endpointsJSON,
 		&addressesJSON, &tiersJSON, &networksJSON, &closuresJSON, &totalCount); err != nil {
 		return Entry{}, 0, err
 	}
@@ -119,6 +121,11 @@ func scanEntryRow(rows pgx.Rows) (Entry, int, error) {
 		return Entry{}, 0, fmt.Errorf("unmarshalling lms config: %w", err)
 	}
 
+	sip2Config, err := unmarshalJSONObject[Sip2Config](sip2ConfigJSON)
+	if err != nil {
+		return Entry{}, 0, fmt.Errorf("unmarshalling sip2 config: %w", err)
+	}
+
 	catalogConfig, err := unmarshalJSONObject[CatalogConfig](catalogConfigJSON)
 	if err != nil {
 		return Entry{}, 0, fmt.Errorf("unmarshalling holdings config: %w", err)
@@ -198,11 +205,13 @@ func scanEntryRow(rows pgx.Rows) (Entry, int, error) {
 		Addresses:       addressesPtr,
 		Closures:        closuresPtr,
 		LmsConfig:       lmsConfigPtr,
+		Sip2Config:      sip2Config,
 		CatalogConfig:   catalogConfig,
 		HoldingsPolicy:  holdingsPolicy,
 		Tiers:           tiersPtr,
 		Networks:        networksPtr,
 		TimeZone:        timeZone,
+		Language:        language,
 		Vendor:          (*EntryVendor)(vendor),
 	}, totalCount, nil
 }
@@ -316,10 +325,24 @@ func buildEntrySQL(whereClause string) string {
 				'address',l.address, 
 				'checkInItemEnabled', l.checkin_item_enabled,
 				'checkOutItemEnabled', l.checkout_item_enabled,
+				'folio', CASE WHEN l.folio_tenant IS NULL THEN NULL ELSE json_strip_nulls(json_build_object(
+					'cancellationReasonId', l.folio_cancellation_reason_id,
+					'holdingsLocationId', l.folio_holdings_location_id,
+					'instanceTypeId', l.folio_instance_type_id,
+					'loanTypeId', l.folio_loan_type_id,
+					'materialTypeId', l.folio_material_type_id,
+					'password', l.folio_password,
+					'requestType', l.folio_request_type,
+					'servicePointId', l.folio_service_point_id,
+					'tenant', l.folio_tenant,
+					'username', l.folio_username
+				)) END,
 				'fromAgency',l.from_agency,
 				'fromAgencyAuthentication', l.from_agency_authentication,
 				'itemLocation', l.item_location,
+				'lookupItemEnabled', l.lookup_item_enabled,
 				'lookupUserEnabled', l.lookup_user_enabled,
+				'renewItemEnabled', l.renew_item_enabled,
 				'requestItemBibIdCode', l.request_item_bib_code,
 				'requestItemPickupLocationEnabled', l.request_item_pickup_location_enabled,
 				'requestItemRequestScopeType', l.request_item_scope_type,
@@ -327,10 +350,30 @@ func buildEntrySQL(whereClause string) string {
 				'requesterPatronPattern', l.requester_patron_pattern,
 				'requesterPickupLocation', l.requester_pickup_location,
 				'supplierPickupLocation', l.supplier_pickup_location,
-				'toAgency', l.to_agency
+				'toAgency', l.to_agency,
+				'type', l.lms_type
 			) 
 		from lms_configs l WHERE l.entry = e.id) as lms_config,
 		(
+		SELECT
+			json_strip_nulls(json_build_object(
+				'address', s.address,
+				'checkInItemEnabled', s.checkin_item_enabled,
+				'checkOutItemEnabled', s.checkout_item_enabled,
+				'errorDetection', s.error_detection,
+				'institutionId', s.institution_id,
+				'locationCode', s.location_code,
+				'loginPassword', s.login_password,
+				'loginUserId', s.login_user_id,
+				'lookupUserEnabled', s.lookup_user_enabled,
+				'renewItemEnabled', s.renew_item_enabled,
+				'requesterPatronPattern', s.requester_patron_pattern,
+				'requesterPickupLocation', s.requester_pickup_location,
+				'supplierPickupLocation', s.supplier_pickup_location,
+				'terminalPassword', s.terminal_password
+			))
+		FROM sip2_configs s WHERE s.entry = e.id) as sip2_config,
+		(
 		SELECT
 			json_strip_nulls(json_build_object(
 				'metadataUpdateMode', h.metadata_update_mode,
@@ -383,13 +426,15 @@ func buildEntrySQL(whereClause string) string {
 							'subtitle', h.metadata_marc21_subtitle,
 							'title', h.metadata_marc21_title
 						))
-					)) END
+					)) END,
+				'sources', h.sources
 				)
 			)
 		from catalog_configs h WHERE h.entry = e.id) as catalog_config,
 		(SELECT hp.policy FROM holdings_policies hp WHERE hp.entry = e.id) as holdings_policy,
 		e.hrid,
 		e.time_zone,
+		e.language,
 		e.type,
 		e.parent,
 		ARRAY(SELECT row_to_json(s) FROM symbols s WHERE s.owner = e.id ORDER BY s.id) as symbols,
@@ -690,6 +735,7 @@ func (a ApiImpl) AddEntry(ctx context.Context, request AddEntryRequestObject) (A
 		Tenant:          request.Body.Tenant,
 		PhoneNumber:     request.Body.PhoneNumber,
 		TimeZone:        request.Body.TimeZone,
+		Language:        request.Body.Language,
 		OrganizationID:  request.Body.OrganizationId,
 		Type:            string(entryType),
 		Parent:          request.Body.Parent,
@@ -772,7 +818,7 @@ func (a ApiImpl) AddEntry(ctx context.Context, request AddEntryRequestObject) (A
 
 	if request.Body.LmsConfig != nil {
 		lmsConfig := request.Body.LmsConfig
-		_, err := qtx.UpsertLMSConfig(ctx, db.UpsertLMSConfigParams{
+		params := db.UpsertLMSConfigParams{
 			Entry:                            &insertedEntry.ID,
 			Address:                          lmsConfig.Address,
 			FromAgency:                       lmsConfig.FromAgency,
@@ -790,15 +836,33 @@ func (a ApiImpl) AddEntry(ctx context.Context, request AddEntryRequestObject) (A
 			RequesterPickupLocation:          lmsConfig.RequesterPickupLocation,
 			RequesterPatronPattern:           lmsConfig.RequesterPatronPattern,
 			SupplierPickupLocation:           lmsConfig.SupplierPickupLocation,
-		})
+			LookupItemEnabled:                lmsConfig.LookupItemEnabled,
+			RenewItemEnabled:                 lmsConfig.RenewItemEnabled,
+			LmsType:                          enumToDB(lmsConfig.Type),
+		}
+		setFolioLmsConfigParams(&params, lmsConfig.Folio)
+		_, err := qtx.UpsertLMSConfig(ctx, params)
 		if err != nil {
 			slog.ErrorContext(ctx, "failed to create lmsConfig component", "error", err, "to_agency", lmsConfig.ToAgency)
 			return AddEntry500TextResponse("Internal server error"), nil
 		}
 	}
 
+	if request.Body.Sip2Config != nil {
+		_, err := qtx.UpsertSip2Config(ctx, sip2ConfigToDBParams(insertedEntry.ID, *request.Body.Sip2Config))
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to create sip2Config component", "error", err)
+			return AddEntry500TextResponse("Internal server error"), nil
+		}
+	}
+
 	if request.Body.CatalogConfig != nil {
-		_, err := qtx.UpsertCatalogConfig(ctx, catalogConfigToDBParams(insertedEntry.ID, *request.Body.CatalogConfig))
+		params, err := catalogConfigToDBParams(insertedEntry.ID, *request.Body.CatalogConfig)
+		if err != nil {
+			slog.ErrorContext(ctx, "unable to convert catalogConfig", "error", err)
+			return AddEntry500TextResponse("Internal server error"), nil
+		}
+		_, err = qtx.UpsertCatalogConfig(ctx, params)
 		if err != nil {
 			slog.ErrorContext(ctx, "failed to create catalogConfig component", "error", err)
 			return AddEntry500TextResponse("Internal server error"), nil
@@ -959,6 +1023,7 @@ func (a ApiImpl) UpdateEntry(ctx context.Context, request UpdateEntryRequestObje
 		Hrid:            maybeUpdateCol(orig.Hrid, request.Body.Hrid),
 		Type:            resultingType,
 		TimeZone:        maybeUpdateCol(orig.TimeZone, request.Body.TimeZone),
+		Language:        maybeUpdateCol(orig.Language, request.Body.Language),
 		OrganizationID:  maybeUpdateCol(orig.OrganizationID, request.Body.OrganizationId),
 		ID:              orig.ID,
 	})
@@ -1145,7 +1210,7 @@ func (a ApiImpl) UpdateEntry(ctx context.Context, request UpdateEntryRequestObje
 				return UpdateEntry500TextResponse("Internal server error"), nil
 			}
 
-			_, err = qtx.UpsertLMSConfig(ctx, db.UpsertLMSConfigParams{
+			params := db.UpsertLMSConfigParams{
 				Entry:                            &orig.ID,
 				Address:                          derefOrDefault(lmsConfig.Address, originalLMSConfig.Address),
 				FromAgency:                       derefOrDefault(lmsConfig.FromAgency, originalLMSConfig.FromAgency),
@@ -1163,7 +1228,12 @@ func (a ApiImpl) UpdateEntry(ctx context.Context, request UpdateEntryRequestObje
 				RequesterPickupLocation:          maybeUpdateCol(originalLMSConfig.RequesterPickupLocation, lmsConfig.RequesterPickupLocation),
 				SupplierPickupLocation:           maybeUpdateCol(originalLMSConfig.SupplierPickupLocation, lmsConfig.SupplierPickupLocation),
 				RequesterPatronPattern:           maybeUpdateCol(originalLMSConfig.RequesterPatronPattern, lmsConfig.RequesterPatronPattern),
-			})
+				LookupItemEnabled:                maybeUpdateCol(originalLMSConfig.LookupItemEnabled, lmsConfig.LookupItemEnabled),
+				RenewItemEnabled:                 maybeUpdateCol(originalLMSConfig.RenewItemEnabled, lmsConfig.RenewItemEnabled),
+				LmsType:                          maybeUpdateEnumCol(originalLMSConfig.LmsType, lmsConfig.Type),
+			}
+			mergeFolioLmsConfigParams(&params, lmsConfig.Folio, originalLMSConfig)
+			_, err = qtx.UpsertLMSConfig(ctx, params)
 			if err != nil {
 				slog.ErrorContext(ctx, "unexpected database error during lmsConfig upsert", "error", err)
 				return UpdateEntry500TextResponse("Internal server error"), nil
@@ -1171,6 +1241,28 @@ func (a ApiImpl) UpdateEntry(ctx context.Context, request UpdateEntryRequestObje
 		}
 	}
 
+	if request.Body.Sip2Config.IsSpecified() {
+		if request.Body.Sip2Config.IsNull() {
+			err = qtx.DeleteSip2ConfigByEntry(ctx, orig.ID)
+			if err != nil {
+				slog.ErrorContext(ctx, "unexpected database error during sip2Config delete", "error", err)
+				return UpdateEntry500TextResponse("Internal server error"), nil
+			}
+		} else {
+			sip2Config := request.Body.Sip2Config.MustGet()
+			originalSip2Config, queryErr := qtx.GetSip2ConfigByEntry(ctx, orig.ID)
+			if queryErr != nil && !errors.Is(queryErr, pgx.ErrNoRows) {
+				slog.ErrorContext(ctx, "unable to query original sip2Config", "error", queryErr)
+				return UpdateEntry500TextResponse("Internal server error"), nil
+			}
+			_, err = qtx.UpsertSip2Config(ctx, sip2ConfigPatchToDBParams(orig.ID, sip2Config, originalSip2Config))
+			if err != nil {
+				slog.ErrorContext(ctx, "unexpected database error during sip2Config upsert", "error", err)
+				return UpdateEntry500TextResponse("Internal server error"), nil
+			}
+		}
+	}
+
 	if request.Body.CatalogConfig.IsSpecified() {
 		if request.Body.CatalogConfig.IsNull() {
 			err = qtx.DeleteCatalogConfigByEntry(ctx, orig.ID)
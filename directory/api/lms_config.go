@@ -0,0 +1,77 @@
+package api
+
+import (
+	"github.com/indexdata/crosslink/directory/db"
+	"github.com/oapi-codegen/nullable"
+)
+
+func enumToDB[T ~string](value *T) *string {
+	if value == nil {
+		return nil
+	}
+	s := string(*value)
+	return &s
+}
+
+func maybeUpdateEnumCol[T ~string](cur *string, patch nullable.Nullable[T]) *string {
+	if !patch.IsSpecified() {
+		return cur
+	}
+	if patch.IsNull() {
+		return nil
+	}
+	s := string(patch.MustGet())
+	return &s
+}
+
+// setFolioLmsConfigParams sets the FOLIO columns of the LMS config, clearing them if cfg is nil
+func setFolioLmsConfigParams(params *db.UpsertLMSConfigParams, cfg *FolioLmsConfig) {
+	if cfg == nil {
+		cfg = &FolioLmsConfig{}
+	}
+	params.FolioTenant = nil
+	if cfg.Tenant != "" {
+		params.FolioTenant = &cfg.Tenant
+	}
+	params.FolioUsername = cfg.Username
+	params.FolioPassword = cfg.Password
+	params.FolioServicePointID = cfg.ServicePointId
+	params.FolioInstanceTypeID = cfg.InstanceTypeId
+	params.FolioHoldingsLocationID = cfg.HoldingsLocationId
+	params.FolioMaterialTypeID = cfg.MaterialTypeId
+	params.FolioLoanTypeID = cfg.LoanTypeId
+	params.FolioCancellationReasonID = cfg.CancellationReasonId
+	params.FolioRequestType = enumToDB(cfg.RequestType)
+}
+
+// mergeFolioLmsConfigParams merges a FOLIO config patch into the original FOLIO columns of the LMS config
+func mergeFolioLmsConfigParams(params *db.UpsertLMSConfigParams, patch nullable.Nullable[FolioLmsConfigPatch], original db.LmsConfig) {
+	params.FolioTenant = original.FolioTenant
+	params.FolioUsername = original.FolioUsername
+	params.FolioPassword = original.FolioPassword
+	params.FolioServicePointID = original.FolioServicePointID
+	params.FolioInstanceTypeID = original.FolioInstanceTypeID
+	params.FolioHoldingsLocationID = original.FolioHoldingsLocationID
+	params.FolioMaterialTypeID = original.FolioMaterialTypeID
+	params.FolioLoanTypeID = original.FolioLoanTypeID
+	params.FolioCancellationReasonID = original.FolioCancellationReasonID
+	params.FolioRequestType = original.FolioRequestType
+	if !patch.IsSpecified() {
+		return
+	}
+	if patch.IsNull() {
+		setFolioLmsConfigParams(params, nil)
+		return
+	}
+	cfg := patch.MustGet()
+	params.FolioTenant = derefOrDefaultPtr(cfg.Tenant, params.FolioTenant)
+	params.FolioUsername = maybeUpdateCol(params.FolioUsername, cfg.Username)
+	params.FolioPassword = maybeUpdateCol(params.FolioPassword, cfg.Password)
+	params.FolioServicePointID = maybeUpdateCol(params.FolioServicePointID, cfg.ServicePointId)
+	params.FolioInstanceTypeID = maybeUpdateCol(params.FolioInstanceTypeID, cfg.InstanceTypeId)
+	params.FolioHoldingsLocationID = maybeUpdateCol(params.FolioHoldingsLocationID, cfg.HoldingsLocationId)
+	params.FolioMaterialTypeID = maybeUpdateCol(params.FolioMaterialTypeID, cfg.MaterialTypeId)
+	params.FolioLoanTypeID = maybeUpdateCol(params.FolioLoanTypeID, cfg.LoanTypeId)
+	params.FolioCancellationReasonID = maybeUpdateCol(params.FolioCancellationReasonID, cfg.CancellationReasonId)
+	params.FolioRequestType = maybeUpdateEnumCol(params.FolioRequestType, cfg.RequestType)
+}
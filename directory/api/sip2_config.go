@@ -0,0 +1,46 @@
+package api
+
+import (
+	"github.com/google/uuid"
+	"github.com/indexdata/crosslink/directory/db"
+)
+
+func sip2ConfigToDBParams(entryID uuid.UUID, cfg Sip2Config) db.UpsertSip2ConfigParams {
+	return db.UpsertSip2ConfigParams{
+		Entry:                   entryID,
+		Address:                 cfg.Address,
+		InstitutionID:           cfg.InstitutionId,
+		LoginUserID:             cfg.LoginUserId,
+		LoginPassword:           cfg.LoginPassword,
+		LocationCode:            cfg.LocationCode,
+		TerminalPassword:        cfg.TerminalPassword,
+		ErrorDetection:          cfg.ErrorDetection,
+		LookupUserEnabled:       cfg.LookupUserEnabled,
+		CheckinItemEnabled:      cfg.CheckInItemEnabled,
+		CheckoutItemEnabled:     cfg.CheckOutItemEnabled,
+		RenewItemEnabled:        cfg.RenewItemEnabled,
+		RequesterPickupLocation: cfg.RequesterPickupLocation,
+		SupplierPickupLocation:  cfg.SupplierPickupLocation,
+		RequesterPatronPattern:  cfg.RequesterPatronPattern,
+	}
+}
+
+func sip2ConfigPatchToDBParams(entryID uuid.UUID, cfg Sip2ConfigPatch, original db.Sip2Config) db.UpsertSip2ConfigParams {
+	return db.UpsertSip2ConfigParams{
+		Entry:                   entryID,
+		Address:                 derefOrDefault(cfg.Address, original.Address),
+		InstitutionID:           derefOrDefault(cfg.InstitutionId, original.InstitutionID),
+		LoginUserID:             maybeUpdateCol(original.LoginUserID, cfg.LoginUserId),
+		LoginPassword:           maybeUpdateCol(original.LoginPassword, cfg.LoginPassword),
+		LocationCode:            maybeUpdateCol(original.LocationCode, cfg.LocationCode),
+		TerminalPassword:        maybeUpdateCol(original.TerminalPassword, cfg.TerminalPassword),
+		ErrorDetection:          maybeUpdateCol(original.ErrorDetection, cfg.ErrorDetection),
+		LookupUserEnabled:       maybeUpdateCol(original.LookupUserEnabled, cfg.LookupUserEnabled),
+		CheckinItemEnabled:      maybeUpdateCol(original.CheckinItemEnabled, cfg.CheckInItemEnabled),
+		CheckoutItemEnabled:     maybeUpdateCol(original.CheckoutItemEnabled, cfg.CheckOutItemEnabled),
+		RenewItemEnabled:        maybeUpdateCol(original.RenewItemEnabled, cfg.RenewItemEnabled),
+		RequesterPickupLocation: maybeUpdateCol(original.RequesterPickupLocation, cfg.RequesterPickupLocation),
+		SupplierPickupLocation:  maybeUpdateCol(original.SupplierPickupLocation, cfg.SupplierPickupLocation),
+		RequesterPatronPattern:  maybeUpdateCol(original.RequesterPatronPattern, cfg.RequesterPatronPattern),
+	}
+}
@@ -0,0 +1 @@
+ALTER TABLE catalog_configs DROP COLUMN sources;
@@ -0,0 +1 @@
+ALTER TABLE catalog_configs ADD COLUMN sources jsonb;
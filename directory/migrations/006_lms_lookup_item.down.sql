@@ -0,0 +1 @@
+ALTER TABLE lms_configs DROP COLUMN lookup_item_enabled;
@@ -0,0 +1 @@
+ALTER TABLE lms_configs ADD COLUMN lookup_item_enabled boolean;
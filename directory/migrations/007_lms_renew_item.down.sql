@@ -0,0 +1 @@
+ALTER TABLE lms_configs DROP COLUMN renew_item_enabled;
@@ -0,0 +1 @@
+ALTER TABLE lms_configs ADD COLUMN renew_item_enabled boolean;
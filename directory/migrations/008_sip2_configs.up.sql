@@ -0,0 +1,20 @@
+CREATE TABLE sip2_configs (
+  id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+  entry uuid NOT NULL UNIQUE REFERENCES entries (id) ON DELETE CASCADE,
+  address text NOT NULL,
+  institution_id varchar(128) NOT NULL,
+  login_user_id varchar(128),
+  login_password text,
+  location_code varchar(128),
+  terminal_password text,
+  error_detection boolean,
+  lookup_user_enabled boolean,
+  checkin_item_enabled boolean,
+  checkout_item_enabled boolean,
+  lookup_item_enabled boolean,
+  renew_item_enabled boolean,
+  item_location varchar(255),
+  requester_pickup_location varchar(128),
+  supplier_pickup_location varchar(128),
+  requester_patron_pattern varchar(255)
+);
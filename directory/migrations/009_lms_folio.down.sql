@@ -0,0 +1,12 @@
+ALTER TABLE lms_configs
+  DROP COLUMN lms_type,
+  DROP COLUMN folio_tenant,
+  DROP COLUMN folio_username,
+  DROP COLUMN folio_password,
+  DROP COLUMN folio_service_point_id,
+  DROP COLUMN folio_instance_type_id,
+  DROP COLUMN folio_holdings_location_id,
+  DROP COLUMN folio_material_type_id,
+  DROP COLUMN folio_loan_type_id,
+  DROP COLUMN folio_cancellation_reason_id,
+  DROP COLUMN folio_request_type;
@@ -0,0 +1,12 @@
+ALTER TABLE lms_configs
+  ADD COLUMN lms_type varchar(16),
+  ADD COLUMN folio_tenant varchar(128),
+  ADD COLUMN folio_username varchar(128),
+  ADD COLUMN folio_password text,
+  ADD COLUMN folio_service_point_id varchar(64),
+  ADD COLUMN folio_instance_type_id varchar(64),
+  ADD COLUMN folio_holdings_location_id varchar(64),
+  ADD COLUMN folio_material_type_id varchar(64),
+  ADD COLUMN folio_loan_type_id varchar(64),
+  ADD COLUMN folio_cancellation_reason_id varchar(64),
+  ADD COLUMN folio_request_type varchar(16);
@@ -0,0 +1 @@
+ALTER TABLE entries ADD COLUMN language varchar(35);
@@ -0,0 +1 @@
+ALTER TABLE sip2_configs ADD COLUMN lookup_item_enabled boolean;
@@ -0,0 +1 @@
+ALTER TABLE sip2_configs DROP COLUMN lookup_item_enabled;
@@ -0,0 +1 @@
+ALTER TABLE sip2_configs ADD COLUMN item_location varchar(255);
@@ -0,0 +1 @@
+ALTER TABLE sip2_configs DROP COLUMN item_location;
@@ -21,9 +21,9 @@ SELECT pg_advisory_xact_lock(hashtextextended('directoryish:consortium-entry', 0
 
 -- name: CreateEntry :one
 INSERT INTO entries (
-  name, description, contact_name, email, from_email, tenant, vendor, phone_number, time_zone, organization_id, type, parent, lms_location_code, hrid
+  name, description, contact_name, email, from_email, tenant, vendor, phone_number, time_zone, organization_id, type, parent, lms_location_code, hrid, language
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
 )
 RETURNING *;
 
@@ -43,7 +43,8 @@ SET
   type = @type,
   parent = @parent,
   lms_location_code = @lms_location_code,
-  hrid = @hrid
+  hrid = @hrid,
+  language = @language
 
 WHERE id = @id;
 
@@ -208,7 +209,8 @@ INSERT INTO catalog_configs (
   holdings_marc_main_field, holdings_marc_restricted_subfield, holdings_marc_shelving_location_subfield,
   holdings_marc21plus1_enabled, holdings_opac_enabled, holdings_reservoir_enabled,
   metadata_marc21_author, metadata_marc21_edition, metadata_marc21_identifier, metadata_marc21_isbn,
-  metadata_marc21_issn, metadata_marc21_subtitle, metadata_marc21_title
+  metadata_marc21_issn, metadata_marc21_subtitle, metadata_marc21_title,
+  sources
 ) VALUES (
   coalesce(sqlc.narg('id'), gen_random_uuid()),
   @entry,
@@ -237,7 +239,8 @@ INSERT INTO catalog_configs (
   @metadata_marc21_isbn,
   @metadata_marc21_issn,
   @metadata_marc21_subtitle,
-  @metadata_marc21_title
+  @metadata_marc21_title,
+  @sources
 )
 ON CONFLICT (entry) DO UPDATE SET
   metadata_update_mode = @metadata_update_mode,
@@ -265,7 +268,8 @@ ON CONFLICT (entry) DO UPDATE SET
   metadata_marc21_isbn = @metadata_marc21_isbn,
   metadata_marc21_issn = @metadata_marc21_issn,
   metadata_marc21_subtitle = @metadata_marc21_subtitle,
-  metadata_marc21_title = @metadata_marc21_title
+  metadata_marc21_title = @metadata_marc21_title,
+  sources = @sources
 WHERE catalog_configs.entry = sqlc.narg('entry')
 RETURNING *;
 
@@ -295,7 +299,10 @@ INSERT INTO  lms_configs (
   accept_item_enabled, checkin_item_enabled, checkout_item_enabled, item_location, 
   request_item_request_type, request_item_scope_type, request_item_bib_code,
   request_item_pickup_location_enabled, requester_pickup_location, supplier_pickup_location,
-  requester_patron_pattern
+  requester_patron_pattern, lookup_item_enabled, renew_item_enabled, lms_type,
+  folio_tenant, folio_username, folio_password, folio_service_point_id, folio_instance_type_id,
+  folio_holdings_location_id, folio_material_type_id, folio_loan_type_id,
+  folio_cancellation_reason_id, folio_request_type
 ) VALUES (
   coalesce(sqlc.narg('id'), gen_random_uuid()),
   @entry,
@@ -314,7 +321,20 @@ INSERT INTO  lms_configs (
   @request_item_pickup_location_enabled,
   @requester_pickup_location,
   @supplier_pickup_location,
-  @requester_patron_pattern
+  @requester_patron_pattern,
+  @lookup_item_enabled,
+  @renew_item_enabled,
+  @lms_type,
+  @folio_tenant,
+  @folio_username,
+  @folio_password,
+  @folio_service_point_id,
+  @folio_instance_type_id,
+  @folio_holdings_location_id,
+  @folio_material_type_id,
+  @folio_loan_type_id,
+  @folio_cancellation_reason_id,
+  @folio_request_type
 )
 ON CONFLICT (entry) DO UPDATE SET
   address = @address,
@@ -332,10 +352,59 @@ ON CONFLICT (entry) DO UPDATE SET
   request_item_pickup_location_enabled = @request_item_pickup_location_enabled,
   requester_pickup_location = @requester_pickup_location,
   supplier_pickup_location = @supplier_pickup_location,
-  requester_patron_pattern = @requester_patron_pattern
+  requester_patron_pattern = @requester_patron_pattern,
+  lookup_item_enabled = @lookup_item_enabled,
+  renew_item_enabled = @renew_item_enabled,
+  lms_type = @lms_type,
+  folio_tenant = @folio_tenant,
+  folio_username = @folio_username,
+  folio_password = @folio_password,
+  folio_service_point_id = @folio_service_point_id,
+  folio_instance_type_id = @folio_instance_type_id,
+  folio_holdings_location_id = @folio_holdings_location_id,
+  folio_material_type_id = @folio_material_type_id,
+  folio_loan_type_id = @folio_loan_type_id,
+  folio_cancellation_reason_id = @folio_cancellation_reason_id,
+  folio_request_type = @folio_request_type
 WHERE lms_configs.entry = sqlc.narg('entry')
 RETURNING *;
 
+-- name: UpsertSip2Config :one
+INSERT INTO sip2_configs (
+  entry, address, institution_id, login_user_id, login_password, location_code,
+  terminal_password, error_detection, lookup_user_enabled, checkin_item_enabled,
+  checkout_item_enabled, renew_item_enabled,
+  requester_pickup_location, supplier_pickup_location, requester_patron_pattern
+) VALUES (
+  @entry, @address, @institution_id, @login_user_id, @login_password, @location_code,
+  @terminal_password, @error_detection, @lookup_user_enabled, @checkin_item_enabled,
+  @checkout_item_enabled, @renew_item_enabled,
+  @requester_pickup_location, @supplier_pickup_location, @requester_patron_pattern
+)
+ON CONFLICT (entry) DO UPDATE SET
+  address = @address,
+  institution_id = @institution_id,
+  login_user_id = @login_user_id,
+  login_password = @login_password,
+  location_code = @location_code,
+  terminal_password = @terminal_password,
+  error_detection = @error_detection,
+  lookup_user_enabled = @lookup_user_enabled,
+  checkin_item_enabled = @checkin_item_enabled,
+  checkout_item_enabled = @checkout_item_enabled,
+  renew_item_enabled = @renew_item_enabled,
+  requester_pickup_location = @requester_pickup_location,
+  supplier_pickup_location = @supplier_pickup_location,
+  requester_patron_pattern = @requester_patron_pattern
+RETURNING *;
+
+-- name: GetSip2ConfigByEntry :one
+SELECT * FROM sip2_configs
+  WHERE entry = @entry;
+
+-- name: DeleteSip2ConfigByEntry :exec
+DELETE FROM sip2_configs WHERE entry = @entry;
+
 -- name: GetNetworkById :one
 SELECT * FROM networks WHERE id = $1 LIMIT 1;
 
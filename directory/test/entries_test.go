@@ -943,6 +943,100 @@ func TestEntryDirectoryContractFieldsAndCatalogConfig(t *testing.T) {
 	}
 }
 
+func TestEntryCatalogConfigSources(t *testing.T) {
+	resetDb()
+
+	headers := map[string]string{
+		"X-Okapi-Tenant":      "ANINST",
+		"X-Okapi-Permissions": `["directory.consortium.all"]`,
+	}
+
+	body := `{
+		"name":"Federated Catalog Entry",
+		"type":"Consortium",
+		"catalogConfig":{
+			"sru":{"address":"https://sru.example.org/reservoir"},
+			"holdingsFormat":{"reservoir":{}},
+			"sources":[
+				{
+					"name":"partner",
+					"priority":10,
+					"zoom":{"address":"z3950.partner.org:210/catalog","options":{"count":"5"}},
+					"holdingsFormat":{"opac":{}}
+				}
+			]
+		}
+	}`
+	res, data := jsonReq(t, http.MethodPost, "/entries", body, headers)
+	if res.StatusCode != http.StatusCreated {
+		t.Fatalf("expected POST status %d, got %d and body %s", http.StatusCreated, res.StatusCode, data)
+	}
+	var created struct {
+		Id string `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(data), &created); err != nil {
+		t.Fatalf("failed to parse create response: %v", err)
+	}
+
+	res, data = jsonReq(t, http.MethodGet, "/entries/by-id/"+created.Id, "", headers)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected GET status %d, got %d and body %s", http.StatusOK, res.StatusCode, data)
+	}
+	entry := make(map[string]any)
+	if err := json.Unmarshal([]byte(data), &entry); err != nil {
+		t.Fatalf("failed to parse entry response: %v", err)
+	}
+	sources := entry["catalogConfig"].(map[string]any)["sources"].([]any)
+	if len(sources) != 1 {
+		t.Fatalf("expected 1 catalogConfig source, got %#v", sources)
+	}
+	source := sources[0].(map[string]any)
+	if source["name"] != "partner" || source["priority"] != float64(10) ||
+		source["zoom"].(map[string]any)["address"] != "z3950.partner.org:210/catalog" {
+		t.Fatalf("catalogConfig sources did not round-trip: %#v", source)
+	}
+
+	res, data = jsonReq(t, http.MethodPatch, "/entries/by-id/"+created.Id, `{"catalogConfig":{"queryConfig":{"title":"title = {term}"}}}`, headers)
+	if res.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected catalogConfig PATCH status %d, got %d and body %s", http.StatusNoContent, res.StatusCode, data)
+	}
+	res, data = jsonReq(t, http.MethodGet, "/entries/by-id/"+created.Id, "", headers)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected GET after catalogConfig PATCH status %d, got %d and body %s", http.StatusOK, res.StatusCode, data)
+	}
+	entry = make(map[string]any)
+	if err := json.Unmarshal([]byte(data), &entry); err != nil {
+		t.Fatalf("failed to parse entry after catalogConfig PATCH: %v", err)
+	}
+	if sources, ok := entry["catalogConfig"].(map[string]any)["sources"].([]any); !ok || len(sources) != 1 {
+		t.Fatalf("catalogConfig PATCH without sources should keep sources: %#v", entry["catalogConfig"])
+	}
+
+	res, data = jsonReq(t, http.MethodPatch, "/entries/by-id/"+created.Id, `{"catalogConfig":{"sources":[
+		{"name":"first","sru":{"address":"https://sru.first.org"}},
+		{"name":"second","priority":5,"sru":{"address":"https://sru.second.org"}}
+	]}}`, headers)
+	if res.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected catalogConfig sources PATCH status %d, got %d and body %s", http.StatusNoContent, res.StatusCode, data)
+	}
+	res, data = jsonReq(t, http.MethodGet, "/entries/by-id/"+created.Id, "", headers)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected GET after catalogConfig sources PATCH status %d, got %d and body %s", http.StatusOK, res.StatusCode, data)
+	}
+	entry = make(map[string]any)
+	if err := json.Unmarshal([]byte(data), &entry); err != nil {
+		t.Fatalf("failed to parse entry after catalogConfig sources PATCH: %v", err)
+	}
+	catalogConfig := entry["catalogConfig"].(map[string]any)
+	sources = catalogConfig["sources"].([]any)
+	if len(sources) != 2 || sources[0].(map[string]any)["name"] != "first" || sources[1].(map[string]any)["name"] != "second" {
+		t.Fatalf("catalogConfig sources PATCH did not replace sources: %#v", sources)
+	}
+	if catalogConfig["sru"].(map[string]any)["address"] != "https://sru.example.org/reservoir" {
+		t.Fatalf("catalogConfig sources PATCH changed primary catalog: %#v", catalogConfig)
+	}
+}
+
 func TestPatchCatalogConfigRequiresAddressForCreation(t *testing.T) {
 	resetDb()
 
@@ -1223,3 +1317,200 @@ func TestPublicReadSanitizesProtectedLMSValues(t *testing.T) {
 		t.Fatalf("protected lmsConfig.fromAgencyAuthentication should be sanitized, got %#v", lmsConfig["fromAgencyAuthentication"])
 	}
 }
+
+func TestEntrySip2Config(t *testing.T) {
+	resetDb()
+
+	headers := map[string]string{
+		"X-Okapi-Tenant":      "ANINST",
+		"X-Okapi-Permissions": `["directory.consortium.all"]`,
+	}
+
+	body := `{
+		"name":"SIP2 Entry",
+		"type":"Institution",
+		"parent":"00000000-0000-0000-0000-000000000004",
+		"symbols":[{"authority":"ISIL","symbol":"SIP2LIB"}],
+		"sip2Config":{
+			"address":"sip2.example.org:6001",
+			"institutionId":"SIP2LIB",
+			"loginUserId":"sipuser",
+			"loginPassword":"secret",
+			"locationCode":"MAIN",
+			"terminalPassword":"terminal"
+		}
+	}`
+	res, data := jsonReq(t, http.MethodPost, "/entries", body, headers)
+	if res.StatusCode != http.StatusCreated {
+		t.Fatalf("expected POST status %d, got %d and body %s", http.StatusCreated, res.StatusCode, data)
+	}
+	var created struct {
+		Id string `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(data), &created); err != nil {
+		t.Fatalf("failed to parse create response: %v", err)
+	}
+
+	getSip2Config := func(headers map[string]string) map[string]any {
+		res, data := jsonReq(t, http.MethodGet, "/entries/by-id/"+created.Id, "", headers)
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("expected GET status %d, got %d and body %s", http.StatusOK, res.StatusCode, data)
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			t.Fatalf("failed to parse entry response: %v", err)
+		}
+		sip2Config, _ := entry["sip2Config"].(map[string]any)
+		return sip2Config
+	}
+
+	sip2Config := getSip2Config(headers)
+	if sip2Config["address"] != "sip2.example.org:6001" ||
+		sip2Config["institutionId"] != "SIP2LIB" ||
+		sip2Config["loginUserId"] != "sipuser" ||
+		sip2Config["loginPassword"] != "secret" ||
+		sip2Config["terminalPassword"] != "terminal" ||
+		sip2Config["locationCode"] != "MAIN" ||
+		sip2Config["errorDetection"] != false ||
+		sip2Config["lookupUserEnabled"] != true ||
+		sip2Config["checkOutItemEnabled"] != true ||
+		sip2Config["checkInItemEnabled"] != true ||
+		sip2Config["renewItemEnabled"] != true ||
+		sip2Config["requesterPatronPattern"] != "INST-{requesterSymbol}" {
+		t.Fatalf("sip2Config fields did not round-trip: %#v", sip2Config)
+	}
+
+	publicHeaders := map[string]string{
+		"X-Okapi-Tenant":      "PUBLIC",
+		"X-Okapi-Permissions": `["directory.public.all"]`,
+	}
+	sip2Config = getSip2Config(publicHeaders)
+	if sip2Config["loginPassword"] != "" || sip2Config["terminalPassword"] != "" {
+		t.Fatalf("protected sip2Config passwords should be sanitized, got %#v", sip2Config)
+	}
+
+	res, data = jsonReq(t, http.MethodPatch, "/entries/by-id/"+created.Id, `{
+		"sip2Config":{
+			"renewItemEnabled":false,
+			"terminalPassword":null
+		}
+	}`, headers)
+	if res.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected PATCH status %d, got %d and body %s", http.StatusNoContent, res.StatusCode, data)
+	}
+	sip2Config = getSip2Config(headers)
+	if sip2Config["renewItemEnabled"] != false || sip2Config["address"] != "sip2.example.org:6001" || sip2Config["loginPassword"] != "secret" {
+		t.Fatalf("sip2Config PATCH did not merge fields: %#v", sip2Config)
+	}
+	if _, ok := sip2Config["terminalPassword"]; ok {
+		t.Fatalf("sip2Config.terminalPassword should be cleared: %#v", sip2Config)
+	}
+
+	res, data = jsonReq(t, http.MethodPatch, "/entries/by-id/"+created.Id, `{"sip2Config":null}`, headers)
+	if res.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected PATCH status %d, got %d and body %s", http.StatusNoContent, res.StatusCode, data)
+	}
+	if sip2Config = getSip2Config(headers); sip2Config != nil {
+		t.Fatalf("sip2Config should be omitted after null PATCH: %#v", sip2Config)
+	}
+}
+
+func TestEntryLmsConfigFolio(t *testing.T) {
+	resetDb()
+
+	headers := map[string]string{
+		"X-Okapi-Tenant":      "ANINST",
+		"X-Okapi-Permissions": `["directory.consortium.all"]`,
+	}
+
+	body := `{
+		"name":"FOLIO Entry",
+		"type":"Institution",
+		"parent":"00000000-0000-0000-0000-000000000004",
+		"symbols":[{"authority":"ISIL","symbol":"FOLIOLIB"}],
+		"lmsConfig":{
+			"type":"folio",
+			"address":"https://folio.example.org",
+			"fromAgency":"FOLIOLIB",
+			"folio":{
+				"tenant":"diku",
+				"username":"ill",
+				"password":"secret",
+				"servicePointId":"3a40852d-49fd-4df2-a1f9-6e2641a6e91f"
+			}
+		}
+	}`
+	res, data := jsonReq(t, http.MethodPost, "/entries", body, headers)
+	if res.StatusCode != http.StatusCreated {
+		t.Fatalf("expected POST status %d, got %d and body %s", http.StatusCreated, res.StatusCode, data)
+	}
+	var created struct {
+		Id string `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(data), &created); err != nil {
+		t.Fatalf("failed to parse create response: %v", err)
+	}
+
+	getLmsConfig := func(headers map[string]string) map[string]any {
+		res, data := jsonReq(t, http.MethodGet, "/entries/by-id/"+created.Id, "", headers)
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("expected GET status %d, got %d and body %s", http.StatusOK, res.StatusCode, data)
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			t.Fatalf("failed to parse entry response: %v", err)
+		}
+		lmsConfig, _ := entry["lmsConfig"].(map[string]any)
+		return lmsConfig
+	}
+
+	lmsConfig := getLmsConfig(headers)
+	folio, _ := lmsConfig["folio"].(map[string]any)
+	if lmsConfig["type"] != "folio" ||
+		folio["tenant"] != "diku" ||
+		folio["username"] != "ill" ||
+		folio["password"] != "secret" ||
+		folio["servicePointId"] != "3a40852d-49fd-4df2-a1f9-6e2641a6e91f" ||
+		folio["requestType"] != "Page" {
+		t.Fatalf("lmsConfig.folio fields did not round-trip: %#v", lmsConfig)
+	}
+
+	publicHeaders := map[string]string{
+		"X-Okapi-Tenant":      "PUBLIC",
+		"X-Okapi-Permissions": `["directory.public.all"]`,
+	}
+	lmsConfig = getLmsConfig(publicHeaders)
+	folio, _ = lmsConfig["folio"].(map[string]any)
+	if folio["password"] != "" {
+		t.Fatalf("protected lmsConfig.folio.password should be sanitized, got %#v", lmsConfig)
+	}
+
+	res, data = jsonReq(t, http.MethodPatch, "/entries/by-id/"+created.Id, `{
+		"lmsConfig":{
+			"folio":{
+				"requestType":"Hold",
+				"username":null
+			}
+		}
+	}`, headers)
+	if res.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected PATCH status %d, got %d and body %s", http.StatusNoContent, res.StatusCode, data)
+	}
+	lmsConfig = getLmsConfig(headers)
+	folio, _ = lmsConfig["folio"].(map[string]any)
+	if lmsConfig["type"] != "folio" || folio["requestType"] != "Hold" || folio["tenant"] != "diku" || folio["password"] != "secret" {
+		t.Fatalf("lmsConfig.folio PATCH did not merge fields: %#v", lmsConfig)
+	}
+	if _, ok := folio["username"]; ok {
+		t.Fatalf("lmsConfig.folio.username should be cleared: %#v", lmsConfig)
+	}
+
+	res, data = jsonReq(t, http.MethodPatch, "/entries/by-id/"+created.Id, `{"lmsConfig":{"type":"ncip","folio":null}}`, headers)
+	if res.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected PATCH status %d, got %d and body %s", http.StatusNoContent, res.StatusCode, data)
+	}
+	lmsConfig = getLmsConfig(headers)
+	if lmsConfig["type"] != "ncip" || lmsConfig["folio"] != nil || lmsConfig["address"] != "https://folio.example.org" {
+		t.Fatalf("lmsConfig.folio should be cleared after null PATCH: %#v", lmsConfig)
+	}
+}
@@ -2,12 +2,19 @@ package httpclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -18,6 +25,8 @@ const (
 
 const DefaultMaxResponseSize int64 = 1024 * 1024 * 10 // 10MB
 
+const TracerName = "github.com/indexdata/crosslink/httpclient"
+
 type HttpError struct {
 	StatusCode int
 	Body       []byte
@@ -30,6 +39,8 @@ func (e *HttpError) Error() string {
 type HttpClient struct {
 	Headers         http.Header
 	MaxResponseSize int64
+	// Context of the requests, a span in it becomes the parent of the request span
+	Context context.Context
 }
 
 func NewClient() *HttpClient {
@@ -41,6 +52,11 @@ func (c *HttpClient) WithMaxSize(maxResponseSize int64) *HttpClient {
 	return c
 }
 
+func (c *HttpClient) WithContext(ctx context.Context) *HttpClient {
+	c.Context = ctx
+	return c
+}
+
 func (c *HttpClient) WithHeaders(headers ...string) *HttpClient {
 	if c.Headers == nil {
 		c.Headers = http.Header{}
@@ -54,19 +70,35 @@ func (c *HttpClient) WithHeaders(headers ...string) *HttpClient {
 	return c
 }
 
-func (c *HttpClient) httpInvoke(client *http.Client, method string, contentTypes []string, url string, reader io.Reader) ([]byte, error) {
-	req, err := http.NewRequest(method, url, reader)
+func (c *HttpClient) httpInvoke(client *http.Client, method string, contentTypes []string, url string, reader io.Reader) (_ []byte, err error) {
+	ctx := c.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, span := otel.Tracer(TracerName).Start(ctx, "HTTP "+method, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("http.request.method", method)))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
 	if err != nil {
 		return nil, err
 	}
+	span.SetAttributes(attribute.String("server.address", req.URL.Hostname()), attribute.String("url.path", req.URL.Path))
 	if c.Headers != nil {
 		req.Header = c.Headers.Clone()
 	}
 	req.Header.Set(ContentType, contentTypes[0])
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 	defer func() {
 		dErr := resp.Body.Close()
 		if dErr != nil {
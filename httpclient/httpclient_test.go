@@ -1,6 +1,7 @@
 package httpclient
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io"
@@ -11,6 +12,14 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 type myType struct {
@@ -223,3 +232,49 @@ func TestMaxSize(t *testing.T) {
 		GetXml(http.DefaultClient, server.URL, &response)
 	assert.ErrorContains(t, err, "response body too large")
 }
+
+func TestRequestSpan(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	}()
+
+	var traceParent string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceParent = r.Header.Get("traceparent")
+		w.Header().Set("Content-Type", "application/xml")
+		_, err := w.Write([]byte("<myType><msg>OK</msg></myType>"))
+		assert.NoError(t, err)
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	var request, response myType
+	err := NewClient().WithContext(ctx).PostXml(http.DefaultClient, server.URL+"/path", request, &response)
+	assert.NoError(t, err)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	span := spans[0]
+	assert.Equal(t, "HTTP POST", span.Name)
+	assert.Equal(t, trace.SpanKindClient, span.SpanKind)
+	assert.Equal(t, parent.SpanContext().SpanID(), span.Parent.SpanID())
+	assert.Equal(t, parent.SpanContext().TraceID(), span.SpanContext.TraceID())
+	assert.Contains(t, span.Attributes, attribute.Int("http.response.status_code", http.StatusOK))
+	assert.Contains(t, span.Attributes, attribute.String("url.path", "/path"))
+	assert.Equal(t, fmt.Sprintf("00-%s-%s-01", span.SpanContext.TraceID(), span.SpanContext.SpanID()), traceParent)
+
+	exporter.Reset()
+	err = NewClient().GetXml(http.DefaultClient, "xxx:/", &response)
+	assert.Error(t, err)
+	spans = exporter.GetSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.False(t, spans[0].Parent.IsValid())
+}
@@ -16,9 +16,11 @@ import (
 	"github.com/indexdata/crosslink/httpclient"
 	"github.com/indexdata/crosslink/illmock/dirmock"
 	"github.com/indexdata/crosslink/illmock/flows"
+	"github.com/indexdata/crosslink/illmock/foliomock"
 	"github.com/indexdata/crosslink/illmock/netutil"
 	"github.com/indexdata/crosslink/illmock/reqform"
 	"github.com/indexdata/crosslink/illmock/role"
+	"github.com/indexdata/crosslink/illmock/sip2mock"
 	"github.com/indexdata/crosslink/illmock/slogwrap"
 	"github.com/indexdata/crosslink/illmock/sruapi"
 	"github.com/indexdata/crosslink/iso18626"
@@ -27,6 +29,7 @@ import (
 
 type MockApp struct {
 	httpPort     string
+	sip2Port     string
 	agencyType   string
 	peerUrl      string
 	messageDelay time.Duration
@@ -35,6 +38,7 @@ type MockApp struct {
 	supplier     Supplier
 	flowsApi     *flows.FlowsApi
 	sruApi       *sruapi.SruApi
+	sip2Mock     *sip2mock.Sip2Mock
 	headers      []string
 	client       httpclient.HttpClient
 }
@@ -266,6 +270,9 @@ func (app *MockApp) parseEnv() error {
 	if app.httpPort == "" {
 		app.httpPort = utils.GetEnv("HTTP_PORT", "8081")
 	}
+	if app.sip2Port == "" {
+		app.sip2Port = os.Getenv("SIP2_PORT")
+	}
 	if app.agencyType == "" {
 		app.agencyType = os.Getenv("AGENCY_TYPE")
 	}
@@ -307,6 +314,9 @@ func (app *MockApp) Shutdown() error {
 	if app.flowsApi != nil {
 		app.flowsApi.Shutdown()
 	}
+	if app.sip2Mock != nil {
+		app.sip2Mock.Shutdown()
+	}
 	if app.server != nil {
 		return app.server.Shutdown(context.Background())
 	}
@@ -362,6 +372,7 @@ func (app *MockApp) Run() error {
 	mux.HandleFunc("/api/flows", app.flowsApi.HttpHandler())
 	mux.HandleFunc("/sru", app.sruApi.HttpHandler())
 	mux.HandleFunc("/ncip", ncipMockHandler)
+	mux.Handle("/folio/", http.StripPrefix("/folio", foliomock.CreateFolioMock()))
 
 	dir, err := dirmock.NewEnv()
 	if err != nil {
@@ -371,6 +382,17 @@ func (app *MockApp) Run() error {
 		return err
 	}
 
+	if app.sip2Port != "" {
+		sip2Addr := app.sip2Port
+		if !strings.Contains(sip2Addr, ":") {
+			sip2Addr = ":" + sip2Addr
+		}
+		app.sip2Mock = sip2mock.CreateSip2Mock()
+		if err := app.sip2Mock.Start(sip2Addr); err != nil {
+			return err
+		}
+	}
+
 	app.server = &http.Server{Addr: addr, Handler: mux}
 	app.flowsApi.Run()
 	return app.server.ListenAndServe()
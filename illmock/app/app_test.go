@@ -1,6 +1,7 @@
 package app
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"encoding/xml"
@@ -67,6 +68,8 @@ func TestParseEnv(t *testing.T) {
 	assert.NoError(t, err, "failed to set env")
 	err = os.Setenv("MESSAGE_DELAY", "1ms")
 	assert.NoError(t, err, "failed to set env")
+	err = os.Setenv("SIP2_PORT", "6001")
+	assert.NoError(t, err, "failed to set env")
 	var app MockApp
 	err = app.parseEnv()
 	assert.NoError(t, err)
@@ -76,6 +79,7 @@ func TestParseEnv(t *testing.T) {
 	assert.Equal(t, "R1", app.requester.requestingAgencyId)
 	assert.Equal(t, "https://localhost:8082", app.peerUrl)
 	assert.Equal(t, 1*time.Millisecond, app.messageDelay)
+	assert.Equal(t, "6001", app.sip2Port)
 	err = os.Unsetenv("HTTP_PORT")
 	assert.NoError(t, err, "failed to set env")
 	err = os.Unsetenv("PEER_URL")
@@ -88,6 +92,8 @@ func TestParseEnv(t *testing.T) {
 	assert.NoError(t, err, "failed to set env")
 	err = os.Unsetenv("MESSAGE_DELAY")
 	assert.NoError(t, err, "failed to set env")
+	err = os.Unsetenv("SIP2_PORT")
+	assert.NoError(t, err, "failed to set env")
 }
 
 func TestAppBadMessageDelay(t *testing.T) {
@@ -314,6 +320,31 @@ func checkCancel(t *testing.T, ret []flows.FlowMessage) (int, int, int) {
 	return ramg, sam, samc
 }
 
+func TestAppSip2(t *testing.T) {
+	var app MockApp
+	app.flowsApi = flows.CreateFlowsApi()
+	app.httpPort = testutil.GetFreePortTest(t)
+	app.sip2Port = testutil.GetFreePortTest(t)
+	go func() {
+		err := app.Run()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			t.Logf("app.Run error %s", err.Error())
+		}
+	}()
+	testutil.WaitForPort(t, "localhost:"+app.httpPort, time.Second)
+
+	conn, err := net.Dial("tcp", "localhost:"+app.sip2Port)
+	assert.NoError(t, err)
+	_, err = conn.Write([]byte("9300CNuser|COpass|\r"))
+	assert.NoError(t, err)
+	res, err := bufio.NewReader(conn).ReadString('\r')
+	assert.NoError(t, err)
+	assert.Equal(t, "941\r", res)
+	assert.NoError(t, conn.Close())
+
+	assert.NoError(t, app.Shutdown())
+}
+
 func TestService(t *testing.T) {
 	var app MockApp
 	app.flowsApi = flows.CreateFlowsApi() // FlowsApi.ParseEnv is not called
@@ -394,6 +425,18 @@ func TestService(t *testing.T) {
 		assert.Contains(t, string(buf), "<explainResponse")
 	})
 
+	t.Run("folio handler: ok", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, url+"/folio/users?query=barcode%3D%3D%22u1%22", nil)
+		assert.NoError(t, err)
+		req.Header.Set("X-Okapi-Tenant", "diku")
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		buf, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Contains(t, string(buf), `"barcode":"u1"`)
+	})
+
 	t.Run("iso18626 handler: Bad method", func(t *testing.T) {
 		resp, err := http.Get(isoUrl)
 		assert.NoError(t, err)
@@ -6,11 +6,16 @@ import (
 	"mime"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/indexdata/crosslink/illmock/netutil"
 	"github.com/indexdata/crosslink/ncip"
+	"github.com/indexdata/go-utils/utils"
 )
 
+// renewalPeriod is the loan extension granted by RenewItem when no due date is desired
+const renewalPeriod = 28 * 24 * time.Hour
+
 func setProblem(msg ncip.ProblemTypeMessage, detail string) []ncip.Problem {
 	return []ncip.Problem{
 		{
@@ -159,6 +164,112 @@ func handleCreateUserFiscalTransaction(req *ncip.NCIPMessage, res *ncip.NCIPMess
 	res.CreateUserFiscalTransactionResponse.Problem = problem
 }
 
+// mockCirculationStatus returns the circulation status of a mock item:
+// items with prefix "l" are on loan, all others are available on shelf.
+func mockCirculationStatus(itemId string) ncip.CirculationStatusValue {
+	if strings.HasPrefix(itemId, "l") {
+		return ncip.CirculationStatusOnLoan
+	}
+	return ncip.CirculationStatusAvailableOnShelf
+}
+
+func mockItemOptionalFields(itemId string) *ncip.ItemOptionalFields {
+	return &ncip.ItemOptionalFields{
+		CirculationStatus: &ncip.SchemeValuePair{Text: string(mockCirculationStatus(itemId))},
+	}
+}
+
+func handleLookupItem(req *ncip.NCIPMessage, res *ncip.NCIPMessage) {
+	var problem []ncip.Problem
+	res.LookupItemResponse = &ncip.LookupItemResponse{}
+	if req.LookupItem.ItemId == nil && req.LookupItem.RequestId == nil {
+		problem = setProblem(ncip.NeededDataMissing, "ItemId or RequestId is required")
+	} else if req.LookupItem.ItemId != nil && strings.HasPrefix(req.LookupItem.ItemId.ItemIdentifierValue, "f") {
+		problem = setProblem(ncip.UnknownItem, req.LookupItem.ItemId.ItemIdentifierValue)
+	}
+	if problem == nil && req.LookupItem.ItemId != nil {
+		res.LookupItemResponse.ItemOptionalFields = mockItemOptionalFields(req.LookupItem.ItemId.ItemIdentifierValue)
+	}
+	res.LookupItemResponse.ItemId = req.LookupItem.ItemId
+	res.LookupItemResponse.RequestId = req.LookupItem.RequestId
+	res.LookupItemResponse.Problem = problem
+}
+
+func handleLookupItemSet(req *ncip.NCIPMessage, res *ncip.NCIPMessage) {
+	var problem []ncip.Problem
+	res.LookupItemSetResponse = &ncip.LookupItemSetResponse{}
+	if len(req.LookupItemSet.ItemId) == 0 && len(req.LookupItemSet.BibliographicId) == 0 &&
+		len(req.LookupItemSet.HoldingsSetId) == 0 {
+		problem = setProblem(ncip.NeededDataMissing, "BibliographicId, HoldingsSetId or ItemId is required")
+	}
+	for _, itemId := range req.LookupItemSet.ItemId {
+		bibInformation := ncip.BibInformation{}
+		if strings.HasPrefix(itemId.ItemIdentifierValue, "f") {
+			bibInformation.Problem = setProblem(ncip.UnknownItem, itemId.ItemIdentifierValue)
+		} else {
+			bibInformation.HoldingsSet = []ncip.HoldingsSet{{
+				ItemInformation: []ncip.ItemInformation{{
+					ItemId:             &itemId,
+					ItemOptionalFields: mockItemOptionalFields(itemId.ItemIdentifierValue),
+				}},
+			}}
+		}
+		res.LookupItemSetResponse.BibInformation = append(res.LookupItemSetResponse.BibInformation, bibInformation)
+	}
+	for _, bibId := range req.LookupItemSet.BibliographicId {
+		bibInformation := ncip.BibInformation{BibliographicId: &bibId}
+		if bibId.BibliographicRecordId != nil {
+			// the mock has a single item per record, identified by the record identifier
+			recordId := bibId.BibliographicRecordId.BibliographicRecordIdentifier
+			bibInformation.HoldingsSet = []ncip.HoldingsSet{{
+				ItemInformation: []ncip.ItemInformation{{
+					ItemId:             &ncip.ItemId{ItemIdentifierValue: recordId},
+					ItemOptionalFields: mockItemOptionalFields(recordId),
+				}},
+			}}
+		}
+		res.LookupItemSetResponse.BibInformation = append(res.LookupItemSetResponse.BibInformation, bibInformation)
+	}
+	for _, holdingsSetId := range req.LookupItemSet.HoldingsSetId {
+		bibInformation := ncip.BibInformation{
+			HoldingsSet: []ncip.HoldingsSet{{
+				HoldingsSetId: holdingsSetId,
+				ItemInformation: []ncip.ItemInformation{{
+					ItemId:             &ncip.ItemId{ItemIdentifierValue: holdingsSetId},
+					ItemOptionalFields: mockItemOptionalFields(holdingsSetId),
+				}},
+			}},
+		}
+		res.LookupItemSetResponse.BibInformation = append(res.LookupItemSetResponse.BibInformation, bibInformation)
+	}
+	res.LookupItemSetResponse.Problem = problem
+}
+
+func handleRenewItem(req *ncip.NCIPMessage, res *ncip.NCIPMessage) {
+	var problem []ncip.Problem
+	res.RenewItemResponse = &ncip.RenewItemResponse{}
+	if req.RenewItem.UserId == nil && len(req.RenewItem.AuthenticationInput) == 0 {
+		problem = setProblem(ncip.NeededDataMissing, "UserId or AuthenticationInput is required")
+	} else if req.RenewItem.ItemId.ItemIdentifierValue == "" {
+		problem = setProblem(ncip.NeededDataMissing, "ItemId is required")
+	} else if req.RenewItem.UserId != nil && strings.HasPrefix(req.RenewItem.UserId.UserIdentifierValue, "f") {
+		problem = setProblem(ncip.UnknownUser, req.RenewItem.UserId.UserIdentifierValue)
+	} else if strings.HasPrefix(req.RenewItem.ItemId.ItemIdentifierValue, "f") {
+		problem = setProblem(ncip.UnknownItem, req.RenewItem.ItemId.ItemIdentifierValue)
+	}
+	if problem == nil {
+		dateDue := req.RenewItem.DesiredDateDue
+		if dateDue == nil {
+			dateDue = &utils.XSDDateTime{Time: time.Now().UTC().Add(renewalPeriod).Truncate(time.Second)}
+		}
+		res.RenewItemResponse.DateDue = dateDue
+		res.RenewItemResponse.RenewalCount = 1
+	}
+	res.RenewItemResponse.ItemId = &req.RenewItem.ItemId
+	res.RenewItemResponse.UserId = req.RenewItem.UserId
+	res.RenewItemResponse.Problem = problem
+}
+
 func ncipMockHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -210,6 +321,12 @@ func ncipMockHandler(w http.ResponseWriter, r *http.Request) {
 		handleCheckOutItem(&ncipRequest, &ncipResponse)
 	case ncipRequest.CreateUserFiscalTransaction != nil:
 		handleCreateUserFiscalTransaction(&ncipRequest, &ncipResponse)
+	case ncipRequest.LookupItem != nil:
+		handleLookupItem(&ncipRequest, &ncipResponse)
+	case ncipRequest.LookupItemSet != nil:
+		handleLookupItemSet(&ncipRequest, &ncipResponse)
+	case ncipRequest.RenewItem != nil:
+		handleRenewItem(&ncipRequest, &ncipResponse)
 	default:
 		ncipResponse.Problem = setProblem(ncip.UnsupportedService, "")
 	}
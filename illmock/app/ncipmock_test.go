@@ -8,8 +8,10 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/indexdata/crosslink/ncip"
+	"github.com/indexdata/go-utils/utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -806,3 +808,220 @@ func TestPostCreateUserFiscalTransactionFailUserId(t *testing.T) {
 	assert.Equal(t, string(ncip.UnknownUser), ncipResponse.CreateUserFiscalTransactionResponse.Problem[0].ProblemType.Text)
 	assert.Equal(t, "f12345", ncipResponse.CreateUserFiscalTransactionResponse.Problem[0].ProblemDetail)
 }
+
+func TestPostLookupItemOK(t *testing.T) {
+	req := ncip.NCIPMessage{
+		Version: ncip.NCIP_V2_02_XSD,
+		LookupItem: &ncip.LookupItem{
+			ItemId: &ncip.ItemId{
+				ItemIdentifierValue: "item-001",
+			},
+		},
+	}
+	ncipResponse := sendReceive(t, req)
+	assert.NotNil(t, ncipResponse.LookupItemResponse)
+	assert.Len(t, ncipResponse.LookupItemResponse.Problem, 0)
+	assert.Equal(t, "item-001", ncipResponse.LookupItemResponse.ItemId.ItemIdentifierValue)
+	assert.Equal(t, string(ncip.CirculationStatusAvailableOnShelf),
+		ncipResponse.LookupItemResponse.ItemOptionalFields.CirculationStatus.Text)
+}
+
+func TestPostLookupItemOnLoan(t *testing.T) {
+	req := ncip.NCIPMessage{
+		Version: ncip.NCIP_V2_02_XSD,
+		LookupItem: &ncip.LookupItem{
+			ItemId: &ncip.ItemId{
+				ItemIdentifierValue: "litem-001",
+			},
+		},
+	}
+	ncipResponse := sendReceive(t, req)
+	assert.NotNil(t, ncipResponse.LookupItemResponse)
+	assert.Len(t, ncipResponse.LookupItemResponse.Problem, 0)
+	assert.Equal(t, string(ncip.CirculationStatusOnLoan),
+		ncipResponse.LookupItemResponse.ItemOptionalFields.CirculationStatus.Text)
+}
+
+func TestPostLookupItemMissingItemId(t *testing.T) {
+	req := ncip.NCIPMessage{
+		Version:    ncip.NCIP_V2_02_XSD,
+		LookupItem: &ncip.LookupItem{},
+	}
+	ncipResponse := sendReceive(t, req)
+	assert.NotNil(t, ncipResponse.LookupItemResponse)
+	assert.Len(t, ncipResponse.LookupItemResponse.Problem, 1)
+	assert.Equal(t, string(ncip.NeededDataMissing), ncipResponse.LookupItemResponse.Problem[0].ProblemType.Text)
+	assert.Equal(t, "ItemId or RequestId is required", ncipResponse.LookupItemResponse.Problem[0].ProblemDetail)
+}
+
+func TestPostLookupItemFailItemId(t *testing.T) {
+	req := ncip.NCIPMessage{
+		Version: ncip.NCIP_V2_02_XSD,
+		LookupItem: &ncip.LookupItem{
+			ItemId: &ncip.ItemId{
+				ItemIdentifierValue: "fitem-001",
+			},
+		},
+	}
+	ncipResponse := sendReceive(t, req)
+	assert.NotNil(t, ncipResponse.LookupItemResponse)
+	assert.Len(t, ncipResponse.LookupItemResponse.Problem, 1)
+	assert.Equal(t, string(ncip.UnknownItem), ncipResponse.LookupItemResponse.Problem[0].ProblemType.Text)
+	assert.Equal(t, "fitem-001", ncipResponse.LookupItemResponse.Problem[0].ProblemDetail)
+	assert.Nil(t, ncipResponse.LookupItemResponse.ItemOptionalFields)
+}
+
+func TestPostLookupItemSetOK(t *testing.T) {
+	req := ncip.NCIPMessage{
+		Version: ncip.NCIP_V2_02_XSD,
+		LookupItemSet: &ncip.LookupItemSet{
+			ItemId: []ncip.ItemId{
+				{ItemIdentifierValue: "item-001"},
+				{ItemIdentifierValue: "fitem-002"},
+			},
+			BibliographicId: []ncip.BibliographicId{
+				{BibliographicRecordId: &ncip.BibliographicRecordId{BibliographicRecordIdentifier: "litem-003"}},
+			},
+			HoldingsSetId: []string{"h-004"},
+		},
+	}
+	ncipResponse := sendReceive(t, req)
+	res := ncipResponse.LookupItemSetResponse
+	assert.NotNil(t, res)
+	assert.Len(t, res.Problem, 0)
+	assert.Len(t, res.BibInformation, 4)
+
+	item := res.BibInformation[0].HoldingsSet[0].ItemInformation[0]
+	assert.Equal(t, "item-001", item.ItemId.ItemIdentifierValue)
+	assert.Equal(t, string(ncip.CirculationStatusAvailableOnShelf), item.ItemOptionalFields.CirculationStatus.Text)
+
+	assert.Len(t, res.BibInformation[1].Problem, 1)
+	assert.Equal(t, string(ncip.UnknownItem), res.BibInformation[1].Problem[0].ProblemType.Text)
+
+	item = res.BibInformation[2].HoldingsSet[0].ItemInformation[0]
+	assert.Equal(t, "litem-003", item.ItemId.ItemIdentifierValue)
+	assert.Equal(t, string(ncip.CirculationStatusOnLoan), item.ItemOptionalFields.CirculationStatus.Text)
+
+	assert.Equal(t, "h-004", res.BibInformation[3].HoldingsSet[0].HoldingsSetId)
+}
+
+func TestPostLookupItemSetMissingId(t *testing.T) {
+	req := ncip.NCIPMessage{
+		Version:       ncip.NCIP_V2_02_XSD,
+		LookupItemSet: &ncip.LookupItemSet{},
+	}
+	ncipResponse := sendReceive(t, req)
+	assert.NotNil(t, ncipResponse.LookupItemSetResponse)
+	assert.Len(t, ncipResponse.LookupItemSetResponse.Problem, 1)
+	assert.Equal(t, string(ncip.NeededDataMissing), ncipResponse.LookupItemSetResponse.Problem[0].ProblemType.Text)
+}
+
+func TestPostRenewItemOK(t *testing.T) {
+	desired := utils.XSDDateTime{Time: time.Date(2030, 1, 15, 0, 0, 0, 0, time.UTC)}
+	req := ncip.NCIPMessage{
+		Version: ncip.NCIP_V2_02_XSD,
+		RenewItem: &ncip.RenewItem{
+			UserId: &ncip.UserId{
+				UserIdentifierValue: "user-001",
+			},
+			ItemId: ncip.ItemId{
+				ItemIdentifierValue: "item-001",
+			},
+			DesiredDateDue: &desired,
+		},
+	}
+	ncipResponse := sendReceive(t, req)
+	assert.NotNil(t, ncipResponse.RenewItemResponse)
+	assert.Len(t, ncipResponse.RenewItemResponse.Problem, 0)
+	assert.Equal(t, "item-001", ncipResponse.RenewItemResponse.ItemId.ItemIdentifierValue)
+	assert.True(t, desired.Equal(ncipResponse.RenewItemResponse.DateDue.Time))
+	assert.Equal(t, uint64(1), ncipResponse.RenewItemResponse.RenewalCount)
+}
+
+func TestPostRenewItemDefaultDateDue(t *testing.T) {
+	req := ncip.NCIPMessage{
+		Version: ncip.NCIP_V2_02_XSD,
+		RenewItem: &ncip.RenewItem{
+			UserId: &ncip.UserId{
+				UserIdentifierValue: "user-001",
+			},
+			ItemId: ncip.ItemId{
+				ItemIdentifierValue: "item-001",
+			},
+		},
+	}
+	ncipResponse := sendReceive(t, req)
+	assert.NotNil(t, ncipResponse.RenewItemResponse)
+	assert.Len(t, ncipResponse.RenewItemResponse.Problem, 0)
+	assert.True(t, ncipResponse.RenewItemResponse.DateDue.After(time.Now().Add(27*24*time.Hour)))
+}
+
+func TestPostRenewItemMissingUserId(t *testing.T) {
+	req := ncip.NCIPMessage{
+		Version: ncip.NCIP_V2_02_XSD,
+		RenewItem: &ncip.RenewItem{
+			ItemId: ncip.ItemId{
+				ItemIdentifierValue: "item-001",
+			},
+		},
+	}
+	ncipResponse := sendReceive(t, req)
+	assert.NotNil(t, ncipResponse.RenewItemResponse)
+	assert.Len(t, ncipResponse.RenewItemResponse.Problem, 1)
+	assert.Equal(t, string(ncip.NeededDataMissing), ncipResponse.RenewItemResponse.Problem[0].ProblemType.Text)
+	assert.Equal(t, "UserId or AuthenticationInput is required", ncipResponse.RenewItemResponse.Problem[0].ProblemDetail)
+}
+
+func TestPostRenewItemMissingItemId(t *testing.T) {
+	req := ncip.NCIPMessage{
+		Version: ncip.NCIP_V2_02_XSD,
+		RenewItem: &ncip.RenewItem{
+			UserId: &ncip.UserId{
+				UserIdentifierValue: "user-001",
+			},
+		},
+	}
+	ncipResponse := sendReceive(t, req)
+	assert.NotNil(t, ncipResponse.RenewItemResponse)
+	assert.Len(t, ncipResponse.RenewItemResponse.Problem, 1)
+	assert.Equal(t, string(ncip.NeededDataMissing), ncipResponse.RenewItemResponse.Problem[0].ProblemType.Text)
+	assert.Equal(t, "ItemId is required", ncipResponse.RenewItemResponse.Problem[0].ProblemDetail)
+}
+
+func TestPostRenewItemFailUserId(t *testing.T) {
+	req := ncip.NCIPMessage{
+		Version: ncip.NCIP_V2_02_XSD,
+		RenewItem: &ncip.RenewItem{
+			UserId: &ncip.UserId{
+				UserIdentifierValue: "fuser-001",
+			},
+			ItemId: ncip.ItemId{
+				ItemIdentifierValue: "item-001",
+			},
+		},
+	}
+	ncipResponse := sendReceive(t, req)
+	assert.NotNil(t, ncipResponse.RenewItemResponse)
+	assert.Len(t, ncipResponse.RenewItemResponse.Problem, 1)
+	assert.Equal(t, string(ncip.UnknownUser), ncipResponse.RenewItemResponse.Problem[0].ProblemType.Text)
+	assert.Nil(t, ncipResponse.RenewItemResponse.DateDue)
+}
+
+func TestPostRenewItemFailItemId(t *testing.T) {
+	req := ncip.NCIPMessage{
+		Version: ncip.NCIP_V2_02_XSD,
+		RenewItem: &ncip.RenewItem{
+			UserId: &ncip.UserId{
+				UserIdentifierValue: "user-001",
+			},
+			ItemId: ncip.ItemId{
+				ItemIdentifierValue: "fitem-001",
+			},
+		},
+	}
+	ncipResponse := sendReceive(t, req)
+	assert.NotNil(t, ncipResponse.RenewItemResponse)
+	assert.Len(t, ncipResponse.RenewItemResponse.Problem, 1)
+	assert.Equal(t, string(ncip.UnknownItem), ncipResponse.RenewItemResponse.Problem[0].ProblemType.Text)
+	assert.Equal(t, "fitem-001", ncipResponse.RenewItemResponse.Problem[0].ProblemDetail)
+}
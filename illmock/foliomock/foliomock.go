@@ -0,0 +1,361 @@
+package foliomock
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// loanPeriod is the loan period granted by check out and renew
+const loanPeriod = 28 * 24 * time.Hour
+
+// token is the access token returned by login and accepted by all other endpoints
+const token = "mock-folio-token"
+
+// namespace is used for deriving stable identifiers from barcodes and HRIDs
+var namespace = uuid.MustParse("6ba7b812-9dad-11d1-80b4-00c04fd430c8")
+
+var barcodeQuery = regexp.MustCompile(`^barcode=="(.*)"$`)
+var hridQuery = regexp.MustCompile(`^hrid=="(.*)"$`)
+var instanceIdQuery = regexp.MustCompile(`^instanceId=="(.*)"$`)
+var holdingsRecordIdQuery = regexp.MustCompile(`^holdingsRecordId=="(.*)"$`)
+
+// FolioMock answers a subset of the FOLIO authn, users, inventory and circulation APIs.
+// It keeps no state: identifiers and barcodes with prefix "f", other than UUIDs, are unknown and items
+// with barcode prefix "l" are checked out.
+type FolioMock struct {
+	mux *http.ServeMux
+}
+
+func CreateFolioMock() *FolioMock {
+	m := &FolioMock{mux: http.NewServeMux()}
+	m.mux.HandleFunc("POST /authn/login-with-expiry", handleLogin)
+	m.mux.HandleFunc("GET /users", handleUsers)
+	m.mux.HandleFunc("GET /inventory/instances", handleInstances)
+	m.mux.HandleFunc("POST /inventory/instances", handleCreate)
+	m.mux.HandleFunc("DELETE /inventory/instances/{id}", handleDelete)
+	m.mux.HandleFunc("GET /holdings-storage/holdings", handleHoldings)
+	m.mux.HandleFunc("POST /holdings-storage/holdings", handleCreate)
+	m.mux.HandleFunc("DELETE /holdings-storage/holdings/{id}", handleDelete)
+	m.mux.HandleFunc("GET /inventory/items", handleItems)
+	m.mux.HandleFunc("GET /inventory/items/{id}", handleItem)
+	m.mux.HandleFunc("POST /inventory/items", handleCreate)
+	m.mux.HandleFunc("DELETE /inventory/items/{id}", handleDelete)
+	m.mux.HandleFunc("POST /circulation/requests", handleCreateRequest)
+	m.mux.HandleFunc("GET /circulation/requests/{id}", handleGetRequest)
+	m.mux.HandleFunc("PUT /circulation/requests/{id}", handleUpdateRequest)
+	m.mux.HandleFunc("POST /circulation/check-out-by-barcode", handleCheckOut)
+	m.mux.HandleFunc("POST /circulation/check-in-by-barcode", handleCheckIn)
+	m.mux.HandleFunc("POST /circulation/renew-by-barcode", handleRenew)
+	return m
+}
+
+func (m *FolioMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Okapi-Tenant") == "" {
+		writeErrors(w, http.StatusBadRequest, "Missing X-Okapi-Tenant header")
+		return
+	}
+	if r.URL.Path != "/authn/login-with-expiry" && !authorized(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	m.mux.ServeHTTP(w, r)
+}
+
+// authorized accepts requests without a token, as FOLIO does behind a gateway that has
+// already authenticated the caller, and requests with the token returned by login.
+func authorized(r *http.Request) bool {
+	value := r.Header.Get("X-Okapi-Token")
+	if cookie, err := r.Cookie("folioAccessToken"); err == nil {
+		value = cookie.Value
+	}
+	return value == "" || value == token
+}
+
+func id(kind string, key string) string {
+	return uuid.NewSHA1(namespace, []byte(kind+":"+key)).String()
+}
+
+// unknown reports whether a barcode or identifier refers to a missing record.
+// UUIDs are always known as they are typically derived by the client.
+func unknown(value string) bool {
+	return strings.HasPrefix(value, "f") && uuid.Validate(value) != nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeErrors(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{"errors": []map[string]any{{"message": message}}})
+}
+
+func readBody(w http.ResponseWriter, r *http.Request) (map[string]any, bool) {
+	bytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	var body map[string]any
+	if err = json.Unmarshal(bytes, &body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return body, true
+}
+
+func stringField(body map[string]any, name string) string {
+	value, _ := body[name].(string)
+	return value
+}
+
+func handleLogin(w http.ResponseWriter, r *http.Request) {
+	body, ok := readBody(w, r)
+	if !ok {
+		return
+	}
+	username := stringField(body, "username")
+	if username == "" || unknown(username) || stringField(body, "password") == "" {
+		writeErrors(w, http.StatusUnprocessableEntity, "Invalid credentials")
+		return
+	}
+	expires := time.Now().Add(10 * time.Minute).UTC()
+	http.SetCookie(w, &http.Cookie{Name: "folioAccessToken", Value: token, Path: "/", Expires: expires, HttpOnly: true})
+	writeJSON(w, http.StatusCreated, map[string]any{"accessTokenExpiration": expires.Format(time.RFC3339)})
+}
+
+func handleUsers(w http.ResponseWriter, r *http.Request) {
+	match := barcodeQuery.FindStringSubmatch(r.URL.Query().Get("query"))
+	if match == nil {
+		writeErrors(w, http.StatusBadRequest, "Unsupported query")
+		return
+	}
+	users := []map[string]any{}
+	if !unknown(match[1]) {
+		users = append(users, map[string]any{"id": id("user", match[1]), "barcode": match[1], "active": true})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"users": users, "totalRecords": len(users)})
+}
+
+func handleInstances(w http.ResponseWriter, r *http.Request) {
+	match := hridQuery.FindStringSubmatch(r.URL.Query().Get("query"))
+	if match == nil {
+		writeErrors(w, http.StatusBadRequest, "Unsupported query")
+		return
+	}
+	instances := []map[string]any{}
+	if !unknown(match[1]) {
+		instances = append(instances, map[string]any{"id": id("instance", match[1]), "hrid": match[1], "title": "Mock title " + match[1]})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"instances": instances, "totalRecords": len(instances)})
+}
+
+// handleHoldings returns a single holdings record for known instances
+func handleHoldings(w http.ResponseWriter, r *http.Request) {
+	match := instanceIdQuery.FindStringSubmatch(r.URL.Query().Get("query"))
+	if match == nil {
+		writeErrors(w, http.StatusBadRequest, "Unsupported query")
+		return
+	}
+	holdings := []map[string]any{}
+	if !unknown(match[1]) {
+		holdings = append(holdings, map[string]any{"id": id("holdings", match[1]), "instanceId": match[1]})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"holdingsRecords": holdings, "totalRecords": len(holdings)})
+}
+
+func handleCreate(w http.ResponseWriter, r *http.Request) {
+	body, ok := readBody(w, r)
+	if !ok {
+		return
+	}
+	if unknown(stringField(body, "barcode")) {
+		writeErrors(w, http.StatusUnprocessableEntity, "Barcode must be unique")
+		return
+	}
+	if stringField(body, "id") == "" {
+		body["id"] = uuid.NewString()
+	}
+	writeJSON(w, http.StatusCreated, body)
+}
+
+func handleDelete(w http.ResponseWriter, r *http.Request) {
+	if unknown(r.PathValue("id")) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func item(barcode string) map[string]any {
+	status := "Available"
+	if strings.HasPrefix(barcode, "l") {
+		status = "Checked out"
+	}
+	return map[string]any{
+		"id":      id("item", barcode),
+		"barcode": barcode,
+		"title":   "Mock title " + barcode,
+		"status":  map[string]any{"name": status},
+		"effectiveCallNumberComponents": map[string]any{
+			"callNumber": "QA76 " + strings.ToUpper(barcode),
+		},
+	}
+}
+
+// handleItems looks up items by barcode or holdings record. A known holdings record has a single item
+// that has the holdings record identifier as barcode.
+func handleItems(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("query")
+	items := []map[string]any{}
+	if match := barcodeQuery.FindStringSubmatch(query); match != nil {
+		if !unknown(match[1]) {
+			items = append(items, item(match[1]))
+		}
+	} else if match := holdingsRecordIdQuery.FindStringSubmatch(query); match != nil {
+		if !unknown(match[1]) {
+			holdingsItem := item(match[1])
+			holdingsItem["holdingsRecordId"] = match[1]
+			items = append(items, holdingsItem)
+		}
+	} else {
+		writeErrors(w, http.StatusBadRequest, "Unsupported query")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "totalRecords": len(items)})
+}
+
+// handleItem treats the item identifier as the barcode
+func handleItem(w http.ResponseWriter, r *http.Request) {
+	itemId := r.PathValue("id")
+	if unknown(itemId) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	res := item(itemId)
+	res["id"] = itemId
+	writeJSON(w, http.StatusOK, res)
+}
+
+// handleCreateRequest fills title-level requests with an item that has the instance identifier as barcode
+func handleCreateRequest(w http.ResponseWriter, r *http.Request) {
+	body, ok := readBody(w, r)
+	if !ok {
+		return
+	}
+	for _, field := range []string{"requestType", "requestLevel", "requesterId", "instanceId"} {
+		if stringField(body, field) == "" {
+			writeErrors(w, http.StatusUnprocessableEntity, field+" is required")
+			return
+		}
+	}
+	instanceId := stringField(body, "instanceId")
+	if unknown(instanceId) || unknown(stringField(body, "itemId")) {
+		writeErrors(w, http.StatusUnprocessableEntity, "Cannot create a request with no available items")
+		return
+	}
+	if stringField(body, "id") == "" {
+		body["id"] = uuid.NewString()
+	}
+	barcode := instanceId
+	if stringField(body, "itemId") != "" {
+		barcode = stringField(body, "itemId")
+	}
+	it := item(barcode)
+	body["itemId"] = it["id"]
+	body["status"] = "Open - Not yet filled"
+	body["item"] = map[string]any{"barcode": barcode, "callNumber": "QA76 " + strings.ToUpper(barcode)}
+	body["instance"] = map[string]any{"title": it["title"]}
+	writeJSON(w, http.StatusCreated, body)
+}
+
+func handleGetRequest(w http.ResponseWriter, r *http.Request) {
+	requestId := r.PathValue("id")
+	if unknown(requestId) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":           requestId,
+		"requestType":  "Page",
+		"requestLevel": "Title",
+		"status":       "Open - Not yet filled",
+	})
+}
+
+func handleUpdateRequest(w http.ResponseWriter, r *http.Request) {
+	body, ok := readBody(w, r)
+	if !ok {
+		return
+	}
+	if unknown(r.PathValue("id")) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if stringField(body, "status") == "Closed - Cancelled" && stringField(body, "cancellationReasonId") == "" {
+		writeErrors(w, http.StatusUnprocessableEntity, "Cancellation reason is required")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkFields writes an error and returns false if a field is missing or refers to an unknown record
+func checkFields(w http.ResponseWriter, body map[string]any, fields ...string) bool {
+	for _, field := range fields {
+		value := stringField(body, field)
+		if value == "" {
+			writeErrors(w, http.StatusUnprocessableEntity, field+" is required")
+			return false
+		}
+		if unknown(value) {
+			writeErrors(w, http.StatusUnprocessableEntity, "No record found for "+field+" "+value)
+			return false
+		}
+	}
+	return true
+}
+
+func loan(body map[string]any) map[string]any {
+	barcode := stringField(body, "itemBarcode")
+	it := item(barcode)
+	it["status"] = map[string]any{"name": "Checked out"}
+	return map[string]any{
+		"id":      uuid.NewString(),
+		"itemId":  it["id"],
+		"userId":  id("user", stringField(body, "userBarcode")),
+		"status":  map[string]any{"name": "Open"},
+		"dueDate": time.Now().Add(loanPeriod).UTC().Format(time.RFC3339),
+		"item":    it,
+	}
+}
+
+func handleCheckOut(w http.ResponseWriter, r *http.Request) {
+	body, ok := readBody(w, r)
+	if !ok || !checkFields(w, body, "itemBarcode", "userBarcode", "servicePointId") {
+		return
+	}
+	writeJSON(w, http.StatusCreated, loan(body))
+}
+
+func handleCheckIn(w http.ResponseWriter, r *http.Request) {
+	body, ok := readBody(w, r)
+	if !ok || !checkFields(w, body, "itemBarcode", "servicePointId") {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"item": item(stringField(body, "itemBarcode"))})
+}
+
+func handleRenew(w http.ResponseWriter, r *http.Request) {
+	body, ok := readBody(w, r)
+	if !ok || !checkFields(w, body, "itemBarcode", "userBarcode") {
+		return
+	}
+	writeJSON(w, http.StatusOK, loan(body))
+}
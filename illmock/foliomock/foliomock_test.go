@@ -0,0 +1,205 @@
+package foliomock
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var server *httptest.Server
+
+func TestMain(m *testing.M) {
+	server = httptest.NewServer(CreateFolioMock())
+	exitCode := m.Run()
+	server.Close()
+	os.Exit(exitCode)
+}
+
+func do(t *testing.T, method string, path string, body string, headers map[string]string) (int, map[string]any) {
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	assert.NoError(t, err)
+	req.Header.Set("X-Okapi-Tenant", "diku")
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	bytes, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	var result map[string]any
+	if res.Header.Get("Content-Type") == "application/json" {
+		assert.NoError(t, json.Unmarshal(bytes, &result))
+	}
+	return res.StatusCode, result
+}
+
+func TestMissingTenant(t *testing.T) {
+	res, err := http.Get(server.URL + "/users?query=" + url.QueryEscape(`barcode=="u1"`))
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestLogin(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/authn/login-with-expiry",
+		strings.NewReader(`{"username":"ill","password":"secret"}`))
+	assert.NoError(t, err)
+	req.Header.Set("X-Okapi-Tenant", "diku")
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Len(t, res.Cookies(), 1)
+	assert.Equal(t, token, res.Cookies()[0].Value)
+
+	status, body := do(t, http.MethodPost, "/authn/login-with-expiry", `{"username":"foo","password":"secret"}`, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Contains(t, body["errors"], map[string]any{"message": "Invalid credentials"})
+}
+
+func TestToken(t *testing.T) {
+	path := "/users?query=" + url.QueryEscape(`barcode=="u1"`)
+	status, _ := do(t, http.MethodGet, path, "", map[string]string{"X-Okapi-Token": token})
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = do(t, http.MethodGet, path, "", map[string]string{"X-Okapi-Token": "bad"})
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestUsers(t *testing.T) {
+	status, body := do(t, http.MethodGet, "/users?query="+url.QueryEscape(`barcode=="u1"`), "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, float64(1), body["totalRecords"])
+	user := body["users"].([]any)[0].(map[string]any)
+	assert.Equal(t, "u1", user["barcode"])
+	assert.Equal(t, id("user", "u1"), user["id"])
+
+	status, body = do(t, http.MethodGet, "/users?query="+url.QueryEscape(`barcode=="foo"`), "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, float64(0), body["totalRecords"])
+
+	status, _ = do(t, http.MethodGet, "/users?query=active==true", "", nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestInstances(t *testing.T) {
+	status, body := do(t, http.MethodGet, "/inventory/instances?query="+url.QueryEscape(`hrid=="in1"`), "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, id("instance", "in1"), body["instances"].([]any)[0].(map[string]any)["id"])
+
+	status, body = do(t, http.MethodPost, "/inventory/instances", `{"title":"A title"}`, nil)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "A title", body["title"])
+	assert.NotEmpty(t, body["id"])
+
+	status, _ = do(t, http.MethodDelete, "/inventory/instances/in1", "", nil)
+	assert.Equal(t, http.StatusNoContent, status)
+	status, _ = do(t, http.MethodDelete, "/inventory/instances/foo", "", nil)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestItems(t *testing.T) {
+	status, body := do(t, http.MethodPost, "/inventory/items", `{"id":"i1","barcode":"b1"}`, nil)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "i1", body["id"])
+	status, _ = do(t, http.MethodPost, "/inventory/items", `{"barcode":"foo"}`, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+
+	status, body = do(t, http.MethodGet, "/inventory/items?query="+url.QueryEscape(`barcode=="l1"`), "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]any{"name": "Checked out"}, body["items"].([]any)[0].(map[string]any)["status"])
+
+	status, body = do(t, http.MethodGet, "/inventory/items/i1", "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]any{"name": "Available"}, body["status"])
+	status, _ = do(t, http.MethodGet, "/inventory/items/foo", "", nil)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, body = do(t, http.MethodGet, "/inventory/items?query="+url.QueryEscape(`holdingsRecordId=="h1"`), "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "h1", body["items"].([]any)[0].(map[string]any)["holdingsRecordId"])
+	status, body = do(t, http.MethodGet, "/inventory/items?query="+url.QueryEscape(`holdingsRecordId=="foo"`), "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, body["items"])
+	status, _ = do(t, http.MethodGet, "/inventory/items?query="+url.QueryEscape(`title=="x"`), "", nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestHoldings(t *testing.T) {
+	status, body := do(t, http.MethodGet, "/holdings-storage/holdings?query="+url.QueryEscape(`instanceId=="in1"`), "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, id("holdings", "in1"), body["holdingsRecords"].([]any)[0].(map[string]any)["id"])
+	status, body = do(t, http.MethodGet, "/holdings-storage/holdings?query="+url.QueryEscape(`instanceId=="foo"`), "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, body["holdingsRecords"])
+	status, _ = do(t, http.MethodGet, "/holdings-storage/holdings", "", nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestRequests(t *testing.T) {
+	status, body := do(t, http.MethodPost, "/circulation/requests",
+		`{"id":"r1","requestType":"Page","requestLevel":"Title","requesterId":"u1","instanceId":"in1"}`, nil)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "r1", body["id"])
+	assert.Equal(t, "in1", body["item"].(map[string]any)["barcode"])
+	assert.Equal(t, "Mock title in1", body["instance"].(map[string]any)["title"])
+
+	status, body = do(t, http.MethodPost, "/circulation/requests", `{"requestType":"Page"}`, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Contains(t, body["errors"], map[string]any{"message": "requestLevel is required"})
+
+	status, _ = do(t, http.MethodPost, "/circulation/requests",
+		`{"requestType":"Page","requestLevel":"Title","requesterId":"u1","instanceId":"foo"}`, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+
+	status, body = do(t, http.MethodGet, "/circulation/requests/r1", "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "Open - Not yet filled", body["status"])
+
+	status, _ = do(t, http.MethodPut, "/circulation/requests/r1", `{"status":"Closed - Cancelled","cancellationReasonId":"c1"}`, nil)
+	assert.Equal(t, http.StatusNoContent, status)
+	status, _ = do(t, http.MethodPut, "/circulation/requests/r1", `{"status":"Closed - Cancelled"}`, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	status, _ = do(t, http.MethodPut, "/circulation/requests/foo", `{}`, nil)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestCirculation(t *testing.T) {
+	status, body := do(t, http.MethodPost, "/circulation/check-out-by-barcode",
+		`{"itemBarcode":"b1","userBarcode":"u1","servicePointId":"sp1"}`, nil)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.NotEmpty(t, body["dueDate"])
+	assert.Equal(t, "Mock title b1", body["item"].(map[string]any)["title"])
+
+	status, body = do(t, http.MethodPost, "/circulation/check-out-by-barcode",
+		`{"itemBarcode":"b1","userBarcode":"foo","servicePointId":"sp1"}`, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Contains(t, body["errors"], map[string]any{"message": "No record found for userBarcode foo"})
+
+	status, _ = do(t, http.MethodPost, "/circulation/check-in-by-barcode", `{"itemBarcode":"b1","servicePointId":"sp1"}`, nil)
+	assert.Equal(t, http.StatusOK, status)
+	status, body = do(t, http.MethodPost, "/circulation/check-in-by-barcode", `{"itemBarcode":"b1"}`, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Contains(t, body["errors"], map[string]any{"message": "servicePointId is required"})
+
+	status, body = do(t, http.MethodPost, "/circulation/renew-by-barcode", `{"itemBarcode":"b1","userBarcode":"u1"}`, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.NotEmpty(t, body["dueDate"])
+
+	status, _ = do(t, http.MethodPost, "/circulation/renew-by-barcode", `{not json`, nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestUnknown(t *testing.T) {
+	assert.True(t, unknown("foo"))
+	assert.False(t, unknown("bar"))
+	assert.False(t, unknown("f47ac10b-58cc-4372-a567-0e02b2c3d479"))
+}
@@ -0,0 +1,330 @@
+package sip2mock
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/indexdata/crosslink/illmock/slogwrap"
+)
+
+// renewalPeriod is the loan period granted by Checkout and Renew when no due date is given
+const renewalPeriod = 28 * 24 * time.Hour
+
+const dateLayout = "20060102   Z150405"
+
+var log *slog.Logger = slogwrap.SlogWrap()
+
+// Sip2Mock is a SIP2 server that answers Login, Patron Information, Checkout, Checkin,
+// Item Information, Renew and Hold messages.
+type Sip2Mock struct {
+	listener net.Listener
+	mu       sync.Mutex
+	conns    map[net.Conn]struct{}
+	wg       sync.WaitGroup
+}
+
+func CreateSip2Mock() *Sip2Mock {
+	return &Sip2Mock{conns: make(map[net.Conn]struct{})}
+}
+
+// Start listens on addr and serves connections in the background.
+func (m *Sip2Mock) Start(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	m.listener = listener
+	log.Info("Start SIP2 serve on " + listener.Addr().String())
+	m.wg.Add(1)
+	go m.serve()
+	return nil
+}
+
+// Addr returns the listening address or an empty string if not started.
+func (m *Sip2Mock) Addr() string {
+	if m.listener == nil {
+		return ""
+	}
+	return m.listener.Addr().String()
+}
+
+func (m *Sip2Mock) Shutdown() {
+	if m.listener == nil {
+		return
+	}
+	m.listener.Close()
+	m.mu.Lock()
+	for conn := range m.conns {
+		conn.Close()
+	}
+	m.mu.Unlock()
+	m.wg.Wait()
+}
+
+func (m *Sip2Mock) serve() {
+	defer m.wg.Done()
+	for {
+		conn, err := m.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Warn("SIP2 accept failed", "error", err)
+			}
+			return
+		}
+		m.mu.Lock()
+		m.conns[conn] = struct{}{}
+		m.mu.Unlock()
+		m.wg.Add(1)
+		go m.handleConn(conn)
+	}
+}
+
+func (m *Sip2Mock) handleConn(conn net.Conn) {
+	defer m.wg.Done()
+	defer func() {
+		m.mu.Lock()
+		delete(m.conns, conn)
+		m.mu.Unlock()
+		conn.Close()
+	}()
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\r')
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				log.Warn("SIP2 read failed", "error", err)
+			}
+			return
+		}
+		response := HandleMessage(strings.Trim(line, "\r\n"))
+		if _, err = conn.Write([]byte(response + "\r")); err != nil {
+			log.Warn("SIP2 write failed", "error", err)
+			return
+		}
+	}
+}
+
+type request struct {
+	code   string
+	fixed  string
+	fields map[string]string
+}
+
+func (r *request) field(id string) string {
+	return r.fields[id]
+}
+
+// fixedLength is the length of the fixed part of each supported request, excluding the command code
+var fixedLength = map[string]int{
+	"93": 2,
+	"63": 31,
+	"11": 38,
+	"09": 37,
+	"17": 18,
+	"29": 38,
+	"15": 19,
+}
+
+func parseRequest(msg string) (*request, error) {
+	if len(msg) < 2 {
+		return nil, fmt.Errorf("message too short")
+	}
+	code := msg[:2]
+	length, ok := fixedLength[code]
+	if !ok {
+		return nil, fmt.Errorf("unsupported message %s", code)
+	}
+	if len(msg) < 2+length {
+		return nil, fmt.Errorf("message %s too short", code)
+	}
+	req := &request{code: code, fixed: msg[2 : 2+length], fields: make(map[string]string)}
+	for _, f := range strings.Split(msg[2+length:], "|") {
+		if len(f) >= 2 {
+			req.fields[f[:2]] = f[2:]
+		}
+	}
+	return req, nil
+}
+
+// checksum returns the SIP2 checksum of msg, which must end with "AZ"
+func checksum(msg string) string {
+	var sum uint16
+	for i := 0; i < len(msg); i++ {
+		sum += uint16(msg[i])
+	}
+	return fmt.Sprintf("%04X", -sum)
+}
+
+// HandleMessage returns the response for a single SIP2 message without terminator.
+// If the message has a bad checksum or is not recognized, a Request SC Resend (96) is returned.
+func HandleMessage(msg string) string {
+	seq := ""
+	if n := len(msg); n >= 11 && msg[n-9:n-7] == "AY" && msg[n-6:n-4] == "AZ" {
+		if checksum(msg[:n-4]) != msg[n-4:] {
+			return "96"
+		}
+		seq = msg[n-7 : n-6]
+		msg = msg[:n-9]
+	}
+	req, err := parseRequest(msg)
+	if err != nil {
+		log.Warn("SIP2 invalid message", "error", err)
+		return "96"
+	}
+	var res string
+	switch req.code {
+	case "93":
+		res = handleLogin(req)
+	case "63":
+		res = handlePatronInformation(req)
+	case "11":
+		res = handleCheckout(req)
+	case "09":
+		res = handleCheckin(req)
+	case "17":
+		res = handleItemInformation(req)
+	case "29":
+		res = handleRenew(req)
+	case "15":
+		res = handleHold(req)
+	}
+	if seq != "" {
+		res += "AY" + seq + "AZ"
+		res += checksum(res)
+	}
+	return res
+}
+
+func now() string {
+	return time.Now().UTC().Format(dateLayout)
+}
+
+func dueDate(nbDueDate string) string {
+	if strings.TrimSpace(nbDueDate) != "" {
+		return nbDueDate
+	}
+	return time.Now().UTC().Add(renewalPeriod).Format(dateLayout)
+}
+
+func flag(ok bool) string {
+	if ok {
+		return "1"
+	}
+	return "0"
+}
+
+func yesNo(ok bool) string {
+	if ok {
+		return "Y"
+	}
+	return "N"
+}
+
+// checkPatronItem returns a screen message if the patron or item is unknown
+func checkPatronItem(req *request, needPatron bool) string {
+	patron := req.field("AA")
+	item := req.field("AB")
+	if needPatron && patron == "" {
+		return "Patron identifier is required"
+	}
+	if item == "" {
+		return "Item identifier is required"
+	}
+	if strings.HasPrefix(patron, "f") {
+		return "Unknown patron " + patron
+	}
+	if strings.HasPrefix(item, "f") {
+		return "Unknown item " + item
+	}
+	return ""
+}
+
+func screenMessage(msg string) string {
+	if msg == "" {
+		return ""
+	}
+	return "AF" + msg + "|"
+}
+
+func handleLogin(req *request) string {
+	ok := req.field("CN") != "" && !strings.HasPrefix(req.field("CN"), "f")
+	return "94" + flag(ok)
+}
+
+func handlePatronInformation(req *request) string {
+	patron := req.field("AA")
+	valid := patron != "" && !strings.HasPrefix(patron, "f")
+	res := "64" + strings.Repeat(" ", 14) + "001" + now() + strings.Repeat("0000", 6) +
+		"AO" + req.field("AO") + "|AA" + patron + "|"
+	if valid {
+		res += "AEMock patron " + patron + "|"
+	}
+	res += "BL" + yesNo(valid) + "|"
+	if !valid {
+		res += screenMessage("Unknown patron " + patron)
+	}
+	return res
+}
+
+func handleCheckout(req *request) string {
+	problem := checkPatronItem(req, true)
+	res := "12" + flag(problem == "") + "NUN" + now() +
+		"AO" + req.field("AO") + "|AA" + req.field("AA") + "|AB" + req.field("AB") + "|AJ|"
+	if problem == "" {
+		res += "AH" + dueDate(req.fixed[20:38]) + "|"
+	}
+	return res + screenMessage(problem)
+}
+
+func handleCheckin(req *request) string {
+	problem := checkPatronItem(req, false)
+	res := "10" + flag(problem == "") + "NUN" + now() +
+		"AO" + req.field("AO") + "|AB" + req.field("AB") + "|AQ" + req.field("AP") + "|"
+	return res + screenMessage(problem)
+}
+
+func handleItemInformation(req *request) string {
+	problem := checkPatronItem(req, false)
+	item := req.field("AB")
+	status := "03" // available
+	if problem != "" {
+		status = "01" // other
+	} else if strings.HasPrefix(item, "l") {
+		status = "04" // charged
+	}
+	res := "18" + status + "0001" + now() + "AB" + item + "|AJ|"
+	if status == "04" {
+		res += "AH" + dueDate("") + "|"
+	}
+	return res + screenMessage(problem)
+}
+
+func handleRenew(req *request) string {
+	problem := checkPatronItem(req, true)
+	res := "30" + flag(problem == "") + yesNo(problem == "") + "UN" + now() +
+		"AO" + req.field("AO") + "|AA" + req.field("AA") + "|AB" + req.field("AB") + "|AJ|"
+	if problem == "" {
+		res += "AH" + dueDate(req.fixed[20:38]) + "|"
+	}
+	return res + screenMessage(problem)
+}
+
+func handleHold(req *request) string {
+	problem := checkPatronItem(req, true)
+	res := "16" + flag(problem == "") + yesNo(problem == "") + now()
+	if problem == "" && req.fixed[0] == '+' {
+		res += "BR1|"
+		if req.field("BS") != "" {
+			res += "BS" + req.field("BS") + "|"
+		}
+	}
+	res += "AO" + req.field("AO") + "|AA" + req.field("AA") + "|AB" + req.field("AB") + "|"
+	return res + screenMessage(problem)
+}
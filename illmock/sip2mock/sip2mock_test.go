@@ -0,0 +1,140 @@
+package sip2mock
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testDate = "20260101    120000"
+
+func TestLogin(t *testing.T) {
+	assert.Equal(t, "941", HandleMessage("9300CNuser|COpass|CPMAIN|"))
+	assert.Equal(t, "940", HandleMessage("9300CNfoo|COpass|"))
+	assert.Equal(t, "940", HandleMessage("9300COpass|"))
+}
+
+func TestPatronInformation(t *testing.T) {
+	res := HandleMessage("63001" + testDate + "          AOINST|AApatron1|ACterm|")
+	assert.True(t, strings.HasPrefix(res, "64"))
+	assert.Contains(t, res, "|AApatron1|")
+	assert.Contains(t, res, "|BLY|")
+
+	res = HandleMessage("63001" + testDate + "          AOINST|AAfoo|")
+	assert.Contains(t, res, "|BLN|")
+	assert.Contains(t, res, "AFUnknown patron foo|")
+}
+
+func TestCheckout(t *testing.T) {
+	res := HandleMessage("11NN" + testDate + "20260201    120000AOINST|AApatron1|ABitem1|")
+	assert.True(t, strings.HasPrefix(res, "121"))
+	assert.Contains(t, res, "|AB"+"item1|")
+	assert.Contains(t, res, "|AH20260201    120000|")
+
+	res = HandleMessage("11NN" + testDate + strings.Repeat(" ", 18) + "AOINST|AApatron1|ABitem1|")
+	assert.True(t, strings.HasPrefix(res, "121"))
+	assert.Contains(t, res, "|AH")
+
+	res = HandleMessage("11NN" + testDate + strings.Repeat(" ", 18) + "AOINST|AApatron1|ABfoo|")
+	assert.True(t, strings.HasPrefix(res, "120"))
+	assert.Contains(t, res, "AFUnknown item foo|")
+
+	res = HandleMessage("11NN" + testDate + strings.Repeat(" ", 18) + "AOINST|AAfoo|ABitem1|")
+	assert.True(t, strings.HasPrefix(res, "120"))
+	assert.Contains(t, res, "AFUnknown patron foo|")
+
+	res = HandleMessage("11NN" + testDate + strings.Repeat(" ", 18) + "AOINST|ABitem1|")
+	assert.True(t, strings.HasPrefix(res, "120"))
+	assert.Contains(t, res, "AFPatron identifier is required|")
+}
+
+func TestCheckin(t *testing.T) {
+	res := HandleMessage("09N" + testDate + testDate + "APMAIN|AOINST|ABitem1|")
+	assert.True(t, strings.HasPrefix(res, "101"))
+	assert.Contains(t, res, "|AQMAIN|")
+
+	res = HandleMessage("09N" + testDate + testDate + "APMAIN|AOINST|ABfoo|")
+	assert.True(t, strings.HasPrefix(res, "100"))
+
+	res = HandleMessage("09N" + testDate + testDate + "APMAIN|AOINST|")
+	assert.True(t, strings.HasPrefix(res, "100"))
+	assert.Contains(t, res, "AFItem identifier is required|")
+}
+
+func TestItemInformation(t *testing.T) {
+	res := HandleMessage("17" + testDate + "AOINST|ABitem1|")
+	assert.True(t, strings.HasPrefix(res, "1803"))
+
+	res = HandleMessage("17" + testDate + "AOINST|ABl1|")
+	assert.True(t, strings.HasPrefix(res, "1804"))
+	assert.Contains(t, res, "|AH")
+
+	res = HandleMessage("17" + testDate + "AOINST|ABfoo|")
+	assert.True(t, strings.HasPrefix(res, "1801"))
+	assert.Contains(t, res, "AFUnknown item foo|")
+}
+
+func TestRenew(t *testing.T) {
+	res := HandleMessage("29NN" + testDate + "20260301    120000AOINST|AApatron1|ABitem1|")
+	assert.True(t, strings.HasPrefix(res, "301Y"))
+	assert.Contains(t, res, "|AH20260301    120000|")
+
+	res = HandleMessage("29NN" + testDate + strings.Repeat(" ", 18) + "AOINST|AApatron1|ABfoo|")
+	assert.True(t, strings.HasPrefix(res, "300N"))
+}
+
+func TestHold(t *testing.T) {
+	res := HandleMessage("15+" + testDate + "BSMain Library|AOINST|AApatron1|ABitem1|")
+	assert.True(t, strings.HasPrefix(res, "161Y"))
+	assert.Contains(t, res, "|BSMain Library|")
+	assert.Contains(t, res, "|ABitem1|")
+
+	res = HandleMessage("15-" + testDate + "AOINST|AApatron1|ABitem1|")
+	assert.True(t, strings.HasPrefix(res, "161Y"))
+	assert.NotContains(t, res, "BR1")
+
+	res = HandleMessage("15+" + testDate + "AOINST|AAfoo|ABitem1|")
+	assert.True(t, strings.HasPrefix(res, "160N"))
+}
+
+func TestInvalidMessages(t *testing.T) {
+	assert.Equal(t, "96", HandleMessage(""))
+	assert.Equal(t, "96", HandleMessage("99"))
+	assert.Equal(t, "96", HandleMessage("17short"))
+}
+
+func TestChecksum(t *testing.T) {
+	msg := "9300CNuser|COpass|AY1AZ"
+	res := HandleMessage(msg + checksum(msg))
+	assert.True(t, strings.HasPrefix(res, "941AY1AZ"))
+	assert.Equal(t, checksum(res[:len(res)-4]), res[len(res)-4:])
+
+	assert.Equal(t, "96", HandleMessage(msg+"0000"))
+}
+
+func TestServer(t *testing.T) {
+	mock := CreateSip2Mock()
+	assert.Equal(t, "", mock.Addr())
+	assert.NoError(t, mock.Start("localhost:0"))
+	defer mock.Shutdown()
+
+	conn, err := net.Dial("tcp", mock.Addr())
+	assert.NoError(t, err)
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	_, err = conn.Write([]byte("9300CNuser|COpass|\r"))
+	assert.NoError(t, err)
+	res, err := reader.ReadString('\r')
+	assert.NoError(t, err)
+	assert.Equal(t, "941\r", res)
+
+	_, err = conn.Write([]byte("17" + testDate + "AOINST|ABitem1|\r\n"))
+	assert.NoError(t, err)
+	res, err = reader.ReadString('\r')
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(res, "1803"))
+}
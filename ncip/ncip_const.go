@@ -13,3 +13,21 @@ const (
 	UnknownUser               ProblemTypeMessage = "Unknown User"
 	UnknownItem               ProblemTypeMessage = "Unknown Item"
 )
+
+type CirculationStatusValue string
+
+// Circulation Status values from the NCIP 2.02 implementation profile
+const (
+	CirculationStatusAvailableOnShelf     CirculationStatusValue = "Available On Shelf"
+	CirculationStatusAvailableForPickup   CirculationStatusValue = "Available For Pickup"
+	CirculationStatusUndefined            CirculationStatusValue = "Circulation Status Undefined"
+	CirculationStatusInProcess            CirculationStatusValue = "In Process"
+	CirculationStatusInTransit            CirculationStatusValue = "In Transit Between Library Locations"
+	CirculationStatusLost                 CirculationStatusValue = "Lost"
+	CirculationStatusMissing              CirculationStatusValue = "Missing"
+	CirculationStatusNotAvailable         CirculationStatusValue = "Not Available"
+	CirculationStatusOnLoan               CirculationStatusValue = "On Loan"
+	CirculationStatusOnOrder              CirculationStatusValue = "On Order"
+	CirculationStatusRecalled             CirculationStatusValue = "Recalled"
+	CirculationStatusWaitingToBeReshelved CirculationStatusValue = "Waiting To Be Reshelved"
+)